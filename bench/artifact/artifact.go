@@ -0,0 +1,111 @@
+// Package artifact uploads result bundles to an artifact store (s3:// for
+// S3/MinIO, file:// for a local or mounted directory), so CI runners don't
+// need a shared filesystem to archive evidence.
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+)
+
+// Object is one stored artifact.
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Store is an artifact backend addressed by "<scheme>://<bucket>/<prefix>".
+type Store interface {
+	// Put stores body under key and returns the object URL.
+	Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) (string, error)
+	List(ctx context.Context, prefix string) ([]Object, error)
+	Delete(ctx context.Context, key string) error
+	// URL returns the URL Put would return for key.
+	URL(key string) string
+}
+
+// Open parses a store URI and returns the store and the key prefix within it,
+// e.g. s3://bench-results/2025-01-01/abc123/ → (S3 bucket, "2025-01-01/abc123/").
+// S3 credentials come from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+// AWS_SESSION_TOKEN, AWS_REGION and (MinIO) AWS_ENDPOINT_URL.
+func Open(uri string) (Store, string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid artifact store: %s", uri)
+	}
+	prefix := strings.TrimPrefix(u.Path, "/")
+	switch u.Scheme {
+	case "s3":
+		if u.Host == "" {
+			return nil, "", fmt.Errorf("invalid artifact store: %s (missing bucket)", uri)
+		}
+		s, err := newS3FromEnv(u.Host)
+		return s, prefix, err
+	case "file":
+		// file:///abs/dir 또는 file://rel/dir
+		return fileStore{root: u.Host + u.Path}, "", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported artifact store scheme: %s", u.Scheme)
+	}
+}
+
+// Vars are the placeholders expanded in a store URI.
+type Vars struct {
+	Date  string // {date}: YYYY-MM-DD (UTC)
+	SHA   string // {sha}: 대상 커밋
+	RunID string // {run_id}
+}
+
+// DefaultVars fills Date with today and SHA from GITHUB_SHA, CI_COMMIT_SHA
+// or `git rev-parse --short HEAD` ("unknown" if none).
+func DefaultVars(runID string) Vars {
+	v := Vars{Date: time.Now().UTC().Format("2006-01-02"), RunID: runID, SHA: "unknown"}
+	for _, env := range []string{"GITHUB_SHA", "CI_COMMIT_SHA", "GIT_COMMIT"} {
+		if s := os.Getenv(env); s != "" {
+			v.SHA = s
+			return v
+		}
+	}
+	if out, err := exec.Command("git", "rev-parse", "--short", "HEAD").Output(); err == nil {
+		v.SHA = strings.TrimSpace(string(out))
+	}
+	return v
+}
+
+// Expand substitutes {date}, {sha} and {run_id} in uri.
+func Expand(uri string, v Vars) string {
+	return strings.NewReplacer("{date}", v.Date, "{sha}", v.SHA, "{run_id}", v.RunID).Replace(uri)
+}
+
+// File is one local file of a bundle.
+type File struct {
+	Path        string
+	ContentType string
+}
+
+// UploadBundle uploads files under prefix (keeping base names) and returns
+// the object URLs in order.
+func UploadBundle(ctx context.Context, s Store, prefix string, files []File) ([]string, error) {
+	var urls []string
+	for _, f := range files {
+		fh, err := os.Open(f.Path)
+		if err != nil {
+			return urls, err
+		}
+		u, err := s.Put(ctx, path.Join(prefix, path.Base(f.Path)), fh, f.ContentType)
+		fh.Close()
+		if err != nil {
+			return urls, fmt.Errorf("upload %s: %w", f.Path, err)
+		}
+		urls = append(urls, u)
+	}
+	return urls, nil
+}
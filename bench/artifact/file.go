@@ -0,0 +1,69 @@
+package artifact
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileStore는 디렉터리를 객체 저장소처럼 사용(키 = 상대 경로)
+type fileStore struct{ root string }
+
+func (f fileStore) URL(key string) string { return "file://" + filepath.Join(f.root, key) }
+
+func (f fileStore) Put(_ context.Context, key string, body io.ReadSeeker, _ string) (string, error) {
+	p := filepath.Join(f.root, key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return "", err
+	}
+	// 같은 key를 동시에 올려도 서로의 임시 파일을 덮지 않게
+	out, err := os.CreateTemp(filepath.Dir(p), filepath.Base(p)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	tmp := out.Name()
+	if _, err := io.Copy(out, body); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return f.URL(key), os.Rename(tmp, p)
+}
+
+func (f fileStore) List(_ context.Context, prefix string) ([]Object, error) {
+	var out []Object
+	err := filepath.WalkDir(f.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == f.root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		key, _ := filepath.Rel(f.root, p)
+		key = filepath.ToSlash(key)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		out = append(out, Object{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	return out, err
+}
+
+func (f fileStore) Delete(_ context.Context, key string) error {
+	return os.Remove(filepath.Join(f.root, key))
+}
@@ -0,0 +1,272 @@
+package artifact
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/internal/secretref"
+)
+
+// s3Store는 SigV4 서명을 직접 구현한 최소 S3 클라이언트(PUT/LIST/DELETE).
+// AWS_ENDPOINT_URL이 있으면 MinIO 등 path-style 엔드포인트를 사용한다.
+type s3Store struct {
+	bucket       string
+	region       string
+	endpoint     *url.URL
+	pathStyle    bool
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	client       *http.Client
+}
+
+func newS3FromEnv(bucket string) (*s3Store, error) {
+	s := &s3Store{
+		bucket: bucket,
+		region: os.Getenv("AWS_REGION"),
+		client: &http.Client{Timeout: 5 * time.Minute},
+	}
+	// 자격증명 값은 secretref://로 간접 지정할 수 있다
+	for env, dst := range map[string]*string{
+		"AWS_ACCESS_KEY_ID":     &s.accessKey,
+		"AWS_SECRET_ACCESS_KEY": &s.secretKey,
+		"AWS_SESSION_TOKEN":     &s.sessionToken,
+	} {
+		v, err := secretref.Env(env)
+		if err != nil {
+			return nil, err
+		}
+		*dst = v
+	}
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, fmt.Errorf("s3 artifact store requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+	ep := os.Getenv("AWS_ENDPOINT_URL")
+	if ep == "" {
+		ep = "https://" + bucket + ".s3." + s.region + ".amazonaws.com"
+	} else {
+		s.pathStyle = true
+	}
+	u, err := url.Parse(strings.TrimRight(ep, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid AWS_ENDPOINT_URL: %s", ep)
+	}
+	s.endpoint = u
+	return s, nil
+}
+
+// objectPath는 path-style이면 /bucket/key, 아니면 /key
+func (s *s3Store) objectPath(key string) string {
+	p := "/" + s3Escape(key, true)
+	if s.pathStyle {
+		p = "/" + s.bucket + p
+	}
+	return p
+}
+
+func (s *s3Store) URL(key string) string {
+	return s.endpoint.Scheme + "://" + s.endpoint.Host + s.objectPath(key)
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) (string, error) {
+	h := sha256.New()
+	n, err := io.Copy(h, body)
+	if err != nil {
+		return "", err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.URL(key), body)
+	if err != nil {
+		return "", err
+	}
+	// *os.File 등은 길이를 모르면 chunked로 보내지고, PutObject는 이를 411/501로 거부한다
+	req.ContentLength = n
+	if n == 0 {
+		req.Body = http.NoBody // 길이 0 + 본문은 "모름"으로 취급된다
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, hex.EncodeToString(h.Sum(nil)), time.Now())
+	if _, err := s.do(req); err != nil {
+		return "", err
+	}
+	return s.URL(key), nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.URL(key), nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, emptySHA256, time.Now())
+	_, err = s.do(req)
+	return err
+}
+
+type listResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *s3Store) List(ctx context.Context, prefix string) ([]Object, error) {
+	var out []Object
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		p := "/"
+		if s.pathStyle {
+			p = "/" + s.bucket
+		}
+		u := s.endpoint.Scheme + "://" + s.endpoint.Host + p + "?" + canonicalQuery(q)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		s.sign(req, emptySHA256, time.Now())
+		body, err := s.do(req)
+		if err != nil {
+			return nil, err
+		}
+		var lr listResult
+		if err := xml.Unmarshal(body, &lr); err != nil {
+			return nil, fmt.Errorf("s3 list: %w", err)
+		}
+		for _, c := range lr.Contents {
+			out = append(out, Object{Key: c.Key, Size: c.Size, LastModified: c.LastModified})
+		}
+		if !lr.IsTruncated || lr.NextContinuationToken == "" {
+			return out, nil
+		}
+		token = lr.NextContinuationToken
+	}
+}
+
+func (s *s3Store) do(req *http.Request) ([]byte, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("s3: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("s3: %s %s -> %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body[:min(len(body), 512)])))
+	}
+	return body, nil
+}
+
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// sign은 AWS Signature Version 4 헤더 서명
+func (s *s3Store) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if lk == "content-type" || strings.HasPrefix(lk, "x-amz-") {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	canonReq := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonHeaders.String(),
+		signed,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	sum := sha256.Sum256([]byte(canonReq))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	for _, part := range []string{s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.accessKey, scope, signed, sig))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// SigV4 정규 쿼리: 키 정렬, RFC 3986 인코딩(공백은 %20)
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vs := append([]string(nil), q[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, s3Escape(k, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape는 unreserved 문자(경로면 '/'도)만 남기고 퍼센트 인코딩
+func s3Escape(s string, path bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' || path && c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,132 @@
+package artifact
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+)
+
+// verifySigV4 recomputes the signature of r from the spec (independently of
+// s3Store.sign) and reports what does not match.
+func verifySigV4(t *testing.T, r *http.Request, body []byte) {
+	t.Helper()
+	sum := sha256.Sum256(body)
+	if got, want := r.Header.Get("X-Amz-Content-Sha256"), hex.EncodeToString(sum[:]); got != want {
+		t.Errorf("x-amz-content-sha256 = %s, want %s", got, want)
+	}
+	auth := r.Header.Get("Authorization")
+	const prefix = "AWS4-HMAC-SHA256 Credential="
+	if !strings.HasPrefix(auth, prefix) {
+		t.Fatalf("authorization = %q", auth)
+	}
+	fields := map[string]string{}
+	for _, f := range strings.Split(strings.TrimPrefix(auth, "AWS4-HMAC-SHA256 "), ", ") {
+		k, v, _ := strings.Cut(f, "=")
+		fields[k] = v
+	}
+	cred := strings.Split(fields["Credential"], "/")
+	if len(cred) != 5 || cred[0] != testAccessKey || cred[2] != "us-east-1" || cred[3] != "s3" || cred[4] != "aws4_request" {
+		t.Fatalf("credential = %q", fields["Credential"])
+	}
+	signed := strings.Split(fields["SignedHeaders"], ";")
+	if !sort.StringsAreSorted(signed) {
+		t.Errorf("signed headers not sorted: %v", signed)
+	}
+	for _, want := range []string{"host", "x-amz-content-sha256", "x-amz-date"} {
+		if !contains(signed, want) {
+			t.Errorf("signed headers %v lack %s", signed, want)
+		}
+	}
+	var canon strings.Builder
+	for _, h := range signed {
+		v := r.Header.Get(h)
+		if h == "host" {
+			v = r.Host
+		}
+		canon.WriteString(h + ":" + strings.TrimSpace(v) + "\n")
+	}
+	creq := strings.Join([]string{r.Method, r.URL.EscapedPath(), r.URL.RawQuery, canon.String(),
+		fields["SignedHeaders"], r.Header.Get("X-Amz-Content-Sha256")}, "\n")
+	csum := sha256.Sum256([]byte(creq))
+	scope := strings.Join(cred[1:], "/")
+	toSign := "AWS4-HMAC-SHA256\n" + r.Header.Get("X-Amz-Date") + "\n" + scope + "\n" + hex.EncodeToString(csum[:])
+	mac := func(key []byte, s string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(s))
+		return h.Sum(nil)
+	}
+	key := mac([]byte("AWS4"+testSecretKey), cred[1])
+	for _, p := range cred[2:] {
+		key = mac(key, p)
+	}
+	if want := hex.EncodeToString(mac(key, toSign)); fields["Signature"] != want {
+		t.Errorf("signature = %s, want %s\ncanonical request:\n%s", fields["Signature"], want, creq)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestS3PutFile(t *testing.T) {
+	for _, content := range []string{"p95_ms,1.5\n", ""} {
+		var seen *http.Request
+		var got []byte
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = r
+			got, _ = io.ReadAll(r.Body)
+			if r.ContentLength < 0 || len(r.TransferEncoding) > 0 {
+				// S3/MinIO: 411 Length Required
+				http.Error(w, "MissingContentLength", http.StatusLengthRequired)
+				return
+			}
+		}))
+		t.Setenv("AWS_ENDPOINT_URL", srv.URL)
+		t.Setenv("AWS_ACCESS_KEY_ID", testAccessKey)
+		t.Setenv("AWS_SECRET_ACCESS_KEY", testSecretKey)
+		t.Setenv("AWS_SESSION_TOKEN", "")
+		t.Setenv("AWS_REGION", "")
+		s, err := newS3FromEnv("bench-artifacts")
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "heat map.csv")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		urls, err := UploadBundle(context.Background(), s, "2026-01-02/run 1", []File{{Path: path, ContentType: "text/csv"}})
+		srv.Close()
+		if err != nil {
+			t.Fatalf("%q: %v", content, err)
+		}
+		if want := srv.URL + "/bench-artifacts/2026-01-02/run%201/heat%20map.csv"; len(urls) != 1 || urls[0] != want {
+			t.Errorf("urls = %v, want [%s]", urls, want)
+		}
+		if seen.ContentLength != int64(len(content)) || string(got) != content {
+			t.Errorf("content-length %d, body %q; want %d, %q", seen.ContentLength, got, len(content), content)
+		}
+		if seen.Header.Get("Content-Type") != "text/csv" {
+			t.Errorf("content-type = %q", seen.Header.Get("Content-Type"))
+		}
+		verifySigV4(t, seen, got)
+	}
+}
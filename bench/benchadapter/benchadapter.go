@@ -0,0 +1,102 @@
+// Package benchadapter lets Go benchmark tests report in the trace_bench
+// JSON ABI, so micro benchmarks land in the same history as trace_bench runs.
+//
+//	func BenchmarkEncode(b *testing.B) {
+//		benchadapter.RunFromTesting(b, benchadapter.Config{
+//			Config: engine.Config{Sampling: 1, Serialization: "json", Compression: "none"},
+//			Op:     func() (int, error) { return encodeOnce() },
+//		})
+//	}
+package benchadapter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/stats"
+)
+
+// OutDirEnv names the directory results are written to when Config.OutPath
+// is empty. If neither is set, results are only reported to testing.B.
+const OutDirEnv = "TRACE_BENCH_OUT_DIR"
+
+// Config describes one adapted benchmark.
+type Config struct {
+	// Config labels the measured pipeline configuration; it is validated
+	// like a trace_bench run.
+	engine.Config
+
+	// Op performs one operation and returns the bytes it produced.
+	// A non-nil error counts toward error_rate.
+	Op func() (int, error)
+
+	// OutPath is the result file; defaults to $TRACE_BENCH_OUT_DIR/<name>.json.
+	OutPath string
+}
+
+// RunFromTesting runs cfg.Op b.N times, reports p95-ms, errors/op and KB/op
+// to b, and writes the trace_bench result JSON.
+func RunFromTesting(b *testing.B, cfg Config) engine.Result {
+	b.Helper()
+	if cfg.Op == nil {
+		b.Fatal("benchadapter: Config.Op is nil")
+	}
+	if err := cfg.Validate(); err != nil {
+		b.Fatalf("benchadapter: %v", err)
+	}
+
+	lat := make([]float64, 0, b.N)
+	var errs, bytes int
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t0 := time.Now()
+		n, err := cfg.Op()
+		lat = append(lat, float64(time.Since(t0))/float64(time.Millisecond))
+		if err != nil {
+			errs++
+		}
+		bytes += n
+	}
+	b.StopTimer()
+
+	sort.Float64s(lat)
+	r := engine.Result{
+		P95ms:     stats.Round5(stats.Percentile(lat, 0.95)),
+		ErrorRate: stats.Round5(float64(errs) / float64(b.N)),
+		SizeKB:    stats.Round2(float64(bytes) / float64(b.N) / 1024),
+		Target:    cfg.Target,
+	}
+	b.ReportMetric(r.P95ms, "p95-ms")
+	b.ReportMetric(r.ErrorRate, "errors/op")
+	b.ReportMetric(r.SizeKB, "KB/op")
+
+	path := cfg.OutPath
+	if path == "" {
+		if dir := os.Getenv(OutDirEnv); dir != "" {
+			path = filepath.Join(dir, resultName(b.Name())+".json")
+		}
+	}
+	if path != "" {
+		// b.N 증가에 따라 여러 번 호출되며, 마지막(가장 긴) 실행 결과가 남는다
+		if err := output.WriteJSONFile(path, r); err != nil {
+			b.Fatalf("benchadapter: %v", err)
+		}
+	}
+	return r
+}
+
+func resultName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ' ', ':':
+			return '_'
+		}
+		return r
+	}, name)
+}
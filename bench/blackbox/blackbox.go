@@ -0,0 +1,267 @@
+// Package blackbox probes targets from the outside (ICMP, TCP, HTTP, DNS)
+// and reports each probe in the trace_bench JSON ABI, so availability
+// probes and benchmarks share one reporting path.
+//
+// Schema (version 1):
+//
+//	version: 1
+//	defaults:
+//	  timeout: 5s
+//	  count: 3                  # attempts per probe
+//	probes:
+//	  - name: grafana
+//	    prober: http            # icmp | tcp | http | dns
+//	    target: http://localhost:3000/api/health
+//	    valid_status: [200]     # http: default any 2xx
+//	    body_contains: ok       # http: optional
+//	    max_latency: 500ms      # optional gate on p95
+//	  - name: prometheus-port
+//	    prober: tcp
+//	    target: localhost:9090
+//	  - name: resolver
+//	    prober: dns
+//	    target: duri.local      # name to resolve
+//	    server: 127.0.0.1:53    # optional; default system resolver
+//	    query_type: A           # A | AAAA | CNAME | MX | TXT
+//	  - name: gateway
+//	    prober: icmp            # needs CAP_NET_RAW
+//	    target: 192.168.0.1
+package blackbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/internal/yamlite"
+	"github.com/duri/trace_bench/pkg/resultenv"
+	"github.com/duri/trace_bench/stats"
+)
+
+// Version is the supported schema version.
+const Version = 1
+
+// Probers.
+const (
+	ICMP = "icmp"
+	TCP  = "tcp"
+	HTTP = "http"
+	DNS  = "dns"
+)
+
+// Defaults applied to probes that leave the fields empty.
+const (
+	DefaultTimeout = 5 * time.Second
+	DefaultCount   = 3
+)
+
+// File is a probe list.
+type File struct {
+	Version  int `json:"version"`
+	Defaults struct {
+		Timeout string `json:"timeout"`
+		Count   int    `json:"count"`
+	} `json:"defaults"`
+	Probes []Probe `json:"probes"`
+}
+
+// Probe is one target to check.
+type Probe struct {
+	Name       string `json:"name"`
+	Prober     string `json:"prober"`
+	Target     string `json:"target"`
+	Timeout    string `json:"timeout"`
+	Count      int    `json:"count"`
+	MaxLatency string `json:"max_latency"`
+
+	// http
+	Method       string `json:"method"`
+	ValidStatus  []int  `json:"valid_status"`
+	BodyContains string `json:"body_contains"`
+	// dns
+	Server    string `json:"server"`
+	QueryType string `json:"query_type"`
+
+	timeout, maxLatency time.Duration
+}
+
+// Load reads and validates a probe list.
+func Load(path string) (*File, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := yamlite.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Validate checks the probes and fills in defaults.
+func (f *File) Validate() error {
+	if f.Version != Version {
+		return fmt.Errorf("unsupported version %d (want %d)", f.Version, Version)
+	}
+	defTimeout := DefaultTimeout
+	if f.Defaults.Timeout != "" {
+		d, err := time.ParseDuration(f.Defaults.Timeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("defaults.timeout: invalid duration %q", f.Defaults.Timeout)
+		}
+		defTimeout = d
+	}
+	defCount := DefaultCount
+	if f.Defaults.Count > 0 {
+		defCount = f.Defaults.Count
+	}
+	seen := map[string]bool{}
+	for i := range f.Probes {
+		p := &f.Probes[i]
+		if p.Name == "" {
+			return fmt.Errorf("probes[%d]: missing name", i)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("probe %s: duplicate name", p.Name)
+		}
+		seen[p.Name] = true
+		if p.Target == "" {
+			return fmt.Errorf("probe %s: missing target", p.Name)
+		}
+		switch p.Prober {
+		case ICMP, TCP, HTTP:
+		case DNS:
+			if p.QueryType == "" {
+				p.QueryType = "A"
+			}
+			if _, ok := dnsLookups[p.QueryType]; !ok {
+				return fmt.Errorf("probe %s: unsupported query_type %q", p.Name, p.QueryType)
+			}
+		default:
+			return fmt.Errorf("probe %s: unknown prober %q (want icmp, tcp, http or dns)", p.Name, p.Prober)
+		}
+		p.timeout = defTimeout
+		if p.Timeout != "" {
+			d, err := time.ParseDuration(p.Timeout)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("probe %s: invalid timeout %q", p.Name, p.Timeout)
+			}
+			p.timeout = d
+		}
+		if p.MaxLatency != "" {
+			d, err := time.ParseDuration(p.MaxLatency)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("probe %s: invalid max_latency %q", p.Name, p.MaxLatency)
+			}
+			p.maxLatency = d
+		}
+		if p.Count <= 0 {
+			p.Count = defCount
+		}
+	}
+	return nil
+}
+
+// Report is the outcome of a probe's attempts.
+type Report struct {
+	Name      string  `json:"name"`
+	Prober    string  `json:"prober"`
+	Target    string  `json:"target"`
+	Attempts  int     `json:"attempts"`
+	Failures  int     `json:"failures"`
+	P50ms     float64 `json:"p50_ms"`
+	P95ms     float64 `json:"p95_ms"`
+	Bytes     int     `json:"bytes,omitempty"`
+	LastError string  `json:"last_error,omitempty"`
+	// OK is false when any attempt failed or p95 exceeded max_latency.
+	OK bool `json:"ok"`
+	// Verdict is fail_slo when the probe failed and fail_infra when the
+	// budget ran out before any attempt (pkg/resultenv).
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+// attempt는 한 번의 probe 결과(n은 응답 바이트 수, 없으면 0)
+type attempt func(ctx context.Context, p *Probe) (n int, err error)
+
+var probers = map[string]attempt{
+	ICMP: probeICMP,
+	TCP:  probeTCP,
+	HTTP: probeHTTP,
+	DNS:  probeDNS,
+}
+
+// Run executes the probe's attempts sequentially.
+func Run(ctx context.Context, p Probe) Report {
+	r := Report{Name: p.Name, Prober: p.Prober, Target: p.Target}
+	var lat []float64
+	for i := 0; i < p.Count && ctx.Err() == nil; i++ {
+		actx, cancel := context.WithTimeout(ctx, p.timeout)
+		t0 := time.Now()
+		n, err := probers[p.Prober](actx, &p)
+		ms := float64(time.Since(t0)) / float64(time.Millisecond)
+		cancel()
+		r.Attempts++
+		if err != nil {
+			r.Failures++
+			r.LastError = err.Error()
+			continue
+		}
+		lat = append(lat, ms)
+		r.Bytes = n
+	}
+	sort.Float64s(lat)
+	if len(lat) > 0 {
+		r.P50ms = stats.Round2(stats.Percentile(lat, 0.50))
+		r.P95ms = stats.Round2(stats.Percentile(lat, 0.95))
+	}
+	r.OK = r.Attempts > 0 && r.Failures == 0
+	if r.OK && p.maxLatency > 0 && r.P95ms > float64(p.maxLatency)/float64(time.Millisecond) {
+		r.OK = false
+		r.LastError = fmt.Sprintf("p95 %.2fms exceeds max_latency %s", r.P95ms, p.maxLatency)
+	}
+	r.Verdict = resultenv.PassFail(r.OK)
+	if r.Attempts == 0 {
+		r.Verdict = resultenv.VerdictFailInfra
+	}
+	return r
+}
+
+// RunAll runs every probe concurrently and returns reports in file order.
+func RunAll(ctx context.Context, ps []Probe) []Report {
+	out := make([]Report, len(ps))
+	done := make(chan struct{})
+	for i, p := range ps {
+		go func(i int, p Probe) {
+			out[i] = Run(ctx, p)
+			done <- struct{}{}
+		}(i, p)
+	}
+	for range ps {
+		<-done
+	}
+	return out
+}
+
+// Result maps the report onto the trace_bench result ABI: p95_ms is the
+// probe latency, error_rate the share of failed attempts and size_kb the
+// response size; the probe is identified by labels.
+func (r Report) Result() engine.Result {
+	res := engine.Result{
+		P95ms:  r.P95ms,
+		SizeKB: stats.Round2(float64(r.Bytes) / 1024),
+		Labels: map[string]string{"probe": r.Name, "prober": r.Prober, "target": r.Target},
+	}
+	if r.Attempts > 0 {
+		res.ErrorRate = stats.Round5(float64(r.Failures) / float64(r.Attempts))
+	}
+	if r.Failures > 0 {
+		res.Errors = map[string]int{"probe": r.Failures}
+	}
+	return res
+}
@@ -0,0 +1,162 @@
+package blackbox
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+func probeTCP(ctx context.Context, p *Probe) (int, error) {
+	var d net.Dialer
+	c, err := d.DialContext(ctx, "tcp", p.Target)
+	if err != nil {
+		return 0, err
+	}
+	return 0, c.Close()
+}
+
+func probeHTTP(ctx context.Context, p *Probe) (int, error) {
+	method := p.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.Target, nil)
+	if err != nil {
+		return 0, err
+	}
+	// 매 시도를 새 연결로 재서 연결 비용까지 포함한다
+	tr := &http.Transport{DisableKeepAlives: true}
+	defer tr.CloseIdleConnections()
+	resp, err := (&http.Client{Transport: tr}).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return len(body), err
+	}
+	if !validStatus(p.ValidStatus, resp.StatusCode) {
+		return len(body), fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if p.BodyContains != "" && !strings.Contains(string(body), p.BodyContains) {
+		return len(body), fmt.Errorf("body does not contain %q", p.BodyContains)
+	}
+	return len(body), nil
+}
+
+func validStatus(valid []int, code int) bool {
+	if len(valid) == 0 {
+		return code/100 == 2
+	}
+	for _, v := range valid {
+		if v == code {
+			return true
+		}
+	}
+	return false
+}
+
+// dnsLookups는 query_type별 조회(응답 레코드 수를 돌려줌)
+var dnsLookups = map[string]func(ctx context.Context, r *net.Resolver, name string) (int, error){
+	"A": func(ctx context.Context, r *net.Resolver, name string) (int, error) {
+		ips, err := r.LookupIP(ctx, "ip4", name)
+		return len(ips), err
+	},
+	"AAAA": func(ctx context.Context, r *net.Resolver, name string) (int, error) {
+		ips, err := r.LookupIP(ctx, "ip6", name)
+		return len(ips), err
+	},
+	"CNAME": func(ctx context.Context, r *net.Resolver, name string) (int, error) {
+		c, err := r.LookupCNAME(ctx, name)
+		return len(c), err
+	},
+	"MX": func(ctx context.Context, r *net.Resolver, name string) (int, error) {
+		mx, err := r.LookupMX(ctx, name)
+		return len(mx), err
+	},
+	"TXT": func(ctx context.Context, r *net.Resolver, name string) (int, error) {
+		txt, err := r.LookupTXT(ctx, name)
+		return len(txt), err
+	},
+}
+
+func probeDNS(ctx context.Context, p *Probe) (int, error) {
+	r := net.DefaultResolver
+	if p.Server != "" {
+		server := p.Server
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		r = &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		}}
+	}
+	n, err := dnsLookups[p.QueryType](ctx, r, p.Target)
+	if err == nil && n == 0 {
+		err = fmt.Errorf("no %s records", p.QueryType)
+	}
+	// 응답 크기 대신 레코드 수는 ABI size_kb에 맞지 않으므로 0
+	return 0, err
+}
+
+// probeICMP는 raw 소켓으로 echo request 1개를 보낸다(IPv4, CAP_NET_RAW 필요)
+func probeICMP(ctx context.Context, p *Probe) (int, error) {
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", p.Target)
+	if err != nil {
+		return 0, err
+	}
+	conn, err := net.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		if os.IsPermission(err) {
+			return 0, fmt.Errorf("icmp needs a raw socket (run as root or grant CAP_NET_RAW): %w", err)
+		}
+		return 0, err
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl)
+	}
+	id, seq := uint16(os.Getpid()), uint16(time.Now().UnixNano())
+	msg := []byte{8, 0, 0, 0, 0, 0, 0, 0, 't', 'r', 'a', 'c', 'e', '_', 'b', 'e', 'n', 'c', 'h'}
+	binary.BigEndian.PutUint16(msg[4:], id)
+	binary.BigEndian.PutUint16(msg[6:], seq)
+	binary.BigEndian.PutUint16(msg[2:], icmpChecksum(msg))
+	dst := &net.IPAddr{IP: ips[0]}
+	if _, err := conn.WriteTo(msg, dst); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, err
+		}
+		// 다른 프로세스의 ICMP도 들어오므로 type/id/seq가 맞는 echo reply만 받는다
+		if n >= 8 && buf[0] == 0 && binary.BigEndian.Uint16(buf[4:]) == id &&
+			binary.BigEndian.Uint16(buf[6:]) == seq && from.String() == dst.String() {
+			return n, nil
+		}
+	}
+}
+
+func icmpChecksum(b []byte) uint16 {
+	var s uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		s += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		s += uint32(b[len(b)-1]) << 8
+	}
+	for s>>16 != 0 {
+		s = s&0xffff + s>>16
+	}
+	return ^uint16(s)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/artifact"
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/history"
+	"github.com/duri/trace_bench/internal/promapi"
+	"github.com/duri/trace_bench/output"
+)
+
+var contentTypes = map[string]string{
+	output.FormatJSON:        "application/json",
+	output.FormatBenchstat:   "text/plain",
+	output.FormatOpenMetrics: "application/openmetrics-text",
+	output.FormatInflux:      "text/plain",
+}
+
+// uploadArtifacts는 번들 파일을 먼저 올리고, 객체 URL 목록을 r.Artifacts에 담은
+// 결과 파일을 마지막에 올린다(결과 자신의 URL도 포함).
+func uploadArtifacts(uri, runID string, files []artifact.File, cfg engine.Config, format, resultPath string, r *engine.Result) error {
+	// 같은 {sha}로 도는 병렬 CI shard끼리 result.json을 덮지 않도록 run별 디렉터리
+	if !strings.Contains(uri, "{run_id}") {
+		uri = strings.TrimSuffix(uri, "/") + "/{run_id}/"
+	}
+	store, prefix, err := artifact.Open(artifact.Expand(uri, artifact.DefaultVars(runID)))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	urls, err := artifact.UploadBundle(ctx, store, prefix, files)
+	if err != nil {
+		return err
+	}
+	if resultPath == "" {
+		dir, err := os.MkdirTemp("", "trace_bench-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		resultPath = filepath.Join(dir, "result.json")
+	}
+	r.Artifacts = append(urls, store.URL(path.Join(prefix, filepath.Base(resultPath))))
+	if err := output.WriteFileAtomicFunc(resultPath, func(w io.Writer) error {
+		return output.Write(w, format, cfg, *r)
+	}); err != nil {
+		return err
+	}
+	_, err = artifact.UploadBundle(ctx, store, prefix, []artifact.File{{Path: resultPath, ContentType: contentTypes[format]}})
+	return err
+}
+
+// artifactsFlags는 artifacts 명령의 플래그 값
+type artifactsFlags struct {
+	olderThan  string
+	keepTagged bool
+	store      string
+	histPath   string
+	dryRun     bool
+}
+
+func (f *artifactsFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&f.olderThan, "older-than", "90d", "remove runs and artifacts older than this (e.g. 90d)")
+	fs.BoolVar(&f.keepTagged, "keep-tagged", false, "keep tagged runs (e.g. baselines) and their artifacts")
+	fs.StringVar(&f.store, "artifact-store", "", "artifact store root to prune (e.g. s3://bench-results/)")
+	fs.StringVar(&f.histPath, "history", historyPath(), "history DB path")
+	fs.BoolVar(&f.dryRun, "dry-run", false, "report what would be removed without removing it")
+}
+
+func runArtifacts(args []string) {
+	if len(args) == 0 || args[0] != "prune" {
+		fmt.Fprintln(os.Stderr, "usage: trace_bench artifacts prune -older-than=90d [-keep-tagged] [-artifact-store=URI] [-history=PATH] [-dry-run]")
+		os.Exit(2)
+	}
+	var f artifactsFlags
+	fs := flag.NewFlagSet("artifacts prune", flag.ExitOnError)
+	f.define(fs)
+	fs.Parse(args[1:])
+
+	age, err := promapi.ParseDuration(f.olderThan)
+	if err != nil {
+		fail(err)
+	}
+	rep, err := prune(time.Now().Add(-age), f.keepTagged, f.store, f.histPath, f.dryRun)
+	if err != nil {
+		fail(err)
+	}
+	output.WriteJSON(os.Stdout, rep)
+}
+
+type pruneReport struct {
+	Cutoff           time.Time `json:"cutoff"`
+	DryRun           bool      `json:"dry_run,omitempty"`
+	HistoryRemoved   int       `json:"history_removed"`
+	HistoryKept      int       `json:"history_kept"`
+	ArtifactsRemoved []string  `json:"artifacts_removed,omitempty"`
+	ArtifactsKept    int       `json:"artifacts_kept_tagged"`
+}
+
+// prune은 history에서 오래된 run을 지우고, 저장소에서는 cutoff 이전 객체 중
+// 보존 대상(태그된 run이 참조하는 URL)이 아닌 것만 지운다
+func prune(cutoff time.Time, keepTagged bool, storeURI, histPath string, dryRun bool) (pruneReport, error) {
+	rep := pruneReport{Cutoff: cutoff.UTC(), DryRun: dryRun}
+	keepURLs := map[string]bool{}
+	if histPath != "" {
+		db, err := history.Open(histPath)
+		if err != nil {
+			return rep, err
+		}
+		filter := func(all []history.Entry) ([]history.Entry, error) {
+			var keep []history.Entry
+			for _, e := range all {
+				if keepTagged && e.Tagged() {
+					for _, u := range e.Result.Artifacts {
+						keepURLs[u] = true
+					}
+				}
+				if e.Time.Before(cutoff) && !(keepTagged && e.Tagged()) {
+					rep.HistoryRemoved++
+					continue
+				}
+				keep = append(keep, e)
+			}
+			rep.HistoryKept = len(keep)
+			return keep, nil
+		}
+		if dryRun {
+			all, err := db.Entries()
+			if err != nil {
+				return rep, err
+			}
+			filter(all)
+		} else if err := db.Rewrite(filter); err != nil {
+			return rep, err
+		}
+	}
+	if storeURI == "" {
+		return rep, nil
+	}
+	store, prefix, err := artifact.Open(storeURI)
+	if err != nil {
+		return rep, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	objs, err := store.List(ctx, prefix)
+	if err != nil {
+		return rep, err
+	}
+	for _, o := range objs {
+		if !o.LastModified.Before(cutoff) {
+			continue
+		}
+		u := store.URL(o.Key)
+		if keepURLs[u] {
+			rep.ArtifactsKept++
+			continue
+		}
+		if !dryRun {
+			if err := store.Delete(ctx, o.Key); err != nil {
+				return rep, err
+			}
+		}
+		rep.ArtifactsRemoved = append(rep.ArtifactsRemoved, u)
+	}
+	return rep, nil
+}
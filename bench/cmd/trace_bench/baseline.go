@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"text/tabwriter"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/history"
+	"github.com/duri/trace_bench/output"
+)
+
+const baselineUsage = `usage:
+  trace_bench baseline set [-run-id ID] NAME [result.json]
+  trace_bench baseline get NAME
+  trace_bench baseline list
+  trace_bench baseline diff [-json] NAME result.json|NAME`
+
+// baselineFlags는 baseline 명령의 플래그 값
+type baselineFlags struct {
+	histPath string
+	runID    string
+	asJSON   bool
+}
+
+func (f *baselineFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&f.histPath, "history", historyPath(), "history DB path")
+	fs.StringVar(&f.runID, "run-id", "", "set: tag this recorded run instead of reading a result file")
+	fs.BoolVar(&f.asJSON, "json", false, "diff: print JSON instead of a table")
+}
+
+func runBaseline(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, baselineUsage)
+		os.Exit(2)
+	}
+	var f baselineFlags
+	fs := flag.NewFlagSet("baseline "+args[0], flag.ExitOnError)
+	f.define(fs)
+	fs.Parse(args[1:])
+	db, err := history.Open(f.histPath)
+	if err != nil {
+		fail(err)
+	}
+	pos := fs.Args()
+
+	switch {
+	case args[0] == "set" && len(pos) == 2 && f.runID == "":
+		r, err := readResult(pos[1])
+		if err != nil {
+			fail(err)
+		}
+		// 결과에 run id가 있으면 그대로 쓰고, 없던 시절 파일은 경로로 식별
+		id := r.RunID
+		if id == "" {
+			id = "file:" + pos[1]
+		}
+		if err := db.SetBaseline(pos[0], "", &history.Entry{RunID: id, Result: r}); err != nil {
+			fail(err)
+		}
+		logger.Info(evBaselineSet, "name", pos[0], "file", pos[1])
+	case args[0] == "set" && len(pos) == 1 && f.runID != "":
+		if err := db.SetBaseline(pos[0], f.runID, nil); err != nil {
+			fail(err)
+		}
+		logger.Info(evBaselineSet, "name", pos[0], "run_id", f.runID)
+	case args[0] == "get" && len(pos) == 1:
+		e, err := db.Baseline(pos[0])
+		if err != nil {
+			fail(err)
+		}
+		output.WriteJSON(os.Stdout, e.Result)
+	case args[0] == "list" && len(pos) == 0:
+		es, err := db.Baselines()
+		if err != nil {
+			fail(err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tRUN_ID\tTIME\tP95_MS\tERROR_RATE")
+		for _, e := range es {
+			for _, t := range e.Tags {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%v\t%v\n", t, e.RunID, e.Time.Format(time.RFC3339), e.Result.P95ms, e.Result.ErrorRate)
+			}
+		}
+		tw.Flush()
+	case args[0] == "diff" && len(pos) == 2:
+		base, err := db.Baseline(pos[0])
+		if err != nil {
+			fail(err)
+		}
+		// 두 번째 인자는 결과 파일, 없으면 다른 baseline 이름
+		cur, err := readResult(pos[1])
+		if os.IsNotExist(err) {
+			var e history.Entry
+			e, err = db.Baseline(pos[1])
+			cur = e.Result
+		}
+		if err != nil {
+			fail(err)
+		}
+		writeDiff(f.asJSON, pos[0], pos[1], base.Result, cur)
+	default:
+		fmt.Fprintln(os.Stderr, baselineUsage)
+		os.Exit(2)
+	}
+}
+
+// against는 -against 비교: 이름으로 baseline을 찾아 diff를 stderr에 출력
+func against(histPath, name string, r engine.Result) ([]output.MetricDiff, error) {
+	db, err := history.Open(histPath)
+	if err != nil {
+		return nil, err
+	}
+	base, err := db.Baseline(name)
+	if err != nil {
+		return nil, err
+	}
+	ds := output.Diff(base.Result, r)
+	return ds, output.WriteDiff(os.Stderr, name, "current", ds)
+}
+
+func writeDiff(asJSON bool, baseLabel, curLabel string, base, cur engine.Result) {
+	ds := output.Diff(base, cur)
+	if asJSON {
+		output.WriteJSON(os.Stdout, ds)
+		return
+	}
+	output.WriteDiff(os.Stdout, baseLabel, curLabel, ds)
+}
+
+// readResult는 -out-format=json 결과 파일을 읽는다
+func readResult(path string) (engine.Result, error) {
+	var r engine.Result
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return r, fmt.Errorf("%s: not a json result: %w", path, err)
+	}
+	return r, nil
+}
+
+// compareFlags는 compare 명령의 플래그 값
+type compareFlags struct {
+	histPath    string
+	againstName string
+	baseFile    string
+	asJSON      bool
+	tui         bool
+	filter      labelFlag
+	sameArch    bool
+}
+
+func (f *compareFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&f.histPath, "history", historyPath(), "history DB path (for -against)")
+	fs.StringVar(&f.againstName, "against", "", "named baseline in the history DB (see trace_bench baseline)")
+	fs.StringVar(&f.baseFile, "base", "", "baseline result file (instead of -against)")
+	fs.BoolVar(&f.asJSON, "json", false, "print JSON instead of a table")
+	fs.BoolVar(&f.tui, "tui", false, "terminal view: colored side-by-side tables of all metrics, percentile deltas and sparklines ($NO_COLOR disables color)")
+	f.filter = labelFlag{}
+	fs.Var(f.filter, "filter", "require both runs to carry this key=value label (repeatable)")
+	fs.BoolVar(&f.sameArch, "require-same-arch", false, "fail unless both runs recorded the same host architecture (e.g. no Graviton vs x86 deltas)")
+}
+
+// compare 모드: trace_bench compare -against=NAME|-base=FILE result.json
+func runCompare(args []string) {
+	var f compareFlags
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	f.define(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 || (f.againstName == "") == (f.baseFile == "") {
+		fmt.Fprintln(os.Stderr, "usage: trace_bench compare -against=NAME|-base=FILE [-filter k=v] [-require-same-arch] [-json|-tui] result.json")
+		os.Exit(2)
+	}
+	cur, err := readResult(fs.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	label := f.baseFile
+	var base engine.Result
+	if f.againstName != "" {
+		label = f.againstName
+		db, err := history.Open(f.histPath)
+		if err != nil {
+			fail(err)
+		}
+		e, err := db.Baseline(f.againstName)
+		if err != nil {
+			fail(err)
+		}
+		base = e.Result
+	} else if base, err = readResult(f.baseFile); err != nil {
+		fail(err)
+	}
+	// 다른 환경/브랜치의 run끼리 비교하지 않도록
+	for _, c := range []struct {
+		name   string
+		labels map[string]string
+	}{{label, base.Labels}, {fs.Arg(0), cur.Labels}} {
+		if !(history.Entry{Labels: c.labels}).Match(f.filter) {
+			fail(fmt.Errorf("%s does not match -filter %s (labels: %s)", c.name, f.filter, fmtLabelSet(c.labels)))
+		}
+	}
+	switch {
+	case f.sameArch && !base.Host.SameArch(cur.Host):
+		fail(fmt.Errorf("-require-same-arch: %s ran on %s, %s on %s", label, base.Host, fs.Arg(0), cur.Host))
+	case base.Host != nil && cur.Host != nil && !reflect.DeepEqual(base.Host, cur.Host):
+		logger.Warn(evConfigWarning, "reason", "runs measured on different hosts, deltas include hardware differences",
+			"base", base.Host.String(), "current", cur.Host.String())
+	}
+	if f.tui {
+		output.WriteDiffTUI(os.Stdout, label, fs.Arg(0), base, cur, os.Getenv("NO_COLOR") == "")
+		return
+	}
+	writeDiff(f.asJSON, label, fs.Arg(0), base, cur)
+}
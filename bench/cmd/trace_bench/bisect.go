@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/slo"
+)
+
+// git bisect run 종료 코드
+const (
+	bisectGood  = 0
+	bisectBad   = 1
+	bisectSkip  = 125 // 빌드/실행 실패: 판정 불가
+	bisectAbort = 128 // 도구 자체 오류: bisect 중단
+)
+
+// bisectVerdict는 검사한 커밋 하나의 판정
+type bisectVerdict struct {
+	Commit  string             `json:"commit"`
+	Verdict string             `json:"verdict"` // good|bad|skip
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+	Failed  []slo.Check        `json:"failed,omitempty"`
+}
+
+// bisectReport는 stdout으로 내보내는 요약
+type bisectReport struct {
+	Good     string          `json:"good"`
+	Bad      string          `json:"bad"`
+	FirstBad string          `json:"first_bad,omitempty"`
+	Steps    []bisectVerdict `json:"steps"`
+}
+
+var firstBadRe = regexp.MustCompile(`(?m)^([0-9a-f]{7,40}) is the first bad commit`)
+
+// bisectFlags는 bisect 명령의 플래그 값
+type bisectFlags struct {
+	good       string
+	bad        string
+	runCmd     string
+	sloPath    string
+	resultPath string
+	repo       string
+	step       bool
+	logPath    string
+}
+
+func (f *bisectFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&f.good, "good", "", "known good commit")
+	fs.StringVar(&f.bad, "bad", "HEAD", "known bad commit")
+	fs.StringVar(&f.runCmd, "run-cmd", "", "shell command run at each commit that produces a json result (stdout or -result)")
+	fs.StringVar(&f.sloPath, "slo", "slo.yaml", "slo.yaml whose bench_metric SLOs decide good/bad (read once, before checkout)")
+	fs.StringVar(&f.resultPath, "result", "", "result file written by -run-cmd (default: last json line of its stdout)")
+	fs.StringVar(&f.repo, "repo", ".", "git work tree to bisect")
+	fs.BoolVar(&f.step, "step", false, "internal: evaluate the current checkout (used by git bisect run)")
+	fs.StringVar(&f.logPath, "log", "", "internal: step log path")
+}
+
+// bisect 모드: SLO 판정을 oracle로 git bisect run을 구동
+func runBisect(args []string) {
+	var f bisectFlags
+	fs := flag.NewFlagSet("bisect", flag.ExitOnError)
+	f.define(fs)
+	fs.Parse(args)
+	if f.runCmd == "" {
+		fail(fmt.Errorf("bisect: -run-cmd is required"))
+	}
+	if f.step {
+		os.Exit(bisectStep(f.runCmd, f.sloPath, f.resultPath, f.logPath))
+	}
+	if f.good == "" {
+		fail(fmt.Errorf("bisect: -good is required"))
+	}
+	defs, err := slo.Load(f.sloPath)
+	if err != nil {
+		fail(err)
+	}
+	if len(defs.Bench()) == 0 {
+		fail(fmt.Errorf("bisect: %s has no bench_metric SLOs to judge by", f.sloPath))
+	}
+
+	// checkout이 바이너리와 slo.yaml을 바꾸지 않도록 임시 디렉터리로 복사
+	dir, err := os.MkdirTemp("", "trace_bench_bisect")
+	if err != nil {
+		fail(err)
+	}
+	defer os.RemoveAll(dir)
+	exe, err := os.Executable()
+	if err != nil {
+		fail(err)
+	}
+	self := filepath.Join(dir, "trace_bench")
+	sloCopy := filepath.Join(dir, "slo.yaml")
+	if err := copyFile(exe, self, 0o755); err != nil {
+		fail(err)
+	}
+	if err := copyFile(f.sloPath, sloCopy, 0o644); err != nil {
+		fail(err)
+	}
+	log := filepath.Join(dir, "steps.jsonl")
+	if f.resultPath != "" {
+		if f.resultPath, err = filepath.Abs(f.resultPath); err != nil {
+			fail(err)
+		}
+	}
+
+	if err := git(f.repo, os.Stderr, "bisect", "start", f.bad, f.good); err != nil {
+		fail(err)
+	}
+	defer git(f.repo, io.Discard, "bisect", "reset")
+	var out bytes.Buffer
+	runArgs := []string{"bisect", "run", self, "bisect", "-step", "-run-cmd", f.runCmd, "-slo", sloCopy, "-log", log}
+	if f.resultPath != "" {
+		runArgs = append(runArgs, "-result", f.resultPath)
+	}
+	err = git(f.repo, io.MultiWriter(&out, os.Stderr), runArgs...)
+
+	rep := bisectReport{Good: f.good, Bad: f.bad}
+	if m := firstBadRe.FindStringSubmatch(out.String()); m != nil {
+		rep.FirstBad = m[1]
+	}
+	if rep.Steps, err = readSteps(log); err != nil && !os.IsNotExist(err) {
+		fail(err)
+	}
+	output.WriteJSON(os.Stdout, rep)
+	if rep.FirstBad == "" {
+		logger.Warn(evBisectUnresolved, "good", f.good, "bad", f.bad)
+		os.Exit(1)
+	}
+}
+
+// bisectStep은 현재 checkout에서 run-cmd를 실행하고 SLO로 판정한다
+func bisectStep(runCmd, sloPath, resultPath, logPath string) int {
+	defs, err := slo.Load(sloPath)
+	if err != nil {
+		logger.Error(evRunFailed, "err", err.Error())
+		return bisectAbort
+	}
+	st := bisectVerdict{Commit: gitHead()}
+	var stdout bytes.Buffer
+	cmd := exec.Command("sh", "-c", runCmd)
+	cmd.Stdout, cmd.Stderr = &stdout, os.Stderr
+	var r engine.Result
+	if err := cmd.Run(); err != nil {
+		logger.Warn(evBisectSkipped, "commit", st.Commit, "reason", "run-cmd failed", "err", err.Error())
+		st.Verdict = "skip"
+	} else if r, err = stepResult(resultPath, stdout.Bytes()); err != nil {
+		logger.Warn(evBisectSkipped, "commit", st.Commit, "reason", "no result", "err", err.Error())
+		st.Verdict = "skip"
+	} else {
+		st.Metrics = r.Metrics()
+		st.Verdict = "good"
+		for _, c := range slo.Evaluate(defs.Bench(), st.Metrics) {
+			if !c.Pass {
+				st.Failed = append(st.Failed, c)
+				st.Verdict = "bad"
+			}
+		}
+	}
+	logger.Info(evBisectStep, "commit", st.Commit, "verdict", st.Verdict)
+	if logPath != "" {
+		f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return bisectAbort
+		}
+		output.WriteJSON(f, st)
+		f.Close()
+	}
+	switch st.Verdict {
+	case "good":
+		return bisectGood
+	case "bad":
+		return bisectBad
+	}
+	return bisectSkip
+}
+
+func stepResult(path string, stdout []byte) (engine.Result, error) {
+	if path != "" {
+		return readResult(path)
+	}
+	// stdout의 마지막 JSON 줄(앞선 빌드 로그 등은 무시)
+	lines := strings.Split(strings.TrimSpace(string(stdout)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		var r engine.Result
+		if json.Unmarshal([]byte(lines[i]), &r) == nil {
+			return r, nil
+		}
+	}
+	return engine.Result{}, fmt.Errorf("no json result on stdout")
+}
+
+func readSteps(path string) ([]bisectVerdict, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var steps []bisectVerdict
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var st bisectVerdict
+		if err := json.Unmarshal(sc.Bytes(), &st); err != nil {
+			return nil, err
+		}
+		steps = append(steps, st)
+	}
+	return steps, sc.Err()
+}
+
+func git(dir string, stdout io.Writer, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir, cmd.Stdout, cmd.Stderr = dir, stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w", strings.Join(args[:min(2, len(args))], " "), err)
+	}
+	return nil
+}
+
+func gitHead() string {
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, b, mode)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/history"
+	"github.com/duri/trace_bench/output"
+)
+
+// calibrateFlags는 calibrate 명령의 플래그 값
+type calibrateFlags struct {
+	from    string
+	out     string
+	filter  labelFlag
+	minRuns int
+}
+
+func (f *calibrateFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&f.from, "from", historyPath(), "history DB with the real-mode runs to fit")
+	fs.StringVar(&f.out, "out", "", "write the model file here instead of stdout (use with -mode model -model-file)")
+	f.filter = labelFlag{}
+	fs.Var(f.filter, "filter", "only runs with this key=value label (repeatable, e.g. -filter workload=pipeline -filter env=ci)")
+	fs.IntVar(&f.minRuns, "min-runs", 3, "fail unless at least this many real-mode runs match")
+}
+
+// calibrate 모드: history의 real 모드 run에 model 모드 계수를 맞춰 -model-file로 쓴다
+func runCalibrate(args []string) {
+	var f calibrateFlags
+	fs := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	f.define(fs)
+	fs.Parse(args)
+
+	db, err := history.Open(f.from)
+	if err != nil {
+		fail(err)
+	}
+	all, err := db.Entries()
+	if err != nil {
+		fail(err)
+	}
+	obs, err := observations(history.Filter(all, f.filter))
+	if err != nil {
+		fail(err)
+	}
+	if len(obs) < f.minRuns {
+		fail(fmt.Errorf("%s: %d matching real-mode runs (need -min-runs %d)", db.Path, len(obs), f.minRuns))
+	}
+	mf, err := engine.Calibrate(obs)
+	if err != nil {
+		fail(err)
+	}
+	if f.out == "" {
+		if err := mf.Write(os.Stdout); err != nil {
+			fail(err)
+		}
+	} else if err := output.WriteFileAtomicFunc(f.out, func(w io.Writer) error { return mf.Write(w) }); err != nil {
+		fail(err)
+	}
+	logger.Info(evModelCalibrated, "runs", mf.Runs, "p95_error", mf.Error["p95_ms"], "size_error", mf.Error["size_kb"],
+		"default", mf.Default, "path", f.out)
+}
+
+// observations는 real 모드 run의 설정 라벨(output.ConfigLabels)과 결과를 꺼낸다
+func observations(es []history.Entry) ([]engine.Observation, error) {
+	var obs []engine.Observation
+	for _, e := range es {
+		// zstd real run은 압축 없이 측정된 값이라(지금은 거부) 맞추지 않는다
+		if e.Labels["mode"] != engine.ModeReal || e.Labels["compression"] == "zstd" {
+			continue
+		}
+		s, err := strconv.ParseFloat(e.Labels["sampling"], 64)
+		if err != nil {
+			return nil, fmt.Errorf("run %s: invalid sampling label %q", e.RunID, e.Labels["sampling"])
+		}
+		obs = append(obs, engine.Observation{
+			Sampling:      s,
+			Serialization: e.Labels["serialization"],
+			Compression:   e.Labels["compression"],
+			P95ms:         e.Result.P95ms,
+			ErrorRate:     e.Result.ErrorRate,
+			SizeKB:        e.Result.SizeKB,
+		})
+	}
+	return obs, nil
+}
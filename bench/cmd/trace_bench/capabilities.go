@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/duri/trace_bench/cost"
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/buildinfo"
+	"github.com/duri/trace_bench/pkg/resultenv"
+	"github.com/duri/trace_bench/profile"
+	"github.com/duri/trace_bench/slo"
+	"github.com/duri/trace_bench/stats"
+)
+
+// capabilities는 오케스트레이션 스크립트가 버전 비교 대신 기능을 확인하는 목록.
+// 필드는 추가만 한다(제거/의미 변경은 스크립트를 깬다)
+type capabilities struct {
+	Version          string         `json:"version"`
+	Modes            []string       `json:"modes"`
+	Workloads        []string       `json:"workloads"`
+	Protocols        []string       `json:"protocols"`
+	Connections      []string       `json:"connections"`
+	Serializations   []string       `json:"serializations"`
+	Compressions     []string       `json:"compressions"`
+	OutputFormats    []string       `json:"output_formats"`
+	QuantileSketches []string       `json:"quantile_sketches"`
+	Backends         []string       `json:"backends"`
+	Brokers          []string       `json:"brokers"`
+	Commands         []string       `json:"commands"`
+	LogEvents        []string       `json:"log_events"`
+	Verdicts         []string       `json:"verdicts"`
+	SchemaVersions   map[string]int `json:"schema_versions"`
+}
+
+func currentCapabilities() capabilities {
+	cmds := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		cmds = append(cmds, name)
+	}
+	sort.Strings(cmds)
+	verdicts := make([]string, len(resultenv.Verdicts))
+	for i, v := range resultenv.Verdicts {
+		verdicts[i] = string(v)
+	}
+	return capabilities{
+		Version:          buildinfo.Read().Version,
+		Modes:            []string{engine.ModeModel, engine.ModeReal, engine.ModeHybrid},
+		Workloads:        engine.Workloads,
+		Protocols:        engine.Protocols,
+		Connections:      []string{engine.ConnReuse, engine.ConnPerRequest, engine.ConnPool + ":N"},
+		Serializations:   engine.Serializations,
+		Compressions:     engine.Compressions,
+		OutputFormats:    output.Formats,
+		QuantileSketches: stats.SketchKinds,
+		Backends:         engine.Backends,
+		Brokers:          engine.Brokers,
+		Commands:         cmds,
+		LogEvents:        logEvents,
+		Verdicts:         verdicts,
+		// 입력 파일의 version 필드와 -envelope 출력의 schema_version
+		SchemaVersions: map[string]int{
+			"slo":      slo.Version,
+			"profiles": profile.Version,
+			"cost":     cost.Version,
+			"queries":  engine.QueriesVersion,
+			"schedule": scheduleVersion,
+			"envelope": resultenv.SchemaVersion,
+		},
+	}
+}
+
+// capabilitiesFlags는 capabilities 명령의 플래그 값
+type capabilitiesFlags struct {
+	asJSON bool
+}
+
+func (f *capabilitiesFlags) define(fs *flag.FlagSet) {
+	fs.BoolVar(&f.asJSON, "json", false, "print JSON (stable field names) instead of a table")
+}
+
+// capabilities 모드: trace_bench capabilities -json
+func runCapabilities(args []string) {
+	var f capabilitiesFlags
+	fs := flag.NewFlagSet("capabilities", flag.ExitOnError)
+	f.define(fs)
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: trace_bench capabilities [-json]")
+		os.Exit(2)
+	}
+	c := currentCapabilities()
+	if f.asJSON {
+		if err := output.WriteJSON(os.Stdout, c); err != nil {
+			fail(err)
+		}
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, row := range []struct {
+		name string
+		vals []string
+	}{
+		{"version", []string{c.Version}}, {"modes", c.Modes}, {"workloads", c.Workloads}, {"protocols", c.Protocols}, {"connections", c.Connections},
+		{"serializations", c.Serializations}, {"compressions", c.Compressions}, {"output formats", c.OutputFormats},
+		{"quantile sketches", c.QuantileSketches}, {"backends", c.Backends}, {"brokers", c.Brokers}, {"commands", c.Commands},
+		{"verdicts", c.Verdicts},
+	} {
+		fmt.Fprintf(tw, "%s\t%s\n", row.name, strings.Join(row.vals, " "))
+	}
+	names := make([]string, 0, len(c.SchemaVersions))
+	for n := range c.SchemaVersions {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fmt.Fprintf(tw, "schema %s\tv%d\n", n, c.SchemaVersions[n])
+	}
+	tw.Flush()
+}
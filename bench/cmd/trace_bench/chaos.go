@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/internal/dockerapi"
+)
+
+// bindChaos는 엔진 밖 fault(kill-target, hook)에 실행 함수를 연결한다
+func bindChaos(faults []engine.ChaosFault, target *engine.Target) error {
+	for i := range faults {
+		f := &faults[i]
+		switch f.Kind {
+		case engine.ChaosKillTarget, engine.ChaosStopTarget:
+			if target == nil {
+				return fmt.Errorf("chaos %s requires -compose-project/-service", f)
+			}
+			name, action := target.Container, "/kill"
+			if f.Kind == engine.ChaosStopTarget {
+				action = "/stop"
+			}
+			f.Inject = func(ctx context.Context) error { return dockerPost(ctx, "/containers/"+url.PathEscape(name)+action) }
+			if f.Until > 0 {
+				// 윈도우 종료 시 컨테이너 재시작
+				f.Revert = func(ctx context.Context) error { return dockerPost(ctx, "/containers/"+url.PathEscape(name)+"/start") }
+			}
+		case engine.ChaosHook:
+			spec, path := f.String(), f.Arg
+			f.Inject = func(ctx context.Context) error { return runHook(ctx, path, "inject", spec) }
+			if f.Until > 0 {
+				f.Revert = func(ctx context.Context) error { return runHook(ctx, path, "revert", spec) }
+			}
+		}
+	}
+	return nil
+}
+
+// hook 스크립트 인터페이스: "<path> inject|revert", TRACE_BENCH_CHAOS=<fault>
+func runHook(ctx context.Context, path, action, spec string) error {
+	cmd := exec.CommandContext(ctx, path, action)
+	cmd.Env = append(os.Environ(), "TRACE_BENCH_CHAOS="+spec)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("chaos hook %s %s: %w", path, action, err)
+	}
+	return nil
+}
+
+func dockerPost(ctx context.Context, path string) error {
+	c, err := dockerapi.New()
+	if err != nil {
+		return err
+	}
+	return c.Post(ctx, path)
+}
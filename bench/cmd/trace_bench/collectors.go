@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/collector"
+	"github.com/duri/trace_bench/engine"
+)
+
+// collectorFlag는 반복 가능한 -collector name=command
+type collectorFlag []collector.Spec
+
+func (c *collectorFlag) String() string {
+	names := make([]string, len(*c))
+	for i, s := range *c {
+		names[i] = s.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func (c *collectorFlag) Set(s string) error {
+	spec, err := collector.ParseSpec(s)
+	if err != nil {
+		return err
+	}
+	for _, o := range *c {
+		if o.Name == spec.Name {
+			return errDuplicateCollector(spec.Name)
+		}
+	}
+	*c = append(*c, spec)
+	return nil
+}
+
+type errDuplicateCollector string
+
+func (e errDuplicateCollector) Error() string { return "duplicate collector " + string(e) }
+
+// startCollectors는 측정 직전에 시작한다(시작 실패는 실행 실패)
+func startCollectors(specs collectorFlag, runID string, labels map[string]string, timeout time.Duration) (collector.Set, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	return collector.StartAll(context.Background(), specs, collector.Hello{RunID: runID, Labels: labels}, timeout)
+}
+
+// finishCollectors는 측정 직후 값을 모아 결과들의 custom_metrics에 합치고 멈춘다.
+// 수집 실패는 경고로 남기고 해당 collector 값만 빠진다
+func finishCollectors(set collector.Set, rs ...*engine.Result) {
+	if set == nil {
+		return
+	}
+	m, errs := set.Collect()
+	errs = append(errs, set.Stop()...)
+	for _, err := range errs {
+		logger.Warn(evCollectorFailed, "err", err.Error())
+	}
+	for _, r := range rs {
+		r.CustomMetrics = m
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/duri/trace_bench/pkg/buildinfo"
+)
+
+// 명령/플래그 정의(describeFlags)에서 completion 스크립트와 man 페이지를 만든다.
+// 플래그를 추가하면 별도 수정 없이 반영된다
+
+type cmdFlag struct {
+	name, value, usage string // value가 ""이면 bool 플래그
+}
+
+type cmdSpec struct {
+	name    string
+	summary string
+	actions []string
+	flags   []cmdFlag
+}
+
+func flagsOf(fs *flag.FlagSet) []cmdFlag {
+	var out []cmdFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		value, usage := flag.UnquoteUsage(f)
+		out = append(out, cmdFlag{name: f.Name, value: value, usage: usage})
+	})
+	return out
+}
+
+// commandSpecs는 completion/man을 제외한 모든 명령(이름순)
+func commandSpecs() []cmdSpec {
+	var specs []cmdSpec
+	for name := range subcommands {
+		d := subcommandDocs[name]
+		spec := cmdSpec{name: name, summary: d.summary, actions: d.actions}
+		spec.flags = flagsOf(describeFlags(name))
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].name < specs[j].name })
+	return specs
+}
+
+// completion 모드: trace_bench completion bash|zsh|fish
+func runCompletion(args []string) {
+	gen := map[string]func(io.Writer, []cmdFlag, []cmdSpec){"bash": writeBashCompletion, "zsh": writeZshCompletion, "fish": writeFishCompletion}[strings.Join(args, " ")]
+	if gen == nil {
+		fmt.Fprintln(os.Stderr, "usage: trace_bench completion bash|zsh|fish")
+		os.Exit(2)
+	}
+	gen(os.Stdout, flagsOf(describeFlags("")), commandSpecs())
+}
+
+// man 모드: trace_bench man > trace_bench.1
+func runMan(args []string) {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: trace_bench man")
+		os.Exit(2)
+	}
+	writeMan(os.Stdout, flagsOf(describeFlags("")), commandSpecs())
+}
+
+func flagWords(flags []cmdFlag) string {
+	words := make([]string, len(flags))
+	for i, f := range flags {
+		words[i] = "-" + f.name
+	}
+	return strings.Join(words, " ")
+}
+
+func writeBashCompletion(w io.Writer, global []cmdFlag, cmds []cmdSpec) {
+	names := make([]string, len(cmds))
+	for i, c := range cmds {
+		names[i] = c.name
+	}
+	fmt.Fprintf(w, `# bash completion for trace_bench (generated by "trace_bench completion bash")
+_trace_bench() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	# 명령은 첫 인자로만 온다
+	local cmd=
+	if ((COMP_CWORD > 1)) && [[ ${COMP_WORDS[1]} != -* ]]; then
+		cmd=${COMP_WORDS[1]}
+	fi
+	local flags= actions= valued=
+	case $cmd in
+	"")
+		flags=%q
+		valued=%q
+		actions=%q
+		;;
+`, flagWords(global), valueWords(global), strings.Join(names, " "))
+	for _, c := range cmds {
+		fmt.Fprintf(w, "\t%s)\n\t\tflags=%q\n\t\tvalued=%q\n", c.name, flagWords(c.flags), valueWords(c.flags))
+		if len(c.actions) > 0 {
+			fmt.Fprintf(w, "\t\tactions=%q\n", strings.Join(c.actions, " "))
+		}
+		fmt.Fprint(w, "\t\t;;\n")
+	}
+	fmt.Fprint(w, `	esac
+	# 값을 받는 플래그(-flag value) 뒤에서는 파일을 제안
+	if [[ $prev == -* && " $valued " == *" ${prev%%=*} "* ]]; then
+		COMPREPLY=($(compgen -f -- "$cur"))
+		return
+	fi
+	if [[ $cur == -* ]]; then
+		COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+	elif [[ -n $actions && ( -z $cmd || ${COMP_WORDS[COMP_CWORD-1]} == "$cmd" ) ]]; then
+		COMPREPLY=($(compgen -W "$actions" -- "$cur"))
+	else
+		COMPREPLY=($(compgen -f -- "$cur"))
+	fi
+}
+complete -o filenames -F _trace_bench trace_bench
+`)
+}
+
+func valueWords(flags []cmdFlag) string {
+	var words []string
+	for _, f := range flags {
+		if f.value != "" {
+			words = append(words, "-"+f.name)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// shortUsage는 usage의 첫 구절(completion 설명용)
+func shortUsage(s string) string {
+	if i := strings.IndexAny(s, ";:(\n"); i > 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+func writeZshCompletion(w io.Writer, global []cmdFlag, cmds []cmdSpec) {
+	esc := strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace
+	spec := func(f cmdFlag) string {
+		s := "'-" + f.name + "[" + esc(shortUsage(f.usage)) + "]"
+		if f.value != "" {
+			s += ":" + esc(f.value) + ":_files"
+		}
+		return s + "'"
+	}
+	fmt.Fprint(w, "#compdef trace_bench\n# zsh completion for trace_bench (generated by \"trace_bench completion zsh\")\n\n_trace_bench() {\n\tlocal -a commands\n\tcommands=(\n")
+	for _, c := range cmds {
+		fmt.Fprintf(w, "\t\t'%s:%s'\n", c.name, esc(c.summary))
+	}
+	fmt.Fprint(w, "\t)\n\tif (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then\n\t\t_describe command commands\n\t\treturn\n\tfi\n\tcase $words[2] in\n")
+	for _, c := range cmds {
+		fmt.Fprintf(w, "\t%s)\n\t\tshift words; (( CURRENT-- ))\n\t\t_arguments", c.name)
+		for _, f := range c.flags {
+			fmt.Fprint(w, " \\\n\t\t\t"+spec(f))
+		}
+		if len(c.actions) > 0 {
+			fmt.Fprintf(w, " \\\n\t\t\t'1:action:(%s)'", strings.Join(c.actions, " "))
+		}
+		fmt.Fprint(w, " \\\n\t\t\t'*:file:_files'\n\t\t;;\n")
+	}
+	fmt.Fprint(w, "\t*)\n\t\t_arguments")
+	for _, f := range global {
+		fmt.Fprint(w, " \\\n\t\t\t"+spec(f))
+	}
+	fmt.Fprint(w, "\n\t\t;;\n\tesac\n}\n\n_trace_bench \"$@\"\n")
+}
+
+func writeFishCompletion(w io.Writer, global []cmdFlag, cmds []cmdSpec) {
+	quote := func(s string) string { return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'" }
+	line := func(cond string, f cmdFlag) {
+		fmt.Fprintf(w, "complete -c trace_bench -n %s -o %s", quote(cond), f.name)
+		if f.value != "" {
+			fmt.Fprint(w, " -r")
+		}
+		fmt.Fprintf(w, " -d %s\n", quote(shortUsage(f.usage)))
+	}
+	fmt.Fprint(w, "# fish completion for trace_bench (generated by \"trace_bench completion fish\")\ncomplete -c trace_bench -f\n")
+	for _, f := range global {
+		line("__fish_use_subcommand", f)
+	}
+	for _, c := range cmds {
+		fmt.Fprintf(w, "complete -c trace_bench -n __fish_use_subcommand -a %s -d %s\n", c.name, quote(c.summary))
+	}
+	for _, c := range cmds {
+		cond := "__fish_seen_subcommand_from " + c.name
+		if len(c.actions) > 0 {
+			fmt.Fprintf(w, "complete -c trace_bench -n %s -a %s\n", quote(cond+"; and test (count (commandline -opc)) -eq 2"), quote(strings.Join(c.actions, " ")))
+		}
+		for _, f := range c.flags {
+			line(cond, f)
+		}
+		if c.name != "completion" && c.name != "man" {
+			fmt.Fprintf(w, "complete -c trace_bench -n %s -F\n", quote(cond))
+		}
+	}
+}
+
+// roff 이스케이프: 백슬래시, 하이픈, 줄 머리의 제어 문자
+func roff(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			lines[i] = `\&` + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func writeManFlags(w io.Writer, flags []cmdFlag) {
+	for _, f := range flags {
+		fmt.Fprintf(w, ".TP\n.B \\-%s", roff(f.name))
+		if f.value != "" {
+			fmt.Fprintf(w, " \\fI%s\\fR", roff(f.value))
+		}
+		fmt.Fprintf(w, "\n%s\n", roff(f.usage))
+	}
+}
+
+func writeMan(w io.Writer, global []cmdFlag, cmds []cmdSpec) {
+	info := buildinfo.Read()
+	date := info.CommitTime
+	if len(date) >= 10 {
+		date = date[:10]
+	}
+	fmt.Fprintf(w, ".\\\" generated by \"trace_bench man\"; do not edit\n.TH TRACE_BENCH 1 %q %q \"User Commands\"\n", date, "trace_bench "+info.Version)
+	fmt.Fprint(w, `.SH NAME
+trace_bench \- load generator and benchmark harness for trace ingest pipelines
+.SH SYNOPSIS
+.B trace_bench
+[\fIoptions\fR]
+.br
+.B trace_bench
+\fIcommand\fR [\fIoptions\fR] [\fIargs\fR]
+.SH DESCRIPTION
+Without a command, trace_bench runs one bench against a target (or the model) and prints the result.
+The commands below record, compare, schedule and post\-process runs.
+.SH OPTIONS
+`)
+	writeManFlags(w, global)
+	fmt.Fprint(w, ".SH COMMANDS\n")
+	for _, c := range cmds {
+		fmt.Fprintf(w, ".SS %s", roff(c.name))
+		if len(c.actions) > 0 {
+			fmt.Fprintf(w, " %s", roff(strings.Join(c.actions, "|")))
+		}
+		fmt.Fprintf(w, "\n%s.\n", roff(strings.ToUpper(c.summary[:1])+c.summary[1:]))
+		writeManFlags(w, c.flags)
+	}
+	fmt.Fprint(w, ".SH DEPRECATED\nThese flags still work but warn, are listed in the envelope's deprecations, and fail the run with \\-fail\\-on\\-deprecated.\n")
+	for _, d := range flagDeprecations {
+		fmt.Fprintf(w, ".TP\n.B \\-%s\nUse \\-%s (%s).\n", roff(d.name), roff(d.replacement), roff(d.note))
+	}
+	fmt.Fprintf(w, `.SH EXIT STATUS
+.TP
+.B 0
+Success.
+.TP
+.B 1
+Error.
+.TP
+.B 2
+Usage error, SLO breach, regression or lint error.
+.TP
+.B %d
+Target unhealthy.
+.TP
+.B %d
+Target build does not match \-expected\-sha.
+.TP
+.B %d
+Run vetoed.
+.TP
+.B %d
+Another run holds the run lock.
+.SH ENVIRONMENT
+.TP
+.B TRACE_BENCH_HISTORY
+Default history DB path.
+.TP
+.B TRACE_BENCH_PROFILES
+Default of \-profiles.
+.TP
+.B TRACE_BENCH_LOCK_DIR
+Directory of the run lock.
+.TP
+.B TRACE_BENCH_LOG_LEVEL
+Default of \-log\-level.
+.TP
+.B NO_COLOR
+Disables color in terminal views.
+.TP
+.B GOMAXPROCS
+Kept as is by \-cpu\-affinity.
+`, exitUnhealthy, exitBuildMismatch, exitVetoed, exitLocked)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// deprecatedFlag는 새 이름으로 바뀐 플래그. 옛 이름도 계속 동작하지만 쓰일 때마다
+// 경고와 봉투의 deprecations[]에 남고, -fail-on-deprecated면 실행을 거부한다
+type deprecatedFlag struct {
+	name        string
+	replacement string
+	note        string
+}
+
+// main 플래그의 옛 이름(tools/run_trace_bench.sh 등 Day20/21 스크립트의 약어)
+var flagDeprecations = []deprecatedFlag{
+	{"ser", "serialization", "short form used by the Day20/21 runner scripts"},
+	{"comp", "compression", "short form used by the Day20/21 runner scripts"},
+}
+
+// runDeprecations는 이번 실행에서 쓰인 옛 이름(봉투의 deprecations)
+var runDeprecations []resultenv.Deprecation
+
+func lookupDeprecated(name string) (deprecatedFlag, bool) {
+	for _, d := range flagDeprecations {
+		if d.name == name {
+			return d, true
+		}
+	}
+	return deprecatedFlag{}, false
+}
+
+// defineDeprecated는 옛 이름을 새 플래그와 같은 Value로 등록한다(둘 중 어느 쪽을 써도 같은 값)
+func defineDeprecated(fs *flag.FlagSet) {
+	for _, d := range flagDeprecations {
+		f := fs.Lookup(d.replacement)
+		if f == nil {
+			panic("deprecated flag -" + d.name + " replaced by undefined -" + d.replacement)
+		}
+		fs.Var(f.Value, d.name, "deprecated: use -"+d.replacement)
+	}
+}
+
+// usedDeprecations는 fs에서 명시적으로 쓴 옛 이름(이름순)
+func usedDeprecations(fs *flag.FlagSet) []resultenv.Deprecation {
+	var out []resultenv.Deprecation
+	fs.Visit(func(f *flag.Flag) {
+		if d, ok := lookupDeprecated(f.Name); ok {
+			out = append(out, resultenv.Deprecation{Kind: "flag", Name: "-" + d.name, Replacement: "-" + d.replacement,
+				Message: fmt.Sprintf("-%s is deprecated (%s); use -%s", d.name, d.note, d.replacement)})
+		}
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// checkDeprecations는 쓰인 옛 이름마다 경고하고, failOn이면 모두 묶어 오류로 돌려준다
+func checkDeprecations(ds []resultenv.Deprecation, failOn bool) error {
+	names := make([]string, len(ds))
+	for i, d := range ds {
+		names[i] = d.Name
+		logger.Warn(evDeprecated, "kind", d.Kind, "name", d.Name, "replacement", d.Replacement)
+	}
+	if failOn && len(ds) > 0 {
+		return fmt.Errorf("-fail-on-deprecated: %s still in use (see the deprecation warnings)", strings.Join(names, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/history"
+	"github.com/duri/trace_bench/internal/secretref"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/stats"
+)
+
+// digest는 기간 내 예약 run(schedule) 요약: 통과/실패, 상위 회귀, 추세
+type digest struct {
+	Since       time.Time       `json:"since"`
+	Until       time.Time       `json:"until"`
+	Runs        int             `json:"runs"`
+	Passed      int             `json:"passed"`
+	Failed      int             `json:"failed"`
+	Benches     []digestBench   `json:"benches"`
+	Regressions []digestRegress `json:"regressions"`
+}
+
+type digestBench struct {
+	Name   string `json:"name"`
+	Runs   int    `json:"runs"`
+	Passed int    `json:"passed"`
+	Failed int    `json:"failed"`
+	// Reasons는 실패한 run의 사유(slo:<name>, target_degraded) 중복 제거
+	Reasons []string `json:"reasons,omitempty"`
+	LastP95 float64  `json:"last_p95_ms"`
+	// Trend는 추세 기간의 run별 p95(오래된 순)
+	Trend []float64 `json:"trend_p95_ms"`
+}
+
+type digestRegress struct {
+	Bench     string  `json:"bench"`
+	RunID     string  `json:"run_id"`
+	Metric    string  `json:"metric"`
+	Current   float64 `json:"current"`
+	Median    float64 `json:"median"`
+	ChangePct float64 `json:"change_pct"`
+}
+
+// digestFlags는 digest 명령의 플래그 값
+type digestFlags struct {
+	histPath string
+	since    time.Duration
+	trend    time.Duration
+	group    string
+	top      int
+	format   string
+	out      string
+	webhook  string
+	smtpAddr string
+	smtpUser string
+	smtpPass string
+	from     string
+	to       string
+	lang     string
+}
+
+func (f *digestFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&f.histPath, "history", historyPath(), "history DB path")
+	fs.DurationVar(&f.since, "since", 24*time.Hour, "summarize runs recorded within this period")
+	fs.DurationVar(&f.trend, "trend", 14*24*time.Hour, "period plotted in the per-bench trend charts")
+	fs.StringVar(&f.group, "group", "schedule", "label naming the bench of a run (runs without it are skipped)")
+	fs.IntVar(&f.top, "top", 5, "regressions listed, largest change first")
+	fs.StringVar(&f.format, "format", "markdown", "digest written to stdout/-out: markdown|html|json")
+	fs.StringVar(&f.out, "out", "", "write the digest here instead of stdout")
+	fs.StringVar(&f.webhook, "webhook", "", "POST the markdown digest as {\"text\": ...} (Slack/Mattermost incoming webhook)")
+	fs.StringVar(&f.smtpAddr, "smtp", "", "send the digest as HTML mail with a markdown part via this SMTP server (host:port)")
+	fs.StringVar(&f.smtpUser, "smtp-user", "", "SMTP PLAIN auth user (empty: no auth)")
+	fs.StringVar(&f.smtpPass, "smtp-password", "secretref://env/SMTP_PASSWORD", "SMTP password, a secretref")
+	fs.StringVar(&f.from, "mail-from", "trace_bench@localhost", "mail From address")
+	fs.StringVar(&f.to, "mail-to", "", "comma-separated mail recipients (with -smtp)")
+	langFlag(fs, &f.lang)
+}
+
+// digest 모드: 최근(기본 24h) 예약 run을 HTML/Markdown 요약 하나로 묶어
+// SMTP 또는 webhook으로 보낸다(schedule 데몬의 아침 보고서)
+func runDigest(args []string) {
+	var f digestFlags
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	f.define(fs)
+	fs.Parse(args)
+	if err := setLang(f.lang); err != nil {
+		fail(fmt.Errorf("digest: %w", err))
+	}
+	if f.format != "markdown" && f.format != "html" && f.format != "json" {
+		fail(fmt.Errorf("digest: unknown -format %q (markdown|html|json)", f.format))
+	}
+	if f.smtpAddr != "" && f.to == "" {
+		fail(fmt.Errorf("digest: -smtp requires -mail-to"))
+	}
+
+	db, err := history.Open(f.histPath)
+	if err != nil {
+		fail(err)
+	}
+	all, err := db.Entries()
+	if err != nil {
+		fail(err)
+	}
+	d := buildDigest(all, f.group, time.Now(), f.since, max(f.trend, f.since), f.top)
+
+	render := map[string]func(io.Writer, *digest) error{
+		"markdown": writeDigestMarkdown,
+		"html":     writeDigestHTML,
+		"json":     func(w io.Writer, d *digest) error { return output.WriteJSON(w, d) },
+	}[f.format]
+	if f.out == "" {
+		if err := render(os.Stdout, d); err != nil {
+			fail(err)
+		}
+	} else {
+		if err := output.WriteFileAtomicFunc(f.out, func(w io.Writer) error { return render(w, d) }); err != nil {
+			fail(err)
+		}
+		logger.Info(evResultWritten, "path", f.out)
+	}
+
+	var md, html bytes.Buffer
+	writeDigestMarkdown(&md, d)
+	writeDigestHTML(&html, d)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if f.webhook != "" {
+		if err := postDigest(ctx, f.webhook, md.String()); err != nil {
+			fail(err)
+		}
+		logger.Info(evDigestSent, "via", "webhook", "runs", d.Runs, "failed", d.Failed)
+	}
+	if f.smtpAddr != "" {
+		var auth smtp.Auth
+		if f.smtpUser != "" {
+			pass, err := secretref.Resolve(f.smtpPass)
+			if err != nil {
+				fail(err)
+			}
+			host, _, _ := strings.Cut(f.smtpAddr, ":")
+			auth = smtp.PlainAuth("", f.smtpUser, pass, host)
+		}
+		rcpt := splitList(f.to)
+		msg, err := digestMail(f.from, rcpt, digestSubject(d), md.Bytes(), html.Bytes())
+		if err != nil {
+			fail(err)
+		}
+		if err := smtp.SendMail(f.smtpAddr, auth, f.from, rcpt, msg); err != nil {
+			fail(fmt.Errorf("digest: smtp %s: %w", f.smtpAddr, err))
+		}
+		logger.Info(evDigestSent, "via", "smtp", "to", f.to, "runs", d.Runs, "failed", d.Failed)
+	}
+}
+
+func buildDigest(all []history.Entry, group string, now time.Time, since, trend time.Duration, top int) *digest {
+	d := &digest{Since: now.Add(-since).UTC(), Until: now.UTC(), Benches: []digestBench{}, Regressions: []digestRegress{}}
+	benches := map[string]*digestBench{}
+	var recent []history.Entry
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Time.Before(all[j].Time) })
+	for _, e := range all {
+		name, ok := e.Labels[group]
+		if !ok || e.Time.Before(now.Add(-trend)) {
+			continue
+		}
+		b := benches[name]
+		if b == nil {
+			b = &digestBench{Name: name}
+			benches[name] = b
+		}
+		b.Trend = append(b.Trend, e.Result.P95ms)
+		if e.Time.Before(now.Add(-since)) {
+			continue
+		}
+		recent = append(recent, e)
+		b.Runs++
+		b.LastP95 = e.Result.P95ms
+		// 판정은 -envelope와 같은 기준(실패 run의 이유만 모은다)
+		if v, reasons := resultVerdict([]engine.Result{e.Result}, nil, nil); v.Failed() {
+			b.Failed++
+			for _, r := range reasons {
+				if !contains(b.Reasons, r) {
+					b.Reasons = append(b.Reasons, r)
+				}
+			}
+		} else {
+			b.Passed++
+		}
+	}
+	for _, b := range benches {
+		if b.Runs == 0 {
+			continue // 추세 기간에만 있는 bench(이번 기간에 실행 안 됨)
+		}
+		d.Benches = append(d.Benches, *b)
+		d.Runs += b.Runs
+		d.Passed += b.Passed
+		d.Failed += b.Failed
+	}
+	sort.Slice(d.Benches, func(i, j int) bool { return d.Benches[i].Name < d.Benches[j].Name })
+
+	// 회귀: 기간 내 run마다 같은 설정의 이전 run과 CUSUM 비교(regress와 같은 기준)
+	// bench/metric마다 가장 큰 변화 하나만
+	opt := history.DefaultShiftOptions
+	worst := map[[2]string]int{}
+	for _, cur := range recent {
+		prev := history.Comparable(all, cur)
+		for i, e := range prev {
+			if !e.Time.Before(cur.Time) {
+				prev = prev[:i]
+				break
+			}
+		}
+		for _, s := range history.DetectShifts(prev, cur, opt) {
+			if !s.Regressed {
+				continue
+			}
+			r := digestRegress{Bench: cur.Labels[group], RunID: cur.RunID, Metric: s.Metric, Current: s.Current, Median: s.Median}
+			if s.Median != 0 {
+				r.ChangePct = stats.Round2((s.Current - s.Median) / math.Abs(s.Median) * 100)
+			}
+			k := [2]string{r.Bench, r.Metric}
+			if i, ok := worst[k]; !ok {
+				worst[k] = len(d.Regressions)
+				d.Regressions = append(d.Regressions, r)
+			} else if r.ChangePct >= d.Regressions[i].ChangePct {
+				d.Regressions[i] = r
+			}
+		}
+	}
+	sort.SliceStable(d.Regressions, func(i, j int) bool { return d.Regressions[i].ChangePct > d.Regressions[j].ChangePct })
+	if top >= 0 && len(d.Regressions) > top {
+		d.Regressions = d.Regressions[:top]
+	}
+	return d
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func digestSubject(d *digest) string {
+	state := tr("all passed")
+	if d.Failed > 0 {
+		state = tr("%d failed", d.Failed)
+	}
+	return tr("trace_bench digest %s: %d runs, %s, %d regressions", d.Until.Format("2006-01-02"), d.Runs, state, len(d.Regressions))
+}
+
+// sparkline은 markdown용 유니코드 추세선
+func sparkline(vs []float64) string {
+	const ticks = "▁▂▃▄▅▆▇█"
+	if len(vs) == 0 {
+		return ""
+	}
+	lo, hi := vs[0], vs[0]
+	for _, v := range vs {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	r := []rune(ticks)
+	var sb strings.Builder
+	for _, v := range vs {
+		i := 0
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(r)-1))
+		}
+		sb.WriteRune(r[i])
+	}
+	return sb.String()
+}
+
+func writeDigestMarkdown(w io.Writer, d *digest) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", digestSubject(d))
+	fmt.Fprintf(&b, "%s – %s · **%d** %s · **%d** %s\n\n", d.Since.Format(time.RFC3339), d.Until.Format(time.RFC3339), d.Passed, tr("passed"), d.Failed, tr("failed"))
+	if len(d.Benches) == 0 {
+		b.WriteString(tr("No scheduled runs in this period.") + "\n")
+	} else {
+		fmt.Fprintf(&b, "| bench | %s | %s | %s | p95 ms | %s | %s |\n|---|---:|---:|---:|---:|---|---|\n", tr("runs"), tr("passed"), tr("failed"), tr("trend"), tr("failures"))
+		for _, x := range d.Benches {
+			fmt.Fprintf(&b, "| %s | %d | %d | %d | %g | %s | %s |\n", x.Name, x.Runs, x.Passed, x.Failed, x.LastP95, sparkline(x.Trend), strings.Join(x.Reasons, ", "))
+		}
+	}
+	if len(d.Regressions) > 0 {
+		fmt.Fprintf(&b, "\n## %s\n\n| bench | %s | %s | %s | %s | run |\n|---|---|---:|---:|---:|---|\n", tr("Top regressions"), tr("metric"), tr("current"), tr("median"), tr("change"))
+		for _, r := range d.Regressions {
+			fmt.Fprintf(&b, "| %s | %s | %g | %g | %+.1f%% | %s |\n", r.Bench, r.Metric, r.Current, r.Median, r.ChangePct, r.RunID)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var digestTmpl = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"chart": trendSVG,
+	"rfc":   func(t time.Time) string { return t.Format(time.RFC3339) },
+	"tr":    func(s string) string { return tr(s) },
+}).Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}"><head><meta charset="utf-8"><title>{{.Subject}}</title>
+<style>body{font-family:sans-serif}table{border-collapse:collapse}td,th{border:1px solid #ccc;padding:4px 8px;text-align:right}td:first-child,th:first-child{text-align:left}.fail{color:#c00}</style>
+</head><body>
+<h1>{{.Subject}}</h1>
+<p>{{rfc .D.Since}} – {{rfc .D.Until}} · <b>{{.D.Passed}}</b> {{tr "passed"}} · <b{{if .D.Failed}} class="fail"{{end}}>{{.D.Failed}}</b> {{tr "failed"}}</p>
+{{if .D.Benches}}<table><tr><th>bench</th><th>{{tr "runs"}}</th><th>{{tr "passed"}}</th><th>{{tr "failed"}}</th><th>p95 ms</th><th>{{tr "p95 trend"}}</th><th>{{tr "failures"}}</th></tr>
+{{range .D.Benches}}<tr><td>{{.Name}}</td><td>{{.Runs}}</td><td>{{.Passed}}</td><td{{if .Failed}} class="fail"{{end}}>{{.Failed}}</td><td>{{.LastP95}}</td><td>{{chart .Trend}}</td><td>{{range $i, $r := .Reasons}}{{if $i}}, {{end}}{{$r}}{{end}}</td></tr>
+{{end}}</table>{{else}}<p>{{tr "No scheduled runs in this period."}}</p>{{end}}
+{{if .D.Regressions}}<h2>{{tr "Top regressions"}}</h2>
+<table><tr><th>bench</th><th>{{tr "metric"}}</th><th>{{tr "current"}}</th><th>{{tr "median"}}</th><th>{{tr "change %"}}</th><th>run</th></tr>
+{{range .D.Regressions}}<tr><td>{{.Bench}}</td><td>{{.Metric}}</td><td>{{.Current}}</td><td>{{.Median}}</td><td class="fail">{{printf "%+.1f" .ChangePct}}</td><td>{{.RunID}}</td></tr>
+{{end}}</table>{{end}}
+</body></html>
+`))
+
+func writeDigestHTML(w io.Writer, d *digest) error {
+	return digestTmpl.Execute(w, struct {
+		Subject string
+		Lang    string
+		D       *digest
+	}{digestSubject(d), msgLang, d})
+}
+
+// trendSVG는 run별 p95 꺾은선(인라인 SVG, 메일 클라이언트에서도 보이게 외부 리소스 없음)
+func trendSVG(vs []float64) template.HTML {
+	const w, h = 160.0, 32.0
+	if len(vs) < 2 {
+		return ""
+	}
+	lo, hi := vs[0], vs[0]
+	for _, v := range vs {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	var pts []string
+	for i, v := range vs {
+		y := h / 2
+		if hi > lo {
+			y = h - 2 - (v-lo)/(hi-lo)*(h-4)
+		}
+		pts = append(pts, fmt.Sprintf("%.1f,%.1f", float64(i)/float64(len(vs)-1)*w, y))
+	}
+	return template.HTML(fmt.Sprintf(`<svg width="%g" height="%g" viewBox="0 0 %g %g"><polyline fill="none" stroke="#36c" stroke-width="1.5" points="%s"/></svg>`,
+		w, h, w, h, strings.Join(pts, " ")))
+}
+
+// postDigest는 incoming webhook 형식({"text": markdown})으로 보낸다
+func postDigest(ctx context.Context, url, md string) error {
+	body, err := json.Marshal(map[string]string{"text": md})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("digest: webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("digest: webhook: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// digestMail은 markdown(text/plain)과 HTML을 담은 multipart/alternative 메일
+func digestMail(from string, to []string, subject string, md, html []byte) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		ctype string
+		data  []byte
+	}{{"text/plain; charset=utf-8", md}, {"text/html; charset=utf-8", html}} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {part.ctype}, "Content-Transfer-Encoding": {"8bit"}})
+		if err != nil {
+			return nil, err
+		}
+		pw.Write(part.data)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: multipart/alternative; boundary=%s\r\n\r\n",
+		from, strings.Join(to, ", "), mime.QEncoding.Encode("utf-8", subject), time.Now().Format(time.RFC1123Z), mw.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
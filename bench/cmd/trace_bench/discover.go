@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/internal/dockerapi"
+)
+
+type dockerInspect struct {
+	State struct {
+		Status string `json:"Status"`
+		Health *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+	HostConfig struct {
+		NanoCPUs   int64  `json:"NanoCpus"`
+		CPUQuota   int64  `json:"CpuQuota"`
+		CPUPeriod  int64  `json:"CpuPeriod"`
+		CpusetCpus string `json:"CpusetCpus"`
+		Memory     int64  `json:"Memory"`
+	} `json:"HostConfig"`
+}
+
+// limits는 inspect의 HostConfig 제한(--cpus는 NanoCpus, --cpu-quota는 CpuQuota/CpuPeriod)
+func (ins dockerInspect) limits() *engine.CgroupLimits {
+	hc := ins.HostConfig
+	l := &engine.CgroupLimits{CPUSet: hc.CpusetCpus, MemoryBytes: hc.Memory}
+	switch {
+	case hc.NanoCPUs > 0:
+		l.CPUs = float64(hc.NanoCPUs) / 1e9
+	case hc.CPUQuota > 0:
+		period := hc.CPUPeriod
+		if period == 0 {
+			period = 100000 // CFS 기본 period(us)
+		}
+		l.CPUs = float64(hc.CPUQuota) / float64(period)
+	}
+	return l
+}
+
+// compose project/service 라벨로 컨테이너를 찾아 공개 포트와 헬스 상태를 확인
+// privatePort가 0이면 첫 번째 공개 TCP 포트를 사용
+func discoverTarget(ctx context.Context, project, service string, privatePort int) (*engine.Target, error) {
+	c, err := dockerapi.New()
+	if err != nil {
+		return nil, err
+	}
+	list, err := c.List(ctx, dockerapi.LabelProject+"="+project, dockerapi.LabelService+"="+service)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("no running container for compose project=%s service=%s", project, service)
+	}
+	ctr := list[0]
+
+	var port *dockerapi.Port
+	for i := range ctr.Ports {
+		p := &ctr.Ports[i]
+		if p.PublicPort == 0 || p.Type != "tcp" {
+			continue
+		}
+		if privatePort == 0 || p.PrivatePort == privatePort {
+			port = p
+			break
+		}
+	}
+	if port == nil {
+		return nil, fmt.Errorf("container %s has no published tcp port (service-port=%d)", ctr.ID[:12], privatePort)
+	}
+	ip := port.IP
+	if ip == "" || ip == "0.0.0.0" || ip == "::" {
+		ip = "127.0.0.1"
+	}
+
+	var ins dockerInspect
+	if err := c.Get(ctx, "/containers/"+ctr.ID+"/json", &ins); err != nil {
+		return nil, err
+	}
+	health := "none" // healthcheck 미정의
+	if ins.State.Health != nil {
+		health = ins.State.Health.Status
+	}
+	if ins.State.Status != "running" {
+		return nil, fmt.Errorf("target container %s is %s", ctr.ID[:12], ins.State.Status)
+	}
+	if health != "healthy" && health != "none" {
+		return nil, fmt.Errorf("target container %s health=%s", ctr.ID[:12], health)
+	}
+
+	name := ctr.ID[:12]
+	if len(ctr.Names) > 0 {
+		name = strings.TrimPrefix(ctr.Names[0], "/")
+	}
+	return &engine.Target{
+		Project:   project,
+		Service:   service,
+		Container: name,
+		Address:   net.JoinHostPort(ip, fmt.Sprint(port.PublicPort)),
+		Health:    health,
+		Limits:    ins.limits(),
+	}, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/benchadapter"
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/history"
+	"github.com/duri/trace_bench/internal/yamlite"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/profile"
+)
+
+// defaultProfiles는 -profiles 기본값(TRACE_BENCH_PROFILES, 없으면 profiles.yaml)
+func defaultProfiles() string {
+	if p := os.Getenv("TRACE_BENCH_PROFILES"); p != "" {
+		return p
+	}
+	return "profiles.yaml"
+}
+
+// applyProfile은 명시하지 않은 플래그만 profile 값으로 채운다(명시한 플래그가 우선)
+func applyProfile(env string, p profile.Profile, labels labelFlag) error {
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for name, v := range map[string]string{
+		"mode":            p.Mode,
+		"workload":        p.Workload,
+		"endpoint":        p.Endpoint,
+		"compose-project": p.ComposeProject,
+		"service":         p.Service,
+		"tls-ca":          p.TLS.CA,
+		"tls-cert":        p.TLS.Cert,
+		"tls-key":         p.TLS.Key,
+		"tls-server-name": p.TLS.ServerName,
+		"health-url":      strings.Join(p.Health, ","),
+		"script":          p.Script,
+	} {
+		if v != "" && !set[name] {
+			if err := flag.Set(name, v); err != nil {
+				return err
+			}
+		}
+	}
+	if _, ok := labels["env"]; !ok {
+		labels["env"] = env
+	}
+	for k, v := range p.Labels {
+		if _, ok := labels[k]; !ok {
+			if err := labels.Set(k + "=" + v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setStrict는 -strict: 이후 읽는 모든 설정 파일에 모르는 키를 거부하고 환경변수를 확인
+func setStrict() error {
+	yamlite.Strict = true
+	return checkEnv(os.Environ())
+}
+
+// knownEnv는 trace_bench가 읽거나 hook/chaos/collector/adapter에 넘기는 TRACE_BENCH_ 변수.
+// 넘긴 변수도 포함해야 hook 안에서 다시 실행한 trace_bench -strict가 통과한다
+func knownEnv() (names map[string]bool, prefixes []string) {
+	names = map[string]bool{}
+	for _, n := range []string{
+		history.EnvPath, "TRACE_BENCH_PROFILES", "TRACE_BENCH_LOCK_DIR", logLevelEnv, logFormatEnv, benchadapter.OutDirEnv,
+		"TRACE_BENCH_HOOK", "TRACE_BENCH_RUN_ID", "TRACE_BENCH_ENDPOINT", "TRACE_BENCH_STATUS",
+		"TRACE_BENCH_TARGET_ADDRESS", "TRACE_BENCH_TARGET_CONTAINER", "TRACE_BENCH_CHAOS", "TRACE_BENCH_COLLECTOR",
+		// Day11-15 러너(tools/run_trace_bench.sh, run_trace_sweep_v2_real.py)가 export해 자식 trace_bench가 물려받는다
+		"TRACE_BENCH_CMD", "TRACE_BENCH_TIMEOUT",
+	} {
+		names[n] = true
+	}
+	// hook의 TRACE_BENCH_<SAMPLING|MODE|...>: 조건부 라벨까지 모두 나오는 설정으로 구한다
+	for k := range output.ConfigLabels(engine.Config{Workload: engine.WorkloadHTTP, Protocol: engine.ProtoH1, BatchTimeout: time.Second, Backpressure: engine.BackpressureBlock}) {
+		names["TRACE_BENCH_"+envName(k)] = true
+	}
+	return names, []string{"TRACE_BENCH_LABEL_", "TRACE_BENCH_METRIC_"}
+}
+
+// checkEnv는 -strict에서 모르는 TRACE_BENCH_ 변수(오타는 조용히 기본값이 된다)를 거부한다
+func checkEnv(environ []string) error {
+	names, prefixes := knownEnv()
+	var unknown []string
+next:
+	for _, kv := range environ {
+		k, _, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(k, "TRACE_BENCH_") || names[k] {
+			continue
+		}
+		for _, p := range prefixes {
+			if strings.HasPrefix(k, p) {
+				continue next
+			}
+		}
+		if s := closest(k, names); s != "" {
+			k += " (did you mean " + s + "?)"
+		}
+		unknown = append(unknown, k)
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("-strict: unknown environment variable %s", strings.Join(unknown, ", "))
+}
+
+// closest는 편집 거리 2 이내에서 가장 가까운 이름(없으면 "")
+func closest(s string, names map[string]bool) string {
+	best, bestD := "", 3
+	for n := range names {
+		if d := editDistance(s, n); d < bestD || d == bestD && n < best {
+			best, bestD = n, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// runnerScripts는 trace_bench를 실행하는 저장소의 러너(환경변수를 자식에 물려준다)
+var runnerScripts = []string{
+	"../../../DuRi_Day11_15_starter/tools/run_trace_bench.sh",
+	"../../../DuRi_Day11_15_starter/tools/run_trace_sweep_v2_real.py",
+	"../../../DuRi_Day11_15_starter/ci/ensure_real_bench.sh",
+}
+
+// TestStrictRunnerEnv는 러너가 쓰는 TRACE_BENCH_ 변수를 모두 설정한 환경을 -strict로 확인한다
+func TestStrictRunnerEnv(t *testing.T) {
+	ref := regexp.MustCompile(`\bTRACE_BENCH_[A-Z_]+\b`)
+	env := os.Environ()
+	seen := map[string]bool{}
+	for _, p := range runnerScripts {
+		b, err := os.ReadFile(filepath.FromSlash(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range ref.FindAllString(string(b), -1) {
+			// TRACE_BENCH_OK:는 -self-check 출력이지 변수가 아니다
+			if name != "TRACE_BENCH_OK" && !seen[name] {
+				seen[name] = true
+				env = append(env, name+"=x")
+			}
+		}
+	}
+	for _, want := range []string{"TRACE_BENCH_CMD", "TRACE_BENCH_TIMEOUT"} {
+		if !seen[want] {
+			t.Errorf("%s no longer referenced by the runners; update runnerScripts", want)
+		}
+	}
+	if err := checkEnv(env); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkEnv([]string{"TRACE_BENCH_TIMEOTU=5"}); err == nil {
+		t.Error("-strict accepted a misspelled variable")
+	}
+}
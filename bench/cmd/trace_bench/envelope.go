@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// startedAt는 프로세스 시작 시각(봉투의 started_at)
+var startedAt = time.Now().UTC()
+
+// envelopePath는 -envelope. 결과 없이 끝나는 경로(fail, 거부, 잠금)도 봉투를 남기도록 전역
+var envelopePath string
+
+func newEnvelope() *resultenv.Envelope {
+	e := resultenv.New("trace_bench").Flags(flag.CommandLine)
+	e.StartedAt = startedAt
+	e.Deprecations = runDeprecations
+	return e
+}
+
+// runEnvelope의 regressed는 -history 회귀 metric
+func runEnvelope(r engine.Result, regressed []string) *resultenv.Envelope {
+	e := newEnvelope()
+	e.Metrics = r.Metrics()
+	for k, v := range r.CustomMetrics {
+		e.Metrics["custom."+k] = v
+	}
+	v, reasons := resultVerdict([]engine.Result{r}, regressed, e.Deprecations)
+	return e.Finish(v, r, reasons...)
+}
+
+// sweep은 metric 이름 앞에 프로토콜을 붙인다(h2.p95_ms). regressed도 같은 이름
+func sweepEnvelope(values []string, rs []engine.Result, regressed []string) *resultenv.Envelope {
+	e := newEnvelope()
+	for i, r := range rs {
+		for k, v := range r.Metrics() {
+			e.Metrics[values[i]+"."+k] = v
+		}
+	}
+	v, reasons := resultVerdict(rs, regressed, e.Deprecations)
+	return e.Finish(v, rs, reasons...)
+}
+
+// resultVerdict는 측정을 마친 run의 판정과 이유(봉투와 digest가 공유).
+// SLO 위반·회귀·model drift는 fail_slo, 실행 후 target 이상은 fail_infra,
+// CPU throttling·deprecated 입력은 pass_with_warnings
+func resultVerdict(rs []engine.Result, regressed []string, deps []resultenv.Deprecation) (resultenv.Verdict, []string) {
+	var failures, infra, warnings []string
+	add := func(list *[]string, reason string) {
+		if !contains(*list, reason) {
+			*list = append(*list, reason)
+		}
+	}
+	for _, r := range rs {
+		for _, c := range r.SLO {
+			if !c.Pass {
+				add(&failures, "slo:"+c.Name)
+			}
+		}
+		if r.ModelDrift {
+			add(&failures, "model_drift")
+		}
+		if r.TargetDegradedPost {
+			add(&infra, "target_degraded")
+		}
+		if t := r.Throttle; t != nil && t.ThrottledPeriods > 0 {
+			add(&warnings, "cpu_throttled")
+		}
+	}
+	for _, m := range regressed {
+		add(&failures, "regress:"+m)
+	}
+	for _, d := range deps {
+		add(&warnings, "deprecated:"+d.Name)
+	}
+	v := resultenv.Judge(failures, warnings)
+	if len(infra) > 0 {
+		v = resultenv.Worst(v, resultenv.VerdictFailInfra)
+	}
+	return v, append(append(failures, infra...), warnings...)
+}
+
+func writeEnvelope(path string, e *resultenv.Envelope) error {
+	if err := output.WriteFileAtomicFunc(path, func(w io.Writer) error { return resultenv.Encode(w, e) }); err != nil {
+		return err
+	}
+	logger.Info(evEnvelopeWritten, "verdict", e.Verdict, "path", path)
+	return nil
+}
+
+// exitEnvelope는 결과 없이 끝나는 run(오류, 비정상 target, hook 거부, 잠금)의
+// 봉투를 -envelope가 있을 때 쓴다. 실패해도 종료 코드는 호출측 그대로
+func exitEnvelope(v resultenv.Verdict, evidence any, reasons ...string) {
+	path := envelopePath
+	if path == "" {
+		return
+	}
+	envelopePath = "" // 쓰기 실패로 fail이 다시 부르지 않도록
+	if err := writeEnvelope(path, newEnvelope().Finish(v, evidence, reasons...)); err != nil {
+		logger.Error(evRunFailed, "err", err.Error())
+	}
+}
+
+// exitVerdict는 trace_bench 종료 코드의 판정(schedule처럼 하위 프로세스로 돌릴 때)
+func exitVerdict(code int) resultenv.Verdict {
+	switch code {
+	case 0:
+		return resultenv.VerdictPass
+	case 2:
+		return resultenv.VerdictFailSLO
+	case exitVetoed, exitLocked:
+		return resultenv.VerdictSkipped
+	}
+	return resultenv.VerdictFailInfra
+}
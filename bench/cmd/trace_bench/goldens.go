@@ -0,0 +1,218 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/cost"
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/internal/golden"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/buildinfo"
+	"github.com/duri/trace_bench/pkg/resultenv"
+	"github.com/duri/trace_bench/slo"
+	"github.com/duri/trace_bench/stats"
+)
+
+// goldenDir는 bench 모듈 루트 기준 golden 파일 위치(go test에서는 testdata/golden)
+var goldenDir = filepath.Join("cmd", "trace_bench", "testdata", "golden")
+
+// goldenTime은 golden 출력에 찍히는 모든 시각(실행마다 달라지지 않게)
+var goldenTime = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// goldensFlags는 goldens 명령의 플래그 값
+type goldensFlags struct {
+	dir string
+}
+
+func (f *goldensFlags) define(fs *flag.FlagSet) {
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: trace_bench goldens check|update [-dir dir]")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&f.dir, "dir", goldenDir, "golden file directory (relative paths from the bench module root)")
+}
+
+// goldens 모드: 하위 게이트가 읽는 출력 형식을 고정 입력으로 렌더링해 golden 파일과 비교/갱신
+func runGoldens(args []string) {
+	var f goldensFlags
+	fs := flag.NewFlagSet("goldens", flag.ExitOnError)
+	f.define(fs)
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	action := args[0]
+	fs.Parse(args[1:])
+
+	switch action {
+	case "check":
+		ms, err := golden.Check(f.dir, goldenCases())
+		if err != nil {
+			fail(err)
+		}
+		for _, m := range ms {
+			fmt.Println(m)
+		}
+		if len(ms) > 0 {
+			fmt.Fprintf(os.Stderr, "%d of %d golden outputs drifted; run trace_bench goldens update if the format change is intended\n", len(ms), len(goldenCases()))
+			os.Exit(2)
+		}
+	case "update":
+		changed, err := golden.Update(f.dir, goldenCases())
+		if err != nil {
+			fail(err)
+		}
+		for _, name := range changed {
+			fmt.Println(filepath.Join(f.dir, name))
+		}
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+// goldenCases는 형식별 golden 출력. 입력은 고정값이고 시각은 goldenTime으로 묶는다
+func goldenCases() []golden.Case {
+	cfg, r := goldenResult()
+	sweepCfgs, sweep := goldenSweep()
+	// 시각은 goldenTime으로, benchstat의 실행 환경(goos/goarch/GOMAXPROCS)은 자리표시자로 바꾼다
+	host := strings.NewReplacer("goos: "+runtime.GOOS+"\n", "goos: GOOS\n", "goarch: "+runtime.GOARCH+"\n", "goarch: GOARCH\n",
+		fmt.Sprintf("-%d\t1\t", runtime.GOMAXPROCS(0)), "-GOMAXPROCS\t1\t")
+	at := func(write func(io.Writer) error) func(io.Writer) error {
+		return func(w io.Writer) error {
+			defer func(now func() time.Time) { output.Now = now }(output.Now)
+			output.Now = func() time.Time { return goldenTime }
+			var b strings.Builder
+			if err := write(&b); err != nil {
+				return err
+			}
+			_, err := host.WriteString(w, b.String())
+			return err
+		}
+	}
+	lang := func(l string, write func(io.Writer, *digest) error) func(io.Writer) error {
+		return func(w io.Writer) error {
+			defer func(prev string) { msgLang = prev }(msgLang)
+			msgLang = l
+			return write(w, goldenDigest())
+		}
+	}
+	var cases []golden.Case
+	for _, f := range output.Formats {
+		f, ext := f, "."+f+".txt"
+		if f == output.FormatJSON {
+			ext = ".json"
+		}
+		cases = append(cases,
+			golden.Case{Name: "result" + ext, Render: at(func(w io.Writer) error { return output.Write(w, f, cfg, r) })},
+			golden.Case{Name: "sweep" + ext, Render: at(func(w io.Writer) error {
+				return output.WriteSweep(w, f, "protocol", sweepCfgs, sweep)
+			})})
+	}
+	return append(cases,
+		golden.Case{Name: "envelope.json", Render: func(w io.Writer) error { return resultenv.Encode(w, goldenEnvelope(r, nil)) }},
+		golden.Case{Name: "envelope.fail.json", Render: func(w io.Writer) error {
+			return resultenv.Encode(w, goldenEnvelope(r, []string{"p95_ms"}))
+		}},
+		golden.Case{Name: "sweep.batch.benchstat.txt", Render: at(func(w io.Writer) error {
+			c, rs := goldenBatchSweep()
+			return output.WriteSweep(w, output.FormatBenchstat, "batch_size,batch_timeout", c, rs)
+		})},
+		golden.Case{Name: "table.txt", Render: func(w io.Writer) error {
+			return output.WriteTable(w, output.SweepLabels("protocol", sweepCfgs), sweep)
+		}},
+		golden.Case{Name: "heatmap.csv", Render: func(w io.Writer) error { return output.WriteHeatmapCSV(w, r.Heatmap) }},
+		golden.Case{Name: "digest.md", Render: lang(langEN, writeDigestMarkdown)},
+		golden.Case{Name: "digest.ko.md", Render: lang(langKO, writeDigestMarkdown)},
+		golden.Case{Name: "digest.html", Render: lang(langEN, writeDigestHTML)},
+	)
+}
+
+// goldenResult는 선택 필드를 고루 채운 real 모드 http 결과
+func goldenResult() (engine.Config, engine.Result) {
+	cfg := engine.Config{Mode: engine.ModeReal, Sampling: 0.5, Serialization: "protobuf", Compression: "gzip",
+		Workload: engine.WorkloadHTTP, Protocol: engine.ProtoH2}
+	h := stats.NewHeatmap(time.Second, []float64{5, 10, 25})
+	for i, d := range []time.Duration{3, 7, 12, 30, 8} {
+		h.Record(goldenTime.Add(time.Duration(i/3)*time.Second), d*time.Millisecond)
+	}
+	r := engine.Result{
+		P95ms: 12.5, ErrorRate: 0.002, SizeKB: 48.25, P99ms: 20.1,
+		Protocol: engine.ProtoH2, RunID: "00000000-0000-4000-8000-000000000001", QuantileSketch: stats.SketchExact,
+		Mem:           &engine.MemStats{AllocsPerOp: 12, BytesPerOp: 4096, GCPauseMs: 0.3, NumGC: 2},
+		Volume:        &engine.VolumeStats{Spans: 20000, Exported: 10000, Bytes: 4940800, CPUSeconds: 1.25, DurationS: 10, Batches: 100, BytesPerSpanSD: 3.5},
+		Cost:          &cost.Estimate{Currency: "USD", SpansPerDay: 5e7, StoredGB: 690.1, EgressGB: 690.1, CoreHours: 52.08, Storage: 15.87, Egress: 62.11, CPU: 2.08, Monthly: 80.06},
+		Heatmap:       h,
+		Errors:        map[string]int{"timeout": 1, "http_5xx": 1},
+		StatusCodes:   map[string]int{"200": 98, "503": 1},
+		Conn:          &engine.ConnStats{Mode: "keep-alive", NewConnections: 1, Reused: 99},
+		TLS:           &engine.TLSStats{Handshakes: 1, HandshakeP95ms: 4.2},
+		SLO:           []slo.Check{{Name: "export-latency", Metric: "p95_ms", Value: 12.5, Threshold: 50, Pass: true}},
+		Labels:        map[string]string{"env": "ci", "branch": "main"},
+		CustomMetrics: map[string]float64{"collector.rss_mb": 52.5},
+	}
+	return cfg, r
+}
+
+// goldenSweep는 비용이 붙은 -protocols h1,h2 sweep
+func goldenSweep() ([]engine.Config, []engine.Result) {
+	var cfgs []engine.Config
+	var rs []engine.Result
+	for i, p := range []string{engine.ProtoH1, engine.ProtoH2} {
+		c := engine.Config{Mode: engine.ModeReal, Sampling: 1, Serialization: "json", Compression: "none",
+			Workload: engine.WorkloadHTTP, Protocol: p}
+		cfgs = append(cfgs, c)
+		rs = append(rs, engine.Result{P95ms: 18.5 - float64(i)*4, ErrorRate: 0.001, SizeKB: 96, P99ms: 30 - float64(i)*6,
+			Protocol: p, RunID: fmt.Sprintf("00000000-0000-4000-8000-00000000001%d", i),
+			Conn: &engine.ConnStats{Mode: "keep-alive", NewConnections: 2 - i, Reused: 98 + i},
+			Cost: &cost.Estimate{Currency: "USD", SpansPerDay: 5e7, Monthly: 120.5 - float64(i)*30}})
+	}
+	return cfgs, rs
+}
+
+// goldenBatchSweep는 -batch-size 100,500 -batch-timeout 1s sweep
+func goldenBatchSweep() ([]engine.Config, []engine.Result) {
+	var cfgs []engine.Config
+	var rs []engine.Result
+	for i, size := range []int{100, 500} {
+		c := engine.Config{Mode: engine.ModeReal, Sampling: 1, Serialization: "protobuf", Compression: "gzip",
+			Workload: engine.WorkloadPipeline, BatchSize: size, BatchTimeout: time.Second}
+		cfgs = append(cfgs, c)
+		rs = append(rs, engine.Result{P95ms: 2.5 + float64(i), ErrorRate: 0, SizeKB: 12 + float64(i)*40,
+			RunID:    fmt.Sprintf("00000000-0000-4000-8000-00000000002%d", i),
+			Batching: &engine.BatchStats{BatchSize: size, Batches: 50 - i*40, SpansPerSec: 9000 + float64(i)*500}})
+	}
+	return cfgs, rs
+}
+
+// goldenEnvelope는 빌드 정보, 시작 시각, 플래그를 고정한 runEnvelope
+func goldenEnvelope(r engine.Result, regressed []string) *resultenv.Envelope {
+	e := runEnvelope(r, regressed)
+	e.Version = "v1.2.0"
+	e.Build = buildinfo.Info{Version: "v1.2.0", Revision: "0123456789abcdef0123456789abcdef01234567", Module: "github.com/duri/trace_bench", GoVersion: "go1.21.0"}
+	e.StartedAt = goldenTime
+	e.Params = map[string]string{"mode": "real", "workload": "http", "sampling": "0.5"}
+	e.Deprecations = []resultenv.Deprecation{{Kind: "flag", Name: "-ser", Replacement: "-serialization",
+		Message: "-ser is deprecated (short form used by the Day20/21 runner scripts); use -serialization"}}
+	e.Verdict, e.Reasons = resultVerdict([]engine.Result{r}, regressed, e.Deprecations)
+	return e
+}
+
+// goldenDigest는 실패와 회귀가 있는 하루치 요약
+func goldenDigest() *digest {
+	return &digest{
+		Since: goldenTime.Add(-24 * time.Hour), Until: goldenTime, Runs: 5, Passed: 4, Failed: 1,
+		Benches: []digestBench{
+			{Name: "nightly-h2", Runs: 3, Passed: 2, Failed: 1, Reasons: []string{"slo:export-latency"}, LastP95: 14.5, Trend: []float64{11, 12.5, 14.5}},
+			{Name: "pipeline", Runs: 2, Passed: 2, LastP95: 0.31, Trend: []float64{0.3, 0.31}},
+		},
+		Regressions: []digestRegress{{Bench: "nightly-h2", RunID: "00000000-0000-4000-8000-000000000002", Metric: "p95_ms", Current: 14.5, Median: 11.75, ChangePct: 23.4}},
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/duri/trace_bench/internal/golden"
+)
+
+// 출력 형식이 바뀌면 실패한다. 의도한 변경이면 bench/에서 trace_bench goldens update
+func TestGoldens(t *testing.T) {
+	ms, err := golden.Check(filepath.Join("testdata", "golden"), goldenCases())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range ms {
+		t.Error(m)
+	}
+	if len(ms) > 0 {
+		t.Log("if the format change is intended, run `go run ./cmd/trace_bench goldens update` from bench/")
+	}
+}
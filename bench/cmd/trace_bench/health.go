@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// 대상 검증 실패 종료 코드(1: 실행 오류, 2: SLO 위반과 구분)
+const (
+	exitUnhealthy     = 3 // preflight에서 대상이 비정상
+	exitBuildMismatch = 4 // -expected-sha와 대상 build가 다름
+)
+
+// resolveTargetURL은 /path를 발견된 대상 주소 기준으로 푼다
+func resolveTargetURL(flagName, u string, t *engine.Target) string {
+	if !strings.HasPrefix(u, "/") {
+		return u
+	}
+	if t == nil {
+		fail(fmt.Errorf("-%s %s: a relative path requires -compose-project and -service", flagName, u))
+	}
+	return "http://" + t.Address + u
+}
+
+// probeBuild는 -target-buildinfo를 조회해 기록하고, -expected-sha와 다르면 실행 전에 종료한다
+func probeBuild(url, expected string, timeout time.Duration, o engine.TLSOptions) *engine.BuildInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*timeout)
+	defer cancel()
+	b, err := engine.ProbeBuildInfo(ctx, url, timeout, o)
+	if err != nil {
+		fail(err)
+	}
+	if expected != "" && !b.Check(expected) {
+		logger.Error(evTargetBuild, "url", url, "version", b.Version, "sha", b.SHA, "expected_sha", expected, "match", false)
+		self.finish(nil, "", fmt.Errorf("target build %s does not match -expected-sha %s", b.SHA, expected))
+		exitEnvelope(resultenv.VerdictFailInfra, b, "build_mismatch")
+		os.Exit(exitBuildMismatch)
+	}
+	logger.Info(evTargetBuild, "url", url, "version", b.Version, "sha", b.SHA, "expected_sha", expected)
+	return b
+}
+
+// checkHealth는 -health-url을 모두 확인하고 실패한 것을 target.unhealthy로 남긴다
+func checkHealth(phase string, urls []string, timeout time.Duration, o engine.TLSOptions) []engine.HealthCheck {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(len(urls)+1)*timeout)
+	defer cancel()
+	cs, err := engine.CheckHealth(ctx, urls, timeout, o)
+	if err != nil {
+		fail(err)
+	}
+	for _, c := range cs {
+		if !c.Healthy {
+			lvl := logger.Warn
+			if phase == "pre" {
+				lvl = logger.Error
+			}
+			lvl(evTargetUnhealthy, "phase", phase, "url", c.URL, "status", c.Status, "err", c.Error)
+		}
+	}
+	return cs
+}
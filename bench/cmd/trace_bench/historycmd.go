@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/duri/trace_bench/history"
+	"github.com/duri/trace_bench/output"
+)
+
+// historyFlags는 history 명령의 플래그 값
+type historyFlags struct {
+	histPath string
+	filter   labelFlag
+	limit    int
+	asJSON   bool
+}
+
+func (f *historyFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&f.histPath, "history", historyPath(), "history DB path")
+	f.filter = labelFlag{}
+	fs.Var(f.filter, "filter", "only runs with this key=value label (repeatable, e.g. -filter env=staging)")
+	fs.IntVar(&f.limit, "limit", 0, "show only the last N matching runs")
+	fs.BoolVar(&f.asJSON, "json", false, "print entries as JSON lines")
+}
+
+// history 모드: 기록된 run 목록(-filter로 환경/브랜치별 분리)
+func runHistory(args []string) {
+	var f historyFlags
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	f.define(fs)
+	fs.Parse(args)
+
+	db, err := history.Open(f.histPath)
+	if err != nil {
+		fail(err)
+	}
+	all, err := db.Entries()
+	if err != nil {
+		fail(err)
+	}
+	es := history.Filter(all, f.filter)
+	if f.limit > 0 && len(es) > f.limit {
+		es = es[len(es)-f.limit:]
+	}
+	if f.asJSON {
+		for _, e := range es {
+			output.WriteJSON(os.Stdout, e)
+		}
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN_ID\tTIME\tP95_MS\tERROR_RATE\tTAGS\tLABELS")
+	for _, e := range es {
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%v\t%s\t%s\n", e.RunID, e.Time.Format(time.RFC3339),
+			e.Result.P95ms, e.Result.ErrorRate, strings.Join(e.Tags, ","), fmtLabelSet(e.Labels))
+	}
+	tw.Flush()
+}
+
+func fmtLabelSet(l map[string]string) string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + l[k]
+	}
+	return strings.Join(parts, ",")
+}
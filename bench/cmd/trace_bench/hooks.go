@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/resultenv"
+	"github.com/duri/trace_bench/stats"
+)
+
+// exitVetoed: -pre-hook/-post-hook이 0이 아닌 코드로 끝나 run을 거부(결과는 기록하지 않음)
+const exitVetoed = 5
+
+// -hook-timeout 기본값
+const defaultHookTimeout = 5 * time.Minute
+
+// hookEnv는 hook에 넘기는 run 메타데이터. post에는 측정 상태와 지표가 더해진다
+//
+//	TRACE_BENCH_HOOK=pre|post, TRACE_BENCH_RUN_ID, TRACE_BENCH_ENDPOINT,
+//	TRACE_BENCH_<SAMPLING|SERIALIZATION|COMPRESSION|MODE|WORKLOAD|PROTOCOL>,
+//	TRACE_BENCH_LABEL_<KEY>, TRACE_BENCH_TARGET_ADDRESS/_CONTAINER,
+//	post: TRACE_BENCH_STATUS=ok|failed, TRACE_BENCH_METRIC_<P95_MS|...>
+func hookEnv(phase, runID string, cfg engine.Config, labels map[string]string, r *engine.Result, measureErr error) []string {
+	env := []string{"TRACE_BENCH_HOOK=" + phase, "TRACE_BENCH_RUN_ID=" + runID}
+	if cfg.Endpoint != "" {
+		env = append(env, "TRACE_BENCH_ENDPOINT="+cfg.Endpoint)
+	}
+	add := func(prefix string, m map[string]string) {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			env = append(env, prefix+envName(k)+"="+m[k])
+		}
+	}
+	add("TRACE_BENCH_", output.ConfigLabels(cfg))
+	add("TRACE_BENCH_LABEL_", labels)
+	if t := cfg.Target; t != nil {
+		env = append(env, "TRACE_BENCH_TARGET_ADDRESS="+t.Address, "TRACE_BENCH_TARGET_CONTAINER="+t.Container)
+	}
+	if phase == engine.HookPost {
+		status := "ok"
+		if measureErr != nil {
+			status = "failed"
+		}
+		env = append(env, "TRACE_BENCH_STATUS="+status)
+		if measureErr == nil && r != nil {
+			m := map[string]string{}
+			for k, v := range r.Metrics() {
+				m[k] = strconv.FormatFloat(v, 'g', -1, 64)
+			}
+			add("TRACE_BENCH_METRIC_", m)
+		}
+	}
+	return env
+}
+
+// envName은 레이블 키를 환경변수 이름 조각으로(영숫자 외는 _)
+func envName(k string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, k)
+}
+
+// runRunHook은 sh -c로 hook을 실행한다. 출력은 stderr로(stdout은 결과 전용)
+func runRunHook(phase, command string, timeout time.Duration, env []string) engine.HookRun {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	t0 := time.Now()
+	err := cmd.Run()
+	h := engine.HookRun{Phase: phase, Command: command, DurationS: stats.Round2(time.Since(t0).Seconds())}
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		h.ExitCode, h.Error = -1, fmt.Sprintf("timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		h.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		h.ExitCode, h.Error = -1, err.Error()
+	}
+	if h.ExitCode != 0 {
+		logger.Error(evHookVetoed, "phase", phase, "command", command, "exit_code", h.ExitCode, "err", h.Error)
+	} else {
+		logger.Info(evHookRun, "phase", phase, "command", command, "duration_s", h.DurationS)
+	}
+	return h
+}
+
+// vetoRun은 hook 거부로 결과를 기록하지 않고 종료한다
+func vetoRun(h engine.HookRun, runID string) {
+	self.finish(nil, runID, fmt.Errorf("run vetoed by %s-hook (exit %d)", h.Phase, h.ExitCode))
+	exitEnvelope(resultenv.VerdictSkipped, h, "vetoed:"+h.Phase+"-hook")
+	os.Exit(exitVetoed)
+}
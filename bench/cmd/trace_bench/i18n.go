@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 사람이 읽는 게이트 출력(lint 보고서, digest)의 메시지 카탈로그.
+// 키는 영어 원문(fmt 형식)이고 번역이 없으면 원문을 쓴다.
+// 로그 이벤트, JSON 필드, 규칙 id, 메트릭 이름은 도구가 읽는 값이라 번역하지 않는다
+const (
+	langEN = "en"
+	langKO = "ko"
+)
+
+var langs = []string{langEN, langKO}
+
+// msgLang은 -lang으로 정한 출력 언어
+var msgLang = langEN
+
+var catalog = map[string]map[string]string{
+	langKO: {
+		// lint
+		"bench args are trace_bench flags, e.g. [-spans, \"20000\"]": "bench args는 trace_bench 플래그입니다. 예: [-spans, \"20000\"]",
+		"replace -%s with -%s":                                     "-%s 대신 -%s 사용",
+		"-%s is deprecated (%s)":                                   "-%s 플래그는 더 이상 권장되지 않습니다(%s)",
+		"short form used by the Day20/21 runner scripts":           "Day20/21 실행 스크립트가 쓰던 약어",
+		"fix the flag value":                                       "플래그 값을 고치세요",
+		"see nightly.yaml or trace_bench init for the file format": "파일 형식은 nightly.yaml 또는 trace_bench init을 참고하세요",
+		"add -mode real (or a real-mode -env) to measure":          "측정하려면 -mode real(또는 real 모드 -env)을 추가하세요",
+		"model mode estimates from the config; no samples are measured, so the sampling rules are skipped":                                       "model 모드는 설정으로 추정만 하고 표본을 측정하지 않으므로 표본 규칙을 건너뜁니다",
+		"raise -spans to at least %d (%d batches of %d spans)":                                                                                   "-spans를 %[1]d 이상으로 올리세요(%[3]d spans 배치 %[2]d개)",
+		"p95 comes from only %d batches (%d above it); a single slow request moves it":                                                           "p95가 배치 %d개(그 위로 %d개)로만 정해져 느린 요청 하나에도 움직입니다",
+		"raise -spans to %d for %d batches":                                                                                                      "배치 %[2]d개가 되도록 -spans를 %[1]d까지 올리세요",
+		"p95 comes from %d batches (%d tail samples); run-to-run noise will hide small regressions":                                              "p95가 배치 %d개(꼬리 표본 %d개)에서 나와 실행 간 잡음이 작은 회귀를 가립니다",
+		"raise -spans to %d for a %ds run":                                                                                                       "%[2]d초 동안 돌도록 -spans를 %[1]d까지 올리세요",
+		"at -span-rate %g the run lasts %.1fs; GC cycles and batch timeouts do not reach a steady state":                                         "-span-rate %g에서는 실행이 %.1f초라 GC 주기와 배치 타임아웃이 정상 상태에 이르지 못합니다",
+		"ramp up from -span-rate %.0f in steps; past capacity the run measures queue drops, not latency":                                         "-span-rate %.0f부터 단계적으로 올리세요. 용량을 넘으면 지연이 아니라 큐 drop을 측정하게 됩니다",
+		"-span-rate %g is above the highest rate sustained in %d history runs (%.0f spans/s)":                                                    "-span-rate %g 값은 history의 %d개 run에서 유지한 최고 속도(%.0f spans/s)보다 높습니다",
+		"raise -spans, lower -workers, or warm the target in a -pre-hook":                                                                        "-spans를 올리거나 -workers를 줄이거나 -pre-hook에서 대상을 예열하세요",
+		"each of %d workers sends only %d requests; their first, connection-opening requests are %.1f%% of the samples and land in the p95 tail": "워커 %d개가 각각 요청 %d개만 보내 연결을 여는 첫 요청이 표본의 %.1f%%를 차지하고 p95 꼬리에 들어갑니다",
+		"run it with -workload http against the target":                                                                                          "대상을 -workload http로 실행하세요",
+		"slo %s asserts error_rate, but the %s workload only encodes and never fails":                                                            "slo %s 규칙은 error_rate를 검사하지만 %s 워크로드는 인코딩만 하고 실패하지 않습니다",
+		"raise -spans to %d (%d batches)":                                                                                                        "-spans를 %d까지 올리세요(배치 %d개)",
+		"slo %s allows error_rate %g, but %d batches resolve it in steps of %.4f: %d failed batches decide the gate":                             "slo %s 규칙은 error_rate %g까지 허용하지만 배치 %d개로는 %.4f 단위로만 구분되어 실패한 배치 %d개가 게이트를 결정합니다",
+		"use -sampling 1 for gate runs":                                                                                                          "게이트 실행에는 -sampling 1을 쓰세요",
+		"slo %s asserts error_rate with -sampling %g: failures in the %.0f%% of spans that are dropped are never checked":                        "slo %s 규칙은 -sampling %g에서 error_rate를 검사해 버려지는 %.0f%% span의 실패는 확인되지 않습니다",
+		"error":                            "오류",
+		"warning":                          "경고",
+		"info":                             "참고",
+		"fix":                              "수정",
+		"%d errors, %d warnings, %d notes": "오류 %d개, 경고 %d개, 참고 %d개",
+		// digest
+		"all passed": "모두 통과",
+		"%d failed":  "%d개 실패",
+		"trace_bench digest %s: %d runs, %s, %d regressions": "trace_bench 요약 %s: run %d개, %s, 회귀 %d개",
+		"runs":                              "run 수",
+		"passed":                            "통과",
+		"failed":                            "실패",
+		"No scheduled runs in this period.": "이 기간에 예약 실행이 없습니다.",
+		"trend":                             "추세",
+		"p95 trend":                         "p95 추세",
+		"failures":                          "실패 사유",
+		"Top regressions":                   "주요 회귀",
+		"metric":                            "메트릭",
+		"current":                           "현재",
+		"median":                            "중앙값",
+		"change":                            "변화",
+		"change %":                          "변화 %",
+	},
+}
+
+// tr은 format을 msgLang으로 옮겨 a로 채운다(인자가 없으면 그대로 문자열)
+func tr(format string, a ...any) string {
+	if t, ok := catalog[msgLang][format]; ok {
+		format = t
+	}
+	if len(a) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, a...)
+}
+
+// defaultLang은 -lang 기본값: LC_ALL, LC_MESSAGES, LANG 순으로 처음 설정된 값(ko_KR.UTF-8 → ko)
+func defaultLang() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(env); v != "" {
+			if strings.HasPrefix(strings.ToLower(v), langKO) {
+				return langKO
+			}
+			return langEN
+		}
+	}
+	return langEN
+}
+
+// langFlag는 사람이 읽는 보고서를 쓰는 명령의 -lang 플래그
+func langFlag(fs *flag.FlagSet, p *string) {
+	fs.StringVar(p, "lang", defaultLang(), "report language: "+strings.Join(langs, "|")+" (default from $LC_ALL, $LC_MESSAGES or $LANG)")
+}
+
+func setLang(lang string) error {
+	for _, l := range langs {
+		if l == lang {
+			msgLang = l
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid -lang %q (expected %s)", lang, strings.Join(langs, "|"))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/duri/trace_bench/profile"
+	"github.com/duri/trace_bench/slo"
+)
+
+// templates/<name>/: scenario.yaml(schedule 설정 형식), slo.yaml, profiles.yaml와
+// 필요하면 요청 스크립트. 파일 안의 {{.Dir}}은 생성할 디렉터리
+//
+//go:embed templates
+var benchTemplates embed.FS
+
+func templateNames() []string {
+	ents, _ := benchTemplates.ReadDir("templates")
+	names := make([]string, 0, len(ents))
+	for _, e := range ents {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// initFlags는 init 명령의 플래그 값
+type initFlags struct {
+	name  string
+	dir   string
+	force bool
+	list  bool
+}
+
+func (o *initFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&o.name, "template", "", "template to scaffold: "+strings.Join(templateNames(), "|"))
+	fs.StringVar(&o.dir, "dir", "", "directory to create the files in (default ./<template>)")
+	fs.BoolVar(&o.force, "force", false, "overwrite existing files")
+	fs.BoolVar(&o.list, "list", false, "list the templates and exit")
+}
+
+// init 모드: trace_bench init -template=NAME [-dir=DIR] [-force]
+// 표준 서비스용 scenario + SLO + profile 묶음을 고쳐 쓸 수 있게 만들어 준다
+func runInit(args []string) {
+	var o initFlags
+	fset := flag.NewFlagSet("init", flag.ExitOnError)
+	o.define(fset)
+	fset.Parse(args)
+	if o.list {
+		for _, n := range templateNames() {
+			fmt.Println(n)
+		}
+		return
+	}
+	if o.name == "" || fset.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "usage: trace_bench init -template=%s [-dir=DIR] [-force]\n", strings.Join(templateNames(), "|"))
+		os.Exit(2)
+	}
+	root := path.Join("templates", o.name)
+	files, err := fs.ReadDir(benchTemplates, root)
+	if err != nil {
+		fail(fmt.Errorf("unknown template %q (templates: %s)", o.name, strings.Join(templateNames(), ", ")))
+	}
+	if o.dir == "" {
+		o.dir = o.name
+	}
+	// 덮어쓰기 전에 전부 확인해 일부만 생성된 상태를 남기지 않는다
+	if !o.force {
+		for _, f := range files {
+			if _, err := os.Stat(filepath.Join(o.dir, f.Name())); err == nil {
+				fail(fmt.Errorf("%s already exists (use -force to overwrite)", filepath.Join(o.dir, f.Name())))
+			}
+		}
+	}
+	if err := os.MkdirAll(o.dir, 0o755); err != nil {
+		fail(err)
+	}
+	vars := struct{ Dir, Template string }{filepath.ToSlash(o.dir), o.name}
+	var written []string
+	for _, f := range files {
+		src, err := benchTemplates.ReadFile(path.Join(root, f.Name()))
+		if err != nil {
+			fail(err)
+		}
+		t, err := template.New(f.Name()).Option("missingkey=error").Parse(string(src))
+		if err != nil {
+			fail(fmt.Errorf("template %s/%s: %w", o.name, f.Name(), err))
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, vars); err != nil {
+			fail(fmt.Errorf("template %s/%s: %w", o.name, f.Name(), err))
+		}
+		out := filepath.Join(o.dir, f.Name())
+		if err := os.WriteFile(out, buf.Bytes(), 0o644); err != nil {
+			fail(err)
+		}
+		written = append(written, out)
+	}
+	// 생성한 묶음이 그대로 로드되는지 확인
+	if err := checkScaffold(o.dir); err != nil {
+		fail(err)
+	}
+	for _, w := range written {
+		fmt.Println(w)
+	}
+	fmt.Fprintf(os.Stderr, "edit the endpoints and thresholds, then run: trace_bench schedule -config %s -once\n", filepath.Join(o.dir, "scenario.yaml"))
+}
+
+func checkScaffold(dir string) error {
+	if _, err := loadSchedule(filepath.Join(dir, "scenario.yaml"), ""); err != nil {
+		return err
+	}
+	if _, err := slo.Load(filepath.Join(dir, "slo.yaml")); err != nil {
+		return err
+	}
+	_, err := profile.Load(filepath.Join(dir, "profiles.yaml"))
+	return err
+}
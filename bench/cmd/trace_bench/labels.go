@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/internal/secretref"
+	"github.com/duri/trace_bench/validate"
+)
+
+var labelNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// labelFlag는 반복 가능한 key=value 플래그(-label, -filter)
+type labelFlag map[string]string
+
+func (l labelFlag) String() string { return fmtLabelSet(l) }
+
+func (l labelFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || !labelNameRe.MatchString(k) {
+		return fmt.Errorf("invalid label %q (want key=value, key matching %s)", s, labelNameRe)
+	}
+	if err := validate.Line("label "+k, v); err != nil {
+		return err
+	}
+	l[k] = v
+	return nil
+}
+
+// 값이 없으면 nil(결과 JSON에서 생략)
+func (l labelFlag) orNil() map[string]string {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// headerFlag는 반복 가능한 -header Name=value(값은 secretref:// 가능)
+type headerFlag map[string]string
+
+func (h headerFlag) String() string {
+	// 값은 자격증명일 수 있으므로 이름만
+	names := make([]string, 0, len(h))
+	for k := range h {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// parseMarkHeader는 -mark-header "Name: value"를 나누고 <runid>를 치환한다
+func parseMarkHeader(s, runID string) (string, string, error) {
+	k, v, ok := strings.Cut(s, ":")
+	k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+	if !ok || k == "" || v == "" || strings.ContainsAny(k, " \t") {
+		return "", "", fmt.Errorf("invalid -mark-header %q (want \"Name: value\", e.g. %q)", s, engine.DefaultMarkHeader)
+	}
+	return http.CanonicalHeaderKey(k), strings.ReplaceAll(v, "<runid>", runID), nil
+}
+
+func (h headerFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" || strings.ContainsAny(k, " \t:") {
+		return fmt.Errorf("invalid header (want Name=value or Name=secretref://...)")
+	}
+	h[http.CanonicalHeaderKey(k)] = v
+	return nil
+}
+
+// resolve는 secretref 값을 풀어 헤더 맵으로(없으면 nil)
+func (h headerFlag) resolve() (map[string]string, error) {
+	if len(h) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(h))
+	for k, ref := range h {
+		v, err := secretref.Resolve(ref)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", k, err)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/history"
+	"github.com/duri/trace_bench/internal/yamlite"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/profile"
+	"github.com/duri/trace_bench/slo"
+)
+
+// lint 심각도: error면 gate로 쓸 수 없는 결과, warning은 결과를 믿기 어려운 구성
+const (
+	lintError   = "error"
+	lintWarning = "warning"
+	lintInfo    = "info"
+)
+
+// lint 기준
+const (
+	lintMinBatches     = 100 // p95 꼬리 표본 5개
+	lintStableBatches  = 400 // p95 꼬리 표본 20개
+	lintMinDurationS   = 30
+	lintWarmRequests   = 50 // 워커당 요청 수: 이보다 적으면 연결 수립이 p95 꼬리에 든다
+	lintRateHeadroom   = 1.2
+	lintErrorsPerLimit = 10 // error_rate 임계값에서 기대하는 실패 배치 수
+)
+
+// main의 불리언 플래그(값을 받지 않는다). bench args를 나눌 때 쓴다
+var lintBoolFlags = map[string]bool{
+	"version": true, "json": true, "strict": true, "fail-on-deprecated": true, "self-check": true, "self-bench": true, "tls-insecure": true, "tui": true,
+}
+
+type lintFinding struct {
+	File     string `json:"file"`
+	Bench    string `json:"bench"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Hint     string `json:"hint"`
+}
+
+// lintFlags는 lint 명령의 플래그 값
+type lintFlags struct {
+	histPath string
+	asJSON   bool
+	strict   bool
+	lang     string
+}
+
+func (o *lintFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&o.histPath, "history", historyPath(), "history DB for the rate check (default: the file's history, then $TRACE_BENCH_HISTORY)")
+	fs.BoolVar(&o.asJSON, "json", false, "print the findings as JSON")
+	fs.BoolVar(&o.strict, "strict", false, "lint as the benches would run with -strict: unknown config keys are errors")
+	langFlag(fs, &o.lang)
+}
+
+// lint 모드: trace_bench lint [-history=PATH] [-json] bench.yaml...
+// bench 설정(schedule 설정 형식)의 흔한 실수를 심각도와 고칠 방법과 함께 알려 준다
+func runLint(args []string) {
+	var o lintFlags
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	o.define(fs)
+	fs.Parse(args)
+	if err := setLang(o.lang); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if o.strict {
+		if err := setStrict(); err != nil {
+			fail(err)
+		}
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: trace_bench lint [-history=PATH] [-json] [-strict] [-lang=en|ko] bench.yaml...")
+		os.Exit(2)
+	}
+	findings := []lintFinding{}
+	for _, path := range fs.Args() {
+		yamlite.Strict = o.strict
+		// spec 없는 bench도 lint한다(일정은 schedule에서만 필요)
+		cfg, err := loadSchedule(path, "@daily")
+		if err != nil {
+			findings = append(findings, lintFinding{File: path, Rule: "config", Severity: lintError, Message: err.Error(),
+				Hint: tr("see nightly.yaml or trace_bench init for the file format")})
+			continue
+		}
+		hist := o.histPath
+		if hist == "" {
+			hist = cfg.History
+		}
+		var entries []history.Entry
+		if hist != "" {
+			if db, err := history.Open(hist); err == nil {
+				entries, _ = db.Entries()
+			}
+		}
+		for _, b := range cfg.Benches {
+			// args에 -strict가 있는 bench는 실행할 때처럼 엄격하게 읽는다
+			yamlite.Strict = o.strict || hasFlag(b.Args, "strict")
+			for _, f := range lintBench(b, entries) {
+				f.File, f.Bench = path, b.Name
+				findings = append(findings, f)
+			}
+		}
+	}
+	errs := 0
+	for _, f := range findings {
+		if f.Severity == lintError {
+			errs++
+		}
+	}
+	if o.asJSON {
+		if err := output.WriteJSON(os.Stdout, findings); err != nil {
+			fail(err)
+		}
+	} else {
+		writeLint(findings)
+	}
+	if errs > 0 {
+		os.Exit(2)
+	}
+}
+
+// benchSettings는 bench args(+ -env profile)에서 lint에 필요한 값
+type benchSettings struct {
+	mode, workload string
+	spans, batch   int
+	workers        int
+	spanRate       float64
+	sampling       float64
+	connections    string
+	errorSLOs      []slo.SLO
+}
+
+func lintBench(b scheduledBench, entries []history.Entry) []lintFinding {
+	var out []lintFinding
+	add := func(rule, sev, hint, format string, a ...any) {
+		out = append(out, lintFinding{Rule: rule, Severity: sev, Message: tr(format, a...), Hint: hint})
+	}
+	flags, err := splitBenchArgs(b.Args)
+	if err != nil {
+		add("args", lintError, tr("bench args are trace_bench flags, e.g. [-spans, \"20000\"]"), "%v", err)
+		return out
+	}
+	for name, v := range flags {
+		if d, ok := lookupDeprecated(name); ok {
+			add("deprecated-flag", lintWarning, tr("replace -%s with -%s", d.name, d.replacement), "-%s is deprecated (%s)", d.name, tr(d.note))
+			if _, set := flags[d.replacement]; !set {
+				flags[d.replacement] = v
+			}
+		}
+	}
+	s, err := resolveBench(flags)
+	if err != nil {
+		add("args", lintError, tr("fix the flag value"), "%v", err)
+		return out
+	}
+	if s.mode != engine.ModeReal {
+		add("model-mode", lintInfo, tr("add -mode real (or a real-mode -env) to measure"), "model mode estimates from the config; no samples are measured, so the sampling rules are skipped")
+		return out
+	}
+
+	ops := (s.spans + s.batch - 1) / s.batch
+	switch {
+	case ops < lintMinBatches:
+		add("p95-samples", lintError, tr("raise -spans to at least %d (%d batches of %d spans)", lintStableBatches*s.batch, lintStableBatches, s.batch),
+			"p95 comes from only %d batches (%d above it); a single slow request moves it", ops, ops/20)
+	case ops < lintStableBatches:
+		add("p95-samples", lintWarning, tr("raise -spans to %d for %d batches", lintStableBatches*s.batch, lintStableBatches),
+			"p95 comes from %d batches (%d tail samples); run-to-run noise will hide small regressions", ops, ops*5/100)
+	}
+	if s.spanRate > 0 {
+		if d := float64(s.spans) / s.spanRate; d < lintMinDurationS {
+			add("short-duration", lintWarning, tr("raise -spans to %d for a %ds run", int(s.spanRate*lintMinDurationS*2), lintMinDurationS*2),
+				"at -span-rate %g the run lasts %.1fs; GC cycles and batch timeouts do not reach a steady state", s.spanRate, d)
+		}
+		if peak, n := historicalRate(b.Name, entries); n > 0 && s.spanRate > peak*lintRateHeadroom {
+			add("rate-above-history", lintWarning, tr("ramp up from -span-rate %.0f in steps; past capacity the run measures queue drops, not latency", peak),
+				"-span-rate %g is above the highest rate sustained in %d history runs (%.0f spans/s)", s.spanRate, n, peak)
+		}
+	}
+	if s.workload == engine.WorkloadHTTP && s.connections != engine.ConnPerRequest {
+		if per := ops / max(s.workers, 1); per < lintWarmRequests {
+			add("missing-warmup", lintWarning, tr("raise -spans, lower -workers, or warm the target in a -pre-hook"),
+				"each of %d workers sends only %d requests; their first, connection-opening requests are %.1f%% of the samples and land in the p95 tail",
+				s.workers, per, 100/float64(max(per, 1)))
+		}
+	}
+	for _, o := range s.errorSLOs {
+		t := *o.Threshold
+		switch {
+		case s.workload != engine.WorkloadHTTP:
+			add("unexercised-error-assertion", lintWarning, tr("run it with -workload http against the target"),
+				"slo %s asserts error_rate, but the %s workload only encodes and never fails", o.Name, s.workload)
+		case t > 0 && float64(ops)*t < lintErrorsPerLimit:
+			add("unresolved-error-assertion", lintWarning, tr("raise -spans to %d (%d batches)", int(math.Ceil(lintErrorsPerLimit/t))*s.batch, int(math.Ceil(lintErrorsPerLimit/t))),
+				"slo %s allows error_rate %g, but %d batches resolve it in steps of %.4f: %d failed batches decide the gate", o.Name, t, ops, 1/float64(ops), int(t*float64(ops))+1)
+		}
+		if s.sampling < 1 {
+			add("sampled-error-assertion", lintWarning, tr("use -sampling 1 for gate runs"),
+				"slo %s asserts error_rate with -sampling %g: failures in the %.0f%% of spans that are dropped are never checked", o.Name, s.sampling, (1-s.sampling)*100)
+		}
+	}
+	return out
+}
+
+// splitBenchArgs는 "-name value"/"-name=value"를 이름 → 값으로(반복 플래그는 마지막 값)
+func splitBenchArgs(args []string) (map[string]string, error) {
+	out := map[string]string{}
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		name := strings.TrimPrefix(strings.TrimPrefix(a, "-"), "-")
+		if name == a || name == "" {
+			return nil, fmt.Errorf("unexpected argument %q (expected a flag)", a)
+		}
+		if n, v, ok := strings.Cut(name, "="); ok {
+			out[n] = v
+			continue
+		}
+		if lintBoolFlags[name] {
+			out[name] = "true"
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, fmt.Errorf("flag -%s needs a value", name)
+		}
+		out[name] = args[i+1]
+		i++
+	}
+	return out, nil
+}
+
+func resolveBench(flags map[string]string) (benchSettings, error) {
+	s := benchSettings{mode: engine.ModeModel, workload: engine.WorkloadPipeline, spans: engine.DefaultSpans,
+		batch: engine.DefaultBatchSize, workers: 1, sampling: 1}
+	var prof profile.Profile
+	if env := flags["env"]; env != "" {
+		path := flags["profiles"]
+		if path == "" {
+			path = defaultProfiles()
+		}
+		f, err := profile.Load(path)
+		if err != nil {
+			return s, err
+		}
+		if prof, err = f.Env(env); err != nil {
+			return s, err
+		}
+	}
+	str := func(name, fromProfile string, dst *string) {
+		if v, ok := flags[name]; ok {
+			*dst = v
+		} else if fromProfile != "" {
+			*dst = fromProfile
+		}
+	}
+	str("mode", prof.Mode, &s.mode)
+	str("workload", prof.Workload, &s.workload)
+	s.connections = flags["connections"]
+	var err error
+	num := func(name string, dst *int) {
+		if v, ok := flags[name]; ok && err == nil {
+			if *dst, err = strconv.Atoi(v); err == nil && *dst <= 0 {
+				err = fmt.Errorf("-%s must be positive", name)
+			}
+			if err != nil {
+				err = fmt.Errorf("-%s %q: %w", name, v, err)
+			}
+		}
+	}
+	num("spans", &s.spans)
+	num("batch", &s.batch)
+	num("workers", &s.workers)
+	if v, ok := flags["batch-size"]; ok && err == nil {
+		// sweep이면 가장 큰 크기(배치 수가 가장 적은 구성)로 판단
+		s.batch = 0
+		for _, p := range splitList(v) {
+			n, perr := strconv.Atoi(p)
+			if perr != nil || n <= 0 {
+				return s, fmt.Errorf("-batch-size %q: bad size %q", v, p)
+			}
+			s.batch = max(s.batch, n)
+		}
+	}
+	for name, dst := range map[string]*float64{"span-rate": &s.spanRate, "sampling": &s.sampling} {
+		if v, ok := flags[name]; ok && err == nil {
+			if *dst, err = strconv.ParseFloat(v, 64); err != nil {
+				err = fmt.Errorf("-%s %q: %w", name, v, err)
+			}
+		}
+	}
+	if err != nil {
+		return s, err
+	}
+	if path := flags["slo"]; path != "" {
+		defs, err := slo.Load(path)
+		if err != nil {
+			return s, err
+		}
+		if defs.SLOs, err = prof.ApplySLO(defs.SLOs); err != nil {
+			return s, err
+		}
+		for _, o := range defs.Bench() {
+			if o.Indicator.BenchMetric == "error_rate" {
+				s.errorSLOs = append(s.errorSLOs, o)
+			}
+		}
+	}
+	return s, nil
+}
+
+// historicalRate는 같은 bench(schedule=<name> 레이블, 없으면 전체) run이 실제로 낸 최고 span 처리율
+func historicalRate(bench string, entries []history.Entry) (float64, int) {
+	var peak float64
+	n := 0
+	for _, e := range entries {
+		if s, ok := e.Labels["schedule"]; ok && s != bench {
+			continue
+		}
+		v := e.Result.Volume
+		if v == nil || v.DurationS <= 0 {
+			continue
+		}
+		n++
+		peak = math.Max(peak, float64(v.Spans)/v.DurationS)
+	}
+	return peak, n
+}
+
+func writeLint(findings []lintFinding) {
+	order := map[string]int{lintError: 0, lintWarning: 1, lintInfo: 2}
+	sort.SliceStable(findings, func(i, j int) bool { return order[findings[i].Severity] < order[findings[j].Severity] })
+	count := map[string]int{}
+	for _, f := range findings {
+		count[f.Severity]++
+		where := f.File
+		if f.Bench != "" {
+			where += ": " + f.Bench
+		}
+		fmt.Printf("%s: %s [%s] %s\n", where, tr(f.Severity), f.Rule, f.Message)
+		if f.Hint != "" {
+			fmt.Printf("    %s: %s\n", tr("fix"), f.Hint)
+		}
+	}
+	fmt.Println(tr("%d errors, %d warnings, %d notes", count[lintError], count[lintWarning], count[lintInfo]))
+}
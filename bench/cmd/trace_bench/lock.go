@@ -0,0 +1,158 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/internal/flock"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// exitLocked: -lock=fail에서 같은 대상+구성의 run이 이미 실행 중
+const exitLocked = 6
+
+// -lock 정책
+const (
+	lockFail = "fail"
+	lockWait = "wait"
+	lockOff  = "off"
+)
+
+// lock 대기 중 재시도 간격
+const lockPoll = 500 * time.Millisecond
+
+// runLock은 프로세스가 끝날 때까지 잡고 있는 lock 파일(GC로 닫히지 않게 보관)
+var runLock *os.File
+
+// lockHolder는 lock 파일 내용: 누가 잡고 있는지 알려 주기 위한 기록
+type lockHolder struct {
+	PID     int       `json:"pid"`
+	RunID   string    `json:"run_id"`
+	Started time.Time `json:"started"`
+	Target  string    `json:"target"`
+	Args    []string  `json:"args"`
+}
+
+// defaultLockDir는 -lock-dir 기본값(TRACE_BENCH_LOCK_DIR, 없으면 임시 디렉터리)
+func defaultLockDir() string {
+	if d := os.Getenv("TRACE_BENCH_LOCK_DIR"); d != "" {
+		return d
+	}
+	return filepath.Join(os.TempDir(), "trace_bench-locks")
+}
+
+// lockTarget은 run이 부하를 주는 대상: endpoint, 발견한 컨테이너, 없으면 이 호스트
+func lockTarget(cfg engine.Config) string {
+	switch {
+	case cfg.Endpoint != "":
+		return cfg.Endpoint
+	case cfg.Target != nil:
+		return cfg.Target.Project + "/" + cfg.Target.Service + "@" + cfg.Target.Address
+	}
+	host, _ := os.Hostname()
+	return "local:" + host
+}
+
+// configHash는 run마다 달라지는 값(run id, 자격 증명, 관측 훅)을 뺀 구성의 해시.
+// sweep이면 plan 전체
+func configHash(cfgs []engine.Config) (string, error) {
+	// 바깥의 nil 필드가 같은 이름의 Config 필드를 가리고 생략된다
+	// (json:"-"는 가리지 못한다. Phases는 func라 직렬화 불가)
+	type hashView struct {
+		engine.Config
+		RunID   *struct{} `json:",omitempty"`
+		Headers *struct{} `json:",omitempty"`
+		Live    *struct{} `json:",omitempty"`
+		Phases  *struct{} `json:",omitempty"`
+		Target  *struct{} `json:",omitempty"`
+	}
+	view := make([]hashView, len(cfgs))
+	for i, c := range cfgs {
+		view[i].Config = c
+	}
+	b, err := json.Marshal(view)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// acquireRunLock은 real/hybrid 모드 run의 대상+구성 lock을 잡는다. fail이면 이미 잡혀 있을 때 보유자를
+// 알리고 exitLocked로 끝내고, wait이면 풀릴 때까지 기다린다(큐잉)
+func acquireRunLock(policy, dir, runID string, cfg engine.Config, plan []engine.Config) {
+	if policy == lockOff || !cfg.Measures() {
+		return // model 모드는 계산만 하므로 서로 간섭하지 않는다
+	}
+	cfgs := plan
+	if cfgs == nil {
+		cfgs = []engine.Config{cfg}
+	}
+	hash, err := configHash(cfgs)
+	if err != nil {
+		fail(err)
+	}
+	target := lockTarget(cfg)
+	key := sha256.Sum256([]byte(target + "\n" + hash))
+	path := filepath.Join(dir, hex.EncodeToString(key[:8])+".lock")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fail(err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		fail(err)
+	}
+	waiting := false
+	for {
+		err := flock.TryLock(f, true)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, flock.ErrLocked) {
+			// lock을 지원하지 않는 파일시스템/플랫폼: 보호 없이 진행
+			logger.Warn(evConfigWarning, "reason", "run lock unavailable, running unlocked", "path", path, "err", err.Error())
+			f.Close()
+			return
+		}
+		holder := readLockHolder(path)
+		if policy == lockFail {
+			self.finish(nil, runID, fmt.Errorf("locked by run %s", holder.RunID))
+			fmt.Fprintf(os.Stderr, "another trace_bench run holds the lock for this target and config (pid %d, run_id %s, started %s): %s\n"+
+				"  target: %s\n  lock: %s\n  use -lock=wait to queue behind it\n",
+				holder.PID, holder.RunID, holder.Started.Format(time.RFC3339), strings.Join(holder.Args, " "), target, path)
+			exitEnvelope(resultenv.VerdictSkipped, holder, "locked")
+			os.Exit(exitLocked)
+		}
+		if !waiting {
+			logger.Info(evRunLockWait, "target", target, "holder_pid", holder.PID, "holder_run_id", holder.RunID, "lock", path)
+			waiting = true
+		}
+		select {
+		case <-self.ctx.Done():
+			fail(self.ctx.Err())
+		case <-time.After(lockPoll):
+		}
+	}
+	// 잡은 뒤 보유자 기록(대기 중인 run이 읽는다)
+	b, _ := json.Marshal(lockHolder{PID: os.Getpid(), RunID: runID, Started: time.Now().UTC(), Target: target, Args: os.Args[1:]})
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt(b, 0)
+	}
+	runLock = f
+}
+
+func readLockHolder(path string) lockHolder {
+	var h lockHolder
+	if b, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(b, &h)
+	}
+	return h
+}
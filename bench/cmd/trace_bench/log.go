@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/duri/trace_bench/logschema"
+)
+
+// 로그 이벤트 이름은 msg로 나가는 안정 ABI: 바꾸면 로그 소비자가 깨진다
+const (
+	evRunFailed        = "run.failed"
+	evTargetDiscovered = "target.discovered"
+	evTargetUnhealthy  = "target.unhealthy"
+	evTargetBuild      = "target.buildinfo"
+	evConfigWarning    = "config.warning"
+	evProfileWritten   = "profile.written"
+	evSLOBreached      = "slo.breached"
+	evSoakCheckpoint   = "soak.checkpoint"
+	evResultWritten    = "result.written"
+	evInfluxWritten    = "export.influx"
+	evRemoteWritten    = "export.remote_write"
+	evArtifactsUpload  = "artifacts.uploaded"
+	evPerfNoteWritten  = "perfnote.written"
+	evBaselineSet      = "baseline.set"
+	evRegression       = "regress.detected"
+	evBisectStep       = "bisect.step"
+	evBisectSkipped    = "bisect.skipped"
+	evBisectUnresolved = "bisect.unresolved"
+	evSelfTelStarted   = "selftel.started"
+	evSelfTelFailed    = "selftel.export_failed"
+	evEnvelopeWritten  = "envelope.written"
+	evCollectorFailed  = "collector.failed"
+	evHookRun          = "hook.run"
+	evHookVetoed       = "hook.vetoed"
+	evSolveProbe       = "solve.probe"
+	evSolveResult      = "solve.result"
+	evSolveInfeasible  = "solve.infeasible"
+	evStorageResult    = "storage.result"
+	evQueryResult      = "query.result"
+	evQueueResult      = "queue.result"
+	evScheduleNext     = "schedule.next"
+	evScheduleRun      = "schedule.run"
+	evDigestSent       = "digest.sent"
+	evRunLockWait      = "run.lock_wait"
+	evMergeResult      = "merge.result"
+	evCPUThrottled     = "generator.throttled"
+	evNetemApplied     = "netem.applied"
+	evNetemRemoved     = "netem.removed"
+	evDeprecated       = "deprecation.used"
+	evModelCalibrated  = "model.calibrated"
+	evModelDrift       = "model.drift"
+)
+
+// logEvents는 위 이벤트 전체(self-check가 스키마와 대조)
+var logEvents = []string{
+	evRunFailed, evTargetDiscovered, evTargetUnhealthy, evTargetBuild, evConfigWarning, evProfileWritten, evSLOBreached,
+	evSoakCheckpoint, evResultWritten, evInfluxWritten, evRemoteWritten, evArtifactsUpload,
+	evPerfNoteWritten, evBaselineSet, evRegression, evBisectStep, evBisectSkipped, evBisectUnresolved,
+	evSelfTelStarted, evSelfTelFailed, evEnvelopeWritten, evCollectorFailed, evHookRun, evHookVetoed,
+	evSolveProbe, evSolveResult, evSolveInfeasible, evStorageResult, evQueryResult,
+	evQueueResult, evScheduleNext, evScheduleRun, evDigestSent, evRunLockWait, evMergeResult, evCPUThrottled,
+	evNetemApplied, evNetemRemoved, evDeprecated, evModelCalibrated, evModelDrift,
+}
+
+// logComponent는 모든 로그 레코드의 component 속성(레포 로그 ABI의 component와 같은 의미)
+const logComponent = "trace_bench"
+
+// 로그 형식/레벨 기본값(하위 명령은 자체 FlagSet이라 환경변수로도 지정)
+const (
+	logLevelEnv  = "TRACE_BENCH_LOG_LEVEL"
+	logFormatEnv = "TRACE_BENCH_LOG_FORMAT"
+)
+
+var logger = newLogger(logOut, slog.LevelInfo, "text")
+
+// logOut은 logger 출력 대상(-tui 동안은 화면의 로그 패널로 돌린다)
+var logOut = &switchWriter{w: os.Stderr}
+
+type switchWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// set은 출력 대상을 바꾸고 이전 대상을 돌려준다
+func (s *switchWriter) set(w io.Writer) io.Writer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.w
+	s.w = w
+	return old
+}
+
+func newLogger(w io.Writer, level slog.Level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler = slog.NewTextHandler(w, opts)
+	if format == "json" {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(h).With("component", logComponent)
+}
+
+// setupLog은 -log-level/-log-format(또는 환경변수 기본값)으로 logger를 다시 만든다
+func setupLog(level, format string) error {
+	var lv slog.Level
+	if err := lv.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log-level: %s", level)
+	}
+	switch format {
+	case "text", "json":
+	default:
+		return fmt.Errorf("invalid log-format: %s", format)
+	}
+	logger = newLogger(logOut, lv, format)
+	return nil
+}
+
+// checkLogABI는 이벤트 목록이 로그 스키마와 일치하는지, 실제 JSON 레코드가
+// 스키마를 통과하는지 확인한다(-self-check)
+func checkLogABI() error {
+	declared := map[string]bool{}
+	for _, e := range logschema.Events() {
+		declared[e] = true
+	}
+	for _, e := range logEvents {
+		if !declared[e] {
+			return fmt.Errorf("log event %s is not declared in the log schema", e)
+		}
+		delete(declared, e)
+	}
+	for e := range declared {
+		return fmt.Errorf("log schema declares %s but trace_bench never emits it", e)
+	}
+	var buf bytes.Buffer
+	l := newLogger(&buf, slog.LevelDebug, "json")
+	for _, e := range logEvents {
+		l.Info(e, "run_id", "self-check", "err", "example")
+	}
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		if err := logschema.Validate(line); err != nil {
+			return fmt.Errorf("log ABI: %w", err)
+		}
+	}
+	return nil
+}
+
+func envOr(env, def string) string {
+	if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+		return v
+	}
+	return def
+}
@@ -1,181 +1,1141 @@
 package main
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
+
+	"github.com/duri/trace_bench/artifact"
+	"github.com/duri/trace_bench/collector"
+	"github.com/duri/trace_bench/cost"
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/history"
+	"github.com/duri/trace_bench/internal/secretref"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/buildinfo"
+	"github.com/duri/trace_bench/pkg/resultenv"
+	"github.com/duri/trace_bench/profile"
+	"github.com/duri/trace_bench/slo"
+	"github.com/duri/trace_bench/stats"
 )
 
-var version = "v0.1.0"
+func main() {
+	if err := setupLog(envOr(logLevelEnv, "info"), envOr(logFormatEnv, "text")); err != nil {
+		fail(err)
+	}
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		runSubcommand(os.Args[1], os.Args[2:])
+		return
+	}
+	runBench(os.Args[1:])
+}
+
+// runBench는 하위 명령 없이 플래그만 준 기본 실행
+func runBench(args []string) {
+	f := defineBenchFlags(flag.CommandLine)
+	defineDeprecated(flag.CommandLine)
+	flag.CommandLine.Parse(args)
+	startRun(f)
+
+	if f.showVersion {
+		buildinfo.Print(os.Stdout, "trace_bench", f.versionJSON)
+		return
+	}
+	if f.selfCheck {
+		runSelfCheck()
+		return
+	}
+
+	b := prepareBench(f)
+	if f.selfBench {
+		b.selfBench()
+		return
+	}
+	b.measure()
+	b.judge()
+	self.startExport()
+	b.writeInflux()
+	if b.sweep != nil {
+		b.writeSweep()
+	} else {
+		b.writeRun()
+	}
+	self.finish(b.r.Metrics(), f.runID, nil)
+	if b.breached {
+		os.Exit(2)
+	}
+}
+
+// benchFlags는 기본 실행의 플래그 값
+type benchFlags struct {
+	showVersion, versionJSON, selfCheck, selfBench bool
+	logLevel, logFormat, otelSelf                  string
+	failOnDeprecated, strict                       bool
+
+	sampling                                          float64
+	serialization, compression, mode, modelFile       string
+	spotSpans, spans, batch, workers                  int
+	driftThreshold                                    float64
+	batchSizes, batchTimeouts, queueMem, backpressure string
+	spanRate                                          float64
+	queueSize                                         int
+	quantileSketch                                    string
+	heatmap                                           time.Duration
+
+	workload, endpoint, retryBackoff, connections string
+	execCommand, sandbox                          string
+	timeout                                       time.Duration
+	retries                                       int
+	tlsCert, tlsKey, tlsCA, tlsServerName         string
+	tlsInsecure                                   bool
+	bodyTemplate, script, feedPath, feedMode      string
+	headers                                       headerFlag
+	markHeader, protocols                         string
+
+	chaos, netemSpec, netemDev, netemNetns, mttrSLO string
+	mttrWindow                                      time.Duration
+
+	sloPath, remoteWrite, influxToken, influxURL, artifactStore string
+	envName, profilesPath                                       string
+	labels                                                      labelFlag
+	collectors                                                  collectorFlag
+	collectorTimeout                                            time.Duration
+	preHook, postHook                                           string
+	hookTimeout                                                 time.Duration
+	lockPolicy, lockDir, cpuAffinity                            string
+	nice                                                        int
+
+	histPath, againstName, perfNote, runID string
+	regressWindow                          int
+	jsonOut, outFormat, envelopeOut        string
+
+	soak, checkpointEvery time.Duration
+	checkpointOut         string
+	heatmapOut            string
+	costModel, traffic    string
+	tui                   bool
+	selfProfile           string
+	profileOut            string
 
-type result struct {
-	P95ms     float64 `json:"p95_ms"`
-	ErrorRate float64 `json:"error_rate"`
-	SizeKB    float64 `json:"size_kb"`
+	healthURLs, targetBuildinfo, expectedSHA string
+	healthTimeout                            time.Duration
+	composeProject, service                  string
+	servicePort, targetPID                   int
 }
 
-func main() {
+func defineBenchFlags(fs *flag.FlagSet) *benchFlags {
+	f := &benchFlags{headers: headerFlag{}, labels: labelFlag{}}
 	// Global flags
-	showVersion := flag.Bool("version", false, "print version and exit")
-	selfCheck := flag.Bool("self-check", false, "run internal checks and print TRACE_BENCH_OK line")
+	fs.BoolVar(&f.showVersion, "version", false, "print version and exit")
+	fs.BoolVar(&f.versionJSON, "json", false, "with -version: print the build info (VCS revision, dirty flag, build time) as JSON")
+	fs.BoolVar(&f.selfCheck, "self-check", false, "run internal checks and print TRACE_BENCH_OK line")
+	fs.StringVar(&f.logLevel, "log-level", envOr(logLevelEnv, "info"), "stderr log level: debug|info|warn|error")
+	fs.StringVar(&f.otelSelf, "otel-self", "", "export the bench's own phase spans/metrics (setup, warmup, measure, export) to this OTLP/HTTP base URL, e.g. http://otel-collector:4318")
+	fs.StringVar(&f.logFormat, "log-format", envOr(logFormatEnv, "text"), "stderr log format: text|json (slog; msg is a stable event name)")
+	fs.BoolVar(&f.failOnDeprecated, "fail-on-deprecated", false, "exit 2 before the run if a deprecated flag is used (for CI; without it deprecated flags only warn)")
+	fs.BoolVar(&f.strict, "strict", false, "reject unknown keys in YAML/JSON config files and unknown TRACE_BENCH_* environment variables instead of ignoring them")
+	fs.BoolVar(&f.selfBench, "self-bench", false, "benchmark the sample collection and export recording path against this config's p95 and print TRACE_BENCH_SELFBENCH_OK line")
 	// Bench flags (align with Day20/21 scripts)
-	sampling := flag.Float64("sampling", 1.0, "sampling rate in [0,1]")
-	serialization := flag.String("serialization", "json", "one of: json|msgpack|protobuf")
-	compression := flag.String("compression", "none", "one of: none|gzip|zstd")
-	jsonOut := flag.String("json-out", "", "write JSON result to this path")
+	fs.Float64Var(&f.sampling, "sampling", 1.0, "sampling rate in [0,1]")
+	fs.StringVar(&f.serialization, "serialization", "json", "one of: json|msgpack|protobuf")
+	fs.StringVar(&f.compression, "compression", "none", "one of: none|gzip|zstd (zstd: model mode only)")
+	fs.StringVar(&f.mode, "mode", engine.ModeModel, "one of: model|real|hybrid (real encodes synthetic spans and reports mem{}; hybrid checks the model estimate with a short real run)")
+	fs.StringVar(&f.modelFile, "model-file", "", "model/hybrid mode: coefficients fitted by trace_bench calibrate (default: the built-in model)")
+	fs.IntVar(&f.spotSpans, "spot-spans", engine.DefaultSpotSpans, "hybrid mode: spans generated by the real spot-check")
+	fs.Float64Var(&f.driftThreshold, "drift-threshold", engine.DefaultDriftThreshold, "hybrid mode: relative difference between model and spot-check (p95_ms, size_kb) that fails the run with model_drift")
+	fs.IntVar(&f.spans, "spans", engine.DefaultSpans, "real mode: spans generated per run")
+	fs.IntVar(&f.batch, "batch", engine.DefaultBatchSize, "real mode: spans per export batch")
+	fs.StringVar(&f.batchSizes, "batch-size", "", "real mode: emulate the OTel batch processor with this send_batch_size (overrides -batch); a comma list sweeps it, e.g. 100,500,2000")
+	fs.StringVar(&f.batchTimeouts, "batch-timeout", "", "real mode: batch processor flush timeout (default "+engine.DefaultBatchTimeout.String()+"); a comma list sweeps it, e.g. 200ms,1s,5s")
+	fs.Float64Var(&f.spanRate, "span-rate", 0, "batch processor: span arrival rate per second (0: unpaced, blocks instead of dropping)")
+	fs.IntVar(&f.queueSize, "queue-size", 0, fmt.Sprintf("batch processor: queue ahead of the exporters in spans (default %d); spans arriving at a full queue are dropped", engine.DefaultQueueSize))
+	fs.StringVar(&f.queueMem, "queue-mem", "", "batch processor: bound the queue by span memory instead of -queue-size, e.g. 64MB; with -soak the result reports the backpressure behavior")
+	fs.StringVar(&f.backpressure, "backpressure", "", "batch processor: what a full queue does: "+strings.Join(engine.Backpressures, "|")+" (default "+engine.BackpressureDrop+")")
+	fs.StringVar(&f.workload, "workload", engine.WorkloadPipeline, "real mode: pipeline (encode only) | http (POST each batch to -endpoint) | exec (run -exec-command per batch)")
+	fs.StringVar(&f.endpoint, "endpoint", "", "http workload: export URL, or a path (e.g. /v1/traces) on the discovered target")
+	fs.StringVar(&f.execCommand, "exec-command", "", "exec workload: shell command run per batch with the encoded batch on stdin; a non-zero exit fails the batch")
+	fs.StringVar(&f.sandbox, "sandbox", "", "exec workload (Linux): run -exec-command isolated in its own network (loopback only), pid and mount namespace with a fresh tmpfs /tmp as workdir: on, or options tmpfs:64MB,cpu:10s,mem:512MB,nofile:256,net:host")
+	fs.DurationVar(&f.timeout, "timeout", engine.DefaultTimeout, "http/exec workload: per-request (per-command) timeout")
+	fs.IntVar(&f.retries, "retries", 0, "http workload: retries for transient failures (timeout, connection errors, 5xx)")
+	fs.StringVar(&f.retryBackoff, "retry-backoff", "exp:50ms", "http workload: retry delay, exp:<dur> or const:<dur>")
+	fs.StringVar(&f.connections, "connections", engine.ConnReuse, "http workload: reuse (keep-alive) | per-request (handshake every call) | pool:N")
+	fs.StringVar(&f.tlsCert, "tls-cert", "", "http workload: client certificate (PEM) for mTLS")
+	fs.StringVar(&f.tlsKey, "tls-key", "", "http workload: client key (PEM) for mTLS")
+	fs.StringVar(&f.tlsCA, "tls-ca", "", "http workload: CA bundle (PEM) to verify the target")
+	fs.StringVar(&f.tlsServerName, "tls-server-name", "", "http workload: SNI / verification name override")
+	fs.BoolVar(&f.tlsInsecure, "tls-insecure", false, "http workload: skip server certificate verification (lab only)")
+	fs.StringVar(&f.bodyTemplate, "body-template", "", "http workload: Go template file for the request body ({{.Seq}}, {{uuid}}, {{randomLine \"file\"}}, {{randInt lo hi}})")
+	fs.StringVar(&f.script, "script", "", "http workload: Starlark request script (request(ctx) -> body or {body, headers}; optional check(resp) assertions)")
+	fs.StringVar(&f.feedPath, "feed", "", "http workload: CSV/JSONL data file exposed to -body-template as {{.Row.<column>}} and to -script as ctx.row")
+	fs.StringVar(&f.feedMode, "feed-mode", engine.FeedSequential, "feed row selection: sequential|random|unique")
+	fs.StringVar(&f.chaos, "chaos", "", "real mode: fault timeline, e.g. latency:+100ms@t=60s..90s,kill-target@t=120s,hook:./fault.sh@t=30s..60s")
+	fs.StringVar(&f.netemSpec, "netem", "", "real mode: tc/netem impairment for the whole run, e.g. delay:50ms,jitter:10ms,loss:0.5% (also duplicate, corrupt, reorder, rate:10mbit); needs -netem-dev, tc and CAP_NET_ADMIN")
+	fs.StringVar(&f.netemDev, "netem-dev", "", "interface -netem is applied to (its root qdisc is replaced for the run and removed after)")
+	fs.StringVar(&f.netemNetns, "netem-netns", "", "network namespace of -netem-dev (ip netns exec)")
+	fs.StringVar(&f.mttrSLO, "mttr-slo", "", "with -chaos: measure recovery time against an SLO, e.g. p95=50ms,error_rate=0.01")
+	fs.DurationVar(&f.mttrWindow, "mttr-window", engine.DefaultMTTRWindow, "mttr evaluation window")
+	fs.StringVar(&f.sloPath, "slo", "", "shared slo.yaml; bench_metric SLOs are checked against the result (exit 2 on breach)")
+	fs.StringVar(&f.remoteWrite, "remote-write", "", "real mode: push per-second aggregates to a Prometheus remote-write URL (e.g. http://mimir:9009/api/v1/push); a sweep pushes one series set per point")
+	fs.StringVar(&f.markHeader, "mark-header", "", "http workload: mark bench traffic with this \"Name: value\" header (<runid> is replaced) so targets can label it "+engine.SyntheticLabel+"=\"true\" and SLOs exclude it, e.g. \""+engine.DefaultMarkHeader+"\"")
+	fs.Var(f.headers, "header", "http workload: extra request header Name=value, value may be secretref://env/NAME or secretref://file/PATH (repeatable)")
+	fs.StringVar(&f.influxToken, "influx-token", "", "InfluxDB token, normally a secretref (default: $INFLUX_TOKEN)")
+	fs.StringVar(&f.influxURL, "influx-url", "", "also write the result to an InfluxDB write endpoint (token from INFLUX_TOKEN, or the -env profile's influx_token_env)")
+	fs.StringVar(&f.artifactStore, "artifact-store", "", "upload the result bundle, e.g. s3://bench-results/{date}/{sha}/ (S3 credentials from AWS_* env) or file:///srv/bench; objects go under {run_id}/ unless the URI places it; not with a sweep")
+	fs.StringVar(&f.envName, "env", "", "environment profile (dev|staging|prod|...) from -profiles: target, credential env vars, SLO thresholds")
+	fs.StringVar(&f.profilesPath, "profiles", defaultProfiles(), "environment profiles file used by -env")
+	fs.Var(&f.collectors, "collector", "custom metrics collector name=command speaking JSON over stdio (see package collector), merged into custom_metrics (repeatable)")
+	fs.StringVar(&f.preHook, "pre-hook", "", "shell command run before the measurement (run metadata in TRACE_BENCH_* env); a non-zero exit vetoes the run (exit "+fmt.Sprint(exitVetoed)+")")
+	fs.StringVar(&f.postHook, "post-hook", "", "shell command run after the measurement, with TRACE_BENCH_STATUS and TRACE_BENCH_METRIC_*; a non-zero exit vetoes the run")
+	fs.DurationVar(&f.hookTimeout, "hook-timeout", defaultHookTimeout, "timeout of each -pre-hook/-post-hook")
+	fs.StringVar(&f.lockPolicy, "lock", lockFail, "concurrent real-mode runs against the same target and config: fail (exit "+fmt.Sprint(exitLocked)+", naming the holder), wait (queue behind it) or off")
+	fs.StringVar(&f.lockDir, "lock-dir", defaultLockDir(), "directory of the run lock files (default $TRACE_BENCH_LOCK_DIR)")
+	fs.StringVar(&f.cpuAffinity, "cpu-affinity", "", "pin the generator to these CPUs, e.g. 0-3 or 0,2,4-5, keeping it off a co-located target's cores (Linux)")
+	fs.IntVar(&f.nice, "nice", 0, "scheduling niceness of the generator, -20..19 (Linux; negative needs root or CAP_SYS_NICE)")
+	fs.DurationVar(&f.collectorTimeout, "collector-timeout", collector.DefaultTimeout, "timeout of one collector request")
+	fs.Var(f.labels, "label", "key=value recorded in the result, history and exported series (repeatable, e.g. -label env=staging)")
+	fs.StringVar(&f.histPath, "history", historyPath(), "append the run to this history DB (default $TRACE_BENCH_HISTORY); a sweep appends one entry per point")
+	fs.StringVar(&f.againstName, "against", "", "print a diff against this named baseline from the history DB to stderr; not with a sweep")
+	fs.IntVar(&f.regressWindow, "regress-window", 0, "compare against the median of the last N history runs with the same config; exit 2 on a sustained (CUSUM) shift")
+	fs.StringVar(&f.perfNote, "perf-note", "", "write a perf-note.json (commit, delta vs -against, verdict) for git notes --ref=perf; not with a sweep")
+	fs.StringVar(&f.runID, "run-id", "", "run id recorded in outputs, logs and pushed series and sent to the target as "+engine.RunIDHeader+"/baggage (default: random UUID)")
+	fs.StringVar(&f.protocols, "protocols", "", "http workload: comma-separated protocols to run side by side (h1,h2; h2 needs an https endpoint)")
+	fs.StringVar(&f.quantileSketch, "quantile-sketch", stats.SketchExact, "real mode latency retention: "+strings.Join(stats.SketchKinds, "|")+" (hdr/tdigest use bounded memory)")
+	fs.IntVar(&f.workers, "workers", 1, "real mode: concurrent exporters (each with its own preallocated sample ring)")
+	fs.StringVar(&f.jsonOut, "json-out", "", "write result (see -out-format) to this path")
+	fs.StringVar(&f.outFormat, "out-format", "json", "one of: "+strings.Join(output.Formats, "|"))
+	fs.StringVar(&f.envelopeOut, "envelope", "", "also write the run as a pkg/resultenv envelope (shared proof-tool format) to this path")
+	// Soak mode
+	fs.DurationVar(&f.soak, "soak", 0, "repeat the measurement for this long (e.g. 6h) and report trends; not with a sweep")
+	fs.DurationVar(&f.checkpointEvery, "checkpoint-every", 5*time.Minute, "soak: checkpoint interval")
+	fs.StringVar(&f.checkpointOut, "checkpoint-out", "", "soak: rolling checkpoint path (default <json-out>.checkpoint.json)")
+	// Latency heatmap
+	fs.DurationVar(&f.heatmap, "heatmap", 0, "real mode: record a time × latency heatmap with this interval (e.g. 1s) into the result")
+	fs.StringVar(&f.heatmapOut, "heatmap-out", "", "also write the heatmap as CSV (time + le bucket columns) for Grafana; not with a sweep")
+	// Cost model
+	fs.StringVar(&f.costModel, "cost-model", "", "real mode: costs.yaml ($/GB stored, $/GB egress, $/core-hour); adds the monthly cost at -traffic to the result and ranks -protocols sweeps by it")
+	fs.StringVar(&f.traffic, "traffic", "", "span volume priced by -cost-model, e.g. 50M/day or 2k/s")
+	// Live terminal monitor
+	fs.BoolVar(&f.tui, "tui", false, "real mode: live terminal view of latency percentiles, RPS, error classes and -health-url status on stderr (NO_COLOR disables color)")
+	// Self-profiling (pprof)
+	fs.StringVar(&f.selfProfile, "self-profile", "", "comma-separated profiles of the bench itself: cpu,heap,allocs,goroutine,block,mutex")
+	fs.StringVar(&f.profileOut, "profile-out", ".", "directory for -self-profile output")
+	// Target discovery (docker compose labels)
+	fs.StringVar(&f.composeProject, "compose-project", "", "docker compose project of the target (e.g. duri)")
+	fs.StringVar(&f.service, "service", "", "docker compose service of the target (e.g. core)")
+	fs.IntVar(&f.servicePort, "service-port", 0, "container port to resolve (0 = first published tcp port)")
+	// Target health pre/postflight
+	fs.StringVar(&f.healthURLs, "health-url", "", "comma-separated health/readiness URLs checked before and after the run (a /path is resolved against the discovered target); unhealthy before the run exits "+fmt.Sprint(exitUnhealthy))
+	fs.StringVar(&f.targetBuildinfo, "target-buildinfo", "", "URL of the target's build-info endpoint (JSON version/sha or plain text; a /path is resolved against the discovered target), recorded as target_build")
+	fs.StringVar(&f.expectedSHA, "expected-sha", "", "with -target-buildinfo: exit "+fmt.Sprint(exitBuildMismatch)+" before the run unless the target reports this commit SHA (prefix match)")
+	fs.DurationVar(&f.healthTimeout, "health-timeout", 5*time.Second, "timeout of one -health-url or -target-buildinfo request")
+	fs.IntVar(&f.targetPID, "target-pid", 0, "real/hybrid mode: sample this local process's CPU and RSS during the measurement into target_process (Linux /proc, Windows PDH, macOS libproc in cgo builds)")
+	return f
+}
+
+// startRun은 로그·run id·strict·deprecated 검사와 -otel-self를 준비한다
+func startRun(f *benchFlags) {
+	if err := setupLog(f.logLevel, f.logFormat); err != nil {
+		fail(err)
+	}
+	if f.runID == "" {
+		f.runID = newRunID()
+	}
+	logger = logger.With("run_id", f.runID)
+	envelopePath = f.envelopeOut
+	if f.strict {
+		if err := setStrict(); err != nil {
+			fail(err)
+		}
+	}
+	runDeprecations = usedDeprecations(flag.CommandLine)
+	if err := checkDeprecations(runDeprecations, f.failOnDeprecated); err != nil {
+		logger.Error(evRunFailed, "err", err.Error())
+		exitEnvelope(resultenv.VerdictFailInfra, nil, "fail_on_deprecated")
+		os.Exit(2)
+	}
+	if f.otelSelf != "" {
+		if err := startSelfTel(f.otelSelf, "run_id", f.runID, "mode", f.mode, "workload", f.workload); err != nil {
+			fail(err)
+		}
+	}
+}
+
+func runSelfCheck() {
+	// Minimal invariants to satisfy CI guard & runner contract
+	if err := (engine.Config{Sampling: 1.0, Serialization: "json", Compression: "none"}).Validate(); err != nil {
+		fmt.Println("TRACE_BENCH_OK: false")
+		os.Exit(2)
+	}
+	// 도구 자신의 로그 ABI(logschema)도 확인
+	if err := checkLogABI(); err != nil {
+		logger.Error(evRunFailed, "err", err.Error())
+		fmt.Println("TRACE_BENCH_OK: false")
+		os.Exit(2)
+	}
+	fmt.Println("TRACE_BENCH_OK: true")
+}
+
+// benchRun은 기본 실행 한 번: 플래그에서 만든 구성과 측정 결과
+type benchRun struct {
+	f              *benchFlags
+	prof           *profile.Profile
+	influxTokenEnv string
+	cfg            engine.Config
+	healthList     []string
+	netem          *engine.Netem
+	// sweep: plan이 있으면 구성별로 순차 실행
+	plan        []engine.Config
+	sweepKey    string
+	sweepLabels []string
+	costs       *cost.Model
+	spansPerDay float64
+	benchSLOs   []slo.SLO
+
+	build     *engine.BuildInfo
+	health    *engine.HealthReport
+	hooks     []engine.HookRun
+	bundle    []artifact.File // -artifact-store로 올릴 부가 파일
+	r         engine.Result
+	sweepCfgs []engine.Config
+	sweep     []engine.Result
+	breached  bool
+}
+
+// prepareBench는 플래그를 검증해 구성과 sweep 계획을 만든다. 잘못된 조합은
+// 측정 전에 실패한다
+func prepareBench(f *benchFlags) *benchRun {
+	b := &benchRun{f: f, influxTokenEnv: "INFLUX_TOKEN"}
+	b.loadProfile()
+	b.buildConfig()
+	b.bindTarget()
+	b.applyRunOptions()
+	b.planSweep()
+	b.loadChecks()
+	return b
+}
+
+// 환경 profile: 명시하지 않은 플래그를 채우고, 자격증명은 환경변수 이름으로만 참조
+func (b *benchRun) loadProfile() {
+	f := b.f
+	if f.envName == "" {
+		return
+	}
+	pf, err := profile.Load(f.profilesPath)
+	if err != nil {
+		fail(err)
+	}
+	p, err := pf.Env(f.envName)
+	if err != nil {
+		fail(err)
+	}
+	if err := applyProfile(f.envName, p, f.labels); err != nil {
+		fail(err)
+	}
+	if p.InfluxTokenEnv != "" {
+		b.influxTokenEnv = p.InfluxTokenEnv
+	}
+	if len(p.SLO) > 0 && f.sloPath == "" {
+		logger.Warn(evConfigWarning, "env", f.envName, "reason", "slo threshold overrides are ignored without -slo")
+	}
+	b.prof = &p
+}
+
+// buildConfig는 워크로드 플래그와 요청 헤더·본문으로 engine.Config를 만든다
+func (b *benchRun) buildConfig() {
+	f := b.f
+	backoff, err := engine.ParseBackoff(f.retryBackoff)
+	if err != nil {
+		fail(err)
+	}
+	connMode, err := engine.ParseConnMode(f.connections)
+	if err != nil {
+		fail(err)
+	}
+	cfg := engine.Config{
+		Sampling:        f.sampling,
+		Serialization:   f.serialization,
+		Compression:     f.compression,
+		Mode:            f.mode,
+		SpotSpans:       f.spotSpans,
+		DriftThreshold:  f.driftThreshold,
+		Spans:           f.spans,
+		BatchSize:       f.batch,
+		Workers:         f.workers,
+		QuantileSketch:  f.quantileSketch,
+		HeatmapInterval: f.heatmap,
+		Workload:        f.workload,
+		Endpoint:        f.endpoint,
+		Command:         execArgv(f.execCommand),
+		Timeout:         f.timeout,
+		Retries:         f.retries,
+		RetryBackoff:    backoff,
+		Connections:     connMode,
+		Feed:            f.feedPath,
+		FeedMode:        f.feedMode,
+		RunID:           f.runID,
+		TargetPID:       f.targetPID,
+		TLS: engine.TLSOptions{
+			CertFile:   f.tlsCert,
+			KeyFile:    f.tlsKey,
+			CAFile:     f.tlsCA,
+			ServerName: f.tlsServerName,
+			Insecure:   f.tlsInsecure,
+		},
+	}
+	if f.sandbox != "" {
+		if cfg.Sandbox, err = engine.ParseSandbox(f.sandbox); err != nil {
+			fail(err)
+		}
+	}
+	if b.prof != nil {
+		if cfg.Headers, err = b.prof.ResolveHeaders(); err != nil {
+			fail(err)
+		}
+	}
+	extra, err := f.headers.resolve()
+	if err != nil {
+		fail(err)
+	}
+	for k, v := range extra {
+		if cfg.Headers == nil {
+			cfg.Headers = map[string]string{}
+		}
+		cfg.Headers[k] = v
+	}
+	if f.markHeader != "" {
+		k, v, err := parseMarkHeader(f.markHeader, f.runID)
+		if err != nil {
+			fail(err)
+		}
+		for h := range cfg.Headers {
+			if http.CanonicalHeaderKey(h) == k {
+				fail(fmt.Errorf("-mark-header %s is also set by -header or the profile", k))
+			}
+		}
+		if cfg.Headers == nil {
+			cfg.Headers = map[string]string{}
+		}
+		cfg.Headers[k] = v
+	}
+	if f.bodyTemplate != "" {
+		body, err := os.ReadFile(f.bodyTemplate)
+		if err != nil {
+			fail(err)
+		}
+		cfg.BodyTemplate = string(body)
+	}
+	if f.script != "" {
+		src, err := os.ReadFile(f.script)
+		if err != nil {
+			fail(err)
+		}
+		cfg.Script, cfg.ScriptFile = string(src), f.script
+	}
+	b.cfg = cfg
+}
 
-	flag.Parse()
+// 타깃 탐색: 포트 하드코딩 대신 Docker API로 공개 포트/헬스 확인
+func (b *benchRun) bindTarget() {
+	f, cfg := b.f, &b.cfg
+	if f.composeProject != "" || f.service != "" {
+		if f.composeProject == "" || f.service == "" {
+			fail(fmt.Errorf("-compose-project and -service must be set together"))
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		t, err := discoverTarget(ctx, f.composeProject, f.service, f.servicePort)
+		cancel()
+		if err != nil {
+			fail(err)
+		}
+		cfg.Target = t
+		logger.Info(evTargetDiscovered, "project", t.Project, "service", t.Service, "address", t.Address, "container", t.Container, "health", t.Health)
+		if strings.HasPrefix(cfg.Endpoint, "/") {
+			cfg.Endpoint = "http://" + t.Address + cfg.Endpoint
+		}
+	}
+	for _, u := range splitList(f.healthURLs) {
+		b.healthList = append(b.healthList, resolveTargetURL("health-url", u, cfg.Target))
+	}
+	if f.expectedSHA != "" && f.targetBuildinfo == "" {
+		fail(fmt.Errorf("-expected-sha requires -target-buildinfo"))
+	}
+	if f.chaos != "" {
+		var err error
+		if cfg.Chaos, err = engine.ParseChaos(f.chaos); err != nil {
+			fail(err)
+		}
+		if err := bindChaos(cfg.Chaos, cfg.Target); err != nil {
+			fail(err)
+		}
+	}
+}
+
+// applyRunOptions는 장애 주입·batch processor·모델 설정을 붙이고 구성을 검증한다
+func (b *benchRun) applyRunOptions() {
+	f, cfg := b.f, &b.cfg
+	var err error
+	if f.netemSpec != "" {
+		if b.netem, err = engine.ParseNetem(f.netemSpec); err != nil {
+			fail(err)
+		}
+		if f.netemDev == "" {
+			fail(fmt.Errorf("-netem requires -netem-dev"))
+		}
+		if cfg.Mode != engine.ModeReal {
+			fail(fmt.Errorf("-netem requires -mode=real"))
+		}
+		b.netem.Dev, b.netem.Netns = f.netemDev, f.netemNetns
+	}
+	if f.mttrSLO != "" {
+		o, err := engine.ParseMTTRSLO(f.mttrSLO)
+		if err != nil {
+			fail(err)
+		}
+		o.Window = f.mttrWindow
+		cfg.MTTR = &o
+	}
+	if f.tui {
+		cfg.Live = engine.NewLive(0)
+	}
+	if f.batchSizes != "" || f.batchTimeouts != "" {
+		cfg.SpanRate, cfg.QueueSize, cfg.Backpressure = f.spanRate, f.queueSize, f.backpressure
+		if f.queueMem != "" {
+			if cfg.QueueMem, err = parseBytes(f.queueMem); err != nil || cfg.QueueMem == 0 {
+				fail(fmt.Errorf("invalid -queue-mem: %q", f.queueMem))
+			}
+		}
+	} else if f.spanRate != 0 || f.queueSize != 0 || f.queueMem != "" || f.backpressure != "" {
+		fail(fmt.Errorf("-span-rate, -queue-size, -queue-mem and -backpressure require -batch-size or -batch-timeout"))
+	}
+	if f.modelFile != "" {
+		if cfg.Mode == engine.ModeReal {
+			fail(fmt.Errorf("-model-file requires -mode=%s or %s", engine.ModeModel, engine.ModeHybrid))
+		}
+		mf, err := engine.LoadModel(f.modelFile)
+		if err != nil {
+			fail(err)
+		}
+		cfg.Model = mf.Coefficients
+	}
+	// sweep 계획이 구성을 복사하므로 그 전에 설정
+	if f.remoteWrite != "" {
+		if cfg.Mode != engine.ModeReal {
+			fail(fmt.Errorf("-remote-write requires -mode=%s", engine.ModeReal))
+		}
+		cfg.TimelineInterval = time.Second
+	}
+}
 
-	if *showVersion {
-		fmt.Printf("trace_bench %s\n", version)
+// planSweep은 한 차원(-protocols) 또는 batch processor 설정(-batch-size ×
+// -batch-timeout)의 sweep 계획을 만든다. 결과 하나를 전제로 하는 플래그는
+// sweep과 함께 쓰면 여기서 거부한다
+func (b *benchRun) planSweep() {
+	f, cfg := b.f, &b.cfg
+	sizeList, timeoutList, err := parseBatchSweep(f.batchSizes, f.batchTimeouts, f.batch)
+	if err != nil {
+		fail(err)
+	}
+	if len(sizeList) > 0 {
+		cfg.BatchSize, cfg.BatchTimeout = sizeList[0], timeoutList[0]
+	}
+	if err := cfg.Validate(); err != nil {
+		fail(err)
+	}
+	// 구성 레이블과 겹치면 시계열/추세가 섞이므로 금지
+	for k := range f.labels {
+		if _, ok := output.ConfigLabels(*cfg)[k]; ok || k == "run_id" || k == "protocol" || k == "workload" {
+			fail(fmt.Errorf("-label %s collides with a built-in label", k))
+		}
+	}
+	protoList := splitList(f.protocols)
+	for _, p := range protoList {
+		c := *cfg
+		c.Protocol = p
+		if err := c.Validate(); err != nil {
+			fail(err)
+		}
+	}
+	if len(protoList) == 1 {
+		cfg.Protocol = protoList[0]
+	}
+	b.sweepKey = "protocol"
+	switch batchSweep := len(sizeList) > 1 || len(timeoutList) > 1; {
+	case len(protoList) > 1 && batchSweep:
+		fail(fmt.Errorf("-protocols cannot be swept together with -batch-size/-batch-timeout"))
+	case len(protoList) > 1:
+		for _, p := range protoList {
+			c := *cfg
+			c.Protocol = p
+			b.plan = append(b.plan, c)
+		}
+	case batchSweep:
+		for _, size := range sizeList {
+			for _, to := range timeoutList {
+				c := *cfg
+				c.BatchSize, c.BatchTimeout = size, to
+				b.plan = append(b.plan, c)
+			}
+		}
+		switch {
+		case len(sizeList) > 1 && len(timeoutList) > 1:
+			b.sweepKey = "batch_size,batch_timeout"
+		case len(sizeList) > 1:
+			b.sweepKey = "batch_size"
+		default:
+			b.sweepKey = "batch_timeout"
+		}
+	}
+	b.sweepLabels = output.SweepLabels(b.sweepKey, b.plan)
+	if b.plan == nil {
 		return
 	}
-	if *selfCheck {
-		// Minimal invariants to satisfy CI guard & runner contract
-		if err := validateInputs(1.0, "json", "none"); err != nil {
-			fmt.Println("TRACE_BENCH_OK: false")
-			os.Exit(2)
+	// -history와 -remote-write는 sweep 지점마다 따로 적용(구성 레이블로 구분)
+	for _, o := range []struct {
+		name string
+		set  bool
+	}{
+		{"artifact-store", f.artifactStore != ""},
+		{"against", f.againstName != ""},
+		{"perf-note", f.perfNote != ""},
+		{"heatmap-out", f.heatmapOut != ""},
+		{"soak", f.soak > 0},
+	} {
+		if o.set {
+			fail(fmt.Errorf("-%s is not supported with a sweep", o.name))
+		}
+	}
+}
+
+// loadChecks는 비용 모델·SLO를 읽고 나머지 실행 옵션을 검증한다
+func (b *benchRun) loadChecks() {
+	f := b.f
+	var err error
+	if f.costModel != "" {
+		if b.cfg.Mode != engine.ModeReal {
+			fail(fmt.Errorf("-cost-model requires -mode=%s", engine.ModeReal))
+		}
+		if f.traffic == "" {
+			fail(fmt.Errorf("-cost-model requires -traffic"))
+		}
+		if b.costs, err = cost.Load(f.costModel); err != nil {
+			fail(err)
+		}
+		if b.spansPerDay, err = cost.ParseTraffic(f.traffic); err != nil {
+			fail(err)
+		}
+	}
+	if f.sloPath != "" {
+		defs, err := slo.Load(f.sloPath)
+		if err != nil {
+			fail(err)
+		}
+		if b.prof != nil {
+			if defs.SLOs, err = b.prof.ApplySLO(defs.SLOs); err != nil {
+				fail(err)
+			}
+		}
+		b.benchSLOs = defs.Bench()
+	}
+	if f.regressWindow > 0 && f.histPath == "" {
+		fail(fmt.Errorf("-regress-window requires -history"))
+	}
+	if f.lockPolicy != lockFail && f.lockPolicy != lockWait && f.lockPolicy != lockOff {
+		fail(fmt.Errorf("invalid -lock %q (fail|wait|off)", f.lockPolicy))
+	}
+	if !output.ValidFormat(f.outFormat) {
+		fail(fmt.Errorf("invalid out-format: %s", f.outFormat))
+	}
+}
+
+func (b *benchRun) selfBench() {
+	// 타깃 p95는 현재 구성으로 한 번 실행해 얻는다
+	r, err := engine.New().Run(context.Background(), b.cfg)
+	if err != nil {
+		fail(err)
+	}
+	sb, err := runSelfBench(b.cfg, b.f.workers, b.f.quantileSketch, r.P95ms)
+	if err != nil {
+		fail(err)
+	}
+	output.WriteJSON(os.Stderr, sb)
+	fmt.Printf("TRACE_BENCH_SELFBENCH_OK: %v\n", sb.OK)
+	if !sb.OK {
+		os.Exit(2)
+	}
+}
+
+// === 연결 포인트(핵심): 실제 계측 로직을 여기에 삽입 ===
+// 아래 measure()는 현재 합리적·결정론적 계산으로 대체되어 있습니다.
+// 실제 환경에서는:
+//   - 대상 워크로드를 N회 실행하고 p95 latency를 산출
+//   - 오류율(실패/총 요청), 출력 크기(KB) 등을 계측
+//   - 필요 시 PID/port 기반으로 실서비스에 주입한 설정을 확인
+//
+// -mode=model: 결정론적 추정기(engine/model.go), -mode=real: 합성 span 실측(engine/real.go)
+func (b *benchRun) measure() {
+	f, cfg := b.f, &b.cfg
+	applySched(f.cpuAffinity, f.nice)
+	// 같은 대상+구성에 동시에 도는 run은 서로의 결과를 오염시킨다
+	acquireRunLock(f.lockPolicy, f.lockDir, f.runID, *cfg, b.plan)
+	if f.targetBuildinfo != "" {
+		b.build = probeBuild(resolveTargetURL("target-buildinfo", f.targetBuildinfo, cfg.Target), f.expectedSHA, f.healthTimeout, cfg.TLS)
+	}
+	if len(b.healthList) > 0 {
+		b.health = &engine.HealthReport{Pre: checkHealth("pre", b.healthList, f.healthTimeout, cfg.TLS)}
+		if !engine.Healthy(b.health.Pre) {
+			self.finish(nil, f.runID, fmt.Errorf("target unhealthy"))
+			exitEnvelope(resultenv.VerdictFailInfra, b.health, "target_unhealthy")
+			os.Exit(exitUnhealthy)
+		}
+	}
+	// pre-hook(캐시 워밍, DB 리셋 등)은 측정 절차의 일부로 결과에 기록
+	if f.preHook != "" {
+		h := runRunHook(engine.HookPre, f.preHook, f.hookTimeout, hookEnv(engine.HookPre, f.runID, *cfg, f.labels.orNil(), nil, nil))
+		if h.ExitCode != 0 {
+			vetoRun(h, f.runID)
+		}
+		b.hooks = append(b.hooks, h)
+	}
+	var stopProfile func() ([]string, error)
+	if f.selfProfile != "" {
+		stop, err := startSelfProfile(f.selfProfile, f.profileOut)
+		if err != nil {
+			fail(err)
+		}
+		stopProfile = stop
+	}
+	cfg.Phases = self.phases()
+	custom, err := startCollectors(f.collectors, f.runID, f.labels.orNil(), f.collectorTimeout)
+	if err != nil {
+		fail(err)
+	}
+	var monitor *runMonitor
+	if cfg.Live != nil {
+		monitor = newRunMonitor(fmt.Sprintf("run %s  %s", f.runID, fmtLabelSet(output.ConfigLabels(*cfg))), cfg.Live, b.benchSLOs, b.healthList, f.healthTimeout, cfg.TLS)
+		cfg.Phases = monitor.phases(cfg.Phases)
+		monitor.start()
+	}
+	runCtx := self.ctx
+	var stopNetem func()
+	if b.netem != nil {
+		if stopNetem, err = startNetem(b.netem); err != nil {
+			fail(err)
+		}
+		// 중단돼도 qdisc를 지우도록 신호를 run 취소로 바꾼다
+		var stop context.CancelFunc
+		runCtx, stop = signal.NotifyContext(runCtx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+	}
+	switch {
+	case b.plan != nil:
+		// sweep: 동일 워크로드를 구성별로 순차 실행
+		for i, c := range b.plan {
+			pr, perr := engine.New().Run(runCtx, c)
+			if perr != nil {
+				err = fmt.Errorf("%s %s: %w", b.sweepKey, b.sweepLabels[i], perr)
+				break
+			}
+			b.sweepCfgs = append(b.sweepCfgs, c)
+			b.sweep = append(b.sweep, pr)
+		}
+	case f.soak > 0:
+		cp := checkpointPath(f.checkpointOut, f.jsonOut)
+		b.r, err = runSoak(runCtx, *cfg, f.soak, f.checkpointEvery, cp)
+		b.bundle = append(b.bundle, artifact.File{Path: cp, ContentType: "application/json"})
+	default:
+		b.r, err = engine.New().Run(runCtx, *cfg)
+	}
+	if stopNetem != nil {
+		stopNetem()
+	}
+	if monitor != nil {
+		monitor.finish()
+	}
+	// 프로토콜 비교에서는 실행 전체의 값을 각 결과에 붙인다
+	measured := []*engine.Result{&b.r}
+	for i := range b.sweep {
+		measured = append(measured, &b.sweep[i])
+	}
+	finishCollectors(custom, measured...)
+	if stopProfile != nil {
+		files, perr := stopProfile()
+		if perr != nil {
+			fail(perr)
+		}
+		logger.Info(evProfileWritten, "files", files)
+		for _, p := range files {
+			b.bundle = append(b.bundle, artifact.File{Path: p, ContentType: "application/octet-stream"})
+		}
+	}
+	// post-hook은 측정 실패 시에도 실행(정리 작업), 거부하면 결과를 남기지 않음
+	if f.postHook != "" {
+		h := runRunHook(engine.HookPost, f.postHook, f.hookTimeout, hookEnv(engine.HookPost, f.runID, *cfg, f.labels.orNil(), &b.r, err))
+		if h.ExitCode != 0 {
+			vetoRun(h, f.runID)
+		}
+		b.hooks = append(b.hooks, h)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+// judge는 실행 전체의 메타데이터를 결과에 붙이고 SLO·model drift·비용을 판정한다
+func (b *benchRun) judge() {
+	f := b.f
+	if b.health != nil {
+		b.health.Post = checkHealth("post", b.healthList, f.healthTimeout, b.cfg.TLS)
+		b.r.Health, b.r.TargetDegradedPost = b.health, !engine.Healthy(b.health.Post)
+	}
+	var host *engine.HostInfo
+	if b.cfg.Measures() {
+		host = engine.DetectHost()
+		host.CPUAffinity, host.Nice = f.cpuAffinity, f.nice
+	}
+	b.r.TargetBuild = b.build
+	b.r.Host = host
+	b.r.Netem = b.netem
+	b.r.Labels = f.labels.orNil()
+	b.r.Hooks = b.hooks
+	for i := range b.sweep {
+		s := &b.sweep[i]
+		s.Host = host
+		s.Netem = b.netem
+		s.Labels = f.labels.orNil()
+		s.Hooks = b.hooks
+		s.Health, s.TargetDegradedPost = b.r.Health, b.r.TargetDegradedPost
+		s.TargetBuild = b.build
+	}
+	if b.costs != nil {
+		price := func(r *engine.Result) {
+			var err error
+			if r.Cost, err = r.Price(*b.costs, b.spansPerDay); err != nil {
+				fail(err)
+			}
+		}
+		if b.sweep == nil {
+			price(&b.r)
 		}
-		fmt.Println("TRACE_BENCH_OK: true")
+		for i := range b.sweep {
+			price(&b.sweep[i])
+		}
+	}
+	b.check(&b.r)
+	for i := range b.sweep {
+		b.check(&b.sweep[i])
+	}
+}
+
+// check는 결과 하나의 SLO 위반과 model drift를 기록하고 throttling을 경고한다
+func (b *benchRun) check(r *engine.Result) {
+	if b.benchSLOs != nil {
+		r.SLO = slo.Evaluate(b.benchSLOs, r.Metrics())
+		for _, c := range r.SLO {
+			if !c.Pass {
+				b.breached = true
+				logger.Warn(evSLOBreached, "slo", c.Name, "metric", c.Metric, "value", c.Value, "threshold", c.Threshold)
+			}
+		}
+	}
+	if r.ModelDrift {
+		b.breached = true
+		logger.Warn(evModelDrift, "metrics", r.Hybrid.Drifted, "drift", r.Hybrid.Drift, "threshold", r.Hybrid.Threshold,
+			"spot_spans", r.Hybrid.SpotSpans)
+	}
+	warnThrottled(*r)
+}
+
+func (b *benchRun) writeInflux() {
+	f := b.f
+	if f.influxURL == "" {
 		return
 	}
+	cfgs, rs := []engine.Config{b.cfg}, []engine.Result{b.r}
+	if b.sweep != nil {
+		cfgs, rs = b.sweepCfgs, b.sweep
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	token, err := secretref.Env(b.influxTokenEnv)
+	if f.influxToken != "" {
+		token, err = secretref.Resolve(f.influxToken)
+	}
+	if err != nil {
+		fail(err)
+	}
+	if err := output.InfluxWrite(ctx, f.influxURL, token, cfgs, rs); err != nil {
+		fail(err)
+	}
+	logger.Info(evInfluxWritten, "url", f.influxURL)
+}
 
-	// Bench mode
-	if err := validateInputs(*sampling, *serialization, *compression); err != nil {
+// pushSeries는 -remote-write로 run 하나의 초 단위 시계열을 보낸다
+func (b *benchRun) pushSeries(cfg engine.Config, r engine.Result) {
+	if b.f.remoteWrite == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := output.RemoteWrite(ctx, b.f.remoteWrite, seriesLabels(cfg, r, b.f.runID), r.Timeline)
+	cancel()
+	if err != nil {
 		fail(err)
 	}
+	logger.Info(evRemoteWritten, "url", b.f.remoteWrite)
+}
 
-	// === 연결 포인트(핵심): 실제 계측 로직을 여기에 삽입 ===
-	// 아래 measure()는 현재 합리적·결정론적 계산으로 대체되어 있습니다.
-	// 실제 환경에서는:
-	//  - 대상 워크로드를 N회 실행하고 p95 latency를 산출
-	//  - 오류율(실패/총 요청), 출력 크기(KB) 등을 계측
-	//  - 필요 시 PID/port 기반으로 실서비스에 주입한 설정을 확인
-	//
-	// 예: r, err := measure(*sampling, *serialization, *compression)
-	//    (실제 구현으로 교체)
-	r, err := modelBasedEstimation(*sampling, *serialization, *compression)
+// addHistory는 -history에 run 하나를 추가하고 회귀한 metric을 돌려준다
+func (b *benchRun) addHistory(cfg engine.Config, r engine.Result) []string {
+	if b.f.histPath == "" {
+		return nil
+	}
+	regressed, err := recordHistory(b.f.histPath, b.f.runID, cfg, r, b.f.regressWindow)
 	if err != nil {
 		fail(err)
 	}
+	b.breached = b.breached || len(regressed) > 0
+	return regressed
+}
+
+// writeSweep은 sweep 결과를 지점별 시계열·history와 표·sweep 출력으로 남긴다
+func (b *benchRun) writeSweep() {
+	f := b.f
+	var regressed []string
+	for i, c := range b.sweepCfgs {
+		b.pushSeries(c, b.sweep[i])
+		for _, m := range b.addHistory(c, b.sweep[i]) {
+			regressed = append(regressed, b.sweepLabels[i]+"."+m)
+		}
+	}
+	if f.envelopeOut != "" {
+		if err := writeEnvelope(f.envelopeOut, sweepEnvelope(b.sweepLabels, b.sweep, regressed)); err != nil {
+			fail(err)
+		}
+	}
+	output.WriteTable(os.Stderr, b.sweepLabels, b.sweep)
+	write := func(w io.Writer) error { return output.WriteSweep(w, f.outFormat, b.sweepKey, b.sweepCfgs, b.sweep) }
+	if f.jsonOut == "" {
+		write(os.Stdout)
+		return
+	}
+	if err := output.WriteFileAtomicFunc(f.jsonOut, write); err != nil {
+		fail(err)
+	}
+	logger.Info(evResultWritten, "sweep", b.sweepKey, "values", strings.Join(b.sweepLabels, ","), "path", f.jsonOut)
+}
+
+// writeRun은 단일 run의 시계열·heatmap·artifact·history·perf note와 결과를 쓴다
+func (b *benchRun) writeRun() {
+	f, r := b.f, &b.r
+	b.pushSeries(b.cfg, *r)
+	if f.heatmapOut != "" {
+		if r.Heatmap == nil {
+			fail(fmt.Errorf("-heatmap-out requires -mode=real and -heatmap"))
+		}
+		if err := output.WriteFileAtomicFunc(f.heatmapOut, func(w io.Writer) error {
+			return output.WriteHeatmapCSV(w, r.Heatmap)
+		}); err != nil {
+			fail(err)
+		}
+		b.bundle = append(b.bundle, artifact.File{Path: f.heatmapOut, ContentType: "text/csv"})
+	}
+	if f.artifactStore != "" {
+		if err := uploadArtifacts(f.artifactStore, f.runID, b.bundle, b.cfg, f.outFormat, f.jsonOut, r); err != nil {
+			fail(err)
+		}
+		logger.Info(evArtifactsUpload, "count", len(r.Artifacts), "store", f.artifactStore)
+	}
+	var delta []output.MetricDiff
+	if f.againstName != "" {
+		var err error
+		if delta, err = against(f.histPath, f.againstName, *r); err != nil {
+			fail(err)
+		}
+	}
+	regressed := b.addHistory(b.cfg, *r)
+	if f.perfNote != "" {
+		note := newPerfNote(f.runID, f.againstName, *r, delta, regressed)
+		if err := output.WriteFileAtomicFunc(f.perfNote, func(w io.Writer) error { return writeIndented(w, note) }); err != nil {
+			fail(err)
+		}
+		logger.Info(evPerfNoteWritten, "verdict", note.Verdict, "path", f.perfNote, "commit", note.Commit,
+			"attach", fmt.Sprintf("git notes --ref=perf add -f -F %s %s", f.perfNote, note.Commit))
+	}
+
+	if f.envelopeOut != "" {
+		if err := writeEnvelope(f.envelopeOut, runEnvelope(*r, regressed)); err != nil {
+			fail(err)
+		}
+	}
 
 	// 출력 경로 결정
-	if *jsonOut == "" {
+	if f.jsonOut == "" {
 		// stdout로 내보내되, 원자성은 호출측에서 보장
-		writeJSON(os.Stdout, r)
+		output.Write(os.Stdout, f.outFormat, b.cfg, *r)
 		return
 	}
 	// 원자적 쓰기
-	tmp := *jsonOut + ".tmp"
-	f, err := os.Create(tmp)
-	if err != nil {
+	if err := output.WriteFileAtomicFunc(f.jsonOut, func(w io.Writer) error {
+		return output.Write(w, f.outFormat, b.cfg, *r)
+	}); err != nil {
 		fail(err)
 	}
-	if err := writeJSON(f, r); err != nil {
-		f.Close()
-		_ = os.Remove(tmp)
-		fail(err)
+	logger.Info(evResultWritten, "sampling", f.sampling, "serialization", f.serialization, "compression", f.compression, "path", f.jsonOut)
+}
+
+// 소크 실행: SIGINT/SIGTERM 시 조기 종료하되 그때까지의 결과는 보고
+func runSoak(parent context.Context, cfg engine.Config, d, every time.Duration, cpPath string) (engine.Result, error) {
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return engine.New().Soak(ctx, cfg, engine.SoakOptions{
+		Duration:        d,
+		CheckpointEvery: every,
+		OnCheckpoint: func(rep engine.SoakReport) error {
+			// 체크포인트마다 원자적 rename
+			if err := output.WriteJSONFile(cpPath, rep); err != nil {
+				return err
+			}
+			logger.Info(evSoakCheckpoint, "checkpoint", len(rep.Checkpoints), "runs", rep.Runs, "elapsed_s", rep.DurationS, "path", cpPath)
+			return nil
+		},
+	})
+}
+
+// 원격 시계열 레이블: run 식별 + 구성 + -label
+func seriesLabels(cfg engine.Config, r engine.Result, runID string) map[string]string {
+	l := output.RunLabels(cfg, r)
+	l["run_id"] = runID
+	return l
+}
+
+// newRunID는 UUID v4
+func newRunID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b[:])
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// recordHistory는 run을 history에 추가한다. window>0이면 추가 전에 직전 run들과
+// 비교해 지속적으로 회귀한 metric을 돌려준다
+func recordHistory(path, runID string, cfg engine.Config, r engine.Result, window int) ([]string, error) {
+	db, err := history.Open(path)
+	if err != nil {
+		return nil, err
 	}
-	_ = f.Close()
-	if err := os.Rename(tmp, *jsonOut); err != nil {
-		fail(err)
+	e := history.Entry{RunID: runID, Time: time.Now().UTC(), Labels: output.RunLabels(cfg, r), Result: r}
+	var regressed []string
+	if window > 0 {
+		all, err := db.Entries()
+		if err != nil {
+			return nil, err
+		}
+		opt := history.DefaultShiftOptions
+		opt.Window = window
+		prev := history.Comparable(all, e)
+		regressed = reportShifts(min(len(prev), window), history.DetectShifts(prev, e, opt))
 	}
-	fmt.Fprintf(os.Stderr, "[BENCH] sampling=%v, ser=%s, comp=%s -> %s\n", *sampling, *serialization, *compression, *jsonOut)
+	return regressed, db.Append(e)
 }
 
-// 입력 검증
-func validateInputs(sampling float64, serialization, compression string) error {
-	if sampling < 0.0 || sampling > 1.0 {
-		return fmt.Errorf("invalid sampling: %v (expected [0,1])", sampling)
+// parseBatchSweep는 -batch-size/-batch-timeout 목록(빠진 쪽은 -batch, 기본 timeout).
+// 둘 다 비면 batch processor를 쓰지 않는다
+func parseBatchSweep(sizes, timeouts string, batch int) ([]int, []time.Duration, error) {
+	if sizes == "" && timeouts == "" {
+		return nil, nil, nil
 	}
-	switch strings.ToLower(serialization) {
-	case "json", "msgpack", "protobuf":
-	default:
-		return fmt.Errorf("invalid serialization: %s", serialization)
+	var sizeList []int
+	for _, v := range splitList(sizes) {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, nil, fmt.Errorf("invalid -batch-size: %q", v)
+		}
+		sizeList = append(sizeList, n)
 	}
-	switch strings.ToLower(compression) {
-	case "none", "gzip", "zstd":
-	default:
-		return fmt.Errorf("invalid compression: %s", compression)
-	}
-	return nil
-}
-
-// 실제 계측 로직 자리에 있는 결정론적 추정기
-// - 무작위값 없음(재현성)
-// - 스크립트의 SLO/형식을 충족
-// 이후 실제 측정치로 치환하세요.
-func modelBasedEstimation(sampling float64, ser, comp string) (result, error) {
-	// 기준선(예: 750ms, 100KB)
-	baseP95 := 750.0
-	baseSize := 100.0
-	baseErr := 0.0020 // 0.2%
-
-	// Serialization/Compression 계수
-	serMul := map[string]float64{
-		"json":     1.00,
-		"msgpack":  0.96,
-		"protobuf": 0.94,
-	}[strings.ToLower(ser)]
-
-	compMul := map[string]float64{
-		"none": 1.00,
-		"gzip": 0.98,
-		"zstd": 0.96,
-	}[strings.ToLower(comp)]
-
-	// p95: 샘플링↑ → 오쵸(오버헤드)↓ 가정
-	p95 := baseP95 * (1.02 - 0.15*sampling) * serMul * compMul
-	if p95 < 1 {
-		p95 = 1
-	}
-	// error_rate: 샘플링↑ → 수집 안정성↑(약간) 가정
-	errRate := baseErr * (1.04 - 0.20*sampling)
-	if errRate < 0 {
-		errRate = 0
-	}
-	// size_kb: 샘플링↑ 및 직렬화/압축에 비례
-	serSizeMul := map[string]float64{
-		"json":     1.00,
-		"msgpack":  0.85,
-		"protobuf": 0.80,
-	}[strings.ToLower(ser)]
-	compSizeMul := map[string]float64{
-		"none": 1.00,
-		"gzip": 0.70,
-		"zstd": 0.55,
-	}[strings.ToLower(comp)]
-	sizeKB := baseSize * (0.60 + 0.50*sampling) * serSizeMul * compSizeMul
-	if sizeKB < 0 {
-		sizeKB = 0
-	}
-
-	// 최소 실행시간(실측 대체 구간 표시/동기화용): 10~30ms 대기
-	// 실제 구현에서는 대상 워크로드를 호출하고 그 시간 분포를 기록하세요.
-	time.Sleep(15 * time.Millisecond)
-
-	return result{
-		P95ms:     round2(p95),
-		ErrorRate: round5(errRate),
-		SizeKB:    round2(sizeKB),
-	}, nil
-}
-
-func round2(x float64) float64 { return float64(int(x*100+0.5)) / 100 }
-func round5(x float64) float64 { return float64(int(x*100000+0.5)) / 100000 }
-
-func writeJSON(w *os.File, r result) error {
-	enc := json.NewEncoder(w)
-	enc.SetEscapeHTML(false)
-	enc.SetIndent("", "")
-	return enc.Encode(r)
+	if len(sizeList) == 0 {
+		sizeList = []int{batch}
+	}
+	var timeoutList []time.Duration
+	for _, v := range splitList(timeouts) {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, nil, fmt.Errorf("invalid -batch-timeout: %q", v)
+		}
+		timeoutList = append(timeoutList, d)
+	}
+	if len(timeoutList) == 0 {
+		timeoutList = []time.Duration{engine.DefaultBatchTimeout}
+	}
+	return sizeList, timeoutList, nil
+}
+
+// parseBytes는 64MB, 512KiB 같은 크기를 읽는다(KB/MB/GB도 1024배로 취급)
+func parseBytes(s string) (int64, error) {
+	num := strings.TrimRight(s, "KMGTiBb")
+	unit := strings.ToUpper(strings.TrimSuffix(strings.TrimSuffix(s[len(num):], "B"), "b"))
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	mult := map[string]float64{"": 1, "K": 1 << 10, "KI": 1 << 10, "M": 1 << 20, "MI": 1 << 20, "G": 1 << 30, "GI": 1 << 30}
+	m, ok := mult[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size unit in %q", s)
+	}
+	return int64(f * m), nil
+}
+
+// warnThrottled는 측정 중 생성기 cgroup이 CPU 쿼터에 걸렸으면 경고한다.
+// 모든 워커가 함께 멈춰 p95가 타깃과 무관하게 부풀어 있다
+func warnThrottled(r engine.Result) {
+	if t := r.Throttle; t != nil && t.ThrottledPeriods > 0 {
+		logger.Warn(evCPUThrottled, "throttled_periods", t.ThrottledPeriods, "periods", t.Periods,
+			"throttled_ms", t.ThrottledMs, "ratio", t.Ratio, "p95_ms", r.P95ms)
+	}
+}
+
+// execArgv는 -exec-command를 hook과 같이 sh -c로 실행한다
+func execArgv(command string) []string {
+	if command == "" {
+		return nil
+	}
+	return []string{"sh", "-c", command}
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func checkpointPath(explicit, jsonOut string) string {
+	if explicit != "" {
+		return explicit
+	}
+	if jsonOut != "" {
+		return jsonOut + ".checkpoint.json"
+	}
+	return "trace_bench.checkpoint.json"
 }
 
 func fail(err error) {
-	fmt.Fprintln(os.Stderr, "[ERR]", err.Error())
+	logger.Error(evRunFailed, "err", err.Error())
+	self.finish(nil, "", err)
+	exitEnvelope(resultenv.VerdictFailInfra, map[string]string{"error": err.Error()}, "run_failed")
 	os.Exit(1)
 }
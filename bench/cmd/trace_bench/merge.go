@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/slo"
+)
+
+// mergeFlags는 merge 명령의 플래그 값
+type mergeFlags struct {
+	out     string
+	sloPath string
+	runID   string
+}
+
+func (o *mergeFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&o.out, "out", "", "merged result path (default stdout)")
+	fs.StringVar(&o.sloPath, "slo", "", "re-evaluate bench_metric SLOs on the merged result (exit 2 on breach)")
+	fs.StringVar(&o.runID, "run-id", "", "run id of the merged result when the shards differ (default: new id)")
+}
+
+// merge 모드: trace_bench merge shard-*.json -out merged.json
+// 병렬 shard 결과를 합친다. 백분위는 평균하지 않고 합친 heatmap에서 다시 구한다
+func runMerge(args []string) {
+	var o mergeFlags
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	o.define(fs)
+	// 플래그와 shard 파일이 섞여 와도 된다(shard-*.json -out merged.json)
+	var files []string
+	for rest := args; ; {
+		fs.Parse(rest)
+		if fs.NArg() == 0 {
+			break
+		}
+		files = append(files, fs.Arg(0))
+		rest = fs.Args()[1:]
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: trace_bench merge [-out=merged.json] [-slo=slo.yaml] [-run-id=ID] shard.json...")
+		os.Exit(2)
+	}
+	shards := make([]engine.Result, len(files))
+	for i, f := range files {
+		r, err := readResult(f)
+		if err != nil {
+			fail(err)
+		}
+		shards[i] = r
+	}
+	r, err := engine.MergeResults(shards)
+	if err != nil {
+		fail(err)
+	}
+	if o.runID != "" {
+		r.RunID = o.runID
+	} else if r.RunID == "" {
+		r.RunID = newRunID()
+	}
+	breached := false
+	if o.sloPath != "" {
+		defs, err := slo.Load(o.sloPath)
+		if err != nil {
+			fail(err)
+		}
+		r.SLO = slo.Evaluate(defs.Bench(), r.Metrics())
+		for _, c := range r.SLO {
+			if !c.Pass {
+				breached = true
+				logger.Warn(evSLOBreached, "slo", c.Name, "metric", c.Metric, "value", c.Value, "threshold", c.Threshold)
+			}
+		}
+	}
+	logger.Info(evMergeResult, "shards", len(shards), "batches", r.Volume.Batches, "p95_ms", r.P95ms, "error_rate", r.ErrorRate)
+	if o.out == "" {
+		if err := output.WriteJSON(os.Stdout, r); err != nil {
+			fail(err)
+		}
+	} else {
+		if err := output.WriteJSONFile(o.out, r); err != nil {
+			fail(err)
+		}
+		logger.Info(evResultWritten, "path", o.out)
+	}
+	if breached {
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/duri/trace_bench/engine"
+)
+
+// tc 명령: netns가 있으면 ip netns exec로 감싼다
+func tcCommand(n *engine.Netem, args ...string) *exec.Cmd {
+	if n.Netns != "" {
+		return exec.Command("ip", append([]string{"netns", "exec", n.Netns, "tc"}, args...)...)
+	}
+	return exec.Command("tc", args...)
+}
+
+func runTC(n *engine.Netem, args ...string) error {
+	cmd := tcCommand(n, args...)
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w: %s", strings.Join(cmd.Args, " "), err, strings.TrimSpace(out.String()))
+	}
+	return nil
+}
+
+// startNetem은 dev의 root qdisc로 netem을 건다. 이미 root qdisc가 있으면 남의
+// 설정을 덮어쓰지 않도록 실패한다. 반환한 함수가 qdisc를 지운다
+func startNetem(n *engine.Netem) (func(), error) {
+	args := append([]string{"qdisc", "add", "dev", n.Dev, "root"}, n.Qdisc...)
+	if err := runTC(n, args...); err != nil {
+		if strings.Contains(err.Error(), "File exists") {
+			return nil, fmt.Errorf("-netem: %s already has a root qdisc (remove it first: tc qdisc del dev %s root): %w", n.Dev, n.Dev, err)
+		}
+		return nil, fmt.Errorf("-netem: %w (needs tc, the sch_netem kernel module and CAP_NET_ADMIN)", err)
+	}
+	logger.Info(evNetemApplied, "dev", n.Dev, "netns", n.Netns, "qdisc", strings.Join(n.Qdisc, " "))
+	return func() {
+		if err := runTC(n, "qdisc", "del", "dev", n.Dev, "root"); err != nil {
+			logger.Warn(evNetemRemoved, "dev", n.Dev, "err", err.Error())
+			return
+		}
+		logger.Info(evNetemRemoved, "dev", n.Dev, "netns", n.Netns)
+	}, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// perfNote는 커밋에 git notes(--ref=perf)로 붙이는 성능 기록.
+// `git notes --ref=perf show <sha>`로 오프라인 조회
+type perfNote struct {
+	Commit   string              `json:"commit"`
+	RunID    string              `json:"run_id,omitempty"`
+	Time     time.Time           `json:"time"`
+	Metrics  map[string]float64  `json:"metrics"`
+	Baseline string              `json:"baseline,omitempty"`
+	Delta    []output.MetricDiff `json:"delta,omitempty"`
+	Verdict  resultenv.Verdict   `json:"verdict"` // pass|fail_slo
+	Reasons  []string            `json:"reasons,omitempty"`
+}
+
+func newPerfNote(runID, baseline string, r engine.Result, delta []output.MetricDiff, regressed []string) perfNote {
+	n := perfNote{
+		Commit:   commitSHA(),
+		RunID:    runID,
+		Time:     time.Now().UTC(),
+		Metrics:  r.Metrics(),
+		Baseline: baseline,
+		Delta:    delta,
+	}
+	for _, c := range r.SLO {
+		if !c.Pass {
+			n.Reasons = append(n.Reasons, "slo:"+c.Name)
+		}
+	}
+	for _, m := range regressed {
+		n.Reasons = append(n.Reasons, "regress:"+m)
+	}
+	n.Verdict = resultenv.Judge(n.Reasons, nil)
+	return n
+}
+
+// commitSHA는 CI 환경변수, 없으면 git HEAD의 전체 SHA
+func commitSHA() string {
+	for _, env := range []string{"GITHUB_SHA", "CI_COMMIT_SHA", "GIT_COMMIT"} {
+		if s := os.Getenv(env); s != "" {
+			return s
+		}
+	}
+	return gitHead()
+}
+
+// git notes show로 읽기 좋게 들여쓴 JSON
+func writeIndented(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+)
+
+// 벤치 자신의 프로파일 수집: 결과가 의심스러울 때 병목이 부하 생성기가
+// 아니라 타깃임을 증명하기 위한 용도
+var profileKinds = []string{"cpu", "heap", "allocs", "goroutine", "block", "mutex"}
+
+// startSelfProfile starts the requested profiles and returns a stop function
+// that writes them to dir as <kind>-<stamp>.pprof.
+func startSelfProfile(spec, dir string) (func() ([]string, error), error) {
+	kinds, err := parseProfileKinds(spec)
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	path := func(kind string) string { return filepath.Join(dir, kind+"-"+stamp+".pprof") }
+
+	var cpuFile *os.File
+	for _, k := range kinds {
+		switch k {
+		case "cpu":
+			f, err := os.Create(path("cpu"))
+			if err != nil {
+				return nil, err
+			}
+			if err := pprof.StartCPUProfile(f); err != nil {
+				f.Close()
+				return nil, err
+			}
+			cpuFile = f
+		case "block":
+			runtime.SetBlockProfileRate(1)
+		case "mutex":
+			runtime.SetMutexProfileFraction(1)
+		}
+	}
+
+	return func() ([]string, error) {
+		var written []string
+		for _, k := range kinds {
+			if k == "cpu" {
+				pprof.StopCPUProfile()
+				if err := cpuFile.Close(); err != nil {
+					return written, err
+				}
+				written = append(written, path("cpu"))
+				continue
+			}
+			if k == "heap" {
+				runtime.GC() // 최신 live heap 반영
+			}
+			f, err := os.Create(path(k))
+			if err != nil {
+				return written, err
+			}
+			err = pprof.Lookup(k).WriteTo(f, 0)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return written, err
+			}
+			written = append(written, path(k))
+		}
+		return written, nil
+	}, nil
+}
+
+func parseProfileKinds(spec string) ([]string, error) {
+	var kinds []string
+	for _, k := range strings.Split(spec, ",") {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" {
+			continue
+		}
+		ok := false
+		for _, known := range profileKinds {
+			if k == known {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid self-profile: %s (expected %s)", k, strings.Join(profileKinds, "|"))
+		}
+		kinds = append(kinds, k)
+	}
+	return kinds, nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/duri/trace_bench/cost"
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/stats"
+	"github.com/duri/trace_bench/validate"
+)
+
+// projected는 추정치와 신뢰구간 하한/상한
+type projected struct {
+	Estimate float64 `json:"estimate"`
+	Low      float64 `json:"low"`
+	High     float64 `json:"high"`
+}
+
+// projection은 project가 stdout으로 내보내는 용량 계획
+type projection struct {
+	Inputs      []string `json:"inputs"`
+	SpansPerDay float64  `json:"spans_per_day"`
+	Confidence  float64  `json:"confidence"`
+	// ExportedSpansPerDay는 샘플링 후 남는 span(입력 결과의 샘플링 비율 기준)
+	ExportedSpansPerDay float64    `json:"exported_spans_per_day"`
+	StorageGBPerDay     projected  `json:"storage_gb_per_day"`
+	StoredGB            *projected `json:"stored_gb,omitempty"` // -retention-days 동안 보관량
+	NetworkMbps         projected  `json:"network_mbps"`
+	CPUCores            projected  `json:"cpu_cores"`
+	CPUCoreHoursPerDay  projected  `json:"cpu_core_hours_per_day"`
+	Notes               []string   `json:"notes,omitempty"`
+}
+
+// projectFlags는 project 명령의 플래그 값
+type projectFlags struct {
+	in         string
+	traffic    string
+	confidence float64
+	retention  float64
+}
+
+func (f *projectFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&f.in, "in", "", "comma-separated real-mode result files (repeated runs narrow the bounds)")
+	fs.StringVar(&f.traffic, "traffic", "", "span volume to project, e.g. 50M/day, \"50M spans/day\" or 2k/s")
+	fs.Float64Var(&f.confidence, "confidence", 0.95, "confidence level of the low/high bounds")
+	fs.Float64Var(&f.retention, "retention-days", 0, "also project the volume stored over this retention")
+}
+
+// project 모드: 측정된 span당 바이트/CPU를 목표 트래픽으로 외삽한다.
+// 구간은 입력 결과가 여럿이면 run 간 편차, 하나면 배치 간 편차(바이트만)로 잡는다
+func runProject(args []string) {
+	var f projectFlags
+	fs := flag.NewFlagSet("project", flag.ExitOnError)
+	f.define(fs)
+	fs.Parse(args)
+	inputs := splitList(f.in)
+	if len(inputs) == 0 || f.traffic == "" {
+		fail(fmt.Errorf("project: -in and -traffic are required"))
+	}
+	if validate.Finite("confidence", f.confidence) != nil || f.confidence <= 0 || f.confidence >= 1 {
+		fail(fmt.Errorf("project: invalid -confidence: %v (expected (0,1))", f.confidence))
+	}
+	if err := validate.NonNegative("-retention-days", f.retention); err != nil {
+		fail(fmt.Errorf("project: %w", err))
+	}
+	perDay, err := cost.ParseTraffic(f.traffic)
+	if err != nil {
+		fail(err)
+	}
+	var vols []engine.VolumeStats
+	for _, path := range inputs {
+		r, err := readResult(path)
+		if err != nil {
+			fail(err)
+		}
+		if r.Volume == nil || r.Volume.Spans == 0 {
+			fail(fmt.Errorf("project: %s has no volume (requires a -mode real result)", path))
+		}
+		vols = append(vols, *r.Volume)
+	}
+
+	z := math.Sqrt2 * math.Erfinv(f.confidence)
+	var bps, cps, kept []float64
+	for _, v := range vols {
+		bps = append(bps, v.BytesPerSpan())
+		cps = append(cps, v.CPUSecondsPerSpan())
+		kept = append(kept, float64(v.Exported)/float64(v.Spans))
+	}
+	p := projection{Inputs: inputs, SpansPerDay: perDay, Confidence: f.confidence, ExportedSpansPerDay: math.Round(perDay * mean(kept))}
+	var bytesPS, cpuPS projected
+	if len(vols) > 1 {
+		bytesPS, cpuPS = meanBounds(bps, z), meanBounds(cps, z)
+	} else {
+		// run 하나: 바이트는 배치 간 편차로, CPU는 구간 없음
+		v := vols[0]
+		half := 0.0
+		if v.Batches > 1 {
+			half = z * v.BytesPerSpanSD / math.Sqrt(float64(v.Batches))
+		}
+		bytesPS = projected{bps[0], bps[0] - half, bps[0] + half}
+		cpuPS = projected{cps[0], cps[0], cps[0]}
+		p.Notes = append(p.Notes, "single input: cpu bounds need two or more results")
+	}
+	perSec := perDay / 86400
+	p.StorageGBPerDay = bytesPS.scale(perDay / (1 << 30))
+	if f.retention > 0 {
+		s := bytesPS.scale(perDay * f.retention / (1 << 30))
+		p.StoredGB = &s
+	}
+	p.NetworkMbps = bytesPS.scale(perSec * 8 / 1e6)
+	p.CPUCores = cpuPS.scale(perSec)
+	p.CPUCoreHoursPerDay = cpuPS.scale(perDay / 3600)
+	p.Notes = append(p.Notes, "storage is the encoded export volume; backends may index or re-encode it",
+		"cpu is the bench process's export pipeline, not the target collector")
+	output.WriteJSON(os.Stdout, p)
+}
+
+// meanBounds는 평균과 정규근사 신뢰구간(run이 2개 이상일 때)
+func meanBounds(xs []float64, z float64) projected {
+	m := mean(xs)
+	var ss float64
+	for _, x := range xs {
+		ss += (x - m) * (x - m)
+	}
+	half := z * math.Sqrt(ss/float64(len(xs)-1)) / math.Sqrt(float64(len(xs)))
+	return projected{m, m - half, m + half}
+}
+
+func mean(xs []float64) float64 {
+	var s float64
+	for _, x := range xs {
+		s += x
+	}
+	return s / float64(len(xs))
+}
+
+// scale은 span당 값을 총량으로(하한은 0 미만으로 내려가지 않음)
+func (p projected) scale(k float64) projected {
+	return projected{stats.Round5(p.Estimate * k), stats.Round5(max(p.Low*k, 0)), stats.Round5(p.High * k)}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/output"
+)
+
+// queryBenchFlags는 querybench 명령의 플래그 값
+type queryBenchFlags struct {
+	bc      engine.BackendConfig
+	headers headerFlag
+	queries string
+	qc      engine.QueryConfig
+	jsonOut string
+}
+
+func (f *queryBenchFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&f.bc.Kind, "backend", "", "storage backend: "+strings.Join(engine.Backends, "|"))
+	fs.StringVar(&f.bc.WriteURL, "write-url", "", "OTLP/HTTP traces URL (tempo, jaeger) or ClickHouse HTTP URL; seeding writes here")
+	fs.StringVar(&f.bc.QueryURL, "query-url", "", "tempo/jaeger query API base (e.g. http://tempo:3200, http://jaeger:16686)")
+	fs.StringVar(&f.bc.Table, "table", engine.DefaultClickHouseTable, "clickhouse span table ([db.]table)")
+	fs.DurationVar(&f.bc.Timeout, "timeout", engine.DefaultTimeout, "one write or query request")
+	f.headers = headerFlag{}
+	fs.Var(f.headers, "header", "extra request header Name=value, value may be secretref://env/NAME (repeatable)")
+	fs.StringVar(&f.bc.TLS.CAFile, "tls-ca", "", "CA bundle to verify the backend")
+	fs.BoolVar(&f.bc.TLS.Insecure, "tls-insecure", false, "skip backend certificate verification")
+	fs.StringVar(&f.queries, "queries", "", "query mix (.yaml/.json, see queries.yaml)")
+	fs.IntVar(&f.qc.Workers, "workers", 4, "concurrent query clients")
+	fs.DurationVar(&f.qc.Duration, "duration", engine.DefaultQueryDuration, "measured window")
+	fs.IntVar(&f.qc.SeedSpans, "seed-spans", engine.DefaultSpans, "spans written before querying (0: query existing data only)")
+	fs.IntVar(&f.qc.BatchSize, "batch", engine.DefaultBatchSize, "spans per seed write")
+	fs.DurationVar(&f.qc.Settle, "settle", 0, "wait between seeding and querying, for backends that buffer ingest (e.g. 15s for tempo)")
+	fs.Int64Var(&f.qc.Seed, "seed", 0, "seed trace generation seed (0: time-based)")
+	fs.StringVar(&f.jsonOut, "json-out", "", "write the result JSON here instead of stdout")
+}
+
+// query-bench 모드: trace ID 조회, service+duration, 태그 검색 등 queries.yaml의
+// 쿼리 묶음을 동시 부하로 돌려 쿼리별 p95를 잰다(읽기 경로 SLO용)
+func runQueryBench(args []string) {
+	var f queryBenchFlags
+	fs := flag.NewFlagSet("query-bench", flag.ExitOnError)
+	f.define(fs)
+	fs.Parse(args)
+	if f.queries == "" {
+		fail(fmt.Errorf("query-bench: -queries is required"))
+	}
+	qs, err := engine.LoadQueries(f.queries)
+	if err != nil {
+		fail(err)
+	}
+	if f.bc.Headers, err = f.headers.resolve(); err != nil {
+		fail(err)
+	}
+	f.qc.Backend, f.qc.Queries = f.bc, qs.Queries
+	r, err := engine.RunQueries(context.Background(), f.qc)
+	if err != nil {
+		fail(err)
+	}
+	for _, q := range r.Queries {
+		logger.Info(evQueryResult, "backend", r.Backend, "query", q.Name, "requests", q.Requests,
+			"p95_ms", q.P95ms, "error_rate", q.ErrorRate, "empty", q.Empty)
+	}
+	if f.jsonOut == "" {
+		output.WriteJSON(os.Stdout, r)
+		return
+	}
+	if err := output.WriteJSONFile(f.jsonOut, r); err != nil {
+		fail(err)
+	}
+	logger.Info(evResultWritten, "path", f.jsonOut)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/output"
+)
+
+// queueBenchFlags는 queuebench 명령의 플래그 값
+type queueBenchFlags struct {
+	qc      engine.QueueConfig
+	jsonOut string
+}
+
+func (f *queueBenchFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&f.qc.Broker, "broker", "", "message broker: "+strings.Join(engine.Brokers, "|"))
+	fs.StringVar(&f.qc.Addr, "addr", "", "kafka bootstrap broker or nats server (host:port)")
+	fs.StringVar(&f.qc.Topic, "topic", "trace_bench", "kafka topic (must exist or be auto-created) or nats subject")
+	fs.StringVar(&f.qc.Acks, "acks", "", "kafka: 0|1|all (default 1); nats: none|stream (default none; stream waits for the JetStream PubAck)")
+	fs.DurationVar(&f.qc.Timeout, "timeout", engine.DefaultTimeout, "one produce request or ack")
+	fs.IntVar(&f.qc.Spans, "spans", engine.DefaultSpans, "spans produced")
+	fs.IntVar(&f.qc.BatchSize, "batch", engine.DefaultBatchSize, "spans per message")
+	fs.IntVar(&f.qc.ProduceBatch, "produce-batch", 1, "messages per produce request (kafka record batch, nats flush)")
+	fs.IntVar(&f.qc.Workers, "workers", 1, "concurrent producers (kafka: spread over partitions)")
+	fs.StringVar(&f.qc.Serialization, "serialization", "json", "one of: "+strings.Join(engine.Serializations, "|"))
+	fs.StringVar(&f.qc.Compression, "compression", "none", "one of: "+strings.Join(engine.Compressions, "|"))
+	fs.DurationVar(&f.qc.Drain, "drain", engine.DefaultQueueDrain, "wait for in-flight messages after the last produce; undelivered ones count as lost")
+	fs.StringVar(&f.jsonOut, "json-out", "", "write the result JSON here instead of stdout")
+}
+
+// queue-bench 모드: 직렬화한 span 배치를 Kafka/NATS로 보내고 소비자가 받기까지의
+// 지연과 backlog(lag)를 잰다(버퍼형 텔레메트리 파이프라인 평가용)
+func runQueueBench(args []string) {
+	var f queueBenchFlags
+	fs := flag.NewFlagSet("queue-bench", flag.ExitOnError)
+	f.define(fs)
+	fs.Parse(args)
+
+	r, err := engine.RunQueue(context.Background(), f.qc)
+	if err != nil {
+		fail(err)
+	}
+	logger.Info(evQueueResult, "broker", r.Broker, "acks", r.Acks, "msgs_per_sec", r.MsgsPerSec,
+		"e2e_p95_ms", r.E2EP95ms, "lag_max", r.LagMax, "lost", r.Lost)
+	if f.jsonOut == "" {
+		output.WriteJSON(os.Stdout, r)
+		return
+	}
+	if err := output.WriteJSONFile(f.jsonOut, r); err != nil {
+		fail(err)
+	}
+	logger.Info(evResultWritten, "path", f.jsonOut)
+}
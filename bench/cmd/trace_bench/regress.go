@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/duri/trace_bench/history"
+	"github.com/duri/trace_bench/output"
+)
+
+// regressFlags는 regress 명령의 플래그 값
+type regressFlags struct {
+	opt      history.ShiftOptions
+	histPath string
+	runID    string
+	metrics  string
+}
+
+func (f *regressFlags) define(fs *flag.FlagSet) {
+	f.opt = history.DefaultShiftOptions
+	fs.StringVar(&f.histPath, "history", historyPath(), "history DB path")
+	fs.StringVar(&f.runID, "run-id", "", "run to check (default: the most recent)")
+	fs.StringVar(&f.metrics, "metrics", "", "comma-separated metrics (default: all in the result)")
+	fs.IntVar(&f.opt.Window, "window", f.opt.Window, "trailing runs with the same config forming the baseline")
+	fs.Float64Var(&f.opt.K, "k", f.opt.K, "CUSUM allowance in robust sigmas")
+	fs.Float64Var(&f.opt.H, "h", f.opt.H, "CUSUM decision interval in robust sigmas")
+	fs.IntVar(&f.opt.MinRuns, "min-runs", f.opt.MinRuns, "minimum consecutive shifted runs to flag")
+}
+
+// regress 모드: 기록된 run(기본: 마지막)을 직전 N개 run의 중앙값과 CUSUM으로 비교
+func runRegress(args []string) {
+	var f regressFlags
+	fs := flag.NewFlagSet("regress", flag.ExitOnError)
+	f.define(fs)
+	fs.Parse(args)
+	f.opt.Metrics = splitList(f.metrics)
+
+	db, err := history.Open(f.histPath)
+	if err != nil {
+		fail(err)
+	}
+	all, err := db.Entries()
+	if err != nil {
+		fail(err)
+	}
+	if len(all) == 0 {
+		fail(fmt.Errorf("history %s is empty", db.Path))
+	}
+	cur := all[len(all)-1]
+	if f.runID != "" {
+		found := false
+		for _, e := range all {
+			if e.RunID == f.runID {
+				cur, found = e, true
+			}
+		}
+		if !found {
+			fail(fmt.Errorf("run %s not found in %s", f.runID, db.Path))
+		}
+	}
+	prev := history.Comparable(all, cur)
+	// cur 이후의 run은 기준선에서 제외
+	for i, e := range prev {
+		if e.Time.After(cur.Time) {
+			prev = prev[:i]
+			break
+		}
+	}
+	shifts := history.DetectShifts(prev, cur, f.opt)
+	output.WriteJSON(os.Stdout, shifts)
+	logger = logger.With("run_id", cur.RunID)
+	if len(reportShifts(min(len(prev), f.opt.Window), shifts)) > 0 {
+		os.Exit(2)
+	}
+}
+
+// reportShifts는 회귀 판정을 stderr에 남기고 회귀한 metric을 돌려준다(run_id는 logger에 실림)
+func reportShifts(n int, shifts []history.Shift) []string {
+	var regressed []string
+	for _, s := range shifts {
+		if s.Regressed {
+			regressed = append(regressed, s.Metric)
+			logger.Warn(evRegression, "metric", s.Metric, "value", s.Current, "median", s.Median,
+				"baseline_runs", n, "cusum", s.CUSUM, "shifted_runs", s.Runs, "since", s.Since)
+		}
+	}
+	return regressed
+}
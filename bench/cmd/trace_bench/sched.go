@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// parseCPUList는 -cpu-affinity 값("0-3", "0,2,4-5")을 CPU 번호 목록으로
+func parseCPUList(s string) ([]int, error) {
+	var cpus []int
+	seen := map[int]bool{}
+	for _, part := range splitList(s) {
+		lo, hi, isRange := strings.Cut(part, "-")
+		a, err := strconv.Atoi(lo)
+		b := a
+		if err == nil && isRange {
+			b, err = strconv.Atoi(hi)
+		}
+		if err != nil || a < 0 || b < a || b >= maxCPUs {
+			return nil, fmt.Errorf("invalid -cpu-affinity %q: bad CPU or range %q", s, part)
+		}
+		for c := a; c <= b; c++ {
+			if !seen[c] {
+				seen[c] = true
+				cpus = append(cpus, c)
+			}
+		}
+	}
+	if len(cpus) == 0 {
+		return nil, fmt.Errorf("invalid -cpu-affinity %q: no CPUs", s)
+	}
+	return cpus, nil
+}
+
+// applySched는 -cpu-affinity/-nice를 프로세스 전체에 적용한다. 같은 호스트의
+// 타깃이 생성기와 CPU를 다투지 않게 하는 용도(적용한 값은 result의 host에 남는다)
+func applySched(affinity string, nice int) {
+	if nice < -20 || nice > 19 {
+		fail(fmt.Errorf("invalid -nice %d (-20..19)", nice))
+	}
+	var cpus []int
+	if affinity != "" {
+		var err error
+		if cpus, err = parseCPUList(affinity); err != nil {
+			fail(err)
+		}
+	}
+	if err := setSched(cpus, nice); err != nil {
+		fail(err)
+	}
+	// GOMAXPROCS는 시작 시 CPU 수로 정해졌으므로 고정한 CPU 수에 맞춘다
+	if cpus != nil && os.Getenv("GOMAXPROCS") == "" {
+		runtime.GOMAXPROCS(len(cpus))
+	}
+}
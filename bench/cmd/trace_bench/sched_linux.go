@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+const maxCPUs = 1024 // cpu_set_t 크기
+
+// setSched는 모든 스레드에 affinity와 nice를 건다(리눅스에서 둘 다 스레드 단위).
+// 새 스레드는 만든 스레드의 설정을 물려받으므로 새로 생긴 스레드가 없을 때까지 반복
+func setSched(cpus []int, nice int) error {
+	var mask [maxCPUs / 64]uint64
+	for _, c := range cpus {
+		mask[c/64] |= 1 << uint(c%64)
+	}
+	done := map[int]bool{}
+	for {
+		ents, err := os.ReadDir("/proc/self/task")
+		if err != nil {
+			return err
+		}
+		fresh := 0
+		for _, e := range ents {
+			tid, err := strconv.Atoi(e.Name())
+			if err != nil || done[tid] {
+				continue
+			}
+			done[tid], fresh = true, fresh+1
+			if cpus != nil {
+				_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, uintptr(tid), unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask[0])))
+				if errno == syscall.EINVAL {
+					return fmt.Errorf("-cpu-affinity: none of CPUs %v is online", cpus)
+				}
+				if errno != 0 && errno != syscall.ESRCH {
+					return fmt.Errorf("-cpu-affinity: %w", errno)
+				}
+			}
+			if nice != 0 {
+				if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, nice); err != nil && err != syscall.ESRCH {
+					if err == syscall.EACCES || err == syscall.EPERM {
+						return fmt.Errorf("-nice %d: %w (negative values need root or CAP_SYS_NICE)", nice, err)
+					}
+					return fmt.Errorf("-nice: %w", err)
+				}
+			}
+		}
+		if fresh == 0 {
+			return nil
+		}
+	}
+}
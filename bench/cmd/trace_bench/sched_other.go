@@ -0,0 +1,18 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+const maxCPUs = 1024
+
+// setSched: affinity/nice 제어는 리눅스만 지원
+func setSched(cpus []int, nice int) error {
+	if cpus == nil && nice == 0 {
+		return nil
+	}
+	return fmt.Errorf("-cpu-affinity/-nice are only supported on linux (running on %s)", runtime.GOOS)
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/duri/trace_bench/internal/cronspec"
+	"github.com/duri/trace_bench/internal/yamlite"
+	"github.com/duri/trace_bench/validate"
+)
+
+// scheduleVersion은 지원하는 schedule -config 스키마 버전
+const scheduleVersion = 1
+
+// scheduleConfig는 schedule -config 파일(nightly.yaml)
+type scheduleConfig struct {
+	Version int `json:"version"`
+	// Spec은 자체 spec이 없는 bench의 기본 일정(-spec이 우선)
+	Spec       string `json:"spec"`
+	History    string `json:"history"`
+	ResultsDir string `json:"results_dir"`
+	Keep       int    `json:"keep"`
+	Push       struct {
+		RemoteWrite   string `json:"remote_write"`
+		InfluxURL     string `json:"influx_url"`
+		ArtifactStore string `json:"artifact_store"`
+	} `json:"push"`
+	Benches []scheduledBench `json:"benches"`
+}
+
+type scheduledBench struct {
+	Name    string   `json:"name"`
+	Spec    string   `json:"spec"`
+	Args    []string `json:"args"`
+	Timeout string   `json:"timeout"`
+
+	sched   *cronspec.Schedule
+	timeout time.Duration
+	next    time.Time
+}
+
+// 중단 시 child에 SIGINT 후 기다리는 시간(소크는 그때까지의 결과를 쓴다)
+const scheduleStopGrace = 30 * time.Second
+
+// scheduleFlags는 schedule 명령의 플래그 값
+type scheduleFlags struct {
+	spec     string
+	cfgPath  string
+	histPath string
+	once     bool
+	strict   bool
+}
+
+func (f *scheduleFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&f.spec, "spec", "", "default schedule for benches without their own spec, e.g. 'cron(0 3 * * *)' (local time; overrides the config's spec)")
+	fs.StringVar(&f.cfgPath, "config", "", "schedule file listing the benches (see nightly.yaml)")
+	fs.StringVar(&f.histPath, "history", historyPath(), "history DB every run is appended to (default: the config's history, then $TRACE_BENCH_HISTORY)")
+	fs.BoolVar(&f.once, "once", false, "run every bench once now and exit (non-zero if any run failed)")
+	fs.BoolVar(&f.strict, "strict", false, "reject unknown config keys and TRACE_BENCH_* variables, here and in every bench run")
+}
+
+// schedule 모드: 설정한 bench들을 cron 일정대로 실행하는 데몬. 매 실행을
+// history에 남기고 결과를 push해 외부 cron + 셸 래퍼가 필요 없다
+func runSchedule(args []string) {
+	var f scheduleFlags
+	fs := flag.NewFlagSet("schedule", flag.ExitOnError)
+	f.define(fs)
+	fs.Parse(args)
+	if f.strict {
+		if err := setStrict(); err != nil {
+			fail(err)
+		}
+	}
+	if f.cfgPath == "" {
+		fmt.Fprintln(os.Stderr, "usage: trace_bench schedule -config=nightly.yaml [-spec='cron(0 3 * * *)'] [-history=PATH] [-once] [-strict]")
+		os.Exit(2)
+	}
+	cfg, err := loadSchedule(f.cfgPath, f.spec)
+	if err != nil {
+		fail(err)
+	}
+	if f.histPath == "" {
+		f.histPath = cfg.History
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fail(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if f.once {
+		failed := 0
+		for i := range cfg.Benches {
+			if err := runScheduled(ctx, exe, cfg, &cfg.Benches[i], f.histPath, time.Now()); err != nil {
+				failed++
+			}
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	now := time.Now()
+	for i := range cfg.Benches {
+		b := &cfg.Benches[i]
+		b.next = b.sched.Next(now)
+		logger.Info(evScheduleNext, "bench", b.Name, "spec", b.sched.String(), "at", b.next.Format(time.RFC3339))
+	}
+	for {
+		// 가장 이른 일정까지 대기
+		due := cfg.Benches[0].next
+		for _, b := range cfg.Benches[1:] {
+			if b.next.Before(due) {
+				due = b.next
+			}
+		}
+		t := time.NewTimer(time.Until(due))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		// bench끼리 CPU를 다투지 않도록 차례로 실행. 실행이 길어져 놓친 일정은
+		// 몰아서 따라잡지 않고 다음 일정으로 넘어간다
+		for i := range cfg.Benches {
+			b := &cfg.Benches[i]
+			if b.next.After(due) {
+				continue
+			}
+			runScheduled(ctx, exe, cfg, b, f.histPath, b.next)
+			if ctx.Err() != nil {
+				return
+			}
+			b.next = b.sched.Next(time.Now())
+			logger.Info(evScheduleNext, "bench", b.Name, "spec", b.sched.String(), "at", b.next.Format(time.RFC3339))
+		}
+	}
+}
+
+func loadSchedule(path, spec string) (*scheduleConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg scheduleConfig
+	if err := yamlite.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if cfg.Version != scheduleVersion {
+		return nil, fmt.Errorf("%s: unsupported version %d (want %d)", path, cfg.Version, scheduleVersion)
+	}
+	if len(cfg.Benches) == 0 {
+		return nil, fmt.Errorf("%s: no benches", path)
+	}
+	if spec != "" {
+		cfg.Spec = spec
+	}
+	if cfg.Keep < 0 {
+		return nil, fmt.Errorf("%s: keep must be non-negative", path)
+	}
+	seen := map[string]bool{}
+	for i := range cfg.Benches {
+		b := &cfg.Benches[i]
+		if err := validate.BenchName(b.Name); err != nil {
+			return nil, fmt.Errorf("%s: bench %d: %w", path, i+1, err)
+		}
+		if err := validate.Args(b.Args); err != nil {
+			return nil, fmt.Errorf("%s: bench %q: %w", path, b.Name, err)
+		}
+		if seen[b.Name] {
+			return nil, fmt.Errorf("%s: duplicate bench %q", path, b.Name)
+		}
+		seen[b.Name] = true
+		s := b.Spec
+		if s == "" {
+			s = cfg.Spec
+		}
+		if s == "" {
+			return nil, fmt.Errorf("%s: bench %q: no spec (set spec, the top-level spec or -spec)", path, b.Name)
+		}
+		if b.sched, err = cronspec.Parse(s); err != nil {
+			return nil, fmt.Errorf("%s: bench %q: %w", path, b.Name, err)
+		}
+		if b.Timeout != "" {
+			if b.timeout, err = time.ParseDuration(b.Timeout); err != nil || b.timeout <= 0 {
+				return nil, fmt.Errorf("%s: bench %q: bad timeout %q", path, b.Name, b.Timeout)
+			}
+		}
+	}
+	return &cfg, nil
+}
+
+// runScheduled는 bench 하나를 trace_bench 하위 프로세스로 실행한다. history,
+// 결과 파일, push 플래그는 bench args에 없을 때만 붙인다(args가 우선)
+func runScheduled(ctx context.Context, exe string, cfg *scheduleConfig, b *scheduledBench, hist string, at time.Time) error {
+	args := append([]string{}, b.Args...)
+	add := func(name, v string) {
+		if v != "" && !hasFlag(b.Args, name) {
+			args = append(args, "-"+name, v)
+		}
+	}
+	var result string
+	if cfg.ResultsDir != "" {
+		dir := filepath.Join(cfg.ResultsDir, b.Name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			logger.Warn(evScheduleRun, "bench", b.Name, "err", err.Error())
+			return err
+		}
+		result = filepath.Join(dir, at.UTC().Format("20060102T150405Z")+".json")
+		add("json-out", result)
+	}
+	add("history", hist)
+	add("lock", lockWait) // 수동 run과 겹치면 실패 대신 기다린다
+	add("remote-write", cfg.Push.RemoteWrite)
+	add("influx-url", cfg.Push.InfluxURL)
+	add("artifact-store", cfg.Push.ArtifactStore)
+	if yamlite.Strict && !hasFlag(b.Args, "strict") {
+		args = append(args, "-strict")
+	}
+	args = append(args, "-label", "schedule="+b.Name)
+
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, exe, args...)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = scheduleStopGrace
+	start := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(start).Seconds()
+	if err != nil {
+		code := -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		}
+		logger.Warn(evScheduleRun, "bench", b.Name, "exit", code, "verdict", exitVerdict(code), "duration_s", elapsed, "err", err.Error())
+		return err
+	}
+	logger.Info(evScheduleRun, "bench", b.Name, "exit", 0, "verdict", exitVerdict(0), "duration_s", elapsed, "result", result)
+	if result != "" && cfg.Keep > 0 {
+		if err := pruneResults(filepath.Dir(result), cfg.Keep); err != nil {
+			logger.Warn(evConfigWarning, "bench", b.Name, "err", err.Error())
+		}
+	}
+	return nil
+}
+
+// hasFlag는 args에 -name/--name(=v 포함)이 있는지
+func hasFlag(args []string, name string) bool {
+	for _, a := range args {
+		a = strings.TrimPrefix(strings.TrimPrefix(a, "-"), "-")
+		if a == name || strings.HasPrefix(a, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneResults는 dir의 결과 중 최근 keep개만 남긴다(파일명이 시각순)
+func pruneResults(dir string, keep int) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) <= keep {
+		return err
+	}
+	sort.Strings(files)
+	for _, f := range files[:len(files)-keep] {
+		if err := os.Remove(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/stats"
+)
+
+// 수집 경로 자체 벤치: 생성기 오버헤드가 타깃 p95의 1% 미만임을 증명
+const (
+	selfBenchSamples     = 2_000_000
+	selfBenchBatches     = 1000
+	selfBenchMinRate     = 100_000 // samples/sec
+	selfBenchMaxOverhead = 1.0     // % of target p95
+	// 워커 goroutine 생성분 허용(샘플 수와 무관한 상수)
+	selfBenchAllocsPerWorker = 8
+	// export 경로: 인코더(json)의 할당 변동만 허용, 배치마다 할당하면 1 이상
+	selfBenchMaxExportAllocs = 0.05 // per batch
+)
+
+type selfBenchResult struct {
+	Workers       int     `json:"workers"`
+	Sketch        string  `json:"quantile_sketch"`
+	Samples       int     `json:"samples"`
+	SamplesPerSec float64 `json:"samples_per_sec"`
+	OverheadNs    float64 `json:"overhead_ns_per_sample"`
+	// Allocs는 측정 구간의 전체 할당 수(나누면 0으로 잘려 검사가 무의미)
+	Allocs          uint64                 `json:"allocs"`
+	AllocsPerSample float64                `json:"allocs_per_sample"`
+	Export          engine.ExportPathBench `json:"export_path"`
+	TargetP95ms     float64                `json:"target_p95_ms"`
+	OverheadPct     float64                `json:"overhead_pct_of_p95"`
+	OK              bool                   `json:"ok"`
+}
+
+// runSelfBench drives the per-sample hot path (two clock reads and a sketch
+// Record) on preallocated sketches and checks it against targetP95ms, then
+// runs cfg's pipeline export with and without the per-batch recording to
+// check that the recording adds no allocations to the export path.
+func runSelfBench(cfg engine.Config, workers int, sketch string, targetP95ms float64) (selfBenchResult, error) {
+	workers = max(workers, 1)
+	per := selfBenchSamples / workers
+	sketches := make([]stats.Sketch, workers)
+	for i := range sketches {
+		sk, err := stats.NewSketch(sketch, per)
+		if err != nil {
+			return selfBenchResult{}, err
+		}
+		sketches[i] = sk
+	}
+
+	var wg sync.WaitGroup
+	var m0, m1 runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m0)
+	start := time.Now()
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(r stats.Sketch) {
+			defer wg.Done()
+			for i := 0; i < per; i++ {
+				t0 := time.Now()
+				r.Record(time.Since(t0))
+			}
+		}(sketches[w])
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&m1)
+
+	total := per * workers
+	res := selfBenchResult{
+		Workers:       workers,
+		Sketch:        sketch,
+		Samples:       total,
+		SamplesPerSec: stats.Round2(float64(total) / elapsed.Seconds()),
+		OverheadNs:    stats.Round2(float64(elapsed) * float64(workers) / float64(total)),
+		Allocs:        m1.Mallocs - m0.Mallocs,
+		TargetP95ms:   targetP95ms,
+	}
+	res.AllocsPerSample = stats.Round5(float64(res.Allocs) / float64(total))
+	if targetP95ms > 0 {
+		res.OverheadPct = stats.Round5(res.OverheadNs / 1e6 / targetP95ms * 100)
+	}
+	cfg.QuantileSketch = sketch
+	var err error
+	if res.Export, err = engine.BenchExportPath(cfg, selfBenchBatches); err != nil {
+		return selfBenchResult{}, err
+	}
+	res.OK = res.Allocs <= uint64(selfBenchAllocsPerWorker*workers) && res.Export.AddedAllocsPerBatch < selfBenchMaxExportAllocs &&
+		res.SamplesPerSec >= selfBenchMinRate && res.OverheadPct < selfBenchMaxOverhead
+	return res, nil
+}
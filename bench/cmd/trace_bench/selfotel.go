@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/pkg/buildinfo"
+	"github.com/duri/trace_bench/selftel"
+)
+
+// selfRun은 -otel-self 계측 상태. tracer가 nil이면 모든 호출이 no-op
+type selfRun struct {
+	t      *selftel.Tracer
+	ctx    context.Context
+	root   *selftel.Span
+	export *selftel.Span
+	once   sync.Once
+}
+
+var self = &selfRun{ctx: context.Background()}
+
+// startSelfTel은 루트 span(trace_bench.run)을 연다. 엔진 phase와 export가 그 하위 span
+func startSelfTel(endpoint string, attrs ...any) error {
+	t, err := selftel.New(endpoint, "trace_bench", map[string]string{"service.version": buildinfo.Read().Version})
+	if err != nil {
+		return err
+	}
+	self.t = t
+	self.ctx, self.root = t.Start(context.Background(), "trace_bench.run", attrs...)
+	logger.Info(evSelfTelStarted, "endpoint", endpoint, "trace_id", t.TraceID())
+	return nil
+}
+
+// phases는 engine.Config.Phases로 넘길 hook(span + phase duration gauge)
+func (s *selfRun) phases() engine.PhaseFunc {
+	if s.t == nil {
+		return nil
+	}
+	return func(ctx context.Context, phase string) func(error) {
+		t0 := time.Now()
+		_, sp := s.t.Start(ctx, phase)
+		return func(err error) {
+			sp.End(err)
+			s.t.Gauge("trace_bench.self.phase.duration", "s", time.Since(t0).Seconds(), "phase", phase)
+		}
+	}
+}
+
+func (s *selfRun) startExport() {
+	if s.t != nil {
+		_, s.export = s.t.Start(s.ctx, "export")
+	}
+}
+
+// finish는 export/루트 span을 닫고 결과 gauge와 함께 한 번만 내보낸다
+func (s *selfRun) finish(metrics map[string]float64, runID string, err error) {
+	if s.t == nil {
+		return
+	}
+	s.once.Do(func() {
+		s.export.End(nil)
+		if runID != "" {
+			s.root.SetAttr("run_id", runID)
+		}
+		s.root.End(err)
+		keys := make([]string, 0, len(metrics))
+		for k := range metrics {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			s.t.Gauge("trace_bench.self.result."+k, "", metrics[k])
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.t.Flush(ctx); err != nil {
+			logger.Warn(evSelfTelFailed, "err", err.Error())
+		}
+	})
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/cost"
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/slo"
+	"github.com/duri/trace_bench/stats"
+	"github.com/duri/trace_bench/validate"
+)
+
+// solve가 탐색할 수 있는 자유 파라미터
+const (
+	freeSampling      = "sampling"
+	freeSerialization = "serialization"
+	freeCompression   = "compression"
+)
+
+// solveProbe는 탐색 중 측정한 구성 하나
+type solveProbe struct {
+	Sampling      float64            `json:"sampling"`
+	Serialization string             `json:"serialization"`
+	Compression   string             `json:"compression"`
+	Cost          float64            `json:"cost"`
+	Estimate      *cost.Estimate     `json:"cost_estimate,omitempty"`
+	Pass          bool               `json:"pass"`
+	Failed        []slo.Check        `json:"failed,omitempty"`
+	Metrics       map[string]float64 `json:"metrics"`
+}
+
+// solveReport는 stdout으로 내보내는 결론. Flags는 추천 구성을 그대로 붙여 쓸 수 있는 플래그,
+// Ranking은 SLO를 만족한 구성을 비용 순으로
+type solveReport struct {
+	Free        []string     `json:"free"`
+	Cost        string       `json:"cost"`
+	Feasible    bool         `json:"feasible"`
+	Recommended *solveProbe  `json:"recommended,omitempty"`
+	Flags       string       `json:"flags,omitempty"`
+	Ranking     []solveProbe `json:"ranking,omitempty"`
+	Probes      []solveProbe `json:"probes"`
+}
+
+// monthlyCost는 -cost-model 사용 시 report의 cost 이름
+const monthlyCost = "monthly_cost"
+
+// solveFlags는 solve 명령의 플래그 값
+type solveFlags struct {
+	free          string
+	costMetric    string
+	costModel     string
+	traffic       string
+	sloP95        time.Duration
+	sloP99        time.Duration
+	sloErr        float64
+	sloPath       string
+	precision     float64
+	samplingMin   float64
+	samplingMax   float64
+	sampling      float64
+	serialization string
+	compression   string
+	mode          string
+	spans         int
+	batch         int
+	workers       int
+	workload      string
+	endpoint      string
+}
+
+func (o *solveFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&o.free, "free", freeSampling, "comma-separated parameters to search: sampling,serialization,compression (the others stay fixed)")
+	fs.StringVar(&o.costMetric, "cost", "size_kb", "result metric to minimize among configurations meeting the SLO")
+	fs.StringVar(&o.costModel, "cost-model", "", "costs.yaml: minimize the monthly cost at -traffic instead of -cost (requires -mode real)")
+	fs.StringVar(&o.traffic, "traffic", "", "span volume priced by -cost-model, e.g. 50M/day")
+	fs.DurationVar(&o.sloP95, "slo-p95", 0, "p95 latency objective (e.g. 700ms)")
+	fs.DurationVar(&o.sloP99, "slo-p99", 0, "p99 latency objective")
+	fs.Float64Var(&o.sloErr, "slo-error-rate", 0, "error rate objective (0..1)")
+	fs.StringVar(&o.sloPath, "slo", "", "shared slo.yaml whose bench_metric SLOs must also hold")
+	fs.Float64Var(&o.precision, "precision", 0.01, "sampling search resolution")
+	fs.Float64Var(&o.samplingMin, "sampling-min", 0, "lowest sampling rate considered")
+	fs.Float64Var(&o.samplingMax, "sampling-max", 1, "highest sampling rate considered")
+	fs.Float64Var(&o.sampling, "sampling", 1.0, "sampling rate when not free")
+	fs.StringVar(&o.serialization, "serialization", "json", "serialization when not free")
+	fs.StringVar(&o.compression, "compression", "none", "compression when not free")
+	fs.StringVar(&o.mode, "mode", engine.ModeModel, "one of: model|real")
+	fs.IntVar(&o.spans, "spans", engine.DefaultSpans, "real mode: spans generated per probe")
+	fs.IntVar(&o.batch, "batch", engine.DefaultBatchSize, "real mode: spans per export batch")
+	fs.IntVar(&o.workers, "workers", 1, "real mode: concurrent exporters")
+	fs.StringVar(&o.workload, "workload", engine.WorkloadPipeline, "real mode: pipeline|http")
+	fs.StringVar(&o.endpoint, "endpoint", "", "http workload: collector URL")
+}
+
+// solve 모드: SLO를 만족하는 구성 중 -cost 지표(또는 -cost-model 월 비용)가 가장 작은 것을 찾는다
+func runSolve(args []string) {
+	var o solveFlags
+	fs := flag.NewFlagSet("solve", flag.ExitOnError)
+	o.define(fs)
+	fs.Parse(args)
+
+	var slos []slo.SLO
+	if o.sloPath != "" {
+		defs, err := slo.Load(o.sloPath)
+		if err != nil {
+			fail(err)
+		}
+		slos = defs.Bench()
+	}
+	if o.sloP95 > 0 {
+		slos = append(slos, flagSLO("solve-p95", "p95_ms", durationMs(o.sloP95)))
+	}
+	if o.sloP99 > 0 {
+		slos = append(slos, flagSLO("solve-p99", "p99_ms", durationMs(o.sloP99)))
+	}
+	if err := validate.Fraction("-slo-error-rate", o.sloErr); err != nil {
+		fail(fmt.Errorf("solve: %w", err))
+	}
+	if o.sloErr > 0 {
+		slos = append(slos, flagSLO("solve-error-rate", "error_rate", o.sloErr))
+	}
+	if len(slos) == 0 {
+		fail(fmt.Errorf("solve: no objective (set -slo-p95, -slo-p99, -slo-error-rate or -slo)"))
+	}
+	frees := splitList(o.free)
+	for _, f := range frees {
+		switch f {
+		case freeSampling, freeSerialization, freeCompression:
+		default:
+			fail(fmt.Errorf("solve: invalid -free: %s (expected %s|%s|%s)", f, freeSampling, freeSerialization, freeCompression))
+		}
+	}
+	if len(frees) == 0 {
+		fail(fmt.Errorf("solve: -free is required"))
+	}
+	if validate.Fraction("sampling-min", o.samplingMin) != nil || validate.Fraction("sampling-max", o.samplingMax) != nil ||
+		validate.Finite("precision", o.precision) != nil || o.precision <= 0 || o.samplingMin >= o.samplingMax {
+		fail(fmt.Errorf("solve: invalid sampling range [%v,%v] / precision %v", o.samplingMin, o.samplingMax, o.precision))
+	}
+
+	s := &solver{ctx: context.Background(), slos: slos, cost: o.costMetric}
+	if o.costModel != "" {
+		if o.mode != engine.ModeReal {
+			fail(fmt.Errorf("solve: -cost-model requires -mode=%s", engine.ModeReal))
+		}
+		var err error
+		if s.model, err = cost.Load(o.costModel); err != nil {
+			fail(err)
+		}
+		if s.spansPerDay, err = cost.ParseTraffic(o.traffic); err != nil {
+			fail(err)
+		}
+		s.cost = monthlyCost
+	}
+	base := engine.Config{
+		Sampling:      o.sampling,
+		Serialization: o.serialization,
+		Compression:   o.compression,
+		Mode:          o.mode,
+		Spans:         o.spans,
+		BatchSize:     o.batch,
+		Workers:       o.workers,
+		Workload:      o.workload,
+		Endpoint:      o.endpoint,
+	}
+	if err := base.Validate(); err != nil {
+		fail(err)
+	}
+	sers, comps := []string{base.Serialization}, []string{base.Compression}
+	if hasItem(frees, freeSerialization) {
+		sers = engine.Serializations
+	}
+	if hasItem(frees, freeCompression) {
+		comps = engine.Compressions
+	}
+	var best *solveProbe
+	for _, ser := range sers {
+		for _, comp := range comps {
+			c := base
+			c.Serialization, c.Compression = ser, comp
+			var p *solveProbe
+			var err error
+			if hasItem(frees, freeSampling) {
+				p, err = s.sampling(c, o.samplingMin, o.samplingMax, o.precision)
+			} else {
+				p, err = s.fixed(c)
+			}
+			if err != nil {
+				fail(err)
+			}
+			if p != nil && (best == nil || p.Cost < best.Cost) {
+				best = p
+			}
+		}
+	}
+
+	rep := solveReport{Free: frees, Cost: s.cost, Feasible: best != nil, Recommended: best, Ranking: s.ranking(), Probes: s.probes}
+	if best != nil {
+		rep.Flags = fmt.Sprintf("-sampling=%g -serialization=%s -compression=%s", best.Sampling, best.Serialization, best.Compression)
+	}
+	output.WriteJSON(os.Stdout, rep)
+	if best == nil {
+		logger.Warn(evSolveInfeasible, "free", strings.Join(frees, ","), "probes", len(s.probes))
+		os.Exit(2)
+	}
+	logger.Info(evSolveResult, "flags", rep.Flags, "cost", s.cost, "value", best.Cost, "probes", len(s.probes))
+}
+
+// solver는 측정 결과를 모으며 후보 구성을 판정한다
+type solver struct {
+	ctx    context.Context
+	slos   []slo.SLO
+	cost   string
+	probes []solveProbe
+
+	model       *cost.Model // -cost-model: cost는 월 비용
+	spansPerDay float64
+}
+
+func (s *solver) probe(c engine.Config) (solveProbe, error) {
+	r, err := engine.New().Run(s.ctx, c)
+	if err != nil {
+		return solveProbe{}, err
+	}
+	m := r.Metrics()
+	p := solveProbe{Sampling: c.Sampling, Serialization: c.Serialization, Compression: c.Compression, Pass: true, Metrics: m}
+	if s.model != nil {
+		if p.Estimate, err = r.Price(*s.model, s.spansPerDay); err != nil {
+			return solveProbe{}, err
+		}
+		p.Cost = p.Estimate.Monthly
+	} else {
+		v, ok := m[s.cost]
+		if !ok {
+			return solveProbe{}, fmt.Errorf("solve: cost metric %s not in result", s.cost)
+		}
+		p.Cost = v
+	}
+	for _, ch := range slo.Evaluate(s.slos, m) {
+		if !ch.Pass {
+			p.Pass = false
+			p.Failed = append(p.Failed, ch)
+		}
+	}
+	logger.Info(evSolveProbe, "sampling", p.Sampling, "serialization", p.Serialization, "compression", p.Compression, "cost", p.Cost, "pass", p.Pass)
+	s.probes = append(s.probes, p)
+	return p, nil
+}
+
+func (s *solver) fixed(c engine.Config) (*solveProbe, error) {
+	p, err := s.probe(c)
+	if err != nil || !p.Pass {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// sampling은 통과 여부가 sampling에 단조라고 보고 [lo,hi]에서 경계를 이분 탐색한다.
+// 통과 구간의 양 끝(경계와 통과하는 끝) 중 비용이 작은 쪽을 고른다
+func (s *solver) sampling(c engine.Config, lo, hi, precision float64) (*solveProbe, error) {
+	c.Sampling = lo
+	pl, err := s.probe(c)
+	if err != nil {
+		return nil, err
+	}
+	c.Sampling = hi
+	ph, err := s.probe(c)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !pl.Pass && !ph.Pass:
+		return nil, nil
+	case pl.Pass && ph.Pass:
+		return cheaper(pl, ph), nil
+	}
+	end, edge, bad := ph, ph, lo
+	if pl.Pass {
+		end, edge, bad = pl, pl, hi
+	}
+	for {
+		mid := stats.Round5(math.Round((edge.Sampling+bad)/2/precision) * precision)
+		if math.Abs(edge.Sampling-bad) <= precision || mid == edge.Sampling || mid == bad {
+			break
+		}
+		c.Sampling = mid
+		p, err := s.probe(c)
+		if err != nil {
+			return nil, err
+		}
+		if p.Pass {
+			edge = p
+		} else {
+			bad = mid
+		}
+	}
+	return cheaper(edge, end), nil
+}
+
+// ranking은 통과한 구성을 비용 순으로(같은 구성은 한 번)
+func (s *solver) ranking() []solveProbe {
+	var out []solveProbe
+	seen := map[string]bool{}
+	for _, p := range s.probes {
+		key := fmt.Sprintf("%g/%s/%s", p.Sampling, p.Serialization, p.Compression)
+		if p.Pass && !seen[key] {
+			seen[key] = true
+			out = append(out, p)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Cost < out[j].Cost })
+	return out
+}
+
+func cheaper(a, b solveProbe) *solveProbe {
+	if b.Cost < a.Cost {
+		return &b
+	}
+	return &a
+}
+
+func flagSLO(name, metric string, threshold float64) slo.SLO {
+	return slo.SLO{Name: name, Indicator: slo.Indicator{BenchMetric: metric}, Threshold: &threshold}
+}
+
+func durationMs(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
+func hasItem(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/output"
+)
+
+// storageBenchFlags는 storagebench 명령의 플래그 값
+type storageBenchFlags struct {
+	bc      engine.BackendConfig
+	headers headerFlag
+	sc      engine.StorageConfig
+	jsonOut string
+}
+
+func (f *storageBenchFlags) define(fs *flag.FlagSet) {
+	fs.StringVar(&f.bc.Kind, "backend", "", "storage backend: "+strings.Join(engine.Backends, "|"))
+	fs.StringVar(&f.bc.WriteURL, "write-url", "", "OTLP/HTTP traces URL (tempo, jaeger; e.g. http://tempo:4318/v1/traces) or ClickHouse HTTP URL (http://clickhouse:8123)")
+	fs.StringVar(&f.bc.QueryURL, "query-url", "", "tempo/jaeger query API base (e.g. http://tempo:3200, http://jaeger:16686)")
+	fs.StringVar(&f.bc.Table, "table", engine.DefaultClickHouseTable, "clickhouse span table ([db.]table)")
+	fs.DurationVar(&f.bc.Timeout, "timeout", engine.DefaultTimeout, "one write or query request")
+	f.headers = headerFlag{}
+	fs.Var(f.headers, "header", "extra request header Name=value, value may be secretref://env/NAME (repeatable)")
+	fs.StringVar(&f.bc.TLS.CAFile, "tls-ca", "", "CA bundle to verify the backend")
+	fs.BoolVar(&f.bc.TLS.Insecure, "tls-insecure", false, "skip backend certificate verification")
+	fs.IntVar(&f.sc.Spans, "spans", engine.DefaultSpans, "spans written")
+	fs.IntVar(&f.sc.BatchSize, "batch", engine.DefaultBatchSize, "spans per write")
+	fs.IntVar(&f.sc.Workers, "workers", 1, "concurrent writers")
+	fs.IntVar(&f.sc.Lookups, "lookups", engine.DefaultLookups, "trace IDs queried back after the write phase (-1 disables)")
+	fs.DurationVar(&f.sc.Settle, "settle", 0, "wait between writing and querying, for backends that buffer ingest (e.g. 15s for tempo)")
+	fs.StringVar(&f.sc.StorageDir, "storage-dir", "", "backend data directory on this host; its growth gives write_amplification for tempo/jaeger")
+	fs.Int64Var(&f.sc.Seed, "seed", 0, "trace generation seed (0: time-based, so reruns write new traces)")
+	fs.StringVar(&f.jsonOut, "json-out", "", "write the result JSON here instead of stdout")
+}
+
+// storage-bench 모드: 생성 span을 저장 백엔드에 쓰고 ingest 처리량, write amplification,
+// trace ID 조회 지연을 잰다(백엔드 선정용)
+func runStorageBench(args []string) {
+	var f storageBenchFlags
+	fs := flag.NewFlagSet("storage-bench", flag.ExitOnError)
+	f.define(fs)
+	fs.Parse(args)
+
+	var err error
+	if f.bc.Headers, err = f.headers.resolve(); err != nil {
+		fail(err)
+	}
+	if (f.bc.Kind == engine.BackendTempo || f.bc.Kind == engine.BackendJaeger) && f.bc.QueryURL == "" && f.sc.Lookups >= 0 {
+		fail(fmt.Errorf("storage-bench: -query-url is required for %s lookups (or -lookups=-1)", f.bc.Kind))
+	}
+	f.sc.Backend = f.bc
+	r, err := engine.RunStorage(context.Background(), f.sc)
+	if err != nil {
+		fail(err)
+	}
+	logger.Info(evStorageResult, "backend", r.Backend, "spans_per_sec", r.SpansPerSec, "write_p95_ms", r.WriteP95ms,
+		"write_amplification", r.WriteAmplification, "lookup_p95_ms", r.LookupP95ms, "lookup_missing", r.LookupMissing)
+	if f.jsonOut == "" {
+		output.WriteJSON(os.Stdout, r)
+		return
+	}
+	if err := output.WriteJSONFile(f.jsonOut, r); err != nil {
+		fail(err)
+	}
+	logger.Info(evResultWritten, "path", f.jsonOut)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/duri/trace_bench/history"
+)
+
+// subcommand는 하위 명령의 실행 함수와 플래그 정의. run도 같은 정의로 FlagSet을
+// 만들므로 completion/man(describeFlags)이 실행 없이 플래그를 얻는다
+type subcommand struct {
+	run   func(args []string)
+	flags func(fs *flag.FlagSet) // 플래그가 없으면 nil
+}
+
+// 하위 명령: trace_bench <name> [args]. 플래그만 주면 기존 bench 실행
+var subcommands = map[string]subcommand{
+	"artifacts":     {runArtifacts, new(artifactsFlags).define},
+	"baseline":      {runBaseline, new(baselineFlags).define},
+	"bisect":        {runBisect, new(bisectFlags).define},
+	"calibrate":     {runCalibrate, new(calibrateFlags).define},
+	"compare":       {runCompare, new(compareFlags).define},
+	"digest":        {runDigest, new(digestFlags).define},
+	"goldens":       {runGoldens, new(goldensFlags).define},
+	"history":       {runHistory, new(historyFlags).define},
+	"init":          {runInit, new(initFlags).define},
+	"lint":          {runLint, new(lintFlags).define},
+	"merge":         {runMerge, new(mergeFlags).define},
+	"regress":       {runRegress, new(regressFlags).define},
+	"project":       {runProject, new(projectFlags).define},
+	"query-bench":   {runQueryBench, new(queryBenchFlags).define},
+	"queue-bench":   {runQueueBench, new(queueBenchFlags).define},
+	"schedule":      {runSchedule, new(scheduleFlags).define},
+	"solve":         {runSolve, new(solveFlags).define},
+	"storage-bench": {runStorageBench, new(storageBenchFlags).define},
+}
+
+// subcommands를 참조하는 명령은 init에서 등록
+func init() {
+	subcommands["capabilities"] = subcommand{runCapabilities, new(capabilitiesFlags).define}
+	subcommands["completion"] = subcommand{run: runCompletion}
+	subcommands["man"] = subcommand{run: runMan}
+}
+
+// subcommandDoc은 completion/man에 쓰는 한 줄 설명. actions는 첫 인자로 받는 동작
+type subcommandDoc struct {
+	summary string
+	actions []string
+}
+
+var subcommandDocs = map[string]subcommandDoc{
+	"artifacts":     {"prune old runs and their stored artifacts", []string{"prune"}},
+	"baseline":      {"name recorded runs as baselines and diff against them", []string{"set", "get", "list", "diff"}},
+	"bisect":        {"drive git bisect run with the SLO verdict as oracle", nil},
+	"calibrate":     {"fit the model-mode coefficients to recorded real runs", nil},
+	"capabilities":  {"list supported workloads, encodings, output formats and schema versions", nil},
+	"compare":       {"diff a result against a baseline", nil},
+	"completion":    {"print a bash, zsh or fish completion script", []string{"bash", "zsh", "fish"}},
+	"digest":        {"summarize recent scheduled runs and send them by mail or webhook", nil},
+	"goldens":       {"check or update the golden files locking every output format", []string{"check", "update"}},
+	"history":       {"list recorded runs", nil},
+	"init":          {"scaffold a scenario, SLO and profile set from a template", nil},
+	"lint":          {"check bench configs for common mistakes", nil},
+	"man":           {"print the trace_bench(1) man page", nil},
+	"merge":         {"combine parallel shard results into one", nil},
+	"project":       {"extrapolate measured bytes/CPU per span to target traffic", nil},
+	"query-bench":   {"measure per-query p95 of trace queries under load", nil},
+	"queue-bench":   {"measure end-to-end latency and lag through Kafka/NATS", nil},
+	"regress":       {"check a recorded run for a sustained regression", nil},
+	"schedule":      {"run configured benches on cron schedules", nil},
+	"solve":         {"find the cheapest config that meets the SLOs", nil},
+	"storage-bench": {"measure storage backend ingest and lookup latency", nil},
+}
+
+// describeFlags는 명령의 플래그 정의만 새 FlagSet에 적용한다. name이 ""이면 기본 실행
+func describeFlags(name string) *flag.FlagSet {
+	if name == "" {
+		fs := flag.NewFlagSet("trace_bench", flag.ContinueOnError)
+		defineBenchFlags(fs)
+		return fs
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	if def := subcommands[name].flags; def != nil {
+		def(fs)
+	}
+	return fs
+}
+
+func runSubcommand(name string, args []string) {
+	cmd, ok := subcommands[name]
+	if !ok {
+		names := make([]string, 0, len(subcommands))
+		for n := range subcommands {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(os.Stderr, "unknown command %q (commands: %s)\n", name, strings.Join(names, ", "))
+		os.Exit(2)
+	}
+	cmd.run(args)
+}
+
+// historyPath는 -history 기본값(TRACE_BENCH_HISTORY)
+func historyPath() string { return os.Getenv(history.EnvPath) }
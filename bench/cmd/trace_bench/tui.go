@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/slo"
+)
+
+// -tui 화면 갱신/헬스 조회 주기
+const (
+	tuiRefresh      = 500 * time.Millisecond
+	tuiHealthPeriod = 5 * time.Second
+	tuiLogLines     = 6
+	tuiGaugeWidth   = 40
+)
+
+const (
+	tuiReset = "\x1b[0m"
+	tuiBold  = "\x1b[1m"
+	tuiDim   = "\x1b[2m"
+	tuiRed   = "\x1b[31m"
+	tuiGreen = "\x1b[32m"
+	tuiCyan  = "\x1b[36m"
+)
+
+// runMonitor는 -tui 실시간 화면: 분위수 게이지, RPS, 오류 분류, 대상 헬스, 최근 로그.
+// 측정 동안 logger 출력을 화면 패널로 돌리고, 끝나면 모아 둔 로그를 stderr로 내보낸다
+type runMonitor struct {
+	out    io.Writer
+	color  bool
+	title  string
+	live   *engine.Live
+	limits map[string]float64 // p95_ms/p99_ms SLO 임계값(게이지 척도)
+
+	healthURLs []string
+	healthTO   time.Duration
+	tls        engine.TLSOptions
+
+	mu      sync.Mutex
+	phase   string
+	health  []engine.HealthCheck
+	logs    bytes.Buffer
+	rps     []float64
+	lastOps int64
+	lastAt  time.Duration
+
+	prevLog io.Writer
+	stop    chan struct{}
+	done    sync.WaitGroup
+}
+
+func newRunMonitor(title string, live *engine.Live, slos []slo.SLO, healthURLs []string, healthTO time.Duration, o engine.TLSOptions) *runMonitor {
+	m := &runMonitor{
+		out: os.Stderr, color: os.Getenv("NO_COLOR") == "", title: title, live: live, limits: map[string]float64{},
+		healthURLs: healthURLs, healthTO: healthTO, tls: o, stop: make(chan struct{}),
+	}
+	for _, s := range slos {
+		if s.Threshold != nil {
+			m.limits[s.Indicator.BenchMetric] = *s.Threshold
+		}
+	}
+	return m
+}
+
+// phases는 phase 전환을 화면에 반영하며 next(self-telemetry 등)로 넘긴다
+func (m *runMonitor) phases(next engine.PhaseFunc) engine.PhaseFunc {
+	return func(ctx context.Context, phase string) func(error) {
+		m.mu.Lock()
+		m.phase = phase
+		m.mu.Unlock()
+		if next == nil {
+			return func(error) {}
+		}
+		return next(ctx, phase)
+	}
+}
+
+func (m *runMonitor) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.logs.Write(p)
+}
+
+func (m *runMonitor) start() {
+	m.prevLog = logOut.set(m)
+	fmt.Fprint(m.out, "\x1b[?25l")
+	m.done.Add(1)
+	go func() {
+		defer m.done.Done()
+		t := time.NewTicker(tuiRefresh)
+		defer t.Stop()
+		for {
+			m.draw()
+			select {
+			case <-t.C:
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+	if len(m.healthURLs) > 0 {
+		m.done.Add(1)
+		go func() {
+			defer m.done.Done()
+			for {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Duration(len(m.healthURLs)+1)*m.healthTO)
+				cs, _ := engine.CheckHealth(ctx, m.healthURLs, m.healthTO, m.tls)
+				cancel()
+				m.mu.Lock()
+				m.health = cs
+				m.mu.Unlock()
+				select {
+				case <-time.After(tuiHealthPeriod):
+				case <-m.stop:
+					return
+				}
+			}
+		}()
+	}
+}
+
+// finish는 마지막 화면을 남기고 터미널/로그 출력을 되돌린다
+func (m *runMonitor) finish() {
+	close(m.stop)
+	m.done.Wait()
+	m.draw()
+	fmt.Fprint(m.out, "\x1b[?25h")
+	logOut.set(m.prevLog)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prevLog.Write(m.logs.Bytes())
+}
+
+func (m *runMonitor) paint(code, s string) string {
+	if !m.color || code == "" {
+		return s
+	}
+	return code + s + tuiReset
+}
+
+func (m *runMonitor) draw() {
+	s := m.live.Snapshot()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if dt := (s.Elapsed - m.lastAt).Seconds(); dt > 0 {
+		m.rps = append(m.rps, float64(s.Ops-m.lastOps)/dt)
+		if len(m.rps) > tuiGaugeWidth {
+			m.rps = m.rps[1:]
+		}
+	}
+	m.lastOps, m.lastAt = s.Ops, s.Elapsed
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&b, "%s  %s\n", m.paint(tuiBold, "trace_bench"), m.title)
+	fmt.Fprintf(&b, "%s %-8s %s %s\n\n", m.paint(tuiDim, "phase"), m.phase, m.paint(tuiDim, "elapsed"), s.Elapsed.Truncate(100*time.Millisecond))
+
+	// 분위수 게이지: SLO 임계값이 있으면 그 척도(초과 시 빨강), 없으면 p99 기준
+	scale := s.P99ms * 1.25
+	for _, k := range []string{"p95_ms", "p99_ms"} {
+		if l, ok := m.limits[k]; ok {
+			scale = max(scale, l)
+		}
+	}
+	b.WriteString(m.paint(tuiBold+tuiCyan, "latency (recent window)") + "\n")
+	for _, g := range []struct {
+		name string
+		v    float64
+	}{{"p50_ms", s.P50ms}, {"p95_ms", s.P95ms}, {"p99_ms", s.P99ms}, {"max_ms", s.MaxMs}} {
+		n := 0
+		if scale > 0 {
+			n = min(int(g.v/scale*tuiGaugeWidth+0.5), tuiGaugeWidth)
+		}
+		code, note := tuiGreen, ""
+		if l, ok := m.limits[g.name]; ok {
+			note = fmt.Sprintf(" / slo %g", l)
+			if g.v > l {
+				code = tuiRed
+			}
+		} else if g.name == "max_ms" {
+			code = tuiDim
+		}
+		bar := m.paint(code, strings.Repeat("█", n)) + m.paint(tuiDim, strings.Repeat("·", tuiGaugeWidth-n))
+		fmt.Fprintf(&b, "  %-7s %s %10.3f%s\n", g.name, bar, g.v, m.paint(tuiDim, note))
+	}
+
+	rate := 0.0
+	if len(m.rps) > 0 {
+		rate = m.rps[len(m.rps)-1]
+	}
+	errRate := 0.0
+	if s.Ops > 0 {
+		errRate = float64(s.Failed) / float64(s.Ops)
+	}
+	fmt.Fprintf(&b, "\n%s\n", m.paint(tuiBold+tuiCyan, "throughput"))
+	fmt.Fprintf(&b, "  rps     %s %10.1f\n", m.paint(tuiCyan, fmt.Sprintf("%-*s", tuiGaugeWidth, output.Sparkline(m.rps, 0, 0))), rate)
+	fmt.Fprintf(&b, "  ops %d  failed %d  error_rate %.5f  sent %.1f KB\n", s.Ops, s.Failed, errRate, float64(s.Bytes)/1024)
+
+	fmt.Fprintf(&b, "\n%s\n", m.paint(tuiBold+tuiCyan, "errors"))
+	if len(s.Errors) == 0 {
+		b.WriteString(m.paint(tuiDim, "  none") + "\n")
+	}
+	classes := make([]string, 0, len(s.Errors))
+	for k := range s.Errors {
+		classes = append(classes, k)
+	}
+	sort.Slice(classes, func(i, j int) bool { return s.Errors[classes[i]] > s.Errors[classes[j]] })
+	for _, k := range classes {
+		fmt.Fprintf(&b, "  %-20s %s\n", k, m.paint(tuiRed, fmt.Sprint(s.Errors[k])))
+	}
+
+	if len(m.healthURLs) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", m.paint(tuiBold+tuiCyan, "target health"))
+		if m.health == nil {
+			b.WriteString(m.paint(tuiDim, "  checking...") + "\n")
+		}
+		for _, c := range m.health {
+			st := m.paint(tuiGreen, "healthy")
+			if !c.Healthy {
+				st = m.paint(tuiRed, "unhealthy")
+				if c.Error != "" {
+					st += m.paint(tuiDim, " "+c.Error)
+				}
+			}
+			fmt.Fprintf(&b, "  %-40s %s %s\n", c.URL, st, m.paint(tuiDim, fmt.Sprintf("%.1fms", c.LatencyMs)))
+		}
+	}
+
+	if lines := lastLines(m.logs.String(), tuiLogLines); len(lines) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", m.paint(tuiBold+tuiCyan, "log"))
+		for _, l := range lines {
+			b.WriteString("  " + m.paint(tuiDim, l) + "\n")
+		}
+	}
+	io.WriteString(m.out, b.String())
+}
+
+func lastLines(s string, n int) []string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil
+	}
+	return lines[max(len(lines)-n, 0):]
+}
@@ -0,0 +1,218 @@
+// Package collector attaches custom measurements (queue depth, cache hit
+// ratio, ...) to a bench run. A collector is any executable speaking
+// newline-delimited JSON over stdio: trace_bench writes one request per line
+// to its stdin and reads one response line from its stdout.
+//
+//	→ {"op":"start","run_id":"…","labels":{"env":"staging"}}   ← {"ok":true}
+//	→ {"op":"collect","elapsed_s":62.4}                        ← {"metrics":{"queue_depth":42}}
+//	→ {"op":"stop"}                                            (stdin is closed; exit)
+//
+// Any response may instead be {"error":"…"}. Stderr is passed through, so
+// collectors can log there. The collected metrics are merged into the
+// result's custom_metrics as <collector>.<metric>.
+package collector
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds each request/response exchange.
+const DefaultTimeout = 10 * time.Second
+
+var nameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Spec is one -collector name=command.
+type Spec struct {
+	Name string
+	Argv []string
+}
+
+// ParseSpec parses "name=command arg...".
+func ParseSpec(s string) (Spec, error) {
+	name, cmd, ok := strings.Cut(s, "=")
+	argv := strings.Fields(cmd)
+	if !ok || len(argv) == 0 {
+		return Spec{}, fmt.Errorf("invalid collector %q (want name=command)", s)
+	}
+	if !nameRe.MatchString(name) {
+		return Spec{}, fmt.Errorf("invalid collector name %q", name)
+	}
+	return Spec{Name: name, Argv: argv}, nil
+}
+
+// Hello is the start request.
+type Hello struct {
+	RunID  string            `json:"run_id"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type request struct {
+	Op       string  `json:"op"`
+	ElapsedS float64 `json:"elapsed_s,omitempty"`
+	*Hello
+}
+
+type response struct {
+	OK      bool               `json:"ok"`
+	Metrics map[string]float64 `json:"metrics"`
+	Error   string             `json:"error"`
+}
+
+// Process is a running collector.
+type Process struct {
+	Spec
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan string
+	started time.Time
+	timeout time.Duration
+}
+
+// Start launches the collector and performs the start exchange.
+func Start(ctx context.Context, s Spec, hello Hello, timeout time.Duration) (*Process, error) {
+	cmd := exec.CommandContext(ctx, s.Argv[0], s.Argv[1:]...)
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "TRACE_BENCH_RUN_ID="+hello.RunID, "TRACE_BENCH_COLLECTOR="+s.Name)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("collector %s: %w", s.Name, err)
+	}
+	p := &Process{Spec: s, cmd: cmd, stdin: stdin, lines: make(chan string), timeout: timeout}
+	go func() {
+		sc := bufio.NewScanner(stdout)
+		sc.Buffer(make([]byte, 64<<10), 1<<20)
+		for sc.Scan() {
+			p.lines <- sc.Text()
+		}
+		close(p.lines)
+	}()
+	if _, err := p.call(request{Op: "start", Hello: &hello}); err != nil {
+		p.Stop()
+		return nil, err
+	}
+	p.started = time.Now()
+	return p, nil
+}
+
+// call은 요청 한 줄을 쓰고 응답 한 줄을 timeout까지 기다린다
+func (p *Process) call(req request) (response, error) {
+	var resp response
+	b, _ := json.Marshal(req)
+	if _, err := p.stdin.Write(append(b, '\n')); err != nil {
+		return resp, fmt.Errorf("collector %s: %s: %w", p.Name, req.Op, err)
+	}
+	select {
+	case line, ok := <-p.lines:
+		if !ok {
+			return resp, fmt.Errorf("collector %s: %s: exited without a response", p.Name, req.Op)
+		}
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			return resp, fmt.Errorf("collector %s: %s: invalid response %q", p.Name, req.Op, line)
+		}
+	case <-time.After(p.timeout):
+		return resp, fmt.Errorf("collector %s: %s: no response within %s", p.Name, req.Op, p.timeout)
+	}
+	if resp.Error != "" {
+		return resp, fmt.Errorf("collector %s: %s: %s", p.Name, req.Op, resp.Error)
+	}
+	return resp, nil
+}
+
+// Collect asks for the measurements since start.
+func (p *Process) Collect() (map[string]float64, error) {
+	resp, err := p.call(request{Op: "collect", ElapsedS: time.Since(p.started).Seconds()})
+	if err != nil {
+		return nil, err
+	}
+	for k := range resp.Metrics {
+		if !nameRe.MatchString(k) {
+			return nil, fmt.Errorf("collector %s: invalid metric name %q", p.Name, k)
+		}
+	}
+	return resp.Metrics, nil
+}
+
+// Stop sends stop, closes stdin and waits briefly for the process to exit.
+func (p *Process) Stop() error {
+	b, _ := json.Marshal(request{Op: "stop"})
+	p.stdin.Write(append(b, '\n'))
+	p.stdin.Close()
+	done := make(chan error, 1)
+	go func() {
+		for range p.lines {
+		}
+		done <- p.cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(p.timeout):
+		p.cmd.Process.Kill()
+		return fmt.Errorf("collector %s: did not exit after stop", p.Name)
+	}
+}
+
+// Set is the collectors of one run.
+type Set []*Process
+
+// StartAll starts every spec; on error the ones already started are stopped.
+func StartAll(ctx context.Context, specs []Spec, hello Hello, timeout time.Duration) (Set, error) {
+	var set Set
+	for _, s := range specs {
+		p, err := Start(ctx, s, hello, timeout)
+		if err != nil {
+			set.Stop()
+			return nil, err
+		}
+		set = append(set, p)
+	}
+	return set, nil
+}
+
+// Collect merges every collector's metrics as <collector>.<metric>. A
+// failing collector is reported in errs and contributes nothing.
+func (s Set) Collect() (map[string]float64, []error) {
+	var errs []error
+	var out map[string]float64
+	for _, p := range s {
+		m, err := p.Collect()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for k, v := range m {
+			if out == nil {
+				out = map[string]float64{}
+			}
+			out[p.Name+"."+k] = v
+		}
+	}
+	return out, errs
+}
+
+// Stop stops every collector and returns the errors.
+func (s Set) Stop() []error {
+	var errs []error
+	for _, p := range s {
+		if err := p.Stop(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
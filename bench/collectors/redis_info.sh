@@ -0,0 +1,28 @@
+#!/usr/bin/env bash
+# trace_bench collector (JSON over stdio, see bench/collector): duri-redis INFO 지표
+#   trace_bench -collector redis=collectors/redis_info.sh ...
+# REDIS_CLI로 접속 방법을 바꿀 수 있다(기본: compose 컨테이너 안의 redis-cli)
+set -uo pipefail
+REDIS_CLI=${REDIS_CLI:-"docker exec duri-redis redis-cli"}
+
+info() { $REDIS_CLI INFO 2>/dev/null | tr -d '\r'; }
+field() { awk -F: -v k="$1" '$1==k {print $2}' <<<"$2"; }
+
+while IFS= read -r line; do
+  case "$line" in
+    *'"op":"start"'*)
+      if info | grep -q '^redis_version:'; then
+        echo '{"ok":true}'
+      else
+        echo '{"error":"redis not reachable via '"$REDIS_CLI"'"}'
+      fi
+      ;;
+    *'"op":"collect"'*)
+      s=$(info)
+      printf '{"metrics":{"used_memory_bytes":%s,"connected_clients":%s,"ops_per_sec":%s,"evicted_keys":%s}}\n' \
+        "$(field used_memory "$s")" "$(field connected_clients "$s")" \
+        "$(field instantaneous_ops_per_sec "$s")" "$(field evicted_keys "$s")"
+      ;;
+    *'"op":"stop"'*) exit 0 ;;
+  esac
+done
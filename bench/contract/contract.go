@@ -0,0 +1,134 @@
+// Package contract loads the declared metrics contract (metrics.yaml): the
+// metric names, types and aggregation labels that dashboards, recording
+// rules and guards may rely on.
+//
+// Schema (version 1):
+//
+//	version: 1
+//	metrics:
+//	  - name: http_server_duration_seconds
+//	    type: histogram          # histogram | counter | gauge
+//	    by: [service]            # aggregation labels
+//	    quantiles: [0.95]        # histogram only (default [0.95])
+//	  - name: http_server_requests_total
+//	    type: counter
+//	    by: [service]
+//	    error_selector: code=~"5.."   # counter only: emits an error ratio
+package contract
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/duri/trace_bench/internal/yamlite"
+)
+
+// Version is the supported schema version.
+const Version = 1
+
+// Metric types.
+const (
+	Histogram = "histogram"
+	Counter   = "counter"
+	Gauge     = "gauge"
+)
+
+// Contract is a parsed metrics.yaml.
+type Contract struct {
+	Version int      `json:"version"`
+	Metrics []Metric `json:"metrics"`
+}
+
+// Metric is one declared metric family.
+type Metric struct {
+	Name          string    `json:"name"`
+	Type          string    `json:"type"`
+	Help          string    `json:"help,omitempty"`
+	By            []string  `json:"by,omitempty"`
+	Quantiles     []float64 `json:"quantiles,omitempty"`
+	ErrorSelector string    `json:"error_selector,omitempty"`
+}
+
+var nameRe = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Load reads a .yaml/.yml or .json contract and validates it.
+func Load(path string) (*Contract, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Contract
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = yamlite.UnmarshalJSON(b, &c)
+	} else {
+		err = yamlite.Unmarshal(b, &c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Validate checks names, types and type-specific fields.
+func (c *Contract) Validate() error {
+	if c.Version != Version {
+		return fmt.Errorf("unsupported contract version: %d (expected %d)", c.Version, Version)
+	}
+	seen := map[string]bool{}
+	for _, m := range c.Metrics {
+		if !nameRe.MatchString(m.Name) {
+			return fmt.Errorf("invalid metric name: %q", m.Name)
+		}
+		if seen[m.Name] {
+			return fmt.Errorf("duplicate metric: %s", m.Name)
+		}
+		seen[m.Name] = true
+		for _, l := range m.By {
+			if !nameRe.MatchString(l) || strings.Contains(l, ":") {
+				return fmt.Errorf("metric %s: invalid label: %q", m.Name, l)
+			}
+		}
+		switch m.Type {
+		case Histogram:
+			for _, q := range m.Quantiles {
+				if q <= 0 || q >= 1 {
+					return fmt.Errorf("metric %s: invalid quantile: %v", m.Name, q)
+				}
+			}
+		case Counter, Gauge:
+			if len(m.Quantiles) > 0 {
+				return fmt.Errorf("metric %s: quantiles require type histogram", m.Name)
+			}
+		default:
+			return fmt.Errorf("metric %s: invalid type: %s", m.Name, m.Type)
+		}
+		if m.ErrorSelector != "" && m.Type != Counter {
+			return fmt.Errorf("metric %s: error_selector requires type counter", m.Name)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the declared metric by name.
+func (c *Contract) Lookup(name string) (Metric, bool) {
+	for _, m := range c.Metrics {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Metric{}, false
+}
+
+// QuantilesOrDefault returns the histogram quantiles, defaulting to p95.
+func (m Metric) QuantilesOrDefault() []float64 {
+	if len(m.Quantiles) == 0 {
+		return []float64{0.95}
+	}
+	return m.Quantiles
+}
@@ -0,0 +1,158 @@
+// Package cost prices trace pipeline configurations from measured per-span
+// volume (engine.VolumeStats), so sweeps can be ranked by monthly cost at
+// a given traffic volume instead of latency and size alone.
+//
+// Schema (version 1, costs.yaml):
+//
+//	version: 1
+//	currency: USD
+//	storage_gb_month: 0.023   # $ per GB-month stored
+//	retention_days: 30        # stored volume = daily volume × retention
+//	egress_gb: 0.09           # $ per GB sent to the backend
+//	core_hour: 0.04           # $ per CPU core-hour spent exporting
+package cost
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/duri/trace_bench/internal/yamlite"
+	"github.com/duri/trace_bench/stats"
+	"github.com/duri/trace_bench/validate"
+)
+
+// Version is the supported schema version.
+const Version = 1
+
+// DaysPerMonth is the billing month used for monthly figures.
+const DaysPerMonth = 30
+
+const gb = 1 << 30
+
+// Model is a parsed costs.yaml.
+type Model struct {
+	Version        int     `json:"version"`
+	Currency       string  `json:"currency,omitempty"`
+	StorageGBMonth float64 `json:"storage_gb_month"`
+	RetentionDays  float64 `json:"retention_days"`
+	EgressGB       float64 `json:"egress_gb"`
+	CoreHour       float64 `json:"core_hour"`
+}
+
+// Load reads a .yaml/.yml or .json cost model and validates it.
+func Load(path string) (*Model, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Model
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = yamlite.UnmarshalJSON(b, &m)
+	default:
+		err = yamlite.Unmarshal(b, &m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Validate checks the schema version and that every price is non-negative.
+func (m Model) Validate() error {
+	if m.Version != Version {
+		return fmt.Errorf("unsupported cost model version: %d (expected %d)", m.Version, Version)
+	}
+	for name, v := range map[string]float64{
+		"storage_gb_month": m.StorageGBMonth, "retention_days": m.RetentionDays,
+		"egress_gb": m.EgressGB, "core_hour": m.CoreHour,
+	} {
+		if err := validate.NonNegative(name, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Estimate is the monthly cost of one configuration at SpansPerDay.
+type Estimate struct {
+	Currency    string  `json:"currency,omitempty"`
+	SpansPerDay float64 `json:"spans_per_day"`
+	// StoredGB is the steady-state volume held for the retention period.
+	StoredGB  float64 `json:"stored_gb"`
+	EgressGB  float64 `json:"egress_gb_month"`
+	CoreHours float64 `json:"core_hours_month"`
+	Storage   float64 `json:"storage"`
+	Egress    float64 `json:"egress"`
+	CPU       float64 `json:"cpu"`
+	Monthly   float64 `json:"monthly"`
+}
+
+// Price returns the monthly cost of spansPerDay spans, each producing
+// bytesPerSpan of output and costing cpuSecondsPerSpan to export.
+func (m Model) Price(spansPerDay, bytesPerSpan, cpuSecondsPerSpan float64) Estimate {
+	dailyGB := spansPerDay * bytesPerSpan / gb
+	e := Estimate{
+		Currency:    m.Currency,
+		SpansPerDay: spansPerDay,
+		StoredGB:    dailyGB * m.RetentionDays,
+		EgressGB:    dailyGB * DaysPerMonth,
+		CoreHours:   spansPerDay * cpuSecondsPerSpan * DaysPerMonth / 3600,
+	}
+	e.Storage = e.StoredGB * m.StorageGBMonth
+	e.Egress = e.EgressGB * m.EgressGB
+	e.CPU = e.CoreHours * m.CoreHour
+	e.Monthly = e.Storage + e.Egress + e.CPU
+	for _, p := range []*float64{&e.StoredGB, &e.EgressGB, &e.CoreHours, &e.Storage, &e.Egress, &e.CPU, &e.Monthly} {
+		*p = stats.Round2(*p)
+	}
+	return e
+}
+
+// ParseTraffic parses a span rate such as "50M", "50M/day", "50M spans/day"
+// or "2k/s" (k, M, G, T suffixes) into spans per day.
+func ParseTraffic(s string) (float64, error) {
+	v := strings.TrimSpace(s)
+	per := 1.0
+	if i := strings.IndexByte(v, '/'); i >= 0 {
+		switch strings.TrimSpace(v[i+1:]) {
+		case "day", "d":
+		case "h", "hour":
+			per = 24
+		case "s", "sec":
+			per = 86400
+		default:
+			return 0, fmt.Errorf("invalid traffic: %q (expected <n>[k|M|G|T][ spans][/day|/h|/s])", s)
+		}
+		v = v[:i]
+	}
+	v = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), "spans"))
+	mul := 1.0
+	if n := len(v); n > 0 {
+		switch v[n-1] {
+		case 'k', 'K':
+			mul = 1e3
+		case 'M':
+			mul = 1e6
+		case 'G', 'B':
+			mul = 1e9
+		case 'T':
+			mul = 1e12
+		}
+		if mul != 1 {
+			v = v[:n-1]
+		}
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	// NaN은 f <= 0을 통과하고, 큰 값은 단위를 곱하면 Inf가 된다
+	if err != nil || validate.Finite("traffic", f*mul*per) != nil || f <= 0 {
+		return 0, fmt.Errorf("invalid traffic: %q (expected <n>[k|M|G|T][ spans][/day|/h|/s])", s)
+	}
+	return f * mul * per, nil
+}
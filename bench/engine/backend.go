@@ -0,0 +1,413 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tracing backends for RunStorage and RunQueries (trace_bench storage-bench,
+// query-bench). Tempo and
+// Jaeger are written over OTLP/HTTP JSON and read through their
+// /api/traces/<id> query API; ClickHouse is written and read over its HTTP
+// interface using the OTel collector exporter's span table layout.
+const (
+	BackendTempo      = "tempo"
+	BackendJaeger     = "jaeger"
+	BackendClickHouse = "clickhouse"
+)
+
+// Backends lists the supported storage backends.
+var Backends = []string{BackendTempo, BackendJaeger, BackendClickHouse}
+
+// DefaultClickHouseTable is the clickhouseexporter default span table.
+const DefaultClickHouseTable = "otel_traces"
+
+// BackendConfig addresses one tracing backend.
+type BackendConfig struct {
+	Kind string
+	// WriteURL is the OTLP/HTTP traces URL (tempo, jaeger; e.g.
+	// http://tempo:4318/v1/traces) or the ClickHouse HTTP URL.
+	WriteURL string
+	// QueryURL is the query API base (tempo: http://tempo:3200, jaeger:
+	// http://jaeger:16686); ClickHouse queries go to WriteURL.
+	QueryURL string
+	Table    string // clickhouse span table (default DefaultClickHouseTable)
+	Headers  map[string]string
+	Timeout  time.Duration // one request (default DefaultTimeout)
+	TLS      TLSOptions
+}
+
+// errNoStoredBytes: 백엔드가 저장 용량을 알려 주지 않음(write amplification 생략)
+var errNoStoredBytes = errors.New("stored bytes not available")
+
+// traceBackend는 저장소 벤치의 쓰기/조회 드라이버
+type traceBackend interface {
+	// write는 배치를 저장하고 보낸 바이트 수를 반환
+	write(ctx context.Context, spans []span) (int, error)
+	// traceByID는 trace가 조회되는지
+	traceByID(ctx context.Context, traceID string) (bool, error)
+	// storedBytes는 백엔드가 디스크에 쓴 총량(지원하지 않으면 errNoStoredBytes)
+	storedBytes(ctx context.Context) (int64, error)
+	// search는 검색 쿼리(query-bench)를 보내고 찾은 trace 수를 반환
+	search(ctx context.Context, q Query) (int, error)
+}
+
+var tableRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Validate checks the backend kind and URLs.
+func (c BackendConfig) Validate() error {
+	valid := func(name, s string) error {
+		if u, err := url.Parse(s); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid %s: %q", name, s)
+		}
+		return nil
+	}
+	if err := valid("write url", c.WriteURL); err != nil {
+		return err
+	}
+	switch c.Kind {
+	case BackendTempo, BackendJaeger:
+		if c.QueryURL != "" {
+			return valid("query url", c.QueryURL)
+		}
+	case BackendClickHouse:
+		if c.Table != "" && !tableRe.MatchString(c.Table) {
+			return fmt.Errorf("invalid clickhouse table: %q", c.Table)
+		}
+	default:
+		return fmt.Errorf("invalid backend: %s (expected %s)", c.Kind, strings.Join(Backends, "|"))
+	}
+	return nil
+}
+
+func newTraceBackend(c BackendConfig, workers int) (traceBackend, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	tr, err := newTransport(ConnMode{Kind: ConnReuse}, c.TLS, "", workers)
+	if err != nil {
+		return nil, err
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	h := httpBackend{client: &http.Client{Transport: tr, Timeout: timeout}, headers: c.Headers}
+	if c.Kind == BackendClickHouse {
+		table := c.Table
+		if table == "" {
+			table = DefaultClickHouseTable
+		}
+		return &clickHouseBackend{httpBackend: h, url: c.WriteURL, table: table}, nil
+	}
+	return &otlpBackend{httpBackend: h, kind: c.Kind, writeURL: c.WriteURL, queryURL: strings.TrimRight(c.QueryURL, "/")}, nil
+}
+
+// httpBackend는 백엔드 공용 HTTP 호출
+type httpBackend struct {
+	client  *http.Client
+	headers map[string]string
+}
+
+// do는 요청을 보내고 2xx 본문을 돌려준다. 2xx가 아니면 statusError
+func (h httpBackend) do(ctx context.Context, method, u, contentType string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return b, statusError{resp.StatusCode, strings.TrimSpace(string(b[:min(len(b), 200)]))}
+	}
+	return b, nil
+}
+
+// statusError는 2xx가 아닌 응답
+type statusError struct {
+	code int
+	body string
+}
+
+func (e statusError) Error() string { return fmt.Sprintf("http %d: %s", e.code, e.body) }
+
+// backendErrorClass는 Result.Errors와 같은 분류로
+func backendErrorClass(err error) string {
+	var se statusError
+	if errors.As(err, &se) {
+		if se.code >= 500 {
+			return ErrHTTP5xx
+		}
+		return ErrHTTP4xx
+	}
+	return classifyError(err)
+}
+
+// otlpBackend: Tempo/Jaeger (OTLP/HTTP 쓰기, /api/traces/<id> 조회)
+type otlpBackend struct {
+	httpBackend
+	kind               string
+	writeURL, queryURL string
+}
+
+func (b *otlpBackend) write(ctx context.Context, spans []span) (int, error) {
+	body, err := encodeOTLP(spans)
+	if err != nil {
+		return 0, err
+	}
+	_, err = b.do(ctx, http.MethodPost, b.writeURL, "application/json", body)
+	return len(body), err
+}
+
+func (b *otlpBackend) traceByID(ctx context.Context, traceID string) (bool, error) {
+	if b.queryURL == "" {
+		return false, fmt.Errorf("no query url")
+	}
+	_, err := b.do(ctx, http.MethodGet, b.queryURL+"/api/traces/"+traceID, "", nil)
+	var se statusError
+	if errors.As(err, &se) && se.code == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b *otlpBackend) storedBytes(context.Context) (int64, error) { return 0, errNoStoredBytes }
+
+// search: Tempo는 /api/search(logfmt tags), Jaeger는 /api/traces(service 필수, JSON tags)
+func (b *otlpBackend) search(ctx context.Context, q Query) (int, error) {
+	if b.queryURL == "" {
+		return 0, fmt.Errorf("no query url")
+	}
+	v := url.Values{}
+	v.Set("limit", strconv.Itoa(searchLimit(q)))
+	if q.minDur > 0 {
+		v.Set("minDuration", q.minDur.String())
+	}
+	if q.maxDur > 0 {
+		v.Set("maxDuration", q.maxDur.String())
+	}
+	path := "/api/search"
+	if b.kind == BackendJaeger {
+		path = "/api/traces"
+		v.Set("service", q.Service)
+		if len(q.Tags) > 0 {
+			tags, err := json.Marshal(q.Tags)
+			if err != nil {
+				return 0, err
+			}
+			v.Set("tags", string(tags))
+		}
+	} else {
+		var tags []string
+		if q.Service != "" {
+			tags = append(tags, "service.name="+strconv.Quote(q.Service))
+		}
+		for _, k := range sortedKeys(q.Tags) {
+			tags = append(tags, k+"="+strconv.Quote(q.Tags[k]))
+		}
+		if len(tags) > 0 {
+			v.Set("tags", strings.Join(tags, " "))
+		}
+	}
+	body, err := b.do(ctx, http.MethodGet, b.queryURL+path+"?"+v.Encode(), "", nil)
+	if err != nil {
+		return 0, err
+	}
+	var resp struct {
+		Traces []json.RawMessage `json:"traces"` // tempo
+		Data   []json.RawMessage `json:"data"`   // jaeger
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, fmt.Errorf("decode search response: %w", err)
+	}
+	return len(resp.Traces) + len(resp.Data), nil
+}
+
+// searchLimit는 검색 결과 상한(기본 20)
+func searchLimit(q Query) int {
+	if q.Limit > 0 {
+		return q.Limit
+	}
+	return 20
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// clickHouseBackend: HTTP 인터페이스로 JSONEachRow INSERT, TraceId 조회, system.parts 용량
+type clickHouseBackend struct {
+	httpBackend
+	url, table string
+}
+
+// chSpan은 clickhouseexporter otel_traces 열의 부분 집합(나머지는 기본값)
+type chSpan struct {
+	Timestamp      string            `json:"Timestamp"`
+	TraceID        string            `json:"TraceId"`
+	SpanID         string            `json:"SpanId"`
+	ParentSpanID   string            `json:"ParentSpanId"`
+	SpanName       string            `json:"SpanName"`
+	SpanKind       string            `json:"SpanKind"`
+	ServiceName    string            `json:"ServiceName"`
+	SpanAttributes map[string]string `json:"SpanAttributes"`
+	Duration       int64             `json:"Duration"`
+	StatusCode     string            `json:"StatusCode"`
+}
+
+// query는 HTTP 인터페이스로 SQL을 보낸다. params는 {name:Type} 자리표시자 값(param_<name>)
+func (b *clickHouseBackend) query(ctx context.Context, q string, body []byte, params ...string) ([]byte, error) {
+	u, err := url.Parse(b.url)
+	if err != nil {
+		return nil, err
+	}
+	v := u.Query()
+	v.Set("query", q)
+	for i := 0; i+1 < len(params); i += 2 {
+		v.Set("param_"+params[i], params[i+1])
+	}
+	u.RawQuery = v.Encode()
+	return b.do(ctx, http.MethodPost, u.String(), "", body)
+}
+
+func (b *clickHouseBackend) write(ctx context.Context, spans []span) (int, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, s := range spans {
+		row := chSpan{
+			Timestamp:      time.Unix(0, s.Start).UTC().Format("2006-01-02 15:04:05.000000000"),
+			TraceID:        hex.EncodeToString(s.TraceID[:]),
+			SpanID:         hex.EncodeToString(s.SpanID[:]),
+			SpanName:       s.Name,
+			SpanKind:       "SPAN_KIND_INTERNAL",
+			ServiceName:    spanService(s),
+			SpanAttributes: make(map[string]string, len(s.Attrs)),
+			Duration:       s.End - s.Start,
+			StatusCode:     "STATUS_CODE_UNSET",
+		}
+		if s.ParentID != ([8]byte{}) {
+			row.ParentSpanID = hex.EncodeToString(s.ParentID[:])
+		}
+		for _, a := range s.Attrs {
+			if a.Key != "service.name" {
+				row.SpanAttributes[a.Key] = a.Value
+			}
+		}
+		if spanFailed(s) {
+			row.StatusCode = "STATUS_CODE_ERROR"
+		}
+		if err := enc.Encode(row); err != nil {
+			return 0, err
+		}
+	}
+	_, err := b.query(ctx, "INSERT INTO "+b.table+" FORMAT JSONEachRow", buf.Bytes())
+	return buf.Len(), err
+}
+
+func (b *clickHouseBackend) traceByID(ctx context.Context, traceID string) (bool, error) {
+	if _, err := hex.DecodeString(traceID); err != nil {
+		return false, fmt.Errorf("invalid trace id: %q", traceID)
+	}
+	out, err := b.query(ctx, "SELECT count() FROM "+b.table+" WHERE TraceId = '"+traceID+"'", nil)
+	if err != nil {
+		return false, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	return n > 0, err
+}
+
+func (b *clickHouseBackend) storedBytes(ctx context.Context) (int64, error) {
+	db, table := "currentDatabase()", "'"+b.table+"'"
+	if i := strings.IndexByte(b.table, '.'); i >= 0 {
+		db, table = "'"+b.table[:i]+"'", "'"+b.table[i+1:]+"'"
+	}
+	out, err := b.query(ctx, "SELECT sum(bytes_on_disk) FROM system.parts WHERE active AND database = "+db+" AND table = "+table, nil)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+}
+
+// search는 조건에 맞는 trace를 limit개까지 세어 본다(값은 query parameter로 전달)
+func (b *clickHouseBackend) search(ctx context.Context, q Query) (int, error) {
+	var where, params []string
+	if q.Service != "" {
+		where = append(where, "ServiceName = {svc:String}")
+		params = append(params, "svc", q.Service)
+	}
+	if q.minDur > 0 {
+		where = append(where, "Duration >= {min_ns:Int64}")
+		params = append(params, "min_ns", strconv.FormatInt(int64(q.minDur), 10))
+	}
+	if q.maxDur > 0 {
+		where = append(where, "Duration <= {max_ns:Int64}")
+		params = append(params, "max_ns", strconv.FormatInt(int64(q.maxDur), 10))
+	}
+	for i, k := range sortedKeys(q.Tags) {
+		where = append(where, fmt.Sprintf("SpanAttributes[{k%d:String}] = {v%d:String}", i, i))
+		params = append(params, fmt.Sprintf("k%d", i), k, fmt.Sprintf("v%d", i), q.Tags[k])
+	}
+	sql := "SELECT count() FROM (SELECT DISTINCT TraceId FROM " + b.table
+	if len(where) > 0 {
+		sql += " WHERE " + strings.Join(where, " AND ")
+	}
+	sql += " LIMIT " + strconv.Itoa(searchLimit(q)) + ")"
+	out, err := b.query(ctx, sql, nil, params...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+// dirBytes는 로컬 데이터 디렉터리의 파일 크기 합(-storage-dir)
+func dirBytes(dir string) (int64, error) {
+	var n int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 백엔드가 쓰는 도중 사라진 파일(compaction 등)은 건너뜀
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			if fi, err := d.Info(); err == nil {
+				n += fi.Size()
+			}
+		}
+		return nil
+	})
+	return n, err
+}
@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Backoff is a retry delay policy parsed from -retry-backoff, e.g.
+// "exp:50ms" (50ms, 100ms, 200ms, ...) or "const:100ms".
+type Backoff struct {
+	Kind string // "exp" | "const"
+	Base time.Duration
+}
+
+// DefaultBackoff is used when retries are enabled without a policy.
+var DefaultBackoff = Backoff{Kind: "exp", Base: 50 * time.Millisecond}
+
+// ParseBackoff parses "exp:50ms", "const:100ms" or a bare duration (const).
+func ParseBackoff(s string) (Backoff, error) {
+	if s == "" {
+		return DefaultBackoff, nil
+	}
+	kind, dur, ok := strings.Cut(s, ":")
+	if !ok {
+		kind, dur = "const", s
+	}
+	if kind != "exp" && kind != "const" {
+		return Backoff{}, fmt.Errorf("invalid retry-backoff: %s (expected exp:<dur>|const:<dur>)", s)
+	}
+	d, err := time.ParseDuration(dur)
+	if err != nil || d < 0 {
+		return Backoff{}, fmt.Errorf("invalid retry-backoff: %s", s)
+	}
+	return Backoff{Kind: kind, Base: d}, nil
+}
+
+// Delay returns the wait before retry number attempt+1 (attempt is 0-based).
+func (b Backoff) Delay(attempt int) time.Duration {
+	if b.Kind == "exp" {
+		return b.Base << min(attempt, 16)
+	}
+	return b.Base
+}
+
+func (b Backoff) String() string { return b.Kind + ":" + b.Base.String() }
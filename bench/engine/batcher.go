@@ -0,0 +1,306 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// DefaultBatchTimeout is the batch processor flush timeout, matching the
+// OTel batch processor's timeout default.
+const DefaultBatchTimeout = 200 * time.Millisecond
+
+// DefaultQueueSize is the batch processor queue in spans, matching the
+// OTel batch processor's max_queue_size default.
+const DefaultQueueSize = 2048
+
+// Backpressure policies of the batch processor queue (Config.Backpressure).
+const (
+	// BackpressureDrop drops batches arriving at a full queue, like the OTel
+	// batch processor.
+	BackpressureDrop = "drop"
+	// BackpressureBlock stalls span arrival until the queue has room, so
+	// overload shows up as delay instead of loss.
+	BackpressureBlock = "block"
+)
+
+// Backpressures lists the supported Config.Backpressure values.
+var Backpressures = []string{BackpressureDrop, BackpressureBlock}
+
+// BatchStats reports a run through the emulated OTel batch processor
+// (Config.BatchTimeout): spans arrive at SpanRate, are flushed when
+// BatchSize spans are pending or BatchTimeout has passed since the oldest
+// one, and are dropped (or block arrival) when the queue ahead of the
+// exporters is full.
+type BatchStats struct {
+	BatchSize      int     `json:"batch_size"`
+	BatchTimeoutMs float64 `json:"batch_timeout_ms"`
+	SpanRate       float64 `json:"span_rate,omitempty"` // 0: as fast as the exporters drain, never dropping
+	Backpressure   string  `json:"backpressure"`
+	// The queue is bounded by QueueSize spans or, with Config.QueueMem, by
+	// QueueMemBytes of queued and in-export spans.
+	QueueSize         int     `json:"queue_size,omitempty"`
+	QueueMemBytes     int64   `json:"queue_mem_bytes,omitempty"`
+	QueueMemPeakBytes int64   `json:"queue_mem_peak_bytes"`
+	BlockedMs         float64 `json:"blocked_ms"`
+	BlockedRatio      float64 `json:"blocked_ratio"` // share of the run span arrival was stalled
+	Batches           int     `json:"batches"`
+	SizeFlushes       int     `json:"size_flushes"`
+	TimeoutFlushes    int     `json:"timeout_flushes"`
+	MeanBatchSpans    float64 `json:"mean_batch_spans"`
+	Arrived           int     `json:"arrived"` // sampled spans offered to the queue
+	Dropped           int     `json:"dropped"`
+	DropRate          float64 `json:"drop_rate"`
+	// SpansPerSec is the exported (not dropped) span throughput.
+	SpansPerSec float64 `json:"spans_per_sec"`
+	// Delay is the time the oldest span of each batch spent from arrival
+	// until its batch was exported: batching wait + queueing + export.
+	DelayP95ms float64 `json:"delay_p95_ms"`
+	DelayP99ms float64 `json:"delay_p99_ms"`
+	// DelayGrowthMs is how much the delay grew from the first to the last
+	// batch of the run (least-squares fit): a queue falling behind.
+	DelayGrowthMs float64 `json:"delay_growth_ms"`
+}
+
+// batchJob은 flush된 배치 하나
+type batchJob struct {
+	spans   []span    // 샘플링 후 span
+	covered int       // 샘플링 전 도착 span 수
+	first   time.Time // 가장 오래된 span의 도착 시각
+	mem     int64     // queue 메모리 추정치
+}
+
+// delayPoint는 배치 하나의 (도착 후 경과 s, 지연 ms)
+type delayPoint struct{ at, ms float64 }
+
+// spanMem은 queue에 있는 span 하나의 메모리 추정치(구조체 + 문자열/속성)
+func spanMem(s span) int64 {
+	n := int64(unsafe.Sizeof(s)) + int64(len(s.Name)) + int64(cap(s.Attrs))*int64(unsafe.Sizeof(attr{}))
+	for _, a := range s.Attrs {
+		n += int64(len(a.Key) + len(a.Value))
+	}
+	return n
+}
+
+// batcher는 span 도착을 흉내 내며 크기/시간 조건으로 배치를 만든다
+type batcher struct {
+	size     int
+	timeout  time.Duration
+	rate     float64
+	queue    chan batchJob
+	sampling float64
+	block    bool
+	start    time.Time
+
+	// memLimit > 0이면 queue는 배치 수가 아니라 mem(export 중 포함)으로 제한
+	memLimit int64
+	mu       sync.Mutex
+	mem      int64
+	freed    chan struct{}
+
+	sizeFlushes, timeoutFlushes, dropped, batches, queued int
+	memPeak                                               int64
+	blocked                                               time.Duration
+}
+
+func newBatcher(cfg Config, batch int) *batcher {
+	qs := cfg.QueueSize
+	if qs <= 0 {
+		qs = DefaultQueueSize
+	}
+	b := &batcher{
+		size:     batch,
+		timeout:  cfg.BatchTimeout,
+		rate:     cfg.SpanRate,
+		sampling: cfg.Sampling,
+		block:    cfg.Backpressure == BackpressureBlock || cfg.SpanRate <= 0,
+		memLimit: cfg.QueueMem,
+		freed:    make(chan struct{}, 1),
+	}
+	if b.memLimit > 0 {
+		// 메모리 한도가 실제 제한, 채널은 모든 배치를 담을 수 있을 만큼
+		qs = cfg.Spans
+		if qs <= 0 {
+			qs = DefaultSpans
+		}
+	}
+	b.queue = make(chan batchJob, max(qs/batch, 1))
+	return b
+}
+
+// admit은 job의 메모리를 예약한다. queue가 비어 있으면 한도를 넘는 배치도 받는다
+func (b *batcher) admit(job batchJob) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.mem > 0 && b.mem+job.mem > b.memLimit {
+		return false
+	}
+	b.mem += job.mem
+	b.memPeak = max(b.memPeak, b.mem)
+	return true
+}
+
+// release는 export가 끝난 배치의 메모리를 돌려준다(워커 goroutine)
+func (b *batcher) release(job batchJob) {
+	if b.memLimit <= 0 {
+		return
+	}
+	b.mu.Lock()
+	b.mem -= job.mem
+	b.mu.Unlock()
+	select {
+	case b.freed <- struct{}{}:
+	default:
+	}
+}
+
+// enqueue는 job을 queue에 넣는다. block이면 자리가 날 때까지 도착을 멈추고
+// 그 시간을 blocked에 더한다. false: drop(또는 취소)
+func (b *batcher) enqueue(ctx context.Context, job batchJob) bool {
+	if b.memLimit > 0 {
+		if !b.admit(job) {
+			if !b.block {
+				return false
+			}
+			t0 := time.Now()
+			for !b.admit(job) {
+				select {
+				case <-b.freed:
+				case <-ctx.Done():
+					b.blocked += time.Since(t0)
+					return false
+				}
+			}
+			b.blocked += time.Since(t0)
+		}
+		b.queue <- job // 용량이 충분해 막히지 않는다
+		return true
+	}
+	select {
+	case b.queue <- job:
+		return true
+	default:
+	}
+	if !b.block {
+		return false
+	}
+	t0 := time.Now()
+	defer func() { b.blocked += time.Since(t0) }()
+	select {
+	case b.queue <- job:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// run은 all을 rate로 흘려 보내고 끝나면 queue를 닫는다. queue가 차 있으면
+// 배치를 버리거나(batch processor의 drop) 도착을 멈춘다(block, rate 0)
+func (b *batcher) run(ctx context.Context, all []span) {
+	defer close(b.queue)
+	var cur batchJob
+	flush := func(byTimeout bool) {
+		if len(cur.spans) == 0 {
+			// 전부 샘플링으로 빠진 배치는 보내지 않는다
+			cur = batchJob{}
+			return
+		}
+		if byTimeout {
+			b.timeoutFlushes++
+		} else {
+			b.sizeFlushes++
+		}
+		if b.enqueue(ctx, cur) {
+			b.batches++
+			b.queued += len(cur.spans)
+		} else {
+			b.dropped += len(cur.spans)
+		}
+		cur = batchJob{}
+	}
+	tick := time.NewTicker(time.Millisecond)
+	defer tick.Stop()
+	b.start = time.Now()
+	for i := 0; i < len(all); {
+		due := len(all)
+		if b.rate > 0 {
+			due = min(len(all), int(b.rate*time.Since(b.start).Seconds())+1)
+		}
+		for ; i < due; i++ {
+			if cur.covered == 0 {
+				// 도착 시각은 예정 시각: block으로 밀린 시간도 지연에 들어간다
+				cur.first = time.Now()
+				if b.rate > 0 {
+					cur.first = b.start.Add(time.Duration(float64(i) / b.rate * float64(time.Second)))
+				}
+			}
+			cur.covered++
+			if sampled(all[i].TraceID, b.sampling) {
+				cur.spans = append(cur.spans, all[i])
+				cur.mem += spanMem(all[i])
+			}
+			if len(cur.spans) >= b.size {
+				flush(false)
+			}
+		}
+		if cur.covered > 0 && time.Since(cur.first) >= b.timeout {
+			flush(true)
+		}
+		if i < len(all) {
+			select {
+			case <-tick.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+	// 남은 span은 종료 시 flush(timeout 분류)
+	flush(true)
+}
+
+func (b *batcher) stats(delay stats.Sketch, points []delayPoint, elapsed time.Duration) *BatchStats {
+	arrived := b.queued + b.dropped
+	bs := &BatchStats{
+		BatchSize:         b.size,
+		BatchTimeoutMs:    stats.Round5(float64(b.timeout) / float64(time.Millisecond)),
+		SpanRate:          b.rate,
+		Backpressure:      BackpressureDrop,
+		QueueMemBytes:     b.memLimit,
+		QueueMemPeakBytes: b.memPeak,
+		BlockedMs:         stats.Round5(float64(b.blocked) / float64(time.Millisecond)),
+		BlockedRatio:      stats.Round5(b.blocked.Seconds() / elapsed.Seconds()),
+		Batches:           b.batches,
+		SizeFlushes:       b.sizeFlushes,
+		TimeoutFlushes:    b.timeoutFlushes,
+		Arrived:           arrived,
+		Dropped:           b.dropped,
+		SpansPerSec:       stats.Round2(float64(b.queued) / elapsed.Seconds()),
+		DelayP95ms:        stats.Round5(delay.Quantile(0.95)),
+		DelayP99ms:        stats.Round5(delay.Quantile(0.99)),
+	}
+	if b.block {
+		bs.Backpressure = BackpressureBlock
+	}
+	if b.memLimit <= 0 {
+		bs.QueueSize = cap(b.queue) * b.size
+	}
+	if len(points) > 1 {
+		xs, ys := make([]float64, len(points)), make([]float64, len(points))
+		lo, hi := points[0].at, points[0].at
+		for i, p := range points {
+			xs[i], ys[i] = p.at, p.ms
+			lo, hi = min(lo, p.at), max(hi, p.at)
+		}
+		bs.DelayGrowthMs = stats.Round5(stats.Slope(xs, ys) * (hi - lo))
+	}
+	if b.batches > 0 {
+		bs.MeanBatchSpans = stats.Round2(float64(b.queued) / float64(b.batches))
+	}
+	if arrived > 0 {
+		bs.DropRate = stats.Round5(float64(b.dropped) / float64(arrived))
+	}
+	return bs
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// BuildInfo is the version a target reported on its build-info endpoint
+// (-target-buildinfo), recorded so gate evidence names the measured build.
+type BuildInfo struct {
+	URL     string `json:"url"`
+	Version string `json:"version,omitempty"`
+	SHA     string `json:"sha,omitempty"`
+	// Expected is -expected-sha, if any; Match reports whether SHA matched it.
+	Expected string `json:"expected_sha,omitempty"`
+	Match    *bool  `json:"match,omitempty"`
+}
+
+// build-info 응답에서 SHA로 읽는 키(앞쪽 우선)
+var shaKeys = []string{"sha", "git_sha", "commit", "git_commit", "revision", "vcs.revision"}
+
+var shaRe = regexp.MustCompile(`^[0-9a-fA-F]{7,64}$`)
+
+// ProbeBuildInfo GETs url and reads the target version and commit SHA. A
+// JSON object is searched for "version" and the usual SHA keys (sha,
+// git_sha, commit, revision, ...); a plain-text body is taken as the SHA
+// when it is hex, otherwise as the version.
+func ProbeBuildInfo(ctx context.Context, url string, timeout time.Duration, o TLSOptions) (*BuildInfo, error) {
+	client, err := probeClient(timeout, o)
+	if err != nil {
+		return nil, err
+	}
+	defer client.CloseIdleConnections()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("target buildinfo: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("target buildinfo: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("target buildinfo: %s -> %s", url, resp.Status)
+	}
+	b := &BuildInfo{URL: url}
+	var doc map[string]any
+	if err := json.Unmarshal(body, &doc); err == nil {
+		b.Version = jsonString(doc["version"])
+		for _, k := range shaKeys {
+			if v := jsonString(doc[k]); v != "" {
+				b.SHA = v
+				break
+			}
+		}
+	} else if t := string(bytes.TrimSpace(body)); shaRe.MatchString(t) {
+		b.SHA = t
+	} else {
+		b.Version = t
+	}
+	if b.Version == "" && b.SHA == "" {
+		return nil, fmt.Errorf("target buildinfo: %s reported no version or sha", url)
+	}
+	return b, nil
+}
+
+func jsonString(v any) string {
+	if s, ok := v.(string); ok {
+		return strings.TrimSpace(s)
+	}
+	return ""
+}
+
+// Check records expected and whether the reported SHA matches it. Short
+// SHAs match by prefix (at least 7 hex digits), case-insensitively.
+func (b *BuildInfo) Check(expected string) bool {
+	b.Expected = expected
+	got, want := strings.ToLower(b.SHA), strings.ToLower(expected)
+	ok := len(got) >= 7 && len(want) >= 7 && (strings.HasPrefix(got, want) || strings.HasPrefix(want, got))
+	b.Match = &ok
+	return ok
+}
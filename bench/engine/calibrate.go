@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/internal/yamlite"
+	"github.com/duri/trace_bench/stats"
+	"github.com/duri/trace_bench/validate"
+)
+
+// ModelFileVersion is the supported -model-file schema version.
+const ModelFileVersion = 1
+
+// ModelFile is a calibrated model as written by trace_bench calibrate.
+type ModelFile struct {
+	Version  int       `json:"version"`
+	FittedAt time.Time `json:"fitted_at"`
+	// Runs is the number of real-mode runs fitted.
+	Runs int `json:"runs"`
+	// Error is the mean absolute relative error of the fit per metric
+	// (p95_ms, size_kb) over those runs.
+	Error map[string]float64 `json:"error,omitempty"`
+	// Default lists the multipliers no run measured, kept from
+	// DefaultModel (e.g. "compression_size.zstd").
+	Default      []string     `json:"default,omitempty"`
+	Coefficients Coefficients `json:"coefficients"`
+}
+
+// LoadModel reads a -model-file and validates it.
+func LoadModel(path string) (*ModelFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var mf ModelFile
+	if err := yamlite.UnmarshalJSON(b, &mf); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if mf.Version != ModelFileVersion {
+		return nil, fmt.Errorf("%s: unsupported model file version: %d (expected %d)", path, mf.Version, ModelFileVersion)
+	}
+	if err := mf.Coefficients.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &mf, nil
+}
+
+// Write encodes mf as indented JSON.
+func (mf *ModelFile) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(mf)
+}
+
+// Observation is one real-mode measurement used for calibration.
+type Observation struct {
+	Sampling      float64
+	Serialization string
+	Compression   string
+	P95ms         float64
+	ErrorRate     float64
+	SizeKB        float64
+}
+
+// calibrateRounds bounds the alternating least-squares passes; the fit
+// converges in a few for the sweeps the history usually holds.
+const calibrateRounds = 50
+
+// Calibrate fits Coefficients to obs. Each product metric is fitted by
+// alternating least squares: the sampling line with the multipliers
+// fixed, then each multiplier with the rest fixed. The result is
+// normalized so json and none have multiplier 1 and the baseline is the
+// value at sampling 1 (Intercept+Slope = 1). Multipliers of values no
+// observation covers keep DefaultModel's and are listed in Default.
+func Calibrate(obs []Observation) (*ModelFile, error) {
+	if len(obs) == 0 {
+		return nil, fmt.Errorf("no real-mode runs to calibrate from")
+	}
+	for i, o := range obs {
+		o.Serialization, o.Compression = strings.ToLower(o.Serialization), strings.ToLower(o.Compression)
+		if err := validate.OneOf("serialization", o.Serialization, Serializations); err != nil {
+			return nil, fmt.Errorf("run %d: %w", i+1, err)
+		}
+		if err := validate.OneOf("compression", o.Compression, Compressions); err != nil {
+			return nil, fmt.Errorf("run %d: %w", i+1, err)
+		}
+		if err := validate.Fraction("sampling", o.Sampling); err != nil {
+			return nil, fmt.Errorf("run %d: %w", i+1, err)
+		}
+		obs[i] = o
+	}
+	def := DefaultModel.(Coefficients)
+	mf := &ModelFile{Version: ModelFileVersion, FittedAt: time.Now().UTC(), Runs: len(obs), Error: map[string]float64{}}
+	c := Coefficients{}
+
+	p95 := func(o Observation) float64 { return o.P95ms }
+	size := func(o Observation) float64 { return o.SizeKB }
+	var missing []string
+	c.BaseP95ms, c.P95, c.SerP95, c.CompP95, missing = fitProduct(obs, p95, def.P95, def.SerP95, def.CompP95)
+	mf.Default = append(mf.Default, prefixed("serialization_p95", "compression_p95", missing)...)
+	c.BaseSizeKB, c.Size, c.SerSize, c.CompSize, missing = fitProduct(obs, size, def.Size, def.SerSize, def.CompSize)
+	mf.Default = append(mf.Default, prefixed("serialization_size", "compression_size", missing)...)
+	c.BaseErrorRate, c.Err = fitLine(obs, func(o Observation) float64 { return o.ErrorRate }, func(Observation) float64 { return 1 }, def.Err)
+
+	for name, y := range map[string]func(Observation) float64{"p95_ms": p95, "size_kb": size} {
+		var sum float64
+		var n int
+		for _, o := range obs {
+			r, _ := c.Estimate(Config{Sampling: o.Sampling, Serialization: o.Serialization, Compression: o.Compression})
+			if v := y(o); v > 0 {
+				sum += math.Abs(r.Metrics()[name]-v) / v
+				n++
+			}
+		}
+		if n > 0 {
+			mf.Error[name] = stats.Round5(sum / float64(n))
+		}
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("calibration diverged: %w", err)
+	}
+	mf.Coefficients = c
+	return mf, nil
+}
+
+// prefixed는 fitProduct의 "ser:json"/"comp:none" 표기를 Coefficients의 JSON 이름으로
+func prefixed(ser, comp string, keys []string) []string {
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		kind, v, _ := strings.Cut(k, ":")
+		if kind == "ser" {
+			out[i] = ser + "." + v
+		} else {
+			out[i] = comp + "." + v
+		}
+	}
+	return out
+}
+
+// fitProduct는 y = base × line(sampling) × ser[s] × comp[c]를 맞춘다.
+// 측정되지 않은 값의 계수는 def에서 가져오고 missing으로 돌려준다
+func fitProduct(obs []Observation, y func(Observation) float64, defLine Linear, defSer, defComp map[string]float64) (float64, Linear, map[string]float64, map[string]float64, []string) {
+	ser, comp := map[string]float64{}, map[string]float64{}
+	for _, o := range obs {
+		ser[o.Serialization], comp[o.Compression] = 1, 1
+	}
+	var base float64
+	var line Linear
+	for round := 0; round < calibrateRounds; round++ {
+		base, line = fitLine(obs, y, func(o Observation) float64 { return ser[o.Serialization] * comp[o.Compression] }, defLine)
+		at := func(o Observation) float64 { return base * line.at(o.Sampling) }
+		fitMul(obs, y, ser, func(o Observation) string { return o.Serialization }, func(o Observation) float64 { return at(o) * comp[o.Compression] })
+		fitMul(obs, y, comp, func(o Observation) string { return o.Compression }, func(o Observation) float64 { return at(o) * ser[o.Serialization] })
+	}
+	// 기준 값(json, none)의 계수를 1로: 나머지를 나누고 그만큼 base에 곱한다
+	for _, t := range []struct {
+		mul  map[string]float64
+		pref []string
+	}{{ser, Serializations}, {comp, Compressions}} {
+		for _, ref := range t.pref {
+			if r, ok := t.mul[ref]; ok && r > 0 {
+				for k := range t.mul {
+					t.mul[k] /= r
+				}
+				base *= r
+				break
+			}
+		}
+	}
+	var missing []string
+	for _, v := range Serializations {
+		if _, ok := ser[v]; !ok {
+			ser[v] = defSer[v]
+			missing = append(missing, "ser:"+v)
+		}
+	}
+	for _, v := range Compressions {
+		if _, ok := comp[v]; !ok {
+			comp[v] = defComp[v]
+			missing = append(missing, "comp:"+v)
+		}
+	}
+	return base, line, ser, comp, missing
+}
+
+// fitLine은 y ≈ base × (a + b×sampling) × x(o)를 최소제곱으로 맞추고 a+b=1로 정규화한다.
+// sampling이 한 값뿐이면 기울기를 정할 수 없어 def의 모양(b/a)을 쓴다
+func fitLine(obs []Observation, y, x func(Observation) float64, def Linear) (float64, Linear) {
+	// y = A·x + B·s·x 의 정규방정식
+	var xx, xsx, ssxx, yx, ysx float64
+	for _, o := range obs {
+		xv, s := x(o), o.Sampling
+		xx += xv * xv
+		xsx += s * xv * xv
+		ssxx += s * s * xv * xv
+		yx += y(o) * xv
+		ysx += y(o) * s * xv
+	}
+	var a, b float64
+	det := xx*ssxx - xsx*xsx
+	if math.Abs(det) > 1e-12*math.Max(1, xx*ssxx) {
+		a = (yx*ssxx - ysx*xsx) / det
+		b = (xx*ysx - xsx*yx) / det
+	} else if d := xx*def.Intercept + xsx*def.Slope; d != 0 {
+		k := yx / d
+		a, b = k*def.Intercept, k*def.Slope
+	}
+	if a+b == 0 {
+		return 0, Linear{Intercept: 1}
+	}
+	return a + b, Linear{Intercept: a / (a + b), Slope: b / (a + b)}
+}
+
+// fitMul은 나머지를 고정하고 키별 계수를 최소제곱으로 맞춘다(mul[k] = Σy·x / Σx²)
+func fitMul(obs []Observation, y func(Observation) float64, mul map[string]float64, key func(Observation) string, x func(Observation) float64) {
+	num, den := map[string]float64{}, map[string]float64{}
+	for _, o := range obs {
+		xv := x(o)
+		num[key(o)] += y(o) * xv
+		den[key(o)] += xv * xv
+	}
+	for k := range mul {
+		if den[k] > 0 {
+			mul[k] = num[k] / den[k]
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// CgroupLimits are the cgroup resource limits of a container; zero values
+// mean unlimited.
+type CgroupLimits struct {
+	Version     int     `json:"version,omitempty"` // 1 or 2 (0: unknown)
+	CPUs        float64 `json:"cpus,omitempty"`    // CFS quota / period
+	CPUSet      string  `json:"cpuset,omitempty"`
+	MemoryBytes int64   `json:"memory_bytes,omitempty"`
+}
+
+// CPUThrottle is the CFS throttling of the generator's own cgroup during
+// the measure phase. Throttled periods stall every worker at once and
+// inflate latency percentiles without any fault in the target.
+type CPUThrottle struct {
+	Periods          int64   `json:"periods"`
+	ThrottledPeriods int64   `json:"throttled_periods"`
+	ThrottledMs      float64 `json:"throttled_ms"`
+	// Ratio is ThrottledPeriods / Periods.
+	Ratio float64 `json:"ratio"`
+}
+
+// cpuStat는 cgroup cpu.stat의 누적 값
+type cpuStat struct {
+	periods, throttled int64
+	throttledTime      time.Duration
+}
+
+// throttleBetween은 측정 구간의 cpu.stat 차이(쿼터가 없어 period가 돌지 않으면 nil)
+func throttleBetween(a, b cpuStat, ok bool) *CPUThrottle {
+	if !ok || b.periods <= a.periods {
+		return nil
+	}
+	t := &CPUThrottle{
+		Periods:          b.periods - a.periods,
+		ThrottledPeriods: b.throttled - a.throttled,
+		ThrottledMs:      float64(b.throttledTime-a.throttledTime) / float64(time.Millisecond),
+	}
+	t.ThrottledMs = stats.Round5(t.ThrottledMs)
+	t.Ratio = stats.Round5(float64(t.ThrottledPeriods) / float64(t.Periods))
+	return t
+}
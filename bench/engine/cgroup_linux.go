@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cgroupDir는 이 프로세스의 cgroup 디렉터리(v1은 controller별). cgroup
+// namespace 밖의 경로가 보이면(컨테이너 안) 마운트 루트가 곧 자기 cgroup이다
+func cgroupDir(controller string) (string, int) {
+	b, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", 0
+	}
+	v2 := fileExists(filepath.Join(cgroupRoot, "cgroup.controllers"))
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		// hierarchy-id:controllers:path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		base, version := "", 0
+		switch {
+		case v2 && parts[0] == "0" && parts[1] == "":
+			base, version = cgroupRoot, 2
+		case !v2 && contains(strings.Split(parts[1], ","), controller):
+			base, version = filepath.Join(cgroupRoot, controller), 1
+		default:
+			continue
+		}
+		if dir := filepath.Join(base, parts[2]); fileExists(dir) {
+			return dir, version
+		}
+		return base, version
+	}
+	return "", 0
+}
+
+// SelfCgroupLimits reports the limits of the cgroup this process runs in,
+// or nil outside cgroups (non-Linux, no cgroupfs).
+func SelfCgroupLimits() *CgroupLimits {
+	dir, v := cgroupDir("cpu")
+	if v == 0 {
+		return nil
+	}
+	l := &CgroupLimits{Version: v}
+	if v == 2 {
+		// cpu.max: "<quota|max> <period>"
+		if f := strings.Fields(readTrim(filepath.Join(dir, "cpu.max"))); len(f) == 2 && f[0] != "max" {
+			q, _ := strconv.ParseFloat(f[0], 64)
+			p, _ := strconv.ParseFloat(f[1], 64)
+			if p > 0 {
+				l.CPUs = q / p
+			}
+		}
+		l.CPUSet = readTrim(filepath.Join(dir, "cpuset.cpus.effective"))
+		if m, err := strconv.ParseInt(readTrim(filepath.Join(dir, "memory.max")), 10, 64); err == nil {
+			l.MemoryBytes = m
+		}
+		return l
+	}
+	q, _ := strconv.ParseFloat(readTrim(filepath.Join(dir, "cpu.cfs_quota_us")), 64)
+	p, _ := strconv.ParseFloat(readTrim(filepath.Join(dir, "cpu.cfs_period_us")), 64)
+	if q > 0 && p > 0 {
+		l.CPUs = q / p
+	}
+	if d, _ := cgroupDir("cpuset"); d != "" {
+		l.CPUSet = readTrim(filepath.Join(d, "cpuset.cpus"))
+	}
+	if d, _ := cgroupDir("memory"); d != "" {
+		// 제한이 없으면 페이지 단위로 내린 int64 최댓값 근처가 들어 있다
+		if m, err := strconv.ParseInt(readTrim(filepath.Join(d, "memory.limit_in_bytes")), 10, 64); err == nil && m < 1<<62 {
+			l.MemoryBytes = m
+		}
+	}
+	return l
+}
+
+// selfCPUStat은 자기 cgroup의 cpu.stat(v2 throttled_usec, v1 throttled_time ns)
+func selfCPUStat() (cpuStat, bool) {
+	var s cpuStat
+	dir, v := cgroupDir("cpu")
+	if v == 0 {
+		return s, false
+	}
+	f, err := os.Open(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return s, false
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		k, val, _ := strings.Cut(sc.Text(), " ")
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch k {
+		case "nr_periods":
+			s.periods = n
+		case "nr_throttled":
+			s.throttled = n
+		case "throttled_usec":
+			s.throttledTime = time.Duration(n) * time.Microsecond
+		case "throttled_time":
+			s.throttledTime = time.Duration(n)
+		}
+	}
+	return s, true
+}
+
+func readTrim(path string) string {
+	b, _ := os.ReadFile(path)
+	return strings.TrimSpace(string(b))
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
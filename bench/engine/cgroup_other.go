@@ -0,0 +1,8 @@
+//go:build !linux
+
+package engine
+
+// SelfCgroupLimits: cgroup은 리눅스에만 있다
+func SelfCgroupLimits() *CgroupLimits { return nil }
+
+func selfCPUStat() (cpuStat, bool) { return cpuStat{}, false }
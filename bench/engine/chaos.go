@@ -0,0 +1,349 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// Chaos fault kinds accepted by ParseChaos.
+const (
+	// ChaosLatency adds a fixed client-side delay to every export.
+	ChaosLatency = "latency"
+	// ChaosKillTarget kills the discovered target container.
+	ChaosKillTarget = "kill-target"
+	// ChaosStopTarget stops the target container for a window
+	// ("stop-target@t=60s..90s") and starts it again when the window ends.
+	ChaosStopTarget = "stop-target"
+	// ChaosHook runs an external script ("<path> inject|revert").
+	ChaosHook = "hook"
+)
+
+// Result phases relative to the chaos timeline.
+const (
+	PhaseBefore = "before"
+	PhaseDuring = "during"
+	PhaseAfter  = "after"
+)
+
+var phaseNames = [3]string{PhaseBefore, PhaseDuring, PhaseAfter}
+
+// ChaosFault is one scheduled fault, e.g. "latency:+100ms@t=60s..90s".
+// Faults without an end are instantaneous (kill-target, hook) or last until
+// the run ends (latency).
+type ChaosFault struct {
+	Kind  string
+	Arg   string
+	At    time.Duration
+	Until time.Duration // 0이면 종료 시점 없음
+	Delay time.Duration // latency 전용
+
+	// Inject/Revert는 엔진 밖의 fault(kill-target, hook)를 호출측이 바인딩한다
+	Inject func(context.Context) error
+	Revert func(context.Context) error
+}
+
+func (f ChaosFault) String() string {
+	s := f.Kind
+	if f.Arg != "" {
+		s += ":" + f.Arg
+	}
+	s += "@t=" + f.At.String()
+	if f.Until > 0 {
+		s += ".." + f.Until.String()
+	}
+	return s
+}
+
+// windowed는 주입 후 일정 기간 "during" 상태를 유지하는 fault
+func (f ChaosFault) windowed() bool { return f.Until > 0 || f.Kind == ChaosLatency }
+
+// ParseChaos parses a comma-separated fault list:
+// "latency:+100ms@t=60s..90s,kill-target@t=120s,hook:./netem.sh@t=30s..60s".
+func ParseChaos(spec string) ([]ChaosFault, error) {
+	var faults []ChaosFault
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		f, err := parseFault(item)
+		if err != nil {
+			return nil, err
+		}
+		faults = append(faults, f)
+	}
+	return faults, nil
+}
+
+func parseFault(item string) (ChaosFault, error) {
+	head, when, ok := strings.Cut(item, "@t=")
+	if !ok {
+		return ChaosFault{}, fmt.Errorf("invalid chaos fault: %s (expected <kind>[:<arg>]@t=<offset>[..<end>])", item)
+	}
+	var f ChaosFault
+	f.Kind, f.Arg, _ = strings.Cut(head, ":")
+	at, until, windowed := strings.Cut(when, "..")
+	var err error
+	if f.At, err = time.ParseDuration(at); err != nil || f.At < 0 {
+		return ChaosFault{}, fmt.Errorf("invalid chaos offset: %s", item)
+	}
+	if windowed {
+		if f.Until, err = time.ParseDuration(until); err != nil || f.Until <= f.At {
+			return ChaosFault{}, fmt.Errorf("invalid chaos window: %s", item)
+		}
+	}
+	switch f.Kind {
+	case ChaosLatency:
+		if f.Delay, err = time.ParseDuration(strings.TrimPrefix(f.Arg, "+")); err != nil || f.Delay <= 0 {
+			return ChaosFault{}, fmt.Errorf("invalid chaos latency: %s", item)
+		}
+	case ChaosKillTarget:
+	case ChaosStopTarget:
+		// 끝이 없으면 재시작할 시점이 없어 대상이 멈춘 채로 남는다
+		if f.Until == 0 {
+			return ChaosFault{}, fmt.Errorf("chaos stop-target requires a window (..<end>): %s", item)
+		}
+	case ChaosHook:
+		if f.Arg == "" {
+			return ChaosFault{}, fmt.Errorf("chaos hook requires a script path: %s", item)
+		}
+	default:
+		return ChaosFault{}, fmt.Errorf("invalid chaos fault kind: %s", f.Kind)
+	}
+	return f, nil
+}
+
+// ChaosEvent records one fault transition.
+type ChaosEvent struct {
+	Fault  string  `json:"fault"`
+	Action string  `json:"action"` // inject | revert
+	AtS    float64 `json:"at_s"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// ChaosPhase summarizes the samples of one phase.
+type ChaosPhase struct {
+	Samples   int     `json:"samples"`
+	P95ms     float64 `json:"p95_ms"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// ChaosReport is the chaos section of a result.
+type ChaosReport struct {
+	Events []ChaosEvent          `json:"events"`
+	Phases map[string]ChaosPhase `json:"phases"`
+}
+
+// chaosController는 fault 타임라인을 실행하고 phase별 샘플을 모은다.
+// soak에서는 모든 run이 같은 controller를 공유한다.
+type chaosController struct {
+	faults []ChaosFault
+	mttr   *MTTROptions
+	start  time.Time
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	delay  atomic.Int64 // 활성 latency fault 합(ns)
+	active atomic.Int32
+	phaseN atomic.Int32
+
+	mu       sync.Mutex
+	events   []ChaosEvent
+	injected []bool
+	reverted []bool
+	sketches [3][]stats.Sketch
+	ops      [3]int
+	failed   [3]int
+	faultAt  time.Duration // 첫 fault 주입 시점(-1: 아직 없음)
+	samples  []mttrSample
+}
+
+func startChaos(ctx context.Context, faults []ChaosFault, mttr *MTTROptions) *chaosController {
+	c := &chaosController{
+		faults:   faults,
+		mttr:     mttr,
+		faultAt:  -1,
+		start:    time.Now(),
+		done:     make(chan struct{}),
+		injected: make([]bool, len(faults)),
+		reverted: make([]bool, len(faults)),
+	}
+	ctx, c.cancel = context.WithCancel(ctx)
+	go c.run(ctx)
+	return c
+}
+
+type chaosStep struct {
+	at     time.Duration
+	i      int
+	revert bool
+}
+
+func (c *chaosController) run(ctx context.Context) {
+	defer close(c.done)
+	var steps []chaosStep
+	for i, f := range c.faults {
+		steps = append(steps, chaosStep{at: f.At, i: i})
+		if f.Until > 0 {
+			steps = append(steps, chaosStep{at: f.Until, i: i, revert: true})
+		}
+	}
+	sort.SliceStable(steps, func(a, b int) bool { return steps[a].at < steps[b].at })
+	for _, s := range steps {
+		t := time.NewTimer(time.Until(c.start.Add(s.at)))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		if s.revert {
+			c.revert(ctx, s.i)
+		} else {
+			c.inject(ctx, s.i)
+		}
+	}
+}
+
+func (c *chaosController) inject(ctx context.Context, i int) {
+	f := c.faults[i]
+	var err error
+	if f.Inject != nil {
+		err = f.Inject(ctx)
+	}
+	if f.Kind == ChaosLatency {
+		c.delay.Add(int64(f.Delay))
+	}
+	if f.windowed() {
+		c.active.Add(1)
+	}
+	c.mu.Lock()
+	c.injected[i] = true
+	if c.faultAt < 0 {
+		c.faultAt = time.Since(c.start)
+	}
+	c.mu.Unlock()
+	c.record(f, "inject", err)
+	c.updatePhase()
+}
+
+func (c *chaosController) revert(ctx context.Context, i int) {
+	f := c.faults[i]
+	var err error
+	if f.Revert != nil {
+		err = f.Revert(ctx)
+	}
+	if f.Kind == ChaosLatency {
+		c.delay.Add(-int64(f.Delay))
+	}
+	if f.windowed() {
+		c.active.Add(-1)
+	}
+	c.mu.Lock()
+	c.reverted[i] = true
+	c.mu.Unlock()
+	c.record(f, "revert", err)
+	c.updatePhase()
+}
+
+func (c *chaosController) record(f ChaosFault, action string, err error) {
+	ev := ChaosEvent{Fault: f.String(), Action: action, AtS: stats.Round2(time.Since(c.start).Seconds())}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	c.mu.Lock()
+	c.events = append(c.events, ev)
+	c.mu.Unlock()
+}
+
+func (c *chaosController) updatePhase() {
+	if c.active.Load() > 0 {
+		c.phaseN.Store(1)
+	} else {
+		c.phaseN.Store(2)
+	}
+}
+
+// phase는 현재 phase 인덱스(before=0, during=1, after=2)
+func (c *chaosController) phase() int { return int(c.phaseN.Load()) }
+
+// extraDelay는 export마다 더할 client측 지연
+func (c *chaosController) extraDelay() time.Duration { return time.Duration(c.delay.Load()) }
+
+// collect는 run 종료 후 워커별 phase 스케치/카운트를 넘겨받는다
+func (c *chaosController) collect(sk [][3]stats.Sketch, ops, failed [][3]int, samples [][]mttrSample) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range samples {
+		c.samples = append(c.samples, s...)
+	}
+	for w := range sk {
+		for p := 0; p < 3; p++ {
+			c.sketches[p] = append(c.sketches[p], sk[w][p])
+			c.ops[p] += ops[w][p]
+			c.failed[p] += failed[w][p]
+		}
+	}
+}
+
+// recovered는 타임라인이 끝났고 MTTR 복귀가 관측되었는지(soak 조기 종료용)
+func (c *chaosController) recovered() bool {
+	select {
+	case <-c.done:
+	default:
+		return false
+	}
+	rep := c.mttrReport()
+	return rep != nil && rep.Recovered
+}
+
+func (c *chaosController) mttrReport() *MTTRReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mttr == nil || c.faultAt < 0 {
+		return nil
+	}
+	return evalMTTR(*c.mttr, c.faultAt, append([]mttrSample(nil), c.samples...))
+}
+
+// stop은 타임라인을 멈추고, 아직 활성인 windowed fault를 되돌린다
+func (c *chaosController) stop() {
+	c.cancel()
+	<-c.done
+	for i, f := range c.faults {
+		c.mu.Lock()
+		active := c.injected[i] && !c.reverted[i] && f.Until > 0
+		c.mu.Unlock()
+		if active {
+			c.revert(context.Background(), i)
+		}
+	}
+}
+
+func (c *chaosController) report() (*ChaosReport, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rep := &ChaosReport{Events: append([]ChaosEvent(nil), c.events...), Phases: map[string]ChaosPhase{}}
+	for p, name := range phaseNames {
+		if c.ops[p] == 0 {
+			continue
+		}
+		sk, err := stats.MergeSketches(c.sketches[p]...)
+		if err != nil {
+			return nil, err
+		}
+		rep.Phases[name] = ChaosPhase{
+			Samples:   c.ops[p],
+			P95ms:     stats.Round5(sk.Quantile(0.95)),
+			ErrorRate: stats.Round5(float64(c.failed[p]) / float64(c.ops[p])),
+		}
+	}
+	return rep, nil
+}
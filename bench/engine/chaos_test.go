@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseChaos(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []string // ChaosFault.String()
+		wantErr bool
+	}{
+		{spec: "latency:+100ms@t=60s..90s,kill-target@t=120s", want: []string{"latency:+100ms@t=1m0s..1m30s", "kill-target@t=2m0s"}},
+		{spec: "stop-target@t=10s..20s", want: []string{"stop-target@t=10s..20s"}},
+		{spec: "hook:./fault.sh@t=30s", want: []string{"hook:./fault.sh@t=30s"}},
+		// 끝이 없는 stop-target은 대상을 멈춘 채로 남긴다
+		{spec: "stop-target@t=10s", wantErr: true},
+		{spec: "stop-target@t=20s..10s", wantErr: true},
+		{spec: "latency:0s@t=1s", wantErr: true},
+		{spec: "hook@t=1s", wantErr: true},
+		{spec: "reboot@t=1s", wantErr: true},
+		{spec: "kill-target", wantErr: true},
+	}
+	for _, tt := range tests {
+		faults, err := ParseChaos(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseChaos(%q) = %v, want error", tt.spec, faults)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseChaos(%q): %v", tt.spec, err)
+			continue
+		}
+		var got []string
+		for _, f := range faults {
+			got = append(got, f.String())
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseChaos(%q) = %v, want %v", tt.spec, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ParseChaos(%q)[%d] = %s, want %s", tt.spec, i, got[i], tt.want[i])
+			}
+		}
+	}
+	f, err := ParseChaos("latency:+50ms@t=1s")
+	if err != nil || f[0].Delay != 50*time.Millisecond {
+		t.Errorf("latency delay = %v (err %v), want 50ms", f, err)
+	}
+}
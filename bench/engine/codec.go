@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 직렬화+압축 파이프라인. exporter처럼 버퍼/압축기를 재사용한다.
+type encoder struct {
+	ser, comp string
+	raw       bytes.Buffer
+	out       bytes.Buffer
+	gz        *gzip.Writer
+	scratch   []byte
+}
+
+func newEncoder(ser, comp string) (*encoder, error) {
+	e := &encoder{ser: strings.ToLower(ser), comp: strings.ToLower(comp)}
+	switch e.comp {
+	case "none", "zstd":
+	case "gzip":
+		e.gz = gzip.NewWriter(&e.out)
+	default:
+		return nil, fmt.Errorf("invalid compression: %s", comp)
+	}
+	return e, nil
+}
+
+// encode는 spans 배치를 직렬화·압축하고 결과 바이트 수를 반환
+func (e *encoder) encode(spans []span) (int, error) {
+	e.raw.Reset()
+	switch e.ser {
+	case "json":
+		if err := json.NewEncoder(&e.raw).Encode(toJSONSpans(spans)); err != nil {
+			return 0, err
+		}
+	case "msgpack":
+		e.scratch = appendMsgpackSpans(e.scratch[:0], spans)
+		e.raw.Write(e.scratch)
+	case "protobuf":
+		e.scratch = appendProtoSpans(e.scratch[:0], spans)
+		e.raw.Write(e.scratch)
+	default:
+		return 0, fmt.Errorf("invalid serialization: %s", e.ser)
+	}
+	return e.compress()
+}
+
+// encodeBytes는 이미 직렬화된 본문(템플릿 렌더링 결과 등)을 압축만 한다
+func (e *encoder) encodeBytes(b []byte) (int, error) {
+	e.raw.Reset()
+	e.raw.Write(b)
+	return e.compress()
+}
+
+func (e *encoder) compress() (int, error) {
+	switch e.comp {
+	case "none":
+		return e.raw.Len(), nil
+	case "gzip":
+		e.out.Reset()
+		e.gz.Reset(&e.out)
+		if _, err := e.gz.Write(e.raw.Bytes()); err != nil {
+			return 0, err
+		}
+		if err := e.gz.Close(); err != nil {
+			return 0, err
+		}
+		return e.out.Len(), nil
+	case "zstd":
+		e.out.Reset()
+		e.out.Write(appendZstdRawFrame(e.out.AvailableBuffer(), e.raw.Bytes()))
+		return e.out.Len(), nil
+	}
+	return 0, fmt.Errorf("invalid compression: %s", e.comp)
+}
+
+// payload는 마지막 encode 결과 바이트(다음 encode 전까지 유효)
+func (e *encoder) payload() []byte {
+	if e.comp == "none" {
+		return e.raw.Bytes()
+	}
+	return e.out.Bytes()
+}
+
+type jsonSpan struct {
+	TraceID  string            `json:"trace_id"`
+	SpanID   string            `json:"span_id"`
+	ParentID string            `json:"parent_span_id,omitempty"`
+	Name     string            `json:"name"`
+	Start    int64             `json:"start_time_unix_nano"`
+	End      int64             `json:"end_time_unix_nano"`
+	Attrs    map[string]string `json:"attributes"`
+}
+
+func toJSONSpans(spans []span) []jsonSpan {
+	out := make([]jsonSpan, len(spans))
+	for i, s := range spans {
+		js := jsonSpan{
+			TraceID: hex.EncodeToString(s.TraceID[:]),
+			SpanID:  hex.EncodeToString(s.SpanID[:]),
+			Name:    s.Name,
+			Start:   s.Start,
+			End:     s.End,
+			Attrs:   make(map[string]string, len(s.Attrs)),
+		}
+		if s.ParentID != ([8]byte{}) {
+			js.ParentID = hex.EncodeToString(s.ParentID[:])
+		}
+		for _, a := range s.Attrs {
+			js.Attrs[a.Key] = a.Value
+		}
+		out[i] = js
+	}
+	return out
+}
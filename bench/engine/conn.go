@@ -0,0 +1,178 @@
+package engine
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Connection modes for the HTTP workload, selected via -connections.
+const (
+	ConnReuse      = "reuse"       // keep-alive, one idle conn per worker (ingress pattern)
+	ConnPerRequest = "per-request" // new TCP/TLS handshake for every request (cold calls)
+	ConnPool       = "pool"        // shared pool capped at N conns ("pool:N")
+)
+
+// ConnMode is a parsed -connections value.
+type ConnMode struct {
+	Kind string
+	Size int // pool size for ConnPool
+}
+
+// ParseConnMode parses "reuse", "per-request" or "pool:N".
+func ParseConnMode(s string) (ConnMode, error) {
+	switch {
+	case s == "" || s == ConnReuse:
+		return ConnMode{Kind: ConnReuse}, nil
+	case s == ConnPerRequest:
+		return ConnMode{Kind: ConnPerRequest}, nil
+	case strings.HasPrefix(s, ConnPool+":"):
+		n, err := strconv.Atoi(strings.TrimPrefix(s, ConnPool+":"))
+		if err != nil || n < 1 {
+			return ConnMode{}, fmt.Errorf("invalid connections: %s (pool size must be >= 1)", s)
+		}
+		return ConnMode{Kind: ConnPool, Size: n}, nil
+	default:
+		return ConnMode{}, fmt.Errorf("invalid connections: %s (expected reuse|per-request|pool:N)", s)
+	}
+}
+
+func (m ConnMode) String() string {
+	if m.Kind == ConnPool {
+		return fmt.Sprintf("%s:%d", ConnPool, m.Size)
+	}
+	if m.Kind == "" {
+		return ConnReuse
+	}
+	return m.Kind
+}
+
+// ConnStats reports how many requests paid for a new connection.
+type ConnStats struct {
+	Mode           string `json:"mode"`
+	NewConnections int    `json:"new_connections"`
+	Reused         int    `json:"reused"`
+}
+
+// HTTP protocols selectable per run (-protocols sweeps over several).
+const (
+	ProtoAuto = ""
+	ProtoH1   = "h1"
+	ProtoH2   = "h2"
+	ProtoH3   = "h3"
+)
+
+// Protocols lists the explicit HTTP protocols this build supports
+// (ProtoAuto aside). ProtoH3 is recognized only to reject it clearly.
+var Protocols = []string{ProtoH1, ProtoH2}
+
+func validProtocol(p, endpoint string) error {
+	switch p {
+	case ProtoAuto, ProtoH1:
+		return nil
+	case ProtoH2:
+		// 표준 라이브러리 Transport는 TLS(ALPN)로만 h2를 협상한다
+		if !strings.HasPrefix(endpoint, "https://") {
+			return fmt.Errorf("protocol h2 requires an https endpoint (h2c is not supported)")
+		}
+		return nil
+	case ProtoH3:
+		return fmt.Errorf("protocol h3 requires a QUIC transport, which this build does not include")
+	default:
+		return fmt.Errorf("invalid protocol: %s (expected h1|h2)", p)
+	}
+}
+
+// newTransport는 실행 단위로 하나 만들어 모든 워커가 공유한다
+func newTransport(m ConnMode, o TLSOptions, proto string, workers int) (*http.Transport, error) {
+	tr := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         (&net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		ForceAttemptHTTP2:   true,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        workers,
+		MaxIdleConnsPerHost: workers,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	switch m.Kind {
+	case ConnPerRequest:
+		tr.DisableKeepAlives = true
+	case ConnPool:
+		tr.MaxConnsPerHost = m.Size
+		tr.MaxIdleConns = m.Size
+		tr.MaxIdleConnsPerHost = m.Size
+	}
+	if o.enabled() {
+		c, err := o.config()
+		if err != nil {
+			return nil, err
+		}
+		tr.TLSClientConfig = c
+	}
+	switch proto {
+	case ProtoH1:
+		// 비어 있는(nil 아닌) TLSNextProto는 h2 업그레이드를 끈다
+		tr.ForceAttemptHTTP2 = false
+		tr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		if tr.TLSClientConfig != nil {
+			tr.TLSClientConfig.NextProtos = []string{"http/1.1"}
+		}
+	case ProtoH2:
+		if tr.TLSClientConfig == nil {
+			tr.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		tr.TLSClientConfig.NextProtos = []string{"h2"}
+	}
+	return tr, nil
+}
+
+// TLSOptions configures TLS/mTLS for the HTTP workload.
+type TLSOptions struct {
+	CertFile, KeyFile string // client certificate (mTLS)
+	CAFile            string // CA bundle to verify the server
+	ServerName        string // SNI / verification name override
+	Insecure          bool   // skip server verification (lab only)
+}
+
+func (o TLSOptions) enabled() bool {
+	return o != (TLSOptions{})
+}
+
+func (o TLSOptions) config() (*tls.Config, error) {
+	c := &tls.Config{ServerName: o.ServerName, InsecureSkipVerify: o.Insecure, MinVersion: tls.VersionTLS12}
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return nil, fmt.Errorf("tls-cert and tls-key must be set together")
+	}
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls client cert: %w", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls ca: no certificates in %s", o.CAFile)
+		}
+		c.RootCAs = pool
+	}
+	return c, nil
+}
+
+// TLSStats reports the TLS handshake phase separately from request latency.
+type TLSStats struct {
+	Handshakes     int     `json:"handshakes"`
+	HandshakeP95ms float64 `json:"handshake_p95_ms"`
+	Failures       int     `json:"handshake_failures"`
+}
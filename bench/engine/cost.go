@@ -0,0 +1,17 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/duri/trace_bench/cost"
+)
+
+// Price prices the measured per-span volume of r at spansPerDay with m.
+// Only real-mode results carry the volume it needs.
+func (r Result) Price(m cost.Model, spansPerDay float64) (*cost.Estimate, error) {
+	if r.Volume == nil || r.Volume.Spans == 0 {
+		return nil, fmt.Errorf("cost model requires a real-mode result (no volume measured)")
+	}
+	e := m.Price(spansPerDay, r.Volume.BytesPerSpan(), r.Volume.CPUSecondsPerSpan())
+	return &e, nil
+}
@@ -0,0 +1,8 @@
+//go:build !unix && !windows
+
+package engine
+
+import "time"
+
+// processCPU: rusage/GetProcessTimes가 없는 플랫폼에서는 0(volume.cpu_seconds가 0으로 기록됨)
+func processCPU() time.Duration { return 0 }
@@ -0,0 +1,19 @@
+//go:build windows
+
+package engine
+
+import (
+	"syscall"
+	"time"
+)
+
+// processCPU는 GetProcessTimes의 user+kernel 시간(100ns 단위 FILETIME)
+func processCPU() time.Duration {
+	var creation, exit, kernel, user syscall.Filetime
+	h, err := syscall.GetCurrentProcess()
+	if err != nil || syscall.GetProcessTimes(h, &creation, &exit, &kernel, &user) != nil {
+		return 0
+	}
+	ticks := func(ft syscall.Filetime) int64 { return int64(ft.HighDateTime)<<32 | int64(ft.LowDateTime) }
+	return time.Duration(ticks(kernel)+ticks(user)) * 100
+}
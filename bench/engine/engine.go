@@ -0,0 +1,458 @@
+// Package engine runs trace_bench measurements and produces results in the
+// trace_bench JSON ABI ({"p95_ms","error_rate","size_kb"}), so other DuRi Go
+// services can embed the same benchmark and self-report identically.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/cost"
+	"github.com/duri/trace_bench/slo"
+	"github.com/duri/trace_bench/stats"
+	"github.com/duri/trace_bench/validate"
+)
+
+// Serializations and Compressions list the supported Config values.
+var (
+	Serializations = []string{"json", "msgpack", "protobuf"}
+	Compressions   = []string{"none", "gzip", "zstd"}
+)
+
+// Config selects the trace pipeline configuration to measure.
+type Config struct {
+	Sampling      float64 // sampling rate in [0,1]
+	Serialization string  // json|msgpack|protobuf
+	Compression   string  // none|gzip|zstd
+
+	// Mode selects the measurement: "model" (default) is the deterministic
+	// estimator, "real" encodes synthetic spans through the pipeline, and
+	// "hybrid" reports the estimate after checking it with a short real run.
+	Mode string
+	// Model, if set, replaces DefaultModel in model and hybrid mode (e.g.
+	// calibrated coefficients).
+	Model     Model
+	Spans     int // real mode: spans generated per run (default DefaultSpans)
+	BatchSize int // real mode: spans per export batch (default DefaultBatchSize)
+	Workers   int // real mode: concurrent exporters (default 1)
+	// SpotSpans and DriftThreshold configure hybrid mode: the spot-check
+	// generates SpotSpans spans (default DefaultSpotSpans), and the model
+	// has drifted when a metric differs from it by more than DriftThreshold
+	// relative to the spot-check (default DefaultDriftThreshold).
+	SpotSpans      int
+	DriftThreshold float64
+	// BatchTimeout, if > 0, runs real mode through an emulated OTel batch
+	// processor: spans arrive at SpanRate per second (0: unpaced), batches
+	// flush at BatchSize spans or BatchTimeout, and a full queue of
+	// QueueSize spans (default DefaultQueueSize) drops them.
+	BatchTimeout time.Duration
+	SpanRate     float64
+	QueueSize    int
+	// QueueMem, if > 0, bounds the batch processor queue by the memory of
+	// the spans queued or being exported instead of by QueueSize, and
+	// Backpressure selects what a full queue does: BackpressureDrop
+	// (default) or BackpressureBlock.
+	QueueMem     int64
+	Backpressure string
+	// QuantileSketch selects how latency samples are kept in real mode:
+	// stats.SketchExact (default), stats.SketchHDR or stats.SketchTDigest.
+	QuantileSketch string
+	// Workload selects what real mode exercises per batch: WorkloadPipeline
+	// (default), WorkloadHTTP, which POSTs each batch to Endpoint, or
+	// WorkloadExec, which runs Command (argv) with the batch on stdin,
+	// inside Sandbox if set.
+	Workload string
+	Endpoint string
+	Command  []string
+	Sandbox  *Sandbox
+	Timeout  time.Duration // per HTTP export or command (default DefaultTimeout)
+	// Retries re-sends batches failing with a transient class (timeout,
+	// connection errors, 5xx) up to this many times, waiting RetryBackoff.
+	Retries      int
+	RetryBackoff Backoff
+	// Connections controls connection reuse (keep-alive, per-request, pool:N),
+	// so handshake cost can be included or excluded explicitly.
+	Connections ConnMode
+	TLS         TLSOptions
+	// Protocol pins the HTTP version: ProtoH1, ProtoH2 or ProtoAuto.
+	Protocol string
+	// Headers are extra request headers (e.g. Authorization) sent with
+	// every export. Values are credentials and never appear in results.
+	Headers map[string]string
+	// RunID identifies the run in the result and, for the http workload,
+	// in every request (RunIDHeader and W3C baggage), so target-side
+	// traces can be filtered to bench traffic.
+	RunID string
+	// BodyTemplate, if set, replaces the serialized spans with a Go
+	// text/template rendered per export (see BodyVars).
+	BodyTemplate string
+	// Script, if set, is a Starlark request script (see ScriptRequest)
+	// that computes each request body and headers and may assert on the
+	// responses; ScriptFile names it in error positions.
+	Script     string
+	ScriptFile string
+	// Feed is a CSV/JSONL data file whose rows are exposed to
+	// BodyTemplate as .Row (Script as ctx.row); FeedMode selects how rows
+	// are drawn.
+	Feed     string
+	FeedMode string
+	// Chaos faults are injected on a timeline relative to the run (or soak)
+	// start; results are then segmented before/during/after.
+	Chaos []ChaosFault
+	chaos *chaosController // Run/Soak가 생성, run 간 공유
+	// MTTR measures recovery time after the first chaos fault.
+	MTTR *MTTROptions
+	// HeatmapInterval, if > 0, records a time × latency heatmap (real mode).
+	HeatmapInterval time.Duration
+	// TimelineInterval, if > 0, aggregates per-interval series (throughput,
+	// errors, latency quantiles) into Result.Timeline, e.g. for remote-write.
+	TimelineInterval time.Duration
+	// Live, if set, receives every export of a real-mode run for live
+	// display; it may be shared across runs (soak, protocol sweeps).
+	Live *Live
+	// Phases, if set, observes the run phases (PhaseSetup, PhaseWarmup,
+	// PhaseMeasure), e.g. for self-instrumentation.
+	Phases PhaseFunc
+
+	// Target, if set, is recorded in the result as measurement evidence.
+	Target *Target
+	// TargetPID, if > 0, is a local process (the target) whose CPU and
+	// resident memory are sampled during the measure phase into
+	// Result.TargetProc.
+	TargetPID int
+}
+
+// Target describes the service that was measured.
+type Target struct {
+	Project   string `json:"compose_project"`
+	Service   string `json:"service"`
+	Container string `json:"container"`
+	Address   string `json:"address"`
+	Health    string `json:"health"`
+	// Limits are the container's cgroup limits from docker inspect.
+	Limits *CgroupLimits `json:"limits,omitempty"`
+}
+
+// Result is the trace_bench result ABI. Field names and JSON tags are stable.
+type Result struct {
+	P95ms     float64 `json:"p95_ms"`
+	ErrorRate float64 `json:"error_rate"`
+	SizeKB    float64 `json:"size_kb"`
+
+	Protocol string `json:"protocol,omitempty"`
+	RunID    string `json:"run_id,omitempty"`
+
+	P99ms          float64         `json:"p99_ms,omitempty"`
+	QuantileSketch string          `json:"quantile_sketch,omitempty"`
+	Mem            *MemStats       `json:"mem,omitempty"`
+	Batching       *BatchStats     `json:"batching,omitempty"`
+	Volume         *VolumeStats    `json:"volume,omitempty"`
+	Cost           *cost.Estimate  `json:"cost,omitempty"`
+	Soak           *SoakReport     `json:"soak,omitempty"`
+	Heatmap        *stats.Heatmap  `json:"heatmap,omitempty"`
+	Timeline       *stats.Timeline `json:"-"`
+	// Errors breaks failed batches down by class (timeout, http_5xx, ...);
+	// StatusCodes counts HTTP responses by status code.
+	Errors      map[string]int `json:"errors,omitempty"`
+	StatusCodes map[string]int `json:"status_codes,omitempty"`
+
+	Retry     *RetryStats  `json:"retry,omitempty"`
+	Conn      *ConnStats   `json:"connections,omitempty"`
+	TLS       *TLSStats    `json:"tls,omitempty"`
+	Feed      *FeedStats   `json:"feed,omitempty"`
+	Script    *ScriptStats `json:"script,omitempty"`
+	Chaos     *ChaosReport `json:"chaos,omitempty"`
+	MTTR      *MTTRReport  `json:"mttr,omitempty"`
+	SLO       []slo.Check  `json:"slo,omitempty"`
+	Artifacts []string     `json:"artifacts,omitempty"`
+
+	Target *Target `json:"target,omitempty"`
+	// Health holds the -health-url checks before and after the run;
+	// TargetDegradedPost is set when a postflight check failed.
+	Health             *HealthReport `json:"health,omitempty"`
+	TargetDegradedPost bool          `json:"target_degraded_post,omitempty"`
+	// Hooks are the -pre-hook/-post-hook commands run around the measurement.
+	Hooks []HookRun `json:"hooks,omitempty"`
+	// TargetBuild is the version/SHA the target reported (-target-buildinfo).
+	TargetBuild *BuildInfo `json:"target_build,omitempty"`
+	// Host is the load-generating machine (real mode).
+	Host *HostInfo `json:"host,omitempty"`
+	// Netem is the -netem impairment the run was measured under.
+	Netem *Netem `json:"netem,omitempty"`
+	// Sandbox isolated the exec workload's command.
+	Sandbox *Sandbox `json:"sandbox,omitempty"`
+	// Throttle is the generator's cgroup CPU throttling while measuring.
+	Throttle *CPUThrottle `json:"cpu_throttle,omitempty"`
+	// TargetProc is the target process's resource use (Config.TargetPID).
+	TargetProc *ProcessStats `json:"target_process,omitempty"`
+	// Labels are the user -label key=value pairs (env, branch, ...).
+	Labels map[string]string `json:"labels,omitempty"`
+	// CustomMetrics are the -collector measurements as <collector>.<metric>.
+	CustomMetrics map[string]float64 `json:"custom_metrics,omitempty"`
+	// Hybrid compares the model estimate with its spot-check (hybrid mode);
+	// ModelDrift is set when they diverge beyond its threshold.
+	Hybrid     *HybridReport `json:"hybrid,omitempty"`
+	ModelDrift bool          `json:"model_drift,omitempty"`
+}
+
+// PhaseFunc is called when a run phase starts; the returned func ends it
+// with the phase's error, if any.
+type PhaseFunc func(ctx context.Context, phase string) func(err error)
+
+// Run phases reported to Config.Phases.
+const (
+	PhaseSetup   = "setup"
+	PhaseWarmup  = "warmup"
+	PhaseMeasure = "measure"
+)
+
+func (c Config) phase(ctx context.Context, name string) func(error) {
+	if c.Phases == nil {
+		return func(error) {}
+	}
+	return c.Phases(ctx, name)
+}
+
+// Measurement modes.
+const (
+	ModeModel  = "model"
+	ModeReal   = "real"
+	ModeHybrid = "hybrid"
+)
+
+// Measures reports whether a run of c generates real samples (real mode,
+// or the spot-check of hybrid mode).
+func (c Config) Measures() bool { return c.Mode == ModeReal || c.Mode == ModeHybrid }
+
+// Metrics returns the scalar result metrics by ABI name: those of
+// slo.BenchMetrics plus, for a batch processor run, drop_rate,
+// batch_delay_p95_ms and spans_per_sec. Optional metrics are present
+// only when measured.
+func (r Result) Metrics() map[string]float64 {
+	m := map[string]float64{"p95_ms": r.P95ms, "error_rate": r.ErrorRate, "size_kb": r.SizeKB}
+	if r.P99ms != 0 {
+		m["p99_ms"] = r.P99ms
+	}
+	if r.MTTR != nil && r.MTTR.MTTRSeconds != nil {
+		m["mttr_seconds"] = *r.MTTR.MTTRSeconds
+	}
+	if r.Batching != nil {
+		m["drop_rate"] = r.Batching.DropRate
+		m["batch_delay_p95_ms"] = r.Batching.DelayP95ms
+		m["spans_per_sec"] = r.Batching.SpansPerSec
+	}
+	return m
+}
+
+// Engine executes benchmark runs.
+type Engine struct{}
+
+// New returns an Engine.
+func New() *Engine { return &Engine{} }
+
+// Run validates cfg and measures it.
+func (e *Engine) Run(ctx context.Context, cfg Config) (Result, error) {
+	if err := cfg.Validate(); err != nil {
+		return Result{}, err
+	}
+	var ctl *chaosController
+	if len(cfg.Chaos) > 0 && cfg.chaos == nil {
+		ctl = startChaos(ctx, cfg.Chaos, cfg.MTTR)
+		cfg.chaos = ctl
+	}
+	var r Result
+	var err error
+	switch cfg.Mode {
+	case "", ModeModel:
+		end := cfg.phase(ctx, PhaseMeasure)
+		r, err = modelBasedEstimation(ctx, cfg)
+		end(err)
+	case ModeReal:
+		r, err = realMeasurement(ctx, cfg)
+		r.QuantileSketch = cfg.QuantileSketch
+		if r.QuantileSketch == "" {
+			r.QuantileSketch = stats.SketchExact
+		}
+	case ModeHybrid:
+		r, err = hybridMeasurement(ctx, cfg)
+	}
+	if ctl != nil {
+		ctl.stop()
+		if err == nil {
+			r.Chaos, err = ctl.report()
+			r.MTTR = ctl.mttrReport()
+		}
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	r.Target = cfg.Target
+	r.Sandbox = cfg.Sandbox
+	r.RunID = cfg.RunID
+	return r, nil
+}
+
+// Validate checks the configuration against the supported values.
+func (c Config) Validate() error {
+	if err := validate.Fraction("sampling", c.Sampling); err != nil {
+		return err
+	}
+	if err := validate.OneOf("serialization", c.Serialization, Serializations); err != nil {
+		return err
+	}
+	if err := validate.OneOf("compression", c.Compression, Compressions); err != nil {
+		return err
+	}
+	// 표준 라이브러리에 zstd 인코더가 없어 실측은 압축 없는 raw 프레임이 된다
+	if c.Measures() && strings.EqualFold(c.Compression, "zstd") {
+		return fmt.Errorf("compression zstd is not measured in mode %s (no zstd encoder; frames would be uncompressed); use mode %s", c.Mode, ModeModel)
+	}
+	if err := validate.Line("run id", c.RunID); err != nil {
+		return err
+	}
+	for k, v := range c.Headers {
+		if err := validate.Line("header "+k, v); err != nil {
+			return err
+		}
+	}
+	switch c.Mode {
+	case "", ModeModel, ModeReal:
+	case ModeHybrid:
+		if c.SpotSpans < 0 {
+			return fmt.Errorf("invalid spot-check spans: %d", c.SpotSpans)
+		}
+		if err := validate.NonNegative("drift threshold", c.DriftThreshold); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid mode: %s", c.Mode)
+	}
+	switch c.Workload {
+	case "", WorkloadPipeline:
+	case WorkloadHTTP:
+		if !c.Measures() {
+			return fmt.Errorf("workload %s requires mode %s or %s", c.Workload, ModeReal, ModeHybrid)
+		}
+		if u, err := url.Parse(c.Endpoint); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid endpoint: %q", c.Endpoint)
+		}
+		if err := validProtocol(c.Protocol, c.Endpoint); err != nil {
+			return err
+		}
+	case WorkloadExec:
+		if !c.Measures() {
+			return fmt.Errorf("workload %s requires mode %s or %s", c.Workload, ModeReal, ModeHybrid)
+		}
+		if len(c.Command) == 0 || c.Command[0] == "" {
+			return fmt.Errorf("workload %s requires a command", c.Workload)
+		}
+	default:
+		return fmt.Errorf("invalid workload: %s", c.Workload)
+	}
+	if len(c.Command) > 0 && c.Workload != WorkloadExec {
+		return fmt.Errorf("command requires workload %s", WorkloadExec)
+	}
+	if c.Sandbox != nil {
+		if c.Workload != WorkloadExec {
+			return fmt.Errorf("sandbox requires workload %s", WorkloadExec)
+		}
+		if !sandboxSupported {
+			return fmt.Errorf("sandbox requires Linux")
+		}
+		if c.Sandbox.TmpfsBytes <= 0 {
+			return fmt.Errorf("invalid sandbox tmpfs size: %d", c.Sandbox.TmpfsBytes)
+		}
+	}
+	if len(c.Headers) > 0 && c.Workload != WorkloadHTTP {
+		return fmt.Errorf("headers require workload %s", WorkloadHTTP)
+	}
+	if c.BodyTemplate != "" && c.Workload != WorkloadHTTP {
+		return fmt.Errorf("body template requires workload %s", WorkloadHTTP)
+	}
+	if c.Script != "" && c.Workload != WorkloadHTTP {
+		return fmt.Errorf("script requires workload %s", WorkloadHTTP)
+	}
+	if c.Script != "" && c.BodyTemplate != "" {
+		return fmt.Errorf("script and body template are mutually exclusive")
+	}
+	if c.Feed != "" && c.BodyTemplate == "" && c.Script == "" {
+		return fmt.Errorf("feed requires a body template or a script")
+	}
+	switch c.FeedMode {
+	case "", FeedSequential, FeedRandom, FeedUnique:
+	default:
+		return fmt.Errorf("invalid feed mode: %s", c.FeedMode)
+	}
+	if len(c.Chaos) > 0 && c.Mode != ModeReal {
+		return fmt.Errorf("chaos requires mode %s", ModeReal)
+	}
+	if c.Live != nil && c.Mode != ModeReal {
+		return fmt.Errorf("live view requires mode %s", ModeReal)
+	}
+	if c.MTTR != nil {
+		if len(c.Chaos) == 0 {
+			return fmt.Errorf("mttr requires a chaos fault to degrade the target")
+		}
+		if c.MTTR.Window <= 0 {
+			return fmt.Errorf("invalid mttr window: %s", c.MTTR.Window)
+		}
+	}
+	for _, f := range c.Chaos {
+		if f.Kind != ChaosLatency && f.Inject == nil {
+			return fmt.Errorf("chaos fault %s is not bound", f)
+		}
+	}
+	if c.Protocol != ProtoAuto && c.Workload != WorkloadHTTP {
+		return fmt.Errorf("protocol %s requires workload %s", c.Protocol, WorkloadHTTP)
+	}
+	switch c.QuantileSketch {
+	case "", stats.SketchExact, stats.SketchHDR, stats.SketchTDigest:
+	default:
+		return fmt.Errorf("invalid quantile-sketch: %s", c.QuantileSketch)
+	}
+	if c.Retries < 0 {
+		return fmt.Errorf("invalid retries: %d", c.Retries)
+	}
+	if c.Retries > 0 && c.Workload != WorkloadHTTP {
+		return fmt.Errorf("retries require workload %s", WorkloadHTTP)
+	}
+	if (c.BatchTimeout != 0 || c.SpanRate != 0 || c.QueueSize != 0) && c.Mode != ModeReal {
+		return fmt.Errorf("batch timeout requires mode %s", ModeReal)
+	}
+	if err := validate.Finite("span rate", c.SpanRate); err != nil {
+		return err
+	}
+	if c.BatchTimeout < 0 || c.SpanRate < 0 || c.QueueSize < 0 {
+		return fmt.Errorf("invalid batch timeout/span rate/queue size: %s/%v/%d", c.BatchTimeout, c.SpanRate, c.QueueSize)
+	}
+	if (c.SpanRate != 0 || c.QueueSize != 0) && c.BatchTimeout == 0 {
+		return fmt.Errorf("span rate and queue size require a batch timeout")
+	}
+	if c.QueueMem < 0 {
+		return fmt.Errorf("invalid queue memory: %d", c.QueueMem)
+	}
+	if (c.QueueMem != 0 || c.Backpressure != "") && c.BatchTimeout == 0 {
+		return fmt.Errorf("queue memory and backpressure require a batch timeout")
+	}
+	if c.QueueMem != 0 && c.QueueSize != 0 {
+		return fmt.Errorf("queue memory and queue size are mutually exclusive")
+	}
+	switch c.Backpressure {
+	case "", BackpressureDrop, BackpressureBlock:
+	default:
+		return fmt.Errorf("invalid backpressure: %s", c.Backpressure)
+	}
+	if c.TargetPID < 0 {
+		return fmt.Errorf("invalid target pid: %d", c.TargetPID)
+	}
+	if c.TargetPID > 0 && !c.Measures() {
+		return fmt.Errorf("target pid requires mode %s or %s", ModeReal, ModeHybrid)
+	}
+	if c.Spans < 0 || c.BatchSize < 0 || c.Workers < 0 {
+		return fmt.Errorf("invalid spans/batch/workers: %d/%d/%d", c.Spans, c.BatchSize, c.Workers)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"time"
+)
+
+// execWaitDelay는 취소 후 자식이 물려받은 파이프를 닫을 때까지 기다리는 시간
+const execWaitDelay = time.Second
+
+// execExporter는 배치마다 Command를 실행하고 인코딩된 배치를 stdin으로 준다
+type execExporter struct {
+	e       *encoder
+	argv    []string
+	timeout time.Duration
+	sandbox *Sandbox
+}
+
+func newExecExporter(enc *encoder, cfg Config) (*execExporter, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	x := &execExporter{e: enc, argv: cfg.Command, timeout: timeout, sandbox: cfg.Sandbox}
+	if x.sandbox != nil {
+		// 샌드박스를 만들 수 없으면 배치마다 실패하는 대신 시작 전에 알린다
+		if err := x.sandbox.check(); err != nil {
+			return nil, err
+		}
+	}
+	return x, nil
+}
+
+func (x *execExporter) enc() *encoder { return x.e }
+
+func (x *execExporter) export(ctx context.Context, batch []span) exportOutcome {
+	n, err := x.e.encode(batch)
+	if err != nil {
+		return exportOutcome{class: ErrEncode, attempts: 1, firstClass: ErrEncode}
+	}
+	ctx, cancel := context.WithTimeout(ctx, x.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, x.argv[0], x.argv[1:]...)
+	cmd.Stdin = bytes.NewReader(x.e.payload())
+	cmd.WaitDelay = execWaitDelay
+	if x.sandbox != nil {
+		if err := x.sandbox.wrap(cmd); err != nil {
+			return exportOutcome{class: ErrSandbox, attempts: 1, firstClass: ErrSandbox}
+		}
+	}
+	t0 := time.Now()
+	err = cmd.Run()
+	out := exportOutcome{bytes: n, attempts: 1, first: time.Since(t0)}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		out.class = ErrTimeout
+	case x.sandbox != nil && errors.As(err, &exitErr) && exitErr.ExitCode() == sandboxSetupFailed:
+		out.class = ErrSandbox
+	default:
+		out.class = ErrExec
+	}
+	out.firstClass = out.class
+	return out
+}
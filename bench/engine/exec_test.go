@@ -0,0 +1,101 @@
+//go:build unix
+
+package engine
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func execConfig(script string) Config {
+	return Config{Mode: ModeReal, Sampling: 1, Serialization: "json", Compression: "none", Spans: 200, BatchSize: 50,
+		Workload: WorkloadExec, Command: []string{"sh", "-c", script}}
+}
+
+func TestExecWorkload(t *testing.T) {
+	r, err := New().Run(context.Background(), execConfig("cat >/dev/null"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ErrorRate != 0 || r.SizeKB <= 0 {
+		t.Errorf("cat: error_rate=%v size_kb=%v", r.ErrorRate, r.SizeKB)
+	}
+	if r, err = New().Run(context.Background(), execConfig("exit 3")); err != nil {
+		t.Fatal(err)
+	}
+	if r.ErrorRate != 1 || r.Errors[ErrExec] == 0 {
+		t.Errorf("exit 3: error_rate=%v errors=%v", r.ErrorRate, r.Errors)
+	}
+	cfg := execConfig("sleep 5")
+	cfg.Spans, cfg.Timeout = 50, 50*time.Millisecond
+	if r, err = New().Run(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	if r.Errors[ErrTimeout] != 1 {
+		t.Errorf("sleep past the timeout: errors=%v", r.Errors)
+	}
+}
+
+func TestParseSandbox(t *testing.T) {
+	for _, c := range []struct {
+		spec string
+		want Sandbox
+	}{
+		{"on", Sandbox{Spec: "on", TmpfsBytes: DefaultSandboxTmpfs}},
+		{"tmpfs:16MB,cpu:10s,mem:1G,nofile:256", Sandbox{Spec: "tmpfs:16MB,cpu:10s,mem:1G,nofile:256", TmpfsBytes: 16 << 20, CPUSeconds: 10, MemBytes: 1 << 30, Files: 256}},
+		{"net:host", Sandbox{Spec: "net:host", HostNetwork: true, TmpfsBytes: DefaultSandboxTmpfs}},
+	} {
+		s, err := ParseSandbox(c.spec)
+		if err != nil || !reflect.DeepEqual(*s, c.want) {
+			t.Errorf("ParseSandbox(%q) = %+v, %v; want %+v", c.spec, s, err, c.want)
+		}
+	}
+	for _, spec := range []string{"off", "tmpfs:0", "tmpfs:x", "cpu:500ms", "nofile:-1", "net:bridge", "pids:10"} {
+		if _, err := ParseSandbox(spec); err == nil {
+			t.Errorf("ParseSandbox(%q) accepted", spec)
+		}
+	}
+}
+
+// TestSandbox runs the command in the sandbox: own pid and network
+// namespace, an empty private /tmp as working directory and the rlimits.
+func TestSandbox(t *testing.T) {
+	sb, err := ParseSandbox("nofile:64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := execConfig(strings.Join([]string{
+		`test $$ = 1`,
+		`test "$(pwd)" = /tmp`,
+		`test -z "$(ls -A /tmp)"`,
+		`test "$(ulimit -n)" = 64`,
+		`test "$(tail -n +3 /proc/net/dev | wc -l)" = 1`,
+		`touch /tmp/trace_bench_sandbox_test`,
+		`cat >/dev/null`,
+	}, " && "))
+	cfg.Sandbox = sb
+	if runtime.GOOS != "linux" {
+		if err := cfg.Validate(); err == nil {
+			t.Error("sandbox accepted outside Linux")
+		}
+		return
+	}
+	if err := sb.check(); err != nil {
+		t.Skip(err)
+	}
+	r, err := New().Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ErrorRate != 0 || r.Sandbox != sb {
+		t.Errorf("error_rate=%v errors=%v sandbox=%v", r.ErrorRate, r.Errors, r.Sandbox)
+	}
+	if _, err := os.Stat("/tmp/trace_bench_sandbox_test"); err == nil {
+		t.Error("sandboxed command wrote to the host /tmp")
+	}
+}
@@ -0,0 +1,427 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Real-mode workloads.
+const (
+	// WorkloadPipeline serializes and compresses batches in-process only.
+	WorkloadPipeline = "pipeline"
+	// WorkloadHTTP additionally POSTs each batch to Config.Endpoint.
+	WorkloadHTTP = "http"
+	// WorkloadExec runs Config.Command per batch with the encoded batch on
+	// its stdin, optionally inside Config.Sandbox.
+	WorkloadExec = "exec"
+)
+
+// Workloads lists the supported real-mode workloads.
+var Workloads = []string{WorkloadPipeline, WorkloadHTTP, WorkloadExec}
+
+// RunIDHeader carries Config.RunID on every http workload request; the
+// same id is sent as W3C baggage (BaggageRunIDKey).
+const (
+	RunIDHeader     = "X-Trace-Bench-Run-Id"
+	BaggageRunIDKey = "trace_bench.run_id"
+)
+
+// Synthetic traffic marking (-mark-header). A target that honours the mark
+// header should record those requests with SyntheticLabel="true" on its
+// request metrics, so SLO queries can exclude bench traffic with
+// synthetic!="true" (slo.yaml: $not_synthetic).
+const (
+	DefaultMarkHeader = "X-Synthetic: trace_bench/<runid>"
+	SyntheticLabel    = "synthetic"
+)
+
+// DefaultTimeout bounds one HTTP export.
+const DefaultTimeout = 5 * time.Second
+
+// Error classes reported in Result.Errors.
+const (
+	ErrTimeout           = "timeout"
+	ErrConnectionRefused = "connection_refused"
+	ErrConnectionReset   = "connection_reset"
+	ErrDNS               = "dns"
+	ErrTLS               = "tls"
+	ErrTransport         = "transport"
+	ErrHTTP4xx           = "http_4xx"
+	ErrHTTP5xx           = "http_5xx"
+	ErrProtocol          = "protocol_mismatch"
+	ErrEncode            = "encode"
+	ErrDecode            = "decode"
+	// ErrScript: the -script request() hook failed; ErrAssertion: its
+	// check() hook rejected the response.
+	ErrScript    = "script"
+	ErrAssertion = "assertion"
+	// ErrBroker: a message broker rejected the produce (queue-bench).
+	ErrBroker = "broker"
+	// ErrExec: the exec workload's command exited non-zero or could not
+	// start; ErrSandbox: its sandbox could not be set up.
+	ErrExec    = "exec"
+	ErrSandbox = "sandbox"
+
+	// errFeedDone은 unique feed 소진 신호(오류로 집계하지 않음)
+	errFeedDone = "feed_done"
+)
+
+// exportOutcome은 배치 1건의 결과(class가 비어 있으면 성공)
+type exportOutcome struct {
+	bytes  int
+	status int
+	class  string
+
+	// 재시도 회계: 시도 횟수, 첫 시도의 지연/분류
+	attempts   int
+	first      time.Duration
+	firstClass string
+
+	newConns, reusedConns int
+	handshakes            []time.Duration // TLS handshake 단계 지연
+	handshakeFailed       int
+}
+
+type exporter interface {
+	enc() *encoder
+	export(ctx context.Context, batch []span) exportOutcome
+}
+
+func newExporter(cfg Config, tr http.RoundTripper, bt *bodyTemplate, sc *requestScript) (exporter, error) {
+	enc, err := newEncoder(cfg.Serialization, cfg.Compression)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Workload {
+	case "", WorkloadPipeline:
+		return pipelineExporter{enc}, nil
+	case WorkloadHTTP:
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = DefaultTimeout
+		}
+		backoff := cfg.RetryBackoff
+		if backoff.Kind == "" {
+			backoff = DefaultBackoff
+		}
+		return &httpExporter{
+			e:       enc,
+			url:     cfg.Endpoint,
+			client:  &http.Client{Timeout: timeout, Transport: tr},
+			retries: cfg.Retries,
+			backoff: backoff,
+			proto:   cfg.Protocol,
+			headers: runHeaders(cfg),
+			body:    bt,
+			script:  sc,
+		}, nil
+	case WorkloadExec:
+		return newExecExporter(enc, cfg)
+	default:
+		return nil, fmt.Errorf("invalid workload: %s", cfg.Workload)
+	}
+}
+
+// runHeaders는 사용자 헤더에 run id 헤더/baggage를 더한다(baggage는 기존 값에 이어 붙임)
+func runHeaders(cfg Config) map[string]string {
+	h := make(map[string]string, len(cfg.Headers)+2)
+	for k, v := range cfg.Headers {
+		h[http.CanonicalHeaderKey(k)] = v
+	}
+	if cfg.RunID != "" {
+		h[RunIDHeader] = cfg.RunID
+		kv := BaggageRunIDKey + "=" + cfg.RunID
+		if b := h["Baggage"]; b != "" {
+			kv = b + "," + kv
+		}
+		h["Baggage"] = kv
+	}
+	return h
+}
+
+type pipelineExporter struct{ e *encoder }
+
+func (p pipelineExporter) enc() *encoder { return p.e }
+
+func (p pipelineExporter) export(_ context.Context, batch []span) exportOutcome {
+	t0 := time.Now()
+	n, err := p.e.encode(batch)
+	if err != nil {
+		return exportOutcome{class: ErrEncode, attempts: 1, firstClass: ErrEncode, first: time.Since(t0)}
+	}
+	return exportOutcome{bytes: n, attempts: 1, first: time.Since(t0)}
+}
+
+type httpExporter struct {
+	e       *encoder
+	url     string
+	client  *http.Client
+	retries int
+	backoff Backoff
+	proto   string
+	headers map[string]string
+	body    *bodyTemplate // nil이면 spans를 직렬화
+	bodyBuf bytes.Buffer
+	script  *requestScript    // body 대신 request()/check() 훅
+	dyn     map[string]string // 현재 요청의 request() 헤더
+}
+
+func (h *httpExporter) enc() *encoder { return h.e }
+
+var contentTypes = map[string]string{
+	"json":     "application/json",
+	"msgpack":  "application/msgpack",
+	"protobuf": "application/x-protobuf",
+}
+
+func (h *httpExporter) export(ctx context.Context, batch []span) exportOutcome {
+	var n int
+	var err error
+	h.dyn = nil
+	if h.script != nil {
+		var req scriptRequest
+		req, err = h.script.next(len(batch))
+		if errors.Is(err, ErrFeedExhausted) {
+			return exportOutcome{class: errFeedDone}
+		}
+		if err != nil {
+			return exportOutcome{class: ErrScript, attempts: 1, firstClass: ErrScript}
+		}
+		h.dyn = req.headers
+		n, err = h.e.encodeBytes(req.body)
+	} else if h.body != nil {
+		err = h.body.render(&h.bodyBuf, len(batch))
+		if errors.Is(err, ErrFeedExhausted) {
+			return exportOutcome{class: errFeedDone}
+		}
+		if err == nil {
+			n, err = h.e.encodeBytes(h.bodyBuf.Bytes())
+		}
+	} else {
+		n, err = h.e.encode(batch)
+	}
+	if err != nil {
+		return exportOutcome{class: ErrEncode, attempts: 1, firstClass: ErrEncode}
+	}
+	var out exportOutcome
+	var first time.Duration
+	var firstClass string
+	var newConns, reused, hsFailed int
+	var hs []time.Duration
+	for attempt := 0; ; attempt++ {
+		t0 := time.Now()
+		out = h.send(ctx, n)
+		newConns += out.newConns
+		reused += out.reusedConns
+		hsFailed += out.handshakeFailed
+		hs = append(hs, out.handshakes...)
+		out.newConns, out.reusedConns = newConns, reused
+		out.handshakes, out.handshakeFailed = hs, hsFailed
+		if attempt == 0 {
+			first, firstClass = time.Since(t0), out.class
+		}
+		// 최종 결과에 첫 시도 회계를 보존
+		out.attempts, out.first, out.firstClass = attempt+1, first, firstClass
+		if out.class == "" || attempt >= h.retries || !retryable(out.class) {
+			return out
+		}
+		select {
+		case <-time.After(h.backoff.Delay(attempt)):
+		case <-ctx.Done():
+			return out
+		}
+	}
+}
+
+// 클라이언트가 재시도하는 일시적 실패만 재시도 대상
+func retryable(class string) bool {
+	switch class {
+	case ErrTimeout, ErrConnectionRefused, ErrConnectionReset, ErrHTTP5xx:
+		return true
+	}
+	return false
+}
+
+// send는 인코딩된 payload를 한 번 전송한다
+func (h *httpExporter) send(ctx context.Context, n int) exportOutcome {
+	var out exportOutcome
+	var hsStart time.Time
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				out.reusedConns++
+			} else {
+				out.newConns++
+			}
+		},
+		TLSHandshakeStart: func() { hsStart = time.Now() },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err != nil {
+				out.handshakeFailed++
+				return
+			}
+			out.handshakes = append(out.handshakes, time.Since(hsStart))
+		},
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodPost, h.url, bytes.NewReader(h.e.payload()))
+	if err != nil {
+		out.class = ErrTransport
+		return out
+	}
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", contentTypes[h.e.ser])
+	if h.e.comp != "none" {
+		req.Header.Set("Content-Encoding", h.e.comp)
+	}
+	// request() 헤더는 고정 헤더/Content-Type보다 우선
+	for k, v := range h.dyn {
+		req.Header.Set(k, v)
+	}
+	out.bytes = n
+	resp, err := h.client.Do(req)
+	if err != nil {
+		out.class = classifyError(err)
+		return out
+	}
+	defer resp.Body.Close()
+	out.status = resp.StatusCode
+	if (h.proto == ProtoH2 && resp.ProtoMajor != 2) || (h.proto == ProtoH1 && resp.ProtoMajor != 1) {
+		out.class = ErrProtocol
+		io.Copy(io.Discard, resp.Body)
+		return out
+	}
+	body, err := io.ReadAll(resp.Body)
+	switch {
+	case err != nil:
+		out.class = classifyError(err)
+	case resp.StatusCode >= 500:
+		out.class = ErrHTTP5xx
+	case resp.StatusCode >= 400:
+		out.class = ErrHTTP4xx
+	case len(body) > 0 && strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") && !json.Valid(body):
+		// OTLP/HTTP partial-success 응답 등 JSON을 기대하는 경우
+		out.class = ErrDecode
+	case h.script != nil && h.script.verify(resp, body) != nil:
+		out.class = ErrAssertion
+	}
+	return out
+}
+
+// classifyError maps a transport error onto an error class.
+func classifyError(err error) string {
+	var nerr net.Error
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var alertErr tls.AlertError
+	var recErr tls.RecordHeaderError
+	switch {
+	case errors.As(err, &certErr), errors.As(err, &alertErr), errors.As(err, &recErr):
+		return ErrTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &nerr) && nerr.Timeout():
+		return ErrTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrConnectionReset
+	case errors.As(err, &dnsErr):
+		return ErrDNS
+	default:
+		return ErrTransport
+	}
+}
+
+// tally는 워커별 결과 집계(워커 종료 후 병합)
+type tally struct {
+	ops           int
+	failed, bytes int
+	errs          map[string]int
+	status        map[string]int
+
+	firstFailed, retried, recovered, retries int
+	newConns, reusedConns                    int
+	handshakeFailed                          int
+	spans, exported                          int     // 배치가 담당한 생성 span / 샘플링 후 보낸 span
+	bpsSum, bpsSq                            float64 // 배치별 span당 바이트의 합/제곱합(분산용)
+}
+
+func newTally() tally {
+	return tally{errs: map[string]int{}, status: map[string]int{}}
+}
+
+func (t *tally) add(o exportOutcome) {
+	t.ops++
+	t.bytes += o.bytes
+	if o.status != 0 {
+		t.status[strconv.Itoa(o.status)]++
+	}
+	if o.class != "" {
+		t.failed++
+		t.errs[o.class]++
+	}
+	t.newConns += o.newConns
+	t.reusedConns += o.reusedConns
+	t.handshakeFailed += o.handshakeFailed
+	if o.firstClass != "" {
+		t.firstFailed++
+	}
+	if o.attempts > 1 {
+		t.retried++
+		t.retries += o.attempts - 1
+		if o.class == "" {
+			t.recovered++
+		}
+	}
+}
+
+func (t *tally) merge(o tally) {
+	t.ops += o.ops
+	t.failed += o.failed
+	t.bytes += o.bytes
+	t.firstFailed += o.firstFailed
+	t.retried += o.retried
+	t.recovered += o.recovered
+	t.retries += o.retries
+	t.newConns += o.newConns
+	t.reusedConns += o.reusedConns
+	t.handshakeFailed += o.handshakeFailed
+	t.spans += o.spans
+	t.exported += o.exported
+	t.bpsSum += o.bpsSum
+	t.bpsSq += o.bpsSq
+	for k, v := range o.errs {
+		t.errs[k] += v
+	}
+	for k, v := range o.status {
+		t.status[k] += v
+	}
+}
+
+func (t *tally) errorsOrNil() map[string]int {
+	if len(t.errs) == 0 {
+		return nil
+	}
+	return t.errs
+}
+
+func (t *tally) statusOrNil() map[string]int {
+	if len(t.status) == 0 {
+		return nil
+	}
+	return t.status
+}
@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mrand "math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// Feed modes: how virtual users draw rows from a data file.
+const (
+	FeedSequential = "sequential" // 전체 워커가 순서대로 순환
+	FeedRandom     = "random"     // 매 요청 임의 행
+	FeedUnique     = "unique"     // 각 행을 한 번만 사용, 소진 시 종료
+)
+
+// ErrFeedExhausted ends a worker once a unique feed has no rows left.
+var ErrFeedExhausted = errors.New("feed exhausted")
+
+// FeedStats reports how a -feed dataset was consumed.
+type FeedStats struct {
+	Mode      string `json:"mode"`
+	Rows      int    `json:"rows"`
+	Drawn     int64  `json:"drawn"`
+	Exhausted bool   `json:"exhausted,omitempty"`
+}
+
+type feed struct {
+	mode string
+	rows []map[string]string
+	next atomic.Int64
+}
+
+// loadFeed는 CSV(첫 줄 헤더) 또는 JSONL(줄마다 객체)을 읽는다
+func loadFeed(path, mode string) (*feed, error) {
+	switch mode {
+	case "":
+		mode = FeedSequential
+	case FeedSequential, FeedRandom, FeedUnique:
+	default:
+		return nil, fmt.Errorf("invalid feed mode: %s", mode)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var rows []map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rows, err = readCSVRows(f)
+	case ".jsonl", ".ndjson":
+		rows, err = readJSONLRows(f)
+	default:
+		return nil, fmt.Errorf("unsupported feed format: %s (want .csv or .jsonl)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("feed %s: %w", path, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("feed %s: no rows", path)
+	}
+	return &feed{mode: mode, rows: rows}, nil
+}
+
+func readCSVRows(r io.Reader) ([]map[string]string, error) {
+	recs, err := csv.NewReader(r).ReadAll()
+	if err != nil || len(recs) == 0 {
+		return nil, err
+	}
+	header := recs[0]
+	rows := make([]map[string]string, 0, len(recs)-1)
+	for _, rec := range recs[1:] {
+		row := make(map[string]string, len(header))
+		for i, k := range header {
+			row[k] = rec[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func readJSONLRows(r io.Reader) ([]map[string]string, error) {
+	var rows []map[string]string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var obj map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &obj); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		row := make(map[string]string, len(obj))
+		for k, v := range obj {
+			if s, ok := v.(string); ok {
+				row[k] = s
+			} else {
+				b, _ := json.Marshal(v)
+				row[k] = string(b)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, sc.Err()
+}
+
+// draw는 모드에 따라 다음 행을 고른다(워커 간 공유, 락 없음)
+func (f *feed) draw() (map[string]string, error) {
+	switch f.mode {
+	case FeedRandom:
+		f.next.Add(1)
+		return f.rows[mrand.Intn(len(f.rows))], nil
+	case FeedUnique:
+		i := f.next.Add(1) - 1
+		if i >= int64(len(f.rows)) {
+			return nil, ErrFeedExhausted
+		}
+		return f.rows[i], nil
+	default:
+		i := f.next.Add(1) - 1
+		return f.rows[i%int64(len(f.rows))], nil
+	}
+}
+
+func (f *feed) stats() *FeedStats {
+	drawn := f.next.Load()
+	st := &FeedStats{Mode: f.mode, Rows: len(f.rows), Drawn: drawn}
+	if f.mode == FeedUnique && drawn > int64(len(f.rows)) {
+		st.Drawn, st.Exhausted = int64(len(f.rows)), true
+	}
+	return st
+}
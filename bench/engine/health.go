@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// HealthReport records the target health/readiness checks around a run.
+type HealthReport struct {
+	Pre  []HealthCheck `json:"pre"`
+	Post []HealthCheck `json:"post,omitempty"`
+}
+
+// HealthCheck is one GET of a health or readiness endpoint. Any 2xx
+// response is healthy.
+type HealthCheck struct {
+	URL       string  `json:"url"`
+	Status    int     `json:"status,omitempty"`
+	Healthy   bool    `json:"healthy"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// Healthy reports whether every check passed.
+func Healthy(cs []HealthCheck) bool {
+	for _, c := range cs {
+		if !c.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHealth GETs each URL once, using the run's TLS options for https
+// endpoints. Failures are recorded in the checks, not returned.
+func CheckHealth(ctx context.Context, urls []string, timeout time.Duration, o TLSOptions) ([]HealthCheck, error) {
+	client, err := probeClient(timeout, o)
+	if err != nil {
+		return nil, err
+	}
+	defer client.CloseIdleConnections()
+	out := make([]HealthCheck, 0, len(urls))
+	for _, u := range urls {
+		out = append(out, probe(ctx, client, u))
+	}
+	return out, nil
+}
+
+// probeClient는 대상 메타데이터 조회용 클라이언트(run의 TLS 옵션 사용)
+func probeClient(timeout time.Duration, o TLSOptions) (*http.Client, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	tr := &http.Transport{}
+	if o.enabled() {
+		c, err := o.config()
+		if err != nil {
+			return nil, err
+		}
+		tr.TLSClientConfig = c
+	}
+	return &http.Client{Timeout: timeout, Transport: tr}, nil
+}
+
+func probe(ctx context.Context, client *http.Client, url string) HealthCheck {
+	c := HealthCheck{URL: url}
+	t0 := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		c.Error = err.Error()
+		return c
+	}
+	resp, err := client.Do(req)
+	c.LatencyMs = stats.Round5(float64(time.Since(t0)) / float64(time.Millisecond))
+	if err != nil {
+		c.Error = classifyError(err)
+		return c
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	c.Status = resp.StatusCode
+	c.Healthy = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !c.Healthy {
+		c.Error = fmt.Sprintf("status %d", resp.StatusCode)
+	}
+	return c
+}
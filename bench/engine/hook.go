@@ -0,0 +1,17 @@
+package engine
+
+// Hook phases (trace_bench -pre-hook / -post-hook).
+const (
+	HookPre  = "pre"
+	HookPost = "post"
+)
+
+// HookRun records a command run before or after the measurement phase.
+// A non-zero exit vetoes the run.
+type HookRun struct {
+	Phase     string  `json:"phase"`
+	Command   string  `json:"command"`
+	ExitCode  int     `json:"exit_code"`
+	DurationS float64 `json:"duration_s"`
+	Error     string  `json:"error,omitempty"`
+}
@@ -0,0 +1,47 @@
+package engine
+
+import "runtime"
+
+// HostInfo describes the machine that generated the load. Latency and CPU
+// numbers from different architectures or CPU models (Graviton vs x86)
+// are not comparable; compare -require-same-arch checks Arch.
+type HostInfo struct {
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	CPUModel string `json:"cpu_model,omitempty"`
+	NumCPU   int    `json:"num_cpu"`
+	// Governor is the cpufreq scaling governor (Linux, empty when the
+	// host does not expose it, as on most VMs); MaxMHz the max frequency.
+	Governor string  `json:"governor,omitempty"`
+	MaxMHz   float64 `json:"max_mhz,omitempty"`
+	// CPUAffinity and Nice are the generator's -cpu-affinity/-nice.
+	CPUAffinity string `json:"cpu_affinity,omitempty"`
+	Nice        int    `json:"nice,omitempty"`
+	// Cgroup are the limits of the generator's own cgroup (container).
+	Cgroup *CgroupLimits `json:"cgroup,omitempty"`
+}
+
+// DetectHost reports the current host; fields the platform does not
+// expose are left empty.
+func DetectHost() *HostInfo {
+	h := &HostInfo{OS: runtime.GOOS, Arch: runtime.GOARCH, NumCPU: runtime.NumCPU(), Cgroup: SelfCgroupLimits()}
+	detectCPU(h)
+	return h
+}
+
+// SameArch reports whether h and o ran on the same architecture; a
+// missing side (results from before host metadata) is unknown, not a match.
+func (h *HostInfo) SameArch(o *HostInfo) bool {
+	return h != nil && o != nil && h.Arch == o.Arch
+}
+
+// String is "arch (cpu model)".
+func (h *HostInfo) String() string {
+	if h == nil {
+		return "unknown"
+	}
+	if h.CPUModel == "" {
+		return h.Arch
+	}
+	return h.Arch + " (" + h.CPUModel + ")"
+}
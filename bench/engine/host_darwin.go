@@ -0,0 +1,9 @@
+package engine
+
+import "syscall"
+
+func detectCPU(h *HostInfo) {
+	if s, err := syscall.Sysctl("machdep.cpu.brand_string"); err == nil {
+		h.CPUModel = s // Apple silicon도 "Apple M2" 등으로 채워진다
+	}
+}
@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// arm64 /proc/cpuinfo에는 model name이 없어 implementer/part로 코어 이름을 찾는다
+var armParts = map[string]string{
+	"0x41/0xd0c": "Neoverse-N1", // Graviton2, Ampere Altra
+	"0x41/0xd40": "Neoverse-V1", // Graviton3
+	"0x41/0xd49": "Neoverse-N2",
+	"0x41/0xd4f": "Neoverse-V2", // Graviton4, Grace
+	"0x41/0xd08": "Cortex-A72",  // Graviton(1세대), Raspberry Pi 4
+	"0x41/0xd0b": "Cortex-A76",
+}
+
+func detectCPU(h *HostInfo) {
+	if f, err := os.Open("/proc/cpuinfo"); err == nil {
+		var implementer, part string
+		sc := bufio.NewScanner(f)
+		for sc.Scan() && h.CPUModel == "" {
+			k, v, ok := strings.Cut(sc.Text(), ":")
+			if !ok {
+				continue
+			}
+			switch k, v = strings.TrimSpace(k), strings.TrimSpace(v); k {
+			case "model name":
+				h.CPUModel = v
+			case "CPU implementer":
+				implementer = v
+			case "CPU part":
+				part = v
+			}
+			if h.CPUModel == "" && implementer != "" && part != "" {
+				if h.CPUModel = armParts[implementer+"/"+part]; h.CPUModel == "" {
+					h.CPUModel = "arm " + implementer + "/" + part
+				}
+			}
+		}
+		f.Close()
+	}
+	const cpufreq = "/sys/devices/system/cpu/cpu0/cpufreq/"
+	if b, err := os.ReadFile(cpufreq + "scaling_governor"); err == nil {
+		h.Governor = strings.TrimSpace(string(b))
+	}
+	if b, err := os.ReadFile(cpufreq + "cpuinfo_max_freq"); err == nil {
+		if khz, err := strconv.ParseFloat(strings.TrimSpace(string(b)), 64); err == nil {
+			h.MaxMHz = khz / 1000
+		}
+	}
+}
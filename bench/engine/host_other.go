@@ -0,0 +1,6 @@
+//go:build !linux && !darwin
+
+package engine
+
+// detectCPU: CPU 모델을 읽는 방법이 없는 플랫폼은 OS/아키텍처만 기록
+func detectCPU(h *HostInfo) {}
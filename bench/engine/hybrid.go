@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// Hybrid mode defaults.
+const (
+	DefaultSpotSpans      = 2000
+	DefaultDriftThreshold = 0.25
+)
+
+// hybridMetrics are the metrics compared between the model estimate and
+// the spot-check. error_rate is left out: both sides are usually near 0,
+// where a relative difference is noise.
+var hybridMetrics = []string{"p95_ms", "size_kb"}
+
+// HybridReport compares a ModeHybrid model estimate with its real
+// spot-check.
+type HybridReport struct {
+	SpotSpans int     `json:"spot_spans"`
+	Threshold float64 `json:"threshold"`
+	// Model and Spot are the compared metrics of the estimate and of the
+	// spot-check; Drift is |model-spot|/spot per metric.
+	Model map[string]float64 `json:"model"`
+	Spot  map[string]float64 `json:"spot"`
+	Drift map[string]float64 `json:"drift"`
+	// Drifted lists the metrics whose Drift exceeds Threshold.
+	Drifted []string `json:"drifted,omitempty"`
+}
+
+// hybridMeasurement은 모델 추정 후 짧은 real 실행으로 확인한다.
+// 결과 지표는 모델 추정값이고, 어긋나면 ModelDrift를 세운다
+func hybridMeasurement(ctx context.Context, cfg Config) (Result, error) {
+	end := cfg.phase(ctx, PhaseMeasure)
+	r, err := modelBasedEstimation(ctx, cfg)
+	end(err)
+	if err != nil {
+		return Result{}, err
+	}
+	spot := cfg
+	spot.Mode = ModeReal
+	spot.Spans = cfg.SpotSpans
+	if spot.Spans <= 0 {
+		spot.Spans = DefaultSpotSpans
+	}
+	sr, err := realMeasurement(ctx, spot)
+	if err != nil {
+		return Result{}, err
+	}
+	rep := &HybridReport{SpotSpans: spot.Spans, Threshold: cfg.DriftThreshold,
+		Model: map[string]float64{}, Spot: map[string]float64{}, Drift: map[string]float64{}}
+	if rep.Threshold <= 0 {
+		rep.Threshold = DefaultDriftThreshold
+	}
+	mm, sm := r.Metrics(), sr.Metrics()
+	for _, name := range hybridMetrics {
+		m, s := mm[name], sm[name]
+		rep.Model[name], rep.Spot[name] = m, s
+		if s == 0 {
+			continue // 측정값이 0이면 상대 차이를 정할 수 없다
+		}
+		d := math.Abs(m-s) / s
+		rep.Drift[name] = stats.Round5(d)
+		if d > rep.Threshold {
+			rep.Drifted = append(rep.Drifted, name)
+		}
+	}
+	sort.Strings(rep.Drifted)
+	r.Hybrid = rep
+	r.ModelDrift = len(rep.Drifted) > 0
+	r.TargetProc = sr.TargetProc
+	return r, nil
+}
@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"context"
+	"testing"
+)
+
+// fixedModel은 설정과 무관하게 고정값을 돌려주는 시험용 Model
+type fixedModel struct{ p95, size float64 }
+
+func (m fixedModel) Estimate(Config) (Result, error) {
+	return Result{P95ms: m.p95, SizeKB: m.size}, nil
+}
+
+func TestHybridDrift(t *testing.T) {
+	cfg := Config{Mode: ModeHybrid, Sampling: 1, Serialization: "json", Compression: "none", SpotSpans: 400}
+	spot, err := New().Run(context.Background(), Config{Mode: ModeReal, Sampling: 1, Serialization: "json", Compression: "none", Spans: 400})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// size_kb는 결정론적이고 p95는 실행마다 흔들리므로 p95에는 넉넉한 임계값을 쓴다
+	cfg.Model, cfg.DriftThreshold = fixedModel{p95: spot.P95ms, size: spot.SizeKB}, 10
+	r, err := New().Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ModelDrift || r.Hybrid == nil || r.Hybrid.SpotSpans != 400 {
+		t.Fatalf("matching model: drift=%v report=%+v", r.ModelDrift, r.Hybrid)
+	}
+	if r.SizeKB != spot.SizeKB {
+		t.Errorf("hybrid result size_kb = %v, want the model estimate %v", r.SizeKB, spot.SizeKB)
+	}
+
+	cfg.Model = fixedModel{p95: spot.P95ms, size: spot.SizeKB * 100}
+	if r, err = New().Run(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !r.ModelDrift || len(r.Hybrid.Drifted) != 1 || r.Hybrid.Drifted[0] != "size_kb" {
+		t.Fatalf("size ×100: drift=%v drifted=%v", r.ModelDrift, r.Hybrid.Drifted)
+	}
+}
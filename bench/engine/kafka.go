@@ -0,0 +1,557 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 최소 Kafka 와이어 클라이언트: Metadata v1, ListOffsets v1, Produce v3, Fetch v4
+// (RecordBatch magic 2, 비압축). 큐 벤치가 쓰는 만큼만 구현한다
+
+const (
+	kafkaProduce     = 0
+	kafkaFetch       = 1
+	kafkaListOffsets = 2
+	kafkaMetadata    = 3
+
+	kafkaClientID = "trace_bench"
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// kafkaError는 응답의 error_code(0이 아닌 값)
+type kafkaError int16
+
+func (e kafkaError) Error() string { return "kafka error code " + strconv.Itoa(int(e)) }
+
+// kafkaConn은 브로커 연결 하나(요청/응답 직렬, 동시 사용 금지)
+type kafkaConn struct {
+	c       net.Conn
+	r       *bufio.Reader
+	corr    int32
+	timeout time.Duration
+}
+
+func dialKafka(ctx context.Context, addr string, timeout time.Duration) (*kafkaConn, error) {
+	d := net.Dialer{Timeout: timeout}
+	c, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaConn{c: c, r: bufio.NewReaderSize(c, 64<<10), timeout: timeout}, nil
+}
+
+func (k *kafkaConn) Close() error { return k.c.Close() }
+
+// send는 헤더를 붙여 요청을 쓴다
+func (k *kafkaConn) send(api, version int16, body []byte) (int32, error) {
+	k.corr++
+	b := make([]byte, 0, 4+10+len(kafkaClientID)+len(body))
+	b = binary.BigEndian.AppendUint32(b, uint32(2+2+4+2+len(kafkaClientID)+len(body)))
+	b = binary.BigEndian.AppendUint16(b, uint16(api))
+	b = binary.BigEndian.AppendUint16(b, uint16(version))
+	b = binary.BigEndian.AppendUint32(b, uint32(k.corr))
+	b = appendKString(b, kafkaClientID)
+	b = append(b, body...)
+	k.c.SetWriteDeadline(time.Now().Add(k.timeout))
+	_, err := k.c.Write(b)
+	return k.corr, err
+}
+
+// recv는 corr에 해당하는 응답 본문을 읽는다
+func (k *kafkaConn) recv(corr int32, wait time.Duration) ([]byte, error) {
+	k.c.SetReadDeadline(time.Now().Add(k.timeout + wait))
+	var hdr [8]byte
+	if _, err := io.ReadFull(k.r, hdr[:]); err != nil {
+		return nil, err
+	}
+	size := int32(binary.BigEndian.Uint32(hdr[:4]))
+	if size < 4 || size > 256<<20 {
+		return nil, fmt.Errorf("kafka: invalid response size %d", size)
+	}
+	if got := int32(binary.BigEndian.Uint32(hdr[4:])); got != corr {
+		return nil, fmt.Errorf("kafka: correlation id %d, expected %d", got, corr)
+	}
+	body := make([]byte, size-4)
+	_, err := io.ReadFull(k.r, body)
+	return body, err
+}
+
+func (k *kafkaConn) roundTrip(api, version int16, body []byte, wait time.Duration) ([]byte, error) {
+	corr, err := k.send(api, version, body)
+	if err != nil {
+		return nil, err
+	}
+	return k.recv(corr, wait)
+}
+
+// kafkaPartition은 파티션과 리더 브로커 주소
+type kafkaPartition struct {
+	id     int32
+	leader string
+}
+
+// kafkaMetadataFor는 topic의 파티션별 리더를 찾는다. 자동 생성 직후의
+// LEADER_NOT_AVAILABLE(5)은 잠시 기다려 다시 묻는다
+func kafkaMetadataFor(ctx context.Context, bootstrap, topic string, timeout time.Duration) ([]kafkaPartition, error) {
+	k, err := dialKafka(ctx, bootstrap, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer k.Close()
+	req := binary.BigEndian.AppendUint32(nil, 1)
+	req = appendKString(req, topic)
+	for attempt := 0; ; attempt++ {
+		body, err := k.roundTrip(kafkaMetadata, 1, req, 0)
+		if err != nil {
+			return nil, err
+		}
+		parts, err := parseKafkaMetadata(body, topic)
+		var ke kafkaError
+		if errors.As(err, &ke) && ke == 5 && attempt < 10 {
+			select {
+			case <-time.After(200 * time.Millisecond):
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		return parts, err
+	}
+}
+
+func parseKafkaMetadata(body []byte, topic string) ([]kafkaPartition, error) {
+	d := kdec{b: body}
+	brokers := map[int32]string{}
+	for n := d.i32(); n > 0; n-- {
+		id := d.i32()
+		host := d.str()
+		port := d.i32()
+		d.str() // rack
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	d.i32() // controller
+	var out []kafkaPartition
+	for n := d.i32(); n > 0; n-- {
+		code := d.i16()
+		name := d.str()
+		d.i8() // is_internal
+		var parts []kafkaPartition
+		for p := d.i32(); p > 0; p-- {
+			pcode := d.i16()
+			id := d.i32()
+			leader := d.i32()
+			for r := d.i32(); r > 0; r-- {
+				d.i32()
+			}
+			for r := d.i32(); r > 0; r-- {
+				d.i32()
+			}
+			if pcode != 0 && code == 0 {
+				code = pcode
+			}
+			parts = append(parts, kafkaPartition{id: id, leader: brokers[leader]})
+		}
+		if name != topic {
+			continue
+		}
+		if code != 0 {
+			return nil, fmt.Errorf("kafka metadata %s: %w", topic, kafkaError(code))
+		}
+		out = parts
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("kafka metadata: %w", d.err)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("kafka metadata: topic %s not found", topic)
+	}
+	for _, p := range out {
+		if p.leader == "" {
+			return nil, fmt.Errorf("kafka metadata %s: partition %d has no leader", topic, p.id)
+		}
+	}
+	return out, nil
+}
+
+// appendRecordBatch는 메시지들을 RecordBatch v2로. key에 보낸 시각(unix ns)을 싣는다
+func appendRecordBatch(b []byte, sentAt int64, msgs [][]byte) []byte {
+	ms := sentAt / 1e6
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], uint64(sentAt))
+	var recs []byte
+	for i, m := range msgs {
+		var r []byte
+		r = append(r, 0)                          // attributes
+		r = binary.AppendVarint(r, 0)             // timestampDelta
+		r = binary.AppendVarint(r, int64(i))      // offsetDelta
+		r = binary.AppendVarint(r, 8)             // key length
+		r = append(r, key[:]...)                  //
+		r = binary.AppendVarint(r, int64(len(m))) // value length
+		r = append(r, m...)
+		r = binary.AppendVarint(r, 0) // headers
+		recs = binary.AppendVarint(recs, int64(len(r)))
+		recs = append(recs, r...)
+	}
+	// crc 이후(attributes부터) 부분
+	var tail []byte
+	tail = binary.BigEndian.AppendUint16(tail, 0) // attributes: 비압축
+	tail = binary.BigEndian.AppendUint32(tail, uint32(len(msgs)-1))
+	tail = binary.BigEndian.AppendUint64(tail, uint64(ms))
+	tail = binary.BigEndian.AppendUint64(tail, uint64(ms))
+	tail = binary.BigEndian.AppendUint64(tail, ^uint64(0)) // producerId -1
+	tail = binary.BigEndian.AppendUint16(tail, ^uint16(0)) // producerEpoch -1
+	tail = binary.BigEndian.AppendUint32(tail, ^uint32(0)) // baseSequence -1
+	tail = binary.BigEndian.AppendUint32(tail, uint32(len(msgs)))
+	tail = append(tail, recs...)
+
+	b = binary.BigEndian.AppendUint64(b, 0)                       // baseOffset
+	b = binary.BigEndian.AppendUint32(b, uint32(4+1+4+len(tail))) // batchLength
+	b = binary.BigEndian.AppendUint32(b, 0)                       // partitionLeaderEpoch
+	b = append(b, 2)                                              // magic
+	b = binary.BigEndian.AppendUint32(b, crc32.Checksum(tail, crc32c))
+	return append(b, tail...)
+}
+
+// kafkaProducer는 파티션 하나에 Produce v3로 쓴다
+type kafkaProducer struct {
+	conn      *kafkaConn
+	topic     string
+	partition int32
+	acks      int16
+	buf       []byte
+}
+
+func (p *kafkaProducer) publish(ctx context.Context, sentAt int64, msgs [][]byte) error {
+	b := p.buf[:0]
+	b = binary.BigEndian.AppendUint16(b, ^uint16(0)) // transactional_id null
+	b = binary.BigEndian.AppendUint16(b, uint16(p.acks))
+	b = binary.BigEndian.AppendUint32(b, uint32(p.conn.timeout.Milliseconds()))
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = appendKString(b, p.topic)
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = binary.BigEndian.AppendUint32(b, uint32(p.partition))
+	lenAt := len(b)
+	b = binary.BigEndian.AppendUint32(b, 0)
+	b = appendRecordBatch(b, sentAt, msgs)
+	binary.BigEndian.PutUint32(b[lenAt:], uint32(len(b)-lenAt-4))
+	p.buf = b
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	corr, err := p.conn.send(kafkaProduce, 3, b)
+	if err != nil || p.acks == 0 {
+		// acks=0: 브로커가 응답하지 않는다
+		return err
+	}
+	body, err := p.conn.recv(corr, 0)
+	if err != nil {
+		return err
+	}
+	d := kdec{b: body}
+	for n := d.i32(); n > 0; n-- {
+		d.str()
+		for m := d.i32(); m > 0; m-- {
+			d.i32()
+			if code := d.i16(); code != 0 && d.err == nil {
+				return kafkaError(code)
+			}
+			d.i64()
+			d.i64()
+		}
+	}
+	return d.err
+}
+
+func (p *kafkaProducer) close() error { return p.conn.Close() }
+
+// kafkaConsumer는 리더별로 Fetch v4를 돌며 key의 보낸 시각을 꺼낸다.
+// lag는 마지막 fetch의 high watermark - 다음 offset 합(브로커 기준)
+type kafkaConsumer struct {
+	topic   string
+	timeout time.Duration
+	leaders map[string][]int32
+	offsets map[int32]int64
+	conns   []*kafkaConn
+	got     func(sentAt int64)
+
+	mu    sync.Mutex
+	lagBy map[int32]int64
+}
+
+func newKafkaConsumer(ctx context.Context, parts []kafkaPartition, topic string, timeout time.Duration, got func(sentAt int64)) (*kafkaConsumer, error) {
+	c := &kafkaConsumer{topic: topic, timeout: timeout, leaders: map[string][]int32{}, offsets: map[int32]int64{}, lagBy: map[int32]int64{}, got: got}
+	for _, p := range parts {
+		c.leaders[p.leader] = append(c.leaders[p.leader], p.id)
+	}
+	// 측정 전 끝 offset부터 읽는다(이전 run의 메시지 제외)
+	for leader, ids := range c.leaders {
+		k, err := dialKafka(ctx, leader, timeout)
+		if err != nil {
+			c.close()
+			return nil, err
+		}
+		c.conns = append(c.conns, k)
+		req := binary.BigEndian.AppendUint32(nil, ^uint32(0)) // replica_id -1
+		req = binary.BigEndian.AppendUint32(req, 1)
+		req = appendKString(req, topic)
+		req = binary.BigEndian.AppendUint32(req, uint32(len(ids)))
+		for _, id := range ids {
+			req = binary.BigEndian.AppendUint32(req, uint32(id))
+			req = binary.BigEndian.AppendUint64(req, ^uint64(0)) // latest
+		}
+		body, err := k.roundTrip(kafkaListOffsets, 1, req, 0)
+		if err != nil {
+			c.close()
+			return nil, err
+		}
+		d := kdec{b: body}
+		for n := d.i32(); n > 0; n-- {
+			d.str()
+			for m := d.i32(); m > 0; m-- {
+				id := d.i32()
+				code := d.i16()
+				d.i64()
+				off := d.i64()
+				if code != 0 && d.err == nil {
+					c.close()
+					return nil, fmt.Errorf("kafka list offsets: %w", kafkaError(code))
+				}
+				c.offsets[id] = off
+			}
+		}
+		if d.err != nil {
+			c.close()
+			return nil, fmt.Errorf("kafka list offsets: %w", d.err)
+		}
+	}
+	return c, nil
+}
+
+func (c *kafkaConsumer) run(ctx context.Context) error {
+	errs := make(chan error, len(c.conns))
+	var wg sync.WaitGroup
+	i := 0
+	for _, ids := range c.leaders {
+		wg.Add(1)
+		go func(k *kafkaConn, ids []int32) {
+			defer wg.Done()
+			errs <- c.fetchLoop(ctx, k, ids, c.got)
+		}(c.conns[i], ids)
+		i++
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const kafkaFetchWait = 100 * time.Millisecond
+
+func (c *kafkaConsumer) fetchLoop(ctx context.Context, k *kafkaConn, ids []int32, got func(int64)) error {
+	offsets := make(map[int32]int64, len(ids))
+	for _, id := range ids {
+		offsets[id] = c.offsets[id]
+	}
+	for ctx.Err() == nil {
+		req := binary.BigEndian.AppendUint32(nil, ^uint32(0))                           // replica_id
+		req = binary.BigEndian.AppendUint32(req, uint32(kafkaFetchWait.Milliseconds())) // max_wait
+		req = binary.BigEndian.AppendUint32(req, 1)                                     // min_bytes
+		req = binary.BigEndian.AppendUint32(req, 32<<20)                                // max_bytes
+		req = append(req, 0)                                                            // isolation_level
+		req = binary.BigEndian.AppendUint32(req, 1)
+		req = appendKString(req, c.topic)
+		req = binary.BigEndian.AppendUint32(req, uint32(len(ids)))
+		for _, id := range ids {
+			req = binary.BigEndian.AppendUint32(req, uint32(id))
+			req = binary.BigEndian.AppendUint64(req, uint64(offsets[id]))
+			req = binary.BigEndian.AppendUint32(req, 8<<20)
+		}
+		body, err := k.roundTrip(kafkaFetch, 4, req, kafkaFetchWait)
+		if err != nil {
+			return err
+		}
+		d := kdec{b: body}
+		d.i32() // throttle
+		for n := d.i32(); n > 0; n-- {
+			d.str()
+			for m := d.i32(); m > 0; m-- {
+				id := d.i32()
+				code := d.i16()
+				hw := d.i64()
+				d.i64() // last_stable_offset
+				for a := d.i32(); a > 0; a-- {
+					d.i64()
+					d.i64()
+				}
+				recs := d.bytes()
+				if code != 0 && d.err == nil {
+					return fmt.Errorf("kafka fetch: %w", kafkaError(code))
+				}
+				offsets[id] = readRecordBatches(recs, offsets[id], got)
+				c.mu.Lock()
+				c.lagBy[id] = max(hw-offsets[id], 0)
+				c.mu.Unlock()
+			}
+		}
+		if d.err != nil {
+			return fmt.Errorf("kafka fetch: %w", d.err)
+		}
+	}
+	return nil
+}
+
+// readRecordBatches는 완전한 배치만 읽고 다음 fetch offset을 돌려준다
+func readRecordBatches(b []byte, next int64, got func(int64)) int64 {
+	for len(b) >= 12 {
+		base := int64(binary.BigEndian.Uint64(b))
+		size := int(int32(binary.BigEndian.Uint32(b[8:])))
+		if size < 49 || len(b) < 12+size {
+			break // 잘린 마지막 배치
+		}
+		batch := b[12 : 12+size]
+		b = b[12+size:]
+		lastDelta := int64(int32(binary.BigEndian.Uint32(batch[11:])))
+		if base+lastDelta < next {
+			continue
+		}
+		if batch[4] == 2 && binary.BigEndian.Uint16(batch[9:])&0x7 == 0 {
+			recs := batch[49:]
+			for count := int32(binary.BigEndian.Uint32(batch[45:])); count > 0 && len(recs) > 0; count-- {
+				n, w := binary.Varint(recs)
+				if w <= 0 || int64(len(recs)-w) < n {
+					break
+				}
+				rec := recs[w : w+int(n)]
+				recs = recs[w+int(n):]
+				if sentAt, off, ok := recordKey(rec); ok && base+off >= next {
+					got(sentAt)
+				}
+			}
+		}
+		next = base + lastDelta + 1
+	}
+	return next
+}
+
+// recordKey는 record의 offsetDelta와 8바이트 key(보낸 시각)를 꺼낸다
+func recordKey(rec []byte) (sentAt, offsetDelta int64, ok bool) {
+	if len(rec) < 1 {
+		return 0, 0, false
+	}
+	p := rec[1:]
+	if _, w := binary.Varint(p); w > 0 { // timestampDelta
+		p = p[w:]
+	} else {
+		return 0, 0, false
+	}
+	offsetDelta, w := binary.Varint(p)
+	if w <= 0 {
+		return 0, 0, false
+	}
+	p = p[w:]
+	klen, w := binary.Varint(p)
+	if w <= 0 || klen != 8 || len(p) < w+8 {
+		return 0, 0, false
+	}
+	return int64(binary.BigEndian.Uint64(p[w:])), offsetDelta, true
+}
+
+func (c *kafkaConsumer) lag() (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var n int64
+	for _, l := range c.lagBy {
+		n += l
+	}
+	return n, true
+}
+
+func (c *kafkaConsumer) close() error {
+	for _, k := range c.conns {
+		k.Close()
+	}
+	return nil
+}
+
+func appendKString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// kdec는 Kafka 응답 디코더. 범위를 벗어나면 err를 세우고 0값을 돌려준다
+type kdec struct {
+	b   []byte
+	err error
+}
+
+func (d *kdec) take(n int) []byte {
+	if d.err != nil || n < 0 || len(d.b) < n {
+		if d.err == nil {
+			d.err = io.ErrUnexpectedEOF
+		}
+		return nil
+	}
+	p := d.b[:n]
+	d.b = d.b[n:]
+	return p
+}
+
+func (d *kdec) i8() int8 {
+	if p := d.take(1); p != nil {
+		return int8(p[0])
+	}
+	return 0
+}
+
+func (d *kdec) i16() int16 {
+	if p := d.take(2); p != nil {
+		return int16(binary.BigEndian.Uint16(p))
+	}
+	return 0
+}
+
+func (d *kdec) i32() int32 {
+	if p := d.take(4); p != nil {
+		return int32(binary.BigEndian.Uint32(p))
+	}
+	return 0
+}
+
+func (d *kdec) i64() int64 {
+	if p := d.take(8); p != nil {
+		return int64(binary.BigEndian.Uint64(p))
+	}
+	return 0
+}
+
+// str은 nullable string(-1은 빈 문자열)
+func (d *kdec) str() string {
+	n := d.i16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+// bytes는 nullable bytes(-1은 nil)
+func (d *kdec) bytes() []byte {
+	n := d.i32()
+	if n < 0 {
+		return nil
+	}
+	return d.take(int(n))
+}
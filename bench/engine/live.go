@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// DefaultLiveWindow is the number of recent latencies Live keeps for its
+// percentiles.
+const DefaultLiveWindow = 4096
+
+// Live aggregates a running real-mode measurement for live display
+// (trace_bench -tui). Workers record into it; Snapshot may be called from
+// any goroutine. Set Config.Live to enable it.
+type Live struct {
+	mu     sync.Mutex
+	start  time.Time
+	ops    int64
+	failed int64
+	bytes  int64
+	errs   map[string]int64
+	recent []time.Duration // 최근 지연 링 버퍼
+	next   int
+	full   bool
+}
+
+// NewLive returns a Live keeping the last window latencies (default
+// DefaultLiveWindow).
+func NewLive(window int) *Live {
+	if window <= 0 {
+		window = DefaultLiveWindow
+	}
+	return &Live{start: time.Now(), errs: map[string]int64{}, recent: make([]time.Duration, window)}
+}
+
+func (l *Live) record(d time.Duration, o exportOutcome) {
+	l.mu.Lock()
+	l.ops++
+	l.bytes += int64(o.bytes)
+	if o.class != "" {
+		l.failed++
+		l.errs[o.class]++
+	}
+	l.recent[l.next] = d
+	if l.next++; l.next == len(l.recent) {
+		l.next, l.full = 0, true
+	}
+	l.mu.Unlock()
+}
+
+// LiveSnapshot is the state of a Live at one instant. Percentiles are over
+// the recent latency window, counters over the whole run.
+type LiveSnapshot struct {
+	Elapsed time.Duration
+	Ops     int64
+	Failed  int64
+	Bytes   int64
+	Errors  map[string]int64
+	P50ms   float64
+	P95ms   float64
+	P99ms   float64
+	MaxMs   float64
+}
+
+// Snapshot copies the current counters and window percentiles.
+func (l *Live) Snapshot() LiveSnapshot {
+	l.mu.Lock()
+	n := l.next
+	if l.full {
+		n = len(l.recent)
+	}
+	win := append([]time.Duration(nil), l.recent[:n]...)
+	s := LiveSnapshot{Elapsed: time.Since(l.start), Ops: l.ops, Failed: l.failed, Bytes: l.bytes, Errors: make(map[string]int64, len(l.errs))}
+	for k, v := range l.errs {
+		s.Errors[k] = v
+	}
+	l.mu.Unlock()
+	if len(win) == 0 {
+		return s
+	}
+	sort.Slice(win, func(i, j int) bool { return win[i] < win[j] })
+	ms := func(q float64) float64 {
+		i := min(int(q*float64(len(win))), len(win)-1)
+		return stats.Round5(float64(win[i]) / float64(time.Millisecond))
+	}
+	s.P50ms, s.P95ms, s.P99ms = ms(0.5), ms(0.95), ms(0.99)
+	s.MaxMs = stats.Round5(float64(win[len(win)-1]) / float64(time.Millisecond))
+	return s
+}
@@ -0,0 +1,264 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// MergeResults combines the results of parallel shards of one bench (same
+// config, run side by side) into the result of the whole run. Counts,
+// bytes and error classes are summed and ratios recomputed from the sums;
+// P95ms/P99ms come from the summed latency heatmaps, never from averaging
+// shard percentiles, so every shard must be a real-mode run with -heatmap
+// (same interval and bounds). Sub-percentiles without a histogram (retry,
+// TLS handshake, batch delay) report the largest shard value, an upper
+// bound of the merged percentile. Soak, chaos and MTTR results cannot be
+// merged; cost, SLO checks and other per-run evidence are dropped.
+func MergeResults(shards []Result) (Result, error) {
+	if len(shards) == 0 {
+		return Result{}, fmt.Errorf("merge: no shards")
+	}
+	first := shards[0]
+	for i, s := range shards {
+		switch {
+		case s.Volume == nil:
+			return Result{}, fmt.Errorf("merge: shard %d has no volume stats (merge needs real-mode results)", i+1)
+		case s.Heatmap == nil:
+			return Result{}, fmt.Errorf("merge: shard %d has no latency heatmap (run shards with -heatmap)", i+1)
+		case s.Soak != nil || s.Chaos != nil || s.MTTR != nil:
+			return Result{}, fmt.Errorf("merge: shard %d is a soak/chaos run, which cannot be merged", i+1)
+		case s.Protocol != first.Protocol:
+			return Result{}, fmt.Errorf("merge: shard %d protocol %q differs from %q", i+1, s.Protocol, first.Protocol)
+		case s.Heatmap.Interval != first.Heatmap.Interval || !slices.Equal(s.Heatmap.Bounds, first.Heatmap.Bounds):
+			return Result{}, fmt.Errorf("merge: shard %d heatmap interval/buckets differ from shard 1", i+1)
+		}
+	}
+
+	out := Result{
+		Protocol:       first.Protocol,
+		QuantileSketch: first.QuantileSketch,
+		Target:         first.Target,
+		TargetBuild:    first.TargetBuild,
+		Host:           first.Host,
+		Netem:          first.Netem,
+		Labels:         commonLabels(shards),
+		Volume:         &VolumeStats{},
+	}
+	if runID := first.RunID; slices.IndexFunc(shards, func(s Result) bool { return s.RunID != runID }) < 0 {
+		out.RunID = runID // 같은 run id로 돈 shard
+	}
+	hm := stats.NewHeatmap(first.Heatmap.Interval, first.Heatmap.Bounds)
+	var failed, ops int
+	var mem MemStats
+	var allocs, allocBytes float64
+	var firstFailed, blocked, batchedS float64 // 비율 × 가중치 합
+	for _, s := range shards {
+		hm.Merge(s.Heatmap)
+		v := s.Volume
+		ops += v.Batches
+		failed += shardFailed(s)
+		out.Volume.Spans += v.Spans
+		out.Volume.Exported += v.Exported
+		out.Volume.Bytes += v.Bytes
+		out.Volume.Batches += v.Batches
+		out.Volume.CPUSeconds += v.CPUSeconds
+		out.Volume.DurationS = max(out.Volume.DurationS, v.DurationS) // 병렬 실행
+		out.Errors = addCounts(out.Errors, s.Errors)
+		out.StatusCodes = addCounts(out.StatusCodes, s.StatusCodes)
+		if s.Mem != nil {
+			allocs += s.Mem.AllocsPerOp * float64(v.Batches)
+			allocBytes += s.Mem.BytesPerOp * float64(v.Batches)
+			mem.GCPauseMs += s.Mem.GCPauseMs
+			mem.NumGC += s.Mem.NumGC
+		}
+		if s.Retry != nil {
+			firstFailed += s.Retry.FirstAttemptErrorRate * float64(v.Batches)
+		}
+		if s.Batching != nil {
+			blocked += s.Batching.BlockedRatio * v.DurationS
+			batchedS += v.DurationS
+		}
+		out.Retry = mergeRetry(out.Retry, s.Retry)
+		out.Conn = mergeConn(out.Conn, s.Conn)
+		out.TLS = mergeTLS(out.TLS, s.TLS)
+		out.Batching = mergeBatching(out.Batching, s.Batching)
+		out.Throttle = mergeThrottle(out.Throttle, s.Throttle)
+	}
+	out.Volume.CPUSeconds = stats.Round5(out.Volume.CPUSeconds)
+	out.Volume.BytesPerSpanSD = stats.Round5(pooledBytesPerSpanSD(shards))
+	out.Heatmap = hm
+
+	totals := hm.Totals()
+	p95, _ := stats.InterpolatedBucketQuantile(hm.Bounds, totals, 0.95)
+	p99, _ := stats.InterpolatedBucketQuantile(hm.Bounds, totals, 0.99)
+	out.P95ms, out.P99ms = stats.Round5(p95), stats.Round5(p99)
+	if ops > 0 {
+		n := float64(ops)
+		out.ErrorRate = stats.Round5(float64(failed) / n)
+		out.SizeKB = stats.Round2(float64(out.Volume.Bytes) / n / 1024)
+		mem.AllocsPerOp, mem.BytesPerOp = stats.Round2(allocs/n), stats.Round2(allocBytes/n)
+	}
+	mem.GCPauseMs = stats.Round5(mem.GCPauseMs)
+	if first.Mem != nil {
+		out.Mem = &mem
+	}
+	if out.Retry != nil && ops > 0 {
+		out.Retry.FirstAttemptErrorRate = stats.Round5(firstFailed / float64(ops))
+	}
+	if b := out.Batching; b != nil {
+		queued := b.Arrived - b.Dropped
+		if b.Batches > 0 {
+			b.MeanBatchSpans = stats.Round2(float64(queued) / float64(b.Batches))
+		}
+		if b.Arrived > 0 {
+			b.DropRate = stats.Round5(float64(b.Dropped) / float64(b.Arrived))
+		}
+		if batchedS > 0 {
+			b.BlockedRatio = stats.Round5(blocked / batchedS)
+		}
+		b.BlockedMs = stats.Round5(b.BlockedMs)
+		b.SpansPerSec = stats.Round2(b.SpansPerSec)
+	}
+	if t := out.Throttle; t != nil && t.Periods > 0 {
+		t.Ratio = stats.Round5(float64(t.ThrottledPeriods) / float64(t.Periods))
+	}
+	return out, nil
+}
+
+// shardFailed는 shard의 실패 op 수: 오류 분류 합, 없으면 error_rate × ops
+func shardFailed(s Result) int {
+	n := 0
+	for _, c := range s.Errors {
+		n += c
+	}
+	if n == 0 {
+		n = int(math.Round(s.ErrorRate * float64(s.Volume.Batches)))
+	}
+	return n
+}
+
+// commonLabels는 모든 shard에 같은 값으로 있는 레이블(shard 번호 등은 빠진다)
+func commonLabels(shards []Result) map[string]string {
+	var out map[string]string
+	for k, v := range shards[0].Labels {
+		same := true
+		for _, s := range shards[1:] {
+			if w, ok := s.Labels[k]; !ok || w != v {
+				same = false
+				break
+			}
+		}
+		if same {
+			if out == nil {
+				out = map[string]string{}
+			}
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// pooledBytesPerSpanSD는 shard별 배치 표본을 합친 표본 표준편차(shard 평균은
+// bytes/spans로 근사)
+func pooledBytesPerSpanSD(shards []Result) float64 {
+	var n, sum float64
+	for _, s := range shards {
+		n += float64(s.Volume.Batches)
+		sum += s.Volume.BytesPerSpan() * float64(s.Volume.Batches)
+	}
+	if n < 2 {
+		return 0
+	}
+	mean := sum / n
+	var ss float64
+	for _, s := range shards {
+		k := float64(s.Volume.Batches)
+		d := s.Volume.BytesPerSpan() - mean
+		ss += max(k-1, 0)*s.Volume.BytesPerSpanSD*s.Volume.BytesPerSpanSD + k*d*d
+	}
+	return math.Sqrt(ss / (n - 1))
+}
+
+// merge*: 개수는 더하고 shard별 p95는 최댓값(합친 p95의 상한)
+
+func mergeRetry(dst, s *RetryStats) *RetryStats {
+	if s == nil {
+		return dst
+	}
+	if dst == nil {
+		dst = &RetryStats{Retries: s.Retries, Backoff: s.Backoff}
+	}
+	dst.FirstAttemptP95ms = max(dst.FirstAttemptP95ms, s.FirstAttemptP95ms)
+	dst.RetriedP95ms = max(dst.RetriedP95ms, s.RetriedP95ms)
+	dst.RetriedRequests += s.RetriedRequests
+	dst.Recovered += s.Recovered
+	dst.TotalRetries += s.TotalRetries
+	return dst
+}
+
+func mergeConn(dst, s *ConnStats) *ConnStats {
+	if s == nil {
+		return dst
+	}
+	if dst == nil {
+		dst = &ConnStats{Mode: s.Mode}
+	}
+	dst.NewConnections += s.NewConnections
+	dst.Reused += s.Reused
+	return dst
+}
+
+func mergeTLS(dst, s *TLSStats) *TLSStats {
+	if s == nil {
+		return dst
+	}
+	if dst == nil {
+		dst = &TLSStats{}
+	}
+	dst.Handshakes += s.Handshakes
+	dst.Failures += s.Failures
+	dst.HandshakeP95ms = max(dst.HandshakeP95ms, s.HandshakeP95ms)
+	return dst
+}
+
+func mergeBatching(dst, s *BatchStats) *BatchStats {
+	if s == nil {
+		return dst
+	}
+	if dst == nil {
+		c := *s
+		c.Batches, c.SizeFlushes, c.TimeoutFlushes, c.Arrived, c.Dropped = 0, 0, 0, 0, 0
+		c.SpanRate, c.SpansPerSec, c.BlockedMs, c.BlockedRatio = 0, 0, 0, 0
+		c.QueueMemPeakBytes, c.DelayP95ms, c.DelayP99ms, c.DelayGrowthMs = 0, 0, 0, 0
+		dst = &c
+	}
+	dst.Batches += s.Batches
+	dst.SizeFlushes += s.SizeFlushes
+	dst.TimeoutFlushes += s.TimeoutFlushes
+	dst.Arrived += s.Arrived
+	dst.Dropped += s.Dropped
+	dst.SpanRate += s.SpanRate // shard마다 따로 도착시킨 부하의 합
+	dst.SpansPerSec += s.SpansPerSec
+	dst.BlockedMs += s.BlockedMs
+	dst.QueueMemPeakBytes = max(dst.QueueMemPeakBytes, s.QueueMemPeakBytes)
+	dst.DelayP95ms = max(dst.DelayP95ms, s.DelayP95ms)
+	dst.DelayP99ms = max(dst.DelayP99ms, s.DelayP99ms)
+	dst.DelayGrowthMs = max(dst.DelayGrowthMs, s.DelayGrowthMs)
+	return dst
+}
+
+func mergeThrottle(dst, s *CPUThrottle) *CPUThrottle {
+	if s == nil {
+		return dst
+	}
+	if dst == nil {
+		dst = &CPUThrottle{}
+	}
+	dst.Periods += s.Periods
+	dst.ThrottledPeriods += s.ThrottledPeriods
+	dst.ThrottledMs += s.ThrottledMs
+	return dst
+}
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+var mergeT0 = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// mergeShard는 배치마다 지연 하나를 heatmap에 기록한 real 모드 shard
+func mergeShard(latMs []float64, spans int, bytes int64) Result {
+	h := stats.NewHeatmap(time.Second, []float64{5, 10, 25, 50})
+	for i, ms := range latMs {
+		h.Record(mergeT0.Add(time.Duration(i)*100*time.Millisecond), time.Duration(ms*float64(time.Millisecond)))
+	}
+	return Result{
+		Protocol: ProtoH2,
+		Heatmap:  h,
+		Volume:   &VolumeStats{Spans: spans, Exported: spans, Bytes: bytes, Batches: len(latMs), CPUSeconds: 0.5, DurationS: 10},
+	}
+}
+
+func repeat(v float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = v
+	}
+	return out
+}
+
+func TestMergeResults(t *testing.T) {
+	// 빠른 shard와 느린 shard: 합친 p95는 shard p95의 평균이 아니라 합친 분포에서
+	fast := append(repeat(3, 90), repeat(8, 10)...)
+	slow := append(repeat(20, 80), repeat(40, 20)...)
+	a := mergeShard(fast, 1000, 100_000)
+	a.Errors = map[string]int{"timeout": 2}
+	a.StatusCodes = map[string]int{"200": 98, "503": 2}
+	a.Labels = map[string]string{"bench": "h2", "shard": "1"}
+	b := mergeShard(slow, 3000, 500_000)
+	b.ErrorRate = 0.03 // 오류 분류 없이 비율만 있는 shard
+	b.StatusCodes = map[string]int{"200": 100}
+	b.Labels = map[string]string{"bench": "h2", "shard": "2"}
+	b.Volume.DurationS = 12
+
+	r, err := MergeResults([]Result{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := r.Volume
+	if v.Spans != 4000 || v.Exported != 4000 || v.Bytes != 600_000 || v.Batches != 200 || v.CPUSeconds != 1 {
+		t.Errorf("volume = %+v, want summed counts", *v)
+	}
+	if v.DurationS != 12 {
+		t.Errorf("duration_s = %v, want the longest shard (12)", v.DurationS)
+	}
+	if r.ErrorRate != 0.025 { // (2 + 0.03×100) / 200
+		t.Errorf("error_rate = %v, want 0.025", r.ErrorRate)
+	}
+	if r.SizeKB != stats.Round2(600_000.0/200/1024) {
+		t.Errorf("size_kb = %v, want bytes per batch of the sums", r.SizeKB)
+	}
+	if r.StatusCodes["200"] != 198 || r.StatusCodes["503"] != 2 || r.Errors["timeout"] != 2 {
+		t.Errorf("status codes, errors = %v, %v, want summed", r.StatusCodes, r.Errors)
+	}
+	if len(r.Labels) != 1 || r.Labels["bench"] != "h2" {
+		t.Errorf("labels = %v, want only the common bench=h2", r.Labels)
+	}
+
+	all := mergeShard(append(append([]float64(nil), fast...), slow...), 0, 0)
+	totals := all.Heatmap.Totals()
+	for _, c := range []struct {
+		name string
+		got  float64
+		q    float64
+	}{{"p95_ms", r.P95ms, 0.95}, {"p99_ms", r.P99ms, 0.99}} {
+		want, _ := stats.InterpolatedBucketQuantile(all.Heatmap.Bounds, totals, c.q)
+		if math.Abs(c.got-want) > 1e-5 {
+			t.Errorf("%s = %v, want %v from the summed heatmap", c.name, c.got, want)
+		}
+	}
+	// 합친 200개 배치의 p95는 25-50ms 버킷에 든다
+	if r.P95ms <= 25 {
+		t.Errorf("p95_ms = %v, want > 25 (pooled tail)", r.P95ms)
+	}
+}
+
+func TestMergeResultsPooledBytesPerSpanSD(t *testing.T) {
+	// 배치별 bytes/span: shard 1 {10, 12}, shard 2 {20, 22, 24}(배치당 10 span)
+	samples := [][]float64{{10, 12}, {20, 22, 24}}
+	var shards []Result
+	var pooled []float64
+	for _, xs := range samples {
+		var bytes int64
+		for _, x := range xs {
+			bytes += int64(x * 10)
+		}
+		s := mergeShard(repeat(3, len(xs)), 10*len(xs), bytes)
+		s.Volume.BytesPerSpanSD = sampleStdDev(xs)
+		shards = append(shards, s)
+		pooled = append(pooled, xs...)
+	}
+	r, err := MergeResults(shards)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := stats.Round5(sampleStdDev(pooled)); math.Abs(r.Volume.BytesPerSpanSD-want) > 1e-5 {
+		t.Errorf("bytes_per_span_sd = %v, want %v (SD of all batches)", r.Volume.BytesPerSpanSD, want)
+	}
+}
+
+func sampleStdDev(xs []float64) float64 {
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	mean := sum / float64(len(xs))
+	var ss float64
+	for _, x := range xs {
+		ss += (x - mean) * (x - mean)
+	}
+	return math.Sqrt(ss / float64(len(xs)-1))
+}
+
+func TestMergeResultsRejects(t *testing.T) {
+	ok := func() Result { return mergeShard([]float64{3, 8}, 20, 2000) }
+	tests := []struct {
+		name  string
+		shard func(*Result)
+		want  string
+	}{
+		{"no volume", func(r *Result) { r.Volume = nil }, "no volume stats"},
+		{"no heatmap", func(r *Result) { r.Heatmap = nil }, "no latency heatmap"},
+		{"heatmap bounds", func(r *Result) { r.Heatmap = stats.NewHeatmap(time.Second, []float64{5, 10, 100}) }, "heatmap interval/buckets differ"},
+		{"heatmap interval", func(r *Result) { r.Heatmap = stats.NewHeatmap(5*time.Second, r.Heatmap.Bounds) }, "heatmap interval/buckets differ"},
+		{"protocol", func(r *Result) { r.Protocol = ProtoH1 }, "protocol"},
+		{"soak", func(r *Result) { r.Soak = &SoakReport{} }, "soak/chaos"},
+		{"chaos", func(r *Result) { r.Chaos = &ChaosReport{} }, "soak/chaos"},
+		{"mttr", func(r *Result) { r.MTTR = &MTTRReport{} }, "soak/chaos"},
+	}
+	for _, tt := range tests {
+		bad := ok()
+		tt.shard(&bad)
+		_, err := MergeResults([]Result{ok(), bad})
+		if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "shard 2") {
+			t.Errorf("%s: MergeResults = %v, want shard 2 error containing %q", tt.name, err, tt.want)
+		}
+	}
+	if _, err := MergeResults(nil); err == nil {
+		t.Error("MergeResults(nil) = nil, want error")
+	}
+}
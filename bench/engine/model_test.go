@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+func estimate(t *testing.T, m Model, sampling float64, ser, comp string) Result {
+	t.Helper()
+	r, err := m.Estimate(Config{Sampling: sampling, Serialization: ser, Compression: comp})
+	if err != nil {
+		t.Fatalf("Estimate(%v, %s, %s): %v", sampling, ser, comp, err)
+	}
+	return r
+}
+
+// fraction maps an arbitrary float64 onto [0,1].
+func fraction(x float64) float64 {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return 0
+	}
+	return math.Abs(math.Mod(x, 1))
+}
+
+// checkModel은 추정기를 교체(보정 모델 등)해도 지켜야 하는 성질을 검사한다
+func checkModel(t *testing.T, m Model) {
+	t.Run("finite", func(t *testing.T) {
+		f := func(x float64, si, ci uint8) bool {
+			ser, comp := Serializations[int(si)%len(Serializations)], Compressions[int(ci)%len(Compressions)]
+			for _, v := range estimate(t, m, fraction(x), ser, comp).Metrics() {
+				if math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+					return false
+				}
+			}
+			return true
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("size grows with sampling", func(t *testing.T) {
+		f := func(a, b float64, si, ci uint8) bool {
+			lo, hi := fraction(a), fraction(b)
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			ser, comp := Serializations[int(si)%len(Serializations)], Compressions[int(ci)%len(Compressions)]
+			return estimate(t, m, lo, ser, comp).SizeKB <= estimate(t, m, hi, ser, comp).SizeKB
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("protobuf no larger than json", func(t *testing.T) {
+		f := func(x float64, ci uint8) bool {
+			s, comp := fraction(x), Compressions[int(ci)%len(Compressions)]
+			return estimate(t, m, s, "protobuf", comp).SizeKB <= estimate(t, m, s, "json", comp).SizeKB
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("compression no larger than none", func(t *testing.T) {
+		f := func(x float64, si, ci uint8) bool {
+			s, ser := fraction(x), Serializations[int(si)%len(Serializations)]
+			comp := Compressions[int(ci)%len(Compressions)]
+			return estimate(t, m, s, ser, comp).SizeKB <= estimate(t, m, s, ser, "none").SizeKB
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("case insensitive", func(t *testing.T) {
+		f := func(x float64) bool {
+			return reflect.DeepEqual(estimate(t, m, fraction(x), "JSON", "Gzip"), estimate(t, m, fraction(x), "json", "gzip"))
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestDefaultModelProperties(t *testing.T) {
+	if err := DefaultModel.(Coefficients).Validate(); err != nil {
+		t.Fatal(err)
+	}
+	checkModel(t, DefaultModel)
+}
+
+func TestCoefficientsValidate(t *testing.T) {
+	c := DefaultModel.(Coefficients)
+	c.SerSize = map[string]float64{"json": 1}
+	if err := c.Validate(); err == nil {
+		t.Error("accepted coefficients without msgpack/protobuf size multipliers")
+	}
+	c = DefaultModel.(Coefficients)
+	c.Size.Slope = math.NaN()
+	if err := c.Validate(); err == nil {
+		t.Error("accepted NaN slope")
+	}
+}
+
+func TestCalibrateRecoversDefault(t *testing.T) {
+	def := DefaultModel.(Coefficients)
+	var obs []Observation
+	for _, s := range []float64{0.1, 0.5, 1} {
+		for _, ser := range Serializations {
+			for _, comp := range Compressions {
+				r := estimate(t, def, s, ser, comp)
+				obs = append(obs, Observation{Sampling: s, Serialization: ser, Compression: comp, P95ms: r.P95ms, ErrorRate: r.ErrorRate, SizeKB: r.SizeKB})
+			}
+		}
+	}
+	mf, err := Calibrate(obs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mf.Default) != 0 {
+		t.Errorf("Default = %v, want none (every value measured)", mf.Default)
+	}
+	for name, e := range mf.Error {
+		if e > 0.001 {
+			t.Errorf("%s fit error %v on noise-free observations", name, e)
+		}
+	}
+	for _, o := range obs {
+		r := estimate(t, mf.Coefficients, o.Sampling, o.Serialization, o.Compression)
+		if math.Abs(r.SizeKB-o.SizeKB) > 0.05 || math.Abs(r.P95ms-o.P95ms) > 0.05 || math.Abs(r.ErrorRate-o.ErrorRate) > 1e-5 {
+			t.Errorf("calibrated %+v, measured %+v", r, o)
+		}
+	}
+	checkModel(t, mf.Coefficients)
+}
+
+func TestCalibrateKeepsUnmeasuredDefaults(t *testing.T) {
+	obs := []Observation{
+		{Sampling: 1, Serialization: "json", Compression: "none", P95ms: 10, SizeKB: 50},
+		{Sampling: 1, Serialization: "protobuf", Compression: "none", P95ms: 9, SizeKB: 30},
+	}
+	mf, err := Calibrate(obs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"serialization_p95.msgpack", "compression_p95.gzip", "compression_p95.zstd",
+		"serialization_size.msgpack", "compression_size.gzip", "compression_size.zstd"}
+	if !reflect.DeepEqual(mf.Default, want) {
+		t.Errorf("Default = %v, want %v", mf.Default, want)
+	}
+	if r := estimate(t, mf.Coefficients, 1, "protobuf", "none"); r.SizeKB != 30 || r.P95ms != 9 {
+		t.Errorf("protobuf/none = %+v, want the measured 9ms/30KB", r)
+	}
+}
@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// DefaultMTTRWindow is the evaluation window used for recovery detection.
+const DefaultMTTRWindow = time.Second
+
+// MTTROptions enables recovery-time measurement: after the first chaos
+// fault, the run is split into windows and the target counts as recovered
+// at the first window that meets the SLO after one that did not.
+type MTTROptions struct {
+	P95       time.Duration
+	ErrorRate float64
+	Window    time.Duration
+}
+
+// ParseMTTRSLO parses "p95=50ms,error_rate=0.01" (either key may be omitted).
+func ParseMTTRSLO(s string) (MTTROptions, error) {
+	o := MTTROptions{ErrorRate: 1, Window: DefaultMTTRWindow}
+	for _, kv := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(kv), "=")
+		if !ok {
+			return MTTROptions{}, fmt.Errorf("invalid mttr-slo: %s (expected p95=<dur>,error_rate=<0..1>)", s)
+		}
+		switch k {
+		case "p95":
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return MTTROptions{}, fmt.Errorf("invalid mttr-slo p95: %s", v)
+			}
+			o.P95 = d
+		case "error_rate":
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f < 0 || f > 1 {
+				return MTTROptions{}, fmt.Errorf("invalid mttr-slo error_rate: %s", v)
+			}
+			o.ErrorRate = f
+		default:
+			return MTTROptions{}, fmt.Errorf("invalid mttr-slo key: %s", k)
+		}
+	}
+	return o, nil
+}
+
+// MTTRReport is the mttr section of a result.
+type MTTRReport struct {
+	SLOP95ms     float64 `json:"slo_p95_ms,omitempty"`
+	SLOErrorRate float64 `json:"slo_error_rate"`
+	WindowS      float64 `json:"window_s"`
+	FaultAtS     float64 `json:"fault_at_s"`
+	Degraded     bool    `json:"degraded"`
+	DegradedAtS  float64 `json:"degraded_at_s,omitempty"`
+	Recovered    bool    `json:"recovered"`
+	RecoveredAtS float64 `json:"recovered_at_s,omitempty"`
+	// MTTRSeconds는 fault 주입부터 SLO 복귀 윈도 시작까지(미복구 시 생략)
+	MTTRSeconds *float64 `json:"mttr_seconds,omitempty"`
+}
+
+// mttrSample은 controller 시작 기준 요청 1건
+type mttrSample struct {
+	at     time.Duration
+	d      time.Duration
+	failed bool
+}
+
+// evalMTTR는 fault 이후 샘플을 윈도로 나눠 이탈→복귀 시점을 찾는다
+func evalMTTR(o MTTROptions, faultAt time.Duration, samples []mttrSample) *MTTRReport {
+	rep := &MTTRReport{
+		SLOP95ms:     stats.Round5(float64(o.P95) / float64(time.Millisecond)),
+		SLOErrorRate: o.ErrorRate,
+		WindowS:      o.Window.Seconds(),
+		FaultAtS:     stats.Round2(faultAt.Seconds()),
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].at < samples[j].at })
+	i := sort.Search(len(samples), func(i int) bool { return samples[i].at >= faultAt })
+	for lo := faultAt; i < len(samples); lo += o.Window {
+		hi := lo + o.Window
+		var ms []float64
+		failed := 0
+		for ; i < len(samples) && samples[i].at < hi; i++ {
+			ms = append(ms, float64(samples[i].d)/float64(time.Millisecond))
+			if samples[i].failed {
+				failed++
+			}
+		}
+		if len(ms) == 0 {
+			continue // 샘플 없는 윈도(요청이 블록됨)는 판정 보류
+		}
+		sort.Float64s(ms)
+		ok := float64(failed)/float64(len(ms)) <= o.ErrorRate &&
+			(o.P95 == 0 || stats.Percentile(ms, 0.95) <= float64(o.P95)/float64(time.Millisecond))
+		switch {
+		case !ok && !rep.Degraded:
+			rep.Degraded, rep.DegradedAtS = true, stats.Round2(lo.Seconds())
+		case ok && rep.Degraded:
+			rep.Recovered, rep.RecoveredAtS = true, stats.Round2(lo.Seconds())
+			mttr := stats.Round2((lo - faultAt).Seconds())
+			rep.MTTRSeconds = &mttr
+			return rep
+		}
+	}
+	if !rep.Degraded && len(samples) > 0 {
+		// 이탈이 관측되지 않음: 영향 없음으로 간주
+		zero := 0.0
+		rep.Recovered, rep.MTTRSeconds = true, &zero
+	}
+	return rep
+}
@@ -0,0 +1,275 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 최소 NATS 클라이언트(텍스트 프로토콜): CONNECT/PING, PUB, SUB/MSG.
+// acks=stream이면 JetStream이 subject를 잡고 있다고 보고 reply inbox로
+// PubAck({"stream":..,"seq":..})을 기다린다. payload 앞 8바이트가 보낸 시각(unix ns)
+
+// natsConn은 연결 하나와 수신 루프
+type natsConn struct {
+	c       net.Conn
+	w       *bufio.Writer
+	wmu     sync.Mutex
+	timeout time.Duration
+	// onMsg는 MSG 수신마다(subject, payload). 수신 goroutine에서 불린다
+	onMsg  func(subject string, payload []byte)
+	pong   chan struct{}
+	done   chan struct{}
+	err    atomic.Value // error
+	closed atomic.Bool
+}
+
+func dialNATS(ctx context.Context, addr string, timeout time.Duration, onMsg func(string, []byte)) (*natsConn, error) {
+	addr = strings.TrimPrefix(addr, "nats://")
+	d := net.Dialer{Timeout: timeout}
+	c, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	n := &natsConn{c: c, w: bufio.NewWriterSize(c, 64<<10), timeout: timeout, onMsg: onMsg, pong: make(chan struct{}, 1), done: make(chan struct{})}
+	r := bufio.NewReaderSize(c, 64<<10)
+	c.SetReadDeadline(time.Now().Add(timeout))
+	line, err := r.ReadString('\n')
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		c.Close()
+		return nil, fmt.Errorf("nats: unexpected greeting %q", strings.TrimSpace(line))
+	}
+	c.SetReadDeadline(time.Time{})
+	go n.readLoop(r)
+	n.write(func(w *bufio.Writer) {
+		w.WriteString(`CONNECT {"verbose":false,"pedantic":false,"lang":"go","name":"trace_bench"}` + "\r\n")
+	})
+	if err := n.ping(ctx); err != nil {
+		n.Close()
+		return nil, err
+	}
+	return n, nil
+}
+
+// write는 쓰기 잠금 아래에서 fn을 쓰고 flush
+func (n *natsConn) write(fn func(w *bufio.Writer)) error {
+	n.wmu.Lock()
+	defer n.wmu.Unlock()
+	n.c.SetWriteDeadline(time.Now().Add(n.timeout))
+	fn(n.w)
+	return n.w.Flush()
+}
+
+// ping은 PING/PONG 왕복(서버가 앞선 명령을 모두 처리했음을 보장)
+func (n *natsConn) ping(ctx context.Context) error {
+	if err := n.write(func(w *bufio.Writer) { w.WriteString("PING\r\n") }); err != nil {
+		return err
+	}
+	select {
+	case <-n.pong:
+		return nil
+	case <-n.done:
+		return n.failure()
+	case <-time.After(n.timeout):
+		return fmt.Errorf("nats: ping: %w", context.DeadlineExceeded)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (n *natsConn) failure() error {
+	if err, ok := n.err.Load().(error); ok {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
+
+func (n *natsConn) readLoop(r *bufio.Reader) {
+	defer close(n.done)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if !n.closed.Load() {
+				n.err.Store(err)
+			}
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			f := strings.Fields(line)
+			size, err := strconv.Atoi(f[len(f)-1])
+			if err != nil || len(f) < 4 {
+				n.err.Store(fmt.Errorf("nats: bad MSG line %q", line))
+				return
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				n.err.Store(err)
+				return
+			}
+			if n.onMsg != nil {
+				n.onMsg(f[1], payload[:size])
+			}
+		case line == "PING":
+			n.write(func(w *bufio.Writer) { w.WriteString("PONG\r\n") })
+		case line == "PONG":
+			select {
+			case n.pong <- struct{}{}:
+			default:
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			n.err.Store(brokerError("nats: " + strings.TrimSpace(strings.TrimPrefix(line, "-ERR"))))
+			n.c.Close()
+			return
+		}
+	}
+}
+
+func (n *natsConn) Close() error {
+	n.closed.Store(true)
+	return n.c.Close()
+}
+
+// natsProducer는 core PUB(acks=none) 또는 JetStream PubAck 대기(acks=stream)
+type natsProducer struct {
+	conn    *natsConn
+	subject string
+	stream  bool
+	inbox   string
+	seq     int
+	acks    chan error
+}
+
+func newNATSProducer(ctx context.Context, addr, subject string, stream bool, timeout time.Duration) (*natsProducer, error) {
+	p := &natsProducer{subject: subject, stream: stream, acks: make(chan error, 1024),
+		inbox: "_INBOX.trace_bench." + strconv.FormatInt(rand.Int63(), 36)}
+	conn, err := dialNATS(ctx, addr, timeout, func(_ string, payload []byte) {
+		var ack struct {
+			Stream string `json:"stream"`
+			Error  *struct {
+				Description string `json:"description"`
+			} `json:"error"`
+		}
+		err := json.Unmarshal(payload, &ack)
+		switch {
+		case err != nil:
+			err = fmt.Errorf("nats: decode pub ack: %w", err)
+		case ack.Error != nil:
+			err = brokerError("nats: pub ack: " + ack.Error.Description)
+		case ack.Stream == "":
+			err = brokerError("nats: no stream bound to the subject")
+		}
+		p.acks <- err
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.conn = conn
+	if stream {
+		if err := conn.write(func(w *bufio.Writer) { fmt.Fprintf(w, "SUB %s.* 1\r\n", p.inbox) }); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *natsProducer) publish(ctx context.Context, sentAt int64, msgs [][]byte) error {
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(sentAt))
+	err := p.conn.write(func(w *bufio.Writer) {
+		for _, m := range msgs {
+			if p.stream {
+				p.seq++
+				fmt.Fprintf(w, "PUB %s %s.%d %d\r\n", p.subject, p.inbox, p.seq, len(m)+8)
+			} else {
+				fmt.Fprintf(w, "PUB %s %d\r\n", p.subject, len(m)+8)
+			}
+			w.Write(ts[:])
+			w.Write(m)
+			w.WriteString("\r\n")
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if !p.stream {
+		return nil
+	}
+	deadline := time.After(p.conn.timeout)
+	for range msgs {
+		select {
+		case err := <-p.acks:
+			if err != nil {
+				return err
+			}
+		case <-p.conn.done:
+			return p.conn.failure()
+		case <-deadline:
+			return fmt.Errorf("nats: pub ack: %w", context.DeadlineExceeded)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (p *natsProducer) close() error { return p.conn.Close() }
+
+// natsConsumer는 core SUB로 받는다. NATS에는 브로커 offset이 없어 lag는
+// RunQueue가 보낸 수 - 받은 수로 계산한다
+type natsConsumer struct {
+	conn *natsConn
+}
+
+func newNATSConsumer(ctx context.Context, addr, subject string, timeout time.Duration, got func(sentAt int64)) (*natsConsumer, error) {
+	c := &natsConsumer{}
+	conn, err := dialNATS(ctx, addr, timeout, func(_ string, payload []byte) {
+		if len(payload) >= 8 {
+			got(int64(binary.BigEndian.Uint64(payload)))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	if err := conn.write(func(w *bufio.Writer) { fmt.Fprintf(w, "SUB %s 1\r\n", subject) }); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// 구독이 서버에 등록된 뒤 생산을 시작하도록
+	if err := conn.ping(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *natsConsumer) run(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-c.conn.done:
+		return c.conn.failure()
+	}
+}
+
+func (c *natsConsumer) lag() (int64, bool) { return 0, false }
+
+func (c *natsConsumer) close() error { return c.conn.Close() }
@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Netem is a network impairment applied with tc/netem for the whole run
+// (-netem) and recorded in the result.
+type Netem struct {
+	Spec  string `json:"spec"`
+	Dev   string `json:"dev"`
+	Netns string `json:"netns,omitempty"`
+	// Qdisc is the netem argument list handed to tc.
+	Qdisc []string `json:"qdisc"`
+
+	Delay     time.Duration `json:"-"`
+	Jitter    time.Duration `json:"-"`
+	Loss      float64       `json:"-"` // 퍼센트
+	Duplicate float64       `json:"-"`
+	Corrupt   float64       `json:"-"`
+	Reorder   float64       `json:"-"`
+	Rate      string        `json:"-"`
+}
+
+var netemRate = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?([kmg]?(bit|bps))$`)
+
+// ParseNetem parses "delay:50ms,jitter:10ms,loss:0.5%" (also duplicate,
+// corrupt and reorder percentages and rate:10mbit).
+func ParseNetem(spec string) (*Netem, error) {
+	n := &Netem{Spec: spec}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid netem %q: expected key:value", item)
+		}
+		var err error
+		switch k {
+		case "delay", "jitter":
+			d, perr := time.ParseDuration(v)
+			if perr != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid netem %s %q", k, v)
+			}
+			if k == "delay" {
+				n.Delay = d
+			} else {
+				n.Jitter = d
+			}
+		case "loss":
+			n.Loss, err = netemPercent(k, v)
+		case "duplicate":
+			n.Duplicate, err = netemPercent(k, v)
+		case "corrupt":
+			n.Corrupt, err = netemPercent(k, v)
+		case "reorder":
+			n.Reorder, err = netemPercent(k, v)
+		case "rate":
+			if !netemRate.MatchString(strings.ToLower(v)) {
+				return nil, fmt.Errorf("invalid netem rate %q (e.g. 10mbit, 500kbit)", v)
+			}
+			n.Rate = strings.ToLower(v)
+		default:
+			return nil, fmt.Errorf("unknown netem impairment %q (delay, jitter, loss, duplicate, corrupt, reorder, rate)", k)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if n.Jitter > 0 && n.Delay == 0 {
+		return nil, fmt.Errorf("invalid netem %q: jitter requires delay", spec)
+	}
+	if n.Reorder > 0 && n.Delay == 0 {
+		return nil, fmt.Errorf("invalid netem %q: reorder requires delay", spec)
+	}
+	n.Qdisc = n.args()
+	if len(n.Qdisc) == 1 {
+		return nil, fmt.Errorf("invalid netem %q: no impairment", spec)
+	}
+	return n, nil
+}
+
+func netemPercent(k, v string) (float64, error) {
+	p, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+	if err != nil || p < 0 || p > 100 {
+		return 0, fmt.Errorf("invalid netem %s %q (percent 0-100)", k, v)
+	}
+	return p, nil
+}
+
+// args는 tc qdisc ... netem 뒤에 붙는 인자(시간은 tc가 읽는 us 단위)
+func (n *Netem) args() []string {
+	us := func(d time.Duration) string { return strconv.FormatInt(d.Microseconds(), 10) + "us" }
+	pct := func(p float64) string { return strconv.FormatFloat(p, 'f', -1, 64) + "%" }
+	a := []string{"netem"}
+	if n.Delay > 0 {
+		a = append(a, "delay", us(n.Delay))
+		if n.Jitter > 0 {
+			a = append(a, us(n.Jitter))
+		}
+	}
+	for _, p := range []struct {
+		name string
+		v    float64
+	}{{"loss", n.Loss}, {"duplicate", n.Duplicate}, {"corrupt", n.Corrupt}, {"reorder", n.Reorder}} {
+		if p.v > 0 {
+			a = append(a, p.name, pct(p.v))
+		}
+	}
+	if n.Rate != "" {
+		a = append(a, "rate", n.Rate)
+	}
+	return a
+}
@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// OTLP/HTTP JSON(ExportTraceServiceRequest). 저장소 벤치가 Tempo/Jaeger에 그대로 보낸다
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKV `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []otlpKV   `json:"attributes"`
+	Status            otlpStatus `json:"status"`
+}
+
+type otlpKV struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code int `json:"code,omitempty"` // 2 = ERROR
+}
+
+const (
+	otlpScopeName    = "trace_bench"
+	otlpKindInternal = 1
+	otlpStatusError  = 2
+)
+
+// spanService는 service.name 속성(없으면 "unknown")
+func spanService(s span) string {
+	for _, a := range s.Attrs {
+		if a.Key == "service.name" {
+			return a.Value
+		}
+	}
+	return "unknown"
+}
+
+// spanFailed는 합성 span의 status 속성이 error인지
+func spanFailed(s span) bool {
+	for _, a := range s.Attrs {
+		if a.Key == "status" {
+			return a.Value == "error"
+		}
+	}
+	return false
+}
+
+// encodeOTLP는 배치를 service.name별 resourceSpans로 묶어 OTLP JSON으로
+func encodeOTLP(spans []span) ([]byte, error) {
+	var req otlpRequest
+	idx := map[string]int{}
+	for _, s := range spans {
+		svc := spanService(s)
+		i, ok := idx[svc]
+		if !ok {
+			i = len(req.ResourceSpans)
+			idx[svc] = i
+			req.ResourceSpans = append(req.ResourceSpans, otlpResourceSpans{
+				Resource:   otlpResource{Attributes: []otlpKV{{"service.name", otlpValue{svc}}}},
+				ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: otlpScopeName}}},
+			})
+		}
+		o := otlpSpan{
+			TraceID:           hex.EncodeToString(s.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.SpanID[:]),
+			Name:              s.Name,
+			Kind:              otlpKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.Start, 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End, 10),
+		}
+		if s.ParentID != ([8]byte{}) {
+			o.ParentSpanID = hex.EncodeToString(s.ParentID[:])
+		}
+		for _, a := range s.Attrs {
+			if a.Key != "service.name" {
+				o.Attributes = append(o.Attributes, otlpKV{a.Key, otlpValue{a.Value}})
+			}
+		}
+		if spanFailed(s) {
+			o.Status.Code = otlpStatusError
+		}
+		ss := &req.ResourceSpans[i].ScopeSpans[0]
+		ss.Spans = append(ss.Spans, o)
+	}
+	return json.Marshal(req)
+}
+
+// rebaseSpans는 고정 seed의 시각을 지금 직전으로 옮긴다(백엔드 보존 기간/검색 창 안에 들도록)
+func rebaseSpans(spans []span, now time.Time) {
+	var last int64
+	for _, s := range spans {
+		last = max(last, s.End)
+	}
+	shift := now.UnixNano() - last
+	for i := range spans {
+		spans[i].Start += shift
+		spans[i].End += shift
+	}
+}
@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"sync"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// ProcSampleInterval is how often the target process is sampled for its
+// peak resident memory during the measure phase.
+const ProcSampleInterval = 250 * time.Millisecond
+
+// ProcessStats is the resource use of the target process (Config.TargetPID)
+// during the measure phase. The columns are the same on every OS; Source
+// names the reader: procfs (Linux), pdh (Windows) or libproc (macOS).
+type ProcessStats struct {
+	PID        int     `json:"pid"`
+	Source     string  `json:"source"`
+	CPUSeconds float64 `json:"cpu_seconds"` // user+system
+	// CPUPercent is CPUSeconds per wall-clock second (100 = one core).
+	CPUPercent   float64 `json:"cpu_pct"`
+	RSSBytes     int64   `json:"rss_bytes"` // at the end of the phase
+	PeakRSSBytes int64   `json:"peak_rss_bytes"`
+	Samples      int     `json:"samples"`
+}
+
+// procReader reads a process's cumulative CPU time and resident memory.
+// newProcReader (proc_<os>.go) opens one for a pid and names its source.
+type procReader interface {
+	read() (procSample, error)
+	close()
+}
+
+type procSample struct {
+	cpu time.Duration
+	rss int64
+}
+
+// procWatch는 측정 구간 동안 대상 프로세스를 주기적으로 읽어 최대 RSS를 잡는다
+type procWatch struct {
+	pid    int
+	source string
+	r      procReader
+	start  time.Time
+	first  procSample
+	stopc  chan struct{}
+	done   sync.WaitGroup
+
+	mu      sync.Mutex
+	peak    int64
+	samples int
+}
+
+// openProcWatch는 측정 전에 대상 프로세스를 한 번 읽어 본다(없는 pid 등은 setup 오류)
+func openProcWatch(pid int) (*procWatch, error) {
+	r, source, err := newProcReader(pid)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.read(); err != nil {
+		r.close()
+		return nil, err
+	}
+	return &procWatch{pid: pid, source: source, r: r, stopc: make(chan struct{})}, nil
+}
+
+func (w *procWatch) begin() {
+	w.start = time.Now()
+	w.first, _ = w.r.read()
+	w.observe(w.first)
+	w.done.Add(1)
+	go func() {
+		defer w.done.Done()
+		t := time.NewTicker(ProcSampleInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-w.stopc:
+				return
+			case <-t.C:
+				if s, err := w.r.read(); err == nil {
+					w.observe(s)
+				}
+			}
+		}
+	}()
+}
+
+func (w *procWatch) observe(s procSample) {
+	w.mu.Lock()
+	w.peak = max(w.peak, s.rss)
+	w.samples++
+	w.mu.Unlock()
+}
+
+// end는 샘플링을 멈추고 구간의 통계를 낸다. 대상이 사라졌으면 nil
+func (w *procWatch) end() *ProcessStats {
+	close(w.stopc)
+	w.done.Wait()
+	defer w.r.close()
+	last, err := w.r.read()
+	if err != nil {
+		return nil
+	}
+	w.observe(last)
+	elapsed := time.Since(w.start).Seconds()
+	cpu := max(last.cpu-w.first.cpu, 0).Seconds()
+	return &ProcessStats{
+		PID:          w.pid,
+		Source:       w.source,
+		CPUSeconds:   stats.Round5(cpu),
+		CPUPercent:   stats.Round2(100 * cpu / max(elapsed, 1e-9)),
+		RSSBytes:     last.rss,
+		PeakRSSBytes: w.peak,
+		Samples:      w.samples,
+	}
+}
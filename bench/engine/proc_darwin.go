@@ -0,0 +1,42 @@
+//go:build darwin && cgo
+
+package engine
+
+/*
+#include <libproc.h>
+#include <mach/mach_time.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+// libprocReader는 proc_pidinfo(PROC_PIDTASKINFO)의 누적 user/system 시간과
+// resident size를 읽는다. 시간은 mach absolute time 단위라 timebase로 ns로 바꾼다
+type libprocReader struct {
+	pid      C.int
+	timebase float64 // ns per mach tick
+}
+
+func newProcReader(pid int) (procReader, string, error) {
+	var tb C.mach_timebase_info_data_t
+	if C.mach_timebase_info(&tb) != 0 || tb.denom == 0 {
+		return nil, "", fmt.Errorf("mach_timebase_info failed")
+	}
+	return libprocReader{pid: C.int(pid), timebase: float64(tb.numer) / float64(tb.denom)}, "libproc", nil
+}
+
+func (r libprocReader) read() (procSample, error) {
+	var ti C.struct_proc_taskinfo
+	size := C.int(unsafe.Sizeof(ti))
+	if n, err := C.proc_pidinfo(r.pid, C.PROC_PIDTASKINFO, 0, unsafe.Pointer(&ti), size); n != size {
+		return procSample{}, fmt.Errorf("proc_pidinfo %d: %v", r.pid, err)
+	}
+	ticks := float64(ti.pti_total_user) + float64(ti.pti_total_system)
+	return procSample{cpu: time.Duration(ticks * r.timebase), rss: int64(ti.pti_resident_size)}, nil
+}
+
+func (libprocReader) close() {}
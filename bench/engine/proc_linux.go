@@ -0,0 +1,57 @@
+//go:build linux
+
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// clockTicks는 /proc/<pid>/stat의 USER_HZ(리눅스 ABI에서 고정 100)
+const clockTicks = 100
+
+// procfsReader는 /proc/<pid>/stat(utime, stime)과 statm(resident)을 읽는다
+type procfsReader struct{ dir string }
+
+func newProcReader(pid int) (procReader, string, error) {
+	return procfsReader{dir: fmt.Sprintf("/proc/%d", pid)}, "procfs", nil
+}
+
+func (r procfsReader) read() (procSample, error) {
+	b, err := os.ReadFile(r.dir + "/stat")
+	if err != nil {
+		return procSample{}, err
+	}
+	// comm은 공백·괄호를 포함할 수 있어 마지막 ')' 뒤부터 센다(state가 3번째 필드)
+	i := bytes.LastIndexByte(b, ')')
+	f := bytes.Fields(b[i+1:])
+	if i < 0 || len(f) < 13 {
+		return procSample{}, fmt.Errorf("%s/stat: unexpected format", r.dir)
+	}
+	utime, err1 := strconv.ParseInt(string(f[11]), 10, 64)
+	stime, err2 := strconv.ParseInt(string(f[12]), 10, 64)
+	if err1 != nil || err2 != nil {
+		return procSample{}, fmt.Errorf("%s/stat: unexpected format", r.dir)
+	}
+	m, err := os.ReadFile(r.dir + "/statm")
+	if err != nil {
+		return procSample{}, err
+	}
+	mf := bytes.Fields(m)
+	if len(mf) < 2 {
+		return procSample{}, fmt.Errorf("%s/statm: unexpected format", r.dir)
+	}
+	pages, err := strconv.ParseInt(string(mf[1]), 10, 64)
+	if err != nil {
+		return procSample{}, fmt.Errorf("%s/statm: unexpected format", r.dir)
+	}
+	return procSample{
+		cpu: time.Duration(utime+stime) * time.Second / clockTicks,
+		rss: pages * int64(os.Getpagesize()),
+	}, nil
+}
+
+func (procfsReader) close() {}
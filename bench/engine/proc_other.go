@@ -0,0 +1,16 @@
+//go:build !linux && !windows && !(darwin && cgo)
+
+package engine
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// newProcReader: macOS는 libproc을 cgo로 부르므로 CGO_ENABLED=0 빌드에서는 지원하지 않는다
+func newProcReader(int) (procReader, string, error) {
+	if runtime.GOOS == "darwin" {
+		return nil, "", fmt.Errorf("target process metrics on darwin need a cgo build (libproc)")
+	}
+	return nil, "", fmt.Errorf("target process metrics are not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestTargetProcess(t *testing.T) {
+	if _, _, err := newProcReader(os.Getpid()); err != nil {
+		t.Skip(err)
+	}
+	// 자기 자신을 대상으로 하면 측정 구간의 CPU가 반드시 잡힌다
+	cfg := Config{Mode: ModeReal, Sampling: 1, Serialization: "json", Compression: "gzip", Spans: 20000, TargetPID: os.Getpid()}
+	r, err := New().Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := r.TargetProc
+	if p == nil || p.PID != os.Getpid() || p.CPUSeconds <= 0 || p.RSSBytes <= 0 || p.PeakRSSBytes < p.RSSBytes || p.Samples < 2 {
+		t.Fatalf("target_process = %+v", p)
+	}
+	if runtime.GOOS == "linux" && p.Source != "procfs" {
+		t.Errorf("source = %q, want procfs", p.Source)
+	}
+
+	cfg.TargetPID = 1 << 30
+	if _, err := New().Run(context.Background(), cfg); err == nil {
+		t.Error("missing target process accepted")
+	}
+	cfg.Mode, cfg.TargetPID = ModeModel, os.Getpid()
+	if err := cfg.Validate(); err == nil {
+		t.Error("target pid accepted in model mode")
+	}
+}
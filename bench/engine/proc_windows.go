@@ -0,0 +1,172 @@
+//go:build windows
+
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	pdh                        = syscall.NewLazyDLL("pdh.dll")
+	procPdhOpenQuery           = pdh.NewProc("PdhOpenQueryW")
+	procPdhAddEnglishCounter   = pdh.NewProc("PdhAddEnglishCounterW")
+	procPdhCollectQueryData    = pdh.NewProc("PdhCollectQueryData")
+	procPdhGetRawCounterValue  = pdh.NewProc("PdhGetRawCounterValue")
+	procPdhCloseQuery          = pdh.NewProc("PdhCloseQuery")
+	kernel32                   = syscall.NewLazyDLL("kernel32.dll")
+	procQueryFullProcessImageW = kernel32.NewProc("QueryFullProcessImageNameW")
+)
+
+const (
+	processQueryLimitedInformation = 0x1000
+	// PDH 인스턴스 이름은 같은 이미지가 여럿이면 name#1, name#2...
+	pdhMaxInstances = 64
+)
+
+// pdhRawCounter는 PDH_RAW_COUNTER(FirstValue가 8바이트 경계에 오도록 패딩)
+type pdhRawCounter struct {
+	CStatus     uint32
+	TimeStamp   [2]uint32
+	_           uint32
+	FirstValue  int64
+	SecondValue int64
+	MultiCount  uint32
+	_           uint32
+}
+
+// pdhReader는 \Process(<instance>)의 % Processor Time(원시값: 100ns 누적)과
+// Working Set을 읽는다. 인스턴스 이름은 같은 이미지의 프로세스가 끝나면 바뀌므로
+// ID Process로 확인하고 어긋나면 다시 찾는다
+type pdhReader struct {
+	pid, image string
+	query      uintptr
+	id, cpu    uintptr // counter handles
+	rss        uintptr
+}
+
+func newProcReader(pid int) (procReader, string, error) {
+	image, err := processImage(pid)
+	if err != nil {
+		return nil, "", err
+	}
+	r := &pdhReader{pid: fmt.Sprint(pid), image: image}
+	if err := r.resolve(); err != nil {
+		r.close()
+		return nil, "", err
+	}
+	return r, "pdh", nil
+}
+
+// processImage는 PDH 인스턴스의 기본 이름(확장자 없는 실행 파일 이름)
+func processImage(pid int) (string, error) {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return "", fmt.Errorf("process %d: %w", pid, err)
+	}
+	defer syscall.CloseHandle(h)
+	buf := make([]uint16, 32768) // 긴 경로(\\?\) 최대
+	n := uint32(len(buf))
+	if ok, _, err := procQueryFullProcessImageW.Call(uintptr(h), 0, uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&n))); ok == 0 {
+		return "", fmt.Errorf("process %d image: %w", pid, err)
+	}
+	base := filepath.Base(syscall.UTF16ToString(buf[:n]))
+	return strings.TrimSuffix(base, filepath.Ext(base)), nil
+}
+
+// resolve는 ID Process가 pid인 인스턴스를 찾아 카운터를 새 쿼리에 붙인다
+func (r *pdhReader) resolve() error {
+	r.close()
+	for i := 0; i < pdhMaxInstances; i++ {
+		inst := r.image
+		if i > 0 {
+			inst = fmt.Sprintf("%s#%d", r.image, i)
+		}
+		if err := r.open(inst); err != nil {
+			r.close()
+			continue
+		}
+		if id, err := r.raw(r.id); err == nil && fmt.Sprint(id) == r.pid {
+			return nil
+		}
+		r.close()
+	}
+	return fmt.Errorf("process %s: no PDH \\Process(%s) instance", r.pid, r.image)
+}
+
+func (r *pdhReader) open(inst string) error {
+	if err := pdhCall(procPdhOpenQuery, 0, 0, uintptr(unsafe.Pointer(&r.query))); err != nil {
+		return err
+	}
+	for _, c := range []struct {
+		counter string
+		h       *uintptr
+	}{{"ID Process", &r.id}, {"% Processor Time", &r.cpu}, {"Working Set", &r.rss}} {
+		path, err := syscall.UTF16PtrFromString(`\Process(` + inst + `)\` + c.counter)
+		if err != nil {
+			return err
+		}
+		if err := pdhCall(procPdhAddEnglishCounter, r.query, uintptr(unsafe.Pointer(path)), 0, uintptr(unsafe.Pointer(c.h))); err != nil {
+			return err
+		}
+	}
+	return pdhCall(procPdhCollectQueryData, r.query)
+}
+
+func (r *pdhReader) raw(counter uintptr) (int64, error) {
+	var v pdhRawCounter
+	if err := pdhCall(procPdhGetRawCounterValue, counter, 0, uintptr(unsafe.Pointer(&v))); err != nil {
+		return 0, err
+	}
+	// PDH_CSTATUS_VALID_DATA(0), PDH_CSTATUS_NEW_DATA(1)
+	if v.CStatus > 1 {
+		return 0, fmt.Errorf("pdh counter status 0x%x", v.CStatus)
+	}
+	return v.FirstValue, nil
+}
+
+func (r *pdhReader) read() (procSample, error) {
+	if r.query == 0 {
+		if err := r.resolve(); err != nil {
+			return procSample{}, err
+		}
+	} else if err := pdhCall(procPdhCollectQueryData, r.query); err != nil {
+		return procSample{}, err
+	}
+	if id, err := r.raw(r.id); err != nil || fmt.Sprint(id) != r.pid {
+		if err := r.resolve(); err != nil {
+			return procSample{}, err
+		}
+	}
+	cpu, err := r.raw(r.cpu)
+	if err != nil {
+		return procSample{}, err
+	}
+	rss, err := r.raw(r.rss)
+	if err != nil {
+		return procSample{}, err
+	}
+	return procSample{cpu: time.Duration(cpu) * 100, rss: rss}, nil
+}
+
+func (r *pdhReader) close() {
+	if r.query != 0 {
+		procPdhCloseQuery.Call(r.query)
+		r.query = 0
+	}
+}
+
+// pdhCall은 PDH_STATUS(0이 성공)를 오류로 바꾼다
+func pdhCall(p *syscall.LazyProc, args ...uintptr) error {
+	if err := p.Find(); err != nil {
+		return err
+	}
+	if st, _, _ := p.Call(args...); st != 0 {
+		return fmt.Errorf("%s: PDH status 0x%x", p.Name, uint32(st))
+	}
+	return nil
+}
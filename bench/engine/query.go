@@ -0,0 +1,371 @@
+package engine
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/duri/trace_bench/internal/yamlite"
+	"github.com/duri/trace_bench/stats"
+)
+
+// Query kinds of a query-bench workload (queries.yaml).
+const (
+	QueryTraceID = "trace_id" // fetch one trace by ID
+	QuerySearch  = "search"   // service / duration / tag search
+)
+
+// QueriesVersion is the supported queries.yaml schema version.
+const QueriesVersion = 1
+
+// DefaultQueryDuration is the default measured window of RunQueries.
+const DefaultQueryDuration = 30 * time.Second
+
+// Query is one entry of queries.yaml. Search queries filter on any
+// combination of Service, MinDuration/MaxDuration and Tags; trace_id
+// queries cycle through TraceIDs, or through the traces RunQueries seeded
+// when TraceIDs is empty.
+type Query struct {
+	Name        string            `json:"name"`
+	Kind        string            `json:"kind"`
+	Weight      int               `json:"weight,omitempty"` // share of the mix (default 1)
+	TraceIDs    []string          `json:"trace_ids,omitempty"`
+	Service     string            `json:"service,omitempty"`
+	MinDuration string            `json:"min_duration,omitempty"` // e.g. 20ms
+	MaxDuration string            `json:"max_duration,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Limit       int               `json:"limit,omitempty"` // max traces returned (default 20)
+
+	minDur, maxDur time.Duration
+}
+
+// QuerySet is a parsed queries.yaml.
+type QuerySet struct {
+	Version int     `json:"version"`
+	Queries []Query `json:"queries"`
+}
+
+// LoadQueries reads a .yaml/.yml or .json query set and validates it.
+func LoadQueries(path string) (*QuerySet, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var qs QuerySet
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = yamlite.UnmarshalJSON(b, &qs)
+	default:
+		err = yamlite.Unmarshal(b, &qs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := qs.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &qs, nil
+}
+
+// Validate checks the version, names, kinds, durations and trace IDs.
+func (qs *QuerySet) Validate() error {
+	if qs.Version != QueriesVersion {
+		return fmt.Errorf("unsupported queries version: %d (expected %d)", qs.Version, QueriesVersion)
+	}
+	if len(qs.Queries) == 0 {
+		return fmt.Errorf("no queries")
+	}
+	seen := map[string]bool{}
+	for i := range qs.Queries {
+		q := &qs.Queries[i]
+		if q.Name == "" {
+			return fmt.Errorf("queries[%d]: name is required", i)
+		}
+		if seen[q.Name] {
+			return fmt.Errorf("duplicate query name: %s", q.Name)
+		}
+		seen[q.Name] = true
+		if q.Weight < 0 || q.Limit < 0 {
+			return fmt.Errorf("query %s: weight and limit must be >= 0", q.Name)
+		}
+		switch q.Kind {
+		case QueryTraceID:
+			for _, id := range q.TraceIDs {
+				if b, err := hex.DecodeString(id); err != nil || len(b) != 16 {
+					return fmt.Errorf("query %s: invalid trace id: %q", q.Name, id)
+				}
+			}
+		case QuerySearch:
+			var err error
+			if q.MinDuration != "" {
+				if q.minDur, err = time.ParseDuration(q.MinDuration); err != nil {
+					return fmt.Errorf("query %s: invalid min_duration: %w", q.Name, err)
+				}
+			}
+			if q.MaxDuration != "" {
+				if q.maxDur, err = time.ParseDuration(q.MaxDuration); err != nil {
+					return fmt.Errorf("query %s: invalid max_duration: %w", q.Name, err)
+				}
+			}
+			if q.maxDur > 0 && q.maxDur < q.minDur {
+				return fmt.Errorf("query %s: max_duration below min_duration", q.Name)
+			}
+		default:
+			return fmt.Errorf("query %s: invalid kind: %q (expected %s|%s)", q.Name, q.Kind, QueryTraceID, QuerySearch)
+		}
+	}
+	return nil
+}
+
+// QueryConfig selects a query-path benchmark (RunQueries).
+type QueryConfig struct {
+	Backend  BackendConfig
+	Queries  []Query       // validated (QuerySet.Validate)
+	Workers  int           // concurrent clients (default 1)
+	Duration time.Duration // measured window (default DefaultQueryDuration)
+	// SeedSpans are written before measuring so trace_id queries have
+	// targets and searches have data; 0 queries the existing data only.
+	SeedSpans int
+	BatchSize int           // spans per seed write (default DefaultBatchSize)
+	Settle    time.Duration // wait between seeding and querying
+	Seed      int64         // seed trace generation (0: time-based)
+}
+
+// QueryStats is the latency of one query of the mix.
+type QueryStats struct {
+	Name      string         `json:"name"`
+	Kind      string         `json:"kind"`
+	Requests  int            `json:"requests"`
+	P50ms     float64        `json:"p50_ms"`
+	P95ms     float64        `json:"p95_ms"`
+	P99ms     float64        `json:"p99_ms"`
+	ErrorRate float64        `json:"error_rate"`
+	Errors    map[string]int `json:"errors,omitempty"`
+	// Empty counts successful queries that matched nothing (a trace ID
+	// not found, or a search without hits), which are usually cheaper
+	// than real hits and skew the percentiles.
+	Empty    int     `json:"empty"`
+	MeanHits float64 `json:"mean_hits"`
+}
+
+// QueryResult reports read-path latency of one backend under concurrency.
+type QueryResult struct {
+	Backend     string       `json:"backend"`
+	Workers     int          `json:"workers"`
+	SeededSpans int          `json:"seeded_spans"`
+	DurationS   float64      `json:"duration_s"`
+	Requests    int          `json:"requests"`
+	QPS         float64      `json:"qps"`
+	ErrorRate   float64      `json:"error_rate"`
+	Queries     []QueryStats `json:"queries"`
+}
+
+// Query returns the stats of the named query, or nil.
+func (r QueryResult) Query(name string) *QueryStats {
+	for i := range r.Queries {
+		if r.Queries[i].Name == name {
+			return &r.Queries[i]
+		}
+	}
+	return nil
+}
+
+// queryTally는 worker 하나가 쿼리별로 모은 값
+type queryTally struct {
+	lat   stats.Sketch
+	errs  tally
+	empty int
+	hits  int
+}
+
+// RunQueries seeds the backend (SeedSpans) and then runs the weighted
+// query mix from Workers concurrent clients for Duration.
+func RunQueries(ctx context.Context, cfg QueryConfig) (QueryResult, error) {
+	if len(cfg.Queries) == 0 {
+		return QueryResult{}, fmt.Errorf("no queries")
+	}
+	workers := max(cfg.Workers, 1)
+	dur := cfg.Duration
+	if dur <= 0 {
+		dur = DefaultQueryDuration
+	}
+	be, err := newTraceBackend(cfg.Backend, workers)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	for _, q := range cfg.Queries {
+		if err := checkQuery(cfg, q); err != nil {
+			return QueryResult{}, err
+		}
+	}
+	seeded, err := seedTraces(ctx, be, cfg, workers)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	select {
+	case <-time.After(cfg.Settle):
+	case <-ctx.Done():
+		return QueryResult{}, ctx.Err()
+	}
+
+	// weight만큼 반복한 순서표를 worker들이 공유 카운터로 돈다
+	var mix []int
+	for i, q := range cfg.Queries {
+		for w := 0; w < max(q.Weight, 1); w++ {
+			mix = append(mix, i)
+		}
+	}
+	var next atomic.Int64
+	tallies := make([][]queryTally, workers)
+	runCtx, cancel := context.WithTimeout(ctx, dur)
+	defer cancel()
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		tallies[w] = make([]queryTally, len(cfg.Queries))
+		for i := range tallies[w] {
+			tallies[w][i] = queryTally{lat: stats.NewHDR(), errs: newTally()}
+		}
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for runCtx.Err() == nil {
+				n := next.Add(1) - 1
+				qi := mix[n%int64(len(mix))]
+				q := cfg.Queries[qi]
+				t0 := time.Now()
+				hits, err := runQuery(runCtx, be, q, seeded, n)
+				if runCtx.Err() != nil {
+					// 창이 닫히며 끊긴 요청은 세지 않는다
+					return
+				}
+				t := &tallies[w][qi]
+				t.lat.Record(time.Since(t0))
+				out := exportOutcome{}
+				if err != nil {
+					out.class = backendErrorClass(err)
+				} else if hits == 0 {
+					t.empty++
+				}
+				t.errs.add(out)
+				t.hits += hits
+			}
+		}(w)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	if err := ctx.Err(); err != nil {
+		return QueryResult{}, err
+	}
+
+	r := QueryResult{Backend: cfg.Backend.Kind, Workers: workers, SeededSpans: max(cfg.SeedSpans, 0), DurationS: stats.Round5(elapsed.Seconds())}
+	var failed int
+	for i, q := range cfg.Queries {
+		sketches := make([]stats.Sketch, workers)
+		total := newTally()
+		var empty, hits int
+		for w := range tallies {
+			sketches[w] = tallies[w][i].lat
+			total.merge(tallies[w][i].errs)
+			empty += tallies[w][i].empty
+			hits += tallies[w][i].hits
+		}
+		lat, _ := stats.MergeSketches(sketches...)
+		qs := QueryStats{Name: q.Name, Kind: q.Kind, Requests: total.ops, Errors: total.errorsOrNil(), Empty: empty}
+		if total.ops > 0 {
+			qs.P50ms = stats.Round5(lat.Quantile(0.50))
+			qs.P95ms = stats.Round5(lat.Quantile(0.95))
+			qs.P99ms = stats.Round5(lat.Quantile(0.99))
+			qs.ErrorRate = stats.Round5(float64(total.failed) / float64(total.ops))
+			if ok := total.ops - total.failed; ok > 0 {
+				qs.MeanHits = stats.Round2(float64(hits) / float64(ok))
+			}
+		}
+		r.Requests += total.ops
+		failed += total.failed
+		r.Queries = append(r.Queries, qs)
+	}
+	r.QPS = stats.Round2(float64(r.Requests) / elapsed.Seconds())
+	if r.Requests > 0 {
+		r.ErrorRate = stats.Round5(float64(failed) / float64(r.Requests))
+	}
+	return r, nil
+}
+
+// checkQuery는 백엔드가 표현할 수 없는 쿼리를 미리 거른다
+func checkQuery(cfg QueryConfig, q Query) error {
+	c := cfg.Backend
+	if q.Kind == QuerySearch && c.Kind == BackendJaeger && q.Service == "" {
+		return fmt.Errorf("query %s: jaeger search requires service", q.Name)
+	}
+	if q.Kind == QueryTraceID && len(q.TraceIDs) == 0 && cfg.SeedSpans <= 0 {
+		return fmt.Errorf("query %s: no trace_ids and nothing seeded", q.Name)
+	}
+	if c.Kind != BackendClickHouse && c.QueryURL == "" {
+		return fmt.Errorf("%s queries require a query url", c.Kind)
+	}
+	return nil
+}
+
+// seedTraces는 SeedSpans를 쓰고 trace ID들을 돌려준다
+func seedTraces(ctx context.Context, be traceBackend, cfg QueryConfig, workers int) ([]string, error) {
+	if cfg.SeedSpans <= 0 {
+		return nil, nil
+	}
+	batch := cfg.BatchSize
+	if batch <= 0 {
+		batch = DefaultBatchSize
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	all := genSpans(cfg.SeedSpans, spansPerTrace, seed)
+	rebaseSpans(all, time.Now())
+	var ids []string
+	for i := 0; i < len(all); i += spansPerTrace {
+		ids = append(ids, hex.EncodeToString(all[i].TraceID[:]))
+	}
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w * batch; i < len(all); i += workers * batch {
+				if _, err := be.write(ctx, all[i:min(i+batch, len(all))]); err != nil {
+					errs <- fmt.Errorf("seed write: %w", err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// runQuery는 쿼리 하나를 보내고 찾은 trace 수를 돌려준다
+func runQuery(ctx context.Context, be traceBackend, q Query, seeded []string, n int64) (int, error) {
+	if q.Kind == QuerySearch {
+		return be.search(ctx, q)
+	}
+	ids := q.TraceIDs
+	if len(ids) == 0 {
+		ids = seeded
+	}
+	found, err := be.traceByID(ctx, ids[n%int64(len(ids))])
+	if found {
+		return 1, err
+	}
+	return 0, err
+}
@@ -0,0 +1,400 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// Message brokers for RunQueue (trace_bench queue-bench): the transport of
+// a buffered telemetry pipeline, where exporters produce serialized span
+// batches and collectors consume them.
+const (
+	BrokerKafka = "kafka"
+	BrokerNATS  = "nats"
+)
+
+// Brokers lists the supported brokers.
+var Brokers = []string{BrokerKafka, BrokerNATS}
+
+// Acknowledgement levels per broker. Kafka: "0" (fire and forget), "1"
+// (leader) or "all" (in-sync replicas). NATS: "none" (core publish) or
+// "stream" (JetStream PubAck; a stream must capture the subject).
+var BrokerAcks = map[string][]string{
+	BrokerKafka: {"0", "1", "all"},
+	BrokerNATS:  {"none", "stream"},
+}
+
+// DefaultQueueDrain bounds the wait for in-flight messages after the last
+// produce.
+const DefaultQueueDrain = 10 * time.Second
+
+// QueueConfig selects a broker transport benchmark (RunQueue).
+type QueueConfig struct {
+	Broker  string
+	Addr    string // bootstrap broker host:port (kafka) or server (nats)
+	Topic   string // kafka topic or nats subject
+	Acks    string // see BrokerAcks (default "1" / "none")
+	Timeout time.Duration
+	// Each message is one serialized, compressed batch of BatchSize spans;
+	// ProduceBatch messages go out per produce request (one kafka record
+	// batch, or one nats flush).
+	Spans         int
+	BatchSize     int
+	ProduceBatch  int
+	Workers       int // concurrent producers (kafka: round-robin over partitions)
+	Serialization string
+	Compression   string
+	Drain         time.Duration // default DefaultQueueDrain
+}
+
+// QueueResult reports producer throughput, end-to-end delivery latency and
+// consumer lag of one broker configuration.
+type QueueResult struct {
+	Broker       string         `json:"broker"`
+	Topic        string         `json:"topic"`
+	Acks         string         `json:"acks"`
+	Messages     int            `json:"messages"`
+	Spans        int            `json:"spans"`
+	BytesSent    int64          `json:"bytes_sent"`
+	DurationS    float64        `json:"duration_s"`
+	MsgsPerSec   float64        `json:"msgs_per_sec"`
+	SpansPerSec  float64        `json:"spans_per_sec"`
+	MBPerSec     float64        `json:"mb_per_sec"`
+	ProduceP95ms float64        `json:"produce_p95_ms"`
+	ProduceP99ms float64        `json:"produce_p99_ms"`
+	ErrorRate    float64        `json:"error_rate"`
+	Errors       map[string]int `json:"errors,omitempty"`
+	// E2E is produce-to-consume latency of delivered messages; Lost counts
+	// messages produced without error but never consumed within Drain.
+	E2EP50ms  float64 `json:"e2e_p50_ms"`
+	E2EP95ms  float64 `json:"e2e_p95_ms"`
+	E2EP99ms  float64 `json:"e2e_p99_ms"`
+	Delivered int     `json:"delivered"`
+	Lost      int     `json:"lost"`
+	// Lag is the consumer backlog in messages, sampled every 100ms:
+	// broker-reported for kafka (high watermark - consumer offset),
+	// produced - consumed for nats (LagSource "client").
+	LagMax    int64   `json:"lag_max"`
+	LagMean   float64 `json:"lag_mean"`
+	LagSource string  `json:"lag_source"`
+}
+
+// queueProducer는 워커 하나의 생산자
+type queueProducer interface {
+	// publish는 메시지들을 한 요청으로 보내고 acks만큼 확인을 기다린다
+	publish(ctx context.Context, sentAt int64, msgs [][]byte) error
+	close() error
+}
+
+// queueConsumer는 측정 동안 받은 메시지마다 생성 시 받은 got을 부른다
+type queueConsumer interface {
+	run(ctx context.Context) error
+	// lag는 브로커가 알려 준 backlog(지원하지 않으면 false)
+	lag() (int64, bool)
+	close() error
+}
+
+// Validate checks the broker, address, topic and acks.
+func (c QueueConfig) Validate() error {
+	acks, ok := BrokerAcks[c.Broker]
+	if !ok {
+		return fmt.Errorf("invalid broker: %s (expected %s)", c.Broker, strings.Join(Brokers, "|"))
+	}
+	if c.Addr == "" || c.Topic == "" {
+		return fmt.Errorf("%s: address and topic are required", c.Broker)
+	}
+	if strings.ContainsAny(c.Topic, " \t\r\n") {
+		return fmt.Errorf("invalid topic: %q", c.Topic)
+	}
+	if c.Acks != "" && !contains(acks, c.Acks) {
+		return fmt.Errorf("invalid %s acks: %s (expected %s)", c.Broker, c.Acks, strings.Join(acks, "|"))
+	}
+	if c.ProduceBatch < 0 || c.ProduceBatch > 1000 {
+		return fmt.Errorf("invalid produce batch: %d (expected 1-1000)", c.ProduceBatch)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// RunQueue produces generated span batches to the broker from Workers
+// producers while one consumer reads them back, and reports delivery
+// latency and lag.
+func RunQueue(ctx context.Context, cfg QueueConfig) (QueueResult, error) {
+	if err := cfg.Validate(); err != nil {
+		return QueueResult{}, err
+	}
+	spans, batch := cfg.Spans, cfg.BatchSize
+	if spans <= 0 {
+		spans = DefaultSpans
+	}
+	if batch <= 0 {
+		batch = DefaultBatchSize
+	}
+	perReq := max(cfg.ProduceBatch, 1)
+	workers := max(cfg.Workers, 1)
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	drain := cfg.Drain
+	if drain <= 0 {
+		drain = DefaultQueueDrain
+	}
+	acks := cfg.Acks
+	if acks == "" {
+		acks = "1"
+		if cfg.Broker == BrokerNATS {
+			acks = "none"
+		}
+	}
+
+	// 메시지(직렬화된 배치)는 미리 인코딩해 생산 루프는 전송만 잰다
+	all := genSpans(spans, spansPerTrace, 1)
+	ser, comp := cfg.Serialization, cfg.Compression
+	if ser == "" {
+		ser = "json"
+	}
+	if comp == "" {
+		comp = "none"
+	}
+	enc, err := newEncoder(ser, comp)
+	if err != nil {
+		return QueueResult{}, err
+	}
+	var msgs [][]byte
+	for i := 0; i < len(all); i += batch {
+		if _, err := enc.encode(all[i:min(i+batch, len(all))]); err != nil {
+			return QueueResult{}, err
+		}
+		msgs = append(msgs, append([]byte(nil), enc.payload()...))
+	}
+
+	var delivered atomic.Int64
+	e2e := stats.NewHDR()
+	var e2eMu sync.Mutex
+	got := func(sentAt int64) {
+		d := time.Duration(time.Now().UnixNano() - sentAt)
+		e2eMu.Lock()
+		e2e.Record(d)
+		e2eMu.Unlock()
+		delivered.Add(1)
+	}
+	cons, prods, err := openQueue(ctx, cfg, acks, workers, timeout, got)
+	if err != nil {
+		return QueueResult{}, err
+	}
+	defer func() {
+		for _, p := range prods {
+			p.close()
+		}
+		cons.close()
+	}()
+	consCtx, stopCons := context.WithCancel(ctx)
+	defer stopCons()
+	consErr := make(chan error, 1)
+	go func() { consErr <- cons.run(consCtx) }()
+
+	// lag 표본: kafka는 브로커 값, nats는 보낸 수 - 받은 수
+	var produced atomic.Int64
+	var lagMax, lagSum, lagN int64
+	lagSource := "broker"
+	sample := func() {
+		l, ok := cons.lag()
+		if !ok {
+			lagSource = "client"
+			l = max(produced.Load()-delivered.Load(), 0)
+		}
+		lagMax = max(lagMax, l)
+		lagSum += l
+		lagN++
+	}
+	stopLag := make(chan struct{})
+	lagDone := make(chan struct{})
+	go func() {
+		defer close(lagDone)
+		t := time.NewTicker(100 * time.Millisecond)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				sample()
+			case <-stopLag:
+				return
+			case <-consCtx.Done():
+				return
+			}
+		}
+	}()
+
+	sketches := make([]stats.Sketch, workers)
+	tallies := make([]tally, workers)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		sketches[w] = stats.NewHDR()
+		tallies[w] = newTally()
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w * perReq; i < len(msgs); i += workers * perReq {
+				if ctx.Err() != nil {
+					return
+				}
+				req := msgs[i:min(i+perReq, len(msgs))]
+				t0 := time.Now()
+				err := prods[w].publish(ctx, t0.UnixNano(), req)
+				sketches[w].Record(time.Since(t0))
+				out := exportOutcome{}
+				for _, m := range req {
+					out.bytes += len(m)
+				}
+				if err != nil {
+					out.class = queueErrorClass(err)
+				} else {
+					produced.Add(int64(len(req)))
+				}
+				tallies[w].add(out)
+			}
+		}(w)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	if err := ctx.Err(); err != nil {
+		return QueueResult{}, err
+	}
+
+	// 보낸 메시지가 모두 도착하거나 drain이 지날 때까지 기다린다
+	deadline := time.Now().Add(drain)
+	for delivered.Load() < produced.Load() && time.Now().Before(deadline) {
+		select {
+		case err := <-consErr:
+			if err != nil {
+				return QueueResult{}, fmt.Errorf("%s consumer: %w", cfg.Broker, err)
+			}
+			deadline = time.Now()
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			return QueueResult{}, ctx.Err()
+		}
+	}
+	close(stopLag)
+	<-lagDone
+	sample()
+	stopCons()
+
+	lat, _ := stats.MergeSketches(sketches...)
+	total := newTally()
+	for _, t := range tallies {
+		total.merge(t)
+	}
+	ops := float64(max(total.ops, 1))
+	r := QueueResult{
+		Broker:       cfg.Broker,
+		Topic:        cfg.Topic,
+		Acks:         acks,
+		Messages:     len(msgs),
+		Spans:        len(all),
+		BytesSent:    int64(total.bytes),
+		DurationS:    stats.Round5(elapsed.Seconds()),
+		MsgsPerSec:   stats.Round2(float64(produced.Load()) / elapsed.Seconds()),
+		SpansPerSec:  stats.Round2(float64(len(all)) / elapsed.Seconds()),
+		MBPerSec:     stats.Round5(float64(total.bytes) / (1 << 20) / elapsed.Seconds()),
+		ProduceP95ms: stats.Round5(lat.Quantile(0.95)),
+		ProduceP99ms: stats.Round5(lat.Quantile(0.99)),
+		ErrorRate:    stats.Round5(float64(total.failed) / ops),
+		Errors:       total.errorsOrNil(),
+		Delivered:    int(delivered.Load()),
+		Lost:         int(max(produced.Load()-delivered.Load(), 0)),
+		LagMax:       lagMax,
+		LagMean:      stats.Round2(float64(lagSum) / float64(max(lagN, 1))),
+		LagSource:    lagSource,
+	}
+	e2eMu.Lock()
+	if e2e.Count() > 0 {
+		r.E2EP50ms = stats.Round5(e2e.Quantile(0.50))
+		r.E2EP95ms = stats.Round5(e2e.Quantile(0.95))
+		r.E2EP99ms = stats.Round5(e2e.Quantile(0.99))
+	}
+	e2eMu.Unlock()
+	return r, nil
+}
+
+// openQueue는 소비자(끝 offset부터)와 워커별 생산자를 연다
+func openQueue(ctx context.Context, cfg QueueConfig, acks string, workers int, timeout time.Duration, got func(int64)) (queueConsumer, []queueProducer, error) {
+	var cons queueConsumer
+	var prods []queueProducer
+	fail := func(err error) (queueConsumer, []queueProducer, error) {
+		for _, p := range prods {
+			p.close()
+		}
+		if cons != nil {
+			cons.close()
+		}
+		return nil, nil, err
+	}
+	switch cfg.Broker {
+	case BrokerKafka:
+		parts, err := kafkaMetadataFor(ctx, cfg.Addr, cfg.Topic, timeout)
+		if err != nil {
+			return fail(err)
+		}
+		kc, err := newKafkaConsumer(ctx, parts, cfg.Topic, timeout, got)
+		if err != nil {
+			return fail(err)
+		}
+		cons = kc
+		level := map[string]int16{"0": 0, "1": 1, "all": -1}[acks]
+		for w := 0; w < workers; w++ {
+			p := parts[w%len(parts)]
+			conn, err := dialKafka(ctx, p.leader, timeout)
+			if err != nil {
+				return fail(err)
+			}
+			prods = append(prods, &kafkaProducer{conn: conn, topic: cfg.Topic, partition: p.id, acks: level})
+		}
+	case BrokerNATS:
+		nc, err := newNATSConsumer(ctx, cfg.Addr, cfg.Topic, timeout, got)
+		if err != nil {
+			return fail(err)
+		}
+		cons = nc
+		for w := 0; w < workers; w++ {
+			p, err := newNATSProducer(ctx, cfg.Addr, cfg.Topic, acks == "stream", timeout)
+			if err != nil {
+				return fail(err)
+			}
+			prods = append(prods, p)
+		}
+	}
+	return cons, prods, nil
+}
+
+// brokerError는 브로커가 거부한 요청(nats -ERR, PubAck 오류)
+type brokerError string
+
+func (e brokerError) Error() string { return string(e) }
+
+// queueErrorClass는 브로커 거부를 ErrBroker로, 나머지는 전송 분류로
+func queueErrorClass(err error) string {
+	var ke kafkaError
+	var be brokerError
+	if errors.As(err, &ke) || errors.As(err, &be) {
+		return ErrBroker
+	}
+	return classifyError(err)
+}
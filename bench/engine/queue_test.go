@@ -0,0 +1,395 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// listen은 루프백 리스너를 열고 연결마다 serve를 돌린다(테스트가 끝나면 닫는다)
+func listen(t *testing.T, serve func(net.Conn)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var conns []net.Conn
+	t.Cleanup(func() {
+		ln.Close()
+		mu.Lock()
+		for _, c := range conns {
+			c.Close()
+		}
+		mu.Unlock()
+	})
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, c)
+			mu.Unlock()
+			go serve(c)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// fakeKafka는 파티션 하나(리더 node 1 = 자신)짜리 토픽을 가진 브로커.
+// Metadata v1, ListOffsets v1, Produce v3, Fetch v4만 답한다
+type fakeKafka struct {
+	topic      string
+	produceErr int16 // 0이 아니면 Produce를 이 error_code로 거부
+	leaderWait int   // 처음 몇 번의 Metadata에 LEADER_NOT_AVAILABLE(5)
+
+	addr string
+	mu   sync.Mutex
+	log  []byte // 받은 RecordBatch들(baseOffset을 고쳐 쓴 채로)
+	next int64
+}
+
+func (f *fakeKafka) start(t *testing.T) string {
+	f.addr = listen(t, f.serve)
+	return f.addr
+}
+
+func (f *fakeKafka) serve(c net.Conn) {
+	r := bufio.NewReader(c)
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(r, req); err != nil {
+			return
+		}
+		d := kdec{b: req}
+		api := d.i16()
+		d.i16() // version
+		corr := d.i32()
+		d.str() // client id
+		body, reply := f.handle(api, &d)
+		if !reply {
+			continue
+		}
+		out := binary.BigEndian.AppendUint32(nil, uint32(4+len(body)))
+		out = binary.BigEndian.AppendUint32(out, uint32(corr))
+		if _, err := c.Write(append(out, body...)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeKafka) handle(api int16, d *kdec) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var b []byte
+	i16 := func(v int16) { b = binary.BigEndian.AppendUint16(b, uint16(v)) }
+	i32 := func(v int32) { b = binary.BigEndian.AppendUint32(b, uint32(v)) }
+	i64 := func(v int64) { b = binary.BigEndian.AppendUint64(b, uint64(v)) }
+	switch api {
+	case kafkaMetadata:
+		d.i32()
+		topic := d.str()
+		host, port, _ := net.SplitHostPort(f.addr)
+		p, _ := strconv.Atoi(port)
+		i32(1) // brokers
+		i32(1)
+		b = appendKString(b, host)
+		i32(int32(p))
+		i16(-1) // rack
+		i32(1)  // controller
+		i32(1)  // topics
+		code := int16(0)
+		switch {
+		case topic != f.topic:
+			code = 3 // UNKNOWN_TOPIC_OR_PARTITION
+		case f.leaderWait > 0:
+			f.leaderWait--
+			code = 5
+		}
+		i16(code)
+		b = appendKString(b, topic)
+		b = append(b, 0)
+		if code == 3 {
+			i32(0)
+			break
+		}
+		i32(1)
+		i16(0)
+		i32(0) // partition
+		i32(1) // leader
+		i32(1)
+		i32(1) // replicas
+		i32(1)
+		i32(1) // isr
+	case kafkaListOffsets:
+		d.i32()
+		d.i32()
+		topic := d.str()
+		d.i32()
+		id := d.i32()
+		i32(1)
+		b = appendKString(b, topic)
+		i32(1)
+		i32(id)
+		i16(0)
+		i64(-1)
+		i64(f.next)
+	case kafkaProduce:
+		d.str() // transactional_id
+		acks := d.i16()
+		d.i32()
+		d.i32()
+		topic := d.str()
+		d.i32()
+		id := d.i32()
+		batch := append([]byte(nil), d.bytes()...)
+		base := f.next
+		if f.produceErr == 0 {
+			binary.BigEndian.PutUint64(batch, uint64(base))
+			f.log = append(f.log, batch...)
+			f.next += int64(binary.BigEndian.Uint32(batch[23:])) + 1
+		}
+		if acks == 0 {
+			return nil, false
+		}
+		i32(1)
+		b = appendKString(b, topic)
+		i32(1)
+		i32(id)
+		i16(f.produceErr)
+		i64(base)
+		i64(-1)
+		i32(0) // throttle
+	case kafkaFetch:
+		d.i32()
+		d.i32()
+		d.i32()
+		d.i32()
+		d.i8()
+		d.i32()
+		topic := d.str()
+		d.i32()
+		id := d.i32()
+		off := d.i64()
+		// 요청 offset을 담은 배치부터 돌려준다(실제 브로커처럼 배치 단위)
+		var recs []byte
+		for l := f.log; len(l) >= 12; {
+			n := 12 + int(binary.BigEndian.Uint32(l[8:]))
+			if int64(binary.BigEndian.Uint64(l))+int64(binary.BigEndian.Uint32(l[23:])) >= off {
+				recs = append(recs, l[:n]...)
+			}
+			l = l[n:]
+		}
+		if len(recs) == 0 {
+			// max_wait 흉내
+			f.mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			f.mu.Lock()
+		}
+		i32(0) // throttle
+		i32(1)
+		b = appendKString(b, topic)
+		i32(1)
+		i32(id)
+		i16(0)
+		i64(f.next)
+		i64(f.next)
+		i32(0) // aborted transactions
+		i32(int32(len(recs)))
+		b = append(b, recs...)
+	}
+	return b, true
+}
+
+func queueConfig(broker, addr, acks string) QueueConfig {
+	return QueueConfig{Broker: broker, Addr: addr, Topic: "spans", Acks: acks, Timeout: 2 * time.Second,
+		Spans: 400, BatchSize: 50, ProduceBatch: 2, Workers: 2, Drain: 2 * time.Second}
+}
+
+func TestKafkaQueue(t *testing.T) {
+	for _, acks := range []string{"0", "1", "all"} {
+		f := &fakeKafka{topic: "spans", leaderWait: 1}
+		r, err := RunQueue(context.Background(), queueConfig(BrokerKafka, f.start(t), acks))
+		if err != nil {
+			t.Fatalf("acks=%s: %v", acks, err)
+		}
+		if r.Messages != 8 || r.Delivered != 8 || r.Lost != 0 || r.ErrorRate != 0 || r.LagSource != "broker" {
+			t.Errorf("acks=%s: %+v", acks, r)
+		}
+		f.mu.Lock()
+		if f.next != 8 {
+			t.Errorf("acks=%s: broker log has %d records, want 8", acks, f.next)
+		}
+		f.mu.Unlock()
+	}
+}
+
+func TestKafkaQueueErrors(t *testing.T) {
+	f := &fakeKafka{topic: "spans", produceErr: 2} // CORRUPT_MESSAGE
+	r, err := RunQueue(context.Background(), queueConfig(BrokerKafka, f.start(t), "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ErrorRate != 1 || r.Errors[ErrBroker] != 4 || r.Delivered != 0 {
+		t.Errorf("rejected produce: error_rate=%v errors=%v delivered=%d", r.ErrorRate, r.Errors, r.Delivered)
+	}
+
+	f = &fakeKafka{topic: "other"}
+	_, err = RunQueue(context.Background(), queueConfig(BrokerKafka, f.start(t), "1"))
+	if err == nil || !strings.Contains(err.Error(), "kafka error code 3") {
+		t.Errorf("unknown topic: %v", err)
+	}
+}
+
+func TestReadRecordBatches(t *testing.T) {
+	first := appendRecordBatch(nil, 1000, [][]byte{[]byte("a"), []byte("b")})
+	second := appendRecordBatch(nil, 2000, [][]byte{[]byte("c")})
+	binary.BigEndian.PutUint64(second, 2)
+	log := append(append([]byte(nil), first...), second...)
+	for _, c := range []struct {
+		name     string
+		log      []byte
+		next     int64
+		want     []int64
+		wantNext int64
+	}{
+		{"all", log, 0, []int64{1000, 1000, 2000}, 3},
+		{"mid batch", log, 1, []int64{1000, 2000}, 3},
+		{"skips read batch", log, 2, []int64{2000}, 3},
+		{"truncated tail", log[:len(log)-1], 0, []int64{1000, 1000}, 2},
+		{"empty", nil, 5, nil, 5},
+	} {
+		var got []int64
+		next := readRecordBatches(c.log, c.next, func(sentAt int64) { got = append(got, sentAt) })
+		if !reflect.DeepEqual(got, c.want) || next != c.wantNext {
+			t.Errorf("%s: got %v next %d, want %v next %d", c.name, got, next, c.want, c.wantNext)
+		}
+	}
+}
+
+// fakeNATS는 PING/SUB/PUB만 아는 서버. reply가 있는 PUB에는 ack를
+// PubAck으로 보낸다(JetStream 흉내). deny면 PUB마다 -ERR
+type fakeNATS struct {
+	ack  string
+	deny bool
+
+	mu   sync.Mutex
+	subs []fakeSub
+}
+
+type fakeSub struct {
+	c            *fakeNATSConn
+	subject, sid string
+}
+
+type fakeNATSConn struct {
+	mu sync.Mutex
+	c  net.Conn
+}
+
+func (c *fakeNATSConn) send(s string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.Write([]byte(s))
+}
+
+func (f *fakeNATS) serve(nc net.Conn) {
+	c := &fakeNATSConn{c: nc}
+	c.send("INFO {\"server_id\":\"fake\"}\r\n")
+	r := bufio.NewReader(nc)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fs := strings.Fields(line)
+		switch {
+		case len(fs) == 0:
+		case fs[0] == "PING":
+			c.send("PONG\r\n")
+		case fs[0] == "SUB":
+			f.mu.Lock()
+			f.subs = append(f.subs, fakeSub{c: c, subject: fs[1], sid: fs[len(fs)-1]})
+			f.mu.Unlock()
+		case fs[0] == "PUB":
+			n, _ := strconv.Atoi(fs[len(fs)-1])
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			if f.deny {
+				c.send("-ERR 'Permissions Violation for Publish to \"" + fs[1] + "\"'\r\n")
+				continue
+			}
+			f.deliver(fs[1], payload[:n])
+			if len(fs) == 4 {
+				f.deliver(fs[2], []byte(f.ack))
+			}
+		}
+	}
+}
+
+func (f *fakeNATS) deliver(subject string, payload []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.subs {
+		match := s.subject == subject
+		if prefix, ok := strings.CutSuffix(s.subject, "*"); ok {
+			rest, found := strings.CutPrefix(subject, prefix)
+			match = found && rest != "" && !strings.Contains(rest, ".")
+		}
+		if match {
+			s.c.send(fmt.Sprintf("MSG %s %s %d\r\n%s\r\n", subject, s.sid, len(payload), payload))
+		}
+	}
+}
+
+func TestNATSQueue(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		srv       *fakeNATS
+		acks      string
+		wantClass string // 비면 오류 없이 모두 도착
+	}{
+		{"core", &fakeNATS{}, "none", ""},
+		{"stream", &fakeNATS{ack: `{"stream":"TRACES","seq":1}`}, "stream", ""},
+		{"stream full", &fakeNATS{ack: `{"error":{"code":400,"description":"maximum messages exceeded"}}`}, "stream", ErrBroker},
+		{"no stream", &fakeNATS{ack: `{"seq":0}`}, "stream", ErrBroker},
+		{"denied", &fakeNATS{deny: true}, "stream", ErrBroker},
+	} {
+		r, err := RunQueue(context.Background(), queueConfig(BrokerNATS, listen(t, c.srv.serve), c.acks))
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if c.wantClass == "" {
+			if r.Delivered != 8 || r.Lost != 0 || r.ErrorRate != 0 || r.LagSource != "client" {
+				t.Errorf("%s: %+v", c.name, r)
+			}
+		} else if r.ErrorRate == 0 || r.Errors[c.wantClass] == 0 {
+			t.Errorf("%s: error_rate=%v errors=%v, want %s", c.name, r.ErrorRate, r.Errors, c.wantClass)
+		}
+	}
+}
+
+func TestNATSGreeting(t *testing.T) {
+	addr := listen(t, func(c net.Conn) { c.Write([]byte("+OK\r\n")) })
+	_, err := dialNATS(context.Background(), "nats://"+addr, time.Second, nil)
+	if err == nil || !strings.Contains(err.Error(), "unexpected greeting") {
+		t.Errorf("dialNATS = %v", err)
+	}
+}
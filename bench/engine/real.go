@@ -0,0 +1,425 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// 실측 모드 기본값
+const (
+	DefaultSpans     = 20000
+	DefaultBatchSize = 200
+	spansPerTrace    = 8
+)
+
+// RetryStats separates first-attempt from retried requests, since p95_ms
+// and error_rate are end-to-end (after retries) as a retrying client sees them.
+type RetryStats struct {
+	Retries               int     `json:"retries"`
+	Backoff               string  `json:"backoff"`
+	FirstAttemptP95ms     float64 `json:"first_attempt_p95_ms"`
+	FirstAttemptErrorRate float64 `json:"first_attempt_error_rate"`
+	RetriedRequests       int     `json:"retried_requests"`
+	RetriedP95ms          float64 `json:"retried_p95_ms"`
+	Recovered             int     `json:"recovered"`
+	TotalRetries          int     `json:"total_retries"`
+}
+
+// MemStats reports the allocation and GC cost of one export operation
+// (one batch serialized and compressed), measured from runtime.MemStats
+// deltas in the style of testing.AllocsPerRun.
+type MemStats struct {
+	AllocsPerOp float64 `json:"allocs_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op"`
+	GCPauseMs   float64 `json:"gc_pause_ms"`
+	NumGC       uint32  `json:"num_gc"`
+}
+
+// VolumeStats is the traffic a real-mode run pushed through the pipeline:
+// the per-span basis of cost (-cost-model) and capacity projections.
+type VolumeStats struct {
+	Spans      int     `json:"spans"`       // generated spans covered by the exported batches
+	Exported   int     `json:"exported"`    // spans kept by sampling and sent
+	Bytes      int64   `json:"bytes"`       // encoded output
+	CPUSeconds float64 `json:"cpu_seconds"` // process user+system CPU during the measure phase
+	DurationS  float64 `json:"duration_s"`
+	// Batches and BytesPerSpanSD describe the batch-to-batch spread of
+	// bytes per span, the basis of projection bounds from a single run.
+	Batches        int     `json:"batches"`
+	BytesPerSpanSD float64 `json:"bytes_per_span_sd"`
+}
+
+// BytesPerSpan and CPUSecondsPerSpan are per generated (pre-sampling) span.
+func (v VolumeStats) BytesPerSpan() float64 {
+	return float64(v.Bytes) / float64(max(v.Spans, 1))
+}
+
+func (v VolumeStats) CPUSecondsPerSpan() float64 {
+	return v.CPUSeconds / float64(max(v.Spans, 1))
+}
+
+// sampleSD는 합/제곱합에서 표본 표준편차
+func sampleSD(sum, sq float64, n int) float64 {
+	if n < 2 {
+		return 0
+	}
+	v := (sq - sum*sum/float64(n)) / float64(n-1)
+	return math.Sqrt(max(v, 0))
+}
+
+// realMeasurement은 합성 span을 샘플링→직렬화→압축(→HTTP 전송)하며 배치 단위로 계측한다
+// - p95_ms: 배치 export 지연의 p95
+// - error_rate: 실패 배치 비율(분류별 내역은 errors{})
+// - size_kb: 배치당 평균 출력 크기
+func realMeasurement(ctx context.Context, cfg Config) (r Result, err error) {
+	// phase는 하나씩 열고 닫는다(endPhase가 현재 phase를 종료)
+	endPhase := cfg.phase(ctx, PhaseSetup)
+	defer func() { endPhase(err) }()
+	spans, batch := cfg.Spans, cfg.BatchSize
+	if spans <= 0 {
+		spans = DefaultSpans
+	}
+	if batch <= 0 {
+		batch = DefaultBatchSize
+	}
+	workers := max(cfg.Workers, 1)
+
+	// 생성/샘플링 비용은 측정 구간에서 제외
+	all := genSpans(spans, spansPerTrace, 1)
+	kept := make([]span, 0, len(all))
+	for _, s := range all {
+		if sampled(s.TraceID, cfg.Sampling) {
+			kept = append(kept, s)
+		}
+	}
+	var batches [][]span
+	var covered []int // 배치별 생성 span 수(샘플링 전)
+	for i := 0; i < len(all); i += batch {
+		lo, hi := i*len(kept)/len(all), min(i+batch, len(all))*len(kept)/len(all)
+		batches = append(batches, kept[lo:hi])
+		covered = append(covered, min(i+batch, len(all))-i)
+	}
+
+	// exact 스케치 초기 용량(워커 몫): batch processor는 timeout flush로 배치가
+	// 더 잘게 나뉘고 워커 간 몫도 고르지 않지만, 넘치면 Ring이 늘어난다
+	capacity := len(batches)/workers + 1
+	if cfg.BatchTimeout > 0 {
+		capacity = len(all)/workers + 1
+	}
+
+	// 워커별 exporter/샘플 스케치 사전 할당, warm-up으로 버퍼·압축기 초기 할당 제외
+	exps := make([]exporter, workers)
+	sketches := make([]stats.Sketch, workers)
+	firstSk := make([]stats.Sketch, workers)   // 재시도 시: 첫 시도 지연
+	retriedSk := make([]stats.Sketch, workers) // 재시도 시: 재시도된 요청의 end-to-end 지연
+	heatmaps := make([]*stats.Heatmap, workers)
+	timelines := make([]*stats.Timeline, workers)
+	hsSk := make([]stats.Sketch, workers) // TLS handshake 단계
+	tallies := make([]tally, workers)
+	var tr *http.Transport
+	if cfg.Workload == WorkloadHTTP {
+		var err error
+		if tr, err = newTransport(cfg.Connections, cfg.TLS, cfg.Protocol, workers); err != nil {
+			return Result{}, err
+		}
+		defer tr.CloseIdleConnections()
+	}
+	var fd *feed
+	if cfg.Feed != "" {
+		var err error
+		if fd, err = loadFeed(cfg.Feed, cfg.FeedMode); err != nil {
+			return Result{}, err
+		}
+	}
+	var bt *bodyTemplate
+	if cfg.BodyTemplate != "" {
+		var err error
+		if bt, err = newBodyTemplate(cfg.BodyTemplate, fd); err != nil {
+			return Result{}, err
+		}
+	}
+	var sc *requestScript
+	if cfg.Script != "" {
+		var err error
+		if sc, err = newRequestScript(cfg, fd); err != nil {
+			return Result{}, err
+		}
+	}
+	// chaos: phase(before/during/after)별 워커 스케치
+	ctl := cfg.chaos
+	var phaseSk [][3]stats.Sketch
+	var phaseOps, phaseFailed [][3]int
+	var mttrSamples [][]mttrSample
+	if ctl != nil {
+		mttrSamples = make([][]mttrSample, workers)
+		phaseSk = make([][3]stats.Sketch, workers)
+		phaseOps = make([][3]int, workers)
+		phaseFailed = make([][3]int, workers)
+	}
+	endPhase(nil)
+	endPhase = cfg.phase(ctx, PhaseWarmup)
+	for w := range exps {
+		exp, err := newExporter(cfg, tr, bt, sc)
+		if err != nil {
+			return Result{}, err
+		}
+		if _, err := exp.enc().encode(batches[0]); err != nil {
+			return Result{}, err
+		}
+		exps[w] = exp
+		sk, err := stats.NewSketch(cfg.QuantileSketch, capacity)
+		if err != nil {
+			return Result{}, err
+		}
+		sketches[w] = sk
+		if cfg.Retries > 0 {
+			firstSk[w], _ = stats.NewSketch(cfg.QuantileSketch, capacity)
+			retriedSk[w], _ = stats.NewSketch(cfg.QuantileSketch, capacity)
+		}
+		if cfg.HeatmapInterval > 0 {
+			heatmaps[w] = stats.NewHeatmap(cfg.HeatmapInterval, stats.DefaultHeatmapBounds())
+		}
+		if cfg.TimelineInterval > 0 {
+			timelines[w] = stats.NewTimeline(cfg.TimelineInterval)
+		}
+		tallies[w] = newTally()
+		if ctl != nil {
+			for p := range phaseSk[w] {
+				phaseSk[w][p], _ = stats.NewSketch(cfg.QuantileSketch, capacity)
+			}
+		}
+		hsSk[w] = stats.NewHDR()
+	}
+
+	var pw *procWatch
+	if cfg.TargetPID > 0 {
+		if pw, err = openProcWatch(cfg.TargetPID); err != nil {
+			return Result{}, err
+		}
+	}
+
+	endPhase(nil)
+	endPhase = cfg.phase(ctx, PhaseMeasure)
+	var m0, m1 runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m0)
+	cg0, cgOK := selfCPUStat()
+	cpu0, measureStart := processCPU(), time.Now()
+	if pw != nil {
+		pw.begin()
+	}
+	// exportBatch는 배치 하나를 보내고 워커 w의 집계에 기록한다(false: feed 소진)
+	exportBatch := func(w int, b []span, cov int) bool {
+		exp, sk, hm, t := exps[w], sketches[w], heatmaps[w], &tallies[w]
+		ph := 0
+		t0 := time.Now()
+		if ctl != nil {
+			ph = ctl.phase()
+			if dl := ctl.extraDelay(); dl > 0 {
+				time.Sleep(dl)
+			}
+		}
+		out := exp.export(ctx, b)
+		if out.class == errFeedDone {
+			return false
+		}
+		d := time.Since(t0)
+		if ctl != nil {
+			phaseSk[w][ph].Record(d)
+			phaseOps[w][ph]++
+			if out.class != "" {
+				phaseFailed[w][ph]++
+			}
+			if ctl.mttr != nil {
+				mttrSamples[w] = append(mttrSamples[w], mttrSample{at: t0.Sub(ctl.start), d: d, failed: out.class != ""})
+			}
+		}
+		sk.Record(d)
+		if cfg.Live != nil {
+			cfg.Live.record(d, out)
+		}
+		if hm != nil {
+			hm.Record(t0, d)
+		}
+		if tl := timelines[w]; tl != nil {
+			tl.Record(t0, d, out.class != "", out.bytes)
+		}
+		t.add(out)
+		t.spans += cov
+		t.exported += len(b)
+		bps := float64(out.bytes) / float64(cov)
+		t.bpsSum += bps
+		t.bpsSq += bps * bps
+		for _, hs := range out.handshakes {
+			hsSk[w].Record(hs)
+		}
+		if firstSk[w] != nil {
+			firstSk[w].Record(out.first)
+			if out.attempts > 1 {
+				retriedSk[w].Record(d)
+			}
+		}
+		return true
+	}
+	var bp *batcher
+	var delaySk []stats.Sketch
+	var delayPts [][]delayPoint // 배치 지연 추세(delay_growth_ms)
+	if cfg.BatchTimeout > 0 {
+		bp = newBatcher(cfg, batch)
+		delaySk = make([]stats.Sketch, workers)
+		delayPts = make([][]delayPoint, workers)
+		go bp.run(ctx, all)
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			if bp != nil {
+				delaySk[w] = stats.NewHDR()
+				for job := range bp.queue {
+					ok := ctx.Err() == nil && exportBatch(w, job.spans, job.covered)
+					bp.release(job)
+					if !ok {
+						continue
+					}
+					d := time.Since(job.first)
+					delaySk[w].Record(d)
+					delayPts[w] = append(delayPts[w], delayPoint{at: job.first.Sub(bp.start).Seconds(), ms: float64(d) / float64(time.Millisecond)})
+				}
+				return
+			}
+			for i := w; i < len(batches); i += workers {
+				if ctx.Err() != nil || !exportBatch(w, batches[i], covered[i]) {
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	cpu1, elapsed := processCPU(), time.Since(measureStart)
+	cg1, _ := selfCPUStat()
+	var target *ProcessStats
+	if pw != nil {
+		target = pw.end()
+	}
+	runtime.ReadMemStats(&m1)
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	if ctl != nil {
+		ctl.collect(phaseSk, phaseOps, phaseFailed, mttrSamples)
+	}
+
+	// 모든 워커 종료 후 병합(락 없음)
+	lat, err := stats.MergeSketches(sketches...)
+	if err != nil {
+		return Result{}, err
+	}
+	var hm *stats.Heatmap
+	if cfg.HeatmapInterval > 0 {
+		hm = heatmaps[0]
+		for _, h := range heatmaps[1:] {
+			hm.Merge(h)
+		}
+	}
+	var tl *stats.Timeline
+	if cfg.TimelineInterval > 0 {
+		tl = timelines[0]
+		for _, t := range timelines[1:] {
+			tl.Merge(t)
+		}
+	}
+	total := newTally()
+	for _, t := range tallies {
+		total.merge(t)
+	}
+	ops := float64(max(total.ops, 1))
+	var feedStats *FeedStats
+	if fd != nil {
+		feedStats = fd.stats()
+	}
+	var scriptStats *ScriptStats
+	if sc != nil {
+		scriptStats = sc.stats()
+	}
+	var retry *RetryStats
+	if cfg.Retries > 0 {
+		first, _ := stats.MergeSketches(firstSk...)
+		retried, _ := stats.MergeSketches(retriedSk...)
+		retry = &RetryStats{
+			Retries:               cfg.Retries,
+			Backoff:               exps[0].(*httpExporter).backoff.String(),
+			FirstAttemptP95ms:     stats.Round5(first.Quantile(0.95)),
+			FirstAttemptErrorRate: stats.Round5(float64(total.firstFailed) / ops),
+			RetriedRequests:       total.retried,
+			RetriedP95ms:          stats.Round5(retried.Quantile(0.95)),
+			Recovered:             total.recovered,
+			TotalRetries:          total.retries,
+		}
+	}
+	var conn *ConnStats
+	var tlsStats *TLSStats
+	if cfg.Workload == WorkloadHTTP {
+		conn = &ConnStats{Mode: cfg.Connections.String(), NewConnections: total.newConns, Reused: total.reusedConns}
+		hs, _ := stats.MergeSketches(hsSk...)
+		if hs.Count() > 0 || total.handshakeFailed > 0 {
+			tlsStats = &TLSStats{
+				Handshakes:     hs.Count(),
+				HandshakeP95ms: stats.Round5(hs.Quantile(0.95)),
+				Failures:       total.handshakeFailed,
+			}
+		}
+	}
+	var batching *BatchStats
+	if bp != nil {
+		delay, _ := stats.MergeSketches(delaySk...)
+		var pts []delayPoint
+		for _, p := range delayPts {
+			pts = append(pts, p...)
+		}
+		batching = bp.stats(delay, pts, elapsed)
+	}
+	return Result{
+		Protocol:  cfg.Protocol,
+		P95ms:     stats.Round5(lat.Quantile(0.95)),
+		P99ms:     stats.Round5(lat.Quantile(0.99)),
+		ErrorRate: stats.Round5(float64(total.failed) / ops),
+		SizeKB:    stats.Round2(float64(total.bytes) / ops / 1024),
+		Mem: &MemStats{
+			AllocsPerOp: stats.Round2(float64(m1.Mallocs-m0.Mallocs) / ops),
+			BytesPerOp:  stats.Round2(float64(m1.TotalAlloc-m0.TotalAlloc) / ops),
+			GCPauseMs:   stats.Round5(float64(m1.PauseTotalNs-m0.PauseTotalNs) / 1e6),
+			NumGC:       m1.NumGC - m0.NumGC,
+		},
+		Volume: &VolumeStats{
+			Spans:      total.spans,
+			Exported:   total.exported,
+			Bytes:      int64(total.bytes),
+			CPUSeconds: stats.Round5((cpu1 - cpu0).Seconds()),
+			DurationS:  stats.Round5(elapsed.Seconds()),
+			Batches:    total.ops,
+			// 표본 표준편차(배치 2개 미만이면 0)
+			BytesPerSpanSD: stats.Round5(sampleSD(total.bpsSum, total.bpsSq, total.ops)),
+		},
+		Batching:    batching,
+		Heatmap:     hm,
+		Timeline:    tl,
+		Errors:      total.errorsOrNil(),
+		StatusCodes: total.statusOrNil(),
+		Retry:       retry,
+		Conn:        conn,
+		TLS:         tlsStats,
+		Feed:        feedStats,
+		Script:      scriptStats,
+		Throttle:    throttleBetween(cg0, cg1, cgOK),
+		TargetProc:  target,
+	}, nil
+}
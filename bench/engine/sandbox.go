@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSandboxTmpfs is the size of the sandbox's private /tmp.
+const DefaultSandboxTmpfs = 64 << 20
+
+// Sandbox isolates the exec workload's command (Linux only): it runs in
+// its own user, pid, mount and (unless HostNetwork) network namespace with
+// only loopback, gets a fresh tmpfs over /tmp as its working directory on
+// every batch, and is bounded by rlimits. Whatever the command leaves
+// behind (background processes, files, connections) dies with the batch.
+// Sandbox setup is part of the measured batch latency.
+type Sandbox struct {
+	Spec        string `json:"spec"`
+	HostNetwork bool   `json:"host_network,omitempty"`
+	TmpfsBytes  int64  `json:"tmpfs_bytes"`
+	// Rlimits; 0 leaves the inherited limit.
+	CPUSeconds int64 `json:"cpu_seconds,omitempty"` // RLIMIT_CPU
+	MemBytes   int64 `json:"mem_bytes,omitempty"`   // RLIMIT_AS
+	Files      int64 `json:"files,omitempty"`       // RLIMIT_NOFILE
+}
+
+// ParseSandbox parses "on" (defaults only) or a list such as
+// "tmpfs:64MB,cpu:10s,mem:512MB,nofile:256,net:host".
+func ParseSandbox(spec string) (*Sandbox, error) {
+	s := &Sandbox{Spec: spec, TmpfsBytes: DefaultSandboxTmpfs}
+	if strings.TrimSpace(spec) == "on" {
+		return s, nil
+	}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid sandbox %q: expected key:value", item)
+		}
+		var err error
+		switch k {
+		case "tmpfs":
+			s.TmpfsBytes, err = sandboxBytes(k, v)
+		case "mem":
+			s.MemBytes, err = sandboxBytes(k, v)
+		case "cpu":
+			d, perr := time.ParseDuration(v)
+			if perr != nil || d < time.Second {
+				return nil, fmt.Errorf("invalid sandbox cpu %q (whole seconds, e.g. 10s)", v)
+			}
+			s.CPUSeconds = int64(d / time.Second)
+		case "nofile":
+			if s.Files, err = strconv.ParseInt(v, 10, 64); err != nil || s.Files <= 0 {
+				return nil, fmt.Errorf("invalid sandbox nofile %q", v)
+			}
+		case "net":
+			if v != "host" && v != "none" {
+				return nil, fmt.Errorf("invalid sandbox net %q (none|host)", v)
+			}
+			s.HostNetwork = v == "host"
+		default:
+			return nil, fmt.Errorf("unknown sandbox option %q (tmpfs, cpu, mem, nofile, net)", k)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// sandboxBytes는 64MB, 1GB 같은 크기(K/M/G는 1024배)
+func sandboxBytes(k, v string) (int64, error) {
+	num := strings.TrimSuffix(strings.ToUpper(v), "B")
+	mult := int64(1)
+	switch {
+	case strings.HasSuffix(num, "K"):
+		mult = 1 << 10
+	case strings.HasSuffix(num, "M"):
+		mult = 1 << 20
+	case strings.HasSuffix(num, "G"):
+		mult = 1 << 30
+	}
+	if mult > 1 {
+		num = num[:len(num)-1]
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n <= 0 || n > (1<<62)/mult {
+		return 0, fmt.Errorf("invalid sandbox %s %q (e.g. 64MB)", k, v)
+	}
+	return n * mult, nil
+}
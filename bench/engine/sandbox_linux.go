@@ -0,0 +1,146 @@
+//go:build linux
+
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// sandboxEnv는 재실행된 자신에게 Sandbox를 넘기는 환경변수. 설정돼 있으면
+// init이 샌드박스를 준비하고 명령으로 exec한다(돌아오지 않음)
+const sandboxEnv = "TRACE_BENCH_SANDBOX"
+
+// sandboxSetupFailed는 샌드박스 준비 실패 종료 코드(docker run과 같은 125)
+const sandboxSetupFailed = 125
+
+const sandboxSupported = true
+
+func init() {
+	if spec, ok := os.LookupEnv(sandboxEnv); ok {
+		sandboxMain(spec, os.Args[1:])
+	}
+}
+
+// wrap은 cmd를 자신(os.Executable)의 재실행으로 바꾼다. 자식은 새 namespace에서
+// 시작해 sandboxMain이 /tmp·lo·rlimit를 준비한 뒤 원래 명령을 exec한다
+func (s *Sandbox) wrap(cmd *exec.Cmd) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("sandbox: %w", err)
+	}
+	spec, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	flags := syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID
+	if !s.HostNetwork {
+		flags |= syscall.CLONE_NEWNET
+	}
+	cmd.Path, cmd.Args = exe, append([]string{exe}, cmd.Args...)
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, sandboxEnv+"="+string(spec))
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  uintptr(flags),
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}},
+		Pdeathsig:   syscall.SIGKILL,
+	}
+	return nil
+}
+
+// check는 명령 없이 샌드박스만 한 번 만들어 본다(user namespace가 막힌 환경 등)
+func (s *Sandbox) check() error {
+	cmd := &exec.Cmd{}
+	if err := s.wrap(cmd); err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("sandbox: %s", msg)
+		}
+		return fmt.Errorf("sandbox: %w (needs unprivileged user namespaces)", err)
+	}
+	return nil
+}
+
+// sandboxMain은 재실행된 자식: 준비에 실패하면 sandboxSetupFailed로 끝난다
+func sandboxMain(spec string, argv []string) {
+	die := func(err error) {
+		fmt.Fprintf(os.Stderr, "trace_bench sandbox: %v\n", err)
+		os.Exit(sandboxSetupFailed)
+	}
+	var s Sandbox
+	if err := json.Unmarshal([]byte(spec), &s); err != nil {
+		die(err)
+	}
+	os.Unsetenv(sandboxEnv)
+	// 마운트가 호스트로 전파되지 않도록 private으로 바꾼 뒤 /tmp를 덮는다
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		die(fmt.Errorf("private mounts: %w", err))
+	}
+	tmp := "/tmp"
+	opts := fmt.Sprintf("size=%d,mode=0700", s.TmpfsBytes)
+	if err := syscall.Mount("tmpfs", tmp, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, opts); err != nil {
+		die(fmt.Errorf("tmpfs %s: %w", tmp, err))
+	}
+	if err := os.Chdir(tmp); err != nil {
+		die(err)
+	}
+	os.Setenv("HOME", tmp)
+	os.Setenv("TMPDIR", tmp)
+	if !s.HostNetwork {
+		if err := loopbackUp(); err != nil {
+			die(fmt.Errorf("loopback: %w", err))
+		}
+	}
+	for _, l := range []struct {
+		res int
+		v   int64
+	}{{syscall.RLIMIT_CPU, s.CPUSeconds}, {syscall.RLIMIT_AS, s.MemBytes}, {syscall.RLIMIT_NOFILE, s.Files}} {
+		if l.v == 0 {
+			continue
+		}
+		if err := syscall.Setrlimit(l.res, &syscall.Rlimit{Cur: uint64(l.v), Max: uint64(l.v)}); err != nil {
+			die(fmt.Errorf("rlimit %d: %w", l.res, err))
+		}
+	}
+	if len(argv) == 0 {
+		os.Exit(0) // check
+	}
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		die(err)
+	}
+	die(syscall.Exec(path, argv, os.Environ()))
+}
+
+// loopbackUp은 새 network namespace의 lo를 올린다(SIOCSIFFLAGS)
+func loopbackUp() error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+	var ifr struct {
+		name  [syscall.IFNAMSIZ]byte
+		flags uint16
+		_     [22]byte
+	}
+	copy(ifr.name[:], "lo")
+	ifr.flags = syscall.IFF_UP | syscall.IFF_RUNNING
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCSIFFLAGS, uintptr(unsafe.Pointer(&ifr))); errno != 0 {
+		return errno
+	}
+	return nil
+}
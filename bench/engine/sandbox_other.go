@@ -0,0 +1,18 @@
+//go:build !linux
+
+package engine
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// 샌드박스는 리눅스 namespace에 의존한다. Validate가 먼저 거부한다
+const (
+	sandboxSupported   = false
+	sandboxSetupFailed = 125
+)
+
+func (s *Sandbox) wrap(*exec.Cmd) error { return fmt.Errorf("sandbox requires Linux") }
+
+func (s *Sandbox) check() error { return fmt.Errorf("sandbox requires Linux") }
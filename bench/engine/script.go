@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"fmt"
+	mrand "math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/duri/trace_bench/internal/starlite"
+)
+
+// Script hook names. request(ctx) is required and returns the request body
+// (a string) or a dict {"body": string or JSON value, "headers": {...}};
+// check(resp), if defined, asserts on every non-error response by
+// returning False or calling fail().
+//
+// ctx has the fields seq, spans, row (the -feed row or None) and run_id;
+// resp has status, headers (lower-case names) and body.
+const (
+	ScriptRequest = "request"
+	ScriptCheck   = "check"
+)
+
+// ScriptStats reports the -script hooks of a run.
+type ScriptStats struct {
+	File  string `json:"file"`
+	Check bool   `json:"check"`
+	// FirstError is the first request()/check() failure, with position.
+	FirstError string `json:"first_error,omitempty"`
+}
+
+// requestScript은 Config.Script의 request/check 훅. 전역은 동결되어 모든 워커가 공유
+type requestScript struct {
+	file           string
+	request, check *starlite.Function
+	runID          string
+	seq            atomic.Int64
+	feed           *feed
+	lines          lineFiles
+
+	errOnce  sync.Once
+	firstErr string
+}
+
+// scriptRequest는 request() 한 번의 결과
+type scriptRequest struct {
+	body    []byte
+	headers map[string]string
+}
+
+func newRequestScript(cfg Config, fd *feed) (*requestScript, error) {
+	file := cfg.ScriptFile
+	if file == "" {
+		file = "script"
+	}
+	sc := &requestScript{file: file, runID: cfg.RunID, feed: fd}
+	prog, err := starlite.Exec(file, []byte(cfg.Script), map[string]any{
+		"uuid": starlite.NewBuiltin("uuid", func(args []any, _ map[string]any) (any, error) {
+			return newUUID(), nil
+		}),
+		"rand_int": starlite.NewBuiltin("rand_int", func(args []any, _ map[string]any) (any, error) {
+			lo, ok1 := intArg(args, 0)
+			hi, ok2 := intArg(args, 1)
+			if len(args) != 2 || !ok1 || !ok2 || hi < lo {
+				return nil, fmt.Errorf("rand_int(lo, hi) requires ints with lo <= hi")
+			}
+			return lo + mrand.Int63n(hi-lo+1), nil
+		}),
+		"random_line": starlite.NewBuiltin("random_line", func(args []any, _ map[string]any) (any, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("random_line(path) takes 1 argument")
+			}
+			path, ok := args[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("random_line: path must be a string")
+			}
+			return sc.lines.randomLine(path)
+		}),
+		"now_unix_nano": starlite.NewBuiltin("now_unix_nano", func(args []any, _ map[string]any) (any, error) {
+			return time.Now().UnixNano(), nil
+		}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid script: %w", err)
+	}
+	if sc.request = prog.Func(ScriptRequest); sc.request == nil {
+		return nil, fmt.Errorf("invalid script: %s does not define %s(ctx)", file, ScriptRequest)
+	}
+	sc.check = prog.Func(ScriptCheck)
+	// 측정 전에 한 번 호출해 스크립트 오류를 미리 드러냄(seq는 소비하지 않음)
+	var row map[string]string
+	if fd != nil {
+		row = fd.rows[0]
+	}
+	if _, err := sc.call(-1, 1, row); err != nil {
+		return nil, fmt.Errorf("invalid script: %w", err)
+	}
+	return sc, nil
+}
+
+func intArg(args []any, i int) (int64, bool) {
+	if i >= len(args) {
+		return 0, false
+	}
+	n, ok := args[i].(int64)
+	return n, ok
+}
+
+// next는 다음 seq(및 feed 행)로 request()를 호출한다
+func (sc *requestScript) next(spans int) (scriptRequest, error) {
+	var row map[string]string
+	if sc.feed != nil {
+		var err error
+		if row, err = sc.feed.draw(); err != nil {
+			return scriptRequest{}, err
+		}
+	}
+	req, err := sc.call(sc.seq.Add(1)-1, spans, row)
+	if err != nil {
+		sc.recordErr(err)
+	}
+	return req, err
+}
+
+func (sc *requestScript) call(seq int64, spans int, row map[string]string) (scriptRequest, error) {
+	var rowVal any
+	if row != nil {
+		rowVal = stringDict(row)
+	}
+	ctx := starlite.NewStruct(map[string]any{
+		"seq":    seq,
+		"spans":  int64(spans),
+		"row":    rowVal,
+		"run_id": sc.runID,
+	})
+	v, err := starlite.Call(sc.request, ctx)
+	if err != nil {
+		return scriptRequest{}, err
+	}
+	switch v := v.(type) {
+	case string:
+		return scriptRequest{body: []byte(v)}, nil
+	case *starlite.Dict:
+		var req scriptRequest
+		for _, k := range v.Keys() {
+			val, _ := v.Get(k)
+			switch k {
+			case "body":
+				s, ok := val.(string)
+				if !ok {
+					// 문자열이 아니면 JSON으로 인코딩
+					if s, err = starlite.EncodeJSON(val); err != nil {
+						return scriptRequest{}, fmt.Errorf("%s(): body: %w", ScriptRequest, err)
+					}
+				}
+				req.body = []byte(s)
+			case "headers":
+				hd, ok := val.(*starlite.Dict)
+				if !ok {
+					return scriptRequest{}, fmt.Errorf("%s(): headers must be a dict, got %s", ScriptRequest, starlite.Type(val))
+				}
+				req.headers = make(map[string]string, hd.Len())
+				for _, hk := range hd.Keys() {
+					hv, _ := hd.Get(hk)
+					name, ok1 := hk.(string)
+					value, ok2 := hv.(string)
+					if !ok1 || !ok2 {
+						return scriptRequest{}, fmt.Errorf("%s(): header names and values must be strings", ScriptRequest)
+					}
+					req.headers[http.CanonicalHeaderKey(name)] = value
+				}
+			default:
+				return scriptRequest{}, fmt.Errorf("%s(): unknown key %s (expected body, headers)", ScriptRequest, starlite.Repr(k))
+			}
+		}
+		return req, nil
+	}
+	return scriptRequest{}, fmt.Errorf("%s() must return a string or a dict, got %s", ScriptRequest, starlite.Type(v))
+}
+
+// verify는 응답에 check()를 적용한다(False 반환이나 fail()이면 실패)
+func (sc *requestScript) verify(resp *http.Response, body []byte) error {
+	if sc.check == nil {
+		return nil
+	}
+	hd := starlite.NewDict()
+	names := make([]string, 0, len(resp.Header))
+	for k := range resp.Header {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		hd.Set(strings.ToLower(k), resp.Header.Get(k))
+	}
+	v, err := starlite.Call(sc.check, starlite.NewStruct(map[string]any{
+		"status":  int64(resp.StatusCode),
+		"headers": hd,
+		"body":    string(body),
+	}))
+	if err == nil && v != nil && !starlite.Truth(v) {
+		err = fmt.Errorf("%s() returned %s", ScriptCheck, starlite.Repr(v))
+	}
+	if err != nil {
+		sc.recordErr(err)
+	}
+	return err
+}
+
+func (sc *requestScript) recordErr(err error) {
+	sc.errOnce.Do(func() { sc.firstErr = err.Error() })
+}
+
+func (sc *requestScript) stats() *ScriptStats {
+	return &ScriptStats{File: sc.file, Check: sc.check != nil, FirstError: sc.firstErr}
+}
+
+// stringDict는 feed 행을 키 정렬된 dict로
+func stringDict(m map[string]string) *starlite.Dict {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	d := starlite.NewDict()
+	for _, k := range keys {
+		d.Set(k, m[k])
+	}
+	return d
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// ExportPathBench is what the real-mode recording adds to one pipeline
+// export, measured by BenchExportPath.
+type ExportPathBench struct {
+	Batches    int     `json:"batches"`
+	BatchSpans int     `json:"batch_spans"`
+	ExportNs   float64 `json:"export_ns_per_batch"`
+	// AddedAllocs는 기록 경로(시각 측정, 스케치 Record, 집계)만의 할당 수.
+	// 인코더 자체의 할당 변동(json)이 섞여 음수일 수도 있다
+	AddedAllocs         int64   `json:"added_allocs"`
+	AddedAllocsPerBatch float64 `json:"added_allocs_per_batch"`
+}
+
+// BenchExportPath exports one batch of cfg n times through the pipeline
+// exporter, first bare and then with the per-batch recording of a real-mode
+// run (two clock reads, sketch Record, tally), and reports the allocations
+// the recording adds to the operation it measures.
+func BenchExportPath(cfg Config, n int) (ExportPathBench, error) {
+	batch := cfg.BatchSize
+	if batch <= 0 {
+		batch = DefaultBatchSize
+	}
+	n = max(n, 1)
+	cfg.Workload = WorkloadPipeline
+	exp, err := newExporter(cfg, nil, nil, nil)
+	if err != nil {
+		return ExportPathBench{}, err
+	}
+	spans := genSpans(batch, spansPerTrace, 1)
+	// warm-up: 버퍼·압축기 초기 할당 제외
+	if _, err := exp.enc().encode(spans); err != nil {
+		return ExportPathBench{}, err
+	}
+	sk, err := stats.NewSketch(cfg.QuantileSketch, n)
+	if err != nil {
+		return ExportPathBench{}, err
+	}
+	t := newTally()
+	ctx := context.Background()
+
+	loop := func(record bool) (time.Duration, uint64) {
+		var m0, m1 runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&m0)
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			if !record {
+				exp.export(ctx, spans)
+				continue
+			}
+			t0 := time.Now()
+			out := exp.export(ctx, spans)
+			sk.Record(time.Since(t0))
+			t.add(out)
+		}
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&m1)
+		return elapsed, m1.Mallocs - m0.Mallocs
+	}
+	bare, bareAllocs := loop(false)
+	_, recAllocs := loop(true)
+	added := int64(recAllocs) - int64(bareAllocs)
+	return ExportPathBench{
+		Batches:             n,
+		BatchSpans:          batch,
+		ExportNs:            stats.Round2(float64(bare) / float64(n)),
+		AddedAllocs:         added,
+		AddedAllocsPerBatch: stats.Round5(float64(added) / float64(n)),
+	}, nil
+}
@@ -0,0 +1,318 @@
+package engine
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// SoakOptions configures a soak run: cfg is measured repeatedly for Duration,
+// and a checkpoint is emitted every CheckpointEvery.
+type SoakOptions struct {
+	Duration        time.Duration
+	CheckpointEvery time.Duration
+	// OnCheckpoint receives the report so far; returning an error aborts.
+	OnCheckpoint func(SoakReport) error
+}
+
+// Checkpoint aggregates the runs of one checkpoint window.
+type Checkpoint struct {
+	ElapsedS   float64 `json:"elapsed_s"`
+	Runs       int     `json:"runs"`
+	P95ms      float64 `json:"p95_ms"` // 윈도우 내 run별 p95 평균
+	ErrorRate  float64 `json:"error_rate"`
+	SizeKB     float64 `json:"size_kb"`
+	HeapInuseK float64 `json:"heap_inuse_kb"`
+	// batch processor(Config.BatchTimeout) 사용 시: 윈도우 내 run별 평균, 메모리는 최대
+	DropRate        float64 `json:"drop_rate,omitempty"`
+	BlockedRatio    float64 `json:"blocked_ratio,omitempty"`
+	BatchDelayP95ms float64 `json:"batch_delay_p95_ms,omitempty"`
+	QueueMemPeakKB  float64 `json:"queue_mem_peak_kb,omitempty"`
+}
+
+// SoakReport is the soak section of a result.
+type SoakReport struct {
+	DurationS   float64      `json:"duration_s"`
+	Runs        int          `json:"runs"`
+	Interrupted bool         `json:"interrupted,omitempty"`
+	Checkpoints []Checkpoint `json:"checkpoints"`
+	// 추세: 체크포인트에 대한 최소제곱 기울기(시간당)
+	P95DriftMsPerHour   float64 `json:"p95_drift_ms_per_hour"`
+	HeapGrowthKBPerHour float64 `json:"heap_growth_kb_per_hour"`
+	// Backpressure summarizes the batch processor under the offered load.
+	Backpressure *BackpressureReport `json:"backpressure,omitempty"`
+}
+
+// Backpressure symptoms reported by a soak through the batch processor.
+const (
+	SymptomDrops         = "drops"
+	SymptomBlocking      = "blocking"
+	SymptomLatencyGrowth = "latency_growth"
+)
+
+// 증상 판정 기준
+const (
+	dropSymptomRate    = 0.001 // 도착 span 중 버린 비율
+	blockSymptomRatio  = 0.05  // 도착이 멈춘 시간 비율
+	growthSymptomShare = 0.5   // run 내 지연 증가가 배치 지연 p95에서 차지하는 비율
+)
+
+// BackpressureReport is how the batch processor queue behaved over a soak
+// at the offered span rate: whether overload turned into drops, blocked
+// arrival or growing delay, the evidence for choosing a backpressure policy
+// and queue bound (Config.Backpressure, Config.QueueMem).
+type BackpressureReport struct {
+	Policy        string `json:"policy"`
+	QueueMemBytes int64  `json:"queue_mem_bytes,omitempty"`
+	QueueSize     int    `json:"queue_size,omitempty"`
+	// OfferedSpanRate is Config.SpanRate (0: unpaced); SpansPerSec is the
+	// mean exported throughput.
+	OfferedSpanRate     float64 `json:"offered_span_rate"`
+	SpansPerSec         float64 `json:"spans_per_sec"`
+	DropRate            float64 `json:"drop_rate"`
+	BlockedRatio        float64 `json:"blocked_ratio"`
+	DelayP95ms          float64 `json:"delay_p95_ms"`
+	DelayGrowthMs       float64 `json:"delay_growth_ms"` // per run, mean
+	DelayDriftMsPerHour float64 `json:"delay_drift_ms_per_hour"`
+	QueueMemPeakBytes   int64   `json:"queue_mem_peak_bytes"`
+	// Symptoms lists the observed overload behaviors (SymptomDrops,
+	// SymptomBlocking, SymptomLatencyGrowth); empty means the queue kept up.
+	Symptoms []string `json:"symptoms"`
+}
+
+// Soak measures cfg repeatedly until opts.Duration elapses or ctx is done.
+// Cancellation ends the soak early and is reported, not treated as an error.
+// The returned result's metrics are averages over all runs.
+func (e *Engine) Soak(ctx context.Context, cfg Config, opts SoakOptions) (Result, error) {
+	if err := cfg.Validate(); err != nil {
+		return Result{}, err
+	}
+	if opts.CheckpointEvery <= 0 || opts.CheckpointEvery > opts.Duration {
+		opts.CheckpointEvery = opts.Duration
+	}
+
+	var ctl *chaosController
+	if len(cfg.Chaos) > 0 {
+		// fault 타임라인은 soak 전체 기준
+		ctl = startChaos(ctx, cfg.Chaos, cfg.MTTR)
+		cfg.chaos = ctl
+		defer ctl.stop()
+	}
+	start := time.Now()
+	deadline := start.Add(opts.Duration)
+	nextCP := start.Add(opts.CheckpointEvery)
+	rep := &SoakReport{}
+	var last Result
+	var win, total soakAgg
+	var hm *stats.Heatmap
+	var tl *stats.Timeline
+
+	checkpoint := func(now time.Time) error {
+		if win.runs == 0 {
+			return nil
+		}
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		n := float64(win.runs)
+		cp := Checkpoint{
+			ElapsedS:   stats.Round2(now.Sub(start).Seconds()),
+			Runs:       win.runs,
+			P95ms:      stats.Round5(win.p95 / n),
+			ErrorRate:  stats.Round5(win.errRate / n),
+			SizeKB:     stats.Round2(win.size / n),
+			HeapInuseK: stats.Round2(float64(ms.HeapInuse) / 1024),
+		}
+		if win.bp.runs > 0 {
+			cp.DropRate = stats.Round5(win.bp.dropRate())
+			cp.BlockedRatio = stats.Round5(win.bp.blocked / n)
+			cp.BatchDelayP95ms = stats.Round5(win.bp.delayP95 / n)
+			cp.QueueMemPeakKB = stats.Round2(float64(win.bp.memPeak) / 1024)
+		}
+		rep.Checkpoints = append(rep.Checkpoints, cp)
+		win = soakAgg{}
+		rep.trend()
+		rep.DurationS = stats.Round2(now.Sub(start).Seconds())
+		rep.Runs = total.runs
+		if opts.OnCheckpoint != nil {
+			return opts.OnCheckpoint(*rep)
+		}
+		return nil
+	}
+
+	for time.Now().Before(deadline) {
+		r, err := e.Run(ctx, cfg)
+		if ctx.Err() != nil {
+			rep.Interrupted = true
+			break
+		}
+		if err != nil {
+			return Result{}, err
+		}
+		last = r
+		win.add(r)
+		total.add(r)
+		if r.Heatmap != nil {
+			if hm == nil {
+				hm = r.Heatmap
+			} else {
+				hm.Merge(r.Heatmap)
+			}
+		}
+		if r.Timeline != nil {
+			if tl == nil {
+				tl = r.Timeline
+			} else {
+				tl.Merge(r.Timeline)
+			}
+		}
+		if now := time.Now(); !now.Before(nextCP) {
+			if err := checkpoint(now); err != nil {
+				return Result{}, err
+			}
+			nextCP = nextCP.Add(opts.CheckpointEvery)
+		}
+		if ctl != nil && cfg.MTTR != nil && ctl.recovered() {
+			break // 복귀 관측 시 조기 종료
+		}
+	}
+	if err := checkpoint(time.Now()); err != nil {
+		return Result{}, err
+	}
+	rep.DurationS = stats.Round2(time.Since(start).Seconds())
+	rep.Runs = total.runs
+	rep.trend()
+	if total.bp.runs > 0 {
+		rep.Backpressure = total.bp.report(cfg, rep)
+	}
+
+	res := last
+	if total.runs > 0 {
+		n := float64(total.runs)
+		res.P95ms = stats.Round5(total.p95 / n)
+		res.ErrorRate = stats.Round5(total.errRate / n)
+		res.SizeKB = stats.Round2(total.size / n)
+		res.P99ms = 0 // run별 p99는 평균 의미가 없어 생략
+		res.Errors, res.StatusCodes = total.errs, total.status
+	}
+	res.Soak = rep
+	res.Heatmap = hm
+	res.Timeline = tl
+	if ctl != nil {
+		ctl.stop()
+		var err error
+		if res.Chaos, err = ctl.report(); err != nil {
+			return Result{}, err
+		}
+		res.MTTR = ctl.mttrReport()
+	}
+	return res, nil
+}
+
+type soakAgg struct {
+	runs               int
+	p95, errRate, size float64
+	errs, status       map[string]int
+	bp                 backpressureAgg
+}
+
+func (a *soakAgg) add(r Result) {
+	a.runs++
+	a.p95 += r.P95ms
+	a.errRate += r.ErrorRate
+	a.size += r.SizeKB
+	a.errs = addCounts(a.errs, r.Errors)
+	a.status = addCounts(a.status, r.StatusCodes)
+	if r.Batching != nil {
+		a.bp.add(*r.Batching)
+	}
+}
+
+// backpressureAgg는 run별 BatchStats 합(메모리는 최대)
+type backpressureAgg struct {
+	runs                                   int
+	arrived, dropped                       int
+	blocked, delayP95, growth, spansPerSec float64
+	memPeak                                int64
+	last                                   BatchStats
+}
+
+func (a *backpressureAgg) add(b BatchStats) {
+	a.runs++
+	a.arrived += b.Arrived
+	a.dropped += b.Dropped
+	a.blocked += b.BlockedRatio
+	a.delayP95 += b.DelayP95ms
+	a.growth += b.DelayGrowthMs
+	a.spansPerSec += b.SpansPerSec
+	a.memPeak = max(a.memPeak, b.QueueMemPeakBytes)
+	a.last = b
+}
+
+func (a *backpressureAgg) dropRate() float64 {
+	if a.arrived == 0 {
+		return 0
+	}
+	return float64(a.dropped) / float64(a.arrived)
+}
+
+func (a *backpressureAgg) report(cfg Config, rep *SoakReport) *BackpressureReport {
+	n := float64(a.runs)
+	hours := make([]float64, 0, len(rep.Checkpoints))
+	delay := make([]float64, 0, len(rep.Checkpoints))
+	for _, c := range rep.Checkpoints {
+		hours = append(hours, c.ElapsedS/3600)
+		delay = append(delay, c.BatchDelayP95ms)
+	}
+	br := &BackpressureReport{
+		Policy:              a.last.Backpressure,
+		QueueMemBytes:       a.last.QueueMemBytes,
+		QueueSize:           a.last.QueueSize,
+		OfferedSpanRate:     cfg.SpanRate,
+		SpansPerSec:         stats.Round2(a.spansPerSec / n),
+		DropRate:            stats.Round5(a.dropRate()),
+		BlockedRatio:        stats.Round5(a.blocked / n),
+		DelayP95ms:          stats.Round5(a.delayP95 / n),
+		DelayGrowthMs:       stats.Round5(a.growth / n),
+		DelayDriftMsPerHour: stats.Round5(stats.Slope(hours, delay)),
+		QueueMemPeakBytes:   a.memPeak,
+		Symptoms:            []string{},
+	}
+	if br.DropRate > dropSymptomRate {
+		br.Symptoms = append(br.Symptoms, SymptomDrops)
+	}
+	if br.BlockedRatio > blockSymptomRatio {
+		br.Symptoms = append(br.Symptoms, SymptomBlocking)
+	}
+	if br.DelayGrowthMs > br.DelayP95ms*growthSymptomShare {
+		br.Symptoms = append(br.Symptoms, SymptomLatencyGrowth)
+	}
+	return br
+}
+
+func addCounts(dst, src map[string]int) map[string]int {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = map[string]int{}
+	}
+	for k, v := range src {
+		dst[k] += v
+	}
+	return dst
+}
+
+func (rep *SoakReport) trend() {
+	hours := make([]float64, len(rep.Checkpoints))
+	p95 := make([]float64, len(rep.Checkpoints))
+	heap := make([]float64, len(rep.Checkpoints))
+	for i, c := range rep.Checkpoints {
+		hours[i] = c.ElapsedS / 3600
+		p95[i] = c.P95ms
+		heap[i] = c.HeapInuseK
+	}
+	rep.P95DriftMsPerHour = stats.Round5(stats.Slope(hours, p95))
+	rep.HeapGrowthKBPerHour = stats.Round2(stats.Slope(hours, heap))
+}
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+)
+
+// 실측 모드에서 사용하는 합성 span
+type span struct {
+	TraceID  [16]byte
+	SpanID   [8]byte
+	ParentID [8]byte
+	Name     string
+	Start    int64 // unix nano
+	End      int64
+	Attrs    []attr
+}
+
+type attr struct {
+	Key   string
+	Value string
+}
+
+var spanNames = []string{"http.request", "db.query", "cache.get", "emotion.update", "memory.recall", "rpc.call"}
+
+// genSpans는 고정 seed로 trace당 spansPerTrace개의 span을 만든다(재현성)
+func genSpans(n, spansPerTrace int, seed int64) []span {
+	rng := rand.New(rand.NewSource(seed))
+	out := make([]span, 0, n)
+	start := int64(1_700_000_000_000_000_000)
+	var trace [16]byte
+	var root [8]byte
+	for i := 0; i < n; i++ {
+		s := span{Start: start + int64(i)*1_000_000}
+		if i%spansPerTrace == 0 {
+			rng.Read(trace[:])
+			rng.Read(root[:])
+			s.SpanID = root
+		} else {
+			rng.Read(s.SpanID[:])
+			s.ParentID = root
+		}
+		s.TraceID = trace
+		s.Name = spanNames[rng.Intn(len(spanNames))]
+		s.End = s.Start + int64(rng.Intn(50_000_000))
+		s.Attrs = []attr{
+			{"service.name", "duri-core"},
+			{"node.id", fmt.Sprintf("node-%d", rng.Intn(8))},
+			{"status", []string{"ok", "ok", "ok", "error"}[rng.Intn(4)]},
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// trace ID 기반 결정론적 샘플링(TraceIDRatio와 동일한 방식)
+func sampled(traceID [16]byte, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	return binary.BigEndian.Uint64(traceID[8:]) < uint64(rate*(1<<63))*2
+}
@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// DefaultLookups is the number of trace-ID queries after the write phase.
+const DefaultLookups = 100
+
+// StorageConfig selects a storage-write benchmark (RunStorage).
+type StorageConfig struct {
+	Backend   BackendConfig
+	Spans     int // spans written (default DefaultSpans)
+	BatchSize int // spans per write (default DefaultBatchSize)
+	Workers   int // concurrent writers (default 1)
+	// Lookups trace IDs are queried after Settle, the time the backend gets
+	// to flush its ingest path (Tempo/Jaeger buffer before traces are
+	// queryable).
+	Lookups int
+	Settle  time.Duration
+	// StorageDir is the backend's local data directory; its growth over
+	// the run gives the write amplification for backends that do not
+	// report stored bytes themselves (Tempo, Jaeger).
+	StorageDir string
+	// Seed varies the generated trace IDs between runs (0: time-based),
+	// so repeated runs do not overwrite each other's traces.
+	Seed int64
+}
+
+// StorageResult reports ingest throughput, write amplification and
+// query-by-trace-ID latency of one backend.
+type StorageResult struct {
+	Backend     string         `json:"backend"`
+	Spans       int            `json:"spans"`
+	Batches     int            `json:"batches"`
+	BytesSent   int64          `json:"bytes_sent"`
+	DurationS   float64        `json:"duration_s"`
+	SpansPerSec float64        `json:"spans_per_sec"`
+	MBPerSec    float64        `json:"mb_per_sec"`
+	WriteP95ms  float64        `json:"write_p95_ms"`
+	WriteP99ms  float64        `json:"write_p99_ms"`
+	ErrorRate   float64        `json:"error_rate"`
+	Errors      map[string]int `json:"errors,omitempty"`
+	// StoredBytes is the backend's on-disk growth over the run;
+	// WriteAmplification is StoredBytes / BytesSent.
+	StoredBytes        int64   `json:"stored_bytes,omitempty"`
+	WriteAmplification float64 `json:"write_amplification,omitempty"`
+	Lookups            int     `json:"lookups"`
+	LookupP95ms        float64 `json:"lookup_p95_ms"`
+	LookupP99ms        float64 `json:"lookup_p99_ms"`
+	// LookupMissing counts trace IDs not (yet) queryable after Settle.
+	LookupMissing int `json:"lookup_missing"`
+	LookupErrors  int `json:"lookup_errors"`
+}
+
+// RunStorage writes generated traces into the configured backend and then
+// queries a sample of them back by trace ID.
+func RunStorage(ctx context.Context, cfg StorageConfig) (StorageResult, error) {
+	spans, batch := cfg.Spans, cfg.BatchSize
+	if spans <= 0 {
+		spans = DefaultSpans
+	}
+	if batch <= 0 {
+		batch = DefaultBatchSize
+	}
+	workers := max(cfg.Workers, 1)
+	lookups := cfg.Lookups
+	if lookups == 0 {
+		lookups = DefaultLookups
+	}
+	be, err := newTraceBackend(cfg.Backend, workers)
+	if err != nil {
+		return StorageResult{}, err
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	all := genSpans(spans, spansPerTrace, seed)
+	rebaseSpans(all, time.Now())
+	var batches [][]span
+	for i := 0; i < len(all); i += batch {
+		batches = append(batches, all[i:min(i+batch, len(all))])
+	}
+
+	stored0, storedErr := storedBytes(ctx, be, cfg.StorageDir)
+	sketches := make([]stats.Sketch, workers)
+	tallies := make([]tally, workers)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		sketches[w] = stats.NewRing(len(batches)/workers + 1)
+		tallies[w] = newTally()
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(batches); i += workers {
+				if ctx.Err() != nil {
+					return
+				}
+				t0 := time.Now()
+				n, err := be.write(ctx, batches[i])
+				sketches[w].Record(time.Since(t0))
+				out := exportOutcome{bytes: n}
+				if err != nil {
+					out.class = backendErrorClass(err)
+				}
+				tallies[w].add(out)
+			}
+		}(w)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	if err := ctx.Err(); err != nil {
+		return StorageResult{}, err
+	}
+	lat, _ := stats.MergeSketches(sketches...)
+	total := newTally()
+	for _, t := range tallies {
+		total.merge(t)
+	}
+	ops := float64(max(total.ops, 1))
+	r := StorageResult{
+		Backend:     cfg.Backend.Kind,
+		Spans:       len(all),
+		Batches:     total.ops,
+		BytesSent:   int64(total.bytes),
+		DurationS:   stats.Round5(elapsed.Seconds()),
+		SpansPerSec: stats.Round2(float64(len(all)) / elapsed.Seconds()),
+		MBPerSec:    stats.Round5(float64(total.bytes) / (1 << 20) / elapsed.Seconds()),
+		WriteP95ms:  stats.Round5(lat.Quantile(0.95)),
+		WriteP99ms:  stats.Round5(lat.Quantile(0.99)),
+		ErrorRate:   stats.Round5(float64(total.failed) / ops),
+		Errors:      total.errorsOrNil(),
+	}
+
+	select {
+	case <-time.After(cfg.Settle):
+	case <-ctx.Done():
+		return StorageResult{}, ctx.Err()
+	}
+	if storedErr == nil {
+		stored1, err := storedBytes(ctx, be, cfg.StorageDir)
+		if err == nil && total.bytes > 0 {
+			r.StoredBytes = stored1 - stored0
+			r.WriteAmplification = stats.Round5(float64(r.StoredBytes) / float64(total.bytes))
+		}
+	}
+	if lookups > 0 {
+		if err := lookupTraces(ctx, be, all, lookups, &r); err != nil {
+			return StorageResult{}, err
+		}
+	}
+	return r, nil
+}
+
+// storedBytes는 백엔드 보고값, 없으면 -storage-dir 크기
+func storedBytes(ctx context.Context, be traceBackend, dir string) (int64, error) {
+	n, err := be.storedBytes(ctx)
+	if errors.Is(err, errNoStoredBytes) && dir != "" {
+		return dirBytes(dir)
+	}
+	return n, err
+}
+
+// lookupTraces는 쓴 trace 중 고르게 lookups개를 ID로 조회한다
+func lookupTraces(ctx context.Context, be traceBackend, all []span, lookups int, r *StorageResult) error {
+	traces := len(all) / spansPerTrace
+	if traces == 0 {
+		return fmt.Errorf("no traces to look up")
+	}
+	n := min(lookups, traces)
+	lat := stats.NewRing(n)
+	for i := 0; i < n; i++ {
+		id := hex.EncodeToString(all[(i*traces/n)*spansPerTrace].TraceID[:])
+		t0 := time.Now()
+		found, err := be.traceByID(ctx, id)
+		lat.Record(time.Since(t0))
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			r.LookupErrors++
+		case !found:
+			r.LookupMissing++
+		}
+	}
+	r.Lookups = n
+	r.LookupP95ms = stats.Round5(lat.Quantile(0.95))
+	r.LookupP99ms = stats.Round5(lat.Quantile(0.99))
+	return nil
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	mrand "math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// BodyVars is the data passed to Config.BodyTemplate on every export.
+type BodyVars struct {
+	Seq   int64             // run 전체에서 단조 증가(워커 간 공유)
+	Spans int               // 배치의 span 수
+	Row   map[string]string // -feed 행(없으면 nil)
+}
+
+// bodyTemplate은 Go template 요청 본문. 모든 워커가 공유한다
+type bodyTemplate struct {
+	t     *template.Template
+	seq   atomic.Int64
+	feed  *feed
+	lines lineFiles
+}
+
+func newBodyTemplate(text string, fd *feed) (*bodyTemplate, error) {
+	bt := &bodyTemplate{feed: fd}
+	t, err := template.New("body").Funcs(template.FuncMap{
+		"uuid":        newUUID,
+		"randomLine":  bt.lines.randomLine,
+		"randInt":     func(lo, hi int) int { return lo + mrand.Intn(hi-lo+1) },
+		"nowUnixNano": func() int64 { return time.Now().UnixNano() },
+	}).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+	bt.t = t
+	// 측정 전에 한 번 렌더링해 함수 인자/파일 오류를 미리 드러냄(seq는 소비하지 않음)
+	dry := BodyVars{Seq: -1}
+	if fd != nil {
+		dry.Row = fd.rows[0]
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, dry); err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+	return bt, nil
+}
+
+// render는 다음 seq로 본문을 buf에 렌더링한다
+func (bt *bodyTemplate) render(buf *bytes.Buffer, spans int) error {
+	v := BodyVars{Spans: spans}
+	if bt.feed != nil {
+		row, err := bt.feed.draw()
+		if err != nil {
+			return err
+		}
+		v.Row = row
+	}
+	v.Seq = bt.seq.Add(1) - 1
+	buf.Reset()
+	return bt.t.Execute(buf, v)
+}
+
+// lineFiles는 randomLine용 파일 캐시(body template/script가 각자 하나씩)
+type lineFiles struct {
+	mu    sync.Mutex
+	files map[string][]string
+}
+
+// randomLine은 파일의 임의 비어 있지 않은 줄(파일은 최초 1회만 읽음)
+func (lf *lineFiles) randomLine(path string) (string, error) {
+	lf.mu.Lock()
+	lines, ok := lf.files[path]
+	if !ok {
+		var err error
+		if lines, err = readLines(path); err != nil {
+			lf.mu.Unlock()
+			return "", err
+		}
+		if lf.files == nil {
+			lf.files = map[string][]string{}
+		}
+		lf.files[path] = lines
+	}
+	lf.mu.Unlock()
+	return lines[mrand.Intn(len(lines))], nil
+}
+
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if l := sc.Text(); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%s: no lines", path)
+	}
+	return lines, nil
+}
+
+// RFC 4122 v4
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// msgpack/protobuf/zstd 최소 인코더 (외부 의존성 없이 유지)
+
+// --- msgpack ---
+
+func appendMsgpackSpans(b []byte, spans []span) []byte {
+	b = mpArray(b, len(spans))
+	for i := range spans {
+		s := &spans[i]
+		n := 6
+		if s.ParentID != ([8]byte{}) {
+			n++
+		}
+		b = append(b, 0x80|byte(n)) // fixmap
+		b = mpStr(b, "trace_id")
+		b = mpBin(b, s.TraceID[:])
+		b = mpStr(b, "span_id")
+		b = mpBin(b, s.SpanID[:])
+		if s.ParentID != ([8]byte{}) {
+			b = mpStr(b, "parent_span_id")
+			b = mpBin(b, s.ParentID[:])
+		}
+		b = mpStr(b, "name")
+		b = mpStr(b, s.Name)
+		b = mpStr(b, "start")
+		b = mpInt(b, s.Start)
+		b = mpStr(b, "end")
+		b = mpInt(b, s.End)
+		b = mpStr(b, "attributes")
+		b = append(b, 0x80|byte(len(s.Attrs)))
+		for _, a := range s.Attrs {
+			b = mpStr(b, a.Key)
+			b = mpStr(b, a.Value)
+		}
+	}
+	return b
+}
+
+func mpArray(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xdc), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdd), uint32(n))
+	}
+}
+
+func mpStr(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+func mpBin(b, v []byte) []byte {
+	return append(append(b, 0xc4, byte(len(v))), v...)
+}
+
+func mpInt(b []byte, v int64) []byte {
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(v))
+}
+
+// --- protobuf (OTLP Span 필드 번호 기준) ---
+
+const (
+	pbVarint  = 0
+	pbFixed64 = 1
+	pbBytes   = 2
+)
+
+func appendProtoSpans(b []byte, spans []span) []byte {
+	var msg, kv []byte
+	for i := range spans {
+		s := &spans[i]
+		msg = msg[:0]
+		msg = pbBytesField(msg, 1, s.TraceID[:])
+		msg = pbBytesField(msg, 2, s.SpanID[:])
+		if s.ParentID != ([8]byte{}) {
+			msg = pbBytesField(msg, 4, s.ParentID[:])
+		}
+		msg = pbBytesField(msg, 5, []byte(s.Name))
+		msg = binary.LittleEndian.AppendUint64(pbTag(msg, 7, pbFixed64), uint64(s.Start))
+		msg = binary.LittleEndian.AppendUint64(pbTag(msg, 8, pbFixed64), uint64(s.End))
+		for _, a := range s.Attrs {
+			kv = kv[:0]
+			kv = pbBytesField(kv, 1, []byte(a.Key))
+			// AnyValue{string_value=1}
+			kv = pbTag(kv, 2, pbBytes)
+			kv = binary.AppendUvarint(kv, uint64(len(a.Value)+1+uvarintLen(uint64(len(a.Value)))))
+			kv = pbBytesField(kv, 1, []byte(a.Value))
+			msg = pbBytesField(msg, 9, kv)
+		}
+		b = pbBytesField(b, 1, msg)
+	}
+	return b
+}
+
+func pbTag(b []byte, field, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wire))
+}
+
+func pbBytesField(b []byte, field int, v []byte) []byte {
+	b = pbTag(b, field, pbBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func uvarintLen(v uint64) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+// --- zstd ---
+//
+// 표준 라이브러리에 zstd 인코더가 없으므로 raw block으로만 구성된 유효한
+// zstd 프레임을 만든다. 디코딩 가능하지만 압축률은 반영되지 않는다(크기=원본+헤더).
+// 그래서 real/hybrid run은 zstd를 거부하고(Config.Validate), 이 프레임은
+// 브로커가 zstd 메시지를 받아들이는지 보는 queue bench에만 쓰인다.
+const zstdMaxBlock = 128 << 10
+
+func appendZstdRawFrame(b, src []byte) []byte {
+	b = binary.LittleEndian.AppendUint32(b, 0xFD2FB528)
+	b = append(b, 0xE0) // FCS 8 bytes, single segment
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(src)))
+	for {
+		n := len(src)
+		if n > zstdMaxBlock {
+			n = zstdMaxBlock
+		}
+		last := uint32(0)
+		if n == len(src) {
+			last = 1
+		}
+		hdr := last | uint32(n)<<3 // block type 0 = raw
+		b = append(b, byte(hdr), byte(hdr>>8), byte(hdr>>16))
+		b = append(b, src[:n]...)
+		src = src[n:]
+		if last == 1 {
+			return b
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoBaseline is returned when no entry carries the requested name.
+var ErrNoBaseline = errors.New("no such baseline")
+
+// SetBaseline tags the entry with runID as name, or appends e tagged as
+// name when runID is empty or unknown. A name labels at most one entry:
+// any previous holder loses it.
+func (db *DB) SetBaseline(name, runID string, e *Entry) error {
+	if name == "" {
+		return fmt.Errorf("baseline name is empty")
+	}
+	return db.Rewrite(func(all []Entry) ([]Entry, error) {
+		found := false
+		for i := range all {
+			all[i].Tags = without(all[i].Tags, name)
+			if runID != "" && all[i].RunID == runID {
+				all[i].Tags = append(all[i].Tags, name)
+				found = true
+			}
+		}
+		if found {
+			return all, nil
+		}
+		if e == nil {
+			return nil, fmt.Errorf("run %s not found in %s", runID, db.Path)
+		}
+		ne := *e
+		if ne.Time.IsZero() {
+			ne.Time = time.Now().UTC()
+		}
+		ne.Tags = append(without(ne.Tags, name), name)
+		return append(all, ne), nil
+	})
+}
+
+// Baseline returns the entry tagged name.
+func (db *DB) Baseline(name string) (Entry, error) {
+	all, err := db.Entries()
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, e := range all {
+		for _, t := range e.Tags {
+			if t == name {
+				return e, nil
+			}
+		}
+	}
+	return Entry{}, fmt.Errorf("%w: %s", ErrNoBaseline, name)
+}
+
+// Baselines returns the tagged entries in file order.
+func (db *DB) Baselines() ([]Entry, error) {
+	all, err := db.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var out []Entry
+	for _, e := range all {
+		if e.Tagged() {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func without(tags []string, name string) []string {
+	out := tags[:0]
+	for _, t := range tags {
+		if t != name {
+			out = append(out, t)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
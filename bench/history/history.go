@@ -0,0 +1,185 @@
+// Package history is the run history DB: one JSON entry per line in a
+// local file, so trends, baselines and retention work without a server.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/internal/flock"
+	"github.com/duri/trace_bench/output"
+)
+
+// EnvPath names the environment variable holding the default DB path.
+const EnvPath = "TRACE_BENCH_HISTORY"
+
+// Entry is one recorded run.
+type Entry struct {
+	RunID  string            `json:"run_id"`
+	Time   time.Time         `json:"time"`
+	Labels map[string]string `json:"labels"`
+	Result engine.Result     `json:"result"`
+	// Tags는 baseline 이름 등. 태그된 run은 보존 정책에서 제외할 수 있다
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Tagged reports whether the entry carries any tag.
+func (e Entry) Tagged() bool { return len(e.Tags) > 0 }
+
+// DefaultLockTimeout bounds how long a read or write waits for other
+// writers of the same file (DB.LockTimeout).
+const DefaultLockTimeout = 30 * time.Second
+
+// DB is a history file. Processes sharing it (e.g. parallel CI shards on
+// one volume) serialize through an flock on the Path+".lock" sidecar:
+// Append and Rewrite take it exclusively, Entries shared, so a rewrite
+// never drops a concurrent append and readers never see a torn line.
+type DB struct {
+	Path string
+	// LockTimeout overrides DefaultLockTimeout.
+	LockTimeout time.Duration
+}
+
+// Open returns the DB at path (created on first append).
+func Open(path string) (*DB, error) {
+	if path == "" {
+		return nil, fmt.Errorf("history path is empty (set -history or %s)", EnvPath)
+	}
+	return &DB{Path: path}, nil
+}
+
+// lock은 sidecar lock 파일을 잡는다(rename으로 바뀌는 history 파일 자체가 아니라)
+func (db *DB) lock(exclusive bool) (func() error, error) {
+	timeout := db.LockTimeout
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+	unlock, err := flock.Lock(db.Path+".lock", exclusive, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("history %s: %w", db.Path, err)
+	}
+	return unlock, nil
+}
+
+// Append adds e at the end of the file.
+func (db *DB) Append(e Entry) error {
+	if err := os.MkdirAll(filepath.Dir(db.Path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	unlock, err := db.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(db.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	// 한 줄을 한 번의 write로(O_APPEND)
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Entries returns all entries in file order. A missing file is empty.
+func (db *DB) Entries() ([]Entry, error) {
+	if _, err := os.Stat(db.Path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil // 읽기만 하는 쪽은 lock 파일을 만들지 않는다
+	}
+	unlock, err := db.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return db.entries()
+}
+
+// entries는 lock 없이 읽는다(호출자가 lock을 잡고 있음)
+func (db *DB) entries() ([]Entry, error) {
+	f, err := os.Open(db.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 1<<20), 64<<20)
+	for n := 1; sc.Scan(); n++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", db.Path, n, err)
+		}
+		out = append(out, e)
+	}
+	return out, sc.Err()
+}
+
+// Rewrite replaces the file with fn(entries), atomically. Writers are
+// held off from the read until the rename, so no append is lost.
+func (db *DB) Rewrite(fn func([]Entry) ([]Entry, error)) error {
+	unlock, err := db.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	all, err := db.entries()
+	if err != nil {
+		return err
+	}
+	keep, err := fn(all)
+	if err != nil {
+		return err
+	}
+	return output.WriteFileAtomicFunc(db.Path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		for _, e := range keep {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Match reports whether the entry carries every key=value of filter.
+func (e Entry) Match(filter map[string]string) bool {
+	for k, v := range filter {
+		if w, ok := e.Labels[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the entries matching filter, in order.
+func Filter(all []Entry, filter map[string]string) []Entry {
+	if len(filter) == 0 {
+		return all
+	}
+	var out []Entry
+	for _, e := range all {
+		if e.Match(filter) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
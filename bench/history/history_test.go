@@ -0,0 +1,130 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDBAppendEntries(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "sub", "history.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 파일이 없으면 빈 기록(lock 파일도 만들지 않는다)
+	if es, err := db.Entries(); err != nil || len(es) != 0 {
+		t.Fatalf("Entries() on missing file = %v, %v, want empty", es, err)
+	}
+	if _, err := os.Stat(db.Path + ".lock"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Entries() created %s.lock", db.Path)
+	}
+	want := runs(10, 12.5, 11)
+	want[1].Tags = []string{"release"}
+	for _, e := range want {
+		if err := db.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := db.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Entries() = %d entries, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i].RunID != want[i].RunID || !got[i].Time.Equal(want[i].Time) || got[i].Result.P95ms != want[i].Result.P95ms ||
+			got[i].Labels["bench"] != "h2" || got[i].Tagged() != want[i].Tagged() {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	b, _ := os.ReadFile(db.Path)
+	if n := strings.Count(string(b), "\n"); n != len(want) {
+		t.Errorf("file has %d lines, want one JSON entry per line (%d)", n, len(want))
+	}
+}
+
+func TestDBEntriesMalformedLine(t *testing.T) {
+	db, _ := Open(filepath.Join(t.TempDir(), "history.jsonl"))
+	if err := db.Append(runs(10)[0]); err != nil {
+		t.Fatal(err)
+	}
+	f, _ := os.OpenFile(db.Path, os.O_WRONLY|os.O_APPEND, 0o644)
+	f.WriteString("\n{not json\n") // 빈 줄은 건너뛰고, 깨진 줄은 위치와 함께 실패
+	f.Close()
+	_, err := db.Entries()
+	if err == nil || !strings.Contains(err.Error(), db.Path+":3:") {
+		t.Errorf("Entries() = %v, want error at %s:3", err, db.Path)
+	}
+}
+
+func TestDBRewriteAndBaseline(t *testing.T) {
+	db, _ := Open(filepath.Join(t.TempDir(), "history.jsonl"))
+	for _, e := range runs(10, 11, 12) {
+		if err := db.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.SetBaseline("release", "run-00", nil); err != nil {
+		t.Fatal(err)
+	}
+	// 이름은 한 run에만: 다시 지정하면 이전 run에서 떨어진다
+	if err := db.SetBaseline("release", "run-02", nil); err != nil {
+		t.Fatal(err)
+	}
+	if e, err := db.Baseline("release"); err != nil || e.RunID != "run-02" {
+		t.Errorf("Baseline(release) = %s, %v, want run-02", e.RunID, err)
+	}
+	if bs, _ := db.Baselines(); len(bs) != 1 {
+		t.Errorf("Baselines() = %d entries, want 1", len(bs))
+	}
+	if _, err := db.Baseline("nightly"); !errors.Is(err, ErrNoBaseline) {
+		t.Errorf("Baseline(nightly) = %v, want ErrNoBaseline", err)
+	}
+	if err := db.SetBaseline("release", "run-99", nil); err == nil {
+		t.Error("SetBaseline(unknown run, nil) = nil, want error")
+	}
+
+	// 태그 없는 run만 지우는 보존 정책
+	err := db.Rewrite(func(all []Entry) ([]Entry, error) {
+		var keep []Entry
+		for _, e := range all {
+			if e.Tagged() {
+				keep = append(keep, e)
+			}
+		}
+		return keep, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if es, _ := db.Entries(); len(es) != 1 || es[0].RunID != "run-02" {
+		t.Errorf("after Rewrite: %v, want only run-02", es)
+	}
+	// fn이 실패하면 파일은 그대로
+	if err := db.Rewrite(func([]Entry) ([]Entry, error) { return nil, errors.New("boom") }); err == nil {
+		t.Error("Rewrite(failing fn) = nil, want error")
+	}
+	if es, _ := db.Entries(); len(es) != 1 {
+		t.Errorf("failed Rewrite changed the file: %v", es)
+	}
+}
+
+func TestOpenEmptyPath(t *testing.T) {
+	if _, err := Open(""); err == nil || !strings.Contains(err.Error(), EnvPath) {
+		t.Errorf("Open(\"\") = %v, want error naming %s", err, EnvPath)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	es := runs(1, 2)
+	es[1].Labels = map[string]string{"bench": "h2", "env": "ci"}
+	if got := Filter(es, map[string]string{"env": "ci"}); len(got) != 1 || got[0].RunID != "run-01" {
+		t.Errorf("Filter(env=ci) = %v, want run-01", got)
+	}
+	if got := Filter(es, nil); len(got) != 2 {
+		t.Errorf("Filter(nil) = %d entries, want all", len(got))
+	}
+}
@@ -0,0 +1,120 @@
+package history
+
+import (
+	"math"
+	"sort"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// ShiftOptions tunes rolling-baseline regression detection.
+type ShiftOptions struct {
+	Window  int      // 기준선: 같은 설정의 직전 N개 run
+	K       float64  // CUSUM allowance (σ 단위)
+	H       float64  // CUSUM decision interval (σ 단위)
+	MinRuns int      // 이만큼 연속된 run이 이동해야 회귀로 본다
+	Metrics []string // 비어 있으면 결과에 있는 모든 metric
+}
+
+// DefaultShiftOptions flags a shift of roughly 1σ sustained over 3+ runs.
+var DefaultShiftOptions = ShiftOptions{Window: 20, K: 0.5, H: 4, MinRuns: 3}
+
+// higherIsBetter는 감소가 회귀인 지표(나머지는 증가가 회귀)
+var higherIsBetter = map[string]bool{"spans_per_sec": true}
+
+// Shift is the verdict for one metric of the current run.
+type Shift struct {
+	Metric  string  `json:"metric"`
+	Current float64 `json:"current"`
+	Median  float64 `json:"median"`
+	Sigma   float64 `json:"sigma"`
+	CUSUM   float64 `json:"cusum"`
+	// Since는 CUSUM이 마지막으로 0을 벗어난 run(변화점)
+	Since     string `json:"since,omitempty"`
+	Runs      int    `json:"runs"`
+	Regressed bool   `json:"regressed"`
+}
+
+// Comparable returns the entries with exactly the labels of cur, oldest
+// first, excluding cur itself.
+func Comparable(all []Entry, cur Entry) []Entry {
+	var out []Entry
+	for _, e := range all {
+		if e.RunID != cur.RunID && sameLabels(e.Labels, cur.Labels) {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out
+}
+
+// DetectShifts compares cur against the median of the trailing window of
+// prev (oldest first) and runs an upward CUSUM over window+cur, so a
+// single noisy run does not count as a regression but a sustained one
+// does: the statistic must exceed H over at least MinRuns runs and cur
+// itself must still sit past the allowance. Higher is worse for every
+// bench metric except throughput (spans_per_sec), whose deviations are
+// negated so a drop counts as the regression.
+func DetectShifts(prev []Entry, cur Entry, opt ShiftOptions) []Shift {
+	if opt.Window > 0 && len(prev) > opt.Window {
+		prev = prev[len(prev)-opt.Window:]
+	}
+	series := append(append([]Entry(nil), prev...), cur)
+	metrics := opt.Metrics
+	if len(metrics) == 0 {
+		for k := range cur.Result.Metrics() {
+			metrics = append(metrics, k)
+		}
+		sort.Strings(metrics)
+	}
+	var out []Shift
+	for _, m := range metrics {
+		var ref []float64
+		for _, e := range prev {
+			if v, ok := e.Result.Metrics()[m]; ok {
+				ref = append(ref, v)
+			}
+		}
+		cv, ok := cur.Result.Metrics()[m]
+		if !ok || len(ref) == 0 {
+			continue
+		}
+		med := stats.Median(ref)
+		// σ 하한: 변동이 거의 없는 지표에서 미세한 차이가 튀지 않게
+		sigma := math.Max(stats.MAD(ref, med), math.Max(math.Abs(med)*0.01, 1e-9))
+		sign := 1.0
+		if higherIsBetter[m] {
+			sign = -1
+		}
+		var z []float64
+		var ids []string
+		for _, e := range series {
+			if v, ok := e.Result.Metrics()[m]; ok {
+				// 스파이크 하나가 CUSUM을 독점하지 않도록 ±H로 자른다
+				z = append(z, math.Max(-opt.H, math.Min(opt.H, sign*(v-med)/sigma)))
+				ids = append(ids, e.RunID)
+			}
+		}
+		cs := stats.CUSUM(z, opt.K)
+		s := Shift{Metric: m, Current: cv, Median: med, Sigma: sigma, CUSUM: cs[len(cs)-1]}
+		for i := len(cs) - 1; i >= 0 && cs[i] > 0; i-- {
+			s.Runs++
+			s.Since = ids[i]
+		}
+		s.Regressed = s.CUSUM > opt.H && s.Runs >= opt.MinRuns && z[len(z)-1] > opt.K
+		out = append(out, s)
+	}
+	return out
+}
+
+func sameLabels(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
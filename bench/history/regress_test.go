@@ -0,0 +1,138 @@
+package history
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+)
+
+var t0 = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// runs는 p95 값마다 하나씩, 한 시간 간격의 run을 만든다
+func runs(p95s ...float64) []Entry {
+	var out []Entry
+	for i, v := range p95s {
+		out = append(out, Entry{RunID: fmt.Sprintf("run-%02d", i), Time: t0.Add(time.Duration(i) * time.Hour),
+			Labels: map[string]string{"bench": "h2"}, Result: engine.Result{P95ms: v}})
+	}
+	return out
+}
+
+func flat(v float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = v
+	}
+	return out
+}
+
+func p95Shift(t *testing.T, series []Entry, opt ShiftOptions) (Shift, bool) {
+	t.Helper()
+	opt.Metrics = []string{"p95_ms"}
+	shifts := DetectShifts(series[:len(series)-1], series[len(series)-1], opt)
+	if len(shifts) == 0 {
+		return Shift{}, false
+	}
+	return shifts[0], true
+}
+
+func TestDetectShifts(t *testing.T) {
+	// 기준선 10ms(σ 하한 0.1ms), 10.5ms는 5σ 이동
+	sustained := append(flat(10, 17), 10.5, 10.5, 10.5, 10.5)
+	opt := DefaultShiftOptions
+	tests := []struct {
+		name      string
+		p95s      []float64
+		opt       func(*ShiftOptions)
+		regressed bool
+		since     string
+	}{
+		{name: "sustained shift", p95s: sustained, regressed: true, since: "run-17"},
+		// 튀는 run 하나는 CUSUM이 H를 넘지 못한다
+		{name: "single spike", p95s: append(flat(10, 20), 40)},
+		{name: "spike then recovery", p95s: append(append(flat(10, 18), 40), 10, 10)},
+		{name: "improvement", p95s: append(flat(10, 17), 9, 9, 9, 9)},
+		// 기준선이 이미 이동한 run뿐이면 그 값이 중앙값이 된다
+		{name: "window inside the shift", p95s: sustained, opt: func(o *ShiftOptions) { o.Window = 3 }},
+		{name: "fewer shifted runs than MinRuns", p95s: sustained, opt: func(o *ShiftOptions) { o.MinRuns = 5 }},
+		{name: "CUSUM below H", p95s: sustained, opt: func(o *ShiftOptions) { o.H = 20 }},
+		// 누적은 H를 넘었지만 현재 run은 기준선으로 돌아왔다
+		{name: "current run within K", p95s: append(flat(10, 16), 10.5, 10.5, 10.5, 10.5, 10.02)},
+	}
+	for _, tt := range tests {
+		o := opt
+		if tt.opt != nil {
+			tt.opt(&o)
+		}
+		s, ok := p95Shift(t, runs(tt.p95s...), o)
+		if !ok {
+			t.Errorf("%s: no verdict for p95_ms", tt.name)
+			continue
+		}
+		if s.Regressed != tt.regressed {
+			t.Errorf("%s: Regressed = %v, want %v (%+v)", tt.name, s.Regressed, tt.regressed, s)
+		}
+		if tt.since != "" && s.Since != tt.since {
+			t.Errorf("%s: Since = %q, want %q", tt.name, s.Since, tt.since)
+		}
+	}
+}
+
+func TestDetectShiftsTooShortWindow(t *testing.T) {
+	// 비교할 run이 없으면 판정하지 않는다
+	if shifts := DetectShifts(nil, runs(50)[0], DefaultShiftOptions); len(shifts) != 0 {
+		t.Errorf("DetectShifts(no baseline) = %+v, want none", shifts)
+	}
+	// run이 MinRuns보다 적으면 큰 이동도 회귀가 아니다
+	s, _ := p95Shift(t, runs(10, 10, 50), DefaultShiftOptions)
+	if s.Regressed {
+		t.Errorf("2-run baseline: Regressed = true, want false (%+v)", s)
+	}
+}
+
+func TestDetectShiftsThroughputDrop(t *testing.T) {
+	series := func(rates ...float64) []Entry {
+		es := runs(flat(10, len(rates))...)
+		for i := range es {
+			es[i].Result.Batching = &engine.BatchStats{SpansPerSec: rates[i]}
+		}
+		return es
+	}
+	opt := DefaultShiftOptions
+	opt.Metrics = []string{"spans_per_sec"}
+	tests := []struct {
+		name      string
+		rates     []float64
+		regressed bool
+	}{
+		// spans_per_sec는 감소가 회귀
+		{name: "drop", rates: append(flat(1000, 17), 900, 900, 900, 900), regressed: true},
+		{name: "rise", rates: append(flat(1000, 17), 1100, 1100, 1100, 1100)},
+	}
+	for _, tt := range tests {
+		es := series(tt.rates...)
+		shifts := DetectShifts(es[:len(es)-1], es[len(es)-1], opt)
+		if len(shifts) != 1 {
+			t.Fatalf("%s: DetectShifts = %+v, want one spans_per_sec verdict", tt.name, shifts)
+		}
+		if shifts[0].Regressed != tt.regressed {
+			t.Errorf("%s: Regressed = %v, want %v (%+v)", tt.name, shifts[0].Regressed, tt.regressed, shifts[0])
+		}
+	}
+}
+
+func TestComparable(t *testing.T) {
+	es := runs(1, 2, 3, 4)
+	es[1].Labels = map[string]string{"bench": "h1"}
+	es[2].Labels = map[string]string{"bench": "h2", "env": "ci"}
+	es[0], es[3] = es[3], es[0] // 파일 순서가 아니라 시각 순
+	got := Comparable(es, Entry{RunID: "cur", Labels: map[string]string{"bench": "h2"}})
+	if len(got) != 2 || got[0].RunID != "run-00" || got[1].RunID != "run-03" {
+		t.Errorf("Comparable = %v, want run-00, run-03", got)
+	}
+	if got := Comparable(es, es[0]); len(got) != 1 || got[0].RunID != "run-00" {
+		t.Errorf("Comparable(self) = %v, want only run-00 (cur excluded)", got)
+	}
+}
@@ -0,0 +1,182 @@
+// Package cronspec parses the five-field cron schedules used by
+// trace_bench schedule:
+//
+//	minute hour day-of-month month day-of-week
+//
+// Each field is *, a value, a range (1-5), a step (*/15, 0-30/10) or a
+// comma list of those; months and weekdays also take names (jan, mon).
+// Day-of-week 0 and 7 are Sunday. As in Vixie cron, when both day fields
+// are restricted a time matches if either one does. The spec may be
+// wrapped as cron(...) and the macros @hourly, @daily (@midnight),
+// @weekly, @monthly and @yearly (@annually) are accepted.
+package cronspec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron spec.
+type Schedule struct {
+	spec                          string
+	minute, hour, dom, month, dow uint64 // 허용 값 비트셋
+	domAny, dowAny                bool
+}
+
+var macros = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// Parse parses spec, e.g. "cron(0 3 * * *)", "*/15 9-18 * * mon-fri" or
+// "@daily".
+func Parse(spec string) (*Schedule, error) {
+	s := strings.TrimSpace(spec)
+	if strings.HasPrefix(s, "cron(") && strings.HasSuffix(s, ")") {
+		s = strings.TrimSpace(s[len("cron(") : len(s)-1])
+	}
+	if m, ok := macros[strings.ToLower(s)]; ok {
+		s = m
+	}
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: want 5 fields (minute hour day-of-month month day-of-week), got %d", spec, len(fields))
+	}
+	sc := &Schedule{spec: spec}
+	var err error
+	if sc.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("cron %q: minute: %w", spec, err)
+	}
+	if sc.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("cron %q: hour: %w", spec, err)
+	}
+	if sc.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("cron %q: day-of-month: %w", spec, err)
+	}
+	if sc.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("cron %q: month: %w", spec, err)
+	}
+	if sc.dow, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, fmt.Errorf("cron %q: day-of-week: %w", spec, err)
+	}
+	if sc.dow&(1<<7) != 0 {
+		sc.dow |= 1 // 7도 일요일
+	}
+	sc.domAny = fields[2] == "*" || strings.HasPrefix(fields[2], "*/")
+	sc.dowAny = fields[4] == "*" || strings.HasPrefix(fields[4], "*/")
+	if sc.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("cron %q: never fires", spec)
+	}
+	return sc, nil
+}
+
+// String returns the spec as given to Parse.
+func (s *Schedule) String() string { return s.spec }
+
+// Next returns the first matching minute strictly after t, in t's location,
+// or the zero time if none falls within the next five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		// 맞지 않는 가장 큰 단위부터 건너뛴다
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+func parseField(f string, lo, hi int, names []string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(f, ",") {
+		rng, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+			rng, step = part[:i], n
+		}
+		from, to := lo, hi
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if from, err = fieldValue(a, lo, hi, names); err != nil {
+				return 0, err
+			}
+			if to, err = fieldValue(b, lo, hi, names); err != nil {
+				return 0, err
+			}
+			if from > to {
+				return 0, fmt.Errorf("bad range %q", rng)
+			}
+		default:
+			v, err := fieldValue(rng, lo, hi, names)
+			if err != nil {
+				return 0, err
+			}
+			from, to = v, v
+			if step > 1 {
+				to = hi // 5/15 = 5부터 끝까지 15 간격
+			}
+		}
+		for v := from; v <= to; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func fieldValue(s string, lo, hi int, names []string) (int, error) {
+	for i, n := range names {
+		if strings.EqualFold(s, n) {
+			return i + lo, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad value %q", s)
+	}
+	if v < lo || v > hi {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, lo, hi)
+	}
+	return v, nil
+}
@@ -0,0 +1,97 @@
+package cronspec
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	kst := time.FixedZone("KST", 9*3600)
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	// 2024-01-15는 월요일
+	for _, c := range []struct {
+		spec, from, want string
+	}{
+		{"*/15 * * * *", "2024-01-15 10:07", "2024-01-15 10:15"},
+		{"*/15 * * * *", "2024-01-15 10:15", "2024-01-15 10:30"}, // 엄밀히 이후
+		{"5/20 * * * *", "2024-01-15 10:07", "2024-01-15 10:25"},
+		{"0-30/10 * * * *", "2024-01-15 10:31", "2024-01-15 11:00"},
+		{"0,45 * * * *", "2024-01-15 10:07", "2024-01-15 10:45"},
+		{"cron(0 3 * * *)", "2024-01-15 10:07", "2024-01-16 03:00"},
+		{" cron( 30 2 * * * ) ", "2024-01-15 10:07", "2024-01-16 02:30"},
+		{"@hourly", "2024-01-15 10:07", "2024-01-15 11:00"},
+		{"@Daily", "2024-01-15 10:07", "2024-01-16 00:00"},
+		{"@weekly", "2024-01-15 10:07", "2024-01-21 00:00"},
+		{"@monthly", "2024-01-15 10:07", "2024-02-01 00:00"},
+		{"@annually", "2024-01-15 10:07", "2025-01-01 00:00"},
+		{"0 9-18 * * mon-fri", "2024-01-19 19:00", "2024-01-22 09:00"},
+		{"0 0 * * 7", "2024-01-15 10:07", "2024-01-21 00:00"},
+		{"0 0 * * 0", "2024-01-15 10:07", "2024-01-21 00:00"},
+		{"0 0 1 Jan *", "2024-01-15 10:07", "2025-01-01 00:00"},
+		{"0 0 * dec *", "2024-01-15 10:07", "2024-12-01 00:00"},
+		// 두 day 필드가 모두 제한되면 어느 한쪽만 맞아도 된다
+		{"0 0 13 * fri", "2024-01-15 10:07", "2024-01-19 00:00"},
+		{"0 0 13 * fri", "2024-02-10 00:00", "2024-02-13 00:00"},
+		// day-of-month만 제한(*/2 day-of-week는 제한으로 치지 않음)
+		{"0 0 20 * */2", "2024-01-15 10:07", "2024-01-20 00:00"},
+		{"0 12 31 * *", "2024-01-31 12:00", "2024-03-31 12:00"},
+		{"0 0 29 feb *", "2024-03-01 00:00", "2028-02-29 00:00"},
+	} {
+		sc, err := Parse(c.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", c.spec, err)
+			continue
+		}
+		if got := sc.Next(at(c.from)); !got.Equal(at(c.want)) {
+			t.Errorf("%q.Next(%s) = %s, want %s", c.spec, c.from, got.Format("2006-01-02 15:04 Mon"), c.want)
+		}
+		if sc.String() != c.spec {
+			t.Errorf("String() = %q, want %q", sc.String(), c.spec)
+		}
+	}
+
+	// Next는 t의 location에서 센다
+	sc, err := Parse("0 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 1, 15, 10, 7, 30, 0, kst)
+	if got, want := sc.Next(from), time.Date(2024, 1, 16, 3, 0, 0, 0, kst); !got.Equal(want) || got.Location() != kst {
+		t.Errorf("Next in KST = %s, want %s", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, c := range []struct {
+		spec, want string
+	}{
+		{"", "want 5 fields"},
+		{"* * * *", "want 5 fields"},
+		{"* * * * * *", "want 5 fields"},
+		{"@reboot", "want 5 fields"},
+		{"60 * * * *", "minute: value 60 out of range 0-59"},
+		{"* 24 * * *", "hour: value 24 out of range 0-23"},
+		{"* * 0 * *", "day-of-month: value 0 out of range 1-31"},
+		{"* * * 13 *", "month: value 13 out of range 1-12"},
+		{"* * * * 8", "day-of-week: value 8 out of range 0-7"},
+		{"*/0 * * * *", `bad step in "*/0"`},
+		{"*/x * * * *", `bad step in "*/x"`},
+		{"30-10 * * * *", `bad range "30-10"`},
+		{"* * * foo *", `bad value "foo"`},
+		{"* * * * mon-", `bad value ""`},
+		{"0 0 30 feb *", "never fires"},
+		{"0 0 31 4,6,9,11 *", "never fires"},
+	} {
+		_, err := Parse(c.spec)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("Parse(%q) = %v, want %q", c.spec, err, c.want)
+		}
+	}
+}
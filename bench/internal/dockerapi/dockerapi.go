@@ -0,0 +1,121 @@
+// Package dockerapi is a minimal Docker Engine API client for trace_bench and
+// the bench tools (container list/inspect and lifecycle actions).
+package dockerapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Client talks to one Docker daemon.
+type Client struct {
+	Base string // 예: http://docker (unix 소켓), http://host:2375
+	HTTP *http.Client
+}
+
+// New follows DOCKER_HOST (unix:// or tcp://) and falls back to the default
+// socket.
+func New() (*Client, error) {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = "unix:///var/run/docker.sock"
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOCKER_HOST: %s", host)
+	}
+	switch u.Scheme {
+	case "unix":
+		sock := u.Path
+		tr := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", sock)
+			},
+		}
+		return &Client{Base: "http://docker", HTTP: &http.Client{Transport: tr, Timeout: 5 * time.Second}}, nil
+	case "tcp", "http":
+		return &Client{Base: "http://" + u.Host, HTTP: &http.Client{Timeout: 5 * time.Second}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported DOCKER_HOST scheme: %s", u.Scheme)
+	}
+}
+
+// Get decodes the JSON response of path into v.
+func (c *Client) Get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Base+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("docker api: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("docker api: %s -> %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Post sends an empty POST (start, stop, kill, ...); 304 Not Modified
+// (already in the requested state) counts as success.
+func (c *Client) Post(ctx context.Context, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Base+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("docker api: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotModified {
+		return fmt.Errorf("docker api: %s -> %s", path, resp.Status)
+	}
+	return nil
+}
+
+// Port is a container port mapping.
+type Port struct {
+	IP          string `json:"IP"`
+	PrivatePort int    `json:"PrivatePort"`
+	PublicPort  int    `json:"PublicPort"`
+	Type        string `json:"Type"`
+}
+
+// Container is an entry of GET /containers/json.
+type Container struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	State  string            `json:"State"`
+	Ports  []Port            `json:"Ports"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// Compose labels set on containers started by docker compose.
+const (
+	LabelProject = "com.docker.compose.project"
+	LabelService = "com.docker.compose.service"
+)
+
+// List returns the running containers matching the label filters
+// (key=value).
+func (c *Client) List(ctx context.Context, labels ...string) ([]Container, error) {
+	path := "/containers/json"
+	if len(labels) > 0 {
+		f, _ := json.Marshal(map[string][]string{"label": labels})
+		path += "?filters=" + url.QueryEscape(string(f))
+	}
+	var list []Container
+	if err := c.Get(ctx, path, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
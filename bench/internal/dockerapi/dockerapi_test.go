@@ -0,0 +1,139 @@
+package dockerapi
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeDaemon은 컨테이너 목록과 lifecycle POST만 아는 Docker Engine 흉내
+func fakeDaemon(t *testing.T, ln net.Listener) (*httptest.Server, *[]string) {
+	t.Helper()
+	var posts []string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/containers/json":
+			var f map[string][]string
+			if s := r.URL.Query().Get("filters"); s != "" {
+				if err := json.Unmarshal([]byte(s), &f); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			list := []Container{{ID: "c1", Names: []string{"/bench-app-1"}, State: "running",
+				Ports:  []Port{{IP: "0.0.0.0", PrivatePort: 4318, PublicPort: 14318, Type: "tcp"}},
+				Labels: map[string]string{LabelProject: "bench", LabelService: "app"}}}
+			if reflect.DeepEqual(f["label"], []string{LabelProject + "=other"}) {
+				list = []Container{}
+			}
+			json.NewEncoder(w).Encode(list)
+		case r.Method == http.MethodPost && r.URL.Path == "/containers/c1/start":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && r.URL.Path == "/containers/c1/stop":
+			w.WriteHeader(http.StatusNotModified) // 이미 멈춤
+		default:
+			http.Error(w, `{"message":"No such container"}`, http.StatusNotFound)
+		}
+		if r.Method == http.MethodPost {
+			posts = append(posts, r.URL.Path)
+		}
+	}))
+	if ln != nil {
+		srv.Listener.Close()
+		srv.Listener = ln
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv, &posts
+}
+
+func TestNew(t *testing.T) {
+	for _, c := range []struct {
+		host, base, wantErr string
+	}{
+		{"", "http://docker", ""},
+		{"unix:///run/user/1000/docker.sock", "http://docker", ""},
+		{"tcp://10.0.0.5:2375", "http://10.0.0.5:2375", ""},
+		{"http://localhost:2375", "http://localhost:2375", ""},
+		{"ssh://user@host", "", "unsupported DOCKER_HOST scheme: ssh"},
+		{"tcp://[::1", "", "invalid DOCKER_HOST"},
+	} {
+		t.Setenv("DOCKER_HOST", c.host)
+		cl, err := New()
+		switch {
+		case c.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("DOCKER_HOST=%q: error %v, want %q", c.host, err, c.wantErr)
+			}
+		case err != nil:
+			t.Errorf("DOCKER_HOST=%q: %v", c.host, err)
+		case cl.Base != c.base:
+			t.Errorf("DOCKER_HOST=%q: base %q, want %q", c.host, cl.Base, c.base)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "docker.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skip(err)
+	}
+	_, posts := fakeDaemon(t, ln)
+	t.Setenv("DOCKER_HOST", "unix://"+sock)
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	list, err := c.List(ctx, LabelProject+"=bench")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != "c1" || list[0].Labels[LabelService] != "app" || list[0].Ports[0].PublicPort != 14318 {
+		t.Errorf("List = %+v", list)
+	}
+	if list, err = c.List(ctx, LabelProject+"=other"); err != nil || len(list) != 0 {
+		t.Errorf("List(other) = %+v, %v", list, err)
+	}
+
+	if err := c.Post(ctx, "/containers/c1/start"); err != nil {
+		t.Errorf("start: %v", err)
+	}
+	if err := c.Post(ctx, "/containers/c1/stop"); err != nil {
+		t.Errorf("stop (304): %v", err)
+	}
+	if err := c.Post(ctx, "/containers/nope/kill"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("kill missing container: %v", err)
+	}
+	if want := []string{"/containers/c1/start", "/containers/c1/stop", "/containers/nope/kill"}; !reflect.DeepEqual(*posts, want) {
+		t.Errorf("posts = %v, want %v", *posts, want)
+	}
+	var v map[string]any
+	if err := c.Get(ctx, "/containers/nope/json", &v); err == nil || !strings.Contains(err.Error(), "/containers/nope/json -> 404") {
+		t.Errorf("Get missing: %v", err)
+	}
+}
+
+func TestTCP(t *testing.T) {
+	srv, _ := fakeDaemon(t, nil)
+	t.Setenv("DOCKER_HOST", "tcp://"+srv.Listener.Addr().String())
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list, err := c.List(context.Background()); err != nil || len(list) != 1 {
+		t.Errorf("List = %+v, %v", list, err)
+	}
+	srv.Close()
+	if _, err := c.List(context.Background()); err == nil || !strings.HasPrefix(err.Error(), "docker api: ") {
+		t.Errorf("List after close: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+// Package flock takes advisory whole-file locks (flock(2)) on lock files,
+// so trace_bench processes sharing a history volume or a bench target
+// serialize their writes. The kernel drops a lock when its holder exits,
+// so a crashed process never leaves a stale lock behind. On platforms
+// without flock the locks are no-ops and TryLock reports
+// errors.ErrUnsupported.
+package flock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrLocked reports that another process holds a conflicting lock.
+var ErrLocked = errors.New("locked by another process")
+
+// 재시도 간격: 짧게 시작해 maxBackoff까지 늘린다
+const (
+	minBackoff = 5 * time.Millisecond
+	maxBackoff = 250 * time.Millisecond
+)
+
+// TryLock locks f without blocking: exclusive for writers, shared for
+// readers. It returns ErrLocked if a conflicting lock is held.
+func TryLock(f *os.File, exclusive bool) error { return tryLock(f, exclusive) }
+
+// Lock opens (creating) the lock file at path and locks it, retrying with
+// backoff while another process holds a conflicting lock, for up to
+// timeout. The returned func releases the lock. Without flock support the
+// lock is a no-op.
+func Lock(path string, exclusive bool, timeout time.Duration) (unlock func() error, err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for backoff := minBackoff; ; backoff = min(backoff*2, maxBackoff) {
+		err := tryLock(f, exclusive)
+		switch {
+		case err == nil:
+			return f.Close, nil // close가 lock도 푼다
+		case errors.Is(err, errors.ErrUnsupported):
+			f.Close()
+			return func() error { return nil }, nil
+		case !errors.Is(err, ErrLocked):
+			f.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		case time.Now().After(deadline):
+			f.Close()
+			return nil, fmt.Errorf("lock %s: still %w after %s", path, ErrLocked, timeout)
+		}
+		time.Sleep(backoff)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package flock
+
+import (
+	"errors"
+	"os"
+)
+
+func tryLock(*os.File, bool) error { return errors.ErrUnsupported }
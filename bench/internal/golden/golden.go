@@ -0,0 +1,133 @@
+// Package golden locks rendered outputs against checked-in files, so a
+// format change that would break a downstream gate shows up as a diff:
+// Check compares each Case with its file, Update rewrites the files after
+// an intended change.
+package golden
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Case is one golden output: Render writes it, Name is its file name
+// relative to the golden directory.
+type Case struct {
+	Name   string
+	Render func(w io.Writer) error
+}
+
+// Mismatch is a case whose rendering differs from its golden file.
+type Mismatch struct {
+	Name string
+	// Missing is set when the golden file does not exist yet.
+	Missing bool
+	// Line and Column locate the first difference (1-based); Want and Got
+	// are the golden and rendered text around it.
+	Line, Column int
+	Want, Got    string
+}
+
+func (m Mismatch) String() string {
+	if m.Missing {
+		return m.Name + ": golden file missing"
+	}
+	return fmt.Sprintf("%s:%d:%d:\n\twant %q\n\tgot  %q", m.Name, m.Line, m.Column, m.Want, m.Got)
+}
+
+// Check renders every case and compares it with dir/<Name>.
+func Check(dir string, cases []Case) ([]Mismatch, error) {
+	var out []Mismatch
+	for _, c := range cases {
+		got, err := render(c)
+		if err != nil {
+			return nil, err
+		}
+		want, err := os.ReadFile(filepath.Join(dir, c.Name))
+		if errors.Is(err, os.ErrNotExist) {
+			out = append(out, Mismatch{Name: c.Name, Missing: true})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(got, want) {
+			m := firstDiff(want, got)
+			m.Name = c.Name
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// Update rewrites the golden files whose content changed and returns
+// their names.
+func Update(dir string, cases []Case) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, c := range cases {
+		got, err := render(c)
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, c.Name)
+		if want, err := os.ReadFile(path); err == nil && bytes.Equal(want, got) {
+			continue
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			return nil, err
+		}
+		changed = append(changed, c.Name)
+	}
+	return changed, nil
+}
+
+func render(c Case) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.Render(&buf); err != nil {
+		return nil, fmt.Errorf("golden %s: %w", c.Name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// diffContext는 Want/Got에 남기는 차이 앞뒤 바이트 수(한 줄짜리 JSON도 읽을 수 있게)
+const diffContext = 40
+
+// firstDiff는 처음 달라지는 줄과 열(한쪽이 먼저 끝나면 그 다음 줄을 "")
+func firstDiff(want, got []byte) Mismatch {
+	wl, gl := strings.Split(string(want), "\n"), strings.Split(string(got), "\n")
+	for i := 0; ; i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if w != g || i >= len(wl) || i >= len(gl) {
+			c := 0
+			for c < len(w) && c < len(g) && w[c] == g[c] {
+				c++
+			}
+			return Mismatch{Line: i + 1, Column: c + 1, Want: excerpt(w, c), Got: excerpt(g, c)}
+		}
+	}
+}
+
+func excerpt(s string, at int) string {
+	lo, hi := max(at-diffContext, 0), min(at+diffContext, len(s))
+	out := s[lo:hi]
+	if lo > 0 {
+		out = "…" + out
+	}
+	if hi < len(s) {
+		out += "…"
+	}
+	return out
+}
@@ -0,0 +1,260 @@
+// Package promapi is a minimal Prometheus HTTP API client for the bench
+// tools (instant queries and label values).
+package promapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Client queries one Prometheus server.
+type Client struct {
+	Base string // 예: http://localhost:9090
+	HTTP *http.Client
+	// Header and Params are added to every request, e.g. X-Scope-OrgID for
+	// Mimir/Cortex or the tenant parameter of prom-label-proxy.
+	Header http.Header
+	Params url.Values
+}
+
+// New returns a client with a bounded HTTP timeout.
+func New(base string) *Client {
+	return &Client{Base: base, HTTP: &http.Client{Timeout: 30 * time.Second}}
+}
+
+type apiResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// get은 Header/Params를 붙여 GET 요청을 보낸다
+func (c *Client) get(ctx context.Context, path string, q url.Values) (*http.Response, error) {
+	if q == nil {
+		q = url.Values{}
+	}
+	for k, vs := range c.Params {
+		q[k] = append(q[k], vs...)
+	}
+	u := c.Base + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range c.Header {
+		req.Header[k] = vs
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("prometheus: %w", err)
+	}
+	return resp, nil
+}
+
+// query는 instant query 응답을 읽는다
+func (c *Client) query(ctx context.Context, expr string, t time.Time) (*apiResponse, error) {
+	q := url.Values{"query": {expr}}
+	if !t.IsZero() {
+		q.Set("time", strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', 3, 64))
+	}
+	resp, err := c.get(ctx, "/api/v1/query", q)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var r apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("prometheus: %s: %w", resp.Status, err)
+	}
+	if r.Status != "success" {
+		return nil, fmt.Errorf("prometheus: %s: %s", r.ErrorType, r.Error)
+	}
+	return &r, nil
+}
+
+// Sample is one element of an instant vector.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Vector evaluates expr at t and returns every sample of the resulting
+// instant vector with its labels.
+func (c *Client) Vector(ctx context.Context, expr string, t time.Time) ([]Sample, error) {
+	r, err := c.query(ctx, expr, t)
+	if err != nil {
+		return nil, err
+	}
+	if r.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("prometheus: expected a vector, got %s", r.Data.ResultType)
+	}
+	var vs []struct {
+		Metric map[string]string `json:"metric"`
+		Value  [2]any            `json:"value"`
+	}
+	if err := json.Unmarshal(r.Data.Result, &vs); err != nil {
+		return nil, err
+	}
+	out := make([]Sample, 0, len(vs))
+	for _, v := range vs {
+		f, err := parseValue(v.Value[1])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Sample{Labels: v.Metric, Value: f})
+	}
+	return out, nil
+}
+
+// Query evaluates expr at t and returns a single value: the scalar, or the
+// first sample of a vector. An empty vector returns NaN.
+func (c *Client) Query(ctx context.Context, expr string, t time.Time) (float64, error) {
+	r, err := c.query(ctx, expr, t)
+	if err != nil {
+		return 0, err
+	}
+	switch r.Data.ResultType {
+	case "scalar":
+		var v [2]any
+		if err := json.Unmarshal(r.Data.Result, &v); err != nil {
+			return 0, err
+		}
+		return parseValue(v[1])
+	case "vector":
+		var vs []struct {
+			Value [2]any `json:"value"`
+		}
+		if err := json.Unmarshal(r.Data.Result, &vs); err != nil {
+			return 0, err
+		}
+		if len(vs) == 0 {
+			return math.NaN(), nil
+		}
+		return parseValue(vs[0].Value[1])
+	default:
+		return 0, fmt.Errorf("prometheus: unsupported result type %s", r.Data.ResultType)
+	}
+}
+
+// Point is one sample of a range query.
+type Point struct {
+	T time.Time
+	V float64
+}
+
+// Series is one series of a range query result.
+type Series struct {
+	Labels map[string]string
+	Points []Point
+}
+
+// Range evaluates expr over [start, end] every step (query_range).
+func (c *Client) Range(ctx context.Context, expr string, start, end time.Time, step time.Duration) ([]Series, error) {
+	q := url.Values{
+		"query": {expr},
+		"start": {strconv.FormatFloat(float64(start.UnixNano())/1e9, 'f', 3, 64)},
+		"end":   {strconv.FormatFloat(float64(end.UnixNano())/1e9, 'f', 3, 64)},
+		"step":  {strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	}
+	resp, err := c.get(ctx, "/api/v1/query_range", q)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var r apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("prometheus: %s: %w", resp.Status, err)
+	}
+	if r.Status != "success" {
+		return nil, fmt.Errorf("prometheus: %s: %s", r.ErrorType, r.Error)
+	}
+	if r.Data.ResultType != "matrix" {
+		return nil, fmt.Errorf("prometheus: expected a matrix, got %s", r.Data.ResultType)
+	}
+	var ms []struct {
+		Metric map[string]string `json:"metric"`
+		Values [][2]any          `json:"values"`
+	}
+	if err := json.Unmarshal(r.Data.Result, &ms); err != nil {
+		return nil, err
+	}
+	out := make([]Series, 0, len(ms))
+	for _, m := range ms {
+		s := Series{Labels: m.Metric, Points: make([]Point, 0, len(m.Values))}
+		for _, v := range m.Values {
+			ts, ok := v[0].(float64)
+			if !ok {
+				return nil, fmt.Errorf("prometheus: unexpected timestamp %v", v[0])
+			}
+			f, err := parseValue(v[1])
+			if err != nil {
+				return nil, err
+			}
+			s.Points = append(s.Points, Point{T: time.Unix(0, int64(ts*1e9)), V: f})
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// LabelValues returns the values of label across all series, e.g. every
+// metric name for "__name__".
+func (c *Client) LabelValues(ctx context.Context, label string) ([]string, error) {
+	resp, err := c.get(ctx, "/api/v1/label/"+url.PathEscape(label)+"/values", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var r struct {
+		Status    string   `json:"status"`
+		ErrorType string   `json:"errorType"`
+		Error     string   `json:"error"`
+		Data      []string `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("prometheus: %s: %w", resp.Status, err)
+	}
+	if r.Status != "success" {
+		return nil, fmt.Errorf("prometheus: %s: %s", r.ErrorType, r.Error)
+	}
+	return r.Data, nil
+}
+
+func parseValue(v any) (float64, error) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("prometheus: unexpected sample value %v", v)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+var durRe = regexp.MustCompile(`^(\d+)(ms|s|m|h|d|w|y)$`)
+
+// ParseDuration parses a Prometheus duration such as "30d" or "6h".
+// Only single-unit durations are accepted.
+func ParseDuration(s string) (time.Duration, error) {
+	m := durRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+	n, _ := strconv.ParseInt(m[1], 10, 64)
+	unit := map[string]time.Duration{
+		"ms": time.Millisecond, "s": time.Second, "m": time.Minute, "h": time.Hour,
+		"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour,
+	}[m[2]]
+	return time.Duration(n) * unit, nil
+}
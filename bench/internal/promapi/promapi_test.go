@@ -0,0 +1,164 @@
+package promapi
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProm은 query 파라미터별로 정해 둔 본문을 돌려주는 Prometheus 흉내.
+// 받은 요청은 reqs에 남는다
+func fakeProm(t *testing.T, bodies map[string]string) (*Client, *[]*http.Request) {
+	t.Helper()
+	var reqs []*http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqs = append(reqs, r)
+		key := r.URL.Query().Get("query")
+		if key == "" {
+			key = r.URL.Path
+		}
+		body, ok := bodies[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.Contains(body, `"status":"error"`) {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return New(srv.URL), &reqs
+}
+
+func TestQuery(t *testing.T) {
+	c, reqs := fakeProm(t, map[string]string{
+		"scalar(1)":  `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"1"]}}`,
+		"up":         `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"a"},"value":[1700000000,"0.25"]},{"metric":{"job":"b"},"value":[1700000000,"1"]}]}}`,
+		"absent_one": `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+		"inf":        `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"+Inf"]}]}}`,
+		"matrix":     `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+		"bad(":       `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+		"number":     `{"status":"success","data":{"resultType":"scalar","result":[1700000000,1]}}`,
+	})
+	for _, c2 := range []struct {
+		expr    string
+		want    float64
+		wantErr string
+	}{
+		{"scalar(1)", 1, ""},
+		{"up", 0.25, ""},
+		{"absent_one", math.NaN(), ""},
+		{"inf", math.Inf(1), ""},
+		{"matrix", 0, "unsupported result type matrix"},
+		{"bad(", 0, "bad_data: parse error"},
+		{"number", 0, "unexpected sample value 1"},
+		{"missing", 0, "404 Not Found"},
+	} {
+		got, err := c.Query(context.Background(), c2.expr, time.Time{})
+		switch {
+		case c2.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), c2.wantErr) {
+				t.Errorf("Query(%q) error = %v, want %q", c2.expr, err, c2.wantErr)
+			}
+		case err != nil:
+			t.Errorf("Query(%q): %v", c2.expr, err)
+		case got != c2.want && !(math.IsNaN(got) && math.IsNaN(c2.want)):
+			t.Errorf("Query(%q) = %v, want %v", c2.expr, got, c2.want)
+		}
+	}
+
+	// time은 초 단위(밀리초까지), Header와 Params는 모든 요청에 붙는다
+	c.Header = http.Header{"X-Scope-Orgid": {"tenant-a"}}
+	c.Params = url.Values{"tenant": {"a"}}
+	if _, err := c.Query(context.Background(), "up", time.UnixMilli(1700000000123)); err != nil {
+		t.Fatal(err)
+	}
+	r := (*reqs)[len(*reqs)-1]
+	if q := r.URL.Query(); q.Get("time") != "1700000000.123" || q.Get("tenant") != "a" || r.URL.Path != "/api/v1/query" {
+		t.Errorf("request %s", r.URL)
+	}
+	if r.Header.Get("X-Scope-OrgID") != "tenant-a" {
+		t.Errorf("headers %v", r.Header)
+	}
+}
+
+func TestVector(t *testing.T) {
+	c, _ := fakeProm(t, map[string]string{
+		"up":     `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"a"},"value":[1700000000,"0"]},{"metric":{"job":"b"},"value":[1700000000,"1"]}]}}`,
+		"scalar": `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"1"]}}`,
+	})
+	got, err := c.Vector(context.Background(), "up", time.Time{})
+	want := []Sample{{Labels: map[string]string{"job": "a"}, Value: 0}, {Labels: map[string]string{"job": "b"}, Value: 1}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Vector(up) = %v, %v; want %v", got, err, want)
+	}
+	if _, err := c.Vector(context.Background(), "scalar", time.Time{}); err == nil || !strings.Contains(err.Error(), "expected a vector") {
+		t.Errorf("Vector(scalar) error = %v", err)
+	}
+}
+
+func TestRange(t *testing.T) {
+	c, reqs := fakeProm(t, map[string]string{
+		"rate(x[1m])": `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"pod":"p"},"values":[[1700000000,"1"],[1700000060.5,"2.5"]]}]}}`,
+		"vector":      `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+		"bad ts":      `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[["x","1"]]}]}}`,
+	})
+	start := time.Unix(1700000000, 0)
+	got, err := c.Range(context.Background(), "rate(x[1m])", start, start.Add(time.Minute), 30*time.Second)
+	want := []Series{{Labels: map[string]string{"pod": "p"}, Points: []Point{
+		{T: time.Unix(1700000000, 0), V: 1}, {T: time.Unix(1700000060, 5e8), V: 2.5}}}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Range = %v, %v; want %v", got, err, want)
+	}
+	q := (*reqs)[0].URL.Query()
+	if (*reqs)[0].URL.Path != "/api/v1/query_range" || q.Get("start") != "1700000000.000" || q.Get("end") != "1700000060.000" || q.Get("step") != "30" {
+		t.Errorf("request %s", (*reqs)[0].URL)
+	}
+	for expr, wantErr := range map[string]string{"vector": "expected a matrix", "bad ts": "unexpected timestamp"} {
+		if _, err := c.Range(context.Background(), expr, start, start, time.Second); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("Range(%q) error = %v, want %q", expr, err, wantErr)
+		}
+	}
+}
+
+func TestLabelValues(t *testing.T) {
+	c, _ := fakeProm(t, map[string]string{
+		"/api/v1/label/__name__/values": `{"status":"success","data":["up","http_requests_total"]}`,
+		"/api/v1/label/bad/values":      `{"status":"error","errorType":"execution","error":"boom"}`,
+	})
+	got, err := c.LabelValues(context.Background(), "__name__")
+	if err != nil || !reflect.DeepEqual(got, []string{"up", "http_requests_total"}) {
+		t.Errorf("LabelValues = %v, %v", got, err)
+	}
+	if _, err := c.LabelValues(context.Background(), "bad"); err == nil || !strings.Contains(err.Error(), "execution: boom") {
+		t.Errorf("LabelValues(bad) error = %v", err)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"250ms": 250 * time.Millisecond,
+		"30s":   30 * time.Second,
+		"5m":    5 * time.Minute,
+		"6h":    6 * time.Hour,
+		"30d":   30 * 24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"1y":    365 * 24 * time.Hour,
+	} {
+		if got, err := ParseDuration(in); err != nil || got != want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "5", "1h30m", "-5m", "1.5h", "3x"} {
+		if _, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q) accepted", in)
+		}
+	}
+}
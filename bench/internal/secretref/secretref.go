@@ -0,0 +1,71 @@
+// Package secretref resolves credential references, so profiles, flags and
+// scenarios name where a secret lives instead of holding it:
+//
+//	secretref://env/NAME     value of environment variable NAME
+//	secretref://file/PATH    contents of PATH, trailing newline trimmed;
+//	                         secretref://file//run/secrets/otlp is absolute,
+//	                         secretref://file/secrets/otlp is relative
+//
+// Errors name the reference, never a resolved value, so results and logs
+// stay clean for the secret-scan gate.
+package secretref
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Scheme prefixes a secret reference.
+const Scheme = "secretref://"
+
+// IsRef reports whether s is a secret reference.
+func IsRef(s string) bool { return strings.HasPrefix(s, Scheme) }
+
+// Resolve returns the secret ref points to. Strings that are not
+// references are returned unchanged.
+func Resolve(ref string) (string, error) {
+	if !IsRef(ref) {
+		return ref, nil
+	}
+	kind, arg, _ := strings.Cut(strings.TrimPrefix(ref, Scheme), "/")
+	if arg == "" {
+		return "", fmt.Errorf("invalid secret reference: %s", ref)
+	}
+	switch kind {
+	case "env":
+		v, ok := os.LookupEnv(arg)
+		if !ok || v == "" {
+			return "", fmt.Errorf("%s: environment variable %s is not set", ref, arg)
+		}
+		return v, nil
+	case "file":
+		b, err := os.ReadFile(arg)
+		if err != nil {
+			// PathError에는 내용이 없으므로 그대로 노출해도 안전
+			return "", fmt.Errorf("%s: %w", ref, err)
+		}
+		v := strings.TrimRight(string(b), "\r\n")
+		if v == "" {
+			return "", fmt.Errorf("%s: file is empty", ref)
+		}
+		return v, nil
+	default:
+		return "", fmt.Errorf("unsupported secret provider %q in %s (want env or file)", kind, ref)
+	}
+}
+
+// Env returns environment variable name, resolving its value when it is
+// itself a reference (e.g. AWS_SECRET_ACCESS_KEY=secretref://file//run/secrets/s3).
+// An unset variable yields "".
+func Env(name string) (string, error) {
+	v := os.Getenv(name)
+	if !IsRef(v) {
+		return v, nil
+	}
+	s, err := Resolve(v)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return s, nil
+}
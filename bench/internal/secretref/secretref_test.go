@@ -0,0 +1,106 @@
+package secretref
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	token := write("token", "s3cr3t\r\n")
+	multi := write("multi", "line1\nline2\n")
+	empty := write("empty", "\n")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, err := filepath.Rel(wd, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TRACE_BENCH_TEST_SECRET", "from-env")
+	t.Setenv("TRACE_BENCH_TEST_EMPTY", "")
+
+	for _, c := range []struct {
+		ref, want, wantErr string
+	}{
+		{"plain-value", "plain-value", ""},
+		{"", "", ""},
+		{"secretref://env/TRACE_BENCH_TEST_SECRET", "from-env", ""},
+		{"secretref://file/" + token, "s3cr3t", ""},
+		{"secretref://file/" + rel, "s3cr3t", ""},
+		{"secretref://file/" + multi, "line1\nline2", ""},
+		{"secretref://env/TRACE_BENCH_TEST_UNSET", "", "environment variable TRACE_BENCH_TEST_UNSET is not set"},
+		{"secretref://env/TRACE_BENCH_TEST_EMPTY", "", "environment variable TRACE_BENCH_TEST_EMPTY is not set"},
+		{"secretref://file/" + empty, "", "file is empty"},
+		{"secretref://file/" + filepath.Join(dir, "missing"), "", "secretref://file/"},
+		{"secretref://env/", "", "invalid secret reference"},
+		{"secretref://env", "", "invalid secret reference"},
+		{"secretref://vault/kv/otlp", "", `unsupported secret provider "vault"`},
+	} {
+		got, err := Resolve(c.ref)
+		switch {
+		case c.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("Resolve(%q) error = %v, want %q", c.ref, err, c.wantErr)
+			}
+		case err != nil:
+			t.Errorf("Resolve(%q): %v", c.ref, err)
+		case got != c.want:
+			t.Errorf("Resolve(%q) = %q, want %q", c.ref, got, c.want)
+		}
+	}
+}
+
+func TestEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("file-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TRACE_BENCH_TEST_PLAIN", "plain")
+	t.Setenv("TRACE_BENCH_TEST_REF", "secretref://file/"+path)
+	t.Setenv("TRACE_BENCH_TEST_BAD", "secretref://env/TRACE_BENCH_TEST_UNSET")
+	for _, c := range []struct {
+		name, want, wantErr string
+	}{
+		{"TRACE_BENCH_TEST_PLAIN", "plain", ""},
+		{"TRACE_BENCH_TEST_REF", "file-key", ""},
+		{"TRACE_BENCH_TEST_UNSET", "", ""},
+		{"TRACE_BENCH_TEST_BAD", "", "TRACE_BENCH_TEST_BAD: secretref://env/TRACE_BENCH_TEST_UNSET: environment variable"},
+	} {
+		got, err := Env(c.name)
+		switch {
+		case c.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("Env(%s) error = %v, want %q", c.name, err, c.wantErr)
+			}
+		case err != nil:
+			t.Errorf("Env(%s): %v", c.name, err)
+		case got != c.want:
+			t.Errorf("Env(%s) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+// 오류 메시지에는 참조만 있고 값은 없어야 한다(secret-scan gate)
+func TestErrorsOmitValue(t *testing.T) {
+	t.Setenv("TRACE_BENCH_TEST_VALUE", "do-not-print")
+	t.Setenv("TRACE_BENCH_TEST_REF", "secretref://bogus/TRACE_BENCH_TEST_VALUE")
+	for _, ref := range []string{"secretref://bogus/TRACE_BENCH_TEST_VALUE", "secretref://env/TRACE_BENCH_TEST_NOPE"} {
+		if _, err := Resolve(ref); err == nil || strings.Contains(err.Error(), "do-not-print") {
+			t.Errorf("Resolve(%q) error = %v", ref, err)
+		}
+	}
+	if _, err := Env("TRACE_BENCH_TEST_REF"); err == nil || strings.Contains(err.Error(), "do-not-print") {
+		t.Errorf("Env error = %v", err)
+	}
+}
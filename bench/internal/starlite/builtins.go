@@ -0,0 +1,478 @@
+package starlite
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// universe는 모든 스크립트에 보이는 내장 이름
+var universe map[string]any
+
+func init() {
+	universe = map[string]any{
+		"len":       NewBuiltin("len", biLen),
+		"str":       NewBuiltin("str", func(a []any, kw map[string]any) (any, error) { return one("str", a, kw, Str) }),
+		"repr":      NewBuiltin("repr", func(a []any, kw map[string]any) (any, error) { return one("repr", a, kw, Repr) }),
+		"type":      NewBuiltin("type", func(a []any, kw map[string]any) (any, error) { return one("type", a, kw, Type) }),
+		"bool":      NewBuiltin("bool", func(a []any, kw map[string]any) (any, error) { return one("bool", a, kw, Truth) }),
+		"int":       NewBuiltin("int", biInt),
+		"float":     NewBuiltin("float", biFloat),
+		"range":     NewBuiltin("range", biRange),
+		"list":      NewBuiltin("list", biList),
+		"tuple":     NewBuiltin("tuple", biTuple),
+		"dict":      NewBuiltin("dict", biDict),
+		"min":       NewBuiltin("min", func(a []any, kw map[string]any) (any, error) { return minMax("min", a, kw, -1) }),
+		"max":       NewBuiltin("max", func(a []any, kw map[string]any) (any, error) { return minMax("max", a, kw, 1) }),
+		"sorted":    NewBuiltin("sorted", biSorted),
+		"enumerate": NewBuiltin("enumerate", biEnumerate),
+		"hasattr":   NewBuiltin("hasattr", biHasattr),
+		"fail":      NewBuiltin("fail", biFail),
+		"print":     NewBuiltin("print", biPrint),
+		"json": NewStruct(map[string]any{
+			"encode": NewBuiltin("json.encode", biJSONEncode),
+			"decode": NewBuiltin("json.decode", biJSONDecode),
+		}),
+	}
+}
+
+// checkArgs는 위치 인자 개수를 검사하고 키워드 인자를 거부한다
+func checkArgs(name string, args []any, kwargs map[string]any, lo, hi int) error {
+	if len(kwargs) > 0 {
+		return fmt.Errorf("%s() does not accept keyword arguments", name)
+	}
+	if len(args) < lo || len(args) > hi {
+		if lo == hi {
+			return fmt.Errorf("%s() takes %d arguments, got %d", name, lo, len(args))
+		}
+		return fmt.Errorf("%s() takes %d to %d arguments, got %d", name, lo, hi, len(args))
+	}
+	return nil
+}
+
+func one[T any](name string, args []any, kwargs map[string]any, f func(any) T) (any, error) {
+	if err := checkArgs(name, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	return f(args[0]), nil
+}
+
+func biLen(args []any, kwargs map[string]any) (any, error) {
+	if err := checkArgs("len", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case string:
+		return int64(len(v)), nil
+	case Tuple:
+		return int64(len(v)), nil
+	case *List:
+		return int64(len(v.elems)), nil
+	case *Dict:
+		return int64(len(v.keys)), nil
+	case rangeValue:
+		return v.len(), nil
+	}
+	return nil, fmt.Errorf("len: %s value has no len", Type(args[0]))
+}
+
+func biInt(args []any, kwargs map[string]any) (any, error) {
+	if err := checkArgs("int", args, kwargs, 1, 2); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case int64:
+		return v, nil
+	case float64:
+		if !floatFitsInt(v) {
+			return nil, fmt.Errorf("int: cannot convert %s to int", formatFloat(v))
+		}
+		return int64(v), nil
+	case bool:
+		if v {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case string:
+		base := int64(10)
+		if len(args) == 2 {
+			b, ok := args[1].(int64)
+			if !ok {
+				return nil, fmt.Errorf("int: base must be int")
+			}
+			base = b
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(v), int(base), 64)
+		if err != nil {
+			return nil, fmt.Errorf("int: invalid literal %s", strconv.Quote(v))
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("int: cannot convert %s", Type(args[0]))
+}
+
+func biFloat(args []any, kwargs map[string]any) (any, error) {
+	if err := checkArgs("float", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1.0, nil
+		}
+		return 0.0, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("float: invalid literal %s", strconv.Quote(v))
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("float: cannot convert %s", Type(args[0]))
+}
+
+func biRange(args []any, kwargs map[string]any) (any, error) {
+	if err := checkArgs("range", args, kwargs, 1, 3); err != nil {
+		return nil, err
+	}
+	var n [3]int64
+	for i, a := range args {
+		v, ok := a.(int64)
+		if !ok {
+			return nil, fmt.Errorf("range: arguments must be int, not %s", Type(a))
+		}
+		n[i] = v
+	}
+	switch len(args) {
+	case 1:
+		return rangeValue{0, n[0], 1}, nil
+	case 2:
+		return rangeValue{n[0], n[1], 1}, nil
+	}
+	if n[2] == 0 {
+		return nil, fmt.Errorf("range: step must not be zero")
+	}
+	return rangeValue{n[0], n[1], n[2]}, nil
+}
+
+// collect는 순회 가능한 값을 슬라이스로
+func collect(v any) ([]any, error) {
+	var out []any
+	err := iterate(v, func(e any) (bool, error) { out = append(out, e); return true, nil })
+	return out, err
+}
+
+func biList(args []any, kwargs map[string]any) (any, error) {
+	if err := checkArgs("list", args, kwargs, 0, 1); err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return &List{}, nil
+	}
+	elems, err := collect(args[0])
+	return &List{elems: elems}, err
+}
+
+func biTuple(args []any, kwargs map[string]any) (any, error) {
+	if err := checkArgs("tuple", args, kwargs, 0, 1); err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return Tuple{}, nil
+	}
+	elems, err := collect(args[0])
+	return Tuple(elems), err
+}
+
+// dict(), dict(pairs), dict(k=v, ...)
+func biDict(args []any, kwargs map[string]any) (any, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("dict() takes at most 1 positional argument, got %d", len(args))
+	}
+	d := NewDict()
+	if len(args) == 1 {
+		if src, ok := args[0].(*Dict); ok {
+			for _, k := range src.keys {
+				d.Set(k, src.at(k))
+			}
+		} else {
+			err := iterate(args[0], func(e any) (bool, error) {
+				pair, err := collect(e)
+				if err != nil || len(pair) != 2 {
+					return false, fmt.Errorf("dict: element is not a key/value pair")
+				}
+				return true, d.Set(pair[0], pair[1])
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	names := make([]string, 0, len(kwargs))
+	for k := range kwargs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		d.Set(k, kwargs[k])
+	}
+	return d, nil
+}
+
+func minMax(name string, args []any, kwargs map[string]any, sign int) (any, error) {
+	if err := checkArgs(name, args, kwargs, 1, math.MaxInt); err != nil {
+		return nil, err
+	}
+	elems := args
+	if len(args) == 1 {
+		var err error
+		if elems, err = collect(args[0]); err != nil {
+			return nil, err
+		}
+	}
+	if len(elems) == 0 {
+		return nil, fmt.Errorf("%s: empty sequence", name)
+	}
+	best := elems[0]
+	for _, e := range elems[1:] {
+		c, err := compare(e, best)
+		if err != nil {
+			return nil, err
+		}
+		if c == sign {
+			best = e
+		}
+	}
+	return best, nil
+}
+
+func biSorted(args []any, kwargs map[string]any) (any, error) {
+	reverse := false
+	for k, v := range kwargs {
+		if k != "reverse" {
+			return nil, fmt.Errorf("sorted() got an unexpected keyword argument %s", k)
+		}
+		reverse = Truth(v)
+	}
+	if err := checkArgs("sorted", args, nil, 1, 1); err != nil {
+		return nil, err
+	}
+	elems, err := collect(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var cmpErr error
+	sort.SliceStable(elems, func(i, j int) bool {
+		c, err := compare(elems[i], elems[j])
+		if err != nil && cmpErr == nil {
+			cmpErr = err
+		}
+		if reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+	return &List{elems: elems}, cmpErr
+}
+
+func biEnumerate(args []any, kwargs map[string]any) (any, error) {
+	if err := checkArgs("enumerate", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	elems, err := collect(args[0])
+	if err != nil {
+		return nil, err
+	}
+	out := &List{elems: make([]any, len(elems))}
+	for i, e := range elems {
+		out.elems[i] = Tuple{int64(i), e}
+	}
+	return out, nil
+}
+
+func biHasattr(args []any, kwargs map[string]any) (any, error) {
+	if err := checkArgs("hasattr", args, kwargs, 2, 2); err != nil {
+		return nil, err
+	}
+	name, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("hasattr: attribute name must be string")
+	}
+	_, err := attr(args[0], name)
+	return err == nil, nil
+}
+
+func biFail(args []any, kwargs map[string]any) (any, error) {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = Str(a)
+	}
+	return nil, fmt.Errorf("fail: %s", strings.Join(parts, " "))
+}
+
+func biPrint(args []any, kwargs map[string]any) (any, error) {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = Str(a)
+	}
+	Print(strings.Join(parts, " "))
+	return nil, nil
+}
+
+func biJSONEncode(args []any, kwargs map[string]any) (any, error) {
+	if err := checkArgs("json.encode", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	return EncodeJSON(args[0])
+}
+
+func biJSONDecode(args []any, kwargs map[string]any) (any, error) {
+	if err := checkArgs("json.decode", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("json.decode: got %s, want string", Type(args[0]))
+	}
+	return DecodeJSON([]byte(s))
+}
+
+// EncodeJSON encodes v as compact JSON; dict keys must be strings and keep
+// their insertion order.
+func EncodeJSON(v any) (string, error) {
+	var b bytes.Buffer
+	if err := encodeJSON(&b, v, 0); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func encodeJSON(b *bytes.Buffer, v any, depth int) error {
+	if depth > 64 {
+		return fmt.Errorf("json.encode: nesting too deep")
+	}
+	switch v := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case int64:
+		b.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("json.encode: cannot encode non-finite float %s", formatFloat(v))
+		}
+		b.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	case string:
+		q, _ := json.Marshal(v)
+		b.Write(q)
+	case Tuple, *List:
+		elems, _ := collect(v)
+		b.WriteByte('[')
+		for i, e := range elems {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := encodeJSON(b, e, depth+1); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	case *Dict:
+		b.WriteByte('{')
+		for i, k := range v.keys {
+			ks, ok := k.(string)
+			if !ok {
+				return fmt.Errorf("json.encode: dict key must be string, not %s", Type(k))
+			}
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			q, _ := json.Marshal(ks)
+			b.Write(q)
+			b.WriteByte(':')
+			if err := encodeJSON(b, v.at(k), depth+1); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	case *Struct:
+		d := NewDict()
+		for _, n := range v.names {
+			d.Set(n, v.fields[n])
+		}
+		return encodeJSON(b, d, depth)
+	default:
+		return fmt.Errorf("json.encode: cannot encode %s", Type(v))
+	}
+	return nil
+}
+
+// DecodeJSON decodes data into script values; objects become dicts in
+// document order, integral numbers become ints.
+func DecodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeJSON(dec)
+	if err != nil {
+		return nil, fmt.Errorf("json.decode: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("json.decode: unexpected data after value")
+	}
+	return v, nil
+}
+
+func decodeJSON(dec *json.Decoder) (any, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := t.(type) {
+	case json.Delim:
+		switch t {
+		case '[':
+			l := &List{}
+			for dec.More() {
+				e, err := decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				l.elems = append(l.elems, e)
+			}
+			_, err := dec.Token()
+			return l, err
+		case '{':
+			d := NewDict()
+			for dec.More() {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				e, err := decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				d.Set(kt.(string), e)
+			}
+			_, err := dec.Token()
+			return d, err
+		}
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return n, nil
+		}
+		return t.Float64()
+	case string, bool, nil:
+		return t, nil
+	}
+	return nil, fmt.Errorf("unexpected token %v", t)
+}
@@ -0,0 +1,542 @@
+// Package starlite interprets the Starlark subset used by bench scripts
+// (trace_bench -script): def/return, if/elif/else, for, list and dict
+// comprehensions, conditional expressions, None/bool/int/float/string/
+// tuple/list/dict values, string/list/dict methods, the common builtins
+// and json.encode/json.decode. while, lambda, load, sets, *args and
+// **kwargs are rejected with a syntax error.
+//
+// Unlike Starlark, ints are 64-bit: arithmetic that would overflow fails
+// with an error instead of wrapping.
+//
+// As in Starlark, recursion is rejected and every global value is frozen
+// once the module has executed, so a Program's functions may be called
+// from many goroutines concurrently.
+package starlite
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Error is a script error (syntax, runtime or fail()) with its position.
+type Error struct {
+	Pos string // file:line
+	Msg string
+}
+
+func (e *Error) Error() string { return e.Pos + ": " + e.Msg }
+
+// Program is an executed script module.
+type Program struct {
+	file        string
+	globals     map[string]any
+	predeclared map[string]any
+}
+
+// Exec parses and executes src as a module. predeclared names (host
+// functions, constants) are visible to the script alongside the builtins.
+func Exec(file string, src []byte, predeclared map[string]any) (*Program, error) {
+	body, err := parse(file, string(src))
+	if err != nil {
+		return nil, err
+	}
+	p := &Program{file: file, globals: map[string]any{}, predeclared: predeclared}
+	th := &thread{}
+	fr := &frame{prog: p, locals: p.globals, module: true}
+	if _, _, err := th.execBlock(fr, body); err != nil {
+		return nil, err
+	}
+	for _, v := range p.globals {
+		freeze(v)
+	}
+	return p, nil
+}
+
+// Global returns a module-level value.
+func (p *Program) Global(name string) (any, bool) {
+	v, ok := p.globals[name]
+	return v, ok
+}
+
+// Func returns the module-level function name, or nil if the script does
+// not define one.
+func (p *Program) Func(name string) *Function {
+	f, _ := p.globals[name].(*Function)
+	return f
+}
+
+// Call calls fn with positional arguments.
+func Call(fn any, args ...any) (any, error) {
+	return (&thread{}).call(fn, args, nil)
+}
+
+// Print is where print() writes; a host may redirect it.
+var Print = func(msg string) { fmt.Fprintln(os.Stderr, msg) }
+
+// thread는 호출 하나의 실행 상태(재귀 검사용 스택)
+type thread struct {
+	stack []*Function
+}
+
+type frame struct {
+	prog   *Program
+	locals map[string]any
+	module bool
+}
+
+type control int
+
+const (
+	ctlNone control = iota
+	ctlReturn
+	ctlBreak
+	ctlContinue
+)
+
+func (th *thread) call(fn any, args []any, kwargs map[string]any) (any, error) {
+	switch fn := fn.(type) {
+	case *Builtin:
+		return fn.fn(args, kwargs)
+	case *Function:
+		for _, f := range th.stack {
+			if f == fn {
+				return nil, fmt.Errorf("function %s called recursively", fn.name)
+			}
+		}
+		locals, err := bind(fn, args, kwargs)
+		if err != nil {
+			return nil, err
+		}
+		th.stack = append(th.stack, fn)
+		defer func() { th.stack = th.stack[:len(th.stack)-1] }()
+		ctl, v, err := th.execBlock(&frame{prog: fn.prog, locals: locals}, fn.body)
+		if err != nil {
+			return nil, err
+		}
+		if ctl != ctlReturn {
+			return nil, nil
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("%s value is not callable", Type(fn))
+}
+
+// bind는 인자를 매개변수에 대응시킨다
+func bind(fn *Function, args []any, kwargs map[string]any) (map[string]any, error) {
+	if len(args) > len(fn.params) {
+		return nil, fmt.Errorf("%s() takes %d positional arguments, got %d", fn.name, len(fn.params), len(args))
+	}
+	locals := make(map[string]any, len(fn.params))
+	for i, a := range args {
+		locals[fn.params[i].name] = a
+	}
+	for k, v := range kwargs {
+		found := false
+		for _, prm := range fn.params {
+			if prm.name == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s() got an unexpected keyword argument %s", fn.name, k)
+		}
+		if _, dup := locals[k]; dup {
+			return nil, fmt.Errorf("%s() got multiple values for parameter %s", fn.name, k)
+		}
+		locals[k] = v
+	}
+	for i, prm := range fn.params {
+		if _, ok := locals[prm.name]; ok {
+			continue
+		}
+		if prm.def == nil {
+			return nil, fmt.Errorf("%s() missing argument %s", fn.name, prm.name)
+		}
+		locals[prm.name] = fn.defaults[i]
+	}
+	return locals, nil
+}
+
+func (th *thread) execBlock(fr *frame, body []stmt) (control, any, error) {
+	for _, s := range body {
+		ctl, v, err := th.exec(fr, s)
+		if err != nil {
+			if _, ok := err.(*Error); !ok {
+				err = &Error{Pos: fmt.Sprintf("%s:%d", fr.prog.file, s.stmtLine()), Msg: err.Error()}
+			}
+			return ctlNone, nil, err
+		}
+		if ctl != ctlNone {
+			return ctl, v, nil
+		}
+	}
+	return ctlNone, nil, nil
+}
+
+func (th *thread) exec(fr *frame, s stmt) (control, any, error) {
+	switch s := s.(type) {
+	case *exprStmt:
+		_, err := th.eval(fr, s.x)
+		return ctlNone, nil, err
+	case *assignStmt:
+		rhs, err := th.eval(fr, s.rhs)
+		if err != nil {
+			return ctlNone, nil, err
+		}
+		if s.op != "=" {
+			cur, err := th.eval(fr, s.lhs)
+			if err != nil {
+				return ctlNone, nil, err
+			}
+			if l, ok := cur.(*List); ok && s.op == "+=" {
+				// list += 는 제자리 확장
+				if err := l.checkMutable(); err != nil {
+					return ctlNone, nil, err
+				}
+				if err := iterate(rhs, func(e any) (bool, error) { l.elems = append(l.elems, e); return true, nil }); err != nil {
+					return ctlNone, nil, err
+				}
+				rhs = l
+			} else if rhs, err = binary(strings.TrimSuffix(s.op, "="), cur, rhs); err != nil {
+				return ctlNone, nil, err
+			}
+		}
+		return ctlNone, nil, th.assign(fr, s.lhs, rhs)
+	case *ifStmt:
+		c, err := th.eval(fr, s.cond)
+		if err != nil {
+			return ctlNone, nil, err
+		}
+		if Truth(c) {
+			return th.execBlock(fr, s.then)
+		}
+		return th.execBlock(fr, s.els)
+	case *forStmt:
+		iter, err := th.eval(fr, s.iter)
+		if err != nil {
+			return ctlNone, nil, err
+		}
+		var ctl control
+		var ret any
+		err = iterate(iter, func(e any) (bool, error) {
+			if err := th.assign(fr, s.vars, e); err != nil {
+				return false, err
+			}
+			c, v, err := th.execBlock(fr, s.body)
+			switch {
+			case err != nil:
+				return false, err
+			case c == ctlReturn:
+				ctl, ret = c, v
+				return false, nil
+			case c == ctlBreak:
+				return false, nil
+			}
+			return true, nil
+		})
+		return ctl, ret, err
+	case *defStmt:
+		fn := &Function{name: s.name, params: s.params, body: s.body, prog: fr.prog}
+		fn.defaults = make([]any, len(s.params))
+		for i, prm := range s.params {
+			if prm.def == nil {
+				continue
+			}
+			v, err := th.eval(fr, prm.def)
+			if err != nil {
+				return ctlNone, nil, err
+			}
+			fn.defaults[i] = v
+		}
+		fr.locals[s.name] = fn
+		return ctlNone, nil, nil
+	case *returnStmt:
+		if s.x == nil {
+			return ctlReturn, nil, nil
+		}
+		v, err := th.eval(fr, s.x)
+		return ctlReturn, v, err
+	case *branchStmt:
+		switch s.kind {
+		case "break":
+			return ctlBreak, nil, nil
+		case "continue":
+			return ctlContinue, nil, nil
+		}
+		return ctlNone, nil, nil
+	}
+	return ctlNone, nil, fmt.Errorf("unexpected statement %T", s)
+}
+
+func (th *thread) assign(fr *frame, lhs expr, v any) error {
+	switch lhs := lhs.(type) {
+	case *identExpr:
+		fr.locals[lhs.name] = v
+		return nil
+	case *indexExpr:
+		x, err := th.eval(fr, lhs.x)
+		if err != nil {
+			return err
+		}
+		idx, err := th.eval(fr, lhs.idx)
+		if err != nil {
+			return err
+		}
+		switch x := x.(type) {
+		case *Dict:
+			return x.Set(idx, v)
+		case *List:
+			if err := x.checkMutable(); err != nil {
+				return err
+			}
+			i, err := seqIndex(idx, len(x.elems))
+			if err != nil {
+				return err
+			}
+			x.elems[i] = v
+			return nil
+		}
+		return fmt.Errorf("%s value does not support item assignment", Type(x))
+	case *tupleExpr:
+		return th.unpack(fr, lhs.elems, v)
+	case *listExpr:
+		return th.unpack(fr, lhs.elems, v)
+	}
+	return fmt.Errorf("invalid assignment target")
+}
+
+func (th *thread) unpack(fr *frame, targets []expr, v any) error {
+	var elems []any
+	if err := iterate(v, func(e any) (bool, error) { elems = append(elems, e); return true, nil }); err != nil {
+		return fmt.Errorf("cannot unpack %s", Type(v))
+	}
+	if len(elems) != len(targets) {
+		return fmt.Errorf("cannot unpack %d values into %d variables", len(elems), len(targets))
+	}
+	for i, t := range targets {
+		if err := th.assign(fr, t, elems[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (th *thread) lookup(fr *frame, name string) (any, error) {
+	if v, ok := fr.locals[name]; ok {
+		return v, nil
+	}
+	if !fr.module {
+		if v, ok := fr.prog.globals[name]; ok {
+			return v, nil
+		}
+	}
+	if v, ok := fr.prog.predeclared[name]; ok {
+		return v, nil
+	}
+	if v, ok := universe[name]; ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("undefined: %s", name)
+}
+
+func (th *thread) eval(fr *frame, x expr) (any, error) {
+	switch x := x.(type) {
+	case *litExpr:
+		return x.v, nil
+	case *identExpr:
+		return th.lookup(fr, x.name)
+	case *tupleExpr:
+		vs, err := th.evalAll(fr, x.elems)
+		return Tuple(vs), err
+	case *listExpr:
+		vs, err := th.evalAll(fr, x.elems)
+		return &List{elems: vs}, err
+	case *dictExpr:
+		d := NewDict()
+		for i := range x.keys {
+			k, err := th.eval(fr, x.keys[i])
+			if err != nil {
+				return nil, err
+			}
+			v, err := th.eval(fr, x.vals[i])
+			if err != nil {
+				return nil, err
+			}
+			if err := d.Set(k, v); err != nil {
+				return nil, err
+			}
+		}
+		return d, nil
+	case *compExpr:
+		return th.comprehension(fr, x)
+	case *unaryExpr:
+		v, err := th.eval(fr, x.x)
+		if err != nil {
+			return nil, err
+		}
+		return unary(x.op, v)
+	case *binaryExpr:
+		l, err := th.eval(fr, x.x)
+		if err != nil {
+			return nil, err
+		}
+		switch x.op {
+		case "and":
+			if !Truth(l) {
+				return l, nil
+			}
+			return th.eval(fr, x.y)
+		case "or":
+			if Truth(l) {
+				return l, nil
+			}
+			return th.eval(fr, x.y)
+		}
+		r, err := th.eval(fr, x.y)
+		if err != nil {
+			return nil, err
+		}
+		return binary(x.op, l, r)
+	case *condExpr:
+		c, err := th.eval(fr, x.cond)
+		if err != nil {
+			return nil, err
+		}
+		if Truth(c) {
+			return th.eval(fr, x.t)
+		}
+		return th.eval(fr, x.f)
+	case *callExpr:
+		fn, err := th.eval(fr, x.fn)
+		if err != nil {
+			return nil, err
+		}
+		var args []any
+		var kwargs map[string]any
+		for i, a := range x.args {
+			v, err := th.eval(fr, a)
+			if err != nil {
+				return nil, err
+			}
+			if x.kwnames[i] == "" {
+				args = append(args, v)
+				continue
+			}
+			if kwargs == nil {
+				kwargs = map[string]any{}
+			}
+			if _, dup := kwargs[x.kwnames[i]]; dup {
+				return nil, fmt.Errorf("duplicate keyword argument %s", x.kwnames[i])
+			}
+			kwargs[x.kwnames[i]] = v
+		}
+		return th.call(fn, args, kwargs)
+	case *indexExpr:
+		v, err := th.eval(fr, x.x)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := th.eval(fr, x.idx)
+		if err != nil {
+			return nil, err
+		}
+		return index(v, idx)
+	case *sliceExpr:
+		v, err := th.eval(fr, x.x)
+		if err != nil {
+			return nil, err
+		}
+		var lo, hi, step any
+		if x.lo != nil {
+			if lo, err = th.eval(fr, x.lo); err != nil {
+				return nil, err
+			}
+		}
+		if x.hi != nil {
+			if hi, err = th.eval(fr, x.hi); err != nil {
+				return nil, err
+			}
+		}
+		if x.step != nil {
+			if step, err = th.eval(fr, x.step); err != nil {
+				return nil, err
+			}
+		}
+		return slice(v, lo, hi, step)
+	case *dotExpr:
+		v, err := th.eval(fr, x.x)
+		if err != nil {
+			return nil, err
+		}
+		return attr(v, x.name)
+	}
+	return nil, fmt.Errorf("unexpected expression %T", x)
+}
+
+func (th *thread) evalAll(fr *frame, xs []expr) ([]any, error) {
+	vs := make([]any, len(xs))
+	for i, x := range xs {
+		v, err := th.eval(fr, x)
+		if err != nil {
+			return nil, err
+		}
+		vs[i] = v
+	}
+	return vs, nil
+}
+
+// comprehension은 절마다 재귀하며 결과를 모은다. 루프 변수는 별도 스코프
+func (th *thread) comprehension(fr *frame, c *compExpr) (any, error) {
+	locals := make(map[string]any, len(fr.locals)+1)
+	for k, v := range fr.locals {
+		locals[k] = v
+	}
+	inner := &frame{prog: fr.prog, locals: locals, module: false}
+	list := &List{}
+	dict := NewDict()
+	var run func(i int) error
+	run = func(i int) error {
+		if i == len(c.clauses) {
+			v, err := th.eval(inner, c.body)
+			if err != nil {
+				return err
+			}
+			if !c.dict {
+				list.elems = append(list.elems, v)
+				return nil
+			}
+			k, err := th.eval(inner, c.key)
+			if err != nil {
+				return err
+			}
+			return dict.Set(k, v)
+		}
+		cl := c.clauses[i]
+		if cl.vars == nil {
+			cond, err := th.eval(inner, cl.cond)
+			if err != nil || !Truth(cond) {
+				return err
+			}
+			return run(i + 1)
+		}
+		iter, err := th.eval(inner, cl.iter)
+		if err != nil {
+			return err
+		}
+		return iterate(iter, func(e any) (bool, error) {
+			if err := th.assign(inner, cl.vars, e); err != nil {
+				return false, err
+			}
+			return true, run(i + 1)
+		})
+	}
+	if err := run(0); err != nil {
+		return nil, err
+	}
+	if c.dict {
+		return dict, nil
+	}
+	return list, nil
+}
@@ -0,0 +1,326 @@
+package starlite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokKind int
+
+const (
+	tEOF tokKind = iota
+	tNewline
+	tIndent
+	tDedent
+	tName
+	tInt
+	tFloat
+	tString
+	tOp
+)
+
+type token struct {
+	kind tokKind
+	text string // tName/tOp: 원문, tString: 디코딩된 값
+	val  any    // tInt/tFloat 값
+	line int
+}
+
+// 긴 연산자부터 매칭
+var operators = []string{
+	"//=", "<<=", ">>=", "==", "!=", "<=", ">=", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "//", "<<", ">>", "**",
+	"+", "-", "*", "/", "%", "&", "|", "^", "~", "<", ">", "=", "(", ")", "[", "]", "{", "}", ",", ":", ".", ";",
+}
+
+type lexer struct {
+	file   string
+	src    string
+	pos    int
+	line   int
+	depth  int   // 괄호 깊이(>0이면 줄바꿈/들여쓰기 무시)
+	indent []int // 들여쓰기 스택
+	toks   []token
+}
+
+// lex는 src 전체를 토큰으로 나눈다(INDENT/DEDENT 포함)
+func lex(file, src string) ([]token, error) {
+	lx := &lexer{file: file, src: strings.ReplaceAll(src, "\r\n", "\n"), line: 1, indent: []int{0}}
+	atLineStart := true
+	for {
+		if atLineStart && lx.depth == 0 {
+			blank, err := lx.lineIndent()
+			if err != nil {
+				return nil, err
+			}
+			if blank {
+				continue
+			}
+			atLineStart = false
+		}
+		if lx.pos >= len(lx.src) {
+			break
+		}
+		c := lx.src[lx.pos]
+		switch {
+		case c == ' ' || c == '\t':
+			lx.pos++
+		case c == '#':
+			for lx.pos < len(lx.src) && lx.src[lx.pos] != '\n' {
+				lx.pos++
+			}
+		case c == '\\' && lx.pos+1 < len(lx.src) && lx.src[lx.pos+1] == '\n':
+			lx.pos += 2
+			lx.line++
+		case c == '\n':
+			if lx.depth == 0 {
+				lx.emit(tNewline, "", nil)
+				atLineStart = true
+			}
+			lx.pos++
+			lx.line++
+		case c == '"' || c == '\'' || (c == 'r' && lx.pos+1 < len(lx.src) && (lx.src[lx.pos+1] == '"' || lx.src[lx.pos+1] == '\'')):
+			if err := lx.str(); err != nil {
+				return nil, err
+			}
+		case isDigit(c) || (c == '.' && lx.pos+1 < len(lx.src) && isDigit(lx.src[lx.pos+1])):
+			if err := lx.number(); err != nil {
+				return nil, err
+			}
+		case isIdentStart(c):
+			start := lx.pos
+			for lx.pos < len(lx.src) && (isIdentStart(lx.src[lx.pos]) || isDigit(lx.src[lx.pos])) {
+				lx.pos++
+			}
+			lx.emit(tName, lx.src[start:lx.pos], nil)
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(lx.src[lx.pos:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, lx.errorf("unexpected character %q", c)
+			}
+			switch op {
+			case "(", "[", "{":
+				lx.depth++
+			case ")", "]", "}":
+				if lx.depth == 0 {
+					return nil, lx.errorf("unbalanced %q", op)
+				}
+				lx.depth--
+			}
+			lx.pos += len(op)
+			lx.emit(tOp, op, nil)
+		}
+	}
+	if lx.depth > 0 {
+		return nil, lx.errorf("unexpected end of file in brackets")
+	}
+	if n := len(lx.toks); n > 0 && lx.toks[n-1].kind != tNewline {
+		lx.emit(tNewline, "", nil)
+	}
+	for len(lx.indent) > 1 {
+		lx.indent = lx.indent[:len(lx.indent)-1]
+		lx.emit(tDedent, "", nil)
+	}
+	lx.emit(tEOF, "", nil)
+	return lx.toks, nil
+}
+
+func (lx *lexer) emit(k tokKind, text string, v any) {
+	lx.toks = append(lx.toks, token{kind: k, text: text, val: v, line: lx.line})
+}
+
+func (lx *lexer) errorf(format string, args ...any) error {
+	return &Error{Pos: fmt.Sprintf("%s:%d", lx.file, lx.line), Msg: fmt.Sprintf(format, args...)}
+}
+
+// lineIndent는 줄 머리의 들여쓰기를 읽어 INDENT/DEDENT를 낸다. 빈 줄/주석 줄이면 blank
+func (lx *lexer) lineIndent() (blank bool, err error) {
+	col := 0
+	for lx.pos < len(lx.src) {
+		switch lx.src[lx.pos] {
+		case ' ':
+			col++
+		case '\t':
+			col += 8 - col%8
+		default:
+			goto done
+		}
+		lx.pos++
+	}
+done:
+	if lx.pos >= len(lx.src) {
+		return false, nil
+	}
+	switch lx.src[lx.pos] {
+	case '\n':
+		lx.pos++
+		lx.line++
+		return true, nil
+	case '#':
+		for lx.pos < len(lx.src) && lx.src[lx.pos] != '\n' {
+			lx.pos++
+		}
+		return true, nil
+	}
+	top := lx.indent[len(lx.indent)-1]
+	switch {
+	case col > top:
+		lx.indent = append(lx.indent, col)
+		lx.emit(tIndent, "", nil)
+	case col < top:
+		for col < lx.indent[len(lx.indent)-1] {
+			lx.indent = lx.indent[:len(lx.indent)-1]
+			lx.emit(tDedent, "", nil)
+		}
+		if col != lx.indent[len(lx.indent)-1] {
+			return false, lx.errorf("unindent does not match any outer indentation level")
+		}
+	}
+	return false, nil
+}
+
+func (lx *lexer) number() error {
+	start := lx.pos
+	s := lx.src
+	if strings.HasPrefix(s[lx.pos:], "0x") || strings.HasPrefix(s[lx.pos:], "0X") {
+		lx.pos += 2
+		for lx.pos < len(s) && strings.IndexByte("0123456789abcdefABCDEF", s[lx.pos]) >= 0 {
+			lx.pos++
+		}
+	} else {
+		float := false
+		for lx.pos < len(s) {
+			c := s[lx.pos]
+			switch {
+			case isDigit(c):
+			case c == '.':
+				float = true
+			case c == 'e' || c == 'E':
+				float = true
+				if lx.pos+1 < len(s) && (s[lx.pos+1] == '+' || s[lx.pos+1] == '-') {
+					lx.pos++
+				}
+			default:
+				goto end
+			}
+			lx.pos++
+		}
+	end:
+		if float {
+			f, err := strconv.ParseFloat(s[start:lx.pos], 64)
+			if err != nil {
+				return lx.errorf("invalid float literal %s", s[start:lx.pos])
+			}
+			lx.emit(tFloat, s[start:lx.pos], f)
+			return nil
+		}
+	}
+	n, err := strconv.ParseInt(s[start:lx.pos], 0, 64)
+	if err != nil {
+		return lx.errorf("invalid int literal %s", s[start:lx.pos])
+	}
+	lx.emit(tInt, s[start:lx.pos], n)
+	return nil
+}
+
+// str은 '...', "...", 삼중 따옴표, r"..." 문자열을 읽는다
+func (lx *lexer) str() error {
+	raw := false
+	if lx.src[lx.pos] == 'r' {
+		raw = true
+		lx.pos++
+	}
+	q := lx.src[lx.pos : lx.pos+1]
+	if strings.HasPrefix(lx.src[lx.pos:], q+q+q) {
+		q = q + q + q
+	}
+	lx.pos += len(q)
+	startLine := lx.line
+	var b strings.Builder
+	for {
+		if lx.pos >= len(lx.src) {
+			lx.line = startLine
+			return lx.errorf("unterminated string")
+		}
+		if strings.HasPrefix(lx.src[lx.pos:], q) {
+			lx.pos += len(q)
+			break
+		}
+		c := lx.src[lx.pos]
+		switch {
+		case c == '\n' && len(q) == 1:
+			return lx.errorf("unterminated string")
+		case c == '\n':
+			lx.line++
+		case c == '\\' && lx.pos+1 < len(lx.src):
+			if raw {
+				b.WriteByte(c)
+				b.WriteByte(lx.src[lx.pos+1])
+				lx.pos += 2
+				continue
+			}
+			n, err := lx.escape(&b)
+			if err != nil {
+				return err
+			}
+			lx.pos += n
+			continue
+		}
+		b.WriteByte(c)
+		lx.pos++
+	}
+	lx.toks = append(lx.toks, token{kind: tString, text: b.String(), line: startLine})
+	return nil
+}
+
+// escape는 \ 시퀀스를 b에 풀어 쓰고 소비한 바이트 수를 돌려준다
+func (lx *lexer) escape(b *strings.Builder) (int, error) {
+	s := lx.src[lx.pos:]
+	switch s[1] {
+	case 'n':
+		b.WriteByte('\n')
+	case 't':
+		b.WriteByte('\t')
+	case 'r':
+		b.WriteByte('\r')
+	case '0':
+		b.WriteByte(0)
+	case '\\', '\'', '"':
+		b.WriteByte(s[1])
+	case '\n':
+		lx.line++
+	case 'x', 'u':
+		n := 2
+		if s[1] == 'u' {
+			n = 4
+		}
+		if len(s) < 2+n {
+			return 0, lx.errorf("truncated escape \\%c", s[1])
+		}
+		v, err := strconv.ParseUint(s[2:2+n], 16, 32)
+		if err != nil {
+			return 0, lx.errorf("invalid escape \\%s", s[1:2+n])
+		}
+		if s[1] == 'x' {
+			b.WriteByte(byte(v))
+		} else {
+			b.WriteRune(rune(v))
+		}
+		return 2 + n, nil
+	default:
+		return 0, lx.errorf("invalid escape \\%c", s[1])
+	}
+	return 2, nil
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,327 @@
+package starlite
+
+import (
+	"fmt"
+	"strings"
+)
+
+// attr는 struct 필드 또는 string/list/dict 메서드(수신자에 묶인 builtin)
+func attr(v any, name string) (any, error) {
+	switch v := v.(type) {
+	case *Struct:
+		if f, ok := v.fields[name]; ok {
+			return f, nil
+		}
+	case string:
+		if m, ok := stringMethods[name]; ok {
+			return bindMethod("string."+name, v, m), nil
+		}
+	case *List:
+		if m, ok := listMethods[name]; ok {
+			return bindMethod("list."+name, v, m), nil
+		}
+	case *Dict:
+		if m, ok := dictMethods[name]; ok {
+			return bindMethod("dict."+name, v, m), nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no .%s field or method", Type(v), name)
+}
+
+type method[T any] func(recv T, args []any) (any, error)
+
+func bindMethod[T any](name string, recv T, m method[T]) *Builtin {
+	return NewBuiltin(name, func(args []any, kwargs map[string]any) (any, error) {
+		if len(kwargs) > 0 {
+			return nil, fmt.Errorf("%s() does not accept keyword arguments", name)
+		}
+		return m(recv, args)
+	})
+}
+
+func nargs(name string, args []any, lo, hi int) error {
+	return checkArgs(name, args, nil, lo, hi)
+}
+
+func strArg(name string, a any) (string, error) {
+	s, ok := a.(string)
+	if !ok {
+		return "", fmt.Errorf("%s: got %s, want string", name, Type(a))
+	}
+	return s, nil
+}
+
+// strip 계열: 인자가 없으면 공백 제거
+func stripper(name string, f func(string, string) string, space func(string) string) method[string] {
+	return func(s string, args []any) (any, error) {
+		if err := nargs(name, args, 0, 1); err != nil {
+			return nil, err
+		}
+		if len(args) == 0 || args[0] == nil {
+			return space(s), nil
+		}
+		cut, err := strArg(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return f(s, cut), nil
+	}
+}
+
+var stringMethods = map[string]method[string]{
+	"upper": func(s string, args []any) (any, error) { return strings.ToUpper(s), nargs("upper", args, 0, 0) },
+	"lower": func(s string, args []any) (any, error) { return strings.ToLower(s), nargs("lower", args, 0, 0) },
+	"strip": stripper("strip", strings.Trim, strings.TrimSpace),
+	"lstrip": stripper("lstrip", strings.TrimLeft, func(s string) string {
+		return strings.TrimLeft(s, " \t\n\r\v\f")
+	}),
+	"rstrip": stripper("rstrip", strings.TrimRight, func(s string) string {
+		return strings.TrimRight(s, " \t\n\r\v\f")
+	}),
+	"startswith": func(s string, args []any) (any, error) {
+		if err := nargs("startswith", args, 1, 1); err != nil {
+			return nil, err
+		}
+		p, err := strArg("startswith", args[0])
+		return strings.HasPrefix(s, p), err
+	},
+	"endswith": func(s string, args []any) (any, error) {
+		if err := nargs("endswith", args, 1, 1); err != nil {
+			return nil, err
+		}
+		p, err := strArg("endswith", args[0])
+		return strings.HasSuffix(s, p), err
+	},
+	"find": func(s string, args []any) (any, error) {
+		if err := nargs("find", args, 1, 1); err != nil {
+			return nil, err
+		}
+		p, err := strArg("find", args[0])
+		return int64(strings.Index(s, p)), err
+	},
+	"replace": func(s string, args []any) (any, error) {
+		if err := nargs("replace", args, 2, 3); err != nil {
+			return nil, err
+		}
+		old, err := strArg("replace", args[0])
+		if err != nil {
+			return nil, err
+		}
+		repl, err := strArg("replace", args[1])
+		if err != nil {
+			return nil, err
+		}
+		n := -1
+		if len(args) == 3 {
+			c, ok := args[2].(int64)
+			if !ok {
+				return nil, fmt.Errorf("replace: count must be int")
+			}
+			n = int(c)
+		}
+		return strings.Replace(s, old, repl, n), nil
+	},
+	"split": func(s string, args []any) (any, error) {
+		if err := nargs("split", args, 0, 1); err != nil {
+			return nil, err
+		}
+		var parts []string
+		if len(args) == 0 || args[0] == nil {
+			parts = strings.Fields(s)
+		} else {
+			sep, err := strArg("split", args[0])
+			if err != nil {
+				return nil, err
+			}
+			if sep == "" {
+				return nil, fmt.Errorf("split: empty separator")
+			}
+			parts = strings.Split(s, sep)
+		}
+		out := &List{elems: make([]any, len(parts))}
+		for i, p := range parts {
+			out.elems[i] = p
+		}
+		return out, nil
+	},
+	"join": func(s string, args []any) (any, error) {
+		if err := nargs("join", args, 1, 1); err != nil {
+			return nil, err
+		}
+		elems, err := collect(args[0])
+		if err != nil {
+			return nil, err
+		}
+		parts := make([]string, len(elems))
+		for i, e := range elems {
+			if parts[i], err = strArg("join", e); err != nil {
+				return nil, err
+			}
+		}
+		return strings.Join(parts, s), nil
+	},
+	"format": func(s string, args []any) (any, error) {
+		// {} 와 {0} 위치 치환만 지원
+		var b strings.Builder
+		auto := 0
+		for i := 0; i < len(s); i++ {
+			switch {
+			case s[i] == '{' && i+1 < len(s) && s[i+1] == '{':
+				b.WriteByte('{')
+				i++
+			case s[i] == '}' && i+1 < len(s) && s[i+1] == '}':
+				b.WriteByte('}')
+				i++
+			case s[i] == '{':
+				end := strings.IndexByte(s[i:], '}')
+				if end < 0 {
+					return nil, fmt.Errorf("format: unmatched '{'")
+				}
+				field := s[i+1 : i+end]
+				n := auto
+				if field == "" {
+					auto++
+				} else if _, err := fmt.Sscanf(field, "%d", &n); err != nil {
+					return nil, fmt.Errorf("format: unsupported field {%s}", field)
+				}
+				if n < 0 || n >= len(args) {
+					return nil, fmt.Errorf("format: index %d out of range", n)
+				}
+				b.WriteString(Str(args[n]))
+				i += end
+			default:
+				b.WriteByte(s[i])
+			}
+		}
+		return b.String(), nil
+	},
+}
+
+var listMethods = map[string]method[*List]{
+	"append": func(l *List, args []any) (any, error) {
+		if err := nargs("append", args, 1, 1); err != nil {
+			return nil, err
+		}
+		if err := l.checkMutable(); err != nil {
+			return nil, err
+		}
+		l.elems = append(l.elems, args[0])
+		return nil, nil
+	},
+	"extend": func(l *List, args []any) (any, error) {
+		if err := nargs("extend", args, 1, 1); err != nil {
+			return nil, err
+		}
+		if err := l.checkMutable(); err != nil {
+			return nil, err
+		}
+		elems, err := collect(args[0])
+		l.elems = append(l.elems, elems...)
+		return nil, err
+	},
+	"pop": func(l *List, args []any) (any, error) {
+		if err := nargs("pop", args, 0, 1); err != nil {
+			return nil, err
+		}
+		if err := l.checkMutable(); err != nil {
+			return nil, err
+		}
+		var idx any = int64(-1)
+		if len(args) == 1 {
+			idx = args[0]
+		}
+		i, err := seqIndex(idx, len(l.elems))
+		if err != nil {
+			return nil, fmt.Errorf("pop: %w", err)
+		}
+		v := l.elems[i]
+		l.elems = append(l.elems[:i], l.elems[i+1:]...)
+		return v, nil
+	},
+	"index": func(l *List, args []any) (any, error) {
+		if err := nargs("index", args, 1, 1); err != nil {
+			return nil, err
+		}
+		for i, e := range l.elems {
+			if equal(e, args[0]) {
+				return int64(i), nil
+			}
+		}
+		return nil, fmt.Errorf("index: value not in list")
+	},
+}
+
+var dictMethods = map[string]method[*Dict]{
+	"get": func(d *Dict, args []any) (any, error) {
+		if err := nargs("get", args, 1, 2); err != nil {
+			return nil, err
+		}
+		if v, ok := d.Get(args[0]); ok {
+			return v, nil
+		}
+		if len(args) == 2 {
+			return args[1], nil
+		}
+		return nil, nil
+	},
+	"keys": func(d *Dict, args []any) (any, error) {
+		return &List{elems: append([]any(nil), d.keys...)}, nargs("keys", args, 0, 0)
+	},
+	"values": func(d *Dict, args []any) (any, error) {
+		out := &List{elems: make([]any, len(d.keys))}
+		for i, k := range d.keys {
+			out.elems[i] = d.at(k)
+		}
+		return out, nargs("values", args, 0, 0)
+	},
+	"items": func(d *Dict, args []any) (any, error) {
+		out := &List{elems: make([]any, len(d.keys))}
+		for i, k := range d.keys {
+			out.elems[i] = Tuple{k, d.at(k)}
+		}
+		return out, nargs("items", args, 0, 0)
+	},
+	"pop": func(d *Dict, args []any) (any, error) {
+		if err := nargs("pop", args, 1, 2); err != nil {
+			return nil, err
+		}
+		v, ok, err := d.delete(args[0])
+		switch {
+		case err != nil:
+			return nil, err
+		case ok:
+			return v, nil
+		case len(args) == 2:
+			return args[1], nil
+		}
+		return nil, fmt.Errorf("pop: key %s not in dict", Repr(args[0]))
+	},
+	"update": func(d *Dict, args []any) (any, error) {
+		if err := nargs("update", args, 1, 1); err != nil {
+			return nil, err
+		}
+		src, ok := args[0].(*Dict)
+		if !ok {
+			return nil, fmt.Errorf("update: got %s, want dict", Type(args[0]))
+		}
+		for _, k := range src.keys {
+			if err := d.Set(k, src.at(k)); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	},
+	"setdefault": func(d *Dict, args []any) (any, error) {
+		if err := nargs("setdefault", args, 1, 2); err != nil {
+			return nil, err
+		}
+		if v, ok := d.Get(args[0]); ok {
+			return v, nil
+		}
+		var def any
+		if len(args) == 2 {
+			def = args[1]
+		}
+		return def, d.Set(args[0], def)
+	},
+}
@@ -0,0 +1,450 @@
+package starlite
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+func unary(op string, v any) (any, error) {
+	switch op {
+	case "not":
+		return !Truth(v), nil
+	case "-":
+		switch v := v.(type) {
+		case int64:
+			if v == math.MinInt64 {
+				return nil, errIntOverflow
+			}
+			return -v, nil
+		case float64:
+			return -v, nil
+		}
+	case "~":
+		if v, ok := v.(int64); ok {
+			return ^v, nil
+		}
+	case "+":
+		switch v.(type) {
+		case int64, float64:
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported operand for unary %s: %s", op, Type(v))
+}
+
+// errIntOverflow: Starlark 정수는 무한 정밀도지만 여기서는 int64라 넘치면 조용히 감싸지 않고 오류
+var errIntOverflow = fmt.Errorf("integer overflow (ints are 64-bit)")
+
+func binary(op string, x, y any) (any, error) {
+	switch op {
+	case "==":
+		return equal(x, y), nil
+	case "!=":
+		return !equal(x, y), nil
+	case "<", ">", "<=", ">=":
+		c, err := compare(x, y)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "<":
+			return c < 0, nil
+		case ">":
+			return c > 0, nil
+		case "<=":
+			return c <= 0, nil
+		}
+		return c >= 0, nil
+	case "in", "not in":
+		ok, err := contains(y, x)
+		if err != nil {
+			return nil, err
+		}
+		return ok == (op == "in"), nil
+	}
+
+	// 정수끼리(/ 제외)는 정수 연산
+	if xi, ok := x.(int64); ok {
+		if yi, ok := y.(int64); ok && op != "/" {
+			switch op {
+			case "+":
+				return addInt(xi, yi)
+			case "-":
+				if yi == math.MinInt64 {
+					if xi >= 0 {
+						return nil, errIntOverflow
+					}
+					return xi - yi, nil
+				}
+				return addInt(xi, -yi)
+			case "*":
+				return mulInt(xi, yi)
+			case "&":
+				return xi & yi, nil
+			case "|":
+				return xi | yi, nil
+			case "^":
+				return xi ^ yi, nil
+			case "<<", ">>":
+				return shift(op, xi, yi)
+			case "//", "%":
+				if yi == 0 {
+					return nil, fmt.Errorf("integer division by zero")
+				}
+				if xi == math.MinInt64 && yi == -1 {
+					if op == "//" {
+						return nil, errIntOverflow
+					}
+					return int64(0), nil
+				}
+				q, r := xi/yi, xi%yi
+				// 나머지 부호는 제수를 따른다(Python 방식)
+				if r != 0 && (r < 0) != (yi < 0) {
+					q, r = q-1, r+yi
+				}
+				if op == "//" {
+					return q, nil
+				}
+				return r, nil
+			}
+		}
+	}
+	if xf, ok := toFloat(x); ok {
+		if yf, ok := toFloat(y); ok {
+			switch op {
+			case "+":
+				return xf + yf, nil
+			case "-":
+				return xf - yf, nil
+			case "*":
+				return xf * yf, nil
+			case "/", "//", "%":
+				if yf == 0 {
+					return nil, fmt.Errorf("floating-point division by zero")
+				}
+				switch op {
+				case "/":
+					return xf / yf, nil
+				case "//":
+					return math.Floor(xf / yf), nil
+				}
+				r := math.Mod(xf, yf)
+				if r != 0 && (r < 0) != (yf < 0) {
+					r += yf
+				}
+				return r, nil
+			}
+		}
+	}
+	switch x := x.(type) {
+	case string:
+		switch op {
+		case "+":
+			if y, ok := y.(string); ok {
+				return x + y, nil
+			}
+		case "*":
+			if n, ok := y.(int64); ok {
+				return strings.Repeat(x, int(max(n, 0))), nil
+			}
+		case "%":
+			return format(x, y)
+		}
+	case *List:
+		switch op {
+		case "+":
+			if y, ok := y.(*List); ok {
+				return &List{elems: append(append([]any(nil), x.elems...), y.elems...)}, nil
+			}
+		case "*":
+			if n, ok := y.(int64); ok {
+				out := &List{}
+				for i := int64(0); i < n; i++ {
+					out.elems = append(out.elems, x.elems...)
+				}
+				return out, nil
+			}
+		}
+	case Tuple:
+		if y, ok := y.(Tuple); ok && op == "+" {
+			return append(append(Tuple(nil), x...), y...), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported operand types for %s: %s and %s", op, Type(x), Type(y))
+}
+
+// floatFitsInt는 f를 잘라 int64로 나타낼 수 있는지(NaN/Inf 제외)
+func floatFitsInt(f float64) bool {
+	return f >= math.MinInt64 && f < math.MaxInt64
+}
+
+func addInt(x, y int64) (any, error) {
+	r := x + y
+	if (x > 0 && y > 0 && r < 0) || (x < 0 && y < 0 && r >= 0) {
+		return nil, errIntOverflow
+	}
+	return r, nil
+}
+
+func mulInt(x, y int64) (any, error) {
+	if x == 0 || y == 0 {
+		return int64(0), nil
+	}
+	r := x * y
+	if r/y != x || (x == -1 && y == math.MinInt64) || (y == -1 && x == math.MinInt64) {
+		return nil, errIntOverflow
+	}
+	return r, nil
+}
+
+func shift(op string, x, n int64) (any, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("negative shift count: %d", n)
+	}
+	if op == ">>" {
+		return x >> min(n, 63), nil
+	}
+	if x == 0 {
+		return int64(0), nil
+	}
+	if n >= 64 || x<<n>>n != x {
+		return nil, errIntOverflow
+	}
+	return x << n, nil
+}
+
+func contains(container, x any) (bool, error) {
+	switch c := container.(type) {
+	case string:
+		s, ok := x.(string)
+		if !ok {
+			return false, fmt.Errorf("'in <string>' requires string as left operand, not %s", Type(x))
+		}
+		return strings.Contains(c, s), nil
+	case *Dict:
+		_, ok := c.Get(x)
+		return ok, nil
+	case Tuple, *List, rangeValue:
+		found := false
+		err := iterate(c, func(e any) (bool, error) {
+			found = equal(e, x)
+			return !found, nil
+		})
+		return found, err
+	}
+	return false, fmt.Errorf("unsupported operand for in: %s", Type(container))
+}
+
+// seqIndex는 음수 인덱스를 보정하고 범위를 검사한다
+func seqIndex(idx any, n int) (int, error) {
+	i, ok := idx.(int64)
+	if !ok {
+		return 0, fmt.Errorf("index must be int, not %s", Type(idx))
+	}
+	if i < 0 {
+		i += int64(n)
+	}
+	if i < 0 || i >= int64(n) {
+		return 0, fmt.Errorf("index %d out of range [0:%d]", idx, n)
+	}
+	return int(i), nil
+}
+
+func index(v, idx any) (any, error) {
+	switch v := v.(type) {
+	case *Dict:
+		if !hashable(idx) {
+			return nil, fmt.Errorf("unhashable type: %s", Type(idx))
+		}
+		e, ok := v.Get(idx)
+		if !ok {
+			return nil, fmt.Errorf("key %s not in dict", Repr(idx))
+		}
+		return e, nil
+	case *List:
+		i, err := seqIndex(idx, len(v.elems))
+		if err != nil {
+			return nil, err
+		}
+		return v.elems[i], nil
+	case Tuple:
+		i, err := seqIndex(idx, len(v))
+		if err != nil {
+			return nil, err
+		}
+		return v[i], nil
+	case string:
+		i, err := seqIndex(idx, len(v))
+		if err != nil {
+			return nil, err
+		}
+		return v[i : i+1], nil
+	case rangeValue:
+		i, err := seqIndex(idx, int(v.len()))
+		if err != nil {
+			return nil, err
+		}
+		return v.start + int64(i)*v.step, nil
+	}
+	return nil, fmt.Errorf("%s value is not indexable", Type(v))
+}
+
+// slice는 x[lo:hi:step] (Python과 같은 경계 보정)
+func slice(v, lo, hi, step any) (any, error) {
+	st := int64(1)
+	if step != nil {
+		s, ok := step.(int64)
+		if !ok {
+			return nil, fmt.Errorf("slice step must be int, not %s", Type(step))
+		}
+		if s == 0 {
+			return nil, fmt.Errorf("slice step cannot be zero")
+		}
+		st = s
+	}
+	var n int64
+	switch v := v.(type) {
+	case string:
+		n = int64(len(v))
+	case *List:
+		n = int64(len(v.elems))
+	case Tuple:
+		n = int64(len(v))
+	default:
+		return nil, fmt.Errorf("%s value is not sliceable", Type(v))
+	}
+	// 음수 step이면 -1이 "첫 원소 앞"이고 기본 범위가 뒤집힌다
+	lower, upper := int64(0), n
+	defLo, defHi := lower, upper
+	if st < 0 {
+		lower, upper = -1, n-1
+		defLo, defHi = upper, lower
+	}
+	bound := func(b any, def int64) (int64, error) {
+		if b == nil {
+			return def, nil
+		}
+		i, ok := b.(int64)
+		if !ok {
+			return 0, fmt.Errorf("slice index must be int, not %s", Type(b))
+		}
+		if i < 0 {
+			return max(i+n, lower), nil
+		}
+		return min(i, upper), nil
+	}
+	i, err := bound(lo, defLo)
+	if err != nil {
+		return nil, err
+	}
+	j, err := bound(hi, defHi)
+	if err != nil {
+		return nil, err
+	}
+	if st == 1 {
+		j = max(i, j)
+		switch v := v.(type) {
+		case string:
+			return v[i:j], nil
+		case *List:
+			return &List{elems: append([]any(nil), v.elems[i:j]...)}, nil
+		}
+		return append(Tuple(nil), v.(Tuple)[i:j]...), nil
+	}
+	var cnt int64
+	switch {
+	case st > 0 && i < j:
+		cnt = (j-i-1)/st + 1
+	case st < 0 && i > j:
+		cnt = int64(uint64(i-j-1)/uint64(-st)) + 1 // -MinInt64도 uint64로는 2^63
+	}
+	idx := make([]int64, cnt)
+	for k := range idx {
+		idx[k] = i + int64(k)*st
+	}
+	switch v := v.(type) {
+	case string:
+		b := make([]byte, len(idx))
+		for k, x := range idx {
+			b[k] = v[x]
+		}
+		return string(b), nil
+	case *List:
+		out := &List{elems: make([]any, len(idx))}
+		for k, x := range idx {
+			out.elems[k] = v.elems[x]
+		}
+		return out, nil
+	}
+	out := make(Tuple, len(idx))
+	for k, x := range idx {
+		out[k] = v.(Tuple)[x]
+	}
+	return out, nil
+}
+
+// format은 "..." % x (%s %r %d %i %f %x %%)
+func format(f string, arg any) (any, error) {
+	args, ok := arg.(Tuple)
+	if !ok {
+		args = Tuple{arg}
+	}
+	var b strings.Builder
+	n := 0
+	for i := 0; i < len(f); i++ {
+		if f[i] != '%' {
+			b.WriteByte(f[i])
+			continue
+		}
+		if i++; i == len(f) {
+			return nil, fmt.Errorf("incomplete format")
+		}
+		c := f[i]
+		if c == '%' {
+			b.WriteByte('%')
+			continue
+		}
+		if n >= len(args) {
+			return nil, fmt.Errorf("not enough arguments for format string")
+		}
+		a := args[n]
+		n++
+		switch c {
+		case 's':
+			b.WriteString(Str(a))
+		case 'r':
+			b.WriteString(Repr(a))
+		case 'd', 'i', 'x':
+			var iv int64
+			switch a := a.(type) {
+			case int64:
+				iv = a
+			case float64:
+				if !floatFitsInt(a) {
+					return nil, fmt.Errorf("%%%c format: %s out of int range", c, formatFloat(a))
+				}
+				iv = int64(a)
+			default:
+				return nil, fmt.Errorf("%%%c format requires number, not %s", c, Type(a))
+			}
+			if c == 'x' {
+				fmt.Fprintf(&b, "%x", iv)
+			} else {
+				fmt.Fprintf(&b, "%d", iv)
+			}
+		case 'f':
+			fv, ok := toFloat(a)
+			if !ok {
+				return nil, fmt.Errorf("%%f format requires number, not %s", Type(a))
+			}
+			fmt.Fprintf(&b, "%f", fv)
+		default:
+			return nil, fmt.Errorf("unsupported format character %q", c)
+		}
+	}
+	if n < len(args) {
+		return nil, fmt.Errorf("too many arguments for format string")
+	}
+	return b.String(), nil
+}
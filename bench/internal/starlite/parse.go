@@ -0,0 +1,830 @@
+package starlite
+
+import (
+	"fmt"
+)
+
+// 구문 트리
+type (
+	expr interface{ exprLine() int }
+	stmt interface{ stmtLine() int }
+
+	pos int
+
+	identExpr struct {
+		pos
+		name string
+	}
+	litExpr struct {
+		pos
+		v any
+	}
+	listExpr struct {
+		pos
+		elems []expr
+	}
+	tupleExpr struct {
+		pos
+		elems []expr
+	}
+	dictExpr struct {
+		pos
+		keys, vals []expr
+	}
+	// compExpr은 [x for ...] / {k: v for ...}
+	compExpr struct {
+		pos
+		dict    bool
+		key     expr // dict일 때만
+		body    expr
+		clauses []compClause
+	}
+	compClause struct {
+		vars expr // for 절(nil이면 if 절)
+		iter expr
+		cond expr
+	}
+	unaryExpr struct {
+		pos
+		op string
+		x  expr
+	}
+	binaryExpr struct {
+		pos
+		op   string
+		x, y expr
+	}
+	condExpr struct {
+		pos
+		cond, t, f expr
+	}
+	callExpr struct {
+		pos
+		fn      expr
+		args    []expr
+		kwnames []string // args와 같은 길이, 위치 인자는 ""
+	}
+	indexExpr struct {
+		pos
+		x, idx expr
+	}
+	sliceExpr struct {
+		pos
+		x, lo, hi, step expr
+	}
+	dotExpr struct {
+		pos
+		x    expr
+		name string
+	}
+
+	exprStmt struct {
+		pos
+		x expr
+	}
+	assignStmt struct {
+		pos
+		op       string // "=" 또는 "+=" 등
+		lhs, rhs expr
+	}
+	ifStmt struct {
+		pos
+		cond      expr
+		then, els []stmt
+	}
+	forStmt struct {
+		pos
+		vars, iter expr
+		body       []stmt
+	}
+	defStmt struct {
+		pos
+		name   string
+		params []param
+		body   []stmt
+	}
+	param struct {
+		name string
+		def  expr
+	}
+	returnStmt struct {
+		pos
+		x expr
+	}
+	branchStmt struct {
+		pos
+		kind string // break|continue|pass
+	}
+)
+
+func (p pos) exprLine() int { return int(p) }
+func (p pos) stmtLine() int { return int(p) }
+
+type parser struct {
+	file string
+	toks []token
+	i    int
+}
+
+func parse(file, src string) ([]stmt, error) {
+	toks, err := lex(file, src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{file: file, toks: toks}
+	var body []stmt
+	for p.peek().kind != tEOF {
+		s, err := p.stmt()
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, s...)
+	}
+	return body, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &Error{Pos: fmt.Sprintf("%s:%d", p.file, t.line), Msg: fmt.Sprintf(format, args...)}
+}
+
+// isOp/isKw는 현재 토큰이 해당 연산자/키워드인지 본다
+func (p *parser) isOp(op string) bool {
+	t := p.peek()
+	return t.kind == tOp && t.text == op
+}
+
+func (p *parser) isKw(kw string) bool {
+	t := p.peek()
+	return t.kind == tName && t.text == kw
+}
+
+func (p *parser) expectOp(op string) error {
+	if !p.isOp(op) {
+		return p.errorf(p.peek(), "expected %q, got %s", op, describe(p.peek()))
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) expectKw(kw string) error {
+	if !p.isKw(kw) {
+		return p.errorf(p.peek(), "expected %q, got %s", kw, describe(p.peek()))
+	}
+	p.next()
+	return nil
+}
+
+func describe(t token) string {
+	switch t.kind {
+	case tEOF:
+		return "end of file"
+	case tNewline:
+		return "newline"
+	case tIndent:
+		return "indent"
+	case tDedent:
+		return "unindent"
+	case tString:
+		return "string literal"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+var keywords = map[string]bool{
+	"and": true, "break": true, "continue": true, "def": true, "elif": true, "else": true,
+	"for": true, "if": true, "in": true, "lambda": true, "load": true, "not": true,
+	"or": true, "pass": true, "return": true, "while": true,
+}
+
+// stmt은 복합문 하나 또는 한 줄의 단순문(;로 여러 개)을 읽는다
+func (p *parser) stmt() ([]stmt, error) {
+	t := p.peek()
+	if t.kind == tName {
+		switch t.text {
+		case "def":
+			s, err := p.def()
+			return []stmt{s}, err
+		case "if":
+			p.next()
+			s, err := p.ifRest(t)
+			return []stmt{s}, err
+		case "for":
+			s, err := p.forStmt()
+			return []stmt{s}, err
+		case "while", "lambda", "load":
+			return nil, p.errorf(t, "%s is not supported", t.text)
+		}
+	}
+	var out []stmt
+	for {
+		s, err := p.simple()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+		if !p.isOp(";") {
+			break
+		}
+		p.next()
+		if p.peek().kind == tNewline {
+			break
+		}
+	}
+	if p.peek().kind != tNewline {
+		return nil, p.errorf(p.peek(), "unexpected %s", describe(p.peek()))
+	}
+	p.next()
+	return out, nil
+}
+
+func (p *parser) simple() (stmt, error) {
+	t := p.peek()
+	at := pos(t.line)
+	if t.kind == tName {
+		switch t.text {
+		case "return":
+			p.next()
+			if p.peek().kind == tNewline || p.isOp(";") {
+				return &returnStmt{pos: at}, nil
+			}
+			x, err := p.exprList()
+			return &returnStmt{pos: at, x: x}, err
+		case "break", "continue", "pass":
+			p.next()
+			return &branchStmt{pos: at, kind: t.text}, nil
+		}
+	}
+	lhs, err := p.exprList()
+	if err != nil {
+		return nil, err
+	}
+	if op := p.peek(); op.kind == tOp {
+		switch op.text {
+		case "=", "+=", "-=", "*=", "/=", "//=", "%=", "&=", "|=", "^=", "<<=", ">>=":
+			p.next()
+			if err := checkTarget(p, lhs, op.text == "="); err != nil {
+				return nil, err
+			}
+			rhs, err := p.exprList()
+			if err != nil {
+				return nil, err
+			}
+			return &assignStmt{pos: at, op: op.text, lhs: lhs, rhs: rhs}, nil
+		}
+	}
+	return &exprStmt{pos: at, x: lhs}, nil
+}
+
+// checkTarget은 대입 대상이 이름/인덱스/(튜플)인지 확인한다
+func checkTarget(p *parser, x expr, allowTuple bool) error {
+	switch x := x.(type) {
+	case *identExpr, *indexExpr:
+		return nil
+	case *tupleExpr:
+		if allowTuple {
+			for _, e := range x.elems {
+				if err := checkTarget(p, e, true); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case *listExpr:
+		if allowTuple {
+			for _, e := range x.elems {
+				if err := checkTarget(p, e, true); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return &Error{Pos: fmt.Sprintf("%s:%d", p.file, x.exprLine()), Msg: "invalid assignment target"}
+}
+
+func (p *parser) suite() ([]stmt, error) {
+	if err := p.expectOp(":"); err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tNewline {
+		return p.stmt()
+	}
+	p.next()
+	if p.peek().kind != tIndent {
+		return nil, p.errorf(p.peek(), "expected an indented block")
+	}
+	p.next()
+	var body []stmt
+	for p.peek().kind != tDedent && p.peek().kind != tEOF {
+		s, err := p.stmt()
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, s...)
+	}
+	p.next()
+	return body, nil
+}
+
+func (p *parser) def() (stmt, error) {
+	t := p.next()
+	name := p.next()
+	if name.kind != tName || keywords[name.text] {
+		return nil, p.errorf(name, "expected function name, got %s", describe(name))
+	}
+	if err := p.expectOp("("); err != nil {
+		return nil, err
+	}
+	var params []param
+	seen := map[string]bool{}
+	for !p.isOp(")") {
+		if p.isOp("*") || p.isOp("**") {
+			return nil, p.errorf(p.peek(), "*args and **kwargs parameters are not supported")
+		}
+		pt := p.next()
+		if pt.kind != tName || keywords[pt.text] {
+			return nil, p.errorf(pt, "expected parameter name, got %s", describe(pt))
+		}
+		if seen[pt.text] {
+			return nil, p.errorf(pt, "duplicate parameter %s", pt.text)
+		}
+		seen[pt.text] = true
+		prm := param{name: pt.text}
+		if p.isOp("=") {
+			p.next()
+			d, err := p.test()
+			if err != nil {
+				return nil, err
+			}
+			prm.def = d
+		} else if len(params) > 0 && params[len(params)-1].def != nil {
+			return nil, p.errorf(pt, "required parameter %s follows optional", pt.text)
+		}
+		params = append(params, prm)
+		if !p.isOp(",") {
+			break
+		}
+		p.next()
+	}
+	if err := p.expectOp(")"); err != nil {
+		return nil, err
+	}
+	body, err := p.suite()
+	if err != nil {
+		return nil, err
+	}
+	return &defStmt{pos: pos(t.line), name: name.text, params: params, body: body}, nil
+}
+
+// ifRest는 "if"/"elif" 다음부터 읽는다
+func (p *parser) ifRest(t token) (stmt, error) {
+	cond, err := p.test()
+	if err != nil {
+		return nil, err
+	}
+	then, err := p.suite()
+	if err != nil {
+		return nil, err
+	}
+	s := &ifStmt{pos: pos(t.line), cond: cond, then: then}
+	switch {
+	case p.isKw("elif"):
+		et := p.next()
+		el, err := p.ifRest(et)
+		if err != nil {
+			return nil, err
+		}
+		s.els = []stmt{el}
+	case p.isKw("else"):
+		p.next()
+		if s.els, err = p.suite(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) forStmt() (stmt, error) {
+	t := p.next()
+	vars, err := p.targets()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectKw("in"); err != nil {
+		return nil, err
+	}
+	iter, err := p.exprList()
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.suite()
+	if err != nil {
+		return nil, err
+	}
+	return &forStmt{pos: pos(t.line), vars: vars, iter: iter, body: body}, nil
+}
+
+// targets는 for 변수(이름 또는 이름 튜플)를 읽는다
+func (p *parser) targets() (expr, error) {
+	at := pos(p.peek().line)
+	var elems []expr
+	for {
+		x, err := p.primary()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, x)
+		if !p.isOp(",") {
+			break
+		}
+		p.next()
+	}
+	var x expr = &tupleExpr{pos: at, elems: elems}
+	if len(elems) == 1 {
+		x = elems[0]
+	}
+	return x, checkTarget(p, x, true)
+}
+
+// exprList는 콤마로 이어진 식(2개 이상이면 튜플)
+func (p *parser) exprList() (expr, error) {
+	at := pos(p.peek().line)
+	x, err := p.test()
+	if err != nil || !p.isOp(",") {
+		return x, err
+	}
+	elems := []expr{x}
+	for p.isOp(",") {
+		p.next()
+		if p.peek().kind == tNewline || p.isOp("=") || p.isOp(")") || p.isOp(":") {
+			break
+		}
+		y, err := p.test()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, y)
+	}
+	return &tupleExpr{pos: at, elems: elems}, nil
+}
+
+// test: or_test ['if' or_test 'else' test]
+func (p *parser) test() (expr, error) {
+	t := p.peek()
+	x, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.isKw("if") {
+		return x, nil
+	}
+	p.next()
+	cond, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectKw("else"); err != nil {
+		return nil, err
+	}
+	f, err := p.test()
+	if err != nil {
+		return nil, err
+	}
+	return &condExpr{pos: pos(t.line), cond: cond, t: x, f: f}, nil
+}
+
+// 이항 연산자 우선순위(낮은 것부터)
+var precedence = []map[string]bool{
+	{"or": true},
+	{"and": true},
+	{"not": true}, // 단항 not 자리
+	{"==": true, "!=": true, "<": true, ">": true, "<=": true, ">=": true, "in": true, "not in": true},
+	{"|": true},
+	{"^": true},
+	{"&": true},
+	{"<<": true, ">>": true},
+	{"+": true, "-": true},
+	{"*": true, "/": true, "//": true, "%": true},
+}
+
+// binOp은 현재 토큰이 level의 이항 연산자면 그 이름을 돌려준다
+func (p *parser) binOp(level int) string {
+	t := p.peek()
+	if t.kind != tOp && t.kind != tName {
+		return ""
+	}
+	op := t.text
+	if t.kind == tName && op == "not" {
+		if nt := p.toks[p.i+1]; nt.kind == tName && nt.text == "in" {
+			op = "not in"
+		} else {
+			return ""
+		}
+	}
+	if t.kind == tName && op != "and" && op != "or" && op != "in" && op != "not in" {
+		return ""
+	}
+	if precedence[level][op] {
+		return op
+	}
+	return ""
+}
+
+func (p *parser) binary(level int) (expr, error) {
+	if level == len(precedence) {
+		return p.unary()
+	}
+	if level == 2 {
+		if p.isKw("not") {
+			t := p.next()
+			x, err := p.binary(level)
+			if err != nil {
+				return nil, err
+			}
+			return &unaryExpr{pos: pos(t.line), op: "not", x: x}, nil
+		}
+		return p.binary(level + 1)
+	}
+	x, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.binOp(level)
+		if op == "" {
+			return x, nil
+		}
+		t := p.next()
+		if op == "not in" {
+			p.next()
+		}
+		y, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryExpr{pos: pos(t.line), op: op, x: x, y: y}
+		if level == 3 && p.binOp(level) != "" {
+			// 비교 연산자는 결합하지 않는다(a < b < c 금지)
+			return nil, p.errorf(p.peek(), "comparison operators are not associative")
+		}
+	}
+}
+
+func (p *parser) unary() (expr, error) {
+	if p.isOp("-") || p.isOp("+") || p.isOp("~") {
+		t := p.next()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{pos: pos(t.line), op: t.text, x: x}, nil
+	}
+	return p.primary()
+}
+
+// primary: operand 뒤에 호출/인덱스/속성 접근
+func (p *parser) primary() (expr, error) {
+	x, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case p.isOp("("):
+			p.next()
+			if x, err = p.call(t, x); err != nil {
+				return nil, err
+			}
+		case p.isOp("["):
+			p.next()
+			if x, err = p.index(t, x); err != nil {
+				return nil, err
+			}
+		case p.isOp("."):
+			p.next()
+			name := p.next()
+			if name.kind != tName {
+				return nil, p.errorf(name, "expected attribute name, got %s", describe(name))
+			}
+			x = &dotExpr{pos: pos(t.line), x: x, name: name.text}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) call(t token, fn expr) (expr, error) {
+	c := &callExpr{pos: pos(t.line), fn: fn}
+	for !p.isOp(")") {
+		if p.isOp("*") || p.isOp("**") {
+			return nil, p.errorf(p.peek(), "*args and **kwargs arguments are not supported")
+		}
+		name := ""
+		if nt := p.peek(); nt.kind == tName && p.toks[p.i+1].kind == tOp && p.toks[p.i+1].text == "=" {
+			name = nt.text
+			p.next()
+			p.next()
+		} else if len(c.kwnames) > 0 && c.kwnames[len(c.kwnames)-1] != "" {
+			return nil, p.errorf(p.peek(), "positional argument follows keyword argument")
+		}
+		a, err := p.test()
+		if err != nil {
+			return nil, err
+		}
+		c.args = append(c.args, a)
+		c.kwnames = append(c.kwnames, name)
+		if !p.isOp(",") {
+			break
+		}
+		p.next()
+	}
+	return c, p.expectOp(")")
+}
+
+func (p *parser) index(t token, x expr) (expr, error) {
+	var lo, hi, step expr
+	var err error
+	if !p.isOp(":") {
+		if lo, err = p.test(); err != nil {
+			return nil, err
+		}
+		if !p.isOp(":") {
+			return &indexExpr{pos: pos(t.line), x: x, idx: lo}, p.expectOp("]")
+		}
+	}
+	p.next()
+	if !p.isOp("]") && !p.isOp(":") {
+		if hi, err = p.test(); err != nil {
+			return nil, err
+		}
+	}
+	if p.isOp(":") {
+		p.next()
+		if !p.isOp("]") {
+			if step, err = p.test(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &sliceExpr{pos: pos(t.line), x: x, lo: lo, hi: hi, step: step}, p.expectOp("]")
+}
+
+func (p *parser) operand() (expr, error) {
+	t := p.next()
+	at := pos(t.line)
+	switch t.kind {
+	case tInt, tFloat:
+		return &litExpr{pos: at, v: t.val}, nil
+	case tString:
+		s := t.text
+		// 인접 문자열 리터럴은 이어 붙임
+		for p.peek().kind == tString {
+			s += p.next().text
+		}
+		return &litExpr{pos: at, v: s}, nil
+	case tName:
+		switch t.text {
+		case "None":
+			return &litExpr{pos: at, v: nil}, nil
+		case "True":
+			return &litExpr{pos: at, v: true}, nil
+		case "False":
+			return &litExpr{pos: at, v: false}, nil
+		}
+		if keywords[t.text] {
+			return nil, p.errorf(t, "unexpected keyword %q", t.text)
+		}
+		return &identExpr{pos: at, name: t.text}, nil
+	case tOp:
+		switch t.text {
+		case "(":
+			if p.isOp(")") {
+				p.next()
+				return &tupleExpr{pos: at}, nil
+			}
+			x, err := p.test()
+			if err != nil {
+				return nil, err
+			}
+			if p.isOp(")") {
+				p.next()
+				return x, nil
+			}
+			elems := []expr{x}
+			for p.isOp(",") {
+				p.next()
+				if p.isOp(")") {
+					break
+				}
+				y, err := p.test()
+				if err != nil {
+					return nil, err
+				}
+				elems = append(elems, y)
+			}
+			return &tupleExpr{pos: at, elems: elems}, p.expectOp(")")
+		case "[":
+			return p.listOrComp(at)
+		case "{":
+			return p.dictOrComp(at)
+		}
+	}
+	return nil, p.errorf(t, "unexpected %s", describe(t))
+}
+
+func (p *parser) listOrComp(at pos) (expr, error) {
+	l := &listExpr{pos: at}
+	for !p.isOp("]") {
+		x, err := p.test()
+		if err != nil {
+			return nil, err
+		}
+		if len(l.elems) == 0 && p.isKw("for") {
+			c := &compExpr{pos: at, body: x}
+			if c.clauses, err = p.compClauses(); err != nil {
+				return nil, err
+			}
+			return c, p.expectOp("]")
+		}
+		l.elems = append(l.elems, x)
+		if !p.isOp(",") {
+			break
+		}
+		p.next()
+	}
+	return l, p.expectOp("]")
+}
+
+func (p *parser) dictOrComp(at pos) (expr, error) {
+	d := &dictExpr{pos: at}
+	for !p.isOp("}") {
+		k, err := p.test()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectOp(":"); err != nil {
+			return nil, err
+		}
+		v, err := p.test()
+		if err != nil {
+			return nil, err
+		}
+		if len(d.keys) == 0 && p.isKw("for") {
+			c := &compExpr{pos: at, dict: true, key: k, body: v}
+			if c.clauses, err = p.compClauses(); err != nil {
+				return nil, err
+			}
+			return c, p.expectOp("}")
+		}
+		d.keys = append(d.keys, k)
+		d.vals = append(d.vals, v)
+		if !p.isOp(",") {
+			break
+		}
+		p.next()
+	}
+	return d, p.expectOp("}")
+}
+
+// compClauses: ('for' targets 'in' or_test | 'if' or_test)+
+func (p *parser) compClauses() ([]compClause, error) {
+	var out []compClause
+	for {
+		switch {
+		case p.isKw("for"):
+			p.next()
+			vars, err := p.targets()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectKw("in"); err != nil {
+				return nil, err
+			}
+			iter, err := p.binary(0)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, compClause{vars: vars, iter: iter})
+		case p.isKw("if"):
+			p.next()
+			cond, err := p.binary(0)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, compClause{cond: cond})
+		default:
+			return out, nil
+		}
+	}
+}
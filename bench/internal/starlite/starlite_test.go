@@ -0,0 +1,150 @@
+package starlite
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// run executes src and returns repr(result).
+func run(t *testing.T, src string) (string, error) {
+	t.Helper()
+	p, err := Exec("test.star", []byte(src), nil)
+	if err != nil {
+		return "", err
+	}
+	v, ok := p.Global("result")
+	if !ok {
+		t.Fatalf("%q does not set result", src)
+	}
+	return Repr(v), nil
+}
+
+// TestConformance checks constructs against the result the reference
+// Starlark implementation (go.starlark.net) gives for the same source.
+func TestConformance(t *testing.T) {
+	for _, c := range []struct{ src, want string }{
+		// 정수/실수
+		{"result = 7 // 2, -7 // 2, 7 % -3, -7 % 3", "(3, -4, -2, 2)"},
+		{"result = 7 / 2, 6 / 3, 7.0 // 2, -7.5 % 2", "(3.5, 2.0, 3.0, 0.5)"},
+		{"result = 1 + 2 * 3 - 4, (1 + 2) * 3", "(3, 9)"},
+		{"result = 0x1f, 1e3, .5", "(31, 1000.0, 0.5)"},
+		{"result = 9223372036854775807, -9223372036854775807 - 1", "(9223372036854775807, -9223372036854775808)"},
+		{"result = int(3.9), int(-3.9), int('ff', 16), float(2)", "(3, -3, 255, 2.0)"},
+		// 비트 연산과 우선순위
+		{"result = 6 & 3, 6 | 3, 6 ^ 3, ~5, 1 << 4, -16 >> 2", "(2, 7, 5, -6, 16, -4)"},
+		{"result = 1 | 2 ^ 3 & 4, 1 + 2 << 3, 1 << 2 + 3", "(3, 24, 32)"},
+		{"result = 1 | 2 == 3", "True"},
+		{"x = 12\nx &= 10\nx |= 1\nx ^= 3\nx <<= 2\nx >>= 1\nresult = x", "20"},
+		// 비교/동등성
+		{"result = 1 == 1.0, 1 < 1.5, 'a' < 'b', [1, 2] < [1, 3], (1, 2) == (1, 2)", "(True, True, True, True, True)"},
+		{"result = True == 1, None == None, [1] == [1.0]", "(False, True, True)"},
+		{"result = 2 in [1, 2], 'b' in 'abc', 'x' not in {'x': 1}", "(True, True, False)"},
+		// dict 키: 1과 1.0은 같은 키
+		{"result = {1: 'a'}[1.0]", `"a"`},
+		{"d = {1: 'a'}\nd[1.0] = 'b'\nresult = d", `{1: "b"}`},
+		{"d = {2.0: 'x'}\nresult = d.get(2), 2 in d, d.pop(2), len(d)", `("x", True, "x", 0)`},
+		{"result = {'a': 1, 'b': 2} == {'b': 2, 'a': 1}", "True"},
+		// 슬라이스(step 포함)
+		{"result = 'hello'[1:3], 'hello'[-3:], 'hello'[:-1], [1, 2, 3][5:]", `("el", "llo", "hell", [])`},
+		{"result = 'hello'[::2], 'hello'[::-1], 'hello'[3:0:-1], 'hello'[-1:-4:-2]", `("hlo", "olleh", "lle", "ol")`},
+		{"result = [0, 1, 2, 3, 4, 5][1::2], (0, 1, 2, 3)[::-2], [1, 2][10:0:-1]", "([1, 3, 5], (3, 1), [2])"},
+		{"result = [1, 2, 3][::9223372036854775807], [1, 2, 3][::-9223372036854775807-1]", "([1], [3])"},
+		// 문자열
+		{`result = "%s-%d-%r" % ("a", 3, "b"), "ab" * 2, "a,b".split(","), "x".upper()`, `("a-3-\"b\"", "abab", ["a", "b"], "X")`},
+		// 제어 흐름, 함수
+		{"def f(a, b=2):\n  return a * b\nresult = f(3), f(3, b=4), f(b=1, a=5)", "(6, 12, 5)"},
+		{"def f():\n  for i in range(10):\n    if i == 3:\n      return i\nresult = f()", "3"},
+		{"r = []\nfor i in range(5):\n  if i % 2:\n    continue\n  if i > 3:\n    break\n  r.append(i)\nresult = r", "[0, 2]"},
+		{"result = 'y' if 0 else 'n', 0 or 'a', 1 and 2, not []", `("n", "a", 2, True)`},
+		{"a, (b, c) = 1, (2, 3)\nresult = a + b + c", "6"},
+		// 컴프리헨션
+		{"result = [x * x for x in range(4) if x]", "[1, 4, 9]"},
+		{"result = {k: v for k, v in [('a', 1), ('b', 2)]}", `{"a": 1, "b": 2}`},
+		{"result = [(x, y) for x in range(2) for y in ['a', 'b']]", `[(0, "a"), (0, "b"), (1, "a"), (1, "b")]`},
+		// 내장 함수
+		{"result = len('abc'), sorted([3, 1, 2]), max(1, 5, 3), min([4, 2]), list(range(1, 7, 2))", "(3, [1, 2, 3], 5, 2, [1, 3, 5])"},
+		{"result = dict([('a', 1)], b=2), list(enumerate(['a', 'b'])), type(1.0), str(None), bool('')", `({"a": 1, "b": 2}, [(0, "a"), (1, "b")], "float", "None", False)`},
+		{`result = json.encode({"a": [1, 2.5, None, True]}), json.decode('{"x": [1, "y"]}')`, `("{\"a\":[1,2.5,null,true]}", {"x": [1, "y"]})`},
+	} {
+		got, err := run(t, c.src)
+		if err != nil {
+			t.Errorf("%q: %v", c.src, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%q\n got %s\nwant %s", c.src, got, c.want)
+		}
+	}
+}
+
+// TestErrors covers runtime errors Starlark also raises and the
+// constructs this subset rejects instead of misinterpreting.
+func TestErrors(t *testing.T) {
+	for _, c := range []struct{ src, want string }{
+		// 64비트 정수: 넘치면 감싸지 않고 오류
+		{"result = 9223372036854775807 + 1", "integer overflow"},
+		{"result = -9223372036854775807 - 2", "integer overflow"},
+		{"result = 3037000500 * 3037000500", "integer overflow"},
+		{"x = -9223372036854775807 - 1\nresult = -x", "integer overflow"},
+		{"x = -9223372036854775807 - 1\nresult = x // -1", "integer overflow"},
+		{"result = 1 << 63", "integer overflow"},
+		{"result = 1 << -1", "negative shift count"},
+		{"x = 1\nx *= 9223372036854775807\nx *= 2\nresult = x", "integer overflow"},
+		{"result = int(1e19)", "cannot convert"},
+		{"result = 9223372036854775808", "invalid int literal"},
+		{"result = 1 // 0", "integer division by zero"},
+		{"result = 1.0 / 0", "division by zero"},
+		{"result = [1, 2][::0]", "slice step cannot be zero"},
+		{"result = 1 & 1.0", "unsupported operand types for &"},
+		{"result = ~1.5", "unsupported operand for unary ~"},
+		{"result = {}['x']", `key "x" not in dict`},
+		{"result = [1][1]", "index 1 out of range"},
+		{"result = 1 < 2 < 3", "comparison operators are not associative"},
+		{"def f():\n  return f()\nresult = f()", "called recursively"},
+		{"result = fail('boom')", "boom"},
+		{"result = [c for c in 'ab']", "string value is not iterable"},
+		// 지원하지 않는 구문은 명확한 구문 오류
+		{"def f(*args):\n  pass\nresult = 1", "*args and **kwargs parameters are not supported"},
+		{"def f(**kw):\n  pass\nresult = 1", "*args and **kwargs parameters are not supported"},
+		{"result = max(*[1, 2])", "*args and **kwargs arguments are not supported"},
+		{"result = dict(**{'a': 1})", "*args and **kwargs arguments are not supported"},
+		{"result = 2 ** 3", `unexpected "**"`},
+		{"while True:\n  pass", "while is not supported"},
+		{"result = lambda x: x", `unexpected keyword "lambda"`},
+		{"load('x.star', 'y')", "load is not supported"},
+	} {
+		_, err := run(t, c.src)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%q: got %v, want error containing %q", c.src, err, c.want)
+		}
+	}
+}
+
+func TestFrozenGlobals(t *testing.T) {
+	p, err := Exec("test.star", []byte("l = [1]\ndef f():\n  l.append(2)\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Call(p.Func("f")); err == nil || !strings.Contains(err.Error(), "frozen") {
+		t.Errorf("mutating a global after Exec: got %v, want frozen error", err)
+	}
+}
+
+// TestRepoScripts parses the request scripts shipped with the repo.
+func TestRepoScripts(t *testing.T) {
+	paths, _ := filepath.Glob("../../cmd/trace_bench/templates/*/*.star")
+	if len(paths) == 0 {
+		t.Fatal("no scripts found")
+	}
+	for _, p := range paths {
+		src, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parse(p, string(src)); err != nil {
+			t.Errorf("%s: %v", p, err)
+		}
+	}
+}
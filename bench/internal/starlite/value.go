@@ -0,0 +1,519 @@
+package starlite
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Values are represented by Go types: nil (None), bool, int64, float64,
+// string, Tuple, *List, *Dict, *Struct, *Function and *Builtin.
+
+// Tuple is an immutable sequence.
+type Tuple []any
+
+// List is a mutable sequence; it becomes read-only once frozen.
+type List struct {
+	elems  []any
+	frozen bool
+}
+
+// NewList returns a list holding elems.
+func NewList(elems ...any) *List { return &List{elems: elems} }
+
+// Elems returns the list elements (not a copy).
+func (l *List) Elems() []any { return l.elems }
+
+func (l *List) checkMutable() error {
+	if l.frozen {
+		return fmt.Errorf("cannot mutate frozen list")
+	}
+	return nil
+}
+
+// Dict is an insertion-ordered mapping with hashable keys (None, bool,
+// int, float, string). As in Starlark, an int and a float with the same
+// value are the same key.
+type Dict struct {
+	keys   []any
+	m      map[any]any // hashKey(k) → 값
+	frozen bool
+}
+
+// NewDict returns an empty dict.
+func NewDict() *Dict { return &Dict{m: map[any]any{}} }
+
+// Len returns the number of entries.
+func (d *Dict) Len() int { return len(d.keys) }
+
+// Keys returns the keys in insertion order (not a copy).
+func (d *Dict) Keys() []any { return d.keys }
+
+// Get returns the value for k.
+func (d *Dict) Get(k any) (any, bool) {
+	if !hashable(k) {
+		return nil, false
+	}
+	v, ok := d.m[hashKey(k)]
+	return v, ok
+}
+
+// at은 d.keys에 있는 키의 값
+func (d *Dict) at(k any) any { return d.m[hashKey(k)] }
+
+// Set inserts or replaces k.
+func (d *Dict) Set(k, v any) error {
+	if d.frozen {
+		return fmt.Errorf("cannot mutate frozen dict")
+	}
+	if !hashable(k) {
+		return fmt.Errorf("unhashable type: %s", Type(k))
+	}
+	hk := hashKey(k)
+	if _, ok := d.m[hk]; !ok {
+		d.keys = append(d.keys, k)
+	}
+	d.m[hk] = v
+	return nil
+}
+
+func (d *Dict) delete(k any) (any, bool, error) {
+	if d.frozen {
+		return nil, false, fmt.Errorf("cannot mutate frozen dict")
+	}
+	v, ok := d.Get(k)
+	if !ok {
+		return nil, false, nil
+	}
+	hk := hashKey(k)
+	delete(d.m, hk)
+	for i, kk := range d.keys {
+		if hashKey(kk) == hk {
+			d.keys = append(d.keys[:i:i], d.keys[i+1:]...)
+			break
+		}
+	}
+	return v, true, nil
+}
+
+// hashKey는 정수 값의 float를 int로 바꿔 1과 1.0이 같은 키가 되게 한다
+func hashKey(k any) any {
+	if f, ok := k.(float64); ok && f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+		return int64(f)
+	}
+	return k
+}
+
+func hashable(k any) bool {
+	switch k.(type) {
+	case nil, bool, int64, float64, string:
+		return true
+	}
+	return false
+}
+
+// Struct is an immutable record with named fields, e.g. the ctx/resp
+// values the host passes to script functions.
+type Struct struct {
+	names  []string
+	fields map[string]any
+}
+
+// NewStruct returns a struct with the given fields.
+func NewStruct(fields map[string]any) *Struct {
+	s := &Struct{fields: fields}
+	for n := range fields {
+		s.names = append(s.names, n)
+	}
+	sort.Strings(s.names)
+	return s
+}
+
+// Field returns the named field.
+func (s *Struct) Field(name string) (any, bool) {
+	v, ok := s.fields[name]
+	return v, ok
+}
+
+// Function is a function defined in a script.
+type Function struct {
+	name     string
+	params   []param
+	defaults []any
+	body     []stmt
+	prog     *Program
+}
+
+// Name returns the function name.
+func (f *Function) Name() string { return f.name }
+
+// Builtin is a function implemented in Go.
+type Builtin struct {
+	name string
+	fn   func(args []any, kwargs map[string]any) (any, error)
+}
+
+// NewBuiltin wraps fn as a script-callable function. fn receives the
+// positional arguments and the keyword arguments (nil if none).
+func NewBuiltin(name string, fn func(args []any, kwargs map[string]any) (any, error)) *Builtin {
+	return &Builtin{name: name, fn: fn}
+}
+
+// Type returns the Starlark type name of v.
+func Type(v any) string {
+	switch v.(type) {
+	case nil:
+		return "NoneType"
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case Tuple:
+		return "tuple"
+	case *List:
+		return "list"
+	case *Dict:
+		return "dict"
+	case *Struct:
+		return "struct"
+	case *Function, *Builtin:
+		return "function"
+	case rangeValue:
+		return "range"
+	}
+	return fmt.Sprintf("<%T>", v)
+}
+
+// Truth reports the truth value of v.
+func Truth(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case Tuple:
+		return len(v) > 0
+	case *List:
+		return len(v.elems) > 0
+	case *Dict:
+		return len(v.keys) > 0
+	case rangeValue:
+		return v.len() > 0
+	}
+	return true
+}
+
+// Str returns str(v): strings unquoted, everything else as Repr.
+func Str(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return Repr(v)
+}
+
+// Repr returns repr(v).
+func Repr(v any) string {
+	var b strings.Builder
+	writeRepr(&b, v, 0)
+	return b.String()
+}
+
+func writeRepr(b *strings.Builder, v any, depth int) {
+	if depth > 32 {
+		b.WriteString("...")
+		return
+	}
+	switch v := v.(type) {
+	case nil:
+		b.WriteString("None")
+	case bool:
+		if v {
+			b.WriteString("True")
+		} else {
+			b.WriteString("False")
+		}
+	case int64:
+		b.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		b.WriteString(formatFloat(v))
+	case string:
+		b.WriteString(strconv.Quote(v))
+	case Tuple:
+		b.WriteByte('(')
+		for i, e := range v {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeRepr(b, e, depth+1)
+		}
+		if len(v) == 1 {
+			b.WriteByte(',')
+		}
+		b.WriteByte(')')
+	case *List:
+		b.WriteByte('[')
+		for i, e := range v.elems {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeRepr(b, e, depth+1)
+		}
+		b.WriteByte(']')
+	case *Dict:
+		b.WriteByte('{')
+		for i, k := range v.keys {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeRepr(b, k, depth+1)
+			b.WriteString(": ")
+			writeRepr(b, v.at(k), depth+1)
+		}
+		b.WriteByte('}')
+	case *Struct:
+		b.WriteString("struct(")
+		for i, n := range v.names {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(n)
+			b.WriteString(" = ")
+			writeRepr(b, v.fields[n], depth+1)
+		}
+		b.WriteByte(')')
+	case *Function:
+		fmt.Fprintf(b, "<function %s>", v.name)
+	case *Builtin:
+		fmt.Fprintf(b, "<built-in function %s>", v.name)
+	case rangeValue:
+		if v.step == 1 {
+			fmt.Fprintf(b, "range(%d, %d)", v.start, v.stop)
+		} else {
+			fmt.Fprintf(b, "range(%d, %d, %d)", v.start, v.stop, v.step)
+		}
+	default:
+		fmt.Fprintf(b, "%v", v)
+	}
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// rangeValue는 range(): 원소를 만들지 않고 순회/인덱싱
+type rangeValue struct{ start, stop, step int64 }
+
+func (r rangeValue) len() int64 {
+	switch {
+	case r.step > 0 && r.start < r.stop:
+		return (r.stop - r.start + r.step - 1) / r.step
+	case r.step < 0 && r.start > r.stop:
+		return (r.start - r.stop - r.step - 1) / -r.step
+	}
+	return 0
+}
+
+// freeze는 v와 그 안의 list/dict를 읽기 전용으로 만든다
+func freeze(v any) {
+	switch v := v.(type) {
+	case Tuple:
+		for _, e := range v {
+			freeze(e)
+		}
+	case *List:
+		if v.frozen {
+			return
+		}
+		v.frozen = true
+		for _, e := range v.elems {
+			freeze(e)
+		}
+	case *Dict:
+		if v.frozen {
+			return
+		}
+		v.frozen = true
+		for _, k := range v.keys {
+			freeze(v.at(k))
+		}
+	case *Struct:
+		for _, f := range v.fields {
+			freeze(f)
+		}
+	case *Function:
+		for _, d := range v.defaults {
+			freeze(d)
+		}
+	}
+}
+
+// iterate는 list/tuple/dict(키)/range 원소마다 f를 부른다(f가 false면 중단)
+func iterate(v any, f func(any) (bool, error)) error {
+	var elems []any
+	switch v := v.(type) {
+	case Tuple:
+		elems = v
+	case *List:
+		elems = v.elems
+	case *Dict:
+		elems = v.keys
+	case rangeValue:
+		n := v.len()
+		for i := int64(0); i < n; i++ {
+			if ok, err := f(v.start + i*v.step); !ok || err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s value is not iterable", Type(v))
+	}
+	// 순회 중 변경에 대비해 스냅샷
+	for _, e := range append([]any(nil), elems...) {
+		if ok, err := f(e); !ok || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// equal은 구조적 동등성(int와 float는 수치 비교)
+func equal(x, y any) bool {
+	switch x := x.(type) {
+	case int64:
+		switch y := y.(type) {
+		case int64:
+			return x == y
+		case float64:
+			return float64(x) == y
+		}
+		return false
+	case float64:
+		switch y := y.(type) {
+		case int64:
+			return x == float64(y)
+		case float64:
+			return x == y
+		}
+		return false
+	case Tuple:
+		yt, ok := y.(Tuple)
+		return ok && equalSeq(x, yt)
+	case *List:
+		yl, ok := y.(*List)
+		return ok && equalSeq(x.elems, yl.elems)
+	case *Dict:
+		yd, ok := y.(*Dict)
+		if !ok || len(x.keys) != len(yd.keys) {
+			return false
+		}
+		for _, k := range x.keys {
+			yv, ok := yd.Get(k)
+			if !ok || !equal(x.at(k), yv) {
+				return false
+			}
+		}
+		return true
+	case rangeValue:
+		return x == y
+	case nil, bool, string:
+		return x == y
+	}
+	return x == y
+}
+
+func equalSeq(x, y []any) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if !equal(x[i], y[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// compare는 순서 비교(-1/0/1). 수, 문자열, 같은 종류의 시퀀스만 허용
+func compare(x, y any) (int, error) {
+	if xf, ok := toFloat(x); ok {
+		if yf, ok := toFloat(y); ok {
+			if xi, ok := x.(int64); ok {
+				if yi, ok := y.(int64); ok {
+					return cmp3(xi < yi, xi > yi), nil
+				}
+			}
+			return cmp3(xf < yf, xf > yf), nil
+		}
+	}
+	switch x := x.(type) {
+	case string:
+		if y, ok := y.(string); ok {
+			return strings.Compare(x, y), nil
+		}
+	case Tuple:
+		if y, ok := y.(Tuple); ok {
+			return compareSeq(x, y)
+		}
+	case *List:
+		if y, ok := y.(*List); ok {
+			return compareSeq(x.elems, y.elems)
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %s and %s", Type(x), Type(y))
+}
+
+func compareSeq(x, y []any) (int, error) {
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if equal(x[i], y[i]) {
+			continue
+		}
+		return compare(x[i], y[i])
+	}
+	return cmp3(len(x) < len(y), len(x) > len(y)), nil
+}
+
+func cmp3(lt, gt bool) int {
+	switch {
+	case lt:
+		return -1
+	case gt:
+		return 1
+	}
+	return 0
+}
+
+// toFloat은 int/float를 float64로 (bool은 수가 아님)
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,413 @@
+// Package yamlite parses the YAML subset used by the bench config files:
+// block mappings and sequences, plain/quoted scalars, flow lists of
+// scalars, literal (|) and folded (>) blocks, and comments. Anchors,
+// aliases, merge keys, tags and multi-document streams are rejected with an
+// error rather than read as plain strings.
+package yamlite
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Strict makes Unmarshal and UnmarshalJSON reject keys that match no
+// struct field, so a misspelt key fails instead of silently keeping its
+// default. It is set once at startup (trace_bench -strict).
+var Strict bool
+
+// Unmarshal parses data and decodes it into v using encoding/json rules
+// (json struct tags apply).
+func Unmarshal(data []byte, v any) error {
+	node, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	return UnmarshalJSON(b, v)
+}
+
+// UnmarshalJSON decodes a JSON config file like json.Unmarshal, honoring
+// Strict.
+func UnmarshalJSON(data []byte, v any) error {
+	if !Strict {
+		return json.Unmarshal(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		// encoding/json 메시지는 `json: unknown field "x"`
+		if k, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return fmt.Errorf("unknown key %s (strict mode)", k)
+		}
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("unexpected data after the top-level value")
+	}
+	return nil
+}
+
+// Parse returns map[string]any, []any or scalar values (string, int64,
+// float64, bool, nil).
+func Parse(data []byte) (any, error) {
+	p := &parser{}
+	for i, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		p.all = append(p.all, line{no: i + 1, raw: raw})
+	}
+	p.lines = p.significant(0, len(p.all))
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+	if strings.HasPrefix(p.lines[0].text, "---") {
+		p.lines = p.lines[1:]
+	}
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+	n, err := p.node(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if isDocMarker(l.text) {
+			return nil, fmt.Errorf("yaml: line %d: multi-document streams are not supported", l.no)
+		}
+		return nil, fmt.Errorf("yaml: line %d: unexpected indentation", l.no)
+	}
+	return n, nil
+}
+
+type line struct {
+	no     int
+	raw    string
+	indent int
+	text   string // 주석/들여쓰기 제거
+	idx    int    // all에서의 위치(블록 스칼라용)
+}
+
+type parser struct {
+	all   []line
+	lines []line
+	pos   int
+}
+
+func (p *parser) significant(from, to int) []line {
+	var out []line
+	for i := from; i < to; i++ {
+		l := p.all[i]
+		trimmed := strings.TrimLeft(l.raw, " ")
+		text := strings.TrimRight(stripComment(trimmed), " \t")
+		if text == "" {
+			continue
+		}
+		l.indent, l.text, l.idx = len(l.raw)-len(trimmed), text, i
+		out = append(out, l)
+	}
+	return out
+}
+
+// 따옴표 밖의 " #" 또는 줄 첫 "#"부터 주석
+func stripComment(s string) string {
+	var q byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case q != 0:
+			if c == q {
+				q = 0
+			}
+		case c == '"' || c == '\'':
+			q = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func isSeqItem(text string) bool { return text == "-" || strings.HasPrefix(text, "- ") }
+
+func isDocMarker(text string) bool {
+	return text == "---" || text == "..." || strings.HasPrefix(text, "--- ")
+}
+
+// unsupported는 앵커·별칭·태그로 시작하는 값/키의 오류(아니면 nil)
+func unsupported(s string, no int) error {
+	if s == "" {
+		return nil
+	}
+	word, _, _ := strings.Cut(s, " ")
+	switch s[0] {
+	case '&':
+		return fmt.Errorf("yaml: line %d: anchors (%s) are not supported", no, word)
+	case '*':
+		return fmt.Errorf("yaml: line %d: aliases (%s) are not supported", no, word)
+	case '!':
+		return fmt.Errorf("yaml: line %d: tags (%s) are not supported", no, word)
+	case '@', '`':
+		return fmt.Errorf("yaml: line %d: reserved indicator %q cannot start a plain scalar", no, s[0])
+	}
+	return nil
+}
+
+func (p *parser) node(indent int) (any, error) {
+	if isSeqItem(p.lines[p.pos].text) {
+		return p.seq(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *parser) seq(indent int) (any, error) {
+	out := []any{}
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent != indent || !isSeqItem(l.text) {
+			break
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(l.text, "-"), " ")
+		if rest == "" {
+			p.pos++
+			v, err := p.child(indent)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+			continue
+		}
+		if _, _, ok := splitKey(rest); ok || isSeqItem(rest) {
+			// "- key: v" → 항목 본문을 한 단계 깊은 줄로 다시 해석
+			inner := indent + len(l.text) - len(rest)
+			p.lines[p.pos] = line{no: l.no, raw: l.raw, indent: inner, text: rest, idx: l.idx}
+			v, err := p.node(inner)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+			continue
+		}
+		v, err := scalarOrFlow(rest, l.no)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+		p.pos++
+	}
+	return out, nil
+}
+
+func (p *parser) mapping(indent int) (any, error) {
+	out := map[string]any{}
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent != indent || isSeqItem(l.text) {
+			if l.indent > indent {
+				return nil, fmt.Errorf("yaml: line %d: unexpected indentation", l.no)
+			}
+			break
+		}
+		key, rest, ok := splitKey(l.text)
+		if !ok {
+			if isDocMarker(l.text) {
+				return nil, fmt.Errorf("yaml: line %d: multi-document streams are not supported", l.no)
+			}
+			return nil, fmt.Errorf("yaml: line %d: expected key: value", l.no)
+		}
+		if key == "<<" {
+			return nil, fmt.Errorf("yaml: line %d: merge keys (<<) are not supported", l.no)
+		}
+		if !strings.HasPrefix(l.text, `"`) && !strings.HasPrefix(l.text, "'") {
+			if err := unsupported(key, l.no); err != nil {
+				return nil, err
+			}
+		}
+		if _, dup := out[key]; dup {
+			return nil, fmt.Errorf("yaml: line %d: duplicate key %q", l.no, key)
+		}
+		p.pos++
+		switch {
+		case rest == "":
+			v, err := p.child(indent)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = v
+		case rest == "|" || rest == ">" || rest == "|-" || rest == ">-":
+			out[key] = p.block(l, indent, rest)
+		default:
+			v, err := scalarOrFlow(rest, l.no)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = v
+		}
+	}
+	return out, nil
+}
+
+// child는 "key:" 또는 "-" 다음 줄의 하위 노드(없으면 nil)
+func (p *parser) child(indent int) (any, error) {
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+	next := p.lines[p.pos]
+	if next.indent > indent || (next.indent == indent && isSeqItem(next.text)) {
+		return p.node(next.indent)
+	}
+	return nil, nil
+}
+
+// block은 |, > 블록 스칼라(원본 줄을 그대로 사용)
+func (p *parser) block(head line, indent int, style string) string {
+	var body []string
+	blockIndent := -1
+	i := head.idx + 1
+	for ; i < len(p.all); i++ {
+		raw := p.all[i].raw
+		trimmed := strings.TrimLeft(raw, " ")
+		if trimmed == "" {
+			body = append(body, "")
+			continue
+		}
+		ind := len(raw) - len(trimmed)
+		if ind <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = ind
+		}
+		body = append(body, raw[min(blockIndent, ind):])
+	}
+	for len(body) > 0 && body[len(body)-1] == "" {
+		body = body[:len(body)-1]
+	}
+	// 블록이 차지한 줄은 건너뜀
+	for p.pos < len(p.lines) && p.lines[p.pos].idx < i {
+		p.pos++
+	}
+	sep := "\n"
+	if style[0] == '>' {
+		sep = " "
+	}
+	s := strings.Join(body, sep)
+	if !strings.HasSuffix(style, "-") {
+		s += "\n"
+	}
+	return s
+}
+
+func splitKey(text string) (key, rest string, ok bool) {
+	if text[0] == '"' || text[0] == '\'' {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 {
+			return "", "", false
+		}
+		key, text = text[1:end+1], text[end+2:]
+		if !strings.HasPrefix(text, ":") {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(text[1:]), true
+	}
+	i := strings.Index(text, ": ")
+	if i < 0 {
+		if strings.HasSuffix(text, ":") {
+			return text[:len(text)-1], "", true
+		}
+		return "", "", false
+	}
+	if strings.ContainsAny(text[:i], "\"'[{") {
+		return "", "", false
+	}
+	return text[:i], strings.TrimSpace(text[i+2:]), true
+}
+
+func scalarOrFlow(s string, no int) (any, error) {
+	switch {
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("yaml: line %d: unterminated flow sequence", no)
+		}
+		out := []any{}
+		inner := strings.TrimSpace(s[1 : len(s)-1])
+		if inner == "" {
+			return out, nil
+		}
+		for _, item := range splitFlow(inner) {
+			v, err := scalar(strings.TrimSpace(item), no)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case s == "{}":
+		return map[string]any{}, nil
+	case strings.HasPrefix(s, "{"):
+		return nil, fmt.Errorf("yaml: line %d: flow mappings are not supported", no)
+	}
+	return scalar(s, no)
+}
+
+// 따옴표 안의 쉼표는 구분자가 아님("..." 안의 \" 이스케이프 포함)
+func splitFlow(s string) []string {
+	var out []string
+	var q byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case q == '"' && c == '\\':
+			i++
+		case q != 0:
+			if c == q {
+				q = 0
+			}
+		case c == '"' || c == '\'':
+			q = c
+		case c == ',':
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, s[start:])
+}
+
+func scalar(s string, no int) (any, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("yaml: line %d: invalid quoted string %s", no, s)
+		}
+		return v, nil
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("yaml: line %d: invalid quoted string %s", no, s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	if err := unsupported(s, no); err != nil {
+		return nil, err
+	}
+	switch s {
+	case "null", "~", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strings.IndexAny(s[:1], "+-.0123456789") == 0 && !strings.ContainsAny(s, "xXpPnN_") {
+		return f, nil
+	}
+	return s, nil
+}
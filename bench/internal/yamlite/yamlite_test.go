@@ -0,0 +1,121 @@
+package yamlite
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, c := range []struct {
+		name, in string
+		want     any
+	}{
+		{"empty", "", nil},
+		{"comments only", "# a\n  # b\n", nil},
+		{"document start", "---\na: 1\n", map[string]any{"a": int64(1)}},
+		{"scalars", "i: 42\nf: 0.5\nneg: -3\nt: true\nn: null\ntilde: ~\ns: hello world\nv: 1.2.3\nhex: 0x10\n",
+			map[string]any{"i": int64(42), "f": 0.5, "neg": int64(-3), "t": true, "n": nil, "tilde": nil,
+				"s": "hello world", "v": "1.2.3", "hex": "0x10"}},
+		{"quoted", `a: "x: \"y\" # z"` + "\nb: 'it''s'\n\"c d\": 1\n",
+			map[string]any{"a": `x: "y" # z`, "b": "it's", "c d": int64(1)}},
+		{"comment after value", "a: 1 # one\nb: a#b\n", map[string]any{"a": int64(1), "b": "a#b"}},
+		{"nested mapping", "a:\n  b:\n    c: 1\n  d: 2\ne: 3\n",
+			map[string]any{"a": map[string]any{"b": map[string]any{"c": int64(1)}, "d": int64(2)}, "e": int64(3)}},
+		{"empty value", "a:\nb: 1\n", map[string]any{"a": nil, "b": int64(1)}},
+		{"sequence", "- 1\n- two\n-\n  - 3\n", []any{int64(1), "two", []any{int64(3)}}},
+		{"sequence of mappings", "items:\n  - name: a\n    n: 1\n  - name: b\n",
+			map[string]any{"items": []any{map[string]any{"name": "a", "n": int64(1)}, map[string]any{"name": "b"}}}},
+		{"unindented sequence", "a:\n- 1\n- 2\nb: 3\n", map[string]any{"a": []any{int64(1), int64(2)}, "b": int64(3)}},
+		{"flow", `a: [1, "x, y", 'z', true]` + "\nb: []\nc: {}\n",
+			map[string]any{"a": []any{int64(1), "x, y", "z", true}, "b": []any{}, "c": map[string]any{}}},
+		{"literal block", "a: |\n  one\n    two\n\n  three\nb: 1\n",
+			map[string]any{"a": "one\n  two\n\nthree\n", "b": int64(1)}},
+		{"folded block strip", "a: >-\n  one\n  two\n", map[string]any{"a": "one two"}},
+		{"crlf", "a: 1\r\nb: 2\r\n", map[string]any{"a": int64(1), "b": int64(2)}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Parse([]byte(c.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got %#v\nwant %#v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, c := range []struct{ in, want string }{
+		{"a: 1\na: 2\n", `line 2: duplicate key "a"`},
+		{"a: 1\n  b: 2\n", "line 2: unexpected indentation"},
+		{"just text\n", "line 1: expected key: value"},
+		{"a: [1, 2\n", "line 1: unterminated flow sequence"},
+		{"a: {b: 1}\n", "line 1: flow mappings are not supported"},
+		{`a: "open` + "\n", "line 1: invalid quoted string"},
+		{"a: &x 1\nb: *x\n", "line 1: anchors (&x) are not supported"},
+		{"a: 1\nb: *x\n", "line 2: aliases (*x) are not supported"},
+		{"a: &base\n  x: 1\n", "line 1: anchors (&base) are not supported"},
+		{"- &x 1\n", "line 1: anchors (&x) are not supported"},
+		{"a: [1, *x]\n", "line 1: aliases (*x) are not supported"},
+		{"&x a: 1\n", "line 1: anchors (&x) are not supported"},
+		{"a:\n  <<: *base\n", "line 2: merge keys (<<) are not supported"},
+		{"a: !!str 1\n", "line 1: tags (!!str) are not supported"},
+		{"a: !Ref b\n", "line 1: tags (!Ref) are not supported"},
+		{"a: @x\n", "line 1: reserved indicator '@'"},
+		{"a: 1\n---\nb: 2\n", "line 2: multi-document streams are not supported"},
+		{"- 1\n---\n- 2\n", "line 2: multi-document streams are not supported"},
+	} {
+		if _, err := Parse([]byte(c.in)); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("Parse(%q) = %v, want error containing %q", c.in, err, c.want)
+		}
+	}
+	// 따옴표 안의 &, *, !는 평범한 문자열
+	got, err := Parse([]byte(`a: "&x"` + "\nb: '*x'\nc: a&b\n"))
+	if want := map[string]any{"a": "&x", "b": "*x", "c": "a&b"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("quoted indicators: got %v, %v", got, err)
+	}
+}
+
+// TestRepoFiles parses every config the bench tools ship or read in this
+// repo; the compose files that use unsupported YAML must fail loudly.
+func TestRepoFiles(t *testing.T) {
+	unsupported := map[string]string{
+		"docker-compose.optimized.yml": "anchors (&common-volumes) are not supported",
+		"compose.health.overlay.yml":   "flow mappings are not supported",
+	}
+	var paths []string
+	for _, pattern := range []string{
+		"../../*.yaml",
+		"../../cmd/trace_bench/templates/*/*.yaml",
+		"../../tools/cmd/*/scenarios/*.yaml",
+		"../../../*compose*.yml",
+		"../../../docker/*compose*.yml",
+	} {
+		m, _ := filepath.Glob(pattern)
+		paths = append(paths, m...)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no config files found")
+	}
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 템플릿은 init이 {{.Dir}}을 치환한 뒤 읽는다
+		_, err = Parse([]byte(strings.ReplaceAll(string(b), "{{.Dir}}", ".")))
+		if want, ok := unsupported[filepath.Base(p)]; ok {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: got %v, want error containing %q", p, err, want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package logschema holds the trace_bench log record schema and validates
+// records against it, so -self-check catches log ABI drift in the tool
+// itself.
+//
+// Only the JSON Schema subset the schema uses is supported: type, required,
+// properties, enum, minLength, additionalProperties (bool) and
+// format: date-time.
+package logschema
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Schema is trace_bench_log.schema.json (draft-07).
+//
+//go:embed trace_bench_log.schema.json
+var Schema []byte
+
+type node struct {
+	Type                 string           `json:"type"`
+	Format               string           `json:"format"`
+	Required             []string         `json:"required"`
+	Properties           map[string]*node `json:"properties"`
+	Enum                 []any            `json:"enum"`
+	MinLength            *int             `json:"minLength"`
+	AdditionalProperties *bool            `json:"additionalProperties"`
+}
+
+var root = mustParse(Schema)
+
+func mustParse(b []byte) *node {
+	var n node
+	if err := json.Unmarshal(b, &n); err != nil {
+		panic(fmt.Sprintf("logschema: %v", err))
+	}
+	return &n
+}
+
+// Events returns the event names (msg enum) declared by the schema.
+func Events() []string {
+	var out []string
+	if p := root.Properties["msg"]; p != nil {
+		for _, v := range p.Enum {
+			if s, ok := v.(string); ok {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// Validate checks one JSON log record against the schema.
+func Validate(record []byte) error {
+	var v any
+	if err := json.Unmarshal(record, &v); err != nil {
+		return fmt.Errorf("log record is not json: %w", err)
+	}
+	return root.validate("$", v)
+}
+
+func (n *node) validate(path string, v any) error {
+	switch n.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: want object", path)
+		}
+		for _, k := range n.Required {
+			if _, ok := obj[k]; !ok {
+				return fmt.Errorf("%s: missing required %q", path, k)
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p, ok := n.Properties[k]
+			if !ok {
+				if n.AdditionalProperties != nil && !*n.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected property %q", path, k)
+				}
+				continue
+			}
+			if err := p.validate(path+"."+k, obj[k]); err != nil {
+				return err
+			}
+		}
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s: want string", path)
+		}
+		if n.MinLength != nil && len(s) < *n.MinLength {
+			return fmt.Errorf("%s: shorter than %d", path, *n.MinLength)
+		}
+		if n.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				return fmt.Errorf("%s: not a date-time: %q", path, s)
+			}
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return fmt.Errorf("%s: want number", path)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: want boolean", path)
+		}
+	}
+	if len(n.Enum) > 0 {
+		for _, e := range n.Enum {
+			if e == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %v is not one of the allowed values", path, v)
+	}
+	return nil
+}
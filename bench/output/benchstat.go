@@ -0,0 +1,54 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/duri/trace_bench/engine"
+)
+
+// WriteBenchstat renders r as Go benchmark text so runs can be compared with
+// benchstat. Sub-benchmark names use key=value parts, which benchstat treats
+// as configuration keys (e.g. `benchstat -col /comp old.txt new.txt`).
+func WriteBenchstat(w io.Writer, cfg engine.Config, r engine.Result) error {
+	line := fmt.Sprintf("%s\t1\t%s p95-ms\t%s errors/op\t%s KB/op",
+		BenchstatName(cfg), fmtFloat(r.P95ms), fmtFloat(r.ErrorRate), fmtFloat(r.SizeKB))
+	if r.Mem != nil {
+		// benchstat이 인식하는 표준 단위
+		line += fmt.Sprintf("\t%s B/op\t%s allocs/op\t%s gc-pause-ms", fmtFloat(r.Mem.BytesPerOp), fmtFloat(r.Mem.AllocsPerOp), fmtFloat(r.Mem.GCPauseMs))
+	}
+	hdr := fmt.Sprintf("goos: %s\ngoarch: %s\npkg: github.com/duri/trace_bench\n", runtime.GOOS, runtime.GOARCH)
+	if r.RunID != "" {
+		// "key: value" 줄은 benchstat의 file config라 run마다 다른 run_id가 그룹을
+		// 쪼갠다. 소문자로 시작하지 않는 줄은 benchfmt가 무시하므로 주석으로 남긴다
+		hdr += "# run_id: " + r.RunID + "\n"
+	}
+	_, err := fmt.Fprintf(w, "%s%s\n", hdr, line)
+	return err
+}
+
+// BenchstatName returns the benchmark name for cfg, e.g.
+// BenchmarkTrace/ser=json/comp=gzip/sampling=0.5-8. A batch processor run
+// adds /batch=<size>/timeout=<dur> (and /backpressure=block), the same
+// keys ConfigLabels splits series by, so batch sweeps stay separate rows.
+func BenchstatName(cfg engine.Config) string {
+	extra := ""
+	if cfg.Protocol != "" {
+		extra = "/proto=" + cfg.Protocol
+	}
+	l := ConfigLabels(cfg)
+	if size, ok := l["batch_size"]; ok {
+		extra += "/batch=" + size + "/timeout=" + l["batch_timeout"]
+		if bp, ok := l["backpressure"]; ok {
+			extra += "/backpressure=" + bp
+		}
+	}
+	return fmt.Sprintf("BenchmarkTrace/ser=%s/comp=%s/sampling=%s%s-%d",
+		strings.ToLower(cfg.Serialization), strings.ToLower(cfg.Compression),
+		fmtFloat(cfg.Sampling), extra, runtime.GOMAXPROCS(0))
+}
+
+func fmtFloat(x float64) string { return strconv.FormatFloat(x, 'f', -1, 64) }
@@ -0,0 +1,65 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+
+	"github.com/duri/trace_bench/engine"
+)
+
+// MetricDiff is one metric compared between a baseline and a run.
+type MetricDiff struct {
+	Metric   string   `json:"metric"`
+	Base     float64  `json:"base"`
+	Current  float64  `json:"current"`
+	Delta    float64  `json:"delta"`
+	DeltaPct *float64 `json:"delta_pct,omitempty"` // base가 0이면 생략
+}
+
+// Diff compares the scalar metrics (engine.Result.Metrics) present in
+// either result, sorted by name.
+func Diff(base, cur engine.Result) []MetricDiff {
+	return diffMaps(base.Metrics(), cur.Metrics())
+}
+
+// diffMaps는 두 지표 맵의 합집합을 이름순으로 비교한다
+func diffMaps(bm, cm map[string]float64) []MetricDiff {
+	var out []MetricDiff
+	for _, k := range sortedKeys(union(bm, cm)) {
+		// 부동소수 잡음 제거(1e-6 단위)
+		d := MetricDiff{Metric: k, Base: bm[k], Current: cm[k], Delta: math.Round((cm[k]-bm[k])*1e6) / 1e6}
+		if bm[k] != 0 {
+			pct := math.Round(d.Delta/bm[k]*10000) / 100
+			d.DeltaPct = &pct
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+func union(a, b map[string]float64) map[string]float64 {
+	m := make(map[string]float64, len(a)+len(b))
+	for k := range a {
+		m[k] = 0
+	}
+	for k := range b {
+		m[k] = 0
+	}
+	return m
+}
+
+// WriteDiff prints ds as a table headed by the two labels.
+func WriteDiff(w io.Writer, baseLabel, curLabel string, ds []MetricDiff) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "\t%s\t%s\tdelta\tdelta_pct\t\n", baseLabel, curLabel)
+	for _, d := range ds {
+		pct := "-"
+		if d.DeltaPct != nil {
+			pct = fmt.Sprintf("%+.2f%%", *d.DeltaPct)
+		}
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%+.6g\t%s\t\n", d.Metric, d.Base, d.Current, d.Delta, pct)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,36 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// WriteHeatmapCSV writes h as CSV with a time column followed by one column
+// per bucket upper bound ("le"), the layout Grafana's heatmap panel expects
+// for pre-bucketed data.
+func WriteHeatmapCSV(w io.Writer, h *stats.Heatmap) error {
+	cw := csv.NewWriter(w)
+	header := []string{"time"}
+	for _, b := range h.Bounds {
+		header = append(header, strconv.FormatFloat(b, 'f', -1, 64))
+	}
+	header = append(header, "+Inf")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range h.Rows() {
+		rec := []string{r.Time.Format(time.RFC3339Nano)}
+		for _, c := range r.Counts {
+			rec = append(rec, strconv.FormatUint(c, 10))
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,95 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+)
+
+// InfluxMeasurement is the measurement name used in line protocol output.
+const InfluxMeasurement = "trace_bench"
+
+// WriteInflux renders results as InfluxDB line protocol: config and user
+// labels as tags,
+// ABI metrics as fields, one line per result, timestamp in nanoseconds.
+func WriteInflux(w io.Writer, cfgs []engine.Config, rs []engine.Result) error {
+	ts := Now().UnixNano()
+	var b strings.Builder
+	for i, r := range rs {
+		b.WriteString(InfluxMeasurement)
+		tags := RunLabels(cfgs[i], r)
+		keys := sortedKeys(tags)
+		for _, k := range keys {
+			fmt.Fprintf(&b, ",%s=%s", influxEscape(k), influxEscape(tags[k]))
+		}
+		m := r.Metrics()
+		if r.Mem != nil {
+			m["allocs_per_op"], m["bytes_per_op"] = r.Mem.AllocsPerOp, r.Mem.BytesPerOp
+		}
+		for j, k := range sortedKeys(m) {
+			sep := ","
+			if j == 0 {
+				sep = " "
+			}
+			fmt.Fprintf(&b, "%s%s=%s", sep, influxEscape(k), strconv.FormatFloat(m[k], 'g', -1, 64))
+		}
+		if r.RunID != "" {
+			// tag가 아닌 string field: series 카디널리티에 영향 없음
+			fmt.Fprintf(&b, ",run_id=%s", strconv.Quote(r.RunID))
+		}
+		fmt.Fprintf(&b, " %d\n", ts)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// InfluxWrite posts line protocol to an InfluxDB write endpoint, e.g.
+// http://influx:8086/api/v2/write?org=lab&bucket=bench (v2, token auth) or
+// http://influx:8086/write?db=bench (v1). token may be empty.
+func InfluxWrite(ctx context.Context, url, token string, cfgs []engine.Config, rs []engine.Result) error {
+	var body bytes.Buffer
+	if err := WriteInflux(&body, cfgs, rs); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+	c := &http.Client{Timeout: 30 * time.Second}
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("influx: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("influx: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// 태그 키/값과 필드 키의 쉼표, 등호, 공백 이스케이프
+var influxEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+func influxEscape(s string) string { return influxEscaper.Replace(s) }
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,132 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/duri/trace_bench/engine"
+)
+
+// ConfigLabels returns the labels identifying cfg in exported series.
+func ConfigLabels(cfg engine.Config) map[string]string {
+	l := map[string]string{
+		"serialization": strings.ToLower(cfg.Serialization),
+		"compression":   strings.ToLower(cfg.Compression),
+		"sampling":      fmtFloat(cfg.Sampling),
+		"mode":          cfg.Mode,
+	}
+	if l["mode"] == "" {
+		l["mode"] = engine.ModeModel
+	}
+	if cfg.Workload != "" {
+		l["workload"] = cfg.Workload
+	}
+	if cfg.Protocol != "" {
+		l["protocol"] = cfg.Protocol
+	}
+	if cfg.BatchTimeout > 0 {
+		size := cfg.BatchSize
+		if size <= 0 {
+			size = engine.DefaultBatchSize
+		}
+		l["batch_size"] = strconv.Itoa(size)
+		l["batch_timeout"] = cfg.BatchTimeout.String()
+		// 기본(drop)과 다른 정책만 시계열을 나눈다
+		if cfg.Backpressure == engine.BackpressureBlock {
+			l["backpressure"] = cfg.Backpressure
+		}
+	}
+	return l
+}
+
+// RunLabels returns ConfigLabels(cfg) plus the user labels of r.
+func RunLabels(cfg engine.Config, r engine.Result) map[string]string {
+	l := ConfigLabels(cfg)
+	for k, v := range r.Labels {
+		l[k] = v
+	}
+	return l
+}
+
+type omFamily struct {
+	name, help, unit string
+	value            func(engine.Result) (float64, bool)
+}
+
+// 결과 ABI 필드 → OpenMetrics gauge (textfile collector가 읽는 이름)
+var omFamilies = []omFamily{
+	{"trace_bench_p95_ms", "p95 export latency in milliseconds", "", metric("p95_ms")},
+	{"trace_bench_p99_ms", "p99 export latency in milliseconds", "", metric("p99_ms")},
+	{"trace_bench_error_rate", "failed exports per export", "", metric("error_rate")},
+	{"trace_bench_size_kb", "payload size per export in KB", "", metric("size_kb")},
+	{"trace_bench_mttr_seconds", "recovery time after the first chaos fault", "seconds", metric("mttr_seconds")},
+	{"trace_bench_allocs_per_op", "heap allocations per export", "", func(r engine.Result) (float64, bool) {
+		if r.Mem == nil {
+			return 0, false
+		}
+		return r.Mem.AllocsPerOp, true
+	}},
+	{"trace_bench_bytes_per_op", "heap bytes allocated per export", "", func(r engine.Result) (float64, bool) {
+		if r.Mem == nil {
+			return 0, false
+		}
+		return r.Mem.BytesPerOp, true
+	}},
+}
+
+func metric(name string) func(engine.Result) (float64, bool) {
+	return func(r engine.Result) (float64, bool) {
+		v, ok := r.Metrics()[name]
+		return v, ok
+	}
+}
+
+// WriteOpenMetrics renders results as OpenMetrics text (one gauge family per
+// ABI metric, config and user labels as labels, terminated by "# EOF"), suitable for the
+// node_exporter textfile collector.
+func WriteOpenMetrics(w io.Writer, cfgs []engine.Config, rs []engine.Result) error {
+	var b strings.Builder
+	for _, f := range omFamilies {
+		var lines []string
+		for i, r := range rs {
+			if v, ok := f.value(r); ok {
+				lines = append(lines, fmt.Sprintf("%s%s %s\n", f.name, fmtLabels(RunLabels(cfgs[i], r)), strconv.FormatFloat(v, 'g', -1, 64)))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "# TYPE %s gauge\n# HELP %s %s\n", f.name, f.name, f.help)
+		if f.unit != "" {
+			fmt.Fprintf(&b, "# UNIT %s %s\n", f.name, f.unit)
+		}
+		b.WriteString(strings.Join(lines, ""))
+	}
+	// run id는 info 계열로만 노출(본 gauge의 카디널리티를 늘리지 않음)
+	var info []string
+	for i, r := range rs {
+		if r.RunID != "" {
+			l := RunLabels(cfgs[i], r)
+			l["run_id"] = r.RunID
+			info = append(info, fmt.Sprintf("trace_bench_run_info%s 1\n", fmtLabels(l)))
+		}
+	}
+	if len(info) > 0 {
+		b.WriteString("# TYPE trace_bench_run_info gauge\n# HELP trace_bench_run_info run id of the result (join on the config labels)\n")
+		b.WriteString(strings.Join(info, ""))
+	}
+	fmt.Fprintf(&b, "# TYPE trace_bench_last_run_timestamp_seconds gauge\n# HELP trace_bench_last_run_timestamp_seconds time the result was written\ntrace_bench_last_run_timestamp_seconds %d\n# EOF\n", Now().Unix())
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func fmtLabels(l map[string]string) string {
+	keys := sortedKeys(l)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + strconv.Quote(l[k])
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
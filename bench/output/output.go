@@ -0,0 +1,168 @@
+// Package output writes trace_bench results in the formats consumed by the
+// Day20/21 scripts and CI gates.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/engine"
+)
+
+// Output formats selectable via -out-format.
+const (
+	FormatJSON        = "json"
+	FormatBenchstat   = "benchstat"
+	FormatOpenMetrics = "openmetrics"
+	FormatInflux      = "influx"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{FormatJSON, FormatBenchstat, FormatOpenMetrics, FormatInflux}
+
+// Now is the write time stamped into the formats that carry one
+// (OpenMetrics' last-run timestamp, Influx line timestamps); golden tests
+// pin it.
+var Now = time.Now
+
+// Write renders r for cfg in the given format.
+func Write(w io.Writer, format string, cfg engine.Config, r engine.Result) error {
+	switch format {
+	case FormatJSON:
+		return WriteJSON(w, r)
+	case FormatBenchstat:
+		return WriteBenchstat(w, cfg, r)
+	case FormatOpenMetrics:
+		return WriteOpenMetrics(w, []engine.Config{cfg}, []engine.Result{r})
+	case FormatInflux:
+		return WriteInflux(w, []engine.Config{cfg}, []engine.Result{r})
+	default:
+		return fmt.Errorf("invalid out-format: %s", format)
+	}
+}
+
+// ValidFormat reports whether format is supported.
+func ValidFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteJSON encodes v as a single compact JSON line.
+func WriteJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "")
+	return enc.Encode(v)
+}
+
+// WriteFileAtomic writes v as JSON to path via a temp file and rename, so
+// readers never observe a partially written result.
+func WriteFileAtomic(path string, v any) error {
+	return WriteFileAtomicFunc(path, func(w io.Writer) error { return WriteJSON(w, v) })
+}
+
+// WriteFileAtomicFunc is WriteFileAtomic for any format: write renders the
+// content. Each call uses its own temp file, so concurrent writers of one
+// path never mix their contents.
+func WriteFileAtomicFunc(path string, write func(io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// WriteJSONFile atomically writes v as JSON to path (same as WriteFileAtomic).
+func WriteJSONFile(path string, v any) error { return WriteFileAtomic(path, v) }
+
+// Sweep is the JSON document for runs that differ in one dimension
+// (e.g. -protocols=h1,h2) or in the batch processor settings
+// (-batch-size × -batch-timeout), so results can be read side by side.
+type Sweep struct {
+	Sweep   string          `json:"sweep"`
+	Results []engine.Result `json:"results"`
+	// RankedByCost lists the swept values from the lowest monthly cost up
+	// when every result was priced (-cost-model).
+	RankedByCost []string `json:"ranked_by_cost,omitempty"`
+}
+
+// RankByCost orders labels by the monthly cost of the matching results,
+// cheapest first; nil unless every result has a cost estimate.
+func RankByCost(labels []string, rs []engine.Result) []string {
+	idx := make([]int, len(rs))
+	for i, r := range rs {
+		if r.Cost == nil {
+			return nil
+		}
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return rs[idx[a]].Cost.Monthly < rs[idx[b]].Cost.Monthly })
+	out := make([]string, len(idx))
+	for i, j := range idx {
+		out[i] = labels[j]
+	}
+	return out
+}
+
+// SweepLabels names each swept config by its key label; a key listing
+// several labels (e.g. "batch_size,batch_timeout") joins their values
+// with "/".
+func SweepLabels(key string, cfgs []engine.Config) []string {
+	labels := make([]string, len(cfgs))
+	for i, c := range cfgs {
+		l := ConfigLabels(c)
+		var vals []string
+		for _, k := range strings.Split(key, ",") {
+			vals = append(vals, l[k])
+		}
+		labels[i] = strings.Join(vals, "/")
+	}
+	return labels
+}
+
+// WriteSweep renders several results; benchstat output gets one line per
+// result so the swept key becomes a benchstat column.
+func WriteSweep(w io.Writer, format, key string, cfgs []engine.Config, rs []engine.Result) error {
+	switch format {
+	case FormatJSON:
+		return WriteJSON(w, Sweep{Sweep: key, Results: rs, RankedByCost: RankByCost(SweepLabels(key, cfgs), rs)})
+	case FormatBenchstat:
+		for i := range rs {
+			if err := WriteBenchstat(w, cfgs[i], rs[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatOpenMetrics:
+		return WriteOpenMetrics(w, cfgs, rs)
+	case FormatInflux:
+		return WriteInflux(w, cfgs, rs)
+	default:
+		return fmt.Errorf("invalid out-format: %s", format)
+	}
+}
@@ -0,0 +1,40 @@
+package output
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// output은 공개 API: 시그니처를 바꾸면 여기서 컴파일이 깨진다(새 함수를 추가할 것)
+var (
+	_ func(string, any) error                   = WriteFileAtomic
+	_ func(string, func(io.Writer) error) error = WriteFileAtomicFunc
+	_ func(string, any) error                   = WriteJSONFile
+	_ func(io.Writer, any) error                = WriteJSON
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.json")
+	if err := WriteFileAtomic(path, map[string]float64{"p95_ms": 1.5}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "{\"p95_ms\":1.5}\n" {
+		t.Errorf("content = %q", b)
+	}
+	if err := WriteFileAtomicFunc(path, func(w io.Writer) error { return io.ErrUnexpectedEOF }); err == nil {
+		t.Error("write error not returned")
+	}
+	if b2, _ := os.ReadFile(path); string(b2) != string(b) {
+		t.Errorf("failed write replaced the file: %q", b2)
+	}
+	if ents, _ := os.ReadDir(dir); len(ents) != 1 {
+		t.Errorf("temp files left behind: %v", ents)
+	}
+}
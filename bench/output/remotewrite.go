@@ -0,0 +1,155 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// RemoteWrite pushes the timeline as Prometheus remote-write (v1) samples:
+// per-interval throughput, error ratio, bytes and latency quantiles, each
+// series carrying labels (e.g. run_id).
+func RemoteWrite(ctx context.Context, url string, labels map[string]string, tl *stats.Timeline) error {
+	if tl == nil {
+		return fmt.Errorf("remote-write requires a timeline (mode real)")
+	}
+	pts := tl.Points()
+	if len(pts) == 0 {
+		return nil
+	}
+	secs := tl.Interval.Seconds()
+	var series []rwSeries
+	add := func(name string, extra map[string]string, v func(stats.TimelinePoint) float64) {
+		s := rwSeries{labels: rwLabels(name, labels, extra)}
+		for _, p := range pts {
+			s.samples = append(s.samples, rwSample{v: v(p), ts: p.Time.UnixMilli()})
+		}
+		series = append(series, s)
+	}
+	add("trace_bench_requests_per_second", nil, func(p stats.TimelinePoint) float64 { return float64(p.Count) / secs })
+	add("trace_bench_error_ratio", nil, func(p stats.TimelinePoint) float64 { return float64(p.Failed) / float64(p.Count) })
+	add("trace_bench_bytes_per_second", nil, func(p stats.TimelinePoint) float64 { return float64(p.Bytes) / secs })
+	add("trace_bench_latency_ms", map[string]string{"quantile": "0.5"}, func(p stats.TimelinePoint) float64 { return p.P50ms })
+	add("trace_bench_latency_ms", map[string]string{"quantile": "0.95"}, func(p stats.TimelinePoint) float64 { return p.P95ms })
+	add("trace_bench_latency_ms", map[string]string{"quantile": "0.99"}, func(p stats.TimelinePoint) float64 { return p.P99ms })
+
+	return postWriteRequest(ctx, url, nil, series)
+}
+
+// RemoteWriteSample is one sample for RemoteWriteSamples.
+type RemoteWriteSample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+	Time   time.Time
+}
+
+// RemoteWriteSamples pushes samples as Prometheus remote-write (v1), adding
+// header to the request (e.g. X-Scope-OrgID to write as one tenant).
+func RemoteWriteSamples(ctx context.Context, url string, header http.Header, samples []RemoteWriteSample) error {
+	series := make([]rwSeries, 0, len(samples))
+	for _, s := range samples {
+		series = append(series, rwSeries{labels: rwLabels(s.Name, s.Labels), samples: []rwSample{{v: s.Value, ts: s.Time.UnixMilli()}}})
+	}
+	return postWriteRequest(ctx, url, header, series)
+}
+
+func postWriteRequest(ctx context.Context, url string, header http.Header, series []rwSeries) error {
+	body := appendSnappyLiteral(nil, appendWriteRequest(nil, series))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	c := &http.Client{Timeout: 30 * time.Second}
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("remote-write: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote-write: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+type rwLabel struct{ name, value string }
+
+type rwSample struct {
+	v  float64
+	ts int64 // ms
+}
+
+type rwSeries struct {
+	labels  []rwLabel
+	samples []rwSample
+}
+
+// 레이블은 이름순 정렬이 프로토콜 요구사항
+func rwLabels(name string, sets ...map[string]string) []rwLabel {
+	ls := []rwLabel{{"__name__", name}}
+	for _, m := range sets {
+		for k, v := range m {
+			ls = append(ls, rwLabel{k, v})
+		}
+	}
+	sort.Slice(ls, func(i, j int) bool { return ls[i].name < ls[j].name })
+	return ls
+}
+
+// prometheus.WriteRequest{timeseries=1{labels=1{name=1,value=2}, samples=2{value=1,timestamp=2}}}
+func appendWriteRequest(b []byte, series []rwSeries) []byte {
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var lb []byte
+			lb = pbBytes(lb, 1, []byte(l.name))
+			lb = pbBytes(lb, 2, []byte(l.value))
+			ts = pbBytes(ts, 1, lb)
+		}
+		for _, smp := range s.samples {
+			var sb []byte
+			sb = binary.AppendUvarint(sb, 1<<3|1) // double
+			sb = binary.LittleEndian.AppendUint64(sb, math.Float64bits(smp.v))
+			sb = binary.AppendUvarint(sb, 2<<3|0) // int64
+			sb = binary.AppendUvarint(sb, uint64(smp.ts))
+			ts = pbBytes(ts, 2, sb)
+		}
+		b = pbBytes(b, 1, ts)
+	}
+	return b
+}
+
+func pbBytes(b []byte, field int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// appendSnappyLiteral은 literal 청크만으로 된 유효한 snappy block(무압축).
+// 외부 의존성 없이 remote-write의 snappy 요구를 만족시킨다.
+func appendSnappyLiteral(b, src []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(src)))
+	for len(src) > 0 {
+		n := min(len(src), 1<<16)
+		// tag: 61(2바이트 길이) << 2 | literal(00), 길이-1 little-endian
+		b = append(b, 61<<2, byte(n-1), byte((n-1)>>8))
+		b = append(b, src[:n]...)
+		src = src[n:]
+	}
+	return b
+}
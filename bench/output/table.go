@@ -0,0 +1,49 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/duri/trace_bench/engine"
+)
+
+// WriteTable prints results side by side, one row per label.
+func WriteTable(w io.Writer, labels []string, rs []engine.Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	// 모든 결과에 비용이 있으면 월 비용 열을 붙인다
+	priced := RankByCost(labels, rs) != nil
+	// batch processor sweep이면 처리량/drop/지연 열을 붙인다
+	batched := len(rs) > 0
+	for _, r := range rs {
+		batched = batched && r.Batching != nil
+	}
+	hdr := "\tp95_ms\tp99_ms\terror_rate\tsize_kb\tnew_conns\ttls_hs_p95_ms\t"
+	if batched {
+		hdr += "spans_per_sec\tdrop_rate\tdelay_p95_ms\tmean_batch\t"
+	}
+	if priced {
+		hdr += "monthly_cost\t"
+	}
+	fmt.Fprintln(tw, hdr)
+	for i, r := range rs {
+		var conns int
+		var hs float64
+		if r.Conn != nil {
+			conns = r.Conn.NewConnections
+		}
+		if r.TLS != nil {
+			hs = r.TLS.HandshakeP95ms
+		}
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%v\t%d\t%v\t", labels[i], r.P95ms, r.P99ms, r.ErrorRate, r.SizeKB, conns, hs)
+		if batched {
+			b := r.Batching
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t", b.SpansPerSec, b.DropRate, b.DelayP95ms, b.MeanBatchSpans)
+		}
+		if priced {
+			fmt.Fprintf(tw, "%v\t", r.Cost.Monthly)
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,291 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/stats"
+)
+
+// ANSI 색(터미널 diff)
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+)
+
+// sparkBlocks는 낮은 값부터 높은 값까지 8단계 막대
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders vals as block characters scaled between lo and hi
+// (both taken from vals when lo == hi).
+func Sparkline(vals []float64, lo, hi float64) string {
+	if lo == hi {
+		lo, hi = math.Inf(1), math.Inf(-1)
+		for _, v := range vals {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	var b strings.Builder
+	for _, v := range vals {
+		i := 0
+		if hi > lo {
+			i = int(math.Round((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1)))
+		}
+		b.WriteRune(sparkBlocks[min(max(i, 0), len(sparkBlocks)-1)])
+	}
+	return b.String()
+}
+
+// lowerIsBetter는 감소가 개선인 지표(나머지는 방향 없음)
+func lowerIsBetter(metric string) bool {
+	switch {
+	case strings.HasSuffix(metric, "_ms"), strings.HasSuffix(metric, "_seconds"),
+		strings.HasPrefix(metric, "errors."), metric == "error_rate", metric == "size_kb",
+		metric == "allocs_per_op", metric == "bytes_per_op", metric == "gc_pause_ms":
+		return true
+	}
+	return false
+}
+
+// TUIMetrics flattens every scalar of r shown by the terminal diff: the
+// result ABI metrics, memory, retry/TLS latencies, error classes
+// (errors.<class>) and -collector values (custom.<name>).
+func TUIMetrics(r engine.Result) map[string]float64 {
+	m := r.Metrics()
+	if r.Mem != nil {
+		m["allocs_per_op"] = r.Mem.AllocsPerOp
+		m["bytes_per_op"] = r.Mem.BytesPerOp
+		m["gc_pause_ms"] = r.Mem.GCPauseMs
+	}
+	if r.Retry != nil {
+		m["first_attempt_p95_ms"] = r.Retry.FirstAttemptP95ms
+		m["first_attempt_error_rate"] = r.Retry.FirstAttemptErrorRate
+	}
+	if r.TLS != nil {
+		m["tls_handshake_p95_ms"] = r.TLS.HandshakeP95ms
+	}
+	for k, v := range r.Errors {
+		m["errors."+k] = float64(v)
+	}
+	for k, v := range r.CustomMetrics {
+		m["custom."+k] = v
+	}
+	return m
+}
+
+// tuiWriter는 색 사용 여부를 들고 다니는 출력기
+type tuiWriter struct {
+	w     io.Writer
+	color bool
+}
+
+func (t tuiWriter) paint(code, s string) string {
+	if !t.color || code == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func (t tuiWriter) heading(s string) {
+	fmt.Fprintf(t.w, "\n%s\n", t.paint(ansiBold+ansiCyan, s))
+}
+
+// deltaColor는 방향이 있는 지표의 악화를 빨강, 개선을 초록으로
+func deltaColor(metric string, delta float64) string {
+	if delta == 0 || !lowerIsBetter(metric) {
+		return ""
+	}
+	if delta > 0 {
+		return ansiRed
+	}
+	return ansiGreen
+}
+
+// deltaBar는 |Δ%|를 2.5%당 한 칸(최대 20칸) 막대로
+func deltaBar(pct *float64) string {
+	if pct == nil {
+		return ""
+	}
+	n := min(int(math.Ceil(math.Abs(*pct)/2.5)), 20)
+	if n == 0 {
+		return ""
+	}
+	arrow := "▲"
+	if *pct < 0 {
+		arrow = "▼"
+	}
+	return arrow + " " + strings.Repeat("█", n)
+}
+
+func fmtNum(v float64) string { return strconv.FormatFloat(v, 'g', 6, 64) }
+
+// WriteDiffTUI prints a colored terminal comparison of two results: a
+// side-by-side table of every metric with delta bars, latency percentiles
+// and distribution sparklines (when both results carry a heatmap), and
+// p95-over-time sparklines (soak checkpoints or heatmap intervals).
+func WriteDiffTUI(w io.Writer, baseLabel, curLabel string, base, cur engine.Result, color bool) error {
+	t := tuiWriter{w: w, color: color}
+	fmt.Fprintf(w, "%s  %s %s  %s %s\n", t.paint(ansiBold, "trace_bench compare"),
+		t.paint(ansiDim, "base:"), baseLabel, t.paint(ansiDim, "current:"), curLabel)
+
+	bm, cm := TUIMetrics(base), TUIMetrics(cur)
+	t.heading("metrics")
+	t.table(diffMaps(bm, cm))
+
+	bh, ch := base.Heatmap, cur.Heatmap
+	if bh != nil && ch != nil && equalBounds(bh.Bounds, ch.Bounds) {
+		bt, ct := bh.Totals(), ch.Totals()
+		pm, pc := map[string]float64{}, map[string]float64{}
+		for _, q := range []float64{0.5, 0.75, 0.9, 0.95, 0.99, 0.999} {
+			name := "p" + strconv.FormatFloat(q*100, 'f', -1, 64) + "_le_ms"
+			pm[name] = stats.BucketQuantile(bh.Bounds, bt, q)
+			pc[name] = stats.BucketQuantile(ch.Bounds, ct, q)
+		}
+		t.heading("latency percentiles (heatmap bucket upper bounds)")
+		ds := diffMaps(pm, pc)
+		sort.Slice(ds, func(i, j int) bool { return percentileOrder(ds[i].Metric) < percentileOrder(ds[j].Metric) })
+		t.table(ds)
+		t.heading("latency distribution")
+		t.distribution(baseLabel, curLabel, bh.Bounds, bt, ct)
+	}
+
+	bs, cs := p95Series(base), p95Series(cur)
+	if len(bs) > 1 || len(cs) > 1 {
+		t.heading("p95 over time")
+		lo, hi := seriesRange(bs, cs)
+		width := max(len(baseLabel), len(curLabel))
+		for _, s := range []struct {
+			label string
+			vals  []float64
+		}{{baseLabel, bs}, {curLabel, cs}} {
+			if len(s.vals) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "  %-*s  %s  %s\n", width, s.label, t.paint(ansiCyan, Sparkline(s.vals, lo, hi)),
+				t.paint(ansiDim, fmt.Sprintf("%s..%s ms", fmtNum(minOf(s.vals)), fmtNum(maxOf(s.vals)))))
+		}
+	}
+	return nil
+}
+
+// table은 색 코드가 정렬을 깨지 않도록 칸을 먼저 채우고 칠한다
+func (t tuiWriter) table(ds []MetricDiff) {
+	mw := len("metric")
+	for _, d := range ds {
+		mw = max(mw, len(d.Metric))
+	}
+	fmt.Fprintf(t.w, "  %s\n", t.paint(ansiDim, fmt.Sprintf("%-*s  %12s  %12s  %12s  %9s", mw, "metric", "base", "current", "delta", "delta_pct")))
+	for _, d := range ds {
+		pct := "-"
+		if d.DeltaPct != nil {
+			pct = fmt.Sprintf("%+.2f%%", *d.DeltaPct)
+		}
+		c := deltaColor(d.Metric, d.Delta)
+		fmt.Fprintf(t.w, "  %-*s  %12s  %12s  %s  %s\n", mw, d.Metric, fmtNum(d.Base), fmtNum(d.Current),
+			t.paint(c, fmt.Sprintf("%12s  %9s", fmt.Sprintf("%+g", d.Delta), pct)), t.paint(c, deltaBar(d.DeltaPct)))
+	}
+}
+
+// distribution은 두 분포를 같은 버킷 구간·같은 척도로 나란히 그린다
+func (t tuiWriter) distribution(baseLabel, curLabel string, bounds []float64, bt, ct []uint64) {
+	lo, hi := -1, -1
+	for i := range bt {
+		if bt[i] > 0 || ct[i] > 0 {
+			if lo < 0 {
+				lo = i
+			}
+			hi = i
+		}
+	}
+	if lo < 0 {
+		return
+	}
+	share := func(c []uint64) []float64 {
+		var total uint64
+		for _, v := range c {
+			total += v
+		}
+		out := make([]float64, 0, hi-lo+1)
+		for _, v := range c[lo : hi+1] {
+			out = append(out, float64(v)/float64(max(total, 1)))
+		}
+		return out
+	}
+	bs, cs := share(bt), share(ct)
+	top := math.Max(maxOf(bs), maxOf(cs))
+	width := max(len(baseLabel), len(curLabel))
+	edge := func(i int) string {
+		if i >= len(bounds) {
+			return "+Inf"
+		}
+		return fmtNum(bounds[i])
+	}
+	fmt.Fprintf(t.w, "  %-*s  %s\n", width, baseLabel, t.paint(ansiDim, Sparkline(bs, 0, top)))
+	fmt.Fprintf(t.w, "  %-*s  %s\n", width, curLabel, t.paint(ansiCyan, Sparkline(cs, 0, top)))
+	fmt.Fprintf(t.w, "  %-*s  %s\n", width, "", t.paint(ansiDim, fmt.Sprintf("≤%s ms .. ≤%s ms", edge(lo), edge(hi))))
+}
+
+// p95Series는 soak 체크포인트 p95, 없으면 heatmap 구간별 p95
+func p95Series(r engine.Result) []float64 {
+	var out []float64
+	if r.Soak != nil {
+		for _, c := range r.Soak.Checkpoints {
+			out = append(out, c.P95ms)
+		}
+		return out
+	}
+	if r.Heatmap != nil {
+		for _, row := range r.Heatmap.Rows() {
+			out = append(out, stats.BucketQuantile(r.Heatmap.Bounds, row.Counts, 0.95))
+		}
+	}
+	return out
+}
+
+func seriesRange(a, b []float64) (float64, float64) {
+	all := append(append([]float64(nil), a...), b...)
+	return minOf(all), maxOf(all)
+}
+
+func minOf(v []float64) float64 {
+	m := math.Inf(1)
+	for _, x := range v {
+		m = math.Min(m, x)
+	}
+	return m
+}
+
+func maxOf(v []float64) float64 {
+	m := math.Inf(-1)
+	for _, x := range v {
+		m = math.Max(m, x)
+	}
+	return m
+}
+
+func equalBounds(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// percentileOrder는 "p99.9_le_ms" → 99.9 (이름순이 아닌 분위순 정렬용)
+func percentileOrder(name string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSuffix(strings.TrimPrefix(name, "p"), "_le_ms"), 64)
+	return f
+}
@@ -0,0 +1,114 @@
+// Package buildinfo derives a tool's provenance (version, VCS revision,
+// dirty flag, build time) from the Go build info embedded by `go build`, so
+// results record exactly which build produced them.
+//
+// Release builds may still override the version and stamp the build time:
+//
+//	go build -ldflags "-X github.com/duri/trace_bench/pkg/buildinfo.Version=v1.2.0 \
+//	    -X github.com/duri/trace_bench/pkg/buildinfo.BuildTime=2024-06-01T12:00:00Z"
+package buildinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// Set via -ldflags -X; empty means derive from the build info.
+var (
+	Version   string
+	BuildTime string
+)
+
+// Info is the provenance of the running binary.
+type Info struct {
+	Version  string `json:"version"`
+	Module   string `json:"module,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	Dirty    bool   `json:"dirty"`
+	// CommitTime is the VCS time of Revision; BuildTime is only known when
+	// stamped via -ldflags.
+	CommitTime string `json:"commit_time,omitempty"`
+	BuildTime  string `json:"build_time,omitempty"`
+	GoVersion  string `json:"go_version"`
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Read returns the provenance of the running binary.
+func Read() Info {
+	once.Do(func() { info = fromBuildInfo(debug.ReadBuildInfo()) })
+	return info
+}
+
+func fromBuildInfo(bi *debug.BuildInfo, ok bool) Info {
+	i := Info{Version: Version, BuildTime: BuildTime}
+	if !ok {
+		if i.Version == "" {
+			i.Version = "unknown"
+		}
+		return i
+	}
+	i.Module, i.GoVersion = bi.Main.Path, bi.GoVersion
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			i.Revision = s.Value
+		case "vcs.modified":
+			i.Dirty = s.Value == "true"
+		case "vcs.time":
+			i.CommitTime = s.Value
+		}
+	}
+	if i.Version != "" {
+		return i
+	}
+	// go install mod@vX는 모듈 버전을, 작업 트리 빌드는 (devel)을 남긴다
+	switch {
+	case bi.Main.Version != "" && bi.Main.Version != "(devel)":
+		i.Version = bi.Main.Version
+	case i.Revision != "":
+		i.Version = "devel+" + shortRev(i.Revision)
+		if i.Dirty {
+			i.Version += "-dirty"
+		}
+	default:
+		i.Version = "devel"
+	}
+	return i
+}
+
+// String is the one-line form printed by -version.
+func (i Info) String() string {
+	// devel 버전은 이미 리비전을 담고 있다
+	if i.Revision == "" || strings.HasPrefix(i.Version, "devel") {
+		return i.Version
+	}
+	s := i.Version + " (" + shortRev(i.Revision)
+	if i.Dirty {
+		s += ", dirty"
+	}
+	return s + ")"
+}
+
+func shortRev(r string) string { return r[:min(12, len(r))] }
+
+// Print writes "<tool> <version>" or, with asJSON, the full Info.
+func Print(w io.Writer, tool string, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Tool string `json:"tool"`
+			Info
+		}{tool, Read()})
+	}
+	_, err := fmt.Fprintf(w, "%s %s\n", tool, Read())
+	return err
+}
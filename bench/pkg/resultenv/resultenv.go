@@ -0,0 +1,125 @@
+// Package resultenv defines the result envelope shared by the proof tools
+// (trace_bench, backupprobe, metricsguard, ...), so aggregation and
+// dashboards read one format whatever tool produced the run:
+//
+//	{"tool":"backupprobe","version":"v0.1.0","schema_version":2,
+//	 "build":{"version":"v0.1.0","revision":"5d14fe1...","dirty":false,...},
+//	 "started_at":"2024-06-01T12:00:00Z","params":{"command":"schedule","incr-every":"1h"},
+//	 "metrics":{"rpo_seconds":812},"verdict":"pass","evidence":{...}}
+//
+// Metrics are flat numbers meant for time series; Verdict is one of the
+// shared Verdicts and Reasons the machine-readable causes behind it;
+// Evidence is the tool's own gate JSON, kept verbatim for humans and audits.
+package resultenv
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/duri/trace_bench/pkg/buildinfo"
+)
+
+// SchemaVersion is bumped on incompatible envelope changes. Version 2
+// replaced the pass|warn|fail|error verdicts with Verdicts and added
+// reasons; Decode still reads version 1.
+const SchemaVersion = 2
+
+// Envelope is one tool run.
+type Envelope struct {
+	Tool          string             `json:"tool"`
+	Version       string             `json:"version"`
+	SchemaVersion int                `json:"schema_version"`
+	Build         buildinfo.Info     `json:"build"`
+	StartedAt     time.Time          `json:"started_at"`
+	Params        map[string]string  `json:"params"`
+	Metrics       map[string]float64 `json:"metrics"`
+	Verdict       Verdict            `json:"verdict"`
+	// Reasons are short tokens such as "slo:export-latency" or
+	// "target_unhealthy" explaining any verdict but pass.
+	Reasons  []string `json:"reasons,omitempty"`
+	Evidence any      `json:"evidence,omitempty"`
+	// Deprecations lists deprecated inputs the run still accepted, so CI
+	// can find callers to migrate before the old form is removed.
+	Deprecations []Deprecation `json:"deprecations,omitempty"`
+}
+
+// Deprecation is one deprecated input used by the run.
+type Deprecation struct {
+	Kind        string `json:"kind"` // "flag"
+	Name        string `json:"name"`
+	Replacement string `json:"replacement,omitempty"`
+	Message     string `json:"message"`
+}
+
+// New starts an envelope for tool at the current time, stamped with the
+// running binary's build info.
+func New(tool string) *Envelope {
+	b := buildinfo.Read()
+	return &Envelope{Tool: tool, Version: b.Version, SchemaVersion: SchemaVersion, Build: b, StartedAt: time.Now().UTC(),
+		Params: map[string]string{}, Metrics: map[string]float64{}}
+}
+
+// Flags records the flags explicitly set on fs as params.
+func (e *Envelope) Flags(fs *flag.FlagSet) *Envelope {
+	fs.Visit(func(f *flag.Flag) { e.Params[f.Name] = f.Value.String() })
+	return e
+}
+
+// Finish sets the verdict and its reasons and attaches the tool's report
+// as evidence.
+func (e *Envelope) Finish(v Verdict, evidence any, reasons ...string) *Envelope {
+	e.Verdict, e.Reasons, e.Evidence = v, reasons, evidence
+	return e
+}
+
+// Validate checks the fields every consumer relies on.
+func (e *Envelope) Validate() error {
+	switch {
+	case e.Tool == "":
+		return fmt.Errorf("envelope: missing tool")
+	case e.SchemaVersion < 1 || e.SchemaVersion > SchemaVersion:
+		return fmt.Errorf("envelope: unsupported schema_version %d (this build reads <= %d)", e.SchemaVersion, SchemaVersion)
+	case e.StartedAt.IsZero():
+		return fmt.Errorf("envelope %s: missing started_at", e.Tool)
+	}
+	if !e.Verdict.Valid() {
+		return fmt.Errorf("envelope %s: invalid verdict %q", e.Tool, e.Verdict)
+	}
+	return nil
+}
+
+// Encode writes e as one compact JSON line.
+func Encode(w io.Writer, e *Envelope) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(e)
+}
+
+// Decode reads one envelope; Evidence stays raw JSON for the caller to
+// decode into the tool's own type. Version 1 verdicts are mapped to their
+// replacements (see ParseVerdict), so callers only see Verdicts.
+func Decode(r io.Reader) (*Envelope, error) {
+	var raw struct {
+		Envelope
+		Evidence json.RawMessage `json:"evidence"`
+	}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("envelope: %w", err)
+	}
+	e := raw.Envelope
+	if e.SchemaVersion == 1 {
+		if v, err := ParseVerdict(string(e.Verdict)); err == nil {
+			e.Verdict = v
+		}
+	}
+	if len(raw.Evidence) > 0 {
+		e.Evidence = raw.Evidence
+	}
+	return &e, e.Validate()
+}
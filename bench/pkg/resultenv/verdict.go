@@ -0,0 +1,107 @@
+package resultenv
+
+import "fmt"
+
+// Verdict is the outcome of one tool run, shared by every proof tool so
+// aggregators and dashboards can group runs without parsing free text.
+type Verdict string
+
+// Verdicts. A run that measured something ends in pass, pass_with_warnings
+// or fail_slo; fail_infra and fail_flaky mean the run says nothing about
+// the system under test.
+const (
+	// VerdictPass: every objective met, nothing to report.
+	VerdictPass Verdict = "pass"
+	// VerdictPassWithWarnings: objectives met, but the run used deprecated
+	// inputs or its environment was degraded (see Envelope.Reasons).
+	VerdictPassWithWarnings Verdict = "pass_with_warnings"
+	// VerdictFailSLO: the measured system missed an objective (SLO,
+	// regression, drift or the gate's own check).
+	VerdictFailSLO Verdict = "fail_slo"
+	// VerdictFailInfra: the tool could not measure (bad input, unreachable
+	// or unhealthy target, I/O error).
+	VerdictFailInfra Verdict = "fail_infra"
+	// VerdictFailFlaky: repeated runs disagreed, so no verdict is trusted.
+	VerdictFailFlaky Verdict = "fail_flaky"
+	// VerdictSkipped: the run was deliberately not performed (vetoed by a
+	// hook, locked out by a concurrent run).
+	VerdictSkipped Verdict = "skipped"
+	// VerdictCached: the verdict was reused from an earlier identical run.
+	VerdictCached Verdict = "cached"
+)
+
+// Verdicts lists every verdict from most to least severe (the order Worst
+// uses).
+var Verdicts = []Verdict{VerdictFailSLO, VerdictFailInfra, VerdictFailFlaky, VerdictPassWithWarnings, VerdictPass, VerdictCached, VerdictSkipped}
+
+// legacyVerdicts maps schema_version 1 verdicts to their replacement.
+var legacyVerdicts = map[string]Verdict{"warn": VerdictPassWithWarnings, "fail": VerdictFailSLO, "error": VerdictFailInfra}
+
+// ParseVerdict parses a verdict, accepting the schema_version 1 values
+// (warn, fail, error) as their replacements.
+func ParseVerdict(s string) (Verdict, error) {
+	if v := Verdict(s); v.Valid() {
+		return v, nil
+	}
+	if v, ok := legacyVerdicts[s]; ok {
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid verdict %q", s)
+}
+
+// Valid reports whether v is one of Verdicts.
+func (v Verdict) Valid() bool { return v.severity() >= 0 }
+
+// Passed reports whether v lets a gate through: pass, pass_with_warnings
+// or cached.
+func (v Verdict) Passed() bool {
+	return v == VerdictPass || v == VerdictPassWithWarnings || v == VerdictCached
+}
+
+// Failed reports whether v is one of the fail_* verdicts. Skipped runs
+// neither pass nor fail.
+func (v Verdict) Failed() bool {
+	return v == VerdictFailSLO || v == VerdictFailInfra || v == VerdictFailFlaky
+}
+
+// severity is the index from the end of Verdicts, -1 if invalid.
+func (v Verdict) severity() int {
+	for i, w := range Verdicts {
+		if v == w {
+			return len(Verdicts) - 1 - i
+		}
+	}
+	return -1
+}
+
+// Worst returns the most severe of vs (skipped if vs is empty), e.g. the
+// verdict of a sweep or of a CI stage running several tools.
+func Worst(vs ...Verdict) Verdict {
+	worst := VerdictSkipped
+	for _, v := range vs {
+		if v.severity() > worst.severity() {
+			worst = v
+		}
+	}
+	return worst
+}
+
+// Judge is the verdict of a run that measured: fail_slo with failures,
+// pass_with_warnings with only warnings, pass otherwise.
+func Judge(failures, warnings []string) Verdict {
+	switch {
+	case len(failures) > 0:
+		return VerdictFailSLO
+	case len(warnings) > 0:
+		return VerdictPassWithWarnings
+	}
+	return VerdictPass
+}
+
+// PassFail is Judge for a gate with a single check.
+func PassFail(ok bool) Verdict {
+	if ok {
+		return VerdictPass
+	}
+	return VerdictFailSLO
+}
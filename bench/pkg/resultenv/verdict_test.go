@@ -0,0 +1,64 @@
+package resultenv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVerdict(t *testing.T) {
+	for in, want := range map[string]Verdict{
+		"pass": VerdictPass, "pass_with_warnings": VerdictPassWithWarnings, "fail_slo": VerdictFailSLO,
+		"fail_infra": VerdictFailInfra, "fail_flaky": VerdictFailFlaky, "skipped": VerdictSkipped, "cached": VerdictCached,
+		"warn": VerdictPassWithWarnings, "fail": VerdictFailSLO, "error": VerdictFailInfra,
+	} {
+		if got, err := ParseVerdict(in); err != nil || got != want {
+			t.Errorf("ParseVerdict(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "PASS", "ok", "failed"} {
+		if v, err := ParseVerdict(in); err == nil {
+			t.Errorf("ParseVerdict(%q) = %q, want error", in, v)
+		}
+	}
+}
+
+func TestVerdictClasses(t *testing.T) {
+	for _, v := range Verdicts {
+		if v.Passed() && v.Failed() {
+			t.Errorf("%s both passed and failed", v)
+		}
+	}
+	if VerdictSkipped.Passed() || VerdictSkipped.Failed() {
+		t.Error("skipped must neither pass nor fail")
+	}
+}
+
+func TestWorst(t *testing.T) {
+	for _, c := range []struct {
+		in   []Verdict
+		want Verdict
+	}{
+		{nil, VerdictSkipped},
+		{[]Verdict{VerdictPass, VerdictCached}, VerdictPass},
+		{[]Verdict{VerdictPass, VerdictPassWithWarnings}, VerdictPassWithWarnings},
+		{[]Verdict{VerdictFailInfra, VerdictFailSLO, VerdictPass}, VerdictFailSLO},
+		{[]Verdict{VerdictSkipped, VerdictFailFlaky}, VerdictFailFlaky},
+	} {
+		if got := Worst(c.in...); got != c.want {
+			t.Errorf("Worst(%v) = %s, want %s", c.in, got, c.want)
+		}
+	}
+}
+
+func TestDecodeV1(t *testing.T) {
+	e, err := Decode(strings.NewReader(`{"tool":"backupprobe","schema_version":1,"started_at":"2024-06-01T12:00:00Z","verdict":"error"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Verdict != VerdictFailInfra {
+		t.Errorf("verdict = %q, want %q", e.Verdict, VerdictFailInfra)
+	}
+	if _, err := Decode(strings.NewReader(`{"tool":"x","schema_version":2,"started_at":"2024-06-01T12:00:00Z","verdict":"fail"}`)); err == nil {
+		t.Error("schema_version 2 accepted a version 1 verdict")
+	}
+}
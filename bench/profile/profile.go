@@ -0,0 +1,159 @@
+// Package profile loads the per-environment bench profiles (profiles.yaml)
+// selected with trace_bench -env, so one bench definition runs against
+// dev, staging and prod without repeating target flags.
+//
+// Schema (version 1):
+//
+//	version: 1
+//	environments:
+//	  staging:
+//	    mode: real
+//	    workload: http
+//	    endpoint: https://otel.staging.example:4318/v1/traces
+//	    health: [https://otel.staging.example:13133/]   # pre/postflight checks
+//	    headers:                      # header -> secretref (or env var name)
+//	      Authorization: secretref://env/STAGING_OTLP_AUTH
+//	      X-Scope-OrgID: secretref://file//run/secrets/tenant
+//	    tls:
+//	      ca: /etc/bench/staging-ca.pem
+//	    influx_token_env: STAGING_INFLUX_TOKEN
+//	    slo:                          # slo.yaml name -> threshold override
+//	      trace-export-p95: 80
+//	    labels:
+//	      region: eu-west-1
+//	    script: gen_request.star      # per-request body/headers/assertions
+//
+// Credentials are referenced (see internal/secretref), never stored in the
+// profile itself.
+package profile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/duri/trace_bench/internal/secretref"
+	"github.com/duri/trace_bench/internal/yamlite"
+	"github.com/duri/trace_bench/slo"
+	"github.com/duri/trace_bench/validate"
+)
+
+// Version is the supported schema version.
+const Version = 1
+
+// File is a parsed profiles.yaml.
+type File struct {
+	Version      int                `json:"version"`
+	Environments map[string]Profile `json:"environments"`
+}
+
+// Profile is the target, credential and SLO settings of one environment.
+// Empty fields leave the corresponding flag default untouched.
+type Profile struct {
+	Mode           string             `json:"mode,omitempty"`
+	Workload       string             `json:"workload,omitempty"`
+	Endpoint       string             `json:"endpoint,omitempty"`
+	ComposeProject string             `json:"compose_project,omitempty"`
+	Service        string             `json:"service,omitempty"`
+	Headers        map[string]string  `json:"headers,omitempty"`
+	Health         []string           `json:"health,omitempty"`
+	TLS            TLS                `json:"tls,omitempty"`
+	InfluxTokenEnv string             `json:"influx_token_env,omitempty"`
+	SLO            map[string]float64 `json:"slo,omitempty"`
+	Labels         map[string]string  `json:"labels,omitempty"`
+	// Script is a Starlark request script (trace_bench -script).
+	Script string `json:"script,omitempty"`
+}
+
+// TLS names the PEM files used against the environment's target.
+type TLS struct {
+	CA         string `json:"ca,omitempty"`
+	Cert       string `json:"cert,omitempty"`
+	Key        string `json:"key,omitempty"`
+	ServerName string `json:"server_name,omitempty"`
+}
+
+// Load reads a .yaml/.yml or .json profiles file and validates it.
+func Load(path string) (*File, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = yamlite.UnmarshalJSON(b, &f)
+	default:
+		err = yamlite.Unmarshal(b, &f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("%s: unsupported profiles version: %d (expected %d)", path, f.Version, Version)
+	}
+	return &f, nil
+}
+
+// Env returns the profile named env.
+func (f *File) Env(env string) (Profile, error) {
+	p, ok := f.Environments[env]
+	if !ok {
+		names := make([]string, 0, len(f.Environments))
+		for n := range f.Environments {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Profile{}, fmt.Errorf("unknown env %q (profiles: %s)", env, strings.Join(names, ", "))
+	}
+	return p, nil
+}
+
+// ResolveHeaders resolves each header's secret reference; a bare value is
+// shorthand for secretref://env/<value>. Errors never include a value.
+func (p Profile) ResolveHeaders() (map[string]string, error) {
+	if len(p.Headers) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(p.Headers))
+	for h, ref := range p.Headers {
+		if !secretref.IsRef(ref) {
+			ref = secretref.Scheme + "env/" + ref
+		}
+		v, err := secretref.Resolve(ref)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", h, err)
+		}
+		out[h] = v
+	}
+	return out, nil
+}
+
+// ApplySLO returns slos with the profile's threshold overrides applied.
+// Overrides must name existing bench_metric SLOs.
+func (p Profile) ApplySLO(slos []slo.SLO) ([]slo.SLO, error) {
+	out := make([]slo.SLO, len(slos))
+	copy(out, slos)
+	for name, t := range p.SLO {
+		if err := validate.Finite("slo "+name+" threshold", t); err != nil {
+			return nil, err
+		}
+		found := false
+		for i := range out {
+			if out[i].Name == name {
+				if out[i].Indicator.BenchMetric == "" {
+					return nil, fmt.Errorf("slo %s: threshold override requires a bench_metric slo", name)
+				}
+				t := t
+				out[i].Threshold = &t
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("slo %s: not defined in the slo file", name)
+		}
+	}
+	return out, nil
+}
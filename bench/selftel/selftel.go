@@ -0,0 +1,277 @@
+// Package selftel instruments trace_bench itself: phase spans (setup,
+// warmup, measure, export) and result gauges, exported as OTLP/HTTP JSON
+// to the same collector the bench evaluates. It is a small stdlib-only
+// subset of the OpenTelemetry data model, not a general SDK.
+//
+// A nil *Tracer is valid and records nothing, so call sites need no
+// enabled checks.
+package selftel
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const scopeName = "github.com/duri/trace_bench/selftel"
+
+// Tracer buffers spans and gauges until Flush.
+type Tracer struct {
+	base     string
+	resource []kv
+	client   *http.Client
+
+	mu      sync.Mutex
+	traceID [16]byte
+	spans   []spanData
+	gauges  []gauge
+}
+
+// New returns a Tracer exporting to an OTLP/HTTP base URL such as
+// http://otel-collector:4318 (signals go to /v1/traces and /v1/metrics).
+func New(endpoint, service string, resource map[string]string) (*Tracer, error) {
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return nil, fmt.Errorf("invalid otel endpoint: %s", endpoint)
+	}
+	t := &Tracer{
+		base:     strings.TrimRight(endpoint, "/"),
+		resource: []kv{attr("service.name", service)},
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, k := range sortedKeys(resource) {
+		t.resource = append(t.resource, attr(k, resource[k]))
+	}
+	rand.Read(t.traceID[:])
+	return t, nil
+}
+
+// TraceID returns the hex trace id shared by all spans of this run.
+func (t *Tracer) TraceID() string {
+	if t == nil {
+		return ""
+	}
+	return hex.EncodeToString(t.traceID[:])
+}
+
+type ctxKey struct{}
+
+// Span is an in-flight span; End records it.
+type Span struct {
+	t    *Tracer
+	data spanData
+	once sync.Once
+}
+
+// Start begins a span named name, child of the span in ctx if any.
+// attrs are alternating key, value pairs.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...any) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	var id [8]byte
+	rand.Read(id[:])
+	s := &Span{t: t, data: spanData{
+		TraceID:    hex.EncodeToString(t.traceID[:]),
+		SpanID:     hex.EncodeToString(id[:]),
+		Name:       name,
+		Kind:       1, // internal
+		Start:      nanos(time.Now()),
+		Attributes: kvs(attrs),
+	}}
+	if p, ok := ctx.Value(ctxKey{}).(*Span); ok && p != nil {
+		s.data.ParentSpanID = p.data.SpanID
+	}
+	return context.WithValue(ctx, ctxKey{}, s), s
+}
+
+// End finishes the span; a non-nil err marks it failed. Later calls are
+// no-ops.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		s.data.End = nanos(time.Now())
+		if err != nil {
+			s.data.Status = &status{Code: 2, Message: err.Error()}
+		}
+		s.t.mu.Lock()
+		s.t.spans = append(s.t.spans, s.data)
+		s.t.mu.Unlock()
+	})
+}
+
+// SetAttr adds an attribute to the span.
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.data.Attributes = append(s.data.Attributes, attr(key, value))
+}
+
+// Gauge records one gauge point now. attrs are alternating key, value pairs.
+func (t *Tracer) Gauge(name, unit string, v float64, attrs ...any) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.gauges = append(t.gauges, gauge{name: name, unit: unit, point: point{Time: nanos(time.Now()), Value: v, Attributes: kvs(attrs)}})
+	t.mu.Unlock()
+}
+
+// Flush exports the buffered spans and gauges and clears the buffer.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	spans, gauges := t.spans, t.gauges
+	t.spans, t.gauges = nil, nil
+	t.mu.Unlock()
+	scope := map[string]any{"name": scopeName}
+	res := map[string]any{"attributes": t.resource}
+	if len(spans) > 0 {
+		body := map[string]any{"resourceSpans": []any{map[string]any{
+			"resource":   res,
+			"scopeSpans": []any{map[string]any{"scope": scope, "spans": spans}},
+		}}}
+		if err := t.post(ctx, "/v1/traces", body); err != nil {
+			return err
+		}
+	}
+	if len(gauges) > 0 {
+		// 같은 이름의 점은 하나의 metric으로 묶는다
+		var order []string
+		byName := map[string]*metric{}
+		for _, g := range gauges {
+			m, ok := byName[g.name]
+			if !ok {
+				m = &metric{Name: g.name, Unit: g.unit}
+				byName[g.name] = m
+				order = append(order, g.name)
+			}
+			m.Gauge.DataPoints = append(m.Gauge.DataPoints, g.point)
+		}
+		ms := make([]*metric, len(order))
+		for i, n := range order {
+			ms[i] = byName[n]
+		}
+		body := map[string]any{"resourceMetrics": []any{map[string]any{
+			"resource":     res,
+			"scopeMetrics": []any{map[string]any{"scope": scope, "metrics": ms}},
+		}}}
+		if err := t.post(ctx, "/v1/metrics", body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Tracer) post(ctx context.Context, path string, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.base+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("otel self export: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otel self export: %s -> %s", path, resp.Status)
+	}
+	return nil
+}
+
+// --- OTLP/JSON 데이터 모델(필요한 부분만) ---
+
+type spanData struct {
+	TraceID      string  `json:"traceId"`
+	SpanID       string  `json:"spanId"`
+	ParentSpanID string  `json:"parentSpanId,omitempty"`
+	Name         string  `json:"name"`
+	Kind         int     `json:"kind"`
+	Start        string  `json:"startTimeUnixNano"`
+	End          string  `json:"endTimeUnixNano"`
+	Attributes   []kv    `json:"attributes,omitempty"`
+	Status       *status `json:"status,omitempty"`
+}
+
+type status struct {
+	Code    int    `json:"code"` // 2 = error
+	Message string `json:"message,omitempty"`
+}
+
+type gauge struct {
+	name, unit string
+	point      point
+}
+
+type point struct {
+	Time       string  `json:"timeUnixNano"`
+	Value      float64 `json:"asDouble"`
+	Attributes []kv    `json:"attributes,omitempty"`
+}
+
+type metric struct {
+	Name  string `json:"name"`
+	Unit  string `json:"unit,omitempty"`
+	Gauge struct {
+		DataPoints []point `json:"dataPoints"`
+	} `json:"gauge"`
+}
+
+type kv struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+func attr(k string, v any) kv {
+	switch x := v.(type) {
+	case string:
+		return kv{k, map[string]any{"stringValue": x}}
+	case bool:
+		return kv{k, map[string]any{"boolValue": x}}
+	case int:
+		return kv{k, map[string]any{"intValue": strconv.Itoa(x)}}
+	case int64:
+		return kv{k, map[string]any{"intValue": strconv.FormatInt(x, 10)}}
+	case float64:
+		return kv{k, map[string]any{"doubleValue": x}}
+	default:
+		return kv{k, map[string]any{"stringValue": fmt.Sprint(x)}}
+	}
+}
+
+func kvs(pairs []any) []kv {
+	var out []kv
+	for i := 0; i+1 < len(pairs); i += 2 {
+		out = append(out, attr(fmt.Sprint(pairs[i]), pairs[i+1]))
+	}
+	return out
+}
+
+func nanos(t time.Time) string { return strconv.FormatInt(t.UnixNano(), 10) }
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,218 @@
+// Package slo loads the shared SLO definition file (slo.yaml) consumed by
+// trace_bench, errorbudget and the rule/dashboard generators, so each SLO
+// is declared once.
+//
+// Schema (version 1):
+//
+//	version: 1
+//	slos:
+//	  - name: trace-export-availability
+//	    objective: 0.999          # (0,1)
+//	    window: 30d               # Prometheus duration
+//	    indicator:
+//	      query: sum(rate(errors[$window])) / sum(rate(total[$window]))
+//	  - name: ingest-availability
+//	    objective: 0.999
+//	    window: 30d
+//	    indicator:             # $not_synthetic drops bench traffic (-mark-header)
+//	      query: sum(rate(http_server_requests_total{code=~"5..",$not_synthetic}[$window])) / sum(rate(http_server_requests_total{$not_synthetic}[$window]))
+//	  - name: trace-export-latency
+//	    objective: 0.99
+//	    window: 7d
+//	    indicator:
+//	      bench_metric: p95_ms    # see BenchMetrics
+//	    threshold: 50             # bench_metric must stay <= threshold
+package slo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/internal/promapi"
+	"github.com/duri/trace_bench/internal/yamlite"
+	"github.com/duri/trace_bench/validate"
+)
+
+// Version is the supported schema version.
+const Version = 1
+
+// NotSynthetic is the matcher $not_synthetic expands to in indicator
+// queries: targets label requests marked by trace_bench -mark-header with
+// synthetic="true", and production SLOs exclude them so drills do not
+// spend the error budget.
+const NotSynthetic = `synthetic!="true"`
+
+// BenchMetrics lists the result metrics a bench SLO may reference.
+var BenchMetrics = []string{"p95_ms", "p99_ms", "error_rate", "size_kb", "mttr_seconds"}
+
+// File is a parsed slo.yaml.
+type File struct {
+	Version int   `json:"version"`
+	SLOs    []SLO `json:"slos"`
+}
+
+// SLO is one objective over a rolling window.
+type SLO struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Objective   float64   `json:"objective"`
+	Window      string    `json:"window"`
+	Indicator   Indicator `json:"indicator"`
+	Threshold   *float64  `json:"threshold,omitempty"`
+}
+
+// Indicator is either a Prometheus error-ratio query or a bench metric.
+type Indicator struct {
+	// Query는 error ratio(0..1) PromQL. $window는 평가 윈도, $not_synthetic은 NotSynthetic으로 치환
+	Query       string `json:"query,omitempty"`
+	BenchMetric string `json:"bench_metric,omitempty"`
+}
+
+// Load reads a .yaml/.yml or .json SLO file and validates it.
+func Load(path string) (*File, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = yamlite.UnmarshalJSON(b, &f)
+	default:
+		err = yamlite.Unmarshal(b, &f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Validate checks the schema version and every SLO.
+func (f *File) Validate() error {
+	if f.Version != Version {
+		return fmt.Errorf("unsupported slo version: %d (expected %d)", f.Version, Version)
+	}
+	seen := map[string]bool{}
+	for _, s := range f.SLOs {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("duplicate slo: %s", s.Name)
+		}
+		seen[s.Name] = true
+	}
+	return nil
+}
+
+// Validate checks one SLO definition.
+func (s SLO) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("slo without name")
+	}
+	if err := validate.Finite("slo "+s.Name+" objective", s.Objective); err != nil {
+		return err
+	}
+	if s.Objective <= 0 || s.Objective >= 1 {
+		return fmt.Errorf("slo %s: invalid objective: %v (expected (0,1))", s.Name, s.Objective)
+	}
+	if _, err := promapi.ParseDuration(s.Window); err != nil {
+		return fmt.Errorf("slo %s: %w", s.Name, err)
+	}
+	switch ind := s.Indicator; {
+	case ind.Query != "" && ind.BenchMetric != "":
+		return fmt.Errorf("slo %s: indicator must set exactly one of query, bench_metric", s.Name)
+	case ind.Query != "":
+		if !strings.Contains(ind.Query, "$window") {
+			return fmt.Errorf("slo %s: indicator query must use $window", s.Name)
+		}
+	case ind.BenchMetric != "":
+		if !validMetric(ind.BenchMetric) {
+			return fmt.Errorf("slo %s: invalid bench_metric: %s (expected %s)", s.Name, ind.BenchMetric, strings.Join(BenchMetrics, "|"))
+		}
+		if s.Threshold == nil {
+			return fmt.Errorf("slo %s: bench_metric requires threshold", s.Name)
+		}
+		if err := validate.Finite("slo "+s.Name+" threshold", *s.Threshold); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("slo %s: indicator must set query or bench_metric", s.Name)
+	}
+	return nil
+}
+
+func validMetric(m string) bool {
+	for _, v := range BenchMetrics {
+		if v == m {
+			return true
+		}
+	}
+	return false
+}
+
+// WindowDuration returns the parsed window.
+func (s SLO) WindowDuration() time.Duration {
+	d, _ := promapi.ParseDuration(s.Window)
+	return d
+}
+
+// ErrorBudget is the allowed bad fraction, 1 - objective.
+func (s SLO) ErrorBudget() float64 { return 1 - s.Objective }
+
+// QueryExpr returns the indicator query with $window set to w and
+// $not_synthetic set to NotSynthetic.
+func (s SLO) QueryExpr(w string) string {
+	return strings.NewReplacer("$window", w, "$not_synthetic", NotSynthetic).Replace(s.Indicator.Query)
+}
+
+// Queries returns the Prometheus-backed SLOs.
+func (f *File) Queries() []SLO {
+	var out []SLO
+	for _, s := range f.SLOs {
+		if s.Indicator.Query != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Bench returns the SLOs evaluated against bench results.
+func (f *File) Bench() []SLO {
+	var out []SLO
+	for _, s := range f.SLOs {
+		if s.Indicator.BenchMetric != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Check is the outcome of one bench SLO against a result.
+type Check struct {
+	Name      string  `json:"name"`
+	Metric    string  `json:"metric"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Pass      bool    `json:"pass"`
+	Missing   bool    `json:"missing,omitempty"` // 결과에 지표 없음(실패 처리)
+}
+
+// Evaluate checks the bench SLOs against metrics (see BenchMetrics).
+func Evaluate(slos []SLO, metrics map[string]float64) []Check {
+	var out []Check
+	for _, s := range slos {
+		c := Check{Name: s.Name, Metric: s.Indicator.BenchMetric, Threshold: *s.Threshold}
+		v, ok := metrics[c.Metric]
+		c.Value, c.Missing = v, !ok
+		c.Pass = ok && v <= c.Threshold
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Median returns the median of xs (0 if empty). xs is not modified.
+func Median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
+
+// MAD returns the median absolute deviation of xs around m, scaled by
+// 1.4826 so it estimates the standard deviation of normal data.
+func MAD(xs []float64, m float64) float64 {
+	dev := make([]float64, len(xs))
+	for i, x := range xs {
+		dev[i] = math.Abs(x - m)
+	}
+	return 1.4826 * Median(dev)
+}
+
+// CUSUM runs a one-sided (upward) tabular CUSUM over the standardized
+// series z with allowance k and returns the statistic after each point.
+// S_i = max(0, S_{i-1} + z_i - k); a sustained upward shift drives S past
+// the decision interval h while isolated spikes decay back to 0.
+func CUSUM(z []float64, k float64) []float64 {
+	out := make([]float64, len(z))
+	var s float64
+	for i, v := range z {
+		s = math.Max(0, s+v-k)
+		out[i] = s
+	}
+	return out
+}
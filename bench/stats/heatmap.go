@@ -0,0 +1,188 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Heatmap counts latency samples per (time interval × latency bucket) so
+// latency evolution across a run can be drawn by Grafana's heatmap panel.
+// Rows are keyed by absolute interval, so heatmaps from different workers or
+// soak iterations merge by simple addition.
+type Heatmap struct {
+	Interval time.Duration
+	Bounds   []float64 // bucket upper bounds in ms (ascending); +Inf is implicit
+	base     int64     // absolute interval index of rows[0]
+	rows     [][]uint64
+}
+
+// DefaultHeatmapBounds returns exponential bounds from 1µs to ~16s.
+func DefaultHeatmapBounds() []float64 {
+	var b []float64
+	for v := 0.001; v < 20000; v *= 2 {
+		b = append(b, Round5(v))
+	}
+	return b
+}
+
+// NewHeatmap returns an empty heatmap.
+func NewHeatmap(interval time.Duration, bounds []float64) *Heatmap {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &Heatmap{Interval: interval, Bounds: bounds}
+}
+
+func (h *Heatmap) row(idx int64) []uint64 {
+	if len(h.rows) == 0 {
+		h.base = idx
+	}
+	for idx < h.base {
+		h.rows = append([][]uint64{make([]uint64, len(h.Bounds)+1)}, h.rows...)
+		h.base--
+	}
+	for idx >= h.base+int64(len(h.rows)) {
+		h.rows = append(h.rows, make([]uint64, len(h.Bounds)+1))
+	}
+	return h.rows[idx-h.base]
+}
+
+// Record counts one sample of latency d observed at time at. It allocates
+// only when a new interval row starts.
+func (h *Heatmap) Record(at time.Time, d time.Duration) {
+	ms := float64(d) / float64(time.Millisecond)
+	b := sort.SearchFloat64s(h.Bounds, ms) // le 의미: ms <= Bounds[b]
+	h.row(at.UnixNano() / int64(h.Interval))[b]++
+}
+
+// Merge adds o's counts; o must use the same interval and bounds.
+func (h *Heatmap) Merge(o *Heatmap) {
+	for i, r := range o.rows {
+		dst := h.row(o.base + int64(i))
+		for b, c := range r {
+			dst[b] += c
+		}
+	}
+}
+
+// HeatmapRow is one time interval of a heatmap.
+type HeatmapRow struct {
+	Time   time.Time `json:"time"`
+	Counts []uint64  `json:"counts"`
+}
+
+// Rows returns the intervals in time order.
+func (h *Heatmap) Rows() []HeatmapRow {
+	out := make([]HeatmapRow, len(h.rows))
+	for i, r := range h.rows {
+		out[i] = HeatmapRow{Time: time.Unix(0, (h.base+int64(i))*int64(h.Interval)).UTC(), Counts: r}
+	}
+	return out
+}
+
+// MarshalJSON renders the heatmap as
+// {"interval_ms", "buckets_le_ms", "rows":[{"time","counts"}]}; each row has
+// one more count than buckets_le_ms, the last being the +Inf bucket.
+func (h *Heatmap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		IntervalMs float64      `json:"interval_ms"`
+		BucketsLe  []float64    `json:"buckets_le_ms"`
+		Rows       []HeatmapRow `json:"rows"`
+	}{float64(h.Interval) / float64(time.Millisecond), h.Bounds, h.Rows()})
+}
+
+// UnmarshalJSON reads the MarshalJSON form back, e.g. from a result file.
+func (h *Heatmap) UnmarshalJSON(b []byte) error {
+	var v struct {
+		IntervalMs float64      `json:"interval_ms"`
+		BucketsLe  []float64    `json:"buckets_le_ms"`
+		Rows       []HeatmapRow `json:"rows"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*h = Heatmap{Interval: time.Duration(v.IntervalMs * float64(time.Millisecond)), Bounds: v.BucketsLe}
+	if h.Interval <= 0 {
+		return fmt.Errorf("heatmap: invalid interval_ms %v", v.IntervalMs)
+	}
+	for _, r := range v.Rows {
+		if len(r.Counts) != len(h.Bounds)+1 {
+			return fmt.Errorf("heatmap: row %s has %d counts, want %d", r.Time.Format(time.RFC3339), len(r.Counts), len(h.Bounds)+1)
+		}
+		dst := h.row(r.Time.UnixNano() / int64(h.Interval))
+		for b, c := range r.Counts {
+			dst[b] += c
+		}
+	}
+	return nil
+}
+
+// Totals returns the counts per bucket summed over all intervals.
+func (h *Heatmap) Totals() []uint64 {
+	out := make([]uint64, len(h.Bounds)+1)
+	for _, r := range h.rows {
+		for b, c := range r {
+			out[b] += c
+		}
+	}
+	return out
+}
+
+// BucketQuantile returns the upper bound (ms) of the bucket holding the q
+// quantile of counts (bucketed per bounds, last = +Inf); the +Inf bucket
+// reports the largest finite bound. It returns 0 for no samples.
+func BucketQuantile(bounds []float64, counts []uint64, q float64) float64 {
+	var total uint64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(total)))
+	var cum uint64
+	for b, c := range counts {
+		cum += c
+		if cum >= rank && c > 0 {
+			if b < len(bounds) {
+				return bounds[b]
+			}
+			break
+		}
+	}
+	return bounds[len(bounds)-1]
+}
+
+// InterpolatedBucketQuantile is BucketQuantile interpolated linearly within
+// the bucket, like PromQL histogram_quantile (the first bucket starts at
+// 0; the +Inf bucket reports the largest finite bound). ok is false for no
+// samples.
+func InterpolatedBucketQuantile(bounds []float64, counts []uint64, q float64) (v float64, ok bool) {
+	var total uint64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return 0, false
+	}
+	rank := q * float64(total)
+	var cum float64
+	for b, c := range counts {
+		if c == 0 || cum+float64(c) < rank {
+			cum += float64(c)
+			continue
+		}
+		if b >= len(bounds) {
+			return bounds[len(bounds)-1], true
+		}
+		lower := 0.0
+		if b > 0 {
+			lower = bounds[b-1]
+		}
+		return lower + (bounds[b]-lower)*(rank-cum)/float64(c), true
+	}
+	return bounds[len(bounds)-1], true
+}
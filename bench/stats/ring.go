@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"sort"
+	"time"
+)
+
+// Ring is an exact latency buffer owned by a single worker. Record does not
+// allocate while the preallocated capacity lasts; past it the buffer grows
+// like append instead of dropping samples, so an undersized estimate (e.g. a
+// worker taking more than its share of batch processor batches) costs an
+// allocation, never accuracy. Rings are not synchronized: each worker writes
+// its own ring and they are merged once all workers have finished.
+type Ring struct {
+	buf []int64
+}
+
+// NewRing preallocates a ring for capacity samples.
+func NewRing(capacity int) *Ring {
+	return &Ring{buf: make([]int64, 0, max(capacity, 1))}
+}
+
+// Record stores one latency sample.
+func (r *Ring) Record(d time.Duration) {
+	r.buf = append(r.buf, int64(d))
+}
+
+// Count returns the number of samples recorded.
+func (r *Ring) Count() int { return len(r.buf) }
+
+// Len returns the number of samples held; a ring keeps every sample, so it
+// always equals Count.
+func (r *Ring) Len() int { return len(r.buf) }
+
+// MergeMs merges the rings into one ascending slice of milliseconds.
+func MergeMs(rings ...*Ring) []float64 {
+	total := 0
+	for _, r := range rings {
+		total += r.Len()
+	}
+	out := make([]float64, 0, total)
+	for _, r := range rings {
+		for _, ns := range r.buf {
+			out = append(out, float64(ns)/float64(time.Millisecond))
+		}
+	}
+	sort.Float64s(out)
+	return out
+}
+
+// Quantile returns the exact q-th quantile in milliseconds of the samples
+// currently held.
+func (r *Ring) Quantile(q float64) float64 {
+	return Percentile(MergeMs(r), q)
+}
+
+// Merge appends other's samples, growing the ring if needed.
+func (r *Ring) Merge(other Sketch) error {
+	o, ok := other.(*Ring)
+	if !ok {
+		return mergeKindError(r, other)
+	}
+	r.buf = append(r.buf, o.buf...)
+	return nil
+}
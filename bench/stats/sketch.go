@@ -0,0 +1,60 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+)
+
+// Quantile sketch kinds selectable via -quantile-sketch.
+const (
+	SketchExact   = "exact"
+	SketchHDR     = "hdr"
+	SketchTDigest = "tdigest"
+)
+
+// SketchKinds lists the supported sketch kinds.
+var SketchKinds = []string{SketchExact, SketchHDR, SketchTDigest}
+
+// Sketch collects latency samples for one worker. Record must not allocate;
+// per-worker sketches are merged once all workers have finished.
+type Sketch interface {
+	Record(d time.Duration)
+	Count() int
+	// Quantile returns the q-th quantile in milliseconds.
+	Quantile(q float64) float64
+	// Merge folds other (of the same kind) into the receiver.
+	Merge(other Sketch) error
+}
+
+// NewSketch returns a sketch of the given kind. capacity sizes the exact
+// sketch; the streaming sketches use fixed memory regardless of run length.
+func NewSketch(kind string, capacity int) (Sketch, error) {
+	switch kind {
+	case "", SketchExact:
+		return NewRing(capacity), nil
+	case SketchHDR:
+		return NewHDR(), nil
+	case SketchTDigest:
+		return NewTDigest(DefaultCompression), nil
+	default:
+		return nil, fmt.Errorf("invalid quantile-sketch: %s", kind)
+	}
+}
+
+// MergeSketches merges sketches into the first one and returns it.
+func MergeSketches(sketches ...Sketch) (Sketch, error) {
+	if len(sketches) == 0 {
+		return nil, fmt.Errorf("no sketches to merge")
+	}
+	dst := sketches[0]
+	for _, s := range sketches[1:] {
+		if err := dst.Merge(s); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
+
+func mergeKindError(dst, src Sketch) error {
+	return fmt.Errorf("cannot merge %T into %T", src, dst)
+}
@@ -0,0 +1,164 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+// latencies는 지수 분포(평균 10ms) 표본. 꼬리가 길어 p99 오차가 드러난다
+func latencies(n int) []time.Duration {
+	rng := rand.New(rand.NewSource(1))
+	ds := make([]time.Duration, n)
+	for i := range ds {
+		ds[i] = time.Duration(rng.ExpFloat64() * float64(10*time.Millisecond))
+	}
+	return ds
+}
+
+func exactMs(ds []time.Duration) []float64 {
+	ms := make([]float64, len(ds))
+	for i, d := range ds {
+		ms[i] = float64(d) / float64(time.Millisecond)
+	}
+	sort.Float64s(ms)
+	return ms
+}
+
+func TestSketchQuantileError(t *testing.T) {
+	ds := latencies(100_000)
+	exact := exactMs(ds)
+	tests := []struct {
+		kind   string
+		relErr float64
+	}{
+		{SketchExact, 0},
+		{SketchHDR, 0.005}, // 하위 버킷 256개: 버킷 중앙값 오차 ~0.2%
+		{SketchTDigest, 0.02},
+	}
+	for _, tt := range tests {
+		sk, err := NewSketch(tt.kind, len(ds))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range ds {
+			sk.Record(d)
+		}
+		if sk.Count() != len(ds) {
+			t.Errorf("%s: Count() = %d, want %d", tt.kind, sk.Count(), len(ds))
+		}
+		for _, q := range []float64{0.5, 0.95, 0.99} {
+			want := Percentile(exact, q)
+			got := sk.Quantile(q)
+			if math.Abs(got-want) > tt.relErr*want+1e-9 {
+				t.Errorf("%s: Quantile(%v) = %.4f, want %.4f ±%.1f%%", tt.kind, q, got, want, tt.relErr*100)
+			}
+		}
+	}
+}
+
+func TestSketchMerge(t *testing.T) {
+	ds := latencies(40_000)
+	for _, kind := range SketchKinds {
+		whole, _ := NewSketch(kind, len(ds))
+		shards := make([]Sketch, 4)
+		for i := range shards {
+			shards[i], _ = NewSketch(kind, len(ds)/len(shards))
+		}
+		for i, d := range ds {
+			whole.Record(d)
+			shards[i%len(shards)].Record(d)
+		}
+		merged, err := MergeSketches(shards...)
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		if merged.Count() != whole.Count() {
+			t.Errorf("%s: merged Count() = %d, want %d", kind, merged.Count(), whole.Count())
+		}
+		for _, q := range []float64{0.5, 0.95, 0.99} {
+			want, got := whole.Quantile(q), merged.Quantile(q)
+			// 병합은 exact/hdr에선 손실이 없고, t-digest는 재압축 오차만 허용
+			tol := 0.0
+			if kind == SketchTDigest {
+				tol = 0.02 * want
+			}
+			if math.Abs(got-want) > tol+1e-9 {
+				t.Errorf("%s: merged Quantile(%v) = %.4f, want %.4f", kind, q, got, want)
+			}
+		}
+	}
+}
+
+func TestSketchMergeKindMismatch(t *testing.T) {
+	if err := NewHDR().Merge(NewRing(1)); err == nil {
+		t.Error("HDR.Merge(*Ring) = nil, want error")
+	}
+	if _, err := MergeSketches(); err == nil {
+		t.Error("MergeSketches() = nil, want error")
+	}
+	if _, err := NewSketch("p2", 1); err == nil {
+		t.Error(`NewSketch("p2") = nil, want error`)
+	}
+}
+
+func TestRingGrowsPastCapacity(t *testing.T) {
+	r := NewRing(4)
+	for i := 1; i <= 10; i++ {
+		r.Record(time.Duration(i) * time.Millisecond)
+	}
+	// 용량을 넘어도 앞 표본을 덮어쓰지 않는다
+	if r.Count() != 10 || r.Len() != 10 {
+		t.Fatalf("Count, Len = %d, %d, want 10, 10", r.Count(), r.Len())
+	}
+	if got := r.Quantile(0); got != 1 {
+		t.Errorf("Quantile(0) = %v, want 1 (oldest sample kept)", got)
+	}
+	if got := r.Quantile(1); got != 10 {
+		t.Errorf("Quantile(1) = %v, want 10", got)
+	}
+	o := NewRing(1)
+	o.Record(20 * time.Millisecond)
+	o.Record(30 * time.Millisecond)
+	if err := r.Merge(o); err != nil {
+		t.Fatal(err)
+	}
+	if r.Count() != 12 || r.Quantile(1) != 30 {
+		t.Errorf("after Merge: Count() = %d, max = %v, want 12, 30", r.Count(), r.Quantile(1))
+	}
+	if ms := MergeMs(r, o); len(ms) != 14 || !sort.Float64sAreSorted(ms) {
+		t.Errorf("MergeMs = %v, want 14 ascending samples", ms)
+	}
+}
+
+func TestInterpolatedBucketQuantile(t *testing.T) {
+	bounds := []float64{10, 20, 40}
+	tests := []struct {
+		name   string
+		counts []uint64
+		q      float64
+		want   float64
+		ok     bool
+	}{
+		{name: "empty", counts: []uint64{0, 0, 0, 0}, q: 0.5},
+		// 첫 버킷은 0부터 선형 보간
+		{name: "first bucket", counts: []uint64{10, 0, 0, 0}, q: 0.5, want: 5, ok: true},
+		{name: "middle bucket", counts: []uint64{10, 10, 0, 0}, q: 0.75, want: 15, ok: true},
+		{name: "skips empty buckets", counts: []uint64{10, 0, 10, 0}, q: 0.75, want: 30, ok: true},
+		{name: "bucket edge", counts: []uint64{10, 10, 0, 0}, q: 0.5, want: 10, ok: true},
+		// +Inf 버킷은 가장 큰 유한 경계
+		{name: "+Inf bucket", counts: []uint64{1, 0, 0, 9}, q: 0.95, want: 40, ok: true},
+	}
+	for _, tt := range tests {
+		got, ok := InterpolatedBucketQuantile(bounds, tt.counts, tt.q)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: InterpolatedBucketQuantile(%v, %v) = %v, %v, want %v, %v", tt.name, tt.counts, tt.q, got, ok, tt.want, tt.ok)
+		}
+		// 보간값은 BucketQuantile(버킷 상한)을 넘지 않는다
+		if ok && got > BucketQuantile(bounds, tt.counts, tt.q)+1e-9 {
+			t.Errorf("%s: interpolated %v above bucket bound %v", tt.name, got, BucketQuantile(bounds, tt.counts, tt.q))
+		}
+	}
+}
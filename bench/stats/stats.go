@@ -0,0 +1,47 @@
+// Package stats holds the numeric helpers shared by the trace_bench engine
+// and its output formats.
+package stats
+
+import "math"
+
+// Round2 rounds x to two decimal places (ms, KB 단위 출력용).
+func Round2(x float64) float64 { return float64(int(x*100+0.5)) / 100 }
+
+// Round5 rounds x to five decimal places (비율 출력용).
+func Round5(x float64) float64 { return float64(int(x*100000+0.5)) / 100000 }
+
+// Percentile returns the q-th quantile (0<=q<=1) of sorted using the
+// nearest-rank method. sorted must be in ascending order.
+func Percentile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// Slope returns the least-squares slope of ys over xs (0 if undefined).
+func Slope(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	if len(xs) < 2 || len(xs) != len(ys) {
+		return 0
+	}
+	var sx, sy, sxx, sxy float64
+	for i := range xs {
+		sx += xs[i]
+		sy += ys[i]
+		sxx += xs[i] * xs[i]
+		sxy += xs[i] * ys[i]
+	}
+	den := n*sxx - sx*sx
+	if den == 0 {
+		return 0
+	}
+	return (n*sxy - sx*sy) / den
+}
@@ -0,0 +1,171 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultCompression is the t-digest compression (δ). Larger values keep
+// more centroids and lower the tail error.
+const DefaultCompression = 200
+
+// TDigest is a merging t-digest (Dunning) over millisecond values. Samples
+// are buffered in a preallocated slice and folded into centroids when the
+// buffer fills, so Record does not allocate.
+type TDigest struct {
+	delta    float64
+	cs, next []centroid
+	buf      []float64
+	n        int
+}
+
+type centroid struct {
+	mean, weight float64
+}
+
+// NewTDigest returns an empty digest with compression delta.
+func NewTDigest(delta float64) *TDigest {
+	size := int(math.Ceil(delta)) * 2
+	return &TDigest{
+		delta: delta,
+		cs:    make([]centroid, 0, size+1),
+		next:  make([]centroid, 0, size+1),
+		buf:   make([]float64, 0, size*5),
+	}
+}
+
+// Record adds d.
+func (t *TDigest) Record(d time.Duration) {
+	t.buf = append(t.buf, float64(d)/float64(time.Millisecond))
+	t.n++
+	if len(t.buf) == cap(t.buf) {
+		t.compress()
+	}
+}
+
+// Count returns the number of recorded samples.
+func (t *TDigest) Count() int { return t.n }
+
+// k1 scale function: 꼬리(q→0,1)에서 centroid를 작게 유지
+func (t *TDigest) k(q float64) float64 {
+	return t.delta / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+func (t *TDigest) compress() {
+	if len(t.buf) == 0 {
+		return
+	}
+	sort.Float64s(t.buf)
+	total := float64(0)
+	for _, c := range t.cs {
+		total += c.weight
+	}
+	total += float64(len(t.buf))
+
+	t.next = t.next[:0]
+	i, j := 0, 0
+	pop := func() centroid {
+		if j >= len(t.buf) || (i < len(t.cs) && t.cs[i].mean <= t.buf[j]) {
+			i++
+			return t.cs[i-1]
+		}
+		j++
+		return centroid{t.buf[j-1], 1}
+	}
+	cur := pop()
+	soFar := 0.0
+	kLo := t.k(0)
+	for i < len(t.cs) || j < len(t.buf) {
+		c := pop()
+		q := (soFar + cur.weight + c.weight) / total
+		if t.k(q)-kLo <= 1 {
+			w := cur.weight + c.weight
+			cur.mean += (c.mean - cur.mean) * c.weight / w
+			cur.weight = w
+			continue
+		}
+		soFar += cur.weight
+		kLo = t.k(soFar / total)
+		t.next = append(t.next, cur)
+		cur = c
+	}
+	t.next = append(t.next, cur)
+	t.cs, t.next = t.next, t.cs
+	t.buf = t.buf[:0]
+}
+
+// Quantile returns the q-th quantile in milliseconds, interpolating
+// between centroid midpoints.
+func (t *TDigest) Quantile(q float64) float64 {
+	t.compress()
+	if len(t.cs) == 0 {
+		return 0
+	}
+	if len(t.cs) == 1 {
+		return t.cs[0].mean
+	}
+	total := float64(t.n)
+	target := q * total
+	cum := 0.0
+	for i, c := range t.cs {
+		mid := cum + c.weight/2
+		if target < mid {
+			if i == 0 {
+				return c.mean
+			}
+			p := t.cs[i-1]
+			pmid := cum - p.weight/2
+			return p.mean + (c.mean-p.mean)*(target-pmid)/(mid-pmid)
+		}
+		cum += c.weight
+	}
+	return t.cs[len(t.cs)-1].mean
+}
+
+// Merge folds other's centroids and buffered samples into t.
+func (t *TDigest) Merge(other Sketch) error {
+	o, ok := other.(*TDigest)
+	if !ok {
+		return mergeKindError(t, other)
+	}
+	t.compress()
+	o.compress()
+	if len(o.cs) == 0 {
+		return nil
+	}
+	// 병합은 실행 종료 후 한 번만 일어나므로 할당 허용
+	merged := append(append([]centroid(nil), t.cs...), o.cs...)
+	sort.Slice(merged, func(a, b int) bool { return merged[a].mean < merged[b].mean })
+	t.cs = merged
+	t.n += o.n
+	// centroid 수를 δ 기준으로 다시 줄인다
+	t.recompress()
+	return nil
+}
+
+func (t *TDigest) recompress() {
+	total := 0.0
+	for _, c := range t.cs {
+		total += c.weight
+	}
+	out := make([]centroid, 0, len(t.cs))
+	cur := t.cs[0]
+	soFar := 0.0
+	kLo := t.k(0)
+	for _, c := range t.cs[1:] {
+		q := (soFar + cur.weight + c.weight) / total
+		if t.k(q)-kLo <= 1 {
+			w := cur.weight + c.weight
+			cur.mean += (c.mean - cur.mean) * c.weight / w
+			cur.weight = w
+			continue
+		}
+		soFar += cur.weight
+		kLo = t.k(soFar / total)
+		out = append(out, cur)
+		cur = c
+	}
+	t.cs = append(out, cur)
+	t.next = make([]centroid, 0, cap(t.cs))
+}
@@ -0,0 +1,128 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// timelineBounds는 1µs~20s 구간의 1.1배 로그 버킷(ms, 분위수 오차 10% 이내)
+var timelineBounds = func() []float64 {
+	var b []float64
+	for v := 0.001; v < 20000; v *= 1.1 {
+		b = append(b, v)
+	}
+	return b
+}()
+
+// Timeline aggregates samples per fixed interval (count, failures, bytes
+// and a latency histogram), e.g. per-second series for remote-write. Like
+// Heatmap, rows are keyed by absolute interval and merge by addition.
+type Timeline struct {
+	Interval time.Duration
+	base     int64
+	rows     []timelineRow
+}
+
+type timelineRow struct {
+	count, failed, bytes uint64
+	hist                 []uint32
+}
+
+// TimelinePoint is one interval of a timeline.
+type TimelinePoint struct {
+	Time   time.Time
+	Count  uint64
+	Failed uint64
+	Bytes  uint64
+	P50ms  float64
+	P95ms  float64
+	P99ms  float64
+}
+
+// NewTimeline returns an empty timeline.
+func NewTimeline(interval time.Duration) *Timeline {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &Timeline{Interval: interval}
+}
+
+func (t *Timeline) row(idx int64) *timelineRow {
+	if len(t.rows) == 0 {
+		t.base = idx
+	}
+	for idx < t.base {
+		t.rows = append([]timelineRow{{}}, t.rows...)
+		t.base--
+	}
+	for idx >= t.base+int64(len(t.rows)) {
+		t.rows = append(t.rows, timelineRow{})
+	}
+	r := &t.rows[idx-t.base]
+	if r.hist == nil {
+		r.hist = make([]uint32, len(timelineBounds)+1)
+	}
+	return r
+}
+
+// Record counts one sample observed at time at. It allocates only when a
+// new interval row starts.
+func (t *Timeline) Record(at time.Time, d time.Duration, failed bool, bytes int) {
+	r := t.row(at.UnixNano() / int64(t.Interval))
+	r.count++
+	if failed {
+		r.failed++
+	}
+	r.bytes += uint64(bytes)
+	r.hist[sort.SearchFloat64s(timelineBounds, float64(d)/float64(time.Millisecond))]++
+}
+
+// Merge adds o's rows; o must use the same interval.
+func (t *Timeline) Merge(o *Timeline) {
+	for i, src := range o.rows {
+		if src.hist == nil {
+			continue
+		}
+		dst := t.row(o.base + int64(i))
+		dst.count += src.count
+		dst.failed += src.failed
+		dst.bytes += src.bytes
+		for b, c := range src.hist {
+			dst.hist[b] += c
+		}
+	}
+}
+
+// Points returns the non-empty intervals in time order.
+func (t *Timeline) Points() []TimelinePoint {
+	var out []TimelinePoint
+	for i, r := range t.rows {
+		if r.count == 0 {
+			continue
+		}
+		out = append(out, TimelinePoint{
+			Time:   time.Unix(0, (t.base+int64(i))*int64(t.Interval)).UTC(),
+			Count:  r.count,
+			Failed: r.failed,
+			Bytes:  r.bytes,
+			P50ms:  Round5(histQuantile(r.hist, r.count, 0.50)),
+			P95ms:  Round5(histQuantile(r.hist, r.count, 0.95)),
+			P99ms:  Round5(histQuantile(r.hist, r.count, 0.99)),
+		})
+	}
+	return out
+}
+
+// nearest-rank 버킷의 상한(+Inf 버킷은 마지막 유한 상한)
+func histQuantile(hist []uint32, n uint64, q float64) float64 {
+	rank := uint64(math.Ceil(q * float64(n)))
+	var seen uint64
+	for b, c := range hist {
+		seen += uint64(c)
+		if seen >= max(rank, 1) {
+			return timelineBounds[min(b, len(timelineBounds)-1)]
+		}
+	}
+	return 0
+}
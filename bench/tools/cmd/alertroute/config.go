@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/duri/trace_bench/internal/yamlite"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// Config is the part of alertmanager.yml that routing depends on.
+type Config struct {
+	Global            map[string]any   `json:"global"`
+	Route             *Route           `json:"route"`
+	InhibitRules      []InhibitRule    `json:"inhibit_rules"`
+	Receivers         []map[string]any `json:"receivers"`
+	Templates         []string         `json:"templates"`
+	TimeIntervals     []namedInterval  `json:"time_intervals"`
+	MuteTimeIntervals []namedInterval  `json:"mute_time_intervals"`
+}
+
+type namedInterval struct {
+	Name string `json:"name"`
+}
+
+// Route is one node of the routing tree.
+type Route struct {
+	Receiver            string            `json:"receiver"`
+	GroupBy             []string          `json:"group_by"`
+	Continue            bool              `json:"continue"`
+	Match               map[string]string `json:"match"`
+	MatchRE             map[string]string `json:"match_re"`
+	Matchers            []string          `json:"matchers"`
+	Routes              []*Route          `json:"routes"`
+	GroupWait           string            `json:"group_wait"`
+	GroupInterval       string            `json:"group_interval"`
+	RepeatInterval      string            `json:"repeat_interval"`
+	MuteTimeIntervals   []string          `json:"mute_time_intervals"`
+	ActiveTimeIntervals []string          `json:"active_time_intervals"`
+
+	matchers []matcher // Match/MatchRE/Matchers를 합친 것(validate에서 채움)
+}
+
+// InhibitRule mutes target alerts while a source alert fires with the
+// same values of the Equal labels.
+type InhibitRule struct {
+	SourceMatch    map[string]string `json:"source_match"`
+	SourceMatchRE  map[string]string `json:"source_match_re"`
+	SourceMatchers []string          `json:"source_matchers"`
+	TargetMatch    map[string]string `json:"target_match"`
+	TargetMatchRE  map[string]string `json:"target_match_re"`
+	TargetMatchers []string          `json:"target_matchers"`
+	Equal          []string          `json:"equal"`
+
+	source, target []matcher
+}
+
+func load(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := yamlite.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Problems are the validation findings; errors make Alertmanager reject
+// the config or route wrongly, warnings are likely mistakes.
+type Problems struct {
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+	// Verdict is fail_slo with errors, pass_with_warnings with only
+	// warnings (pkg/resultenv).
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+func (p *Problems) errorf(format string, a ...any) {
+	p.Errors = append(p.Errors, fmt.Sprintf(format, a...))
+}
+
+func (p *Problems) warnf(format string, a ...any) {
+	p.Warnings = append(p.Warnings, fmt.Sprintf(format, a...))
+}
+
+var (
+	labelRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	// Alertmanager(model.ParseDuration)는 1h30m 같은 복합 단위를 받는다
+	amDurationRe = regexp.MustCompile(`^(\d+(ms|s|m|h|d|w|y))+$`)
+	envRefRe     = regexp.MustCompile(`\$\{?[A-Za-z_][A-Za-z0-9_]*\}?`)
+)
+
+// validate는 matcher를 컴파일해 Route/InhibitRule에 채우고 문제를 모은다
+func (c *Config) validate() Problems {
+	var p Problems
+	receivers := map[string]bool{}
+	for i, r := range c.Receivers {
+		name, _ := r["name"].(string)
+		switch {
+		case name == "":
+			p.errorf("receivers[%d]: missing name", i)
+		case receivers[name]:
+			p.errorf("receivers[%d]: duplicate receiver %q", i, name)
+		}
+		receivers[name] = true
+		// Alertmanager는 설정 파일의 환경변수를 치환하지 않는다
+		for _, ref := range envRefs(r) {
+			p.warnf("receiver %q: %s is sent literally (alertmanager does not expand environment variables)", name, ref)
+		}
+	}
+	intervals := map[string]bool{}
+	for _, t := range append(append([]namedInterval{}, c.TimeIntervals...), c.MuteTimeIntervals...) {
+		intervals[t.Name] = true
+	}
+
+	used := map[string]bool{}
+	if c.Route == nil {
+		p.errorf("no route configured")
+	} else {
+		if c.Route.Receiver == "" {
+			p.errorf("route: root route must have a receiver")
+		}
+		if len(c.Route.Match)+len(c.Route.MatchRE)+len(c.Route.Matchers) > 0 {
+			p.errorf("route: root route must not have any matchers")
+		}
+		c.Route.walk("route", func(path string, r *Route) {
+			var err error
+			if r.matchers, err = compileMatchers(r.Match, r.MatchRE, r.Matchers); err != nil {
+				p.errorf("%s: %v", path, err)
+			}
+			if r.Receiver != "" {
+				used[r.Receiver] = true
+				if !receivers[r.Receiver] {
+					p.errorf("%s: undefined receiver %q", path, r.Receiver)
+				}
+			}
+			for _, l := range r.GroupBy {
+				if l != "..." && !labelRe.MatchString(l) {
+					p.errorf("%s: invalid group_by label %q", path, l)
+				}
+			}
+			for _, d := range [][2]string{{"group_wait", r.GroupWait}, {"group_interval", r.GroupInterval}, {"repeat_interval", r.RepeatInterval}} {
+				if d[1] != "" && !amDurationRe.MatchString(d[1]) {
+					p.errorf("%s: invalid %s %q", path, d[0], d[1])
+				}
+			}
+			for _, t := range append(append([]string{}, r.MuteTimeIntervals...), r.ActiveTimeIntervals...) {
+				if !intervals[t] {
+					p.errorf("%s: undefined time interval %q", path, t)
+				}
+			}
+		})
+	}
+	for name := range receivers {
+		if !used[name] {
+			p.warnf("receiver %q is not used by any route", name)
+		}
+	}
+	for i := range c.InhibitRules {
+		ir := &c.InhibitRules[i]
+		var err error
+		if ir.source, err = compileMatchers(ir.SourceMatch, ir.SourceMatchRE, ir.SourceMatchers); err != nil {
+			p.errorf("inhibit_rules[%d]: source: %v", i, err)
+		}
+		if ir.target, err = compileMatchers(ir.TargetMatch, ir.TargetMatchRE, ir.TargetMatchers); err != nil {
+			p.errorf("inhibit_rules[%d]: target: %v", i, err)
+		}
+		for _, l := range ir.Equal {
+			if !labelRe.MatchString(l) {
+				p.errorf("inhibit_rules[%d]: invalid equal label %q", i, l)
+			}
+		}
+		if len(ir.source) == 0 || len(ir.target) == 0 {
+			p.warnf("inhibit_rules[%d]: empty source or target matchers match every alert", i)
+		}
+	}
+	sort.Strings(p.Warnings)
+	p.Verdict = resultenv.Judge(p.Errors, p.Warnings)
+	return p
+}
+
+// walk는 경로 이름(route.routes[0]...)과 함께 전위 순회한다
+func (r *Route) walk(path string, fn func(string, *Route)) {
+	fn(path, r)
+	for i, c := range r.Routes {
+		c.walk(fmt.Sprintf("%s.routes[%d]", path, i), fn)
+	}
+}
+
+// envRefs는 receiver 설정 값 안의 ${VAR}/$VAR 참조를 찾는다
+func envRefs(v any) []string {
+	var out []string
+	switch t := v.(type) {
+	case string:
+		out = append(out, envRefRe.FindAllString(t, -1)...)
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			out = append(out, envRefs(t[k])...)
+		}
+	case []any:
+		for _, e := range t {
+			out = append(out, envRefs(e)...)
+		}
+	}
+	return out
+}
+
+// integrations는 receiver의 *_configs 종류(slack, webhook, ...)
+func integrations(r map[string]any) []string {
+	var out []string
+	for k, v := range r {
+		if n, ok := strings.CutSuffix(k, "_configs"); ok {
+			if l, ok := v.([]any); ok && len(l) > 0 {
+				out = append(out, n)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,151 @@
+// Command alertroute validates alertmanager.yml and simulates how an alert
+// with a given label set is routed, so drills do not fail on routing
+// mistakes.
+//
+//	alertroute check -config ../alertmanager/alertmanager.yml
+//	alertroute simulate severity=critical team=duri
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: alertroute <command> [flags]\n\ncommands:\n  check                    validate the config\n  simulate key=value ...   print the receivers and inhibitions for a label set")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "check":
+		check(os.Args[2:])
+	case "simulate":
+		simulate(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func commonFlags(name string) (*flag.FlagSet, *string, *bool) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	path := fs.String("config", "../alertmanager/alertmanager.yml", "Alertmanager configuration")
+	jsonOut := fs.Bool("json", false, "print the result as JSON")
+	return fs, path, jsonOut
+}
+
+// loadValid는 설정을 읽고 검증하며, 오류가 있으면 출력 후 종료한다
+func loadValid(path string) (*Config, Problems) {
+	c, err := load(path)
+	if err != nil {
+		fail(err)
+	}
+	return c, c.validate()
+}
+
+func check(args []string) {
+	fs, path, jsonOut := commonFlags("check")
+	fs.Parse(args)
+	_, p := loadValid(*path)
+	if *jsonOut {
+		writeJSON(os.Stdout, p)
+	} else {
+		for _, e := range p.Errors {
+			fmt.Printf("ERROR %s\n", e)
+		}
+		for _, w := range p.Warnings {
+			fmt.Printf("WARN  %s\n", w)
+		}
+		fmt.Printf("ALERTROUTE_OK: %v\n", len(p.Errors) == 0)
+	}
+	if len(p.Errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+func simulate(args []string) {
+	fs, path, jsonOut := commonFlags("simulate")
+	fs.Parse(args)
+	labels := map[string]string{}
+	for _, a := range fs.Args() {
+		k, v, ok := strings.Cut(a, "=")
+		if !ok || !labelRe.MatchString(k) {
+			fail(fmt.Errorf("invalid label %q (want key=value)", a))
+		}
+		labels[k] = v
+	}
+	if len(labels) == 0 {
+		usage()
+	}
+	c, p := loadValid(*path)
+	if len(p.Errors) > 0 {
+		for _, e := range p.Errors {
+			fmt.Fprintf(os.Stderr, "[ERR] %s\n", e)
+		}
+		os.Exit(1)
+	}
+	sim := c.simulate(labels)
+	if *jsonOut {
+		writeJSON(os.Stdout, sim)
+		return
+	}
+	writeSimulation(os.Stdout, sim)
+}
+
+func writeSimulation(w io.Writer, sim Simulation) {
+	for _, m := range sim.Routes {
+		fmt.Fprintf(w, "receiver %s", m.Receiver)
+		if len(m.Integrations) > 0 {
+			fmt.Fprintf(w, " (%s)", strings.Join(m.Integrations, ", "))
+		}
+		fmt.Fprintf(w, "\n  route     %s", m.Path)
+		if len(m.Matchers) > 0 {
+			fmt.Fprintf(w, " {%s}", strings.Join(m.Matchers, ", "))
+		}
+		fmt.Fprintf(w, "\n  group_by  [%s] -> %s\n", strings.Join(m.GroupBy, ", "), m.GroupKey)
+		fmt.Fprintf(w, "  timing    wait=%s interval=%s repeat=%s\n", orDefault(m.GroupWait, "30s"), orDefault(m.GroupInterval, "5m"), orDefault(m.RepeatInterval, "4h"))
+		if len(m.MuteIntervals) > 0 {
+			fmt.Fprintf(w, "  muted     during %s\n", strings.Join(m.MuteIntervals, ", "))
+		}
+	}
+	if sim.FallbackRoot {
+		fmt.Fprintln(w, "note: no child route matched; the root receiver catches this alert")
+	}
+	for _, in := range sim.Inhibitions {
+		eq := ""
+		if len(in.Equal) > 0 {
+			eq = " with equal " + strings.Join(in.Equal, ",")
+		}
+		if in.Role == "target" {
+			fmt.Fprintf(w, "inhibited by rule %d while an alert {%s} fires%s\n", in.Rule, strings.Join(in.Other, ", "), eq)
+		} else {
+			fmt.Fprintf(w, "inhibits (rule %d) alerts {%s}%s\n", in.Rule, strings.Join(in.Other, ", "), eq)
+		}
+	}
+}
+
+// Alertmanager 기본값(설정에 없을 때)
+func orDefault(v, def string) string {
+	if v == "" {
+		return def + " (default)"
+	}
+	return v
+}
+
+func writeJSON(w io.Writer, v any) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
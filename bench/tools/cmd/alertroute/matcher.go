@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// matcher is one Alertmanager label matcher (=, !=, =~, !~).
+type matcher struct {
+	name, op, value string
+	re              *regexp.Regexp
+}
+
+func (m matcher) String() string { return m.name + m.op + strconv.Quote(m.value) }
+
+// matches는 없는 label을 빈 문자열로 본다(Alertmanager와 같음)
+func (m matcher) matches(labels map[string]string) bool {
+	v := labels[m.name]
+	switch m.op {
+	case "=":
+		return v == m.value
+	case "!=":
+		return v != m.value
+	case "=~":
+		return m.re.MatchString(v)
+	default: // !~
+		return !m.re.MatchString(v)
+	}
+}
+
+func matchAll(ms []matcher, labels map[string]string) bool {
+	for _, m := range ms {
+		if !m.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func newMatcher(name, op, value string) (matcher, error) {
+	if !labelRe.MatchString(name) {
+		return matcher{}, fmt.Errorf("invalid label name %q", name)
+	}
+	m := matcher{name: name, op: op, value: value}
+	if op == "=~" || op == "!~" {
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return matcher{}, fmt.Errorf("%s: %w", m, err)
+		}
+		m.re = re
+	}
+	return m, nil
+}
+
+// compileMatchers는 구식 match/match_re와 matchers 문자열을 합친다(이름순으로 안정 정렬)
+func compileMatchers(eq, re map[string]string, list []string) ([]matcher, error) {
+	var out []matcher
+	for _, set := range []struct {
+		op string
+		m  map[string]string
+	}{{"=", eq}, {"=~", re}} {
+		names := make([]string, 0, len(set.m))
+		for k := range set.m {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			m, err := newMatcher(k, set.op, set.m[k])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, m)
+		}
+	}
+	for _, s := range list {
+		ms, err := parseMatchers(s)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ms...)
+	}
+	return out, nil
+}
+
+var matcherRe = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*(.*?)\s*$`)
+
+// parseMatchers는 'a="b"' 한 개나 '{a="b", c=~"d"}' 목록을 읽는다
+func parseMatchers(s string) ([]matcher, error) {
+	t := strings.TrimSpace(s)
+	if strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}") {
+		t = t[1 : len(t)-1]
+	}
+	var out []matcher
+	for _, part := range splitOutsideQuotes(t, ',') {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		g := matcherRe.FindStringSubmatch(part)
+		if g == nil {
+			return nil, fmt.Errorf("invalid matcher %q", part)
+		}
+		v := g[3]
+		if strings.HasPrefix(v, `"`) {
+			u, err := strconv.Unquote(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid matcher %q: bad quoting", part)
+			}
+			v = u
+		}
+		m, err := newMatcher(g[1], g[2], v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func splitOutsideQuotes(s string, sep byte) []string {
+	var out []string
+	start, inQ := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && inQ:
+			i++
+		case s[i] == '"':
+			inQ = !inQ
+		case s[i] == sep && !inQ:
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, s[start:])
+}
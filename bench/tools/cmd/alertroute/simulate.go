@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Match is one route an alert is delivered through, with the settings it
+// inherits from its parents.
+type Match struct {
+	Path           string   `json:"route"`
+	Receiver       string   `json:"receiver"`
+	Integrations   []string `json:"integrations,omitempty"`
+	Matchers       []string `json:"matchers,omitempty"`
+	GroupBy        []string `json:"group_by,omitempty"`
+	GroupKey       string   `json:"group_key"`
+	GroupWait      string   `json:"group_wait,omitempty"`
+	GroupInterval  string   `json:"group_interval,omitempty"`
+	RepeatInterval string   `json:"repeat_interval,omitempty"`
+	MuteIntervals  []string `json:"mute_time_intervals,omitempty"`
+}
+
+// Inhibition is an inhibit rule the alert takes part in.
+type Inhibition struct {
+	Rule  int      `json:"rule"`
+	Role  string   `json:"role"` // target: 이 알림이 억제됨, source: 이 알림이 억제함
+	Other []string `json:"other_matchers"`
+	Equal []string `json:"equal,omitempty"`
+}
+
+// Simulation is the routing outcome for one label set.
+type Simulation struct {
+	Labels       map[string]string `json:"labels"`
+	Routes       []Match           `json:"routes"`
+	Inhibitions  []Inhibition      `json:"inhibitions,omitempty"`
+	FallbackRoot bool              `json:"fallback_root"`
+}
+
+// 하위 route가 비워 두면 부모 값을 물려받는 설정
+type inherited struct {
+	receiver, groupWait, groupInterval, repeatInterval string
+	groupBy                                            []string
+}
+
+func (in inherited) with(r *Route) inherited {
+	if r.Receiver != "" {
+		in.receiver = r.Receiver
+	}
+	if r.GroupBy != nil {
+		in.groupBy = r.GroupBy
+	}
+	if r.GroupWait != "" {
+		in.groupWait = r.GroupWait
+	}
+	if r.GroupInterval != "" {
+		in.groupInterval = r.GroupInterval
+	}
+	if r.RepeatInterval != "" {
+		in.repeatInterval = r.RepeatInterval
+	}
+	return in
+}
+
+// simulate는 Alertmanager의 라우팅 규칙을 따른다: 깊이 우선으로 처음 맞는 자식에서 멈추고
+// (continue: true면 계속), 맞는 자식이 없으면 그 노드 자신이 받는다
+func (c *Config) simulate(labels map[string]string) Simulation {
+	receivers := map[string]map[string]any{}
+	for _, r := range c.Receivers {
+		name, _ := r["name"].(string)
+		receivers[name] = r
+	}
+	var routes []Match
+	var visit func(path string, r *Route, in inherited) bool
+	visit = func(path string, r *Route, in inherited) bool {
+		if !matchAll(r.matchers, labels) {
+			return false
+		}
+		in = in.with(r)
+		matched := false
+		for i, ch := range r.Routes {
+			if visit(fmt.Sprintf("%s.routes[%d]", path, i), ch, in) {
+				matched = true
+				if !ch.Continue {
+					break
+				}
+			}
+		}
+		if !matched {
+			routes = append(routes, Match{
+				Path: path, Receiver: in.receiver, Integrations: integrations(receivers[in.receiver]),
+				Matchers: matcherStrings(r.matchers), GroupBy: in.groupBy, GroupKey: groupKey(in.groupBy, labels),
+				GroupWait: in.groupWait, GroupInterval: in.groupInterval, RepeatInterval: in.repeatInterval,
+				MuteIntervals: r.MuteTimeIntervals,
+			})
+		}
+		return true
+	}
+	sim := Simulation{Labels: labels}
+	if c.Route != nil {
+		visit("route", c.Route, inherited{})
+	}
+	sim.Routes = routes
+	sim.FallbackRoot = len(routes) == 1 && routes[0].Path == "route"
+
+	for i, ir := range c.InhibitRules {
+		if matchAll(ir.target, labels) {
+			sim.Inhibitions = append(sim.Inhibitions, Inhibition{Rule: i, Role: "target", Other: matcherStrings(ir.source), Equal: ir.Equal})
+		}
+		if matchAll(ir.source, labels) {
+			sim.Inhibitions = append(sim.Inhibitions, Inhibition{Rule: i, Role: "source", Other: matcherStrings(ir.target), Equal: ir.Equal})
+		}
+	}
+	return sim
+}
+
+// groupKey는 group_by label 값으로 만든 묶음 키("..."는 모든 label)
+func groupKey(by []string, labels map[string]string) string {
+	if len(by) == 1 && by[0] == "..." {
+		by = nil
+		for k := range labels {
+			by = append(by, k)
+		}
+		sort.Strings(by)
+	}
+	parts := make([]string, 0, len(by))
+	for _, k := range by {
+		parts = append(parts, k+"="+labels[k])
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func matcherStrings(ms []matcher) []string {
+	out := make([]string, 0, len(ms))
+	for _, m := range ms {
+		out = append(out, m.String())
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"time"
+
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// startedAt는 프로세스 시작 시각(봉투의 started_at)
+var startedAt = time.Now().UTC()
+
+// envelopeFlag는 판정이 있는 하위 명령이 공유하는 -envelope
+type envelopeFlag struct {
+	path *string
+	fs   *flag.FlagSet
+}
+
+func addEnvelopeFlag(fs *flag.FlagSet) envelopeFlag {
+	return envelopeFlag{fs: fs, path: fs.String("envelope", "", "also write the result as a pkg/resultenv envelope (shared proof-tool format) to this path")}
+}
+
+// write는 -envelope가 있을 때만 쓴다(params에 하위 명령 이름 포함)
+func (f envelopeFlag) write(v resultenv.Verdict, metrics map[string]float64, evidence any) {
+	if *f.path == "" {
+		return
+	}
+	e := resultenv.New("backupprobe").Flags(f.fs)
+	e.StartedAt = startedAt
+	e.Params["command"] = f.fs.Name()
+	e.Metrics = metrics
+	e.Finish(v, evidence)
+	if err := output.WriteFileAtomicFunc(*f.path, func(w io.Writer) error { return resultenv.Encode(w, e) }); err != nil {
+		fail(err)
+	}
+}
+
+func b2f(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
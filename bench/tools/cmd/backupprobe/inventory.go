@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Artifact kinds (scripts/duri_backup.sh full|incr).
+const (
+	KindFull = "FULL"
+	KindIncr = "INCR"
+)
+
+// Artifact is one backup archive in the inventory.
+type Artifact struct {
+	Path string    `json:"path"`
+	Kind string    `json:"kind"`
+	Time time.Time `json:"time"`
+	Host string    `json:"host"`
+	Size int64     `json:"size"`
+}
+
+// FULL__YYYY-MM-DD__HHMM__host.tar.zst[.age|.gpg|.enc]
+var artifactRe = regexp.MustCompile(`^(FULL|INCR)__(\d{4}-\d{2}-\d{2})__(\d{4})__(.+?)\.tar(\.[a-z0-9]+)*$`)
+
+// scanInventory는 archive 아래 FULL/, INCR/의 백업을 시각순으로 돌려준다
+// (파일명 시각은 백업 스크립트와 같은 로컬 시간)
+func scanInventory(archive string) ([]Artifact, error) {
+	var out []Artifact
+	for _, kind := range []string{KindFull, KindIncr} {
+		ents, err := os.ReadDir(filepath.Join(archive, kind))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range ents {
+			m := artifactRe.FindStringSubmatch(e.Name())
+			if m == nil || e.IsDir() || filepath.Ext(e.Name()) == ".sha256" {
+				continue
+			}
+			t, err := time.ParseInLocation("2006-01-02 1504", m[2]+" "+m[3], time.Local)
+			if err != nil {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, Artifact{Path: filepath.Join(archive, kind, e.Name()), Kind: m[1], Time: t, Host: m[4], Size: info.Size()})
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%s: no FULL__/INCR__ backups found", archive)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out, nil
+}
@@ -0,0 +1,62 @@
+// Command backupprobe verifies the DuRi backups produced by
+// scripts/duri_backup.sh (FULL__/INCR__ archives under /mnt/hdd/ARCHIVE).
+//
+//	backupprobe encryption -archive /mnt/hdd/ARCHIVE -key-ref secretref://file//etc/duri/backup.key
+//	backupprobe sentinel                              # before each backup (cron)
+//	backupprobe seed -tables 8 -rows 10000            # checksummed dataset, ledger kept outside the backup
+//	backupprobe restore -seed-ledger /var/lib/duri-backup/seed
+//	backupprobe restore --at=2024-06-01T12:00:00Z -decrypt "age -d -i /etc/duri/backup.key"
+//	backupprobe restore -restore-parallelism=1,2,4,8 -target-rto 1h
+//	backupprobe schedule -full-every 168h -incr-every 1h -out reports/textfile/backup_schedule.prom
+//
+// restore picks the last FULL at or before -at plus the INCRs after it,
+// extracts them in order into a scratch directory and checks that the last
+// sentinel written before the restore point is present and the first one
+// written after -at (or after the next backup) is not. With
+// -restore-parallelism it instead restores the chain once per level and
+// recommends the lowest level that meets -target-rto.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/duri/trace_bench/pkg/buildinfo"
+)
+
+// scripts/duri_backup.sh의 ARCH_BASE
+const defaultArchive = "/mnt/hdd/ARCHIVE"
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: backupprobe <command> [flags]\n\ncommands:\n  encryption   verify backups are encrypted and the decryption key reference resolves\n  sentinel     write a timestamped sentinel into the backed-up tree\n  seed         write a checksummed synthetic dataset into the backed-up tree\n  verify-seed  verify a (restored) seeded dataset row by row against the ledger\n  restore      point-in-time restore of the FULL+INCR chain with sentinel checks\n  schedule     check backup timestamps against the declared FULL/INCR schedule and report RPO")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "-version", "--version":
+		buildinfo.Print(os.Stdout, "backupprobe", len(os.Args) > 2 && os.Args[2] == "-json")
+	case "encryption":
+		runEncryption(os.Args[2:])
+	case "sentinel":
+		runSentinel(os.Args[2:])
+	case "seed":
+		runSeed(os.Args[2:])
+	case "verify-seed":
+		runVerifySeed(os.Args[2:])
+	case "restore":
+		runRestore(os.Args[2:])
+	case "schedule":
+		runSchedule(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
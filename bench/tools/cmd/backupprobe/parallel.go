@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/pkg/resultenv"
+	"github.com/duri/trace_bench/stats"
+)
+
+// restoreChain은 chain을 순서대로 dest에 적용한다. 증분은 순서대로만 풀 수 있으므로
+// parallel>1이면 뒤 아카이브의 복호화/압축 해제를 최대 parallel개까지 미리 staging에 풀어 둔다
+func restoreChain(ctx context.Context, chain []Artifact, dest, decrypt string, parallel int) (int64, error) {
+	var total int64
+	if parallel <= 1 {
+		for _, a := range chain {
+			n, err := extract(ctx, a.Path, dest, decrypt)
+			total += n
+			if err != nil {
+				return total, fmt.Errorf("%s: %w", filepath.Base(a.Path), err)
+			}
+		}
+		return total, nil
+	}
+
+	staging, err := os.MkdirTemp("", "backupprobe-stage-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(staging)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type staged struct {
+		path string
+		n    int64
+		err  error
+	}
+	done := make([]chan staged, len(chain))
+	for i := range done {
+		done[i] = make(chan staged, 1)
+	}
+	// 슬롯은 추출이 끝나야 반납하므로 staging에는 최대 parallel개만 쌓인다
+	slots := make(chan struct{}, parallel)
+	go func() {
+		for i, a := range chain {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				for _, d := range done[i:] {
+					d <- staged{err: ctx.Err()}
+				}
+				return
+			}
+			go func(i int, a Artifact) {
+				dst := filepath.Join(staging, fmt.Sprintf("%04d.tar", i))
+				n, err := stage(ctx, a.Path, dst, decrypt)
+				done[i] <- staged{dst, n, err}
+			}(i, a)
+		}
+	}()
+	for i, a := range chain {
+		s := <-done[i]
+		total += s.n
+		if s.err == nil {
+			s.err = untar(ctx, nil, "", dest, s.path)
+			os.Remove(s.path)
+		}
+		if s.err != nil {
+			return total, fmt.Errorf("%s: %w", filepath.Base(a.Path), s.err)
+		}
+		<-slots
+	}
+	return total, nil
+}
+
+// stage는 아카이브를 복호화·압축 해제한 평문 tar로 dst에 쓴다
+func stage(ctx context.Context, path, dst, decrypt string) (int64, error) {
+	s, err := openArchive(ctx, path, decrypt)
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		s.Close()
+		return 0, err
+	}
+	if argv := strings.Fields(decompressors[s.Format][1]); len(argv) > 0 {
+		cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = s, f, os.Stderr
+		if err = cmd.Run(); err != nil {
+			err = fmt.Errorf("%s: %w", argv[0], err)
+		}
+	} else {
+		_, err = f.ReadFrom(s)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := s.Close(); err == nil {
+		err = cerr
+	}
+	return s.cr.n, err
+}
+
+// SweepRun is one parallelism level of the restore benchmark.
+type SweepRun struct {
+	Parallelism int     `json:"parallelism"`
+	Bytes       int64   `json:"bytes"`
+	ElapsedSec  float64 `json:"elapsed_sec"`
+	MBps        float64 `json:"mb_per_sec"`
+	// Speedup and Efficiency are relative to the lowest level.
+	Speedup    float64 `json:"speedup"`
+	Efficiency float64 `json:"efficiency"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// SweepReport is the gate JSON of backupprobe restore -restore-parallelism.
+type SweepReport struct {
+	Chain     []Artifact `json:"chain"`
+	TargetRTO string     `json:"target_rto"`
+	Runs      []SweepRun `json:"runs"`
+	// Recommended is the lowest level whose restore fits the target RTO (0 = none does).
+	Recommended int  `json:"recommended_parallelism"`
+	OK          bool `json:"ok"`
+	// Verdict is OK as a pkg/resultenv verdict.
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+func runSweep(chain []Artifact, levels []int, decrypt string, target, timeout time.Duration, jsonOut bool, env envelopeFlag) {
+	rep := SweepReport{Chain: chain, TargetRTO: target.String()}
+	for _, p := range levels {
+		r := SweepRun{Parallelism: p}
+		dest, err := os.MkdirTemp("", "backupprobe-sweep-")
+		if err != nil {
+			fail(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		t0 := time.Now()
+		r.Bytes, err = restoreChain(ctx, chain, dest, decrypt, p)
+		r.ElapsedSec = stats.Round2(time.Since(t0).Seconds())
+		cancel()
+		os.RemoveAll(dest)
+		if err != nil {
+			r.Error = err.Error()
+		} else if r.ElapsedSec > 0 {
+			r.MBps = stats.Round2(float64(r.Bytes) / 1e6 / r.ElapsedSec)
+		}
+		rep.Runs = append(rep.Runs, r)
+	}
+	base := rep.Runs[0]
+	for i := range rep.Runs {
+		r := &rep.Runs[i]
+		if r.Error != "" || base.Error != "" || r.ElapsedSec == 0 {
+			continue
+		}
+		r.Speedup = stats.Round2(base.ElapsedSec / r.ElapsedSec)
+		r.Efficiency = stats.Round2(r.Speedup * float64(base.Parallelism) / float64(r.Parallelism))
+		if rep.Recommended == 0 && r.ElapsedSec <= target.Seconds() {
+			rep.Recommended = r.Parallelism
+		}
+	}
+	rep.OK = rep.Recommended > 0
+	rep.Verdict = resultenv.PassFail(rep.OK)
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+	} else {
+		fmt.Printf("restore benchmark: %d artifacts (%s), target RTO %s\n", len(chain), chainKinds(chain), rep.TargetRTO)
+		fmt.Printf("%-12s %10s %10s %8s %10s\n", "parallelism", "elapsed_s", "MB/s", "speedup", "efficiency")
+		for _, r := range rep.Runs {
+			if r.Error != "" {
+				fmt.Printf("%-12d FAIL %s\n", r.Parallelism, r.Error)
+				continue
+			}
+			fmt.Printf("%-12d %10.2f %10.2f %8.2f %10.2f\n", r.Parallelism, r.ElapsedSec, r.MBps, r.Speedup, r.Efficiency)
+		}
+		if rep.OK {
+			fmt.Printf("[PASS] parallelism %d restores within %s\n", rep.Recommended, rep.TargetRTO)
+		} else {
+			fmt.Printf("[FAIL] no parallelism level restores within %s\n", rep.TargetRTO)
+		}
+		fmt.Printf("RECOMMENDED_PARALLELISM: %d\n", rep.Recommended)
+		fmt.Printf("BACKUPPROBE_OK: %v\n", rep.OK)
+	}
+	metrics := map[string]float64{"recommended_parallelism": float64(rep.Recommended)}
+	for _, r := range rep.Runs {
+		metrics[fmt.Sprintf("p%d.elapsed_sec", r.Parallelism)] = r.ElapsedSec
+		metrics[fmt.Sprintf("p%d.mb_per_sec", r.Parallelism)] = r.MBps
+	}
+	env.write(rep.Verdict, metrics, rep)
+	if !rep.OK {
+		os.Exit(1)
+	}
+}
+
+// parseLevels는 "1,2,4,8"을 오름차순 정수 목록으로 읽는다
+func parseLevels(s string) ([]int, error) {
+	var out []int
+	for _, f := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid level %q", f)
+		}
+		if len(out) > 0 && n <= out[len(out)-1] {
+			return nil, fmt.Errorf("levels must be increasing: %s", s)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
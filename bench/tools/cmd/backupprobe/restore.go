@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// RestoreReport is the gate JSON of backupprobe restore.
+type RestoreReport struct {
+	At           time.Time   `json:"at"`
+	RestorePoint time.Time   `json:"restore_point"`
+	Chain        []Artifact  `json:"chain"`
+	Dest         string      `json:"dest"`
+	Bytes        int64       `json:"bytes"`
+	ElapsedSec   float64     `json:"elapsed_sec"`
+	Before       *Sentinel   `json:"sentinel_before,omitempty"`
+	BeforeFound  bool        `json:"sentinel_before_found"`
+	After        *Sentinel   `json:"sentinel_after,omitempty"`
+	AfterFound   bool        `json:"sentinel_after_found"`
+	Seed         *SeedReport `json:"seed,omitempty"`
+	Errors       []string    `json:"errors,omitempty"`
+	OK           bool        `json:"ok"`
+	// Verdict is pass_with_warnings when no sentinel written after the
+	// restore point was available to check its absence (pkg/resultenv).
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+func runRestore(args []string) {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	archive := fs.String("archive", defaultArchive, "backup archive root (FULL/, INCR/)")
+	atStr := fs.String("at", "", "restore point in time (RFC3339, e.g. 2024-06-01T12:00:00Z; default now)")
+	host := fs.String("host", "", "only use backups of this host (default: the host of the newest backup)")
+	dest := fs.String("dest", "", "restore into this empty directory (default: a temporary directory, removed afterwards)")
+	decrypt := fs.String("decrypt", "", "command that decrypts stdin to stdout for encrypted backups (e.g. \"age -d -i /etc/duri/backup.key\")")
+	sentinels := fs.String("sentinels", defaultSentinels, "live sentinel directory written by 'backupprobe sentinel'")
+	sentinelPath := fs.String("sentinel-path", ".backup_sentinels", "sentinel directory relative to the restored tree")
+	seedLedger := fs.String("seed-ledger", "", "also verify the seeded dataset against this ledger (see 'backupprobe seed'; empty = skip)")
+	seedPath := fs.String("seed-path", ".backup_seed", "seeded dataset directory relative to the restored tree")
+	skipVerify := fs.Bool("skip-verify", false, "do not check the .sha256 sidecars before restoring")
+	dryRun := fs.Bool("dry-run", false, "print the selected chain only")
+	parallelism := fs.Int("parallelism", 1, "artifacts decrypted/decompressed ahead of extraction (1 = stream each archive straight into tar)")
+	sweep := fs.String("restore-parallelism", "", "benchmark mode: comma-separated parallelism levels (e.g. 1,2,4,8) restored in turn, without sentinel checks")
+	targetRTO := fs.Duration("target-rto", time.Hour, "restore time objective the benchmark recommends a parallelism for")
+	timeout := fs.Duration("timeout", 2*time.Hour, "restore time budget")
+	jsonOut := fs.Bool("json", false, "print the gate JSON instead of the summary")
+	env := addEnvelopeFlag(fs)
+	fs.Parse(args)
+
+	at := time.Now()
+	if *atStr != "" {
+		var err error
+		if at, err = time.Parse(time.RFC3339, *atStr); err != nil {
+			fail(fmt.Errorf("-at: %w", err))
+		}
+	}
+	arts, err := scanInventory(*archive)
+	if err != nil {
+		fail(err)
+	}
+	chain, next, err := selectChain(arts, at, *host)
+	if err != nil {
+		fail(err)
+	}
+	rep := RestoreReport{At: at, RestorePoint: chain[len(chain)-1].Time, Chain: chain}
+	if *dryRun {
+		for _, a := range chain {
+			fmt.Printf("%s %s %s\n", a.Time.Format(time.RFC3339), a.Kind, a.Path)
+		}
+		return
+	}
+	if *sweep != "" {
+		levels, err := parseLevels(*sweep)
+		if err != nil {
+			fail(fmt.Errorf("-restore-parallelism: %w", err))
+		}
+		if !*skipVerify {
+			for _, a := range chain {
+				if err := verifySidecar(a.Path); err != nil {
+					fail(err)
+				}
+			}
+		}
+		runSweep(chain, levels, *decrypt, *targetRTO, *timeout, *jsonOut, env)
+		return
+	}
+
+	// 센티넬은 복원 전에 고른다: before는 복원 지점 백업이 시작되기 전에 쓴 것,
+	// after는 다음 백업(없으면 -at) 이후에 쓴 것이라 어느 체인에도 들어갈 수 없다
+	live, err := readSentinels(*sentinels)
+	if err != nil {
+		fail(err)
+	}
+	cutoff := at
+	if !next.IsZero() && next.After(cutoff) {
+		cutoff = next
+	}
+	rep.Before, rep.After = pickSentinels(live, rep.RestorePoint, cutoff)
+
+	rep.Dest = *dest
+	if rep.Dest == "" {
+		if rep.Dest, err = os.MkdirTemp("", "backupprobe-restore-"); err != nil {
+			fail(err)
+		}
+		defer os.RemoveAll(rep.Dest)
+	} else if err := os.MkdirAll(rep.Dest, 0o755); err != nil {
+		fail(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	if !*skipVerify {
+		for _, a := range chain {
+			if err := verifySidecar(a.Path); err != nil {
+				rep.Errors = append(rep.Errors, err.Error())
+			}
+		}
+	}
+	if len(rep.Errors) == 0 {
+		t0 := time.Now()
+		rep.Bytes, err = restoreChain(ctx, chain, rep.Dest, *decrypt, *parallelism)
+		rep.ElapsedSec = time.Since(t0).Seconds()
+		if err != nil {
+			rep.Errors = append(rep.Errors, err.Error())
+		}
+	}
+
+	restored := len(rep.Errors) == 0
+	if restored {
+		dir := filepath.Join(rep.Dest, *sentinelPath)
+		if rep.Before == nil {
+			rep.Errors = append(rep.Errors, fmt.Sprintf("no sentinel in %s was written before %s; run 'backupprobe sentinel' before backups", *sentinels, rep.RestorePoint.Format(time.RFC3339)))
+		} else if rep.BeforeFound = rep.Before.presentIn(dir); !rep.BeforeFound {
+			rep.Errors = append(rep.Errors, fmt.Sprintf("sentinel %s (written %s) is missing from the restore", rep.Before.Name, rep.Before.Time.Format(time.RFC3339)))
+		}
+		if rep.After != nil {
+			if rep.AfterFound = rep.After.presentIn(dir); rep.AfterFound {
+				rep.Errors = append(rep.Errors, fmt.Sprintf("sentinel %s (written %s) is in a restore to %s", rep.After.Name, rep.After.Time.Format(time.RFC3339), at.Format(time.RFC3339)))
+			}
+		}
+	}
+	if restored && *seedLedger != "" {
+		sr := verifySeed(filepath.Join(rep.Dest, *seedPath), *seedLedger, "", 0)
+		rep.Seed = &sr
+		if !sr.OK {
+			rep.Errors = append(rep.Errors, fmt.Sprintf("seeded dataset: %d/%d rows lost", sr.Lost, sr.Expected))
+		}
+	}
+	rep.OK = len(rep.Errors) == 0
+	rep.Verdict = resultenv.PassFail(rep.OK)
+	if rep.OK && rep.After == nil {
+		rep.Verdict = resultenv.VerdictPassWithWarnings
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+	} else {
+		fmt.Printf("restore to %s: point %s, %d artifacts (%s), %.1f MB in %.1fs\n",
+			at.Format(time.RFC3339), rep.RestorePoint.Format(time.RFC3339), len(chain), chainKinds(chain), float64(rep.Bytes)/1e6, rep.ElapsedSec)
+		if rep.Before != nil && rep.BeforeFound {
+			fmt.Printf("[PASS] sentinel %s present\n", rep.Before.Name)
+		}
+		if rep.After != nil && !rep.AfterFound && len(rep.Errors) == 0 {
+			fmt.Printf("[PASS] sentinel %s absent\n", rep.After.Name)
+		} else if rep.After == nil {
+			fmt.Printf("[WARN] no sentinel written after %s; absence not checked\n", cutoff.Format(time.RFC3339))
+		}
+		if rep.Seed != nil {
+			printSeedReport(*rep.Seed)
+		}
+		for _, e := range rep.Errors {
+			fmt.Printf("[FAIL] %s\n", e)
+		}
+		fmt.Printf("BACKUPPROBE_OK: %v\n", rep.OK)
+	}
+	metrics := map[string]float64{"bytes": float64(rep.Bytes), "elapsed_sec": rep.ElapsedSec, "artifacts": float64(len(chain))}
+	if rep.Seed != nil {
+		metrics["seed_lost_rows"], metrics["seed_loss_ratio"] = float64(rep.Seed.Lost), rep.Seed.LossRatio
+	}
+	env.write(rep.Verdict, metrics, rep)
+	if !rep.OK {
+		os.Exit(1)
+	}
+}
+
+// selectChain은 at 이전의 마지막 FULL과 그 뒤 at까지의 INCR을 돌려준다.
+// next는 at 이후 첫 백업 시각(없으면 zero)
+func selectChain(arts []Artifact, at time.Time, host string) (chain []Artifact, next time.Time, err error) {
+	if host == "" {
+		host = arts[len(arts)-1].Host
+	}
+	full := -1
+	for i, a := range arts {
+		if a.Host != host {
+			continue
+		}
+		if a.Time.After(at) {
+			next = a.Time
+			break
+		}
+		if a.Kind == KindFull {
+			full = i
+		}
+	}
+	if full < 0 {
+		return nil, time.Time{}, fmt.Errorf("no FULL backup of host %s at or before %s", host, at.Format(time.RFC3339))
+	}
+	// full이 at 이전 마지막 FULL이므로 그 뒤 at까지는 INCR뿐이다
+	for _, a := range arts[full:] {
+		if a.Time.After(at) {
+			break
+		}
+		if a.Host == host {
+			chain = append(chain, a)
+		}
+	}
+	return chain, next, nil
+}
+
+func chainKinds(chain []Artifact) string {
+	n := 0
+	for _, a := range chain {
+		if a.Kind == KindIncr {
+			n++
+		}
+	}
+	return fmt.Sprintf("1 FULL + %d INCR", n)
+}
+
+// verifySidecar는 duri_backup.sh가 남긴 <archive>.sha256과 대조한다
+func verifySidecar(path string) error {
+	b, err := os.ReadFile(path + ".sha256")
+	if err != nil {
+		return fmt.Errorf("%s: %w (use -skip-verify to restore without checksums)", filepath.Base(path), err)
+	}
+	want := strings.Fields(string(b))
+	if len(want) == 0 {
+		return fmt.Errorf("%s.sha256: empty", filepath.Base(path))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != strings.ToLower(want[0]) {
+		return fmt.Errorf("%s: sha256 %s does not match sidecar %s", filepath.Base(path), got[:12], want[0][:min(12, len(want[0]))])
+	}
+	return nil
+}
+
+// decompressors는 복호화된 스트림의 형식별 (tar 옵션, 압축 해제 명령)
+var decompressors = map[string][2]string{
+	"zstd":  {"--zstd", "zstd -dc"},
+	"gzip":  {"-z", "gzip -dc"},
+	"xz":    {"-J", "xz -dc"},
+	"bzip2": {"-j", "bzip2 -dc"},
+	"tar":   {"", ""},
+}
+
+// archiveStream은 (복호화된) 압축 tar 스트림. 형식은 복호화 뒤 헤더로 정한다
+type archiveStream struct {
+	*bufio.Reader
+	Format string
+	f      *os.File
+	cr     *countingReader
+	dec    *exec.Cmd
+}
+
+func openArchive(ctx context.Context, path, decrypt string) (*archiveStream, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &archiveStream{f: f, cr: &countingReader{r: f}}
+	br := bufio.NewReaderSize(s.cr, 64<<10)
+	head, _ := br.Peek(512)
+	var in io.Reader = br
+	if format, encrypted := detectFormat(head); encrypted {
+		if decrypt == "" {
+			f.Close()
+			return nil, fmt.Errorf("archive is %s-encrypted; set -decrypt", format)
+		}
+		argv := strings.Fields(decrypt)
+		s.dec = exec.CommandContext(ctx, argv[0], argv[1:]...)
+		s.dec.Stdin, s.dec.Stderr = br, os.Stderr
+		out, err := s.dec.StdoutPipe()
+		if err == nil {
+			err = s.dec.Start()
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("decrypt: %w", err)
+		}
+		in = out
+	}
+	s.Reader = bufio.NewReaderSize(in, 64<<10)
+	head, _ = s.Peek(512)
+	if s.Format, _ = detectFormat(head); decompressors[s.Format] == [2]string{} && s.Format != "tar" {
+		s.Close()
+		return nil, fmt.Errorf("not a tar archive after decryption (%s)", s.Format)
+	}
+	return s, nil
+}
+
+// Close는 복호화 프로세스를 기다리고 파일을 닫는다
+func (s *archiveStream) Close() error {
+	defer s.f.Close()
+	if s.dec != nil {
+		if err := s.dec.Wait(); err != nil {
+			return fmt.Errorf("decrypt: %w", err)
+		}
+	}
+	return nil
+}
+
+// extract는 (복호화 →) tar -x로 증분 아카이브 하나를 dest에 풀고 읽은 아카이브 바이트 수를 돌려준다
+func extract(ctx context.Context, path, dest, decrypt string) (int64, error) {
+	s, err := openArchive(ctx, path, decrypt)
+	if err != nil {
+		return 0, err
+	}
+	err = untar(ctx, s, decompressors[s.Format][0], dest, "-")
+	if cerr := s.Close(); err == nil {
+		err = cerr
+	}
+	return s.cr.n, err
+}
+
+// untar는 증분 tar(file이 "-"면 in)를 dest에 적용한다(--listed-incremental로 삭제까지 반영)
+func untar(ctx context.Context, in io.Reader, compression, dest, file string) error {
+	args := []string{"-x", "--listed-incremental=/dev/null", "--numeric-owner", "-C", dest, "-f", file}
+	if compression != "" {
+		args = append([]string{compression}, args...)
+	}
+	tar := exec.CommandContext(ctx, "tar", args...)
+	tar.Stdin = in
+	if out, err := tar.CombinedOutput(); err != nil {
+		return fmt.Errorf("tar: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
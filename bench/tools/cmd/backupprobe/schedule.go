@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// MissedWindow is a schedule slot that passed (plus grace) without a backup.
+type MissedWindow struct {
+	Kind string    `json:"kind"`
+	Due  time.Time `json:"due"`
+	// Last is the backup the missed slot was scheduled after.
+	Last time.Time `json:"last"`
+}
+
+// ScheduleCheck is the conformance of one backup kind.
+type ScheduleCheck struct {
+	Kind     string         `json:"kind"`
+	Every    string         `json:"every"`
+	Grace    string         `json:"grace"`
+	Last     time.Time      `json:"last,omitempty"`
+	Count    int            `json:"count"` // backups in the window
+	Missed   []MissedWindow `json:"missed,omitempty"`
+	Conforms bool           `json:"conforms"`
+	// Verdict is pass_with_warnings when slots were missed within
+	// -max-missed.
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+// ScheduleReport is the gate JSON of backupprobe schedule.
+type ScheduleReport struct {
+	Host       string          `json:"host"`
+	CheckedAt  time.Time       `json:"checked_at"`
+	Window     string          `json:"window"`
+	RPOSeconds float64         `json:"rpo_seconds"` // age of the newest recovery point
+	Kinds      []ScheduleCheck `json:"kinds"`
+	OK         bool            `json:"ok"`
+	// Verdict is the worst kind verdict (pkg/resultenv).
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+func runSchedule(args []string) {
+	fs := flag.NewFlagSet("schedule", flag.ExitOnError)
+	archive := fs.String("archive", defaultArchive, "backup archive root (FULL/, INCR/)")
+	host := fs.String("host", "", "backup host (default: the host of the newest backup)")
+	fullEvery := fs.Duration("full-every", 7*24*time.Hour, "declared FULL interval")
+	fullGrace := fs.Duration("full-grace", 12*time.Hour, "lateness tolerated before a FULL slot counts as missed")
+	incrEvery := fs.Duration("incr-every", time.Hour, "declared INCR interval (a FULL also counts as a recovery point)")
+	incrGrace := fs.Duration("incr-grace", 15*time.Minute, "lateness tolerated before an INCR slot counts as missed")
+	window := fs.Duration("window", 7*24*time.Hour, "only report slots due inside this lookback")
+	maxMissed := fs.Int("max-missed", 0, "missed slots per kind tolerated inside the window")
+	out := fs.String("out", "", "write RPO and missed-window metrics as a textfile for the node_exporter textfile collector")
+	jsonOut := fs.Bool("json", false, "print the gate JSON instead of the summary")
+	env := addEnvelopeFlag(fs)
+	fs.Parse(args)
+
+	arts, err := scanInventory(*archive)
+	if err != nil {
+		fail(err)
+	}
+	if *host == "" {
+		*host = arts[len(arts)-1].Host
+	}
+	var fulls, all []time.Time
+	for _, a := range arts {
+		if a.Host != *host {
+			continue
+		}
+		all = append(all, a.Time)
+		if a.Kind == KindFull {
+			fulls = append(fulls, a.Time)
+		}
+	}
+	now := time.Now()
+	from := now.Add(-*window)
+	rep := ScheduleReport{Host: *host, CheckedAt: now.UTC(), Window: window.String(), RPOSeconds: now.Sub(all[len(all)-1]).Seconds(), OK: true}
+	var verdicts []resultenv.Verdict
+	for _, k := range []struct {
+		kind         string
+		points       []time.Time
+		every, grace time.Duration
+	}{
+		{KindFull, fulls, *fullEvery, *fullGrace},
+		{KindIncr, all, *incrEvery, *incrGrace},
+	} {
+		c := ScheduleCheck{Kind: k.kind, Every: k.every.String(), Grace: k.grace.String()}
+		c.Missed = missedWindows(k.kind, k.points, k.every, k.grace, from, now)
+		for _, t := range k.points {
+			if t.After(from) {
+				c.Count++
+			}
+		}
+		if len(k.points) > 0 {
+			c.Last = k.points[len(k.points)-1]
+		}
+		c.Conforms = len(c.Missed) <= *maxMissed
+		c.Verdict = resultenv.PassFail(c.Conforms)
+		if c.Conforms && len(c.Missed) > 0 {
+			c.Verdict = resultenv.VerdictPassWithWarnings
+		}
+		rep.OK = rep.OK && c.Conforms
+		rep.Kinds = append(rep.Kinds, c)
+		verdicts = append(verdicts, c.Verdict)
+	}
+	rep.Verdict = resultenv.Worst(verdicts...)
+
+	if *out != "" {
+		if err := output.WriteFileAtomicFunc(*out, func(w io.Writer) error { return writeScheduleTextfile(w, rep) }); err != nil {
+			fail(err)
+		}
+	}
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+	} else {
+		fmt.Printf("host %s: RPO %s (newest recovery point %s)\n", rep.Host, (time.Duration(rep.RPOSeconds) * time.Second).String(), all[len(all)-1].Format(time.RFC3339))
+		for _, c := range rep.Kinds {
+			last := "never"
+			if !c.Last.IsZero() {
+				last = c.Last.Format(time.RFC3339)
+			}
+			fmt.Printf("[%s] %s every %s: %d backups in %s, last %s, %d missed\n", c.Verdict, c.Kind, c.Every, c.Count, rep.Window, last, len(c.Missed))
+			for _, m := range c.Missed {
+				fmt.Printf("  missed %s slot due %s\n", m.Kind, m.Due.Format(time.RFC3339))
+			}
+		}
+		fmt.Printf("BACKUPPROBE_OK: %v\n", rep.OK)
+	}
+	metrics := map[string]float64{"rpo_seconds": rep.RPOSeconds}
+	for _, c := range rep.Kinds {
+		metrics[strings.ToLower(c.Kind)+".missed_windows"] = float64(len(c.Missed))
+	}
+	env.write(rep.Verdict, metrics, rep)
+	if !rep.OK {
+		os.Exit(1)
+	}
+}
+
+// missedWindows는 연속한 백업 사이(마지막 백업→now 포함)에서 every+grace가 지나도록
+// 백업이 없던 슬롯을 센다. 슬롯 기준은 직전 백업 시각(늦은 백업은 일정을 민다)
+func missedWindows(kind string, points []time.Time, every, grace time.Duration, from, now time.Time) []MissedWindow {
+	var out []MissedWindow
+	if len(points) == 0 {
+		// 한 번도 없으면 창 전체가 빈 슬롯
+		for due := from.Add(every); !due.Add(grace).After(now); due = due.Add(every) {
+			out = append(out, MissedWindow{Kind: kind, Due: due})
+		}
+		return out
+	}
+	for i, p := range points {
+		next := now
+		if i+1 < len(points) {
+			next = points[i+1]
+		}
+		for due := p.Add(every); due.Add(grace).Before(next); due = due.Add(every) {
+			if due.After(from) && !due.Add(grace).After(now) {
+				out = append(out, MissedWindow{Kind: kind, Due: due, Last: p})
+			}
+		}
+	}
+	return out
+}
+
+// writeScheduleTextfile은 RPO/누락 슬롯 gauge를 쓴다
+func writeScheduleTextfile(w io.Writer, rep ScheduleReport) error {
+	fmt.Fprintln(w, "# HELP trace_bench_backup_rpo_seconds age of the newest backup recovery point")
+	fmt.Fprintln(w, "# TYPE trace_bench_backup_rpo_seconds gauge")
+	fmt.Fprintf(w, "trace_bench_backup_rpo_seconds{host=%q} %.0f\n", rep.Host, rep.RPOSeconds)
+	fmt.Fprintln(w, "# HELP trace_bench_backup_last_timestamp_seconds time of the newest backup per kind")
+	fmt.Fprintln(w, "# TYPE trace_bench_backup_last_timestamp_seconds gauge")
+	for _, c := range rep.Kinds {
+		if !c.Last.IsZero() {
+			fmt.Fprintf(w, "trace_bench_backup_last_timestamp_seconds{host=%q,kind=%q} %d\n", rep.Host, c.Kind, c.Last.Unix())
+		}
+	}
+	fmt.Fprintln(w, "# HELP trace_bench_backup_missed_windows schedule slots missed inside the lookback window")
+	fmt.Fprintln(w, "# TYPE trace_bench_backup_missed_windows gauge")
+	for _, c := range rep.Kinds {
+		fmt.Fprintf(w, "trace_bench_backup_missed_windows{host=%q,kind=%q} %d\n", rep.Host, c.Kind, len(c.Missed))
+	}
+	fmt.Fprintln(w, "# HELP trace_bench_backup_schedule_conformant whether the kind met its declared schedule")
+	fmt.Fprintln(w, "# TYPE trace_bench_backup_schedule_conformant gauge")
+	for _, c := range rep.Kinds {
+		v := 0
+		if c.Conforms {
+			v = 1
+		}
+		fmt.Fprintf(w, "trace_bench_backup_schedule_conformant{host=%q,kind=%q} %d\n", rep.Host, c.Kind, v)
+	}
+	_, err := fmt.Fprintf(w, "# HELP trace_bench_backup_schedule_check_timestamp_seconds time of the last schedule check\n# TYPE trace_bench_backup_schedule_check_timestamp_seconds gauge\ntrace_bench_backup_schedule_check_timestamp_seconds %d\n", rep.CheckedAt.Unix())
+	return err
+}
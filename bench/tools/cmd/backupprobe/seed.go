@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/resultenv"
+	"github.com/duri/trace_bench/stats"
+)
+
+// 시드 데이터는 백업 대상 안에, 원장(기대값)은 백업 밖에 둔다.
+// 원장이 백업과 함께 사라지면 손실을 잴 수 없기 때문
+const (
+	defaultSeedDir    = "/home/duri/DuRiWorkspace/.backup_seed"
+	defaultSeedLedger = "/var/lib/duri-backup/seed"
+	generationFile    = "GENERATION"
+)
+
+// SeedManifest describes one generation of the synthetic dataset. Rows are
+// regenerated from Seed, so the ledger stays small at any dataset size.
+type SeedManifest struct {
+	Generation string      `json:"generation"`
+	Seed       int64       `json:"seed"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Tables     []SeedTable `json:"tables"`
+}
+
+// SeedTable is one table file (<name>.tsv: key, value, sha256 per row).
+type SeedTable struct {
+	Name string `json:"name"`
+	Rows int    `json:"rows"`
+}
+
+// TableCheck is the verification of one restored table.
+type TableCheck struct {
+	Name     string `json:"name"`
+	Expected int    `json:"expected"`
+	Intact   int    `json:"intact"`
+	Missing  int    `json:"missing"`
+	Corrupt  int    `json:"corrupt"` // present with a wrong value or row hash
+	Extra    int    `json:"extra"`
+	Error    string `json:"error,omitempty"`
+	// Verdict is fail_slo when rows were lost or the table was unreadable.
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+// SeedReport is the gate JSON of backupprobe verify-seed.
+type SeedReport struct {
+	Generation string       `json:"generation"`
+	Tables     []TableCheck `json:"tables"`
+	Expected   int          `json:"expected_rows"`
+	Lost       int          `json:"lost_rows"` // missing + corrupt
+	LossRatio  float64      `json:"loss_ratio"`
+	Error      string       `json:"error,omitempty"`
+	OK         bool         `json:"ok"`
+	// Verdict is fail_infra when the ledger generation could not be
+	// loaded, pass_with_warnings when the lost rows are within -max-loss.
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+func runSeed(args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	dir := fs.String("dir", defaultSeedDir, "dataset directory inside the backed-up tree")
+	ledger := fs.String("ledger", defaultSeedLedger, "manifest directory outside the backed-up tree")
+	tables := fs.Int("tables", 8, "number of tables")
+	rows := fs.Int("rows", 10000, "rows per table")
+	keep := fs.Int("keep", 500, "keep only the newest N ledger manifests (0 = all)")
+	fs.Parse(args)
+
+	if *tables < 1 || *rows < 1 {
+		fail(fmt.Errorf("-tables and -rows must be positive"))
+	}
+	now := time.Now().UTC()
+	m := SeedManifest{Generation: now.Format(sentinelLayout), Seed: now.UnixNano(), CreatedAt: now}
+	for i := 0; i < *tables; i++ {
+		m.Tables = append(m.Tables, SeedTable{Name: fmt.Sprintf("t%02d", i), Rows: *rows})
+	}
+	if err := writeSeed(*dir, *ledger, m); err != nil {
+		fail(err)
+	}
+	if *keep > 0 {
+		gens, err := ledgerGenerations(*ledger)
+		if err != nil {
+			fail(err)
+		}
+		for _, g := range gens[:max(0, len(gens)-*keep)] {
+			os.Remove(filepath.Join(*ledger, g+".json"))
+		}
+	}
+	fmt.Printf("seed generation %s: %d tables x %d rows\n", m.Generation, *tables, *rows)
+}
+
+// writeSeed는 원장 → 테이블 → GENERATION 순으로 쓴다(트리의 세대는 항상 원장에 있다)
+func writeSeed(dir, ledger string, m SeedManifest) error {
+	for _, d := range []string{dir, ledger} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			return err
+		}
+	}
+	if err := output.WriteFileAtomicFunc(filepath.Join(ledger, m.Generation+".json"), func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(m)
+	}); err != nil {
+		return err
+	}
+	keepTables := map[string]bool{}
+	for _, t := range m.Tables {
+		keepTables[t.Name+".tsv"] = true
+		if err := output.WriteFileAtomicFunc(filepath.Join(dir, t.Name+".tsv"), func(w io.Writer) error {
+			bw := bufio.NewWriter(w)
+			seedRows(m.Seed, t, func(key, value string) {
+				fmt.Fprintf(bw, "%s\t%s\t%s\n", key, value, rowHash(t.Name, key, value))
+			})
+			return bw.Flush()
+		}); err != nil {
+			return err
+		}
+	}
+	// 이전 세대에만 있던 테이블은 지운다
+	old, _ := filepath.Glob(filepath.Join(dir, "*.tsv"))
+	for _, p := range old {
+		if !keepTables[filepath.Base(p)] {
+			os.Remove(p)
+		}
+	}
+	return output.WriteFileAtomicFunc(filepath.Join(dir, generationFile), func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, m.Generation)
+		return err
+	})
+}
+
+// seedRows는 (seed, 테이블 이름)에서 결정적으로 행을 만든다
+func seedRows(seed int64, t SeedTable, fn func(key, value string)) {
+	h := fnv.New64a()
+	h.Write([]byte(t.Name))
+	r := rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+	b := make([]byte, 16)
+	for i := 0; i < t.Rows; i++ {
+		r.Read(b)
+		fn(fmt.Sprintf("%s-%07d", t.Name, i), hex.EncodeToString(b))
+	}
+}
+
+func rowHash(table, key, value string) string {
+	s := sha256.Sum256([]byte(table + "\x00" + key + "\x00" + value))
+	return hex.EncodeToString(s[:])
+}
+
+// ledgerGenerations는 원장의 세대를 오래된 순으로 돌려준다
+func ledgerGenerations(ledger string) ([]string, error) {
+	ents, err := os.ReadDir(ledger)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, e := range ents {
+		if g, ok := strings.CutSuffix(e.Name(), ".json"); ok {
+			if _, err := time.Parse(sentinelLayout, g); err == nil {
+				out = append(out, g)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func runVerifySeed(args []string) {
+	fs := flag.NewFlagSet("verify-seed", flag.ExitOnError)
+	dir := fs.String("dir", defaultSeedDir, "dataset directory to verify (e.g. inside a restored tree)")
+	ledger := fs.String("ledger", defaultSeedLedger, "manifest directory written by 'backupprobe seed'")
+	generation := fs.String("generation", "", "expected generation (default: the dataset's GENERATION file)")
+	maxLoss := fs.Float64("max-loss", 0, "tolerated fraction of lost rows")
+	jsonOut := fs.Bool("json", false, "print the gate JSON instead of the summary")
+	env := addEnvelopeFlag(fs)
+	fs.Parse(args)
+
+	rep := verifySeed(*dir, *ledger, *generation, *maxLoss)
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+	} else {
+		printSeedReport(rep)
+		fmt.Printf("BACKUPPROBE_OK: %v\n", rep.OK)
+	}
+	env.write(rep.Verdict, map[string]float64{"expected_rows": float64(rep.Expected), "lost_rows": float64(rep.Lost), "loss_ratio": rep.LossRatio}, rep)
+	if !rep.OK {
+		os.Exit(1)
+	}
+}
+
+// verifySeed는 원장의 세대를 다시 만들어 dir의 모든 행과 대조한다
+func verifySeed(dir, ledger, generation string, maxLoss float64) (rep SeedReport) {
+	defer func() { rep.Verdict = rep.verdict() }()
+	if generation == "" {
+		b, err := os.ReadFile(filepath.Join(dir, generationFile))
+		if err != nil {
+			rep.Error = fmt.Sprintf("dataset generation unknown: %v", err)
+			return rep
+		}
+		generation = strings.TrimSpace(string(b))
+	}
+	rep.Generation = generation
+	b, err := os.ReadFile(filepath.Join(ledger, generation+".json"))
+	if err != nil {
+		rep.Error = fmt.Sprintf("generation %s is not in the ledger: %v", generation, err)
+		return rep
+	}
+	var m SeedManifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		rep.Error = fmt.Sprintf("ledger %s: %v", generation, err)
+		return rep
+	}
+	for _, t := range m.Tables {
+		c := checkTable(filepath.Join(dir, t.Name+".tsv"), m.Seed, t)
+		c.Verdict = resultenv.PassFail(c.Missing+c.Corrupt == 0 && c.Error == "")
+		rep.Expected += c.Expected
+		rep.Lost += c.Missing + c.Corrupt
+		rep.Tables = append(rep.Tables, c)
+	}
+	if rep.Expected > 0 {
+		rep.LossRatio = stats.Round5(float64(rep.Lost) / float64(rep.Expected))
+	}
+	rep.OK = rep.LossRatio <= maxLoss
+	return rep
+}
+
+func (rep SeedReport) verdict() resultenv.Verdict {
+	switch {
+	case rep.Error != "":
+		return resultenv.VerdictFailInfra
+	case !rep.OK:
+		return resultenv.VerdictFailSLO
+	case rep.Lost > 0:
+		return resultenv.VerdictPassWithWarnings
+	}
+	return resultenv.VerdictPass
+}
+
+func checkTable(path string, seed int64, t SeedTable) TableCheck {
+	c := TableCheck{Name: t.Name, Expected: t.Rows}
+	got := map[string][2]string{}
+	f, err := os.Open(path)
+	if err == nil {
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			if p := strings.Split(sc.Text(), "\t"); len(p) == 3 {
+				got[p[0]] = [2]string{p[1], p[2]}
+			}
+		}
+		err = sc.Err()
+		f.Close()
+	}
+	if err != nil {
+		c.Error = err.Error()
+	}
+	seedRows(seed, t, func(key, value string) {
+		g, ok := got[key]
+		switch {
+		case !ok:
+			c.Missing++
+		case g[0] != value || g[1] != rowHash(t.Name, key, value):
+			c.Corrupt++
+		default:
+			c.Intact++
+		}
+		delete(got, key)
+	})
+	c.Extra = len(got)
+	return c
+}
+
+func printSeedReport(rep SeedReport) {
+	if rep.Error != "" {
+		fmt.Printf("[%s] seed: %s\n", rep.Verdict, rep.Error)
+		return
+	}
+	for _, c := range rep.Tables {
+		fmt.Printf("[%s] seed %s: %d/%d intact, %d missing, %d corrupt, %d extra", c.Verdict, c.Name, c.Intact, c.Expected, c.Missing, c.Corrupt, c.Extra)
+		if c.Error != "" {
+			fmt.Printf(" (%s)", c.Error)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("seed generation %s: %d/%d rows lost (%.3f%%)\n", rep.Generation, rep.Lost, rep.Expected, rep.LossRatio*100)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// duri_backup.sh의 SRC 아래(백업 대상 안)
+const defaultSentinels = "/home/duri/DuRiWorkspace/.backup_sentinels"
+
+// 파일명이 곧 기록 시각(UTC)
+const sentinelLayout = "20060102T150405.000000000Z"
+
+// Sentinel is one known record written into the backed-up tree; its
+// presence in a restore proves which point in time the restore reached.
+type Sentinel struct {
+	Name  string    `json:"name"`
+	Time  time.Time `json:"time"`
+	Token string    `json:"token"`
+}
+
+func runSentinel(args []string) {
+	fs := flag.NewFlagSet("sentinel", flag.ExitOnError)
+	dir := fs.String("dir", defaultSentinels, "sentinel directory inside the backed-up tree")
+	keep := fs.Int("keep", 500, "keep only the newest N sentinels (0 = all)")
+	fs.Parse(args)
+
+	s, err := writeSentinel(*dir, time.Now())
+	if err != nil {
+		fail(err)
+	}
+	if *keep > 0 {
+		all, err := readSentinels(*dir)
+		if err != nil {
+			fail(err)
+		}
+		for _, old := range all[:max(0, len(all)-*keep)] {
+			os.Remove(filepath.Join(*dir, old.Name))
+		}
+	}
+	fmt.Printf("sentinel %s %s\n", s.Name, s.Time.Format(time.RFC3339Nano))
+}
+
+func writeSentinel(dir string, now time.Time) (Sentinel, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Sentinel{}, err
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return Sentinel{}, err
+	}
+	now = now.UTC()
+	s := Sentinel{Name: now.Format(sentinelLayout) + ".sentinel", Time: now, Token: hex.EncodeToString(b)}
+	return s, os.WriteFile(filepath.Join(dir, s.Name), []byte(s.Token+"\n"), 0o644)
+}
+
+// readSentinels는 dir의 센티넬을 시각순으로 읽는다
+func readSentinels(dir string) ([]Sentinel, error) {
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("sentinels: %w", err)
+	}
+	var out []Sentinel
+	for _, e := range ents {
+		stamp, ok := strings.CutSuffix(e.Name(), ".sentinel")
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(sentinelLayout, stamp)
+		if err != nil {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Sentinel{Name: e.Name(), Time: t, Token: strings.TrimSpace(string(b))})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out, nil
+}
+
+// pickSentinels는 point 이전의 마지막 센티넬과 cutoff 이후의 첫 센티넬을 고른다
+func pickSentinels(all []Sentinel, point, cutoff time.Time) (before, after *Sentinel) {
+	for i := range all {
+		s := &all[i]
+		if s.Time.Before(point) {
+			before = s
+		}
+		if after == nil && s.Time.After(cutoff) {
+			after = s
+		}
+	}
+	return before, after
+}
+
+// presentIn은 복원된 dir에 같은 토큰으로 남아 있는지 본다
+func (s *Sentinel) presentIn(dir string) bool {
+	b, err := os.ReadFile(filepath.Join(dir, s.Name))
+	return err == nil && strings.TrimSpace(string(b)) == s.Token
+}
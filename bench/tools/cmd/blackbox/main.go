@@ -0,0 +1,95 @@
+// Command blackbox runs the probe list (probes.yaml) once and reports each
+// probe; as a gate it exits 1 when any probe fails.
+//
+//	blackbox -config probes.yaml
+//	blackbox -config probes.yaml -only grafana,prometheus-port -json > probes.jsonl
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/blackbox"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+func main() {
+	path := flag.String("config", "probes.yaml", "probe list")
+	only := flag.String("only", "", "comma-separated probe names to run (default all)")
+	jsonOut := flag.Bool("json", false, "print one trace_bench result ABI JSON line per probe instead of the table")
+	out := flag.String("out", "", "also write the ABI JSON lines to this file")
+	budget := flag.Duration("budget", 2*time.Minute, "overall time budget")
+	flag.Parse()
+
+	f, err := blackbox.Load(*path)
+	if err != nil {
+		fail(err)
+	}
+	probes := f.Probes
+	if *only != "" {
+		probes = nil
+		want := map[string]bool{}
+		for _, n := range strings.Split(*only, ",") {
+			want[strings.TrimSpace(n)] = true
+		}
+		for _, p := range f.Probes {
+			if want[p.Name] {
+				probes = append(probes, p)
+				delete(want, p.Name)
+			}
+		}
+		for n := range want {
+			fail(fmt.Errorf("unknown probe %q", n))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *budget)
+	defer cancel()
+	reports := blackbox.RunAll(ctx, probes)
+
+	writeABI := func(w io.Writer) error {
+		for _, r := range reports {
+			if err := output.WriteJSON(w, r.Result()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if *out != "" {
+		if err := output.WriteFileAtomicFunc(*out, writeABI); err != nil {
+			fail(err)
+		}
+	}
+	ok := true
+	var verdicts []resultenv.Verdict
+	for _, r := range reports {
+		ok = ok && r.OK
+		verdicts = append(verdicts, r.Verdict)
+	}
+	if *jsonOut {
+		writeABI(os.Stdout)
+	} else {
+		for _, r := range reports {
+			state := string(r.Verdict)
+			if !r.OK {
+				state += " " + r.LastError
+			}
+			fmt.Printf("%-20s %-5s %d/%d p50=%.2fms p95=%.2fms %s\n", r.Name, r.Prober, r.Attempts-r.Failures, r.Attempts, r.P50ms, r.P95ms, state)
+		}
+		fmt.Printf("BLACKBOX_OK: %v (%s)\n", ok, resultenv.Worst(verdicts...))
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
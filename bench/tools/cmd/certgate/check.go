@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// check는 검증 없이 handshake해 체인을 받은 뒤 직접 검증한다(만료/무효 체인도 남은 일수를 보고하기 위해)
+func check(ctx context.Context, e Endpoint, now time.Time, warn, failWin time.Duration) Check {
+	c := Check{Name: e.Name, Address: e.Address, State: StateFail}
+	host, _, _ := net.SplitHostPort(e.Address)
+	name := e.ServerName
+	if name == "" {
+		name = host
+	}
+	d := tls.Dialer{Config: &tls.Config{ServerName: name, InsecureSkipVerify: true}}
+	conn, err := d.DialContext(ctx, "tcp", e.Address)
+	if err != nil {
+		c.Error = err.Error()
+		return c
+	}
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	conn.Close()
+	if len(certs) == 0 {
+		c.Error = "no certificates served"
+		return c
+	}
+	leaf := certs[0]
+	c.Subject, c.Issuer = leaf.Subject.String(), leaf.Issuer.String()
+
+	// 체인에서 가장 먼저 만료되는 인증서가 실제 만료 시점
+	first := leaf
+	for _, ct := range certs[1:] {
+		if ct.NotAfter.Before(first.NotAfter) {
+			first = ct
+		}
+	}
+	c.NotAfter = first.NotAfter.UTC().Format(time.RFC3339)
+	c.ExpiringCert = first.Subject.String()
+	left := first.NotAfter.Sub(now)
+	c.DaysRemaining = float64(int(left.Hours()/24*10)) / 10
+
+	roots, err := rootPool(e.CAFile)
+	if err != nil {
+		c.Error = err.Error()
+		return c
+	}
+	inter := x509.NewCertPool()
+	for _, ct := range certs[1:] {
+		inter.AddCert(ct)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{DNSName: name, Roots: roots, Intermediates: inter, CurrentTime: now}); err != nil {
+		c.Error = fmt.Sprintf("chain does not verify: %v", err)
+		return c
+	}
+	c.ChainValid = true
+	switch {
+	case left < failWin:
+		c.State = StateFail
+	case left < warn:
+		c.State = StateWarn
+	default:
+		c.State = StatePass
+	}
+	return c
+}
+
+// rootPool은 시스템 루트에 ca_file을 더한다
+func rootPool(caFile string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if caFile == "" {
+		return pool, nil
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no PEM certificates", caFile)
+	}
+	return pool, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/duri/trace_bench/internal/promapi"
+	"github.com/duri/trace_bench/internal/yamlite"
+)
+
+// Config is the endpoint list.
+type Config struct {
+	Version   int        `json:"version"`
+	Warn      string     `json:"warn"`
+	Fail      string     `json:"fail"`
+	Endpoints []Endpoint `json:"endpoints"`
+
+	warn, fail time.Duration
+}
+
+// Endpoint is one TLS server to check.
+type Endpoint struct {
+	Name    string `json:"name"`
+	Address string `json:"address"` // host:port
+	// ServerName overrides SNI and the verified host name (default: host).
+	ServerName string `json:"server_name"`
+	// CAFile adds PEM roots (e.g. the internal CA) to the system pool.
+	CAFile string `json:"ca_file"`
+}
+
+// 기본 창: 30일 이내 warn, 7일 이내 fail
+const (
+	defaultWarn = "30d"
+	defaultFail = "7d"
+)
+
+func loadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := yamlite.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if c.Version != 1 {
+		return nil, fmt.Errorf("%s: unsupported version %d", path, c.Version)
+	}
+	if c.Warn == "" {
+		c.Warn = defaultWarn
+	}
+	if c.Fail == "" {
+		c.Fail = defaultFail
+	}
+	if c.warn, err = promapi.ParseDuration(c.Warn); err != nil {
+		return nil, fmt.Errorf("%s: warn: %w", path, err)
+	}
+	if c.fail, err = promapi.ParseDuration(c.Fail); err != nil {
+		return nil, fmt.Errorf("%s: fail: %w", path, err)
+	}
+	if c.fail > c.warn {
+		return nil, fmt.Errorf("%s: fail window %s is wider than warn window %s", path, c.Fail, c.Warn)
+	}
+	if len(c.Endpoints) == 0 {
+		return nil, fmt.Errorf("%s: no endpoints", path)
+	}
+	seen := map[string]bool{}
+	for i, e := range c.Endpoints {
+		if e.Name == "" || seen[e.Name] {
+			return nil, fmt.Errorf("%s: endpoints[%d]: missing or duplicate name %q", path, i, e.Name)
+		}
+		seen[e.Name] = true
+		if _, _, err := net.SplitHostPort(e.Address); err != nil {
+			return nil, fmt.Errorf("%s: endpoint %s: address must be host:port: %w", path, e.Name, err)
+		}
+	}
+	return &c, nil
+}
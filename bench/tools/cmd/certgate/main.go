@@ -0,0 +1,140 @@
+// Command certgate connects to the configured TLS endpoints, verifies their
+// certificate chains and fails when a chain expires inside the fail window
+// (warn<30d, fail<7d by default). Days remaining per endpoint are reported
+// in the gate JSON and, with -out, as textfile metrics.
+//
+//	certgate -config tls_endpoints.yaml
+//	certgate -config tls_endpoints.yaml -json -out reports/textfile/certgate.prom
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// Endpoint states.
+const (
+	StatePass = "pass"
+	StateWarn = "warn"
+	StateFail = "fail"
+)
+
+// Check is the gate result of one endpoint.
+type Check struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	// DaysRemaining is until the earliest NotAfter in the served chain.
+	DaysRemaining float64 `json:"days_remaining"`
+	NotAfter      string  `json:"not_after,omitempty"`
+	ExpiringCert  string  `json:"expiring_cert,omitempty"` // subject of the earliest-expiring cert
+	Subject       string  `json:"subject,omitempty"`
+	Issuer        string  `json:"issuer,omitempty"`
+	ChainValid    bool    `json:"chain_valid"`
+	State         string  `json:"state"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// Report is the gate JSON.
+type Report struct {
+	Warn      string  `json:"warn"`
+	Fail      string  `json:"fail"`
+	CheckedAt string  `json:"checked_at"`
+	Endpoints []Check `json:"endpoints"`
+	OK        bool    `json:"ok"`
+	// Verdict is the worst endpoint verdict (pkg/resultenv).
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+// verdict: 연결하지 못해 체인을 받지 못한 엔드포인트는 fail_infra
+func (c Check) verdict() resultenv.Verdict {
+	if c.Subject == "" {
+		return resultenv.VerdictFailInfra
+	}
+	v, _ := resultenv.ParseVerdict(c.State)
+	return v
+}
+
+func main() {
+	path := flag.String("config", "tls_endpoints.yaml", "TLS endpoint list")
+	jsonOut := flag.Bool("json", false, "print the gate JSON instead of the summary")
+	out := flag.String("out", "", "write days-remaining metrics as a textfile for the node_exporter textfile collector")
+	timeout := flag.Duration("timeout", 10*time.Second, "per-endpoint handshake timeout")
+	flag.Parse()
+
+	f, err := loadConfig(*path)
+	if err != nil {
+		fail(err)
+	}
+	now := time.Now()
+	rep := Report{Warn: f.Warn, Fail: f.Fail, CheckedAt: now.UTC().Format(time.RFC3339), OK: true}
+	var verdicts []resultenv.Verdict
+	for _, e := range f.Endpoints {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		c := check(ctx, e, now, f.warn, f.fail)
+		cancel()
+		rep.OK = rep.OK && c.State != StateFail
+		rep.Endpoints = append(rep.Endpoints, c)
+		verdicts = append(verdicts, c.verdict())
+	}
+	rep.Verdict = resultenv.Worst(verdicts...)
+
+	if *out != "" {
+		if err := output.WriteFileAtomicFunc(*out, func(w io.Writer) error { return writeTextfile(w, rep) }); err != nil {
+			fail(err)
+		}
+	}
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+	} else {
+		for _, c := range rep.Endpoints {
+			switch {
+			case c.Error != "":
+				fmt.Printf("[FAIL] %s (%s): %s\n", c.Name, c.Address, c.Error)
+			default:
+				fmt.Printf("[%s] %s (%s): %.1fd remaining (%s expires %s)\n", strings.ToUpper(c.State), c.Name, c.Address, c.DaysRemaining, c.ExpiringCert, c.NotAfter)
+			}
+		}
+		fmt.Printf("CERTGATE_OK: %v\n", rep.OK)
+	}
+	if !rep.OK {
+		os.Exit(1)
+	}
+}
+
+// writeTextfile은 알람/대시보드용 gauge를 쓴다
+func writeTextfile(w io.Writer, rep Report) error {
+	fmt.Fprintln(w, "# HELP trace_bench_cert_days_remaining days until the earliest certificate in the served chain expires")
+	fmt.Fprintln(w, "# TYPE trace_bench_cert_days_remaining gauge")
+	for _, c := range rep.Endpoints {
+		if c.NotAfter != "" {
+			fmt.Fprintf(w, "trace_bench_cert_days_remaining{endpoint=%q,address=%q} %.3f\n", c.Name, c.Address, c.DaysRemaining)
+		}
+	}
+	fmt.Fprintln(w, "# HELP trace_bench_cert_chain_valid whether the served chain verifies against the configured roots")
+	fmt.Fprintln(w, "# TYPE trace_bench_cert_chain_valid gauge")
+	for _, c := range rep.Endpoints {
+		v := 0
+		if c.ChainValid {
+			v = 1
+		}
+		fmt.Fprintf(w, "trace_bench_cert_chain_valid{endpoint=%q,address=%q} %d\n", c.Name, c.Address, v)
+	}
+	_, err := fmt.Fprintf(w, "# HELP trace_bench_cert_check_timestamp_seconds time of the last certgate run\n# TYPE trace_bench_cert_check_timestamp_seconds gauge\ntrace_bench_cert_check_timestamp_seconds %d\n", time.Now().Unix())
+	return err
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
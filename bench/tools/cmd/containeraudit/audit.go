@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/duri/trace_bench/internal/dockerapi"
+)
+
+// Finding is one policy violation or drift.
+type Finding struct {
+	Container string `json:"container"`
+	Service   string `json:"service,omitempty"`
+	Check     string `json:"check"` // memory_limit, cpu_limit, restart_policy, healthcheck, drift, missing
+	Message   string `json:"message"`
+}
+
+// inspect는 감사에 필요한 GET /containers/{id}/json 필드
+type inspect struct {
+	Name   string `json:"Name"`
+	Config struct {
+		Labels      map[string]string `json:"Labels"`
+		Healthcheck *struct {
+			Test []string `json:"Test"`
+		} `json:"Healthcheck"`
+	} `json:"Config"`
+	HostConfig struct {
+		Memory        int64 `json:"Memory"`
+		NanoCpus      int64 `json:"NanoCpus"`
+		CpuQuota      int64 `json:"CpuQuota"`
+		CpuPeriod     int64 `json:"CpuPeriod"`
+		RestartPolicy struct {
+			Name string `json:"Name"`
+		} `json:"RestartPolicy"`
+	} `json:"HostConfig"`
+}
+
+func (i inspect) cpus() float64 {
+	h := i.HostConfig
+	switch {
+	case h.NanoCpus > 0:
+		return float64(h.NanoCpus) / 1e9
+	case h.CpuQuota > 0 && h.CpuPeriod > 0:
+		return float64(h.CpuQuota) / float64(h.CpuPeriod)
+	}
+	return 0
+}
+
+func (i inspect) restart() string {
+	if i.HostConfig.RestartPolicy.Name == "" {
+		return "no"
+	}
+	return i.HostConfig.RestartPolicy.Name
+}
+
+func (i inspect) healthcheck() bool {
+	h := i.Config.Healthcheck
+	return h != nil && len(h.Test) > 0 && h.Test[0] != "NONE"
+}
+
+// audit은 실행 중인 컨테이너를 정책과 compose 선언에 비교한다(읽기 전용)
+func audit(ctx context.Context, c *dockerapi.Client, p *Policy, decl map[string]declared) (audited int, fs []Finding, err error) {
+	var list []dockerapi.Container
+	if p.Project != "" {
+		list, err = c.List(ctx, dockerapi.LabelProject+"="+p.Project)
+	} else {
+		list, err = c.List(ctx)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	seen := map[string]bool{}
+	for _, ctr := range list {
+		name := strings.TrimPrefix(firstOr(ctr.Names, ctr.ID), "/")
+		if p.Project == "" && !strings.HasPrefix(name, p.NamePrefix) {
+			continue
+		}
+		var ins inspect
+		if err := c.Get(ctx, "/containers/"+ctr.ID+"/json", &ins); err != nil {
+			return 0, nil, err
+		}
+		service := ins.Config.Labels[dockerapi.LabelService]
+		rules, ok := p.rulesFor(name, service)
+		if !ok {
+			continue
+		}
+		audited++
+		add := func(check, format string, a ...any) {
+			fs = append(fs, Finding{Container: name, Service: service, Check: check, Message: fmt.Sprintf(format, a...)})
+		}
+		mem, cpus := ins.HostConfig.Memory, ins.cpus()
+		if on(rules.MemoryLimit) && mem == 0 {
+			add("memory_limit", "no memory limit")
+		}
+		if rules.maxMemory > 0 && mem > rules.maxMemory {
+			add("memory_limit", "memory limit %s exceeds max_memory %s", fmtBytes(mem), rules.MaxMemory)
+		}
+		if on(rules.CPULimit) && cpus == 0 {
+			add("cpu_limit", "no cpu limit")
+		}
+		if len(rules.RestartPolicy) > 0 && !contains(rules.RestartPolicy, ins.restart()) {
+			add("restart_policy", "restart policy %q not in %v", ins.restart(), rules.RestartPolicy)
+		}
+		if on(rules.Healthcheck) && !ins.healthcheck() {
+			add("healthcheck", "no healthcheck")
+		}
+
+		d, ok := lookupDeclared(decl, name, service)
+		if !ok {
+			if len(decl) > 0 {
+				add("drift", "not declared in any compose file")
+			}
+			continue
+		}
+		seen[d.Service] = true
+		if d.Memory != mem {
+			add("drift", "memory limit %s, compose declares %s (%s)", fmtBytes(mem), fmtBytes(d.Memory), d.File)
+		}
+		if math.Abs(d.CPUs-cpus) > 1e-6 {
+			add("drift", "cpu limit %g, compose declares %g (%s)", cpus, d.CPUs, d.File)
+		}
+		if want := orNo(d.Restart); want != ins.restart() {
+			add("drift", "restart policy %q, compose declares %q (%s)", ins.restart(), want, d.File)
+		}
+		if d.Healthcheck != ins.healthcheck() {
+			add("drift", "healthcheck present=%v, compose declares %v (%s)", ins.healthcheck(), d.Healthcheck, d.File)
+		}
+	}
+	var missing []string
+	for name := range decl {
+		if !seen[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, s := range missing {
+		d := decl[s]
+		if _, ok := p.rulesFor(d.ContainerName, s); !ok {
+			continue
+		}
+		fs = append(fs, Finding{Container: d.ContainerName, Service: s, Check: "missing", Message: fmt.Sprintf("declared in %s but not running", d.File)})
+	}
+	return audited, fs, nil
+}
+
+// lookupDeclared는 compose 서비스 라벨, 없으면 container_name으로 선언을 찾는다
+func lookupDeclared(decl map[string]declared, name, service string) (declared, bool) {
+	if d, ok := decl[service]; ok {
+		return d, true
+	}
+	for _, d := range decl {
+		if d.ContainerName != "" && d.ContainerName == name {
+			return d, true
+		}
+	}
+	return declared{}, false
+}
+
+func on(b *bool) bool { return b != nil && *b }
+
+func orNo(s string) string {
+	if s == "" {
+		return "no"
+	}
+	return s
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func firstOr(list []string, def string) string {
+	if len(list) > 0 {
+		return list[0]
+	}
+	return def
+}
+
+func fmtBytes(n int64) string {
+	if n == 0 {
+		return "none"
+	}
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	v, i := float64(n), 0
+	for ; v >= 1024 && i < len(units)-1; i++ {
+		v /= 1024
+	}
+	return fmt.Sprintf("%g%s", math.Round(v*100)/100, units[i])
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/duri/trace_bench/internal/yamlite"
+)
+
+// declared는 compose 파일에 선언된 서비스의 감사 대상 설정
+type declared struct {
+	File          string
+	Service       string
+	ContainerName string
+	Memory        int64   // 0 = 제한 없음
+	CPUs          float64 // 0 = 제한 없음
+	Restart       string  // 빈 값 = "no"
+	Healthcheck   bool
+}
+
+type composeFile struct {
+	Services map[string]struct {
+		ContainerName string `json:"container_name"`
+		Restart       string `json:"restart"`
+		MemLimit      any    `json:"mem_limit"`
+		CPUs          any    `json:"cpus"`
+		Healthcheck   *struct {
+			Disable bool `json:"disable"`
+			Test    any  `json:"test"`
+		} `json:"healthcheck"`
+		Deploy struct {
+			Resources struct {
+				Limits struct {
+					CPUs   any `json:"cpus"`
+					Memory any `json:"memory"`
+				} `json:"limits"`
+			} `json:"resources"`
+			RestartPolicy struct {
+				Condition string `json:"condition"`
+			} `json:"restart_policy"`
+		} `json:"deploy"`
+	} `json:"services"`
+}
+
+// loadCompose는 여러 compose 파일의 서비스를 합친다(뒤 파일이 같은 서비스를 덮어씀)
+func loadCompose(paths []string) (map[string]declared, error) {
+	out := map[string]declared{}
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var f composeFile
+		if err := yamlite.Unmarshal(b, &f); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for name, s := range f.Services {
+			d := declared{File: path, Service: name, ContainerName: s.ContainerName, Restart: s.Restart}
+			if d.Restart == "" {
+				// swarm 형식 deploy.restart_policy(any → always 와 같은 의미)
+				switch s.Deploy.RestartPolicy.Condition {
+				case "any":
+					d.Restart = "always"
+				case "on-failure":
+					d.Restart = "on-failure"
+				}
+			}
+			mem := s.Deploy.Resources.Limits.Memory
+			if mem == nil {
+				mem = s.MemLimit
+			}
+			if mem != nil {
+				if d.Memory, err = parseDockerBytes(fmt.Sprint(mem)); err != nil {
+					return nil, fmt.Errorf("%s: service %s: memory: %w", path, name, err)
+				}
+			}
+			cpus := s.Deploy.Resources.Limits.CPUs
+			if cpus == nil {
+				cpus = s.CPUs
+			}
+			if cpus != nil {
+				if d.CPUs, err = strconv.ParseFloat(fmt.Sprint(cpus), 64); err != nil {
+					return nil, fmt.Errorf("%s: service %s: invalid cpus %v", path, name, cpus)
+				}
+			}
+			d.Healthcheck = s.Healthcheck != nil && !s.Healthcheck.Disable && fmt.Sprint(s.Healthcheck.Test) != "[NONE]"
+			out[name] = d
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,83 @@
+// Command containeraudit inspects the running DuRi containers through the
+// Docker API and checks CPU/memory limits, restart policies and healthchecks
+// against a policy file, flagging drift from the declared compose
+// configuration. It only reads; infra files and containers are never
+// modified.
+//
+//	containeraudit -policy container_policy.yaml
+//	DOCKER_HOST=tcp://host:2375 containeraudit -policy container_policy.yaml -json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/duri/trace_bench/internal/dockerapi"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// Report is the gate JSON.
+type Report struct {
+	Policy   string    `json:"policy"`
+	Audited  int       `json:"audited"`
+	Findings []Finding `json:"findings"`
+	OK       bool      `json:"ok"`
+	// Verdict is OK as a pkg/resultenv verdict.
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+func main() {
+	path := flag.String("policy", "container_policy.yaml", "audit policy")
+	noDrift := flag.Bool("no-drift", false, "skip the comparison with the compose files")
+	jsonOut := flag.Bool("json", false, "print the gate JSON instead of the summary")
+	flag.Parse()
+
+	p, err := loadPolicy(*path)
+	if err != nil {
+		fail(err)
+	}
+	var decl map[string]declared
+	if !*noDrift {
+		if decl, err = loadCompose(p.Compose); err != nil {
+			fail(err)
+		}
+	}
+	c, err := dockerapi.New()
+	if err != nil {
+		fail(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	n, fs, err := audit(ctx, c, p, decl)
+	if err != nil {
+		fail(err)
+	}
+	rep := Report{Policy: *path, Audited: n, Findings: fs, OK: len(fs) == 0}
+	rep.Verdict = resultenv.PassFail(rep.OK)
+	if rep.Findings == nil {
+		rep.Findings = []Finding{}
+	}
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+	} else {
+		for _, f := range fs {
+			fmt.Printf("[FAIL] %s %s: %s\n", f.Container, f.Check, f.Message)
+		}
+		fmt.Printf("audited %d containers, %d findings\n", n, len(fs))
+		fmt.Printf("CONTAINERAUDIT_OK: %v\n", rep.OK)
+	}
+	if !rep.OK {
+		os.Exit(1)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
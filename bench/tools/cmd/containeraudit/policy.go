@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/duri/trace_bench/internal/yamlite"
+)
+
+// Policy is the container audit policy file.
+type Policy struct {
+	Version int `json:"version"`
+	// Compose files declaring the stack; live containers are compared
+	// against them (drift). Relative paths are relative to the policy file.
+	Compose []string `json:"compose"`
+	// Project selects containers by compose project label; NamePrefix
+	// selects by container name when Project is empty.
+	Project    string `json:"project"`
+	NamePrefix string `json:"name_prefix"`
+	Require    Rules  `json:"require"`
+	// Containers override Require per container name or compose service.
+	Containers []Override `json:"containers"`
+}
+
+// Rules are the requirements of one container.
+type Rules struct {
+	MemoryLimit   *bool    `json:"memory_limit"`
+	CPULimit      *bool    `json:"cpu_limit"`
+	Healthcheck   *bool    `json:"healthcheck"`
+	RestartPolicy []string `json:"restart_policy"`
+	MaxMemory     string   `json:"max_memory"`
+
+	maxMemory int64
+}
+
+// Override applies Rules to the container or service Name.
+type Override struct {
+	Name string `json:"name"`
+	Rules
+	// Ignore skips the container entirely (e.g. one-shot jobs).
+	Ignore bool `json:"ignore"`
+}
+
+func loadPolicy(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	if err := yamlite.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if p.Version != 1 {
+		return nil, fmt.Errorf("%s: unsupported version %d", path, p.Version)
+	}
+	if p.Project == "" && p.NamePrefix == "" {
+		return nil, fmt.Errorf("%s: set project or name_prefix to select the containers", path)
+	}
+	for i, c := range p.Compose {
+		if !filepath.IsAbs(c) {
+			p.Compose[i] = filepath.Join(filepath.Dir(path), c)
+		}
+	}
+	if err := p.Require.compile(); err != nil {
+		return nil, fmt.Errorf("%s: require: %w", path, err)
+	}
+	for i := range p.Containers {
+		if err := p.Containers[i].compile(); err != nil {
+			return nil, fmt.Errorf("%s: containers[%d]: %w", path, i, err)
+		}
+	}
+	return &p, nil
+}
+
+func (r *Rules) compile() error {
+	if r.MaxMemory != "" {
+		n, err := parseDockerBytes(r.MaxMemory)
+		if err != nil {
+			return fmt.Errorf("max_memory: %w", err)
+		}
+		r.maxMemory = n
+	}
+	return nil
+}
+
+// rulesFor는 require에 이름/서비스가 맞는 override를 덮어쓴 규칙(ignore면 ok=false)
+func (p *Policy) rulesFor(name, service string) (r Rules, ok bool) {
+	r = p.Require
+	for _, o := range p.Containers {
+		if o.Name != name && o.Name != service {
+			continue
+		}
+		if o.Ignore {
+			return r, false
+		}
+		if o.MemoryLimit != nil {
+			r.MemoryLimit = o.MemoryLimit
+		}
+		if o.CPULimit != nil {
+			r.CPULimit = o.CPULimit
+		}
+		if o.Healthcheck != nil {
+			r.Healthcheck = o.Healthcheck
+		}
+		if o.RestartPolicy != nil {
+			r.RestartPolicy = o.RestartPolicy
+		}
+		if o.MaxMemory != "" {
+			r.MaxMemory, r.maxMemory = o.MaxMemory, o.maxMemory
+		}
+	}
+	return r, true
+}
+
+// parseDockerBytes는 compose/docker 크기 표기(512m, 1g, 1GiB, 1073741824)를 읽는다(1024배 단위)
+func parseDockerBytes(s string) (int64, error) {
+	t := strings.ToLower(strings.TrimSpace(s))
+	t = strings.TrimSuffix(strings.TrimSuffix(t, "ib"), "b")
+	mult := int64(1)
+	if t != "" {
+		switch t[len(t)-1] {
+		case 'k':
+			mult = 1 << 10
+		case 'm':
+			mult = 1 << 20
+		case 'g':
+			mult = 1 << 30
+		case 't':
+			mult = 1 << 40
+		}
+		if mult > 1 {
+			t = t[:len(t)-1]
+		}
+	}
+	f, err := strconv.ParseFloat(t, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(f * float64(mult)), nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/duri/trace_bench/contract"
+)
+
+// Snapshot is the metrics ABI snapshot: every metric name a Prometheus
+// server exposed when `dashguard snapshot` ran.
+type Snapshot struct {
+	Version     int      `json:"version"`
+	Source      string   `json:"source,omitempty"`
+	GeneratedAt string   `json:"generated_at,omitempty"`
+	Metrics     []string `json:"metrics"`
+}
+
+// Prometheus가 스크레이프/알림 평가 시 직접 만드는 시계열
+var builtinMetrics = []string{
+	"up", "scrape_duration_seconds", "scrape_samples_scraped", "scrape_samples_post_metric_relabeling",
+	"scrape_series_added", "ALERTS", "ALERTS_FOR_STATE",
+}
+
+// abi는 대시보드가 참조해도 되는 메트릭 이름 집합과 각 이름의 출처
+type abi map[string]string
+
+func (a abi) add(name, source string) {
+	if _, ok := a[name]; !ok {
+		a[name] = source
+	}
+}
+
+func newABI() abi {
+	a := abi{}
+	for _, m := range builtinMetrics {
+		a.add(m, "builtin")
+	}
+	return a
+}
+
+// loadSnapshot은 dashguard snapshot 출력이나 이름 배열(JSON)을 읽는다
+func (a abi) loadSnapshot(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(b, &s.Metrics); err != nil {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for _, m := range s.Metrics {
+		a.add(m, path)
+	}
+	return nil
+}
+
+// loadContract는 선언된 메트릭과 histogram의 _bucket/_sum/_count를 더한다
+func (a abi) loadContract(path string) error {
+	c, err := contract.Load(path)
+	if err != nil {
+		return err
+	}
+	for _, m := range c.Metrics {
+		a.add(m.Name, path)
+		if m.Type == contract.Histogram {
+			for _, sfx := range []string{"_bucket", "_sum", "_count"} {
+				a.add(m.Name+sfx, path)
+			}
+		}
+	}
+	return nil
+}
+
+var recordRe = regexp.MustCompile(`^\s*(?:-\s*)?record:\s*["']?([a-zA-Z_:][a-zA-Z0-9_:]*)["']?\s*(?:#.*)?$`)
+
+// loadRules는 규칙 파일의 recording rule 이름을 더한다
+// (규칙 파일에는 yamlite가 다루지 않는 flow mapping이 흔해 줄 단위로 읽는다)
+func (a abi) loadRules(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if m := recordRe.FindStringSubmatch(sc.Text()); m != nil {
+			a.add(m[1], path)
+		}
+	}
+	return sc.Err()
+}
+
+// ruleFiles는 prometheus.yml의 rule_files 글롭을 저장소 경로로 푼다
+// 상대 경로는 root(컨테이너의 config 디렉터리에 마운트되는 저장소 디렉터리) 기준이고,
+// 절대 경로는 configDir 접두사를 root로 바꾼다. 찾지 못한 항목은 missing으로 돌려준다
+func ruleFiles(promYML, root, configDir string) (files, missing []string, err error) {
+	globs, err := ruleFileGlobs(promYML)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, g := range globs {
+		p := g
+		switch {
+		case strings.HasPrefix(g, configDir+"/"):
+			p = filepath.Join(root, strings.TrimPrefix(g, configDir+"/"))
+		case !filepath.IsAbs(g):
+			p = filepath.Join(root, g)
+		}
+		m, _ := filepath.Glob(p)
+		if len(m) == 0 {
+			missing = append(missing, g)
+		}
+		files = append(files, m...)
+	}
+	sort.Strings(files)
+	return files, missing, nil
+}
+
+// ruleFileGlobs는 최상위 rule_files: 블록의 "- 경로" 항목만 읽는다
+func ruleFileGlobs(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []string
+	in := false
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		t := strings.TrimSpace(line)
+		switch {
+		case t == "" || strings.HasPrefix(t, "#"):
+		case strings.HasPrefix(line, "rule_files:"):
+			in = true
+		case in && strings.HasPrefix(t, "- "):
+			out = append(out, strings.Trim(strings.TrimSpace(strings.TrimPrefix(t, "- ")), `"'`))
+		default:
+			if line[0] != ' ' && line[0] != '\t' {
+				in = false
+			}
+		}
+	}
+	return out, sc.Err()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Dashboard is the part of a Grafana dashboard JSON dashguard reads.
+type Dashboard struct {
+	Path   string  `json:"-"`
+	UID    string  `json:"uid"`
+	Title  string  `json:"title"`
+	Panels []Panel `json:"panels"`
+	// Rows는 schemaVersion 16 이전 형식
+	Rows []struct {
+		Panels []Panel `json:"panels"`
+	} `json:"rows"`
+}
+
+// Panel is one panel; rows nest their collapsed panels.
+type Panel struct {
+	ID         int             `json:"id"`
+	Title      string          `json:"title"`
+	Datasource json.RawMessage `json:"datasource"`
+	Targets    []Target        `json:"targets"`
+	Panels     []Panel         `json:"panels"`
+}
+
+// Target is one panel query.
+type Target struct {
+	RefID      string          `json:"refId"`
+	Expr       string          `json:"expr"`
+	Datasource json.RawMessage `json:"datasource"`
+}
+
+// loadDashboards는 경로(파일 또는 디렉터리의 *.json)에서 대시보드를 읽는다
+// provisioning API 형식({"dashboard": {...}})도 받는다
+func loadDashboards(paths []string) ([]Dashboard, error) {
+	var files []string
+	for _, p := range paths {
+		st, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !st.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		m, _ := filepath.Glob(filepath.Join(p, "*.json"))
+		files = append(files, m...)
+	}
+	sort.Strings(files)
+	var out []Dashboard
+	for _, f := range files {
+		d, err := readDashboard(f)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func readDashboard(path string) (Dashboard, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Dashboard{}, err
+	}
+	var wrap struct {
+		Dashboard *json.RawMessage `json:"dashboard"`
+	}
+	if err := json.Unmarshal(b, &wrap); err != nil {
+		return Dashboard{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if wrap.Dashboard != nil {
+		b = *wrap.Dashboard
+	}
+	var d Dashboard
+	if err := json.Unmarshal(b, &d); err != nil {
+		return Dashboard{}, fmt.Errorf("%s: %w", path, err)
+	}
+	d.Path = path
+	return d, nil
+}
+
+// allPanels는 row에 접힌 패널까지 펼친 목록
+func (d Dashboard) allPanels() []Panel {
+	var out []Panel
+	var walk func(ps []Panel)
+	walk = func(ps []Panel) {
+		for _, p := range ps {
+			out = append(out, p)
+			walk(p.Panels)
+		}
+	}
+	walk(d.Panels)
+	for _, r := range d.Rows {
+		walk(r.Panels)
+	}
+	return out
+}
+
+// prometheusTarget는 datasource가 Prometheus가 아니라고 명시된 query를 거른다
+// (target → panel 순으로 보고, 지정이 없거나 이름/변수만 있으면 Prometheus로 간주)
+func prometheusTarget(p Panel, t Target) bool {
+	ds := t.Datasource
+	if len(ds) == 0 || string(ds) == "null" {
+		ds = p.Datasource
+	}
+	var ref struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(ds, &ref) == nil && ref.Type != "" {
+		return ref.Type == "prometheus"
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LCS 표가 이보다 크면 가운데 블록을 통째로 -/+로 보인다
+const maxDiffCells = 4 << 20
+
+// writeLineDiff는 a→b의 줄 단위 차이를 변경 줄 앞뒤 context줄과 함께 -/+로 쓴다
+func writeLineDiff(w io.Writer, a, b string, context int) {
+	al, bl := strings.Split(a, "\n"), strings.Split(b, "\n")
+	// 공통 접두/접미를 먼저 잘라 LCS 크기를 줄인다
+	pre := 0
+	for pre < len(al) && pre < len(bl) && al[pre] == bl[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(al)-pre && suf < len(bl)-pre && al[len(al)-1-suf] == bl[len(bl)-1-suf] {
+		suf++
+	}
+	am, bm := al[pre:len(al)-suf], bl[pre:len(bl)-suf]
+
+	type op struct {
+		kind byte // ' ', '-', '+'
+		line string
+	}
+	var ops []op
+	for _, l := range al[:pre] {
+		ops = append(ops, op{' ', l})
+	}
+	if len(am)*len(bm) > maxDiffCells {
+		for _, l := range am {
+			ops = append(ops, op{'-', l})
+		}
+		for _, l := range bm {
+			ops = append(ops, op{'+', l})
+		}
+	} else {
+		// lcs[i][j] = am[i:], bm[j:]의 LCS 길이
+		lcs := make([][]int, len(am)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(bm)+1)
+		}
+		for i := len(am) - 1; i >= 0; i-- {
+			for j := len(bm) - 1; j >= 0; j-- {
+				if am[i] == bm[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(am) || j < len(bm) {
+			switch {
+			case i < len(am) && j < len(bm) && am[i] == bm[j]:
+				ops = append(ops, op{' ', am[i]})
+				i++
+				j++
+			case j < len(bm) && (i == len(am) || lcs[i][j+1] >= lcs[i+1][j]):
+				ops = append(ops, op{'+', bm[j]})
+				j++
+			default:
+				ops = append(ops, op{'-', am[i]})
+				i++
+			}
+		}
+	}
+	for _, l := range al[len(al)-suf:] {
+		ops = append(ops, op{' ', l})
+	}
+
+	// 변경 줄 주변 context줄만 출력
+	show := make([]bool, len(ops))
+	for k, o := range ops {
+		if o.kind == ' ' {
+			continue
+		}
+		for c := max(0, k-context); c <= min(len(ops)-1, k+context); c++ {
+			show[c] = true
+		}
+	}
+	gap := false
+	for k, o := range ops {
+		if !show[k] {
+			gap = true
+			continue
+		}
+		if gap {
+			fmt.Fprintln(w, "  ...")
+			gap = false
+		}
+		fmt.Fprintf(w, "%c %s\n", o.kind, o.line)
+	}
+}
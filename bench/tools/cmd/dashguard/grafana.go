@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/internal/secretref"
+)
+
+// grafana는 Grafana HTTP API 클라이언트(GRAFANA_URL/GRAFANA_TOKEN 관례는 tools/annotate_grafana.sh와 같음)
+type grafana struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+// grafanaFlags는 render/push가 공유하는 접속 플래그
+type grafanaFlags struct {
+	url, token *string
+	timeout    *time.Duration
+}
+
+func addGrafanaFlags(fs *flag.FlagSet) grafanaFlags {
+	def := os.Getenv("GRAFANA_URL")
+	if def == "" {
+		def = "http://localhost:3000"
+	}
+	return grafanaFlags{
+		url:     fs.String("grafana-url", def, "Grafana base URL (default $GRAFANA_URL)"),
+		token:   fs.String("token", "", "Grafana API token or secretref://env/NAME|file/PATH (default $GRAFANA_TOKEN; empty = anonymous)"),
+		timeout: fs.Duration("timeout", 30*time.Second, "HTTP timeout of one Grafana request"),
+	}
+}
+
+func (f grafanaFlags) client() (*grafana, error) {
+	tok, err := secretref.Resolve(*f.token)
+	if *f.token == "" {
+		tok, err = secretref.Env("GRAFANA_TOKEN")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &grafana{base: strings.TrimRight(*f.url, "/"), token: tok, http: &http.Client{Timeout: *f.timeout}}, nil
+}
+
+// apiError는 Grafana가 돌려준 비 2xx 응답
+type apiError struct {
+	Path   string
+	Status int
+	Msg    string
+}
+
+func (e *apiError) Error() string {
+	if e.Msg != "" {
+		return fmt.Sprintf("grafana %s: %d %s", e.Path, e.Status, e.Msg)
+	}
+	return fmt.Sprintf("grafana %s: %d", e.Path, e.Status)
+}
+
+// do는 요청을 보내고 2xx가 아니면 *apiError를 돌려준다(body는 호출자가 닫음)
+func (g *grafana) do(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, g.base+path, rd)
+	if err != nil {
+		return nil, err
+	}
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := g.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("grafana: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		var m struct {
+			Message string `json:"message"`
+		}
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		json.Unmarshal(b, &m)
+		return nil, &apiError{Path: strings.SplitN(path, "?", 2)[0], Status: resp.StatusCode, Msg: m.Message}
+	}
+	return resp, nil
+}
+
+// getJSON은 GET 후 v로 디코딩한다
+func (g *grafana) getJSON(ctx context.Context, path string, v any) error {
+	resp, err := g.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// notFound는 404 응답인지 본다
+func notFound(err error) bool {
+	ae, ok := err.(*apiError)
+	return ok && ae.Status == http.StatusNotFound
+}
@@ -0,0 +1,216 @@
+// Command dashguard guards the Grafana dashboards against drift from the
+// metrics actually exported: every panel query's metric names must exist
+// in the metrics ABI snapshot, the metrics contract or a recording rule.
+// It also smoke-renders the provisioned dashboards through the Grafana
+// render API and pushes the repo's dashboards to Grafana, so the repo is
+// the source of truth.
+//
+//	dashguard snapshot -prom http://localhost:9090 -out metrics_abi.json
+//	dashguard check -abi metrics_abi.json
+//	dashguard render -grafana-url http://localhost:3000 -budget 30s
+//	dashguard push -grafana-url http://localhost:3000 -folder DuRi -dry-run
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/internal/promapi"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: dashguard <command> [flags]\n\ncommands:\n  check       report panels whose queries reference unknown metrics\n  snapshot    write the metrics ABI snapshot from a Prometheus server\n  render      render each dashboard via the Grafana render API within a time budget\n  push        upload the repo dashboards to a Grafana folder (diff preview, -dry-run)")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "check":
+		check(os.Args[2:])
+	case "snapshot":
+		snapshot(os.Args[2:])
+	case "render":
+		render(os.Args[2:])
+	case "push":
+		push(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+// Finding is one panel query referencing metrics outside the ABI.
+type Finding struct {
+	Dashboard string   `json:"dashboard"`
+	UID       string   `json:"uid,omitempty"`
+	PanelID   int      `json:"panel_id"`
+	Panel     string   `json:"panel"`
+	RefID     string   `json:"ref_id,omitempty"`
+	Expr      string   `json:"expr"`
+	Missing   []string `json:"missing"`
+}
+
+// Report is the check output.
+type Report struct {
+	Dashboards int       `json:"dashboards"`
+	Queries    int       `json:"queries"`
+	Known      int       `json:"known_metrics"`
+	Findings   []Finding `json:"findings"`
+	OK         bool      `json:"ok"`
+	// Verdict is OK as a pkg/resultenv verdict.
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+func check(args []string) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	dashboards := fs.String("dashboards", "../grafana/dashboards", "comma-separated dashboard JSON files or directories")
+	abiPath := fs.String("abi", "", "metrics ABI snapshot (dashguard snapshot output or a JSON array of names); required")
+	contractPath := fs.String("contract", "metrics.yaml", "metrics contract; its metrics count as known (empty to skip)")
+	promYML := fs.String("prometheus", "../prometheus/prometheus.yml", "Prometheus config; recording rules in its rule_files count as known (empty to skip)")
+	root := fs.String("root", "..", "repository directory mounted as the Prometheus config directory")
+	configDir := fs.String("config-dir", "/etc/prometheus", "Prometheus config directory inside the container (absolute rule_files under it map to -root)")
+	extraRules := fs.String("rules", "", "comma-separated extra rule file globs whose recording rules count as known")
+	jsonOut := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+
+	if *abiPath == "" {
+		fail(fmt.Errorf("-abi is required (create one with dashguard snapshot)"))
+	}
+	known := newABI()
+	if err := known.loadSnapshot(*abiPath); err != nil {
+		fail(err)
+	}
+	if *contractPath != "" {
+		if err := known.loadContract(*contractPath); err != nil {
+			fail(err)
+		}
+	}
+	if *promYML != "" {
+		files, missing, err := ruleFiles(*promYML, *root, strings.TrimRight(*configDir, "/"))
+		if err != nil {
+			fail(err)
+		}
+		for _, g := range missing {
+			fmt.Fprintf(os.Stderr, "[WARN] %s: rule_files %s matched no file under %s\n", *promYML, g, *root)
+		}
+		for _, f := range files {
+			if err := known.loadRules(f); err != nil {
+				fail(err)
+			}
+		}
+	}
+	for _, g := range splitList(*extraRules) {
+		files, _ := filepath.Glob(g)
+		if len(files) == 0 {
+			fail(fmt.Errorf("-rules %s matched no file", g))
+		}
+		for _, f := range files {
+			if err := known.loadRules(f); err != nil {
+				fail(err)
+			}
+		}
+	}
+	ds, err := loadDashboards(splitList(*dashboards))
+	if err != nil {
+		fail(err)
+	}
+
+	rep := Report{Dashboards: len(ds), Known: len(known), Findings: []Finding{}}
+	for _, d := range ds {
+		for _, p := range d.allPanels() {
+			for _, t := range p.Targets {
+				if t.Expr == "" || !prometheusTarget(p, t) {
+					continue
+				}
+				rep.Queries++
+				var missing []string
+				for _, m := range metricNames(t.Expr) {
+					if _, ok := known[m]; !ok {
+						missing = append(missing, m)
+					}
+				}
+				if len(missing) > 0 {
+					rep.Findings = append(rep.Findings, Finding{
+						Dashboard: d.Path, UID: d.UID, PanelID: p.ID, Panel: p.Title,
+						RefID: t.RefID, Expr: t.Expr, Missing: missing,
+					})
+				}
+			}
+		}
+	}
+	rep.OK = len(rep.Findings) == 0
+	rep.Verdict = resultenv.PassFail(rep.OK)
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+	} else {
+		writeFindings(os.Stdout, rep)
+	}
+	if !rep.OK {
+		os.Exit(1)
+	}
+}
+
+func writeFindings(w io.Writer, rep Report) {
+	for _, f := range rep.Findings {
+		fmt.Fprintf(w, "%s: panel %d %q", f.Dashboard, f.PanelID, f.Panel)
+		if f.RefID != "" {
+			fmt.Fprintf(w, " ref %s", f.RefID)
+		}
+		fmt.Fprintf(w, ": unknown metric %s\n", strings.Join(f.Missing, ", "))
+	}
+	fmt.Fprintf(w, "dashboards=%d queries=%d known_metrics=%d findings=%d\nDASHGUARD_OK: %v\n",
+		rep.Dashboards, rep.Queries, rep.Known, len(rep.Findings), rep.OK)
+}
+
+func snapshot(args []string) {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	prom := fs.String("prom", "http://localhost:9090", "Prometheus base URL")
+	out := fs.String("out", "metrics_abi.json", "snapshot file")
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	names, err := promapi.New(*prom).LabelValues(ctx, "__name__")
+	if err != nil {
+		fail(err)
+	}
+	sort.Strings(names)
+	s := Snapshot{Version: 1, Source: *prom, GeneratedAt: time.Now().UTC().Format(time.RFC3339), Metrics: names}
+	if err := output.WriteFileAtomicFunc(*out, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}); err != nil {
+		fail(err)
+	}
+	fmt.Fprintf(os.Stderr, "[OK] %d metrics -> %s\n", len(names), *out)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
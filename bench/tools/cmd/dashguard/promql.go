@@ -0,0 +1,150 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// 메트릭 이름이 아닌 PromQL 키워드(집계 수식어, 이항 연산자)
+var promKeywords = map[string]bool{
+	"by": true, "without": true, "on": true, "ignoring": true, "group_left": true, "group_right": true,
+	"bool": true, "and": true, "or": true, "unless": true, "offset": true, "atan2": true,
+	"inf": true, "nan": true,
+}
+
+// 뒤따르는 괄호가 label 목록인 키워드
+var labelListKeywords = map[string]bool{
+	"by": true, "without": true, "on": true, "ignoring": true, "group_left": true, "group_right": true,
+}
+
+// metricNames returns the metric names an expression selects, sorted and
+// deduplicated. It is a tokenizer rather than a parser: identifiers are
+// metric names unless they call a function, are keywords or label lists,
+// or sit inside strings, label matchers ({...}) or ranges ([...]). Grafana
+// variables ($var, ${var}, [[var]]) are skipped.
+func metricNames(expr string) []string {
+	seen := map[string]bool{}
+	skipList := false // 직전 토큰이 by/on 등: 다음 (...)은 label 목록
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			i = skipString(expr, i)
+		case c == '{':
+			i = skipTo(expr, i, '{', '}')
+		case c == '[':
+			i = skipTo(expr, i, '[', ']')
+		case c == '(' && skipList:
+			i = skipTo(expr, i, '(', ')')
+			skipList = false
+		case c == '$':
+			i++
+			if i < len(expr) && expr[i] == '{' {
+				i = skipTo(expr, i, '{', '}')
+				continue
+			}
+			for i < len(expr) && isIdent(expr[i], true) {
+				i++
+			}
+		case c >= '0' && c <= '9' || c == '.':
+			// 숫자/기간 리터럴(5m, 1e3, 0x1f)
+			for i < len(expr) && (isIdent(expr[i], true) || expr[i] == '.') {
+				i++
+			}
+		case isIdent(c, false):
+			j := i
+			for j < len(expr) && isIdent(expr[j], true) {
+				j++
+			}
+			word := expr[i:j]
+			i = j
+			lw := strings.ToLower(word)
+			if promKeywords[lw] {
+				skipList = labelListKeywords[lw]
+				continue
+			}
+			skipList = false
+			if next := nextNonSpace(expr, i); next == '(' {
+				continue // 함수/집계
+			}
+			if aggregations[lw] && labelListKeywords[nextWord(expr, i)] {
+				continue // sum by (...) (...)
+			}
+			seen[word] = true
+		default:
+			i++
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for n := range seen {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// 수식어가 앞에 올 수 있는 집계 연산자
+var aggregations = map[string]bool{
+	"sum": true, "min": true, "max": true, "avg": true, "group": true, "stddev": true, "stdvar": true,
+	"count": true, "count_values": true, "bottomk": true, "topk": true, "quantile": true,
+	"limitk": true, "limit_ratio": true,
+}
+
+func nextWord(s string, i int) string {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r') {
+		i++
+	}
+	j := i
+	for j < len(s) && isIdent(s[j], true) {
+		j++
+	}
+	return strings.ToLower(s[i:j])
+}
+
+func isIdent(c byte, rest bool) bool {
+	return c == '_' || c == ':' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || rest && c >= '0' && c <= '9'
+}
+
+func nextNonSpace(s string, i int) byte {
+	for ; i < len(s); i++ {
+		if s[i] != ' ' && s[i] != '\t' && s[i] != '\n' && s[i] != '\r' {
+			return s[i]
+		}
+	}
+	return 0
+}
+
+// skipString은 닫는 따옴표 다음 위치를 돌려준다(백틱 외에는 \ 이스케이프)
+func skipString(s string, i int) int {
+	q := s[i]
+	for i++; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && q != '`':
+			i++
+		case s[i] == q:
+			return i + 1
+		}
+	}
+	return i
+}
+
+// skipTo는 open..close 쌍(중첩, 문자열 포함)을 건너뛴다
+func skipTo(s string, i int, open, close byte) int {
+	depth := 0
+	for i < len(s) {
+		switch s[i] {
+		case '"', '\'', '`':
+			i = skipString(s, i)
+			continue
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return i
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+)
+
+// Grafana가 저장할 때 바꾸는 필드(비교에서 제외)
+var volatileFields = []string{"id", "version", "iteration"}
+
+func push(args []string) {
+	fs := flag.NewFlagSet("push", flag.ExitOnError)
+	gf := addGrafanaFlags(fs)
+	dashboards := fs.String("dashboards", "../grafana/dashboards", "comma-separated dashboard JSON files or directories")
+	folder := fs.String("folder", "DuRi", "Grafana folder title (created if missing)")
+	dryRun := fs.Bool("dry-run", false, "print the diff preview only; change nothing in Grafana")
+	message := fs.String("message", "dashguard push", "version history message")
+	ctxLines := fs.Int("context", 3, "diff context lines")
+	fs.Parse(args)
+
+	g, err := gf.client()
+	if err != nil {
+		fail(err)
+	}
+	local, err := loadRawDashboards(splitList(*dashboards))
+	if err != nil {
+		fail(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	folderUID, err := ensureFolder(ctx, g, *folder, *dryRun)
+	if err != nil {
+		fail(err)
+	}
+	var created, updated, unchanged int
+	for _, d := range local {
+		uid, _ := d.body["uid"].(string)
+		title, _ := d.body["title"].(string)
+		var live struct {
+			Dashboard map[string]any `json:"dashboard"`
+			Meta      struct {
+				FolderUID string `json:"folderUid"`
+			} `json:"meta"`
+		}
+		err := g.getJSON(ctx, "/api/dashboards/uid/"+url.PathEscape(uid), &live)
+		switch {
+		case notFound(err):
+			created++
+			fmt.Printf("+ create %s %q (%s)\n", uid, title, d.path)
+		case err != nil:
+			fail(fmt.Errorf("%s: %w", d.path, err))
+		default:
+			before, after := normalized(live.Dashboard), normalized(d.body)
+			// dry-run에서 새로 만들 폴더는 UID가 ""(기존 대시보드는 모두 이동)
+			moved := live.Meta.FolderUID != folderUID || folderUID == ""
+			if before == after && !moved {
+				unchanged++
+				continue
+			}
+			updated++
+			fmt.Printf("~ update %s %q (%s)\n", uid, title, d.path)
+			if moved {
+				fmt.Printf("  move to folder %q\n", *folder)
+			}
+			writeLineDiff(os.Stdout, before, after, *ctxLines)
+		}
+		if *dryRun {
+			continue
+		}
+		body := map[string]any{}
+		for k, v := range d.body {
+			body[k] = v
+		}
+		body["id"] = nil
+		req := map[string]any{"dashboard": body, "folderUid": folderUID, "overwrite": true, "message": *message}
+		resp, err := g.do(ctx, http.MethodPost, "/api/dashboards/db", req)
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", d.path, err))
+		}
+		resp.Body.Close()
+	}
+	mode := ""
+	if *dryRun {
+		mode = " (dry-run)"
+	}
+	fmt.Printf("created=%d updated=%d unchanged=%d%s\n", created, updated, unchanged, mode)
+}
+
+// rawDashboard는 필드를 잃지 않도록 원본 JSON 그대로 올린다
+type rawDashboard struct {
+	path string
+	body map[string]any
+}
+
+func loadRawDashboards(paths []string) ([]rawDashboard, error) {
+	ds, err := loadDashboards(paths)
+	if err != nil {
+		return nil, err
+	}
+	var out []rawDashboard
+	for _, d := range ds {
+		if d.UID == "" {
+			return nil, fmt.Errorf("%s: dashboard has no uid (push needs a stable uid)", d.Path)
+		}
+		b, err := os.ReadFile(d.Path)
+		if err != nil {
+			return nil, err
+		}
+		var doc map[string]any
+		if err := json.Unmarshal(b, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", d.Path, err)
+		}
+		if inner, ok := doc["dashboard"].(map[string]any); ok {
+			doc = inner
+		}
+		out = append(out, rawDashboard{path: d.Path, body: doc})
+	}
+	return out, nil
+}
+
+// normalized는 비교용 정렬된 JSON(volatileFields 제외)
+func normalized(d map[string]any) string {
+	c := make(map[string]any, len(d))
+	for k, v := range d {
+		c[k] = v
+	}
+	for _, k := range volatileFields {
+		delete(c, k)
+	}
+	b, _ := json.MarshalIndent(c, "", "  ")
+	return string(b)
+}
+
+// ensureFolder는 제목이 title인 폴더의 UID를 돌려주고, 없으면 만든다(dry-run이면 "")
+func ensureFolder(ctx context.Context, g *grafana, title string, dryRun bool) (string, error) {
+	var folders []struct {
+		UID   string `json:"uid"`
+		Title string `json:"title"`
+	}
+	if err := g.getJSON(ctx, "/api/folders?limit=1000", &folders); err != nil {
+		return "", err
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i].UID < folders[j].UID })
+	for _, f := range folders {
+		if f.Title == title {
+			return f.UID, nil
+		}
+	}
+	fmt.Printf("+ folder %q\n", title)
+	if dryRun {
+		return "", nil
+	}
+	resp, err := g.do(ctx, http.MethodPost, "/api/folders", map[string]string{"title": title})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var f struct {
+		UID string `json:"uid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&f); err != nil {
+		return "", fmt.Errorf("grafana /api/folders: %w", err)
+	}
+	return f.UID, nil
+}
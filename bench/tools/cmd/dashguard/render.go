@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// RenderCheck is the render smoke result of one dashboard.
+type RenderCheck struct {
+	UID       string  `json:"uid"`
+	Title     string  `json:"title,omitempty"`
+	Status    int     `json:"status,omitempty"`
+	ElapsedMs float64 `json:"elapsed_ms"`
+	Bytes     int     `json:"bytes,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	OK        bool    `json:"ok"`
+	// Verdict is OK as a pkg/resultenv verdict.
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+var pngMagic = []byte("\x89PNG\r\n\x1a\n")
+
+// 렌더 전에 확인하지 않는 내장/변수 datasource
+func builtinDatasource(uid string) bool {
+	switch uid {
+	case "", "grafana", "-- Grafana --", "-- Mixed --", "-- Dashboard --":
+		return true
+	}
+	return strings.HasPrefix(uid, "$")
+}
+
+func render(args []string) {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	gf := addGrafanaFlags(fs)
+	uids := fs.String("uids", "", "comma-separated dashboard UIDs (default: the uid of every -dashboards file)")
+	dashboards := fs.String("dashboards", "../grafana/dashboards", "comma-separated dashboard JSON files or directories to take UIDs from")
+	budget := fs.Duration("budget", 30*time.Second, "time budget of one dashboard render")
+	from := fs.String("from", "now-1h", "render time range start")
+	to := fs.String("to", "now", "render time range end")
+	width := fs.Int("width", 1600, "render width in pixels")
+	height := fs.Int("height", 900, "render height in pixels")
+	outDir := fs.String("out-dir", "", "save each rendered PNG here as <uid>.png")
+	jsonOut := fs.Bool("json", false, "print the results as JSON")
+	fs.Parse(args)
+
+	g, err := gf.client()
+	if err != nil {
+		fail(err)
+	}
+	list := splitList(*uids)
+	if len(list) == 0 {
+		ds, err := loadDashboards(splitList(*dashboards))
+		if err != nil {
+			fail(err)
+		}
+		for _, d := range ds {
+			if d.UID == "" {
+				fmt.Fprintf(os.Stderr, "[WARN] %s: no uid, skipped\n", d.Path)
+				continue
+			}
+			list = append(list, d.UID)
+		}
+	}
+	if len(list) == 0 {
+		fail(fmt.Errorf("no dashboard UIDs to render"))
+	}
+	if *outDir != "" {
+		if err := os.MkdirAll(*outDir, 0o755); err != nil {
+			fail(err)
+		}
+	}
+
+	q := url.Values{"from": {*from}, "to": {*to}, "width": {fmt.Sprint(*width)}, "height": {fmt.Sprint(*height)},
+		"timeout": {fmt.Sprint(int(budget.Seconds()))}}
+	dsSeen := map[string]error{}
+	ok := true
+	var results []RenderCheck
+	for _, uid := range list {
+		rc := renderOne(g, uid, q, *budget, dsSeen, *outDir)
+		rc.Verdict = resultenv.PassFail(rc.OK)
+		ok = ok && rc.OK
+		results = append(results, rc)
+	}
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(results)
+	} else {
+		for _, rc := range results {
+			state := string(rc.Verdict)
+			if !rc.OK {
+				state += " " + rc.Error
+			}
+			fmt.Printf("%s\t%q\t%.0fms\t%s\n", rc.UID, rc.Title, rc.ElapsedMs, state)
+		}
+		fmt.Printf("DASHGUARD_OK: %v\n", ok)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// renderOne은 대시보드 존재 → datasource UID → render 순으로 확인한다
+func renderOne(g *grafana, uid string, q url.Values, budget time.Duration, dsSeen map[string]error, outDir string) RenderCheck {
+	rc := RenderCheck{UID: uid}
+	ctx, cancel := context.WithTimeout(context.Background(), budget)
+	defer cancel()
+	var live struct {
+		Dashboard Dashboard `json:"dashboard"`
+		Meta      struct {
+			Slug string `json:"slug"`
+		} `json:"meta"`
+	}
+	if err := g.getJSON(ctx, "/api/dashboards/uid/"+url.PathEscape(uid), &live); err != nil {
+		if notFound(err) {
+			err = fmt.Errorf("dashboard not found")
+		}
+		rc.Error = err.Error()
+		return rc
+	}
+	rc.Title = live.Dashboard.Title
+	if err := checkDatasources(ctx, g, live.Dashboard, dsSeen); err != nil {
+		rc.Error = err.Error()
+		return rc
+	}
+
+	t0 := time.Now()
+	resp, err := g.do(ctx, http.MethodGet, "/render/d/"+url.PathEscape(uid)+"/"+url.PathEscape(live.Meta.Slug)+"?"+q.Encode(), nil)
+	rc.ElapsedMs = float64(time.Since(t0).Milliseconds())
+	if err != nil {
+		var ae *apiError
+		switch {
+		case errors.As(err, &ae):
+			rc.Status = ae.Status
+		case ctx.Err() != nil:
+			err = fmt.Errorf("render exceeded the %s budget", budget)
+		}
+		rc.Error = err.Error()
+		return rc
+	}
+	defer resp.Body.Close()
+	rc.Status = resp.StatusCode
+	body, err := io.ReadAll(resp.Body)
+	rc.ElapsedMs = float64(time.Since(t0).Milliseconds())
+	rc.Bytes = len(body)
+	switch {
+	case ctx.Err() != nil:
+		rc.Error = fmt.Sprintf("render exceeded the %s budget", budget)
+	case err != nil:
+		rc.Error = err.Error()
+	case !bytes.HasPrefix(body, pngMagic):
+		// 렌더러 플러그인이 없으면 Grafana는 200과 함께 오류 이미지/HTML을 돌려준다
+		rc.Error = fmt.Sprintf("render returned %s, not a PNG", resp.Header.Get("Content-Type"))
+	default:
+		rc.OK = true
+	}
+	if rc.OK && outDir != "" {
+		if err := os.WriteFile(filepath.Join(outDir, uid+".png"), body, 0o644); err != nil {
+			rc.OK, rc.Error = false, err.Error()
+		}
+	}
+	return rc
+}
+
+// checkDatasources는 패널/쿼리가 가리키는 datasource UID가 Grafana에 있는지 본다(결과는 UID별로 캐시)
+func checkDatasources(ctx context.Context, g *grafana, d Dashboard, seen map[string]error) error {
+	for _, p := range d.allPanels() {
+		refs := []json.RawMessage{p.Datasource}
+		for _, t := range p.Targets {
+			refs = append(refs, t.Datasource)
+		}
+		for _, raw := range refs {
+			var ref struct {
+				UID string `json:"uid"`
+			}
+			if json.Unmarshal(raw, &ref) != nil || builtinDatasource(ref.UID) {
+				continue
+			}
+			err, ok := seen[ref.UID]
+			if !ok {
+				var v struct{}
+				err = g.getJSON(ctx, "/api/datasources/uid/"+url.PathEscape(ref.UID), &v)
+				seen[ref.UID] = err
+			}
+			if notFound(err) {
+				return fmt.Errorf("panel %d %q: datasource uid %s not found", p.ID, p.Title, ref.UID)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func diskSpace(string) (total, free int64, err error) {
+	return 0, 0, fmt.Errorf("free space is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// diskSpace는 path가 있는 볼륨의 전체/호출자가 쓸 수 있는 바이트(쿼터 반영)
+func diskSpace(path string) (total, free int64, err error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var avail, tot, totalFree uint64
+	r, _, e := procGetDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(&avail)), uintptr(unsafe.Pointer(&tot)), uintptr(unsafe.Pointer(&totalFree)))
+	if r == 0 {
+		return 0, 0, fmt.Errorf("GetDiskFreeSpaceEx: %v", e)
+	}
+	return int64(tot), int64(avail), nil
+}
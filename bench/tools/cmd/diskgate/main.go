@@ -0,0 +1,214 @@
+// Command diskgate checks storage headroom: local volumes (Prometheus TSDB,
+// backups) by filesystem free space and directory/WAL size, and optionally
+// the TSDB as reported by Prometheus itself. Days until full are projected
+// from the recent growth rate, so capacity issues surface in proof runs
+// rather than as pages.
+//
+//	diskgate -volume tsdb=/var/lib/prometheus -volume backup=/mnt/hdd/duri_backups
+//	diskgate -prom http://localhost:9090 -json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// Check states.
+const (
+	StatePass = "pass"
+	StateWarn = "warn"
+	StateFail = "fail"
+)
+
+// Check is the headroom of one volume or of the TSDB.
+type Check struct {
+	Name      string  `json:"name"`
+	Source    string  `json:"source"` // 경로 또는 prometheus
+	UsedBytes int64   `json:"used_bytes"`
+	WALBytes  int64   `json:"wal_bytes,omitempty"`
+	FreeBytes int64   `json:"free_bytes,omitempty"`
+	FreePct   float64 `json:"free_pct,omitempty"`
+	// LimitBytes is the TSDB retention size limit, if configured.
+	LimitBytes int64 `json:"limit_bytes,omitempty"`
+	// GrowthBytesPerDay is the slope of used bytes over -growth-window;
+	// DaysUntilFull is nil when there is no growth or too little history.
+	GrowthBytesPerDay float64  `json:"growth_bytes_per_day"`
+	DaysUntilFull     *float64 `json:"days_until_full,omitempty"`
+	State             string   `json:"state"`
+	Reasons           []string `json:"reasons,omitempty"`
+	Error             string   `json:"error,omitempty"`
+}
+
+// Thresholds are the gate limits; a warn threshold is looser than its
+// fail threshold.
+type Thresholds struct {
+	WarnFreePct  float64 `json:"warn_free_pct"`
+	FailFreePct  float64 `json:"fail_free_pct"`
+	WarnDays     float64 `json:"warn_days"`
+	FailDays     float64 `json:"fail_days"`
+	MaxWALBytes  int64   `json:"max_wal_bytes,omitempty"`
+	GrowthWindow string  `json:"growth_window"`
+}
+
+// Report is the gate JSON.
+type Report struct {
+	CheckedAt  string     `json:"checked_at"`
+	Thresholds Thresholds `json:"thresholds"`
+	Checks     []Check    `json:"checks"`
+	OK         bool       `json:"ok"`
+	// Verdict is the worst check verdict (pkg/resultenv).
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+// verdict: 측정하지 못한 check(stat, TSDB 조회 실패)는 fail_infra
+func (c Check) verdict() resultenv.Verdict {
+	if c.Error != "" {
+		return resultenv.VerdictFailInfra
+	}
+	v, _ := resultenv.ParseVerdict(c.State)
+	return v
+}
+
+func main() {
+	var volumes volumeFlag
+	flag.Var(&volumes, "volume", "name=path of a volume to check (repeatable, e.g. -volume tsdb=/var/lib/prometheus)")
+	prom := flag.String("prom", "", "also check the TSDB through this Prometheus' own metrics")
+	state := flag.String("state", ".diskgate_history.jsonl", "usage history of local volumes for the growth projection")
+	window := flag.Duration("growth-window", 7*24*time.Hour, "history window of the growth rate")
+	warnFree := flag.Float64("warn-free-pct", 25, "warn below this share of free space (percent)")
+	failFree := flag.Float64("fail-free-pct", 10, "fail below this share of free space (percent)")
+	warnDays := flag.Float64("warn-days", 30, "warn when projected full within this many days")
+	failDays := flag.Float64("fail-days", 7, "fail when projected full within this many days")
+	maxWAL := flag.String("max-wal", "", "fail when a WAL is larger than this (e.g. 2GiB)")
+	jsonOut := flag.Bool("json", false, "print the gate JSON instead of the summary")
+	flag.Parse()
+
+	if len(volumes) == 0 && *prom == "" {
+		fmt.Fprintln(os.Stderr, "usage: diskgate -volume name=path ... [-prom URL] [flags]")
+		os.Exit(2)
+	}
+	if *failFree > *warnFree || *failDays > *warnDays {
+		fail(fmt.Errorf("fail thresholds must be stricter than warn thresholds"))
+	}
+	th := Thresholds{WarnFreePct: *warnFree, FailFreePct: *failFree, WarnDays: *warnDays, FailDays: *failDays, GrowthWindow: window.String()}
+	if *maxWAL != "" {
+		n, err := parseBytes(*maxWAL)
+		if err != nil {
+			fail(fmt.Errorf("-max-wal: %w", err))
+		}
+		th.MaxWALBytes = n
+	}
+
+	now := time.Now()
+	rep := Report{CheckedAt: now.UTC().Format(time.RFC3339), Thresholds: th, OK: true}
+	var verdicts []resultenv.Verdict
+	if len(volumes) > 0 {
+		hist, err := loadHistory(*state)
+		if err != nil {
+			fail(err)
+		}
+		var samples []sample
+		for _, v := range volumes {
+			c, s := checkVolume(v, now)
+			if c.Error == "" {
+				samples = append(samples, s)
+				projectGrowth(&c, append(hist.since(v.name, now.Add(-*window)), s))
+			}
+			rep.Checks = append(rep.Checks, c)
+		}
+		if err := appendHistory(*state, samples, now.Add(-2**window)); err != nil {
+			fail(err)
+		}
+	}
+	if *prom != "" {
+		rep.Checks = append(rep.Checks, checkTSDB(strings.TrimRight(*prom, "/"), *window))
+	}
+	for i := range rep.Checks {
+		judge(&rep.Checks[i], th)
+		rep.OK = rep.OK && rep.Checks[i].State != StateFail
+		verdicts = append(verdicts, rep.Checks[i].verdict())
+	}
+	rep.Verdict = resultenv.Worst(verdicts...)
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+	} else {
+		for _, c := range rep.Checks {
+			fmt.Printf("[%s] %s (%s): %s\n", strings.ToUpper(c.State), c.Name, c.Source, summary(c))
+		}
+		fmt.Printf("DISKGATE_OK: %v\n", rep.OK)
+	}
+	if !rep.OK {
+		os.Exit(1)
+	}
+}
+
+// judge는 임계값으로 상태를 정한다(가장 나쁜 것이 이김)
+func judge(c *Check, th Thresholds) {
+	c.State = StatePass
+	if c.Error != "" {
+		c.State = StateFail
+		return
+	}
+	mark := func(state, format string, a ...any) {
+		c.Reasons = append(c.Reasons, fmt.Sprintf(format, a...))
+		if state == StateFail || c.State == StatePass {
+			c.State = state
+		}
+	}
+	if c.FreeBytes > 0 || c.LimitBytes > 0 {
+		switch {
+		case c.FreePct < th.FailFreePct:
+			mark(StateFail, "free %.1f%% < %.0f%%", c.FreePct, th.FailFreePct)
+		case c.FreePct < th.WarnFreePct:
+			mark(StateWarn, "free %.1f%% < %.0f%%", c.FreePct, th.WarnFreePct)
+		}
+	}
+	if d := c.DaysUntilFull; d != nil {
+		switch {
+		case *d < th.FailDays:
+			mark(StateFail, "full in %.1fd < %.0fd", *d, th.FailDays)
+		case *d < th.WarnDays:
+			mark(StateWarn, "full in %.1fd < %.0fd", *d, th.WarnDays)
+		}
+	}
+	if th.MaxWALBytes > 0 && c.WALBytes > th.MaxWALBytes {
+		mark(StateFail, "WAL %s > %s", fmtBytes(c.WALBytes), fmtBytes(th.MaxWALBytes))
+	}
+}
+
+func summary(c Check) string {
+	if c.Error != "" {
+		return c.Error
+	}
+	s := "used " + fmtBytes(c.UsedBytes)
+	if c.WALBytes > 0 {
+		s += ", WAL " + fmtBytes(c.WALBytes)
+	}
+	if c.LimitBytes > 0 {
+		s += fmt.Sprintf(", limit %s (%.1f%% left)", fmtBytes(c.LimitBytes), c.FreePct)
+	} else if c.FreeBytes > 0 {
+		s += fmt.Sprintf(", free %s (%.1f%%)", fmtBytes(c.FreeBytes), c.FreePct)
+	}
+	s += fmt.Sprintf(", growth %s/day", fmtBytes(int64(c.GrowthBytesPerDay)))
+	if c.DaysUntilFull != nil {
+		s += fmt.Sprintf(", full in %.1fd", *c.DaysUntilFull)
+	}
+	if len(c.Reasons) > 0 {
+		s += " — " + strings.Join(c.Reasons, "; ")
+	}
+	return s
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
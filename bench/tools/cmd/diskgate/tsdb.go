@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/duri/trace_bench/internal/promapi"
+	"github.com/duri/trace_bench/stats"
+)
+
+// TSDB 크기는 Prometheus 자신의 메트릭으로 본다(데이터 디렉터리가 컨테이너 안에 있어도 동작)
+const (
+	tsdbUsedExpr  = `max(prometheus_tsdb_storage_blocks_bytes) + max(prometheus_tsdb_wal_storage_size_bytes) + max(prometheus_tsdb_head_chunks_storage_size_bytes or vector(0))`
+	tsdbWALExpr   = `max(prometheus_tsdb_wal_storage_size_bytes)`
+	tsdbLimitExpr = `max(prometheus_tsdb_retention_limit_bytes)`
+	// 블록 크기 증가율(바이트/초)
+	tsdbGrowthExpr = `max(deriv(prometheus_tsdb_storage_blocks_bytes[%s]))`
+)
+
+// checkTSDB는 retention 크기 한도가 있으면 그 한도 대비 headroom을 본다
+func checkTSDB(base string, window time.Duration) Check {
+	c := Check{Name: "prometheus-tsdb", Source: base}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	p := promapi.New(base)
+	q := func(expr string) float64 {
+		if c.Error != "" {
+			return math.NaN()
+		}
+		v, err := p.Query(ctx, expr, time.Time{})
+		if err != nil {
+			c.Error = err.Error()
+		}
+		return v
+	}
+	used := q(tsdbUsedExpr)
+	wal := q(tsdbWALExpr)
+	limit := q(tsdbLimitExpr)
+	growth := q(fmt.Sprintf(tsdbGrowthExpr, promDuration(window)))
+	if c.Error != "" {
+		return c
+	}
+	if math.IsNaN(used) {
+		c.Error = "prometheus exposes no prometheus_tsdb_* metrics (is it scraping itself?)"
+		return c
+	}
+	c.UsedBytes = int64(used)
+	if !math.IsNaN(wal) {
+		c.WALBytes = int64(wal)
+	}
+	if !math.IsNaN(growth) {
+		c.GrowthBytesPerDay = stats.Round2(growth * 86400)
+	}
+	// retention.size가 0이면 한도 없음: 디스크 여유는 -volume으로 본다
+	if !math.IsNaN(limit) && limit > 0 {
+		c.LimitBytes = int64(limit)
+		c.FreeBytes = max(c.LimitBytes-c.UsedBytes, 0)
+		c.FreePct = stats.Round2(float64(c.FreeBytes) / limit * 100)
+		if c.GrowthBytesPerDay > 0 {
+			d := stats.Round2(float64(c.FreeBytes) / c.GrowthBytesPerDay)
+			c.DaysUntilFull = &d
+		}
+	}
+	return c
+}
+
+// promDuration은 time.Duration을 Prometheus 범위 표기로 바꾼다(초 단위)
+func promDuration(d time.Duration) string {
+	return fmt.Sprintf("%ds", int64(d.Seconds()))
+}
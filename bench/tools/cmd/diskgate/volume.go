@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+type volume struct{ name, path string }
+
+// volumeFlag는 반복 가능한 -volume name=path(순서 유지)
+type volumeFlag []volume
+
+func (v *volumeFlag) String() string {
+	var parts []string
+	for _, x := range *v {
+		parts = append(parts, x.name+"="+x.path)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (v *volumeFlag) Set(s string) error {
+	name, path, ok := strings.Cut(s, "=")
+	if !ok || name == "" || path == "" {
+		return fmt.Errorf("invalid volume %q (want name=path)", s)
+	}
+	*v = append(*v, volume{name, path})
+	return nil
+}
+
+// sample은 history 한 줄(파일시스템 사용량 기준으로 성장률을 잰다)
+type sample struct {
+	Time   time.Time `json:"time"`
+	Volume string    `json:"volume"`
+	Used   int64     `json:"used_bytes"`
+}
+
+func checkVolume(v volume, now time.Time) (Check, sample) {
+	c := Check{Name: v.name, Source: v.path}
+	total, free, err := diskSpace(v.path)
+	if err != nil {
+		c.Error = err.Error()
+		return c, sample{}
+	}
+	c.FreeBytes = free
+	if total > 0 {
+		c.FreePct = stats.Round2(float64(c.FreeBytes) / float64(total) * 100)
+	}
+	used, err := dirSize(v.path)
+	if err != nil {
+		c.Error = err.Error()
+		return c, sample{}
+	}
+	c.UsedBytes = used
+	// Prometheus TSDB 디렉터리면 WAL도 따로 본다
+	if wal, err := dirSize(filepath.Join(v.path, "wal")); err == nil {
+		c.WALBytes = wal
+	}
+	return c, sample{Time: now, Volume: v.name, Used: total - c.FreeBytes}
+}
+
+func dirSize(root string) (int64, error) {
+	var n int64
+	err := filepath.WalkDir(root, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			n += info.Size()
+		}
+		return nil
+	})
+	return n, err
+}
+
+// projectGrowth는 사용량 추세(바이트/일)로 남은 일수를 구한다
+func projectGrowth(c *Check, ss []sample) {
+	if len(ss) < 2 {
+		return
+	}
+	t0 := ss[0].Time
+	var xs, ys []float64
+	for _, s := range ss {
+		xs = append(xs, s.Time.Sub(t0).Hours()/24)
+		ys = append(ys, float64(s.Used))
+	}
+	c.GrowthBytesPerDay = stats.Round2(stats.Slope(xs, ys))
+	if c.GrowthBytesPerDay > 0 {
+		d := stats.Round2(float64(c.FreeBytes) / c.GrowthBytesPerDay)
+		c.DaysUntilFull = &d
+	}
+}
+
+type history []sample
+
+func loadHistory(path string) (history, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var h history
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var s sample
+		if json.Unmarshal(sc.Bytes(), &s) == nil {
+			h = append(h, s)
+		}
+	}
+	return h, sc.Err()
+}
+
+func (h history) since(volume string, from time.Time) []sample {
+	var out []sample
+	for _, s := range h {
+		if s.Volume == volume && !s.Time.Before(from) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// appendHistory는 새 표본을 더하고 cutoff 이전 줄은 버린다
+func appendHistory(path string, add []sample, cutoff time.Time) error {
+	h, err := loadHistory(path)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, s := range append(h, add...) {
+		if s.Time.Before(cutoff) {
+			continue
+		}
+		line, _ := json.Marshal(s)
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+var byteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB"}
+
+func fmtBytes(n int64) string {
+	v, i := float64(n), 0
+	for ; (v >= 1024 || v <= -1024) && i < len(byteUnits)-1; i++ {
+		v /= 1024
+	}
+	return strconv.FormatFloat(stats.Round2(v), 'f', -1, 64) + byteUnits[i]
+}
+
+// parseBytes는 512MiB, 2GiB, 1TB 같은 크기를 읽는다(KB/MB/GB도 1024배로 취급)
+func parseBytes(s string) (int64, error) {
+	num := strings.TrimRight(s, "KMGTiBb")
+	unit := strings.ToUpper(strings.TrimSuffix(strings.TrimSuffix(s[len(num):], "B"), "b"))
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	mult := map[string]float64{"": 1, "K": 1 << 10, "KI": 1 << 10, "M": 1 << 20, "MI": 1 << 20, "G": 1 << 30, "GI": 1 << 30, "T": 1 << 40, "TI": 1 << 40}
+	m, ok := mult[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size unit in %q", s)
+	}
+	return int64(f * m), nil
+}
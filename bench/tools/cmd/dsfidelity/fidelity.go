@@ -0,0 +1,176 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/duri/trace_bench/stats"
+)
+
+// Downsampling strategies: how a coarse point is derived from the raw p95
+// points in its window.
+const (
+	StrategyAvg  = "avg"  // avg_over_time, Thanos/Mimir downsampled sum/count
+	StrategyMax  = "max"  // max_over_time
+	StrategyLast = "last" // sampling the raw series at the coarse step
+	StrategyP95  = "p95"  // quantile_over_time(0.95)
+)
+
+type point struct {
+	t time.Time
+	v float64
+}
+
+// source는 원시 고해상도 p95 series와 창별 기준 p95
+type source interface {
+	name() string
+	raw() []point
+	resolution() time.Duration
+	// truth는 [lo, hi) 창의 기준 p95(없으면 false)
+	truth(lo, hi time.Time) (float64, bool)
+	strategies() []string
+}
+
+// Fidelity is the p95 estimation error of one strategy at one window size,
+// relative to the source's reference p95 of each window.
+type Fidelity struct {
+	Window   string  `json:"window"`
+	Strategy string  `json:"strategy"` // a Strategy* or recorded:<name>
+	Windows  int     `json:"windows"`
+	MAEms    float64 `json:"mae_ms"`
+	// BiasPct is the mean signed relative error (negative: underestimates).
+	BiasPct      float64 `json:"bias_pct"`
+	P95AbsErrPct float64 `json:"p95_abs_err_pct"`
+	MaxAbsErrPct float64 `json:"max_abs_err_pct"`
+	Within       bool    `json:"within_tolerance"`
+}
+
+// errAgg는 창별 오차 모음
+type errAgg struct {
+	abs, rel []float64
+}
+
+func (a *errAgg) add(est, ref float64) {
+	if ref <= 0 || math.IsNaN(est) {
+		return
+	}
+	a.abs = append(a.abs, math.Abs(est-ref))
+	a.rel = append(a.rel, (est-ref)/ref)
+}
+
+func (a *errAgg) fidelity(window time.Duration, strategy string, tolerance float64) Fidelity {
+	f := Fidelity{Window: window.String(), Strategy: strategy, Windows: len(a.rel)}
+	if len(a.rel) == 0 {
+		return f
+	}
+	var sumAbs, sumRel float64
+	absRel := make([]float64, len(a.rel))
+	for i := range a.rel {
+		sumAbs += a.abs[i]
+		sumRel += a.rel[i]
+		absRel[i] = math.Abs(a.rel[i])
+	}
+	sort.Float64s(absRel)
+	n := float64(len(a.rel))
+	f.MAEms = stats.Round5(sumAbs / n)
+	f.BiasPct = stats.Round2(100 * sumRel / n)
+	f.P95AbsErrPct = stats.Round2(100 * quantile(absRel, 0.95))
+	f.MaxAbsErrPct = stats.Round2(100 * absRel[len(absRel)-1])
+	f.Within = quantile(absRel, 0.95) <= tolerance
+	return f
+}
+
+// downsample은 raw를 창 크기 w로 정렬된 창마다 strategy로 줄인 값과 그 창
+func downsample(raw []point, w, res time.Duration, strategy string) (est []point) {
+	if len(raw) == 0 {
+		return nil
+	}
+	first, end := raw[0].t, raw[len(raw)-1].t.Add(res)
+	lo := first.Truncate(w)
+	if lo.Before(first) {
+		lo = lo.Add(w) // 시작이 잘린 창은 제외
+	}
+	i := 0
+	for ; !lo.Add(w).After(end); lo = lo.Add(w) {
+		hi := lo.Add(w)
+		for i < len(raw) && raw[i].t.Before(lo) {
+			i++
+		}
+		j := i
+		for j < len(raw) && raw[j].t.Before(hi) {
+			j++
+		}
+		if j > i {
+			est = append(est, point{lo, reduce(raw[i:j], strategy)})
+		}
+		i = j
+	}
+	return est
+}
+
+func reduce(ps []point, strategy string) float64 {
+	switch strategy {
+	case StrategyAvg:
+		var s float64
+		for _, p := range ps {
+			s += p.v
+		}
+		return s / float64(len(ps))
+	case StrategyMax:
+		m := ps[0].v
+		for _, p := range ps[1:] {
+			m = max(m, p.v)
+		}
+		return m
+	case StrategyLast:
+		return ps[len(ps)-1].v
+	default:
+		vs := make([]float64, len(ps))
+		for i, p := range ps {
+			vs[i] = p.v
+		}
+		sort.Float64s(vs)
+		return quantile(vs, 0.95)
+	}
+}
+
+// quantile은 정렬된 값의 선형 보간 분위수(quantile_over_time과 같은 방식)
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	rank := q * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := min(lo+1, len(sorted)-1)
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// analyze는 창 크기 × strategy마다 기준 대비 오차를 잰다
+func analyze(src source, windows []time.Duration, tolerance float64) []Fidelity {
+	var out []Fidelity
+	for _, w := range windows {
+		for _, s := range src.strategies() {
+			var agg errAgg
+			for _, p := range downsample(src.raw(), w, src.resolution(), s) {
+				if ref, ok := src.truth(p.t, p.t.Add(w)); ok {
+					agg.add(p.v, ref)
+				}
+			}
+			out = append(out, agg.fidelity(w, s, tolerance))
+		}
+	}
+	return out
+}
+
+// recorded는 이미 줄여진 series(recording rule 등)를 기준과 비교한다.
+// t의 점은 range selector처럼 (t-w, t] 창을 덮는다
+func recorded(src source, name string, w time.Duration, pts []point, tolerance float64) Fidelity {
+	var agg errAgg
+	for _, p := range pts {
+		if ref, ok := src.truth(p.t.Add(-w+1), p.t.Add(1)); ok {
+			agg.add(p.v, ref)
+		}
+	}
+	return agg.fidelity(w, "recorded:"+name, tolerance)
+}
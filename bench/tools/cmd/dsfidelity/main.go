@@ -0,0 +1,205 @@
+// Command dsfidelity quantifies how much p95 accuracy downsampling costs: it
+// takes a raw high-resolution bench p95 series, reduces it to coarser
+// windows the way downsampling and recording rules do (avg, max, last,
+// p95-over-time) and compares each coarse point with the reference p95 of
+// its window. The error per window size tells how long the raw resolution
+// must be retained before dashboards and SLO checks over downsampled data
+// stop being trustworthy.
+//
+// With -result the source is a trace_bench result with a heatmap (-mode real
+// -heatmap 1s, typically from a soak): the reference is the p95 of the
+// merged latency histogram of each window, i.e. the true distribution. With
+// -prom the source is a Prometheus series, compared additionally with
+// existing recorded/downsampled series (-recorded); without a histogram the
+// reference is then the p95 of the raw points of each window.
+//
+//	dsfidelity -result soak.json -windows 1m,5m,1h
+//	dsfidelity -prom http://localhost:9090 -raw 'trace_bench_latency_ms{quantile="0.95",run_id="..."}' -range 6h \
+//	    -recorded 'p95_5m@5m=job:trace_bench_latency_ms:p95_5m'
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/internal/promapi"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// Report is the analyzer JSON.
+type Report struct {
+	Source       string     `json:"source"`
+	ResolutionS  float64    `json:"resolution_s"`
+	Points       int        `json:"points"`
+	TolerancePct float64    `json:"tolerance_pct"`
+	Results      []Fidelity `json:"results"`
+	// Best is the most accurate strategy per window size (lowest p95
+	// error); Within tells whether it stays inside the tolerance.
+	Best []Fidelity `json:"best"`
+	// Verdict is fail_slo when even the best strategy of some window size
+	// is outside the tolerance, fail_infra when no window could be compared
+	// (pkg/resultenv).
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+// recordedFlag는 반복 가능한 -recorded name@window=expr
+type recordedFlag []recordedSeries
+
+type recordedSeries struct {
+	name, expr string
+	window     time.Duration
+}
+
+func (r *recordedFlag) String() string { return fmt.Sprint(len(*r)) }
+
+func (r *recordedFlag) Set(v string) error {
+	head, expr, ok := strings.Cut(v, "=")
+	name, win, ok2 := strings.Cut(head, "@")
+	if !ok || !ok2 || name == "" || expr == "" {
+		return fmt.Errorf("want name@window=expr, got %q", v)
+	}
+	w, err := promapi.ParseDuration(win)
+	if err != nil {
+		return err
+	}
+	*r = append(*r, recordedSeries{name: name, expr: expr, window: w})
+	return nil
+}
+
+func main() {
+	var rec recordedFlag
+	result := flag.String("result", "", "trace_bench result JSON with a heatmap (-heatmap)")
+	prom := flag.String("prom", "", "Prometheus base URL (instead of -result)")
+	rawExpr := flag.String("raw", `trace_bench_latency_ms{quantile="0.95"}`, "-prom: expression selecting the one raw p95 series")
+	rng := flag.Duration("range", 6*time.Hour, "-prom: analyzed range ending at -end")
+	endFlag := flag.String("end", "", "-prom: end of the range (RFC3339, default now)")
+	step := flag.Duration("step", time.Second, "-prom: raw resolution to query")
+	flag.Var(&rec, "recorded", "-prom: name@window=expr of a downsampled/recorded series to compare (repeatable)")
+	windows := flag.String("windows", "1m,5m,1h", "comma list of downsampling windows")
+	tolerance := flag.Float64("tolerance", 0.1, "acceptable p95 relative error (p95 over windows)")
+	jsonOut := flag.Bool("json", false, "print the JSON report instead of the table")
+	flag.Parse()
+
+	if (*result == "") == (*prom == "") {
+		fmt.Fprintln(os.Stderr, "usage: dsfidelity -result result.json | -prom URL [flags]")
+		os.Exit(2)
+	}
+	if len(rec) > 0 && *prom == "" {
+		fail(fmt.Errorf("-recorded requires -prom"))
+	}
+	var ws []time.Duration
+	for _, v := range strings.Split(*windows, ",") {
+		w, err := promapi.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			fail(fmt.Errorf("-windows: %w", err))
+		}
+		ws = append(ws, w)
+	}
+
+	var src source
+	recordedPts := map[string][]point{}
+	if *result != "" {
+		h, err := loadHeatmap(*result)
+		if err != nil {
+			fail(err)
+		}
+		src = h
+	} else {
+		end := time.Now()
+		if *endFlag != "" {
+			t, err := time.Parse(time.RFC3339, *endFlag)
+			if err != nil {
+				fail(fmt.Errorf("-end: %w", err))
+			}
+			end = t
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		c := promapi.New(strings.TrimRight(*prom, "/"))
+		p, err := loadProm(ctx, c, *rawExpr, end.Add(-*rng), end, *step)
+		if err != nil {
+			fail(err)
+		}
+		src = p
+		for _, r := range rec {
+			ss, err := c.Range(ctx, r.expr, end.Add(-*rng), end, r.window)
+			if err != nil {
+				fail(fmt.Errorf("-recorded %s: %w", r.name, err))
+			}
+			if len(ss) != 1 {
+				fail(fmt.Errorf("-recorded %s must select exactly one series, got %d", r.name, len(ss)))
+			}
+			recordedPts[r.name] = toPoints(ss[0].Points)
+		}
+	}
+	if len(src.raw()) == 0 {
+		fail(fmt.Errorf("%s: no raw points", src.name()))
+	}
+
+	rep := Report{Source: src.name(), ResolutionS: src.resolution().Seconds(), Points: len(src.raw()), TolerancePct: 100 * *tolerance}
+	rep.Results = analyze(src, ws, *tolerance)
+	for _, r := range rec {
+		rep.Results = append(rep.Results, recorded(src, r.name, r.window, recordedPts[r.name], *tolerance))
+	}
+	rep.Best = best(rep.Results)
+	var outside []string
+	for _, f := range rep.Best {
+		if !f.Within {
+			outside = append(outside, f.Window)
+		}
+	}
+	rep.Verdict = resultenv.Judge(outside, nil)
+	if len(rep.Best) == 0 {
+		rep.Verdict = resultenv.VerdictFailInfra
+	}
+
+	if *jsonOut {
+		if err := output.WriteJSON(os.Stdout, rep); err != nil {
+			fail(err)
+		}
+		return
+	}
+	fmt.Printf("source %s: %d points at %s resolution, tolerance %.1f%%\n", rep.Source, rep.Points, src.resolution(), rep.TolerancePct)
+	fmt.Printf("%-8s %-20s %8s %10s %9s %12s %12s\n", "window", "strategy", "windows", "mae_ms", "bias_pct", "p95_err_pct", "max_err_pct")
+	for _, f := range rep.Results {
+		fmt.Printf("%-8s %-20s %8d %10.3f %9.2f %12.2f %12.2f\n", f.Window, f.Strategy, f.Windows, f.MAEms, f.BiasPct, f.P95AbsErrPct, f.MaxAbsErrPct)
+	}
+	for _, f := range rep.Best {
+		verdict := "within"
+		if !f.Within {
+			verdict = "OUTSIDE"
+		}
+		fmt.Printf("best at %s: %s (p95 error %.2f%%, %s tolerance)\n", f.Window, f.Strategy, f.P95AbsErrPct, verdict)
+	}
+}
+
+// best는 창 크기마다 p95 오차가 가장 작은 결과(창 순서 유지)
+func best(rs []Fidelity) []Fidelity {
+	var out []Fidelity
+	idx := map[string]int{}
+	for _, f := range rs {
+		if f.Windows == 0 {
+			continue
+		}
+		i, ok := idx[f.Window]
+		if !ok {
+			idx[f.Window] = len(out)
+			out = append(out, f)
+			continue
+		}
+		if f.P95AbsErrPct < out[i].P95AbsErrPct {
+			out[i] = f
+		}
+	}
+	return out
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
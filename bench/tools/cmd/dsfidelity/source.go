@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/duri/trace_bench/internal/promapi"
+	"github.com/duri/trace_bench/stats"
+)
+
+// heatmapSource는 trace_bench 결과의 heatmap(-heatmap): 원시 series는 구간별
+// p95, 기준은 창 안의 구간을 합친 히스토그램의 p95(다운샘플링 전 실제 분포)
+type heatmapSource struct {
+	hm   *stats.Heatmap
+	rows []stats.HeatmapRow
+	pts  []point
+}
+
+func loadHeatmap(path string) (*heatmapSource, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r struct {
+		Heatmap *stats.Heatmap `json:"heatmap"`
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if r.Heatmap == nil {
+		return nil, fmt.Errorf("%s: no heatmap (run trace_bench -mode real -heatmap 1s)", path)
+	}
+	h := &heatmapSource{hm: r.Heatmap, rows: r.Heatmap.Rows()}
+	for _, row := range h.rows {
+		if v, ok := stats.InterpolatedBucketQuantile(h.hm.Bounds, row.Counts, 0.95); ok {
+			h.pts = append(h.pts, point{row.Time, v})
+		}
+	}
+	return h, nil
+}
+
+func (h *heatmapSource) name() string              { return "heatmap" }
+func (h *heatmapSource) raw() []point              { return h.pts }
+func (h *heatmapSource) resolution() time.Duration { return h.hm.Interval }
+func (h *heatmapSource) strategies() []string {
+	return []string{StrategyAvg, StrategyMax, StrategyLast, StrategyP95}
+}
+
+func (h *heatmapSource) truth(lo, hi time.Time) (float64, bool) {
+	if len(h.rows) == 0 || lo.Before(h.rows[0].Time) || hi.After(h.rows[len(h.rows)-1].Time.Add(h.hm.Interval)) {
+		return 0, false
+	}
+	sum := make([]uint64, len(h.hm.Bounds)+1)
+	i := sort.Search(len(h.rows), func(i int) bool { return !h.rows[i].Time.Before(lo) })
+	for ; i < len(h.rows) && h.rows[i].Time.Before(hi); i++ {
+		for b, c := range h.rows[i].Counts {
+			sum[b] += c
+		}
+	}
+	return stats.InterpolatedBucketQuantile(h.hm.Bounds, sum, 0.95)
+}
+
+// promSource는 Prometheus의 원시 p95 series(예: trace_bench remote-write의
+// trace_bench_latency_ms{quantile="0.95"}). 히스토그램이 없으므로 기준은 창
+// 안 원시 점들의 p95(quantile_over_time)
+type promSource struct {
+	pts []point
+	res time.Duration
+}
+
+func loadProm(ctx context.Context, c *promapi.Client, expr string, start, end time.Time, step time.Duration) (*promSource, error) {
+	ss, err := c.Range(ctx, expr, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	if len(ss) != 1 {
+		return nil, fmt.Errorf("-raw must select exactly one series, got %d", len(ss))
+	}
+	return &promSource{pts: toPoints(ss[0].Points), res: step}, nil
+}
+
+func toPoints(ps []promapi.Point) []point {
+	out := make([]point, len(ps))
+	for i, p := range ps {
+		out[i] = point{p.T, p.V}
+	}
+	return out
+}
+
+func (p *promSource) name() string              { return "prometheus" }
+func (p *promSource) raw() []point              { return p.pts }
+func (p *promSource) resolution() time.Duration { return p.res }
+
+// 기준이 원시 점의 p95라 StrategyP95는 항상 0이 되므로 뺀다
+func (p *promSource) strategies() []string { return []string{StrategyAvg, StrategyMax, StrategyLast} }
+
+func (p *promSource) truth(lo, hi time.Time) (float64, bool) {
+	if len(p.pts) == 0 || lo.Before(p.pts[0].t) || hi.After(p.pts[len(p.pts)-1].t.Add(p.res)) {
+		return 0, false
+	}
+	var vs []float64
+	for _, pt := range p.pts {
+		if !pt.t.Before(lo) && pt.t.Before(hi) {
+			vs = append(vs, pt.v)
+		}
+	}
+	if len(vs) == 0 {
+		return 0, false
+	}
+	sort.Float64s(vs)
+	return quantile(vs, 0.95), true
+}
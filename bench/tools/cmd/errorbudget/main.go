@@ -0,0 +1,209 @@
+// Command errorbudget computes remaining error budget, multi-window burn
+// rates and projected exhaustion for SLOs backed by Prometheus queries,
+// and writes JSON plus a Markdown table for the weekly report.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/internal/promapi"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/resultenv"
+	"github.com/duri/trace_bench/slo"
+	"github.com/duri/trace_bench/stats"
+)
+
+// Budget is the per-SLO report.
+type Budget struct {
+	Name       string             `json:"name"`
+	Objective  float64            `json:"objective"`
+	Window     string             `json:"window"`
+	ErrorRatio float64            `json:"error_ratio"`
+	Consumed   float64            `json:"budget_consumed"`  // 윈도 예산 대비 소진 비율
+	Remaining  float64            `json:"budget_remaining"` // 1 - consumed (음수면 초과)
+	BurnRates  map[string]float64 `json:"burn_rates"`
+	Exhaustion string             `json:"projected_exhaustion,omitempty"`
+	Status     string             `json:"status"` // ok | burning | exhausted | no_data
+	// Verdict is Status as a pkg/resultenv verdict.
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+// Report is the errorbudget output document.
+type Report struct {
+	GeneratedAt string   `json:"generated_at"`
+	ProjectFrom string   `json:"project_from"`
+	SLOs        []Budget `json:"slos"`
+	// Verdict is the worst SLO verdict.
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+// statusVerdicts: 소진 중(burning)은 아직 예산이 남아 경고, 데이터 없음은 측정 실패
+var statusVerdicts = map[string]resultenv.Verdict{
+	"ok":        resultenv.VerdictPass,
+	"burning":   resultenv.VerdictPassWithWarnings,
+	"exhausted": resultenv.VerdictFailSLO,
+	"no_data":   resultenv.VerdictFailInfra,
+}
+
+func main() {
+	prom := flag.String("prom", "http://localhost:9090", "Prometheus base URL")
+	sloPath := flag.String("slo", "slo.yaml", "shared SLO definitions; SLOs with an indicator query are evaluated")
+	windows := flag.String("burn-windows", "1h,6h,1d,3d", "comma-separated burn-rate windows")
+	projectFrom := flag.String("project-from", "1d", "burn-rate window used to project exhaustion")
+	at := flag.String("at", "", "evaluation time (RFC3339, default now)")
+	jsonOut := flag.String("json-out", "", "write JSON here instead of stdout")
+	mdOut := flag.String("md-out", "", "write a Markdown table here")
+	flag.Parse()
+
+	defs, err := slo.Load(*sloPath)
+	if err != nil {
+		fail(err)
+	}
+	slos := defs.Queries()
+	if len(slos) == 0 {
+		fail(fmt.Errorf("%s: no SLOs with an indicator query", *sloPath))
+	}
+	now := time.Now().UTC()
+	if *at != "" {
+		if now, err = time.Parse(time.RFC3339, *at); err != nil {
+			fail(fmt.Errorf("invalid -at: %s", *at))
+		}
+	}
+	burnWindows := strings.Split(*windows, ",")
+	if !contains(burnWindows, *projectFrom) {
+		burnWindows = append(burnWindows, *projectFrom)
+	}
+	for _, w := range burnWindows {
+		if _, err := promapi.ParseDuration(w); err != nil {
+			fail(err)
+		}
+	}
+
+	c := promapi.New(strings.TrimRight(*prom, "/"))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	rep := Report{GeneratedAt: now.Format(time.RFC3339), ProjectFrom: *projectFrom}
+	var verdicts []resultenv.Verdict
+	for _, s := range slos {
+		b, err := evaluate(ctx, c, s, burnWindows, *projectFrom, now)
+		if err != nil {
+			fail(fmt.Errorf("slo %s: %w", s.Name, err))
+		}
+		b.Verdict = statusVerdicts[b.Status]
+		rep.SLOs = append(rep.SLOs, b)
+		verdicts = append(verdicts, b.Verdict)
+	}
+	rep.Verdict = resultenv.Worst(verdicts...)
+
+	writeJSON := func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	}
+	if *jsonOut == "" {
+		writeJSON(os.Stdout)
+	} else if err := output.WriteFileAtomicFunc(*jsonOut, writeJSON); err != nil {
+		fail(err)
+	}
+	if *mdOut != "" {
+		if err := output.WriteFileAtomicFunc(*mdOut, func(w io.Writer) error { return writeMarkdown(w, rep, burnWindows) }); err != nil {
+			fail(err)
+		}
+	}
+}
+
+func evaluate(ctx context.Context, c *promapi.Client, s slo.SLO, windows []string, projectFrom string, now time.Time) (Budget, error) {
+	b := Budget{Name: s.Name, Objective: s.Objective, Window: s.Window, BurnRates: map[string]float64{}}
+	allowed := s.ErrorBudget()
+	ratio := func(w string) (float64, error) {
+		return c.Query(ctx, s.QueryExpr(w), now)
+	}
+	er, err := ratio(s.Window)
+	if err != nil {
+		return Budget{}, err
+	}
+	if math.IsNaN(er) {
+		b.Status = "no_data"
+		return b, nil
+	}
+	b.ErrorRatio = stats.Round5(er)
+	b.Consumed = stats.Round5(er / allowed)
+	b.Remaining = stats.Round5(1 - er/allowed)
+	for _, w := range windows {
+		v, err := ratio(w)
+		if err != nil {
+			return Budget{}, err
+		}
+		if !math.IsNaN(v) {
+			b.BurnRates[w] = stats.Round5(v / allowed)
+		}
+	}
+
+	// 소진 예측: 남은 예산 / 현재 소진 속도. burn rate 1이면 윈도 길이만큼 걸린다
+	win := s.WindowDuration()
+	burn := b.BurnRates[projectFrom]
+	switch {
+	case b.Remaining <= 0:
+		b.Status = "exhausted"
+	case burn > 1:
+		b.Status = "burning"
+	default:
+		b.Status = "ok"
+	}
+	if b.Remaining > 0 && burn > 0 {
+		left := time.Duration(b.Remaining * float64(win) / burn)
+		b.Exhaustion = now.Add(left).Format(time.RFC3339)
+	}
+	return b, nil
+}
+
+func writeMarkdown(w io.Writer, rep Report, windows []string) error {
+	fmt.Fprintf(w, "## Error budget (%s)\n\n", rep.GeneratedAt)
+	fmt.Fprint(w, "| SLO | objective | window | error ratio | remaining |")
+	for _, bw := range windows {
+		fmt.Fprintf(w, " burn %s |", bw)
+	}
+	fmt.Fprint(w, " exhaustion | status |\n|---|---|---|---|---|")
+	for range windows {
+		fmt.Fprint(w, "---|")
+	}
+	fmt.Fprint(w, "---|---|\n")
+	for _, b := range rep.SLOs {
+		fmt.Fprintf(w, "| %s | %v | %s | %v | %.1f%% |", b.Name, b.Objective, b.Window, b.ErrorRatio, b.Remaining*100)
+		for _, bw := range windows {
+			if v, ok := b.BurnRates[bw]; ok {
+				fmt.Fprintf(w, " %.2f |", v)
+			} else {
+				fmt.Fprint(w, " - |")
+			}
+		}
+		exh := b.Exhaustion
+		if exh == "" {
+			exh = "-"
+		}
+		fmt.Fprintf(w, " %s | %s |\n", exh, b.Status)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
@@ -0,0 +1,133 @@
+// Command metricsguard works with the declared metrics contract
+// (metrics.yaml).
+//
+//	metricsguard gen-rules -contract metrics.yaml -out rules.yml
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/contract"
+	"github.com/duri/trace_bench/internal/promapi"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/buildinfo"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: metricsguard <command> [flags]\n\ncommands:\n  gen-rules   emit recording rules from the metrics contract")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "-version", "--version":
+		buildinfo.Print(os.Stdout, "metricsguard", len(os.Args) > 2 && os.Args[2] == "-json")
+	case "gen-rules":
+		genRules(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func genRules(args []string) {
+	fs := flag.NewFlagSet("gen-rules", flag.ExitOnError)
+	path := fs.String("contract", "metrics.yaml", "metrics contract")
+	window := fs.String("window", "5m", "rate window")
+	group := fs.String("group", "metrics-contract", "rule group name")
+	out := fs.String("out", "", "write rules here instead of stdout")
+	envelope := fs.String("envelope", "", "also write a pkg/resultenv envelope (shared proof-tool format) to this path")
+	fs.Parse(args)
+	started := time.Now().UTC()
+
+	c, err := contract.Load(*path)
+	if err != nil {
+		fail(err)
+	}
+	if _, err := promapi.ParseDuration(*window); err != nil {
+		fail(err)
+	}
+	var rules int
+	write := func(w io.Writer) (err error) {
+		rules, err = writeRecordingRules(w, *group, *path, *window, c)
+		return err
+	}
+	if *out == "" {
+		write(os.Stdout)
+	} else if err := output.WriteFileAtomicFunc(*out, write); err != nil {
+		fail(err)
+	}
+	if *envelope != "" {
+		e := resultenv.New("metricsguard").Flags(fs)
+		e.StartedAt = started
+		e.Params["command"] = fs.Name()
+		e.Metrics = map[string]float64{"contract_metrics": float64(len(c.Metrics)), "rules": float64(rules)}
+		e.Finish(resultenv.VerdictPass, nil)
+		if err := output.WriteFileAtomicFunc(*envelope, func(w io.Writer) error { return resultenv.Encode(w, e) }); err != nil {
+			fail(err)
+		}
+	}
+}
+
+// 규칙 이름은 level:metric:operations 관례를 따른다
+func writeRecordingRules(w io.Writer, group, src, window string, c *contract.Contract) (int, error) {
+	fmt.Fprintf(w, "# Code generated by metricsguard gen-rules from %s. DO NOT EDIT.\ngroups:\n", src)
+	fmt.Fprintf(w, "  - name: %s\n    rules:\n", group)
+	n := 0
+	rule := func(record, expr string) {
+		n++
+		fmt.Fprintf(w, "      - record: %s\n        expr: %s\n", record, strconv.Quote(expr))
+	}
+	for _, m := range c.Metrics {
+		level, by := levelOf(m.By), strings.Join(m.By, ", ")
+		switch m.Type {
+		case contract.Histogram:
+			byLe := strings.Join(append([]string{"le"}, m.By...), ", ")
+			for _, q := range m.QuantilesOrDefault() {
+				rule(fmt.Sprintf("%s:%s:p%s_rate%s", level, m.Name, quantileName(q), window),
+					fmt.Sprintf("histogram_quantile(%s, sum by (%s) (rate(%s_bucket[%s])))", strconv.FormatFloat(q, 'g', -1, 64), byLe, m.Name, window))
+			}
+		case contract.Counter:
+			rule(fmt.Sprintf("%s:%s:rate%s", level, m.Name, window),
+				fmt.Sprintf("sum%s (rate(%s[%s]))", byClause(by), m.Name, window))
+			if m.ErrorSelector != "" {
+				rule(fmt.Sprintf("%s:%s:error_ratio_rate%s", level, m.Name, window),
+					fmt.Sprintf("sum%s (rate(%s{%s}[%s])) / sum%s (rate(%s[%s]))", byClause(by), m.Name, m.ErrorSelector, window, byClause(by), m.Name, window))
+			}
+		}
+	}
+	return n, nil
+}
+
+func levelOf(by []string) string {
+	if len(by) == 0 {
+		return "job"
+	}
+	return strings.Join(by, "_")
+}
+
+func byClause(by string) string {
+	if by == "" {
+		return ""
+	}
+	return " by (" + by + ")"
+}
+
+// 0.95 → "95", 0.999 → "999"
+func quantileName(q float64) string {
+	return strings.TrimPrefix(strconv.FormatFloat(q, 'f', -1, 64), "0.")
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
@@ -0,0 +1,181 @@
+// Command pipelineprobe measures end-to-end ingestion latency of the
+// monitoring pipeline: it writes a uniquely labeled marker sample (through
+// the Pushgateway or synthexporter) and polls the Prometheus query API until
+// the sample appears. A marker that does not show up within -max-latency is
+// a scrape pipeline stall.
+//
+//	pipelineprobe -prom http://localhost:9090 -pushgateway http://localhost:9091
+//	pipelineprobe -synthexporter http://localhost:9119 -out reports/textfile/pipeline_probe.prom
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/internal/promapi"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// MarkerMetric is the marker series name; the probe id is its probe_id label.
+const MarkerMetric = "trace_bench_pipeline_probe_marker"
+
+// Result is the outcome of one probe.
+type Result struct {
+	ProbeID   string  `json:"probe_id"`
+	Sink      string  `json:"sink"`
+	Written   string  `json:"written"`
+	LatencyMs float64 `json:"latency_ms,omitempty"`
+	Polls     int     `json:"polls"`
+	Stalled   bool    `json:"stalled"`
+	Error     string  `json:"error,omitempty"`
+	OK        bool    `json:"ok"`
+	// Verdict is fail_slo for a stall and fail_infra when the marker could
+	// not be written or Prometheus not queried (pkg/resultenv).
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+func (r Result) verdict() resultenv.Verdict {
+	switch {
+	case r.OK:
+		return resultenv.VerdictPass
+	case r.Stalled:
+		return resultenv.VerdictFailSLO
+	}
+	return resultenv.VerdictFailInfra
+}
+
+func main() {
+	prom := flag.String("prom", "http://localhost:9090", "Prometheus base URL")
+	pushgateway := flag.String("pushgateway", "http://localhost:9091", "Pushgateway base URL to write the marker to")
+	synth := flag.String("synthexporter", "", "write the marker to this synthexporter instead of the Pushgateway")
+	job := flag.String("job", "trace_bench_pipeline_probe", "Pushgateway job name")
+	maxLatency := flag.Duration("max-latency", 2*time.Minute, "fail as a pipeline stall when the marker is not queryable within this time")
+	interval := flag.Duration("interval", time.Second, "query poll interval (latency resolution)")
+	keep := flag.Bool("keep", false, "leave the marker in the Pushgateway after the probe")
+	out := flag.String("out", "", "write the result as a textfile for the node_exporter textfile collector")
+	jsonOut := flag.Bool("json", false, "print the result as JSON")
+	flag.Parse()
+	if *interval <= 0 || *maxLatency <= 0 {
+		fail(fmt.Errorf("-interval and -max-latency must be positive"))
+	}
+
+	var s sink = pushgatewaySink{base: strings.TrimRight(*pushgateway, "/"), job: *job}
+	if *synth != "" {
+		s = synthSink{base: strings.TrimRight(*synth, "/")}
+	}
+	r := probe(s, promapi.New(strings.TrimRight(*prom, "/")), *maxLatency, *interval)
+	r.Verdict = r.verdict()
+	// Polls==0이면 marker 쓰기부터 실패한 것
+	if !*keep && r.Polls > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := s.remove(ctx, r.ProbeID); err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] remove marker: %v\n", err)
+		}
+		cancel()
+	}
+
+	if *out != "" {
+		if err := output.WriteFileAtomicFunc(*out, func(w io.Writer) error { return writeTextfile(w, r) }); err != nil {
+			fail(err)
+		}
+	}
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(r)
+	} else {
+		switch {
+		case r.OK:
+			fmt.Printf("probe %s via %s: visible after %.0fms (%d polls)\n", r.ProbeID, r.Sink, r.LatencyMs, r.Polls)
+		default:
+			fmt.Printf("probe %s via %s: %s %s\n", r.ProbeID, r.Sink, r.Verdict, r.Error)
+		}
+		fmt.Printf("PIPELINE_PROBE_OK: %v\n", r.OK)
+	}
+	if !r.OK {
+		os.Exit(1)
+	}
+}
+
+// probe는 marker를 쓰고 질의 API에 보일 때까지 interval마다 조회한다
+func probe(s sink, c *promapi.Client, maxLatency, interval time.Duration) Result {
+	r := Result{ProbeID: newProbeID(), Sink: s.name()}
+	ctx, cancel := context.WithTimeout(context.Background(), maxLatency+30*time.Second)
+	defer cancel()
+	t0 := time.Now()
+	r.Written = t0.UTC().Format(time.RFC3339Nano)
+	if err := s.write(ctx, r.ProbeID, t0); err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	expr := fmt.Sprintf(`%s{probe_id=%q}`, MarkerMetric, r.ProbeID)
+	deadline := t0.Add(maxLatency)
+	var lastErr error
+	for {
+		r.Polls++
+		qctx, qcancel := context.WithTimeout(ctx, interval+5*time.Second)
+		v, err := c.Query(qctx, expr, time.Time{})
+		qcancel()
+		if err == nil && !math.IsNaN(v) {
+			r.LatencyMs = float64(time.Since(t0).Microseconds()) / 1000
+			r.OK = true
+			return r
+		}
+		lastErr = err
+		if time.Now().Add(interval).After(deadline) {
+			break
+		}
+		time.Sleep(interval)
+	}
+	// 질의 자체가 실패하면 stall이 아니라 Prometheus 오류로 보고한다
+	if lastErr != nil {
+		r.Error = lastErr.Error()
+		return r
+	}
+	r.Stalled = true
+	r.Error = fmt.Sprintf("marker not queryable within %s (scrape pipeline stall)", maxLatency)
+	return r
+}
+
+// writeTextfile은 알람 규칙이 읽는 gauge를 쓴다(prometheus/rules/pipeline_probe.rules.yml)
+func writeTextfile(w io.Writer, r Result) error {
+	ok, stalled := 0, 0
+	if r.OK {
+		ok = 1
+	}
+	if r.Stalled {
+		stalled = 1
+	}
+	fmt.Fprintf(w, "# HELP trace_bench_pipeline_probe_success whether the last marker became queryable in time\n# TYPE trace_bench_pipeline_probe_success gauge\n")
+	fmt.Fprintf(w, "trace_bench_pipeline_probe_success{sink=%q} %d\n", r.Sink, ok)
+	fmt.Fprintf(w, "# HELP trace_bench_pipeline_probe_stalled whether the last marker timed out\n# TYPE trace_bench_pipeline_probe_stalled gauge\n")
+	fmt.Fprintf(w, "trace_bench_pipeline_probe_stalled{sink=%q} %d\n", r.Sink, stalled)
+	if r.OK {
+		fmt.Fprintf(w, "# HELP trace_bench_pipeline_latency_seconds write-to-queryable latency of the last marker\n# TYPE trace_bench_pipeline_latency_seconds gauge\n")
+		fmt.Fprintf(w, "trace_bench_pipeline_latency_seconds{sink=%q} %g\n", r.Sink, r.LatencyMs/1000)
+	}
+	fmt.Fprintf(w, "# HELP trace_bench_pipeline_probe_timestamp_seconds time of the last probe\n# TYPE trace_bench_pipeline_probe_timestamp_seconds gauge\n")
+	_, err := fmt.Fprintf(w, "trace_bench_pipeline_probe_timestamp_seconds{sink=%q} %d\n", r.Sink, time.Now().Unix())
+	return err
+}
+
+func newProbeID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
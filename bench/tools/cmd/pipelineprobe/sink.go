@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sink는 marker 샘플을 Prometheus가 scrape하는 곳에 쓴다
+type sink interface {
+	name() string
+	write(ctx context.Context, id string, t time.Time) error
+	remove(ctx context.Context, id string) error
+}
+
+// pushgatewaySink는 probe마다 grouping key(job, probe_id)를 따로 둔다
+type pushgatewaySink struct {
+	base, job string
+}
+
+func (p pushgatewaySink) name() string { return "pushgateway" }
+
+func (p pushgatewaySink) group(id string) string {
+	return p.base + "/metrics/job/" + url.PathEscape(p.job) + "/probe_id/" + url.PathEscape(id)
+}
+
+func (p pushgatewaySink) write(ctx context.Context, id string, t time.Time) error {
+	body := fmt.Sprintf("# TYPE %s gauge\n%s %d\n", MarkerMetric, MarkerMetric, t.Unix())
+	return send(ctx, http.MethodPut, p.group(id), body)
+}
+
+func (p pushgatewaySink) remove(ctx context.Context, id string) error {
+	return send(ctx, http.MethodDelete, p.group(id), "")
+}
+
+// synthSink는 synthexporter의 /-/probe를 쓴다(최근 marker 몇 개만 유지하므로 remove는 없음)
+type synthSink struct {
+	base string
+}
+
+func (s synthSink) name() string { return "synthexporter" }
+
+func (s synthSink) write(ctx context.Context, id string, t time.Time) error {
+	return send(ctx, http.MethodPost, s.base+"/-/probe?id="+url.QueryEscape(id), "")
+}
+
+func (s synthSink) remove(context.Context, string) error { return nil }
+
+func send(ctx context.Context, method, u, body string) error {
+	req, err := http.NewRequestWithContext(ctx, method, u, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("%s %s: %s %s", method, u, resp.Status, strings.TrimSpace(string(b)))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/duri/trace_bench/internal/yamlite"
+)
+
+// Inventory is the expected port list.
+type Inventory struct {
+	Version int `json:"version"`
+	// IgnoreLoopback treats loopback-only listeners that are not listed as
+	// internal (not an exposure).
+	IgnoreLoopback bool    `json:"ignore_loopback"`
+	Ports          []Entry `json:"ports"`
+}
+
+// Entry is one expected port. Bind is the widest allowed bind scope:
+// loopback, host (a specific address) or any (default).
+type Entry struct {
+	Name  string `json:"name"`
+	Port  int    `json:"port"`
+	Proto string `json:"proto"`
+	Bind  string `json:"bind"`
+}
+
+func loadInventory(path string) (*Inventory, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var inv Inventory
+	if err := yamlite.Unmarshal(b, &inv); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if inv.Version != 1 {
+		return nil, fmt.Errorf("%s: unsupported version %d", path, inv.Version)
+	}
+	seen := map[string]bool{}
+	for i := range inv.Ports {
+		e := &inv.Ports[i]
+		if e.Proto == "" {
+			e.Proto = "tcp"
+		}
+		if e.Bind == "" {
+			e.Bind = ScopeAny
+		}
+		if e.Port <= 0 || e.Port > 65535 {
+			return nil, fmt.Errorf("%s: ports[%d]: invalid port %d", path, i, e.Port)
+		}
+		if e.Proto != "tcp" && e.Proto != "udp" {
+			return nil, fmt.Errorf("%s: ports[%d]: invalid proto %q", path, i, e.Proto)
+		}
+		if _, ok := scopeRank[e.Bind]; !ok {
+			return nil, fmt.Errorf("%s: ports[%d]: invalid bind %q (want loopback, host or any)", path, i, e.Bind)
+		}
+		k := fmt.Sprintf("%s/%d", e.Proto, e.Port)
+		if seen[k] {
+			return nil, fmt.Errorf("%s: duplicate port %s", path, k)
+		}
+		seen[k] = true
+	}
+	return &inv, nil
+}
+
+func (inv *Inventory) lookup(proto string, port int) (*Entry, int) {
+	for i := range inv.Ports {
+		if inv.Ports[i].Proto == proto && inv.Ports[i].Port == port {
+			return &inv.Ports[i], i
+		}
+	}
+	return nil, -1
+}
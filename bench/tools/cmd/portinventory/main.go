@@ -0,0 +1,221 @@
+// Command portinventory enumerates the listening ports of the DuRi stack
+// (from /proc/net and/or published Docker ports), compares them with the
+// expected inventory and fails on unexpected exposures.
+//
+//	portinventory -inventory port_inventory.yaml
+//	portinventory -inventory port_inventory.yaml -source docker -json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/internal/dockerapi"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// Finding kinds.
+const (
+	KindUnexpected = "unexpected" // 목록에 없는 포트
+	KindTooWide    = "too_wide"   // 허용 범위보다 넓게 바인드
+	KindMissing    = "missing"    // 목록에 있으나 리스닝하지 않음
+)
+
+// Finding is one inventory mismatch.
+type Finding struct {
+	Kind     string    `json:"kind"`
+	Listener *Listener `json:"listener,omitempty"`
+	Expected *Entry    `json:"expected,omitempty"`
+	Message  string    `json:"message"`
+}
+
+// Report is the gate JSON.
+type Report struct {
+	Inventory string     `json:"inventory"`
+	Listeners []Listener `json:"listeners"`
+	Findings  []Finding  `json:"findings"`
+	OK        bool       `json:"ok"`
+	// Verdict is fail_slo when OK is false and pass_with_warnings when only
+	// warnings (missing ports without -require-all) were found.
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+func main() {
+	path := flag.String("inventory", "port_inventory.yaml", "expected port inventory")
+	source := flag.String("source", "proc", "where to enumerate ports: proc, docker or both")
+	procRoot := flag.String("proc", "/proc", "procfs root (e.g. /host/proc inside a container)")
+	requireAll := flag.Bool("require-all", false, "also fail when an inventory port is not listening")
+	jsonOut := flag.Bool("json", false, "print the gate JSON instead of the summary")
+	flag.Parse()
+
+	inv, err := loadInventory(*path)
+	if err != nil {
+		fail(err)
+	}
+	var ls []Listener
+	switch *source {
+	case "proc", "docker", "both":
+	default:
+		fail(fmt.Errorf("invalid -source %q (want proc, docker or both)", *source))
+	}
+	if *source != "docker" {
+		l, err := procListeners(*procRoot)
+		if err != nil {
+			fail(err)
+		}
+		ls = append(ls, l...)
+	}
+	if *source != "proc" {
+		l, err := dockerListeners()
+		if err != nil {
+			fail(err)
+		}
+		ls = append(ls, l...)
+	}
+	ls = dedupe(ls)
+
+	fs := compare(inv, ls)
+	rep := Report{Inventory: *path, Listeners: ls, Findings: fs, OK: true}
+	var failures, warnings []string
+	for _, f := range fs {
+		if f.Kind != KindMissing || *requireAll {
+			rep.OK = false
+			failures = append(failures, f.Message)
+		} else {
+			warnings = append(warnings, f.Message)
+		}
+	}
+	rep.Verdict = resultenv.Judge(failures, warnings)
+	if rep.Findings == nil {
+		rep.Findings = []Finding{}
+	}
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+	} else {
+		for _, f := range fs {
+			level := "FAIL"
+			if f.Kind == KindMissing && !*requireAll {
+				level = "WARN"
+			}
+			fmt.Printf("[%s] %s: %s\n", level, f.Kind, f.Message)
+		}
+		fmt.Printf("%d listeners, %d findings\n", len(ls), len(fs))
+		fmt.Printf("PORTINVENTORY_OK: %v\n", rep.OK)
+	}
+	if !rep.OK {
+		os.Exit(1)
+	}
+}
+
+// compare는 리스너를 목록과 맞춘다(루프백 리스너는 ignore_loopback이면 노출이 아님)
+func compare(inv *Inventory, ls []Listener) []Finding {
+	var fs []Finding
+	used := make([]bool, len(inv.Ports))
+	for i := range ls {
+		l := &ls[i]
+		e, idx := inv.lookup(l.Proto, l.Port)
+		if e == nil {
+			if l.Scope == ScopeLoopback && inv.IgnoreLoopback {
+				continue
+			}
+			fs = append(fs, Finding{Kind: KindUnexpected, Listener: l, Message: fmt.Sprintf("%s %s bound to %s (%s)%s", l.Proto, net.JoinHostPort(l.Address, fmt.Sprint(l.Port)), l.Scope, l.Source, owner(l))})
+			continue
+		}
+		used[idx] = true
+		if wider(l.Scope, e.Bind) {
+			fs = append(fs, Finding{Kind: KindTooWide, Listener: l, Expected: e, Message: fmt.Sprintf("%s (%s) %s/%d bound to %s, inventory allows %s%s", e.Name, l.Source, l.Proto, l.Port, l.Scope, e.Bind, owner(l))})
+		}
+	}
+	for i := range inv.Ports {
+		if !used[i] {
+			e := &inv.Ports[i]
+			fs = append(fs, Finding{Kind: KindMissing, Expected: e, Message: fmt.Sprintf("%s %s/%d not listening", e.Name, e.Proto, e.Port)})
+		}
+	}
+	return fs
+}
+
+// 바인드 범위 순서: loopback < host < any
+var scopeRank = map[string]int{ScopeLoopback: 0, ScopeHost: 1, ScopeAny: 2}
+
+func wider(got, allowed string) bool { return scopeRank[got] > scopeRank[allowed] }
+
+func owner(l *Listener) string {
+	if l.Process == "" {
+		return ""
+	}
+	return " by " + l.Process
+}
+
+// dockerListeners는 공개(publish)된 컨테이너 포트
+func dockerListeners() ([]Listener, error) {
+	c, err := dockerapi.New()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	list, err := c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []Listener
+	for _, ctr := range list {
+		name := ctr.ID
+		if len(ctr.Names) > 0 {
+			name = strings.TrimPrefix(ctr.Names[0], "/")
+		}
+		for _, p := range ctr.Ports {
+			if p.PublicPort == 0 {
+				continue
+			}
+			ip := net.ParseIP(p.IP)
+			if ip == nil {
+				ip = net.IPv4zero
+			}
+			out = append(out, Listener{Proto: p.Type, Address: ip.String(), Port: p.PublicPort, Scope: scopeOf(ip), Process: "container " + name, Source: "docker"})
+		}
+	}
+	return out, nil
+}
+
+// dedupe는 같은 proto/주소/포트(예: docker-proxy와 Docker API가 모두 보고)를 하나로 합치고 정렬한다
+func dedupe(ls []Listener) []Listener {
+	seen := map[string]int{}
+	var out []Listener
+	for _, l := range ls {
+		k := fmt.Sprintf("%s/%s/%d", l.Proto, l.Address, l.Port)
+		if i, ok := seen[k]; ok {
+			if out[i].Process == "" || l.Source == "docker" {
+				out[i].Process = l.Process
+			}
+			continue
+		}
+		seen[k] = len(out)
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Port != out[j].Port {
+			return out[i].Port < out[j].Port
+		}
+		if out[i].Proto != out[j].Proto {
+			return out[i].Proto < out[j].Proto
+		}
+		return out[i].Address < out[j].Address
+	})
+	return out
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Listener is one listening socket or published container port.
+type Listener struct {
+	Proto   string `json:"proto"` // tcp | udp
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+	Scope   string `json:"scope"` // any | loopback | host
+	Process string `json:"process,omitempty"`
+	Source  string `json:"source"` // proc | docker
+}
+
+// Bind scopes.
+const (
+	ScopeAny      = "any"
+	ScopeLoopback = "loopback"
+	ScopeHost     = "host" // 특정 인터페이스 주소
+)
+
+func scopeOf(ip net.IP) string {
+	switch {
+	case ip == nil || ip.IsUnspecified():
+		return ScopeAny
+	case ip.IsLoopback():
+		return ScopeLoopback
+	}
+	return ScopeHost
+}
+
+// /proc/net/* 상태 코드: TCP LISTEN=0A, UDP는 연결 없는 소켓 07
+var procTables = []struct {
+	file, proto, state string
+}{
+	{"tcp", "tcp", "0A"}, {"tcp6", "tcp", "0A"},
+	{"udp", "udp", "07"}, {"udp6", "udp", "07"},
+}
+
+// procListeners는 /proc/net에서 리스닝 소켓을 읽는다(root가 아니면 프로세스 이름은 일부만 채워짐)
+func procListeners(root string) ([]Listener, error) {
+	owners := socketOwners(root)
+	var out []Listener
+	read := 0
+	for _, t := range procTables {
+		f, err := os.Open(filepath.Join(root, "net", t.file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		read++
+		sc := bufio.NewScanner(f)
+		sc.Scan() // 헤더
+		for sc.Scan() {
+			fs := strings.Fields(sc.Text())
+			if len(fs) < 10 || fs[3] != t.state {
+				continue
+			}
+			ip, port, err := parseHexAddr(fs[1])
+			if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("%s: %w", t.file, err)
+			}
+			out = append(out, Listener{Proto: t.proto, Address: ip.String(), Port: port, Scope: scopeOf(ip), Process: owners[fs[9]], Source: "proc"})
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if read == 0 {
+		return nil, fmt.Errorf("%s/net: no socket tables (not Linux?)", root)
+	}
+	return out, nil
+}
+
+// parseHexAddr는 "0100007F:1F90" 같은 주소를 읽는다(4바이트 단위 little-endian)
+func parseHexAddr(s string) (net.IP, int, error) {
+	h, p, ok := strings.Cut(s, ":")
+	b, err := hex.DecodeString(h)
+	if !ok || err != nil || (len(b) != 4 && len(b) != 16) {
+		return nil, 0, fmt.Errorf("invalid address %q", s)
+	}
+	for i := 0; i < len(b); i += 4 {
+		b[i], b[i+1], b[i+2], b[i+3] = b[i+3], b[i+2], b[i+1], b[i]
+	}
+	port, err := strconv.ParseUint(p, 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid port in %q", s)
+	}
+	return net.IP(b), int(port), nil
+}
+
+// socketOwners는 소켓 inode → "comm(pid)" (읽을 수 없는 프로세스는 건너뜀)
+func socketOwners(root string) map[string]string {
+	out := map[string]string{}
+	pids, _ := filepath.Glob(filepath.Join(root, "[0-9]*"))
+	for _, dir := range pids {
+		fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+		if err != nil {
+			continue
+		}
+		comm, _ := os.ReadFile(filepath.Join(dir, "comm"))
+		owner := fmt.Sprintf("%s(%s)", strings.TrimSpace(string(comm)), filepath.Base(dir))
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+			if err != nil {
+				continue
+			}
+			if inode, ok := strings.CutPrefix(link, "socket:["); ok {
+				out[strings.TrimSuffix(inode, "]")] = owner
+			}
+		}
+	}
+	return out
+}
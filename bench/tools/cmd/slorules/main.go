@@ -0,0 +1,129 @@
+// Command slorules derives multi-window, multi-burn-rate Prometheus alerting
+// rules from slo.yaml, so alerts follow the same SLO source of truth.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/internal/promapi"
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/slo"
+)
+
+// Policy is one alert: fire when Budget of the error budget is spent within
+// Long, confirmed over Short (e.g. page:2%/1h/5m).
+type Policy struct {
+	Severity    string
+	Budget      float64
+	Long, Short string
+}
+
+// DefaultPolicies는 SRE workbook 권장값(30d 기준 burn 14.4/6/1)
+const DefaultPolicies = "page:2%/1h/5m,page:5%/6h/30m,ticket:10%/3d/6h"
+
+func parsePolicies(s string) ([]Policy, error) {
+	var out []Policy
+	for _, item := range strings.Split(s, ",") {
+		sev, rest, ok := strings.Cut(strings.TrimSpace(item), ":")
+		parts := strings.Split(rest, "/")
+		if !ok || len(parts) != 3 || !strings.HasSuffix(parts[0], "%") {
+			return nil, fmt.Errorf("invalid policy: %s (expected <severity>:<pct>%%/<long>/<short>)", item)
+		}
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(parts[0], "%"), 64)
+		if err != nil || pct <= 0 || pct > 100 {
+			return nil, fmt.Errorf("invalid policy budget: %s", item)
+		}
+		for _, w := range parts[1:] {
+			if _, err := promapi.ParseDuration(w); err != nil {
+				return nil, fmt.Errorf("policy %s: %w", item, err)
+			}
+		}
+		out = append(out, Policy{Severity: sev, Budget: pct / 100, Long: parts[1], Short: parts[2]})
+	}
+	return out, nil
+}
+
+// burnRate는 long 윈도 안에 예산의 Budget 비율을 쓰는 소진 속도
+func (p Policy) burnRate(window time.Duration) float64 {
+	long, _ := promapi.ParseDuration(p.Long)
+	return p.Budget * float64(window) / float64(long)
+}
+
+func main() {
+	sloPath := flag.String("slo", "slo.yaml", "shared SLO definitions")
+	policies := flag.String("policies", DefaultPolicies, "comma-separated <severity>:<budget%>/<long>/<short> pairs")
+	group := flag.String("group", "slo-burn-rate", "rule group name")
+	out := flag.String("out", "", "write rules here instead of stdout")
+	flag.Parse()
+
+	defs, err := slo.Load(*sloPath)
+	if err != nil {
+		fail(err)
+	}
+	pol, err := parsePolicies(*policies)
+	if err != nil {
+		fail(err)
+	}
+	write := func(w io.Writer) error { return writeRules(w, *group, *sloPath, defs.Queries(), pol) }
+	if *out == "" {
+		write(os.Stdout)
+		return
+	}
+	if err := output.WriteFileAtomicFunc(*out, write); err != nil {
+		fail(err)
+	}
+}
+
+func writeRules(w io.Writer, group, src string, slos []slo.SLO, pol []Policy) error {
+	fmt.Fprintf(w, "# Code generated by slorules from %s. DO NOT EDIT.\ngroups:\n", src)
+	fmt.Fprintf(w, "  - name: %s\n    rules:\n", group)
+	for _, s := range slos {
+		// 정책에 쓰이는 윈도별 error ratio 기록 규칙
+		seen := map[string]bool{}
+		for _, p := range pol {
+			for _, win := range []string{p.Short, p.Long} {
+				if seen[win] {
+					continue
+				}
+				seen[win] = true
+				fmt.Fprintf(w, "      - record: %s\n", recordName(win))
+				fmt.Fprintf(w, "        expr: %s\n", strconv.Quote(s.QueryExpr(win)))
+				fmt.Fprintf(w, "        labels:\n          slo: %s\n", strconv.Quote(s.Name))
+			}
+		}
+		for _, p := range pol {
+			th := fmt.Sprintf("(%s * %s)", fmtFloat(p.burnRate(s.WindowDuration())), fmtFloat(s.ErrorBudget()))
+			sel := fmt.Sprintf("{slo=%s}", strconv.Quote(s.Name))
+			expr := fmt.Sprintf("%s%s > %s and %s%s > %s", recordName(p.Long), sel, th, recordName(p.Short), sel, th)
+			fmt.Fprintf(w, "      - alert: SLOErrorBudgetBurn\n")
+			fmt.Fprintf(w, "        expr: %s\n", strconv.Quote(expr))
+			fmt.Fprintf(w, "        for: %s\n", forDuration(p.Short))
+			fmt.Fprintf(w, "        labels:\n          severity: %s\n          slo: %s\n          long_window: %s\n          short_window: %s\n",
+				p.Severity, strconv.Quote(s.Name), p.Long, p.Short)
+			fmt.Fprintf(w, "        annotations:\n          summary: %s\n",
+				strconv.Quote(fmt.Sprintf("%s is burning %g%% of its %s error budget within %s", s.Name, p.Budget*100, s.Window, p.Long)))
+		}
+	}
+	return nil
+}
+
+func recordName(window string) string { return "slo:error_ratio:rate" + window }
+
+// for는 short 윈도의 1/10(최소 1m)로 일시적 스파이크를 거른다
+func forDuration(short string) string {
+	d, _ := promapi.ParseDuration(short)
+	return fmt.Sprintf("%dm", int(max(d/10, time.Minute)/time.Minute))
+}
+
+func fmtFloat(f float64) string { return strconv.FormatFloat(f, 'g', 6, 64) }
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
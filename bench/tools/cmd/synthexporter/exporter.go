@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// exporter는 시나리오 시각을 step 격자로 적분해 counter/histogram을 누적한다.
+// 같은 시나리오와 같은 시각이면 항상 같은 값을 내므로 드릴이 재현된다.
+type exporter struct {
+	sc    *Scenario
+	speed float64
+	now   func() time.Time
+
+	mu    sync.Mutex
+	start time.Time
+	steps int64 // 적분을 마친 step 수
+	state []seriesState
+	marks []probeMark
+}
+
+// probeMark는 pipelineprobe가 쓴 marker(시나리오와 무관하게 노출)
+type probeMark struct {
+	id string
+	at time.Time
+}
+
+// 노출을 유지하는 최근 marker 수(probe_id 카디널리티 상한)
+const maxProbeMarks = 16
+
+type seriesState struct {
+	total   float64   // counter 누적값, histogram 관측 수
+	sum     float64   // histogram 관측값 합
+	buckets []float64 // histogram 버킷별 le 이하 관측 수
+}
+
+func newExporter(sc *Scenario, speed float64) *exporter {
+	e := &exporter{sc: sc, speed: speed, now: time.Now}
+	e.reset()
+	return e
+}
+
+// reset은 시나리오 시계를 0으로 되돌리고 누적값을 지운다
+func (e *exporter) reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.start = e.now()
+	e.steps = 0
+	e.state = make([]seriesState, len(e.sc.Series))
+	for i, sr := range e.sc.Series {
+		e.state[i].buckets = make([]float64, len(sr.Buckets))
+	}
+}
+
+// mark는 probe marker를 추가한다(오래된 것부터 밀려남)
+func (e *exporter) mark(id string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.marks = append(e.marks, probeMark{id: id, at: e.now()})
+	if len(e.marks) > maxProbeMarks {
+		e.marks = e.marks[len(e.marks)-maxProbeMarks:]
+	}
+}
+
+// elapsed는 배속을 적용한 시나리오 시각
+func (e *exporter) elapsed() time.Duration {
+	return time.Duration(float64(e.now().Sub(e.start)) * e.speed)
+}
+
+// advance는 시각 t까지 완료된 step을 적분한다(e.mu를 잡은 상태에서 호출)
+func (e *exporter) advance(t time.Duration) {
+	step := e.sc.step
+	dt := step.Seconds()
+	for ; time.Duration(e.steps+1)*step <= t; e.steps++ {
+		// step 중간 시각의 값으로 적분
+		mid := e.sc.at(time.Duration(e.steps)*step + step/2)
+		for i := range e.sc.Series {
+			sr, st := &e.sc.Series[i], &e.state[i]
+			v := sr.valueAt(mid)
+			switch sr.Type {
+			case Counter:
+				st.total += v * dt
+			case Histogram:
+				n := sr.Rate * dt
+				st.total += n
+				st.sum += n * v
+				for b, le := range sr.Buckets {
+					if v <= le {
+						st.buckets[b] += n
+					}
+				}
+			}
+		}
+	}
+}
+
+// write는 step 격자 시각 기준의 Prometheus text format을 쓴다
+func (e *exporter) write(w io.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.advance(e.elapsed())
+	t := time.Duration(e.steps) * e.sc.step
+	fmt.Fprintln(w, "# HELP synthexporter_scenario_seconds scenario time of the exposed values")
+	fmt.Fprintln(w, "# TYPE synthexporter_scenario_seconds gauge")
+	fmt.Fprintf(w, "synthexporter_scenario_seconds %s\n", fmtFloat(t.Seconds()))
+	if len(e.marks) > 0 {
+		fmt.Fprintln(w, "# HELP trace_bench_pipeline_probe_marker unix time the pipeline probe marker was written")
+		fmt.Fprintln(w, "# TYPE trace_bench_pipeline_probe_marker gauge")
+		for _, m := range e.marks {
+			fmt.Fprintf(w, "trace_bench_pipeline_probe_marker%s %d\n", formatLabels(map[string]string{"probe_id": m.id}, "", ""), m.at.Unix())
+		}
+	}
+	done := map[string]bool{}
+	for i, sr := range e.sc.Series {
+		if !done[sr.Name] {
+			done[sr.Name] = true
+			if sr.Help != "" {
+				fmt.Fprintf(w, "# HELP %s %s\n", sr.Name, escapeHelp(sr.Help))
+			}
+			fmt.Fprintf(w, "# TYPE %s %s\n", sr.Name, sr.Type)
+		}
+		st := e.state[i]
+		switch sr.Type {
+		case Gauge:
+			fmt.Fprintf(w, "%s%s %s\n", sr.Name, formatLabels(sr.Labels, "", ""), fmtFloat(sr.valueAt(e.sc.at(t))))
+		case Counter:
+			fmt.Fprintf(w, "%s%s %s\n", sr.Name, formatLabels(sr.Labels, "", ""), fmtFloat(st.total))
+		case Histogram:
+			for b, le := range sr.Buckets {
+				fmt.Fprintf(w, "%s_bucket%s %s\n", sr.Name, formatLabels(sr.Labels, "le", fmtFloat(le)), fmtFloat(st.buckets[b]))
+			}
+			fmt.Fprintf(w, "%s_bucket%s %s\n", sr.Name, formatLabels(sr.Labels, "le", "+Inf"), fmtFloat(st.total))
+			fmt.Fprintf(w, "%s_sum%s %s\n", sr.Name, formatLabels(sr.Labels, "", ""), fmtFloat(st.sum))
+			fmt.Fprintf(w, "%s_count%s %s\n", sr.Name, formatLabels(sr.Labels, "", ""), fmtFloat(st.total))
+		}
+	}
+}
+
+// formatLabels는 정렬된 {k="v",...}를 만든다(extra가 있으면 마지막에 추가)
+func formatLabels(l map[string]string, extra, extraValue string) string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		parts = append(parts, k+`="`+labelEscaper.Replace(l[k])+`"`)
+	}
+	if extra != "" {
+		parts = append(parts, extra+`="`+extraValue+`"`)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func fmtFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
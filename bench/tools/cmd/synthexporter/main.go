@@ -0,0 +1,109 @@
+// Command synthexporter exposes a Prometheus /metrics endpoint whose values
+// follow a scenario file (ramped error rates, latency spikes), so alert
+// drills get deterministic, reproducible trigger conditions.
+//
+//	synthexporter -scenario scenarios/error_ramp.yaml -listen :9119
+//	synthexporter -scenario scenarios/error_ramp.yaml -print 1m
+//
+// POST /-/reset restarts the scenario clock; POST /-/probe?id=X exposes a
+// pipelineprobe marker.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	path := flag.String("scenario", "", "scenario file (required)")
+	listen := flag.String("listen", ":9119", "listen address")
+	speed := flag.Float64("speed", 1, "scenario seconds per wall-clock second")
+	printEvery := flag.Duration("print", 0, "print the exposition every this much scenario time until the end, then exit")
+	flag.Parse()
+	if *path == "" {
+		fmt.Fprintln(os.Stderr, "usage: synthexporter -scenario FILE [-listen :9119] [-speed N] [-print DURATION]")
+		os.Exit(2)
+	}
+	if *speed <= 0 {
+		fail(fmt.Errorf("-speed must be positive"))
+	}
+	sc, err := loadScenario(*path)
+	if err != nil {
+		fail(err)
+	}
+	if *printEvery > 0 {
+		printTimeline(sc, *printEvery)
+		return
+	}
+
+	e := newExporter(sc, *speed)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		e.write(w)
+	})
+	mux.HandleFunc("/-/reset", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "use POST", http.StatusMethodNotAllowed)
+			return
+		}
+		e.reset()
+		fmt.Fprintln(w, "scenario restarted")
+	})
+	mux.HandleFunc("/-/probe", func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		if r.Method != http.MethodPost || id == "" {
+			http.Error(w, "use POST /-/probe?id=<probe id>", http.StatusBadRequest)
+			return
+		}
+		e.mark(id)
+		fmt.Fprintln(w, "marked")
+	})
+	mux.HandleFunc("/-/healthy", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	srv := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	fmt.Fprintf(os.Stderr, "[INFO] synthexporter: %s on %s (%d series, %s%s)\n", *path, *listen, len(sc.Series), sc.duration, loopNote(sc))
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fail(err)
+	}
+}
+
+// printTimeline은 서버 없이 시나리오 시각 every마다의 노출값을 출력한다(리뷰/재현 확인용)
+func printTimeline(sc *Scenario, every time.Duration) {
+	var t time.Duration
+	e := newExporter(sc, 1)
+	e.now = func() time.Time { return e.start.Add(t) }
+	for t = 0; t <= sc.duration; t += every {
+		fmt.Printf("# t=%s\n", t)
+		e.write(os.Stdout)
+	}
+}
+
+func loopNote(sc *Scenario) string {
+	if sc.Loop {
+		return ", looping"
+	}
+	return ""
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/duri/trace_bench/internal/promapi"
+	"github.com/duri/trace_bench/internal/yamlite"
+)
+
+// Series types.
+const (
+	Gauge     = "gauge"
+	Counter   = "counter"
+	Histogram = "histogram"
+)
+
+// Scenario scripts metric values over scenario time.
+type Scenario struct {
+	Version  int      `json:"version"`
+	Duration string   `json:"duration"`
+	Loop     bool     `json:"loop"`
+	Step     string   `json:"step"`
+	Series   []Series `json:"series"`
+
+	duration, step time.Duration
+}
+
+// Series is one exposed time series. Points are interpolated linearly;
+// two points with the same "at" make an instant jump. For a gauge the value
+// is exposed as is, for a counter it is the increase per second, for a
+// histogram it is the latency of the Rate observations per second.
+type Series struct {
+	Name    string            `json:"name"`
+	Type    string            `json:"type"`
+	Help    string            `json:"help"`
+	Labels  map[string]string `json:"labels"`
+	Buckets []float64         `json:"buckets"`
+	Rate    float64           `json:"rate"`
+	Points  []Point           `json:"points"`
+}
+
+// Point is the value of a series at scenario offset At (e.g. "2m").
+type Point struct {
+	At    string  `json:"at"`
+	Value float64 `json:"value"`
+
+	at time.Duration
+}
+
+var (
+	nameRe  = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Prometheus 클라이언트 기본 버킷(초)
+var defaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+func loadScenario(path string) (*Scenario, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Scenario
+	if err := yamlite.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := s.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// validate는 값을 검사하고 기간/기본값을 채운다
+func (s *Scenario) validate() error {
+	if s.Version != 1 {
+		return fmt.Errorf("unsupported scenario version %d", s.Version)
+	}
+	if len(s.Series) == 0 {
+		return fmt.Errorf("no series")
+	}
+	s.step = time.Second
+	if s.Step != "" {
+		d, err := promapi.ParseDuration(s.Step)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("step: invalid duration %q", s.Step)
+		}
+		s.step = d
+	}
+	seen := map[string]bool{}
+	var last time.Duration
+	for i := range s.Series {
+		sr := &s.Series[i]
+		if !nameRe.MatchString(sr.Name) {
+			return fmt.Errorf("series[%d]: invalid metric name %q", i, sr.Name)
+		}
+		switch sr.Type {
+		case "":
+			sr.Type = Gauge
+		case Gauge, Counter, Histogram:
+		default:
+			return fmt.Errorf("series %s: unknown type %q (want gauge, counter or histogram)", sr.Name, sr.Type)
+		}
+		for k := range sr.Labels {
+			if !labelRe.MatchString(k) || k == "le" {
+				return fmt.Errorf("series %s: invalid label name %q", sr.Name, k)
+			}
+		}
+		if id := sr.Name + formatLabels(sr.Labels, "", ""); seen[id] {
+			return fmt.Errorf("series %s: duplicate series", id)
+		} else {
+			seen[id] = true
+		}
+		if sr.Type == Histogram {
+			if len(sr.Buckets) == 0 {
+				sr.Buckets = defaultBuckets
+			}
+			if !sort.Float64sAreSorted(sr.Buckets) {
+				return fmt.Errorf("series %s: buckets must be increasing", sr.Name)
+			}
+			if sr.Rate == 0 {
+				sr.Rate = 1
+			}
+			if sr.Rate < 0 {
+				return fmt.Errorf("series %s: rate must be positive", sr.Name)
+			}
+		}
+		if len(sr.Points) == 0 {
+			return fmt.Errorf("series %s: no points", sr.Name)
+		}
+		for j := range sr.Points {
+			p := &sr.Points[j]
+			d, err := promapi.ParseDuration(p.At)
+			if p.At == "0" {
+				d, err = 0, nil
+			}
+			if err != nil {
+				return fmt.Errorf("series %s: points[%d]: %w", sr.Name, j, err)
+			}
+			if j > 0 && d < sr.Points[j-1].at {
+				return fmt.Errorf("series %s: points[%d]: at %s is before the previous point", sr.Name, j, p.At)
+			}
+			if sr.Type != Gauge && (p.Value < 0 || math.IsNaN(p.Value)) {
+				return fmt.Errorf("series %s: points[%d]: %s value must not be negative", sr.Name, j, sr.Type)
+			}
+			p.at = d
+			last = max(last, d)
+		}
+	}
+	s.duration = last
+	if s.Duration != "" {
+		d, err := promapi.ParseDuration(s.Duration)
+		if err != nil {
+			return fmt.Errorf("duration: %w", err)
+		}
+		if d < last {
+			return fmt.Errorf("duration %s is shorter than the last point (%s)", s.Duration, last)
+		}
+		s.duration = d
+	}
+	if s.Loop && s.duration == 0 {
+		return fmt.Errorf("loop needs a positive duration")
+	}
+	return nil
+}
+
+// at은 시나리오 시각 t를 loop를 반영한 오프셋으로 바꾼다
+func (s *Scenario) at(t time.Duration) time.Duration {
+	if s.Loop {
+		return t % s.duration
+	}
+	return t
+}
+
+// valueAt은 오프셋 t의 값(첫 점 이전은 첫 값, 마지막 점 이후는 마지막 값)
+func (sr *Series) valueAt(t time.Duration) float64 {
+	ps := sr.Points
+	// t보다 뒤인 첫 점(같은 at이 여럿이면 마지막 점이 이후 구간을 정함)
+	i := sort.Search(len(ps), func(i int) bool { return ps[i].at > t })
+	switch {
+	case i == 0:
+		return ps[0].Value
+	case i == len(ps):
+		return ps[len(ps)-1].Value
+	}
+	a, b := ps[i-1], ps[i]
+	f := float64(t-a.at) / float64(b.at-a.at)
+	return a.Value + f*(b.Value-a.Value)
+}
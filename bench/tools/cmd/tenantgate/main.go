@@ -0,0 +1,204 @@
+// Command tenantgate verifies tenant isolation of the shared monitoring
+// stack: it writes a marker sample (remote-write) and a span (OTLP/HTTP)
+// for each of two or more synthetic tenants, then queries as each tenant and
+// fails when a tenant-scoped query returns another tenant's data, or when
+// a tenant cannot see its own data within -max-wait (the gate proves
+// nothing then).
+//
+// Queries are scoped by header (-scope header: X-Scope-OrgID for Mimir,
+// Cortex, Loki and Tempo) or by a label-enforcing proxy parameter (-scope
+// label: prom-label-proxy's ?tenant=<id>).
+//
+//	tenantgate -remote-write http://mimir:9009/api/v1/push -prom http://mimir:9009/prometheus \
+//	    -otlp http://tempo:4318/v1/traces -tempo http://tempo:3200
+//	tenantgate -scope label -remote-write http://prometheus:9090/api/v1/write -prom http://prom-label-proxy:8080
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// Signals checked by the gate.
+const (
+	SignalMetrics = "metrics"
+	SignalTraces  = "traces"
+)
+
+// Check states.
+const (
+	StatePass = "pass"
+	StateFail = "fail"
+)
+
+// Check is the result of querying one signal as one tenant.
+type Check struct {
+	Signal string `json:"signal"`
+	Tenant string `json:"tenant"`
+	// Visible is whether the tenant's own data was returned; Leaked lists
+	// the other tenants whose data was returned to it.
+	Visible bool     `json:"visible"`
+	Leaked  []string `json:"leaked,omitempty"`
+	Queries int      `json:"queries"`
+	State   string   `json:"state"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Report is the gate JSON.
+type Report struct {
+	ProbeID string   `json:"probe_id"`
+	Scope   string   `json:"scope"`
+	Tenants []string `json:"tenants"`
+	Checks  []Check  `json:"checks"`
+	Leaks   int      `json:"leaks"`
+	OK      bool     `json:"ok"`
+	// Verdict is the worst check verdict (pkg/resultenv).
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+// verdict: 누출은 fail_slo, 자기 marker를 못 본 것(격리 미검증)은 fail_infra
+func (c Check) verdict() resultenv.Verdict {
+	switch {
+	case len(c.Leaked) > 0:
+		return resultenv.VerdictFailSLO
+	case !c.Visible:
+		return resultenv.VerdictFailInfra
+	}
+	return resultenv.VerdictPass
+}
+
+func main() {
+	tenants := flag.String("tenants", "bench-tenant-a,bench-tenant-b", "comma list of synthetic tenant IDs (at least two)")
+	scopeMode := flag.String("scope", scopeHeader, "how queries are scoped to a tenant: header | label")
+	scopeHeaderName := flag.String("scope-header", "X-Scope-OrgID", "-scope header: tenant header, also sent with writes")
+	tenantLabel := flag.String("tenant-label", "tenant", "label/attribute carrying the tenant on written data; -scope label: the proxy parameter")
+	remoteWrite := flag.String("remote-write", "", "remote-write URL for the metric markers (empty: skip metrics)")
+	prom := flag.String("prom", "http://localhost:9090", "Prometheus-compatible query base URL")
+	otlp := flag.String("otlp", "", "OTLP/HTTP traces URL for the span markers, e.g. http://tempo:4318/v1/traces (empty: skip traces)")
+	tempo := flag.String("tempo", "http://localhost:3200", "Tempo query base URL")
+	maxWait := flag.Duration("max-wait", 2*time.Minute, "fail when a tenant's own data is not queryable within this time")
+	interval := flag.Duration("interval", 2*time.Second, "query poll interval")
+	out := flag.String("out", "", "write the result as a textfile for the node_exporter textfile collector")
+	jsonOut := flag.Bool("json", false, "print the gate JSON instead of the summary")
+	flag.Parse()
+
+	ids := splitList(*tenants)
+	switch {
+	case len(ids) < 2:
+		fail(fmt.Errorf("-tenants needs at least two tenants"))
+	case *remoteWrite == "" && *otlp == "":
+		fail(fmt.Errorf("nothing to check: set -remote-write and/or -otlp"))
+	case *interval <= 0 || *maxWait <= 0:
+		fail(fmt.Errorf("-interval and -max-wait must be positive"))
+	}
+	seen := map[string]bool{}
+	for _, t := range ids {
+		if seen[t] {
+			fail(fmt.Errorf("duplicate tenant %s", t))
+		}
+		seen[t] = true
+	}
+	sc, err := newScope(*scopeMode, *scopeHeaderName, *tenantLabel)
+	if err != nil {
+		fail(err)
+	}
+
+	rep := Report{ProbeID: newID(8), Scope: sc.String(), Tenants: ids, OK: true}
+	var verdicts []resultenv.Verdict
+	var probes []prober
+	if *remoteWrite != "" {
+		probes = append(probes, &metricsProbe{writeURL: *remoteWrite, queryURL: strings.TrimRight(*prom, "/"), scope: sc})
+	}
+	if *otlp != "" {
+		probes = append(probes, &tracesProbe{writeURL: *otlp, queryURL: strings.TrimRight(*tempo, "/"), scope: sc})
+	}
+	for _, p := range probes {
+		rep.Checks = append(rep.Checks, run(p, rep.ProbeID, ids, *maxWait, *interval)...)
+	}
+	for _, c := range rep.Checks {
+		rep.Leaks += len(c.Leaked)
+		rep.OK = rep.OK && c.State == StatePass
+		verdicts = append(verdicts, c.verdict())
+	}
+	rep.Verdict = resultenv.Worst(verdicts...)
+
+	if *out != "" {
+		if err := output.WriteFileAtomicFunc(*out, func(w io.Writer) error { return writeTextfile(w, rep) }); err != nil {
+			fail(err)
+		}
+	}
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+	} else {
+		for _, c := range rep.Checks {
+			fmt.Printf("%-4s %-7s as %-20s visible=%v", strings.ToUpper(c.State), c.Signal, c.Tenant, c.Visible)
+			if len(c.Leaked) > 0 {
+				fmt.Printf(" LEAKED %s", strings.Join(c.Leaked, ","))
+			}
+			if c.Error != "" {
+				fmt.Printf(" (%s)", c.Error)
+			}
+			fmt.Println()
+		}
+		fmt.Printf("TENANT_ISOLATION_OK: %v\n", rep.OK)
+	}
+	if !rep.OK {
+		os.Exit(1)
+	}
+}
+
+// writeTextfile은 알람 규칙이 읽는 gauge를 쓴다(prometheus/rules/tenant_isolation.alerts.yml)
+func writeTextfile(w io.Writer, rep Report) error {
+	ok := 0
+	if rep.OK {
+		ok = 1
+	}
+	fmt.Fprintf(w, "# HELP trace_bench_tenant_isolation_ok whether the last tenant isolation gate passed\n# TYPE trace_bench_tenant_isolation_ok gauge\n")
+	fmt.Fprintf(w, "trace_bench_tenant_isolation_ok{scope=%q} %d\n", rep.Scope, ok)
+	fmt.Fprintf(w, "# HELP trace_bench_tenant_isolation_leaks cross-tenant results returned by tenant-scoped queries\n# TYPE trace_bench_tenant_isolation_leaks gauge\n")
+	leaks := map[string]int{}
+	for _, c := range rep.Checks {
+		leaks[c.Signal] += len(c.Leaked)
+	}
+	for _, s := range []string{SignalMetrics, SignalTraces} {
+		if n, ok := leaks[s]; ok {
+			fmt.Fprintf(w, "trace_bench_tenant_isolation_leaks{scope=%q,signal=%q} %d\n", rep.Scope, s, n)
+		}
+	}
+	fmt.Fprintf(w, "# HELP trace_bench_tenant_isolation_timestamp_seconds time of the last gate run\n# TYPE trace_bench_tenant_isolation_timestamp_seconds gauge\n")
+	_, err := fmt.Fprintf(w, "trace_bench_tenant_isolation_timestamp_seconds{scope=%q} %d\n", rep.Scope, time.Now().Unix())
+	return err
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func newID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/duri/trace_bench/internal/promapi"
+	"github.com/duri/trace_bench/output"
+)
+
+// MarkerMetric is the metric marker written per tenant; the gate run is
+// its probe_id label.
+const MarkerMetric = "trace_bench_tenant_isolation_marker"
+
+// metricsProbe는 remote-write로 쓰고 Prometheus 질의 API로 조회한다
+type metricsProbe struct {
+	writeURL, queryURL string
+	scope              scope
+}
+
+func (m *metricsProbe) signal() string { return SignalMetrics }
+
+func (m *metricsProbe) write(ctx context.Context, probeID string, tenants []string) error {
+	now := time.Now()
+	for _, t := range tenants {
+		s := output.RemoteWriteSample{
+			Name:   MarkerMetric,
+			Labels: map[string]string{"probe_id": probeID, m.scope.label: t},
+			Value:  1,
+			Time:   now,
+		}
+		if err := output.RemoteWriteSamples(ctx, m.writeURL, m.scope.write(t), []output.RemoteWriteSample{s}); err != nil {
+			return fmt.Errorf("tenant %s: %w", t, err)
+		}
+	}
+	return nil
+}
+
+// observe는 (1) probe의 marker 전체와 (2) 다른 tenant를 명시한 selector를
+// tenant로 한정해 질의한다. (2)는 matcher를 덮어쓰지 않고 덧붙이기만 하는
+// 프록시의 누출을 잡는다
+func (m *metricsProbe) observe(ctx context.Context, probeID, tenant string, tenants []string) ([]string, int, error) {
+	h, p := m.scope.query(tenant)
+	c := promapi.New(m.queryURL)
+	c.Header, c.Params = h, p
+	exprs := []string{fmt.Sprintf("%s{probe_id=%q}", MarkerMetric, probeID)}
+	for _, t := range tenants {
+		if t != tenant {
+			exprs = append(exprs, fmt.Sprintf("%s{probe_id=%q,%s=%q}", MarkerMetric, probeID, m.scope.label, t))
+		}
+	}
+	var seen []string
+	var n int
+	for _, e := range exprs {
+		vs, err := c.Vector(ctx, e, time.Time{})
+		n++
+		if err != nil {
+			return seen, n, err
+		}
+		for _, v := range vs {
+			if t, ok := v.Labels[m.scope.label]; ok {
+				seen = append(seen, t)
+			}
+		}
+	}
+	return seen, n, nil
+}
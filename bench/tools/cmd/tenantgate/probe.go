@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Scope modes.
+const (
+	scopeHeader = "header"
+	scopeLabel  = "label"
+)
+
+// scope는 요청을 한 tenant로 한정하는 방법
+type scope struct {
+	mode, header, label string
+}
+
+func newScope(mode, header, label string) (scope, error) {
+	switch mode {
+	case scopeHeader, scopeLabel:
+	default:
+		return scope{}, fmt.Errorf("invalid -scope: %s", mode)
+	}
+	if label == "" || (mode == scopeHeader && header == "") {
+		return scope{}, fmt.Errorf("-scope %s needs -tenant-label and -scope-header", mode)
+	}
+	return scope{mode: mode, header: header, label: label}, nil
+}
+
+func (s scope) String() string {
+	if s.mode == scopeHeader {
+		return s.mode + ":" + s.header
+	}
+	return s.mode + ":" + s.label
+}
+
+// query는 질의 요청을 tenant로 한정하는 header/parameter
+func (s scope) query(tenant string) (http.Header, url.Values) {
+	if s.mode == scopeHeader {
+		return s.write(tenant), nil
+	}
+	return nil, url.Values{s.label: {tenant}}
+}
+
+// write는 쓰기 요청의 header(label 모드는 데이터의 레이블로만 구분)
+func (s scope) write(tenant string) http.Header {
+	if s.mode != scopeHeader {
+		return nil
+	}
+	h := http.Header{}
+	h.Set(s.header, tenant)
+	return h
+}
+
+// prober는 신호(metrics/traces) 하나에 대해 marker를 쓰고 tenant로 한정해 조회한다
+type prober interface {
+	signal() string
+	write(ctx context.Context, probeID string, tenants []string) error
+	// observe는 tenant로 한정된 질의들이 돌려준 marker의 tenant 목록
+	observe(ctx context.Context, probeID, tenant string, tenants []string) (seen []string, queries int, err error)
+}
+
+// run은 marker를 쓰고 모든 tenant가 자기 데이터를 볼 때까지(또는 maxWait)
+// interval마다 조회한다. 누출은 어느 조회에서든 한 번이라도 보이면 실패
+func run(p prober, probeID string, tenants []string, maxWait, interval time.Duration) []Check {
+	checks := make([]Check, len(tenants))
+	for i, t := range tenants {
+		checks[i] = Check{Signal: p.signal(), Tenant: t}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), maxWait+30*time.Second)
+	defer cancel()
+	if err := p.write(ctx, probeID, tenants); err != nil {
+		for i := range checks {
+			checks[i].State, checks[i].Error = StateFail, err.Error()
+		}
+		return checks
+	}
+	deadline := time.Now().Add(maxWait)
+	for {
+		pending := false
+		for i := range checks {
+			c := &checks[i]
+			qctx, qcancel := context.WithTimeout(ctx, interval+10*time.Second)
+			seen, n, err := p.observe(qctx, probeID, c.Tenant, tenants)
+			qcancel()
+			c.Queries += n
+			c.Error = ""
+			if err != nil {
+				c.Error = err.Error()
+			}
+			for _, t := range seen {
+				if t == c.Tenant {
+					c.Visible = true
+				} else if !slices.Contains(c.Leaked, t) {
+					c.Leaked = append(c.Leaked, t)
+				}
+			}
+			pending = pending || !c.Visible
+		}
+		if !pending || time.Now().Add(interval).After(deadline) {
+			break
+		}
+		time.Sleep(interval)
+	}
+	for i := range checks {
+		c := &checks[i]
+		sort.Strings(c.Leaked)
+		c.State = StatePass
+		switch {
+		case len(c.Leaked) > 0:
+			c.State = StateFail
+		case !c.Visible:
+			c.State = StateFail
+			if c.Error == "" {
+				c.Error = fmt.Sprintf("own marker not queryable within %s", maxWait)
+			}
+		}
+	}
+	return checks
+}
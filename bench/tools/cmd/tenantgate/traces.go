@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tracesProbe는 tenant마다 span 하나를 OTLP/HTTP JSON으로 쓰고 Tempo 질의
+// API(trace ID 조회, 태그 검색)로 조회한다
+type tracesProbe struct {
+	writeURL, queryURL string
+	scope              scope
+	traceIDs           map[string]string // tenant → trace ID(hex)
+}
+
+func (p *tracesProbe) signal() string { return SignalTraces }
+
+func (p *tracesProbe) write(ctx context.Context, probeID string, tenants []string) error {
+	p.traceIDs = map[string]string{}
+	now := time.Now()
+	for _, t := range tenants {
+		id := newID(16)
+		p.traceIDs[t] = id
+		body, err := json.Marshal(markerSpan(id, probeID, p.scope.label, t, now))
+		if err != nil {
+			return err
+		}
+		if _, _, err := send(ctx, http.MethodPost, p.writeURL, p.scope.write(t), "application/json", body); err != nil {
+			return fmt.Errorf("tenant %s: %w", t, err)
+		}
+	}
+	return nil
+}
+
+// observe는 tenant로 한정해 모든 tenant의 trace ID를 조회하고(자기 것만
+// 찾아져야 한다) probe_id 태그로 검색한다
+func (p *tracesProbe) observe(ctx context.Context, probeID, tenant string, tenants []string) ([]string, int, error) {
+	h, q := p.scope.query(tenant)
+	var seen []string
+	var n int
+	for _, t := range tenants {
+		code, _, err := send(ctx, http.MethodGet, p.queryURL+"/api/traces/"+p.traceIDs[t]+paramString(q), h, "", nil)
+		n++
+		switch {
+		case code == http.StatusNotFound:
+		case err != nil:
+			return seen, n, err
+		default:
+			seen = append(seen, t)
+		}
+	}
+	v := url.Values{"tags": {"probe_id=" + strconv.Quote(probeID)}, "limit": {"100"}}
+	for k, vs := range q {
+		v[k] = vs
+	}
+	_, body, err := send(ctx, http.MethodGet, p.queryURL+"/api/search?"+v.Encode(), h, "", nil)
+	n++
+	if err != nil {
+		return seen, n, err
+	}
+	var resp struct {
+		Traces []struct {
+			TraceID string `json:"traceID"`
+		} `json:"traces"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return seen, n, fmt.Errorf("decode search response: %w", err)
+	}
+	for _, tr := range resp.Traces {
+		for t, id := range p.traceIDs {
+			// Tempo는 검색 결과의 trace ID에서 앞자리 0을 뺀다
+			if strings.TrimLeft(tr.TraceID, "0") == strings.TrimLeft(id, "0") {
+				seen = append(seen, t)
+			}
+		}
+	}
+	return seen, n, nil
+}
+
+// markerSpan은 OTLP JSON(ExportTraceServiceRequest) span 하나. tenant와
+// probe_id는 resource 속성
+func markerSpan(traceID, probeID, label, tenant string, now time.Time) any {
+	kv := func(k, v string) map[string]any {
+		return map[string]any{"key": k, "value": map[string]string{"stringValue": v}}
+	}
+	return map[string]any{"resourceSpans": []any{map[string]any{
+		"resource": map[string]any{"attributes": []any{
+			kv("service.name", "trace_bench_tenantgate"), kv(label, tenant), kv("probe_id", probeID),
+		}},
+		"scopeSpans": []any{map[string]any{
+			"scope": map[string]string{"name": "trace_bench"},
+			"spans": []any{map[string]any{
+				"traceId":           traceID,
+				"spanId":            newID(8),
+				"name":              "tenant-isolation-probe",
+				"kind":              1,
+				"startTimeUnixNano": strconv.FormatInt(now.Add(-time.Millisecond).UnixNano(), 10),
+				"endTimeUnixNano":   strconv.FormatInt(now.UnixNano(), 10),
+			}},
+		}},
+	}}}
+}
+
+func paramString(q url.Values) string {
+	if len(q) == 0 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
+
+// send는 요청 하나를 보내고 상태 코드와 본문을 돌려준다(2xx가 아니면 오류)
+func send(ctx context.Context, method, u string, header http.Header, contentType string, body []byte) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, b, fmt.Errorf("%s %s: %s %s", method, u, resp.Status, strings.TrimSpace(string(b[:min(len(b), 512)])))
+	}
+	return resp.StatusCode, b, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"debug/buildinfo"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Check is the verdict for one pinned tool.
+type Check struct {
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`
+	SHA256   string   `json:"sha256,omitempty"`
+	Version  string   `json:"version,omitempty"`
+	Revision string   `json:"revision,omitempty"`
+	Go       string   `json:"go,omitempty"`
+	Problems []string `json:"problems,omitempty"`
+	OK       bool     `json:"ok"`
+}
+
+// Identity is what a binary reports about itself.
+type Identity struct {
+	SHA256   string
+	Version  string // -version output (or Go build info main module version)
+	Revision string
+	Go       string
+}
+
+// resolve는 pin의 경로를 정한다(상대 경로는 dir 기준, 비면 $PATH)
+func resolve(p Pin, dir string) (string, error) {
+	switch {
+	case p.Path == "":
+		return exec.LookPath(p.Name)
+	case filepath.IsAbs(p.Path):
+		return p.Path, nil
+	}
+	return filepath.Join(dir, p.Path), nil
+}
+
+// identify는 파일 해시와 Go build info, 필요하면 versionFlag 출력을 모은다
+func identify(path, versionFlag string, timeout time.Duration) (Identity, error) {
+	var id Identity
+	f, err := os.Open(path)
+	if err != nil {
+		return id, err
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	f.Close()
+	if err != nil {
+		return id, err
+	}
+	id.SHA256 = hex.EncodeToString(h.Sum(nil))
+	// Go 바이너리가 아니면 build info가 없다(셸 스크립트 등)
+	if bi, err := buildinfo.ReadFile(path); err == nil {
+		id.Go, id.Version = bi.GoVersion, bi.Main.Version
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.revision" {
+				id.Revision = s.Value
+			}
+		}
+	}
+	if versionFlag != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, path, strings.Fields(versionFlag)...).CombinedOutput()
+		if err != nil {
+			return id, fmt.Errorf("%s %s: %v", filepath.Base(path), versionFlag, err)
+		}
+		id.Version = strings.TrimSpace(string(out))
+	}
+	return id, nil
+}
+
+func check(p Pin, dir string, timeout time.Duration) Check {
+	c := Check{Name: p.Name}
+	path, err := resolve(p, dir)
+	if err != nil {
+		c.Problems = append(c.Problems, "not installed: "+err.Error())
+		return c
+	}
+	c.Path = path
+	id, err := identify(path, p.VersionFlag, timeout)
+	c.SHA256, c.Version, c.Revision, c.Go = id.SHA256, id.Version, id.Revision, id.Go
+	if err != nil {
+		c.Problems = append(c.Problems, err.Error())
+		return c
+	}
+	if p.SHA256 != "" && !strings.EqualFold(p.SHA256, id.SHA256) {
+		c.Problems = append(c.Problems, fmt.Sprintf("sha256 %s, pinned %s", short(id.SHA256), short(p.SHA256)))
+	}
+	// -version 출력은 "trace_bench v0.1.0"처럼 이름을 포함하므로 부분 일치
+	if p.Version != "" && !strings.Contains(id.Version, p.Version) {
+		c.Problems = append(c.Problems, fmt.Sprintf("version %q, pinned %s", id.Version, p.Version))
+	}
+	if p.Revision != "" && !strings.HasPrefix(id.Revision, p.Revision) {
+		c.Problems = append(c.Problems, fmt.Sprintf("revision %q, pinned %s", short(id.Revision), p.Revision))
+	}
+	c.OK = len(c.Problems) == 0
+	return c
+}
+
+func short(s string) string {
+	if len(s) > 12 {
+		return s[:12]
+	}
+	return s
+}
@@ -0,0 +1,208 @@
+// Command toolcheck verifies that the proof tool binaries on a host are the
+// ones pinned in a manifest (SHA256, version, VCS revision), since gate
+// evidence is only comparable when the proving host and CI run the same
+// tools. CI pins the binaries it built; hosts verify before proving.
+//
+//	toolcheck pin -dir bin -out tool_pins.yaml          # in CI, after make tools
+//	toolcheck verify -manifest tool_pins.yaml -dir /opt/duri/bin
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duri/trace_bench/output"
+	"github.com/duri/trace_bench/pkg/buildinfo"
+	"github.com/duri/trace_bench/pkg/resultenv"
+)
+
+// Report is the gate JSON of toolcheck verify.
+type Report struct {
+	Manifest string   `json:"manifest"`
+	Dir      string   `json:"dir"`
+	Tools    []Check  `json:"tools"`
+	Unpinned []string `json:"unpinned,omitempty"` // executables in -dir missing from the manifest
+	OK       bool     `json:"ok"`
+	// Verdict is pass_with_warnings when only unpinned executables were
+	// found without -strict (pkg/resultenv).
+	Verdict resultenv.Verdict `json:"verdict"`
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: toolcheck <command> [flags]\n\ncommands:\n  verify   check installed tool binaries against the pinned manifest\n  pin      write a manifest pinning the binaries in a directory")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "-version", "--version":
+		buildinfo.Print(os.Stdout, "toolcheck", len(os.Args) > 2 && os.Args[2] == "-json")
+	case "verify":
+		verify(os.Args[2:])
+	case "pin":
+		pin(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func verify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	path := fs.String("manifest", "tool_pins.yaml", "pinned tool manifest")
+	dir := fs.String("dir", "bin", "directory relative tool paths are resolved against")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of one version_flag invocation")
+	strict := fs.Bool("strict", false, "also fail on executables in -dir that the manifest does not pin")
+	jsonOut := fs.Bool("json", false, "print the gate JSON instead of the summary")
+	envelope := fs.String("envelope", "", "also write a pkg/resultenv envelope (shared proof-tool format) to this path")
+	fs.Parse(args)
+	started := time.Now().UTC()
+
+	m, err := loadManifest(*path)
+	if err != nil {
+		fail(err)
+	}
+	rep := Report{Manifest: *path, Dir: *dir, OK: true}
+	pinned := map[string]bool{}
+	for _, p := range m.Tools {
+		c := check(p, *dir, *timeout)
+		rep.OK = rep.OK && c.OK
+		rep.Tools = append(rep.Tools, c)
+		pinned[filepath.Base(c.Path)] = true
+	}
+	if bins, err := executables(*dir); err == nil {
+		for _, b := range bins {
+			if !pinned[b] {
+				rep.Unpinned = append(rep.Unpinned, b)
+			}
+		}
+	}
+	if *strict && len(rep.Unpinned) > 0 {
+		rep.OK = false
+	}
+	// 이유: 어긋난 도구는 mismatch:<name>, -strict가 아니면 unpinned:<name>은 경고
+	var failures, warnings []string
+	for _, c := range rep.Tools {
+		if !c.OK {
+			failures = append(failures, "mismatch:"+c.Name)
+		}
+	}
+	mismatched := len(failures)
+	for _, b := range rep.Unpinned {
+		if *strict {
+			failures = append(failures, "unpinned:"+b)
+		} else {
+			warnings = append(warnings, "unpinned:"+b)
+		}
+	}
+	rep.Verdict = resultenv.Judge(failures, warnings)
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+	} else {
+		for _, c := range rep.Tools {
+			if c.OK {
+				fmt.Printf("[PASS] %s %s (%s)\n", c.Name, c.Version, short(c.SHA256))
+			} else {
+				fmt.Printf("[FAIL] %s: %s\n", c.Name, strings.Join(c.Problems, "; "))
+			}
+		}
+		for _, b := range rep.Unpinned {
+			state := "WARN"
+			if *strict {
+				state = "FAIL"
+			}
+			fmt.Printf("[%s] %s: not pinned in %s\n", state, filepath.Join(*dir, b), *path)
+		}
+		fmt.Printf("TOOLCHECK_OK: %v\n", rep.OK)
+	}
+	if *envelope != "" {
+		e := resultenv.New("toolcheck").Flags(fs)
+		e.StartedAt = started
+		e.Params["command"] = fs.Name()
+		e.Metrics = map[string]float64{"tools": float64(len(rep.Tools)), "mismatched": float64(mismatched), "unpinned": float64(len(rep.Unpinned))}
+		e.Finish(rep.Verdict, rep, append(failures, warnings...)...)
+		if err := output.WriteFileAtomicFunc(*envelope, func(w io.Writer) error { return resultenv.Encode(w, e) }); err != nil {
+			fail(err)
+		}
+	}
+	if !rep.OK {
+		os.Exit(1)
+	}
+}
+
+func pin(args []string) {
+	fs := flag.NewFlagSet("pin", flag.ExitOnError)
+	dir := fs.String("dir", "bin", "directory of the binaries to pin")
+	versionFlag := fs.String("version-flag", "-version", "flag that prints a tool's version (tools that reject it are pinned by build info only)")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of one version invocation")
+	out := fs.String("out", "", "write the manifest here instead of stdout")
+	fs.Parse(args)
+
+	bins, err := executables(*dir)
+	if err != nil {
+		fail(err)
+	}
+	if len(bins) == 0 {
+		fail(fmt.Errorf("%s: no executables to pin", *dir))
+	}
+	m := &Manifest{Version: 1}
+	for _, b := range bins {
+		p := Pin{Name: b, Path: b, VersionFlag: *versionFlag}
+		id, err := identify(filepath.Join(*dir, b), p.VersionFlag, *timeout)
+		if err != nil {
+			// 버전 플래그가 없는 도구: 해시/build info만 고정
+			p.VersionFlag = ""
+			if id, err = identify(filepath.Join(*dir, b), "", *timeout); err != nil {
+				fail(err)
+			}
+		}
+		p.SHA256, p.Version, p.Revision = id.SHA256, id.Version, id.Revision
+		if p.Version == "(devel)" {
+			p.Version = ""
+		}
+		m.Tools = append(m.Tools, p)
+	}
+	write := func(w io.Writer) error { return writeManifest(w, *dir, m) }
+	if *out == "" {
+		write(os.Stdout)
+		return
+	}
+	if err := output.WriteFileAtomicFunc(*out, write); err != nil {
+		fail(err)
+	}
+}
+
+// executables는 dir 바로 아래의 실행 파일 이름(정렬)
+func executables(dir string) ([]string, error) {
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, e := range ents {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		out = append(out, e.Name())
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "[ERR]", err)
+	os.Exit(1)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/duri/trace_bench/internal/yamlite"
+)
+
+// Manifest pins the proof tool binaries a host may run.
+//
+//	version: 1
+//	tools:
+//	  - name: trace_bench
+//	    path: trace_bench          # relative to -dir; empty = $PATH lookup of name
+//	    sha256: 3f1c...
+//	    version: v0.1.0            # matched against '<tool> -version', else Go build info
+//	    version_flag: -version
+//	    revision: 5d14fe1          # vcs.revision prefix from Go build info
+type Manifest struct {
+	Version int   `json:"version"`
+	Tools   []Pin `json:"tools"`
+}
+
+// Pin is the expected identity of one binary.
+type Pin struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	SHA256      string `json:"sha256"`
+	Version     string `json:"version"`
+	VersionFlag string `json:"version_flag"`
+	Revision    string `json:"revision"`
+}
+
+func loadManifest(path string) (*Manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := yamlite.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if m.Version != 1 {
+		return nil, fmt.Errorf("%s: unsupported version %d", path, m.Version)
+	}
+	seen := map[string]bool{}
+	for i, p := range m.Tools {
+		switch {
+		case p.Name == "":
+			return nil, fmt.Errorf("%s: tools[%d]: missing name", path, i)
+		case seen[p.Name]:
+			return nil, fmt.Errorf("%s: duplicate tool %q", path, p.Name)
+		case p.SHA256 == "" && p.Version == "":
+			return nil, fmt.Errorf("%s: tool %q pins neither sha256 nor version", path, p.Name)
+		}
+		seen[p.Name] = true
+	}
+	return &m, nil
+}
+
+// writeManifest는 yamlite가 다시 읽을 수 있는 형식으로 쓴다
+func writeManifest(w io.Writer, src string, m *Manifest) error {
+	sort.Slice(m.Tools, func(i, j int) bool { return m.Tools[i].Name < m.Tools[j].Name })
+	fmt.Fprintf(w, "# Generated by toolcheck pin from %s. Commit it with the CI build that produced the binaries.\nversion: 1\ntools:\n", src)
+	for _, p := range m.Tools {
+		fmt.Fprintf(w, "  - name: %s\n", p.Name)
+		for _, kv := range [][2]string{{"path", p.Path}, {"sha256", p.SHA256}, {"version", p.Version}, {"version_flag", p.VersionFlag}, {"revision", p.Revision}} {
+			if kv[1] != "" {
+				fmt.Fprintf(w, "    %s: %q\n", kv[0], kv[1])
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package validate_test
+
+import (
+	"context"
+	"flag"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/duri/trace_bench/cost"
+	"github.com/duri/trace_bench/engine"
+	"github.com/duri/trace_bench/internal/yamlite"
+	"github.com/duri/trace_bench/profile"
+	"github.com/duri/trace_bench/slo"
+	"github.com/duri/trace_bench/validate"
+)
+
+// Pathological inputs seeded into every string target.
+var hostile = []string{"", "NaN", "nan", "-NaN", "Inf", "+Inf", "-Inf", "1e309", "-0", "0x1p-1074", "1_0",
+	"\x00", "a\r\nX-Injected: 1", "\xff\xfe", "‮", strings.Repeat("a", validate.MaxLine+1), "json\x00gzip", " json", "JSON"}
+
+// addFiles seeds f with the repo's own config files matching pattern.
+func addFiles(f *testing.F, pattern string) {
+	paths, _ := filepath.Glob(filepath.Join("..", pattern))
+	for _, p := range paths {
+		if b, err := os.ReadFile(p); err == nil {
+			f.Add(b)
+		}
+	}
+}
+
+func FuzzLine(f *testing.F) {
+	for _, s := range hostile {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if validate.Line("v", s) != nil {
+			return
+		}
+		if len(s) > validate.MaxLine || !utf8.ValidString(s) {
+			t.Fatalf("accepted %q", s)
+		}
+		for _, r := range s {
+			if r != '\t' && unicode.IsControl(r) {
+				t.Fatalf("accepted control character %U in %q", r, s)
+			}
+		}
+	})
+}
+
+// FuzzFlagStrings parses the estimator's flags the way main does and runs
+// the model for every accepted combination: its result must be finite.
+func FuzzFlagStrings(f *testing.F) {
+	for _, s := range hostile {
+		f.Add(s, "json", "none", "run-1")
+		f.Add("0.5", s, "gzip", s)
+	}
+	f.Add("1", "protobuf", "zstd", "")
+	f.Fuzz(func(t *testing.T, sampling, ser, comp, runID string) {
+		fs := flag.NewFlagSet("fuzz", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		var cfg engine.Config
+		fs.Float64Var(&cfg.Sampling, "sampling", 1, "")
+		fs.StringVar(&cfg.Serialization, "serialization", "json", "")
+		fs.StringVar(&cfg.Compression, "compression", "none", "")
+		fs.StringVar(&cfg.RunID, "run-id", "", "")
+		args := []string{"-sampling=" + sampling, "-serialization=" + ser, "-compression=" + comp, "-run-id=" + runID}
+		if fs.Parse(args) != nil || cfg.Validate() != nil {
+			return
+		}
+		if math.IsNaN(cfg.Sampling) || cfg.Sampling < 0 || cfg.Sampling > 1 {
+			t.Fatalf("accepted sampling %v from %q", cfg.Sampling, sampling)
+		}
+		r, err := engine.New().Run(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("valid config %+v: %v", cfg, err)
+		}
+		for name, v := range r.Metrics() {
+			if math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+				t.Fatalf("%s = %v for sampling=%q ser=%q comp=%q", name, v, sampling, ser, comp)
+			}
+		}
+	})
+}
+
+func FuzzTraffic(f *testing.F) {
+	for _, s := range append(hostile, "50M/day", "2k/s", "1e308T/s", "NaN/h", "10 spans/h") {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		v, err := cost.ParseTraffic(s)
+		if err == nil && (math.IsNaN(v) || math.IsInf(v, 0) || v <= 0) {
+			t.Fatalf("ParseTraffic(%q) = %v", s, v)
+		}
+	})
+}
+
+// FuzzConfigFiles decodes arbitrary YAML as each config file type, as Load
+// does, in both lenient and strict mode. Whatever validates must carry
+// finite thresholds and prices.
+func FuzzConfigFiles(f *testing.F) {
+	addFiles(f, "*.yaml")
+	addFiles(f, "cmd/trace_bench/templates/*/*.yaml")
+	f.Add([]byte("version: 1\nslos:\n  - name: x\n    objective: .nan\n    window: 1h\n    indicator:\n      bench_metric: p95_ms\n    threshold: .inf\n"))
+	f.Add([]byte("version: 1\nenvironments:\n  dev:\n    slo:\n      p95: 1e400\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, strict := range []bool{false, true} {
+			yamlite.Strict = strict
+			var sf slo.File
+			if yamlite.Unmarshal(data, &sf) == nil && sf.Validate() == nil {
+				for _, s := range sf.SLOs {
+					if s.Objective <= 0 || s.Objective >= 1 || (s.Threshold != nil && validate.Finite("threshold", *s.Threshold) != nil) {
+						t.Fatalf("accepted slo %+v", s)
+					}
+				}
+				var pf profile.File
+				if yamlite.Unmarshal(data, &pf) == nil {
+					for env, p := range pf.Environments {
+						slos, err := p.ApplySLO(sf.SLOs)
+						for _, s := range slos {
+							if err == nil && s.Threshold != nil && validate.Finite("threshold", *s.Threshold) != nil {
+								t.Fatalf("env %s: accepted threshold override %v", env, *s.Threshold)
+							}
+						}
+					}
+				}
+			}
+			var m cost.Model
+			if yamlite.Unmarshal(data, &m) == nil && m.Validate() == nil {
+				for _, v := range []float64{m.StorageGBMonth, m.RetentionDays, m.EgressGB, m.CoreHour} {
+					if validate.NonNegative("price", v) != nil {
+						t.Fatalf("accepted cost model %+v", m)
+					}
+				}
+			}
+		}
+		yamlite.Strict = false
+	})
+}
+
+// FuzzScenarioYAML parses arbitrary scenario (schedule) files and checks
+// every bench the way the schedule loader does.
+func FuzzScenarioYAML(f *testing.F) {
+	addFiles(f, "nightly.yaml")
+	addFiles(f, "cmd/trace_bench/templates/*/scenario.yaml")
+	f.Add([]byte("version: 1\nbenches:\n  - name: ../etc\n    args: [\"-spans\", \"1\\u0000\"]\n"))
+	f.Add([]byte("benches:\n  - name: \"a\\nb\"\n    args: [spans]\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// 로더가 읽는 필드만(나머지 키는 무시)
+		var scenario struct {
+			Benches []struct {
+				Name string   `json:"name"`
+				Args []string `json:"args"`
+			} `json:"benches"`
+		}
+		if yamlite.Unmarshal(data, &scenario) != nil {
+			return
+		}
+		for _, b := range scenario.Benches {
+			if validate.BenchName(b.Name) == nil && (filepath.Base(b.Name) != b.Name || strings.ContainsAny(b.Name, "/\\\n\x00")) {
+				t.Fatalf("accepted bench name %q", b.Name)
+			}
+			if validate.Args(b.Args) == nil {
+				for _, a := range b.Args {
+					if strings.ContainsAny(a, "\x00\r\n") {
+						t.Fatalf("accepted args %q", b.Args)
+					}
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,109 @@
+// Package validate holds the input checks shared by the flag parsers, the
+// config loaders and engine.Config.Validate, so every entry point rejects
+// the same malformed values before they reach the estimator or the result
+// encoders:
+//
+//   - numbers must be finite (flag.Float64 and strconv accept "NaN" and
+//     "Inf", and NaN slips through plain range comparisons);
+//   - single-line strings (names, labels, run ids, header values, bench
+//     args) must be valid UTF-8 without control characters and bounded in
+//     length, since they end up in HTTP headers, exposition formats, file
+//     names and exec argv.
+//
+// The package depends on the standard library only; it is imported by the
+// packages whose values it checks.
+package validate
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxLine is the longest accepted single-line value in bytes.
+const MaxLine = 4096
+
+// Finite rejects NaN and ±Inf.
+func Finite(name string, v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Errorf("invalid %s: %v (expected a finite number)", name, v)
+	}
+	return nil
+}
+
+// Range checks lo <= v <= hi for a finite v.
+func Range(name string, v, lo, hi float64) error {
+	if err := Finite(name, v); err != nil {
+		return err
+	}
+	if v < lo || v > hi {
+		return fmt.Errorf("invalid %s: %v (expected [%v,%v])", name, v, lo, hi)
+	}
+	return nil
+}
+
+// Fraction checks a finite v in [0,1] (sampling, rates).
+func Fraction(name string, v float64) error { return Range(name, v, 0, 1) }
+
+// NonNegative checks a finite v >= 0.
+func NonNegative(name string, v float64) error {
+	if err := Finite(name, v); err != nil {
+		return err
+	}
+	if v < 0 {
+		return fmt.Errorf("invalid %s: %v (expected >= 0)", name, v)
+	}
+	return nil
+}
+
+// Line checks a single-line value: valid UTF-8, at most MaxLine bytes, and
+// no control characters other than tab.
+func Line(name, s string) error {
+	if len(s) > MaxLine {
+		return fmt.Errorf("invalid %s: %d bytes (expected at most %d)", name, len(s), MaxLine)
+	}
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("invalid %s: %q is not valid UTF-8", name, s)
+	}
+	if i := strings.IndexFunc(s, func(r rune) bool { return r != '\t' && unicode.IsControl(r) }); i >= 0 {
+		return fmt.Errorf("invalid %s: %q contains a control character at byte %d", name, s, i)
+	}
+	return nil
+}
+
+// OneOf checks that v is one of allowed, ignoring case.
+func OneOf(name, v string, allowed []string) error {
+	for _, a := range allowed {
+		if strings.EqualFold(v, a) {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid %s: %q (expected %s)", name, v, strings.Join(allowed, "|"))
+}
+
+// BenchName checks a bench name, which also names its results directory.
+func BenchName(s string) error {
+	if err := Line("bench name", s); err != nil {
+		return err
+	}
+	if s == "" || s == "." || s == ".." || strings.ContainsAny(s, `/\`) {
+		return fmt.Errorf("invalid bench name %q: must be set and contain no path separators", s)
+	}
+	return nil
+}
+
+// Args checks bench args passed to trace_bench as argv: every element a
+// Line, the first one a flag.
+func Args(args []string) error {
+	for i, a := range args {
+		if err := Line(fmt.Sprintf("arg %d", i+1), a); err != nil {
+			return err
+		}
+	}
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("invalid args: %q is not a flag (args are trace_bench flags, e.g. [-spans, \"20000\"])", args[0])
+	}
+	return nil
+}